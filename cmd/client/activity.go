@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes"
+
+	"workout-manager-service/pb"
+)
+
+var activityFormats = map[string]pb.ActivityFormat{
+	"fit": pb.ActivityFormat_ACTIVITY_FORMAT_FIT,
+	"tcx": pb.ActivityFormat_ACTIVITY_FORMAT_TCX,
+}
+
+// runUploadActivity uploads a FIT or TCX file for an athlete and prints the
+// workout it was recorded as, lap by lap.
+func runUploadActivity(ctx context.Context, c pb.WorkoutManagerClient, args []string) error {
+	fs := flag.NewFlagSet("upload-activity", flag.ExitOnError)
+	var (
+		athleteID  = fs.String("athlete", "", "ID of the athlete who recorded the activity")
+		movementID = fs.String("movement", "", "ID of the conditioning movement performed; matched by sport when empty")
+		title      = fs.String("title", "", "Title of the workout; the sports of the file when empty")
+		format     = fs.String("format", "", "File format, fit or tcx; guessed from the file extension when empty")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *athleteID == "" {
+		return errors.New("usage: upload-activity -athlete ID [flags] FILE")
+	}
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	f, ok := activityFormats[strings.ToLower(*format)]
+	if !ok {
+		return fmt.Errorf("unknown format %q; use -format fit or -format tcx", *format)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stream, err := c.UploadActivity(ctx)
+	if err != nil {
+		return err
+	}
+	err = sendChunks(file, func(chunk []byte, first bool) error {
+		msg := &pb.UploadActivityRequest{Chunk: chunk}
+		if first {
+			msg.Options = &pb.UploadActivityOptions{
+				AthleteId:  *athleteID,
+				Format:     f,
+				MovementId: *movementID,
+				Title:      *title,
+			}
+		}
+		return stream.Send(msg)
+	})
+	if err != nil {
+		return err
+	}
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+	if res.GetErr() != "" {
+		return errors.New(res.GetErr())
+	}
+	w := res.GetData()
+	fmt.Printf("workout %s\t%s\n", w.GetName(), w.GetTitle())
+	for _, c := range w.GetConditioning() {
+		d, _ := ptypes.Duration(c.GetDuration())
+		fmt.Printf("%s\t%s\t%.0f m\tavg %d bpm\tmax %d bpm\n", c.GetSport(), d, c.GetDistance(), c.GetAvgHeartRate(), c.GetMaxHeartRate())
+		for i, l := range c.GetLaps() {
+			d, _ := ptypes.Duration(l.GetDuration())
+			fmt.Printf("  lap %d\t%s\t%.0f m\tavg %d bpm\n", i+1, d, l.GetDistance(), l.GetAvgHeartRate())
+		}
+	}
+	return nil
+}
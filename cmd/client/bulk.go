@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"workout-manager-service/pb"
+)
+
+// importChunkSize is the most file data sent in a single stream message.
+const importChunkSize = 64 << 10
+
+var formats = map[string]pb.MovementFormat{
+	"csv":    pb.MovementFormat_MOVEMENT_FORMAT_CSV,
+	"ndjson": pb.MovementFormat_MOVEMENT_FORMAT_NDJSON,
+	"jsonl":  pb.MovementFormat_MOVEMENT_FORMAT_NDJSON,
+}
+
+var policies = map[string]pb.ConflictPolicy{
+	"fail":      pb.ConflictPolicy_CONFLICT_POLICY_FAIL,
+	"skip":      pb.ConflictPolicy_CONFLICT_POLICY_SKIP,
+	"overwrite": pb.ConflictPolicy_CONFLICT_POLICY_OVERWRITE,
+}
+
+// columnMapping collects repeated -map from=to flags.
+type columnMapping map[string]string
+
+func (m columnMapping) String() string {
+	pairs := make([]string, 0, len(m))
+	for from, to := range m {
+		pairs = append(pairs, from+"="+to)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (m columnMapping) Set(v string) error {
+	parts := strings.SplitN(v, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("expected from=to, got %q", v)
+	}
+	m[parts[0]] = parts[1]
+	return nil
+}
+
+// runImport uploads a movement file and prints the server's report. It fails
+// when the import was not written, unless it was a dry run.
+func runImport(ctx context.Context, c pb.WorkoutManagerClient, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	var (
+		format  = fs.String("format", "", "File format, csv or ndjson; guessed from the file extension when empty")
+		policy  = fs.String("policy", "fail", "What to do with rows matching existing movements: fail, skip or overwrite")
+		dryRun  = fs.Bool("dry-run", false, "Validate the file and report what would happen without writing anything")
+		mapping = columnMapping{}
+	)
+	fs.Var(mapping, "map", "Map a file header onto a movement column, as header=column; may be repeated")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: import [flags] FILE")
+	}
+	path := fs.Arg(0)
+	f, err := formatFor(*format, path)
+	if err != nil {
+		return err
+	}
+	p, ok := policies[*policy]
+	if !ok {
+		return fmt.Errorf("unknown conflict policy %q", *policy)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stream, err := c.ImportMovements(ctx)
+	if err != nil {
+		return err
+	}
+	err = sendChunks(file, func(chunk []byte, first bool) error {
+		msg := &pb.ImportMovementsRequest{Chunk: chunk}
+		if first {
+			msg.Options = &pb.ImportOptions{
+				Format:         f,
+				Columns:        mapping,
+				ConflictPolicy: p,
+				DryRun:         *dryRun,
+			}
+		}
+		return stream.Send(msg)
+	})
+	if err != nil {
+		return err
+	}
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+	if res.GetErr() != "" {
+		return errors.New(res.GetErr())
+	}
+	report := res.GetReport()
+	for _, row := range report.GetRows() {
+		line := fmt.Sprintf("row %d\t%s\t%s", row.GetRow(), row.GetAction(), row.GetName())
+		if row.GetErr() != "" {
+			line += "\t" + row.GetErr()
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("created %d, updated %d, skipped %d, rejected %d\n",
+		report.GetCreated(), report.GetUpdated(), report.GetSkipped(), report.GetRejected())
+	switch {
+	case report.GetDryRun():
+		fmt.Println("dry run: nothing was written")
+	case !report.GetCommitted():
+		return errors.New("nothing was written because some rows were rejected")
+	}
+	return nil
+}
+
+// runExport downloads the movement catalog to a file or standard output.
+func runExport(ctx context.Context, c pb.WorkoutManagerClient, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	var (
+		format      = fs.String("format", "", "File format, csv or ndjson; guessed from -o when empty and csv otherwise")
+		out         = fs.String("o", "", "File to write; standard output when empty")
+		category    = fs.String("category", "", "Only export movements in this category")
+		showDeleted = fs.Bool("show-deleted", false, "Include deleted movements")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	name := *out
+	if name == "" && *format == "" {
+		name = "stdout.csv"
+	}
+	f, err := formatFor(*format, name)
+	if err != nil {
+		return err
+	}
+	stream, err := c.ExportMovements(ctx, &pb.ExportMovementsRequest{
+		Format:       f,
+		CategoryName: *category,
+		ShowDeleted:  *showDeleted,
+	})
+	if err != nil {
+		return err
+	}
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(msg.GetChunk()); err != nil {
+			return err
+		}
+	}
+}
+
+// sendChunks reads r to the end and sends it in chunks of at most
+// importChunkSize, flagging the first. At least one chunk is sent, so that an
+// empty file still carries the import options.
+func sendChunks(r io.Reader, send func(chunk []byte, first bool) error) error {
+	buf := make([]byte, importChunkSize)
+	for sent := false; ; sent = true {
+		n, err := r.Read(buf)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if n == 0 && sent {
+			return nil
+		}
+		if err := send(buf[:n], !sent); err != nil {
+			return err
+		}
+	}
+}
+
+// formatFor resolves an explicit format or guesses one from a file name.
+func formatFor(format string, path string) (pb.MovementFormat, error) {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	f, ok := formats[strings.ToLower(format)]
+	if !ok {
+		return 0, fmt.Errorf("unknown format %q; use -format csv or -format ndjson", format)
+	}
+	return f, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"workout-manager-service/pb"
+)
+
+var historySources = map[string]pb.HistorySource{
+	"strong":   pb.HistorySource_HISTORY_SOURCE_STRONG,
+	"hevy":     pb.HistorySource_HISTORY_SOURCE_HEVY,
+	"fitnotes": pb.HistorySource_HISTORY_SOURCE_FITNOTES,
+}
+
+// runImportHistory uploads another app's workout export for an athlete and
+// prints the server's report, including suggestions for exercise names that
+// match no movement. It fails when the import was not written, unless it was
+// a dry run.
+func runImportHistory(ctx context.Context, c pb.WorkoutManagerClient, args []string) error {
+	fs := flag.NewFlagSet("import-history", flag.ExitOnError)
+	var (
+		athleteID      = fs.String("athlete", "", "ID of the athlete whose history is imported")
+		source         = fs.String("source", "", "App the export comes from: strong, hevy or fitnotes")
+		unit           = fs.String("unit", "kg", "Weight unit of exports that do not record one, kg or lb")
+		timeZone       = fs.String("tz", "", "IANA time zone of timestamps without one; UTC when empty")
+		skipUnresolved = fs.Bool("skip-unresolved", false, "Leave out sets of exercises that match no movement instead of failing")
+		dryRun         = fs.Bool("dry-run", false, "Report what would be imported without writing anything")
+		mapping        = columnMapping{}
+	)
+	fs.Var(mapping, "map", "Map an exercise name onto a movement ID, as name=id; may be repeated")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *athleteID == "" {
+		return errors.New("usage: import-history -athlete ID -source APP [flags] FILE")
+	}
+	src, ok := historySources[strings.ToLower(*source)]
+	if !ok {
+		return fmt.Errorf("unknown source %q; use strong, hevy or fitnotes", *source)
+	}
+	file, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stream, err := c.ImportWorkoutHistory(ctx)
+	if err != nil {
+		return err
+	}
+	err = sendChunks(file, func(chunk []byte, first bool) error {
+		msg := &pb.ImportWorkoutHistoryRequest{Chunk: chunk}
+		if first {
+			msg.Options = &pb.HistoryImportOptions{
+				AthleteId:      *athleteID,
+				Source:         src,
+				Unit:           *unit,
+				TimeZone:       *timeZone,
+				Mappings:       mapping,
+				SkipUnresolved: *skipUnresolved,
+				DryRun:         *dryRun,
+			}
+		}
+		return stream.Send(msg)
+	})
+	if err != nil {
+		return err
+	}
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+	if res.GetErr() != "" {
+		return errors.New(res.GetErr())
+	}
+	report := res.GetReport()
+	for _, u := range report.GetUnresolved() {
+		fmt.Printf("unresolved\t%s\t%d sets\n", u.GetName(), u.GetSets())
+		for _, s := range u.GetSuggestions() {
+			fmt.Printf("\tdid you mean %s (%s)? -map %q\n", s.GetMovementName(), s.GetMovementId(), u.GetName()+"="+s.GetMovementId())
+		}
+	}
+	fmt.Printf("workouts %d, sets %d, duplicates %d, ignored rows %d, skipped sets %d\n",
+		report.GetWorkouts(), report.GetSets(), report.GetDuplicates(), report.GetIgnoredRows(), report.GetSkippedSets())
+	switch {
+	case report.GetDryRun():
+		fmt.Println("dry run: nothing was written")
+	case report.GetCommitted():
+	case len(report.GetUnresolved()) > 0:
+		return errors.New("nothing was written because some exercises are unresolved; map them or pass -skip-unresolved")
+	default:
+		fmt.Println("nothing new to import")
+	}
+	return nil
+}
@@ -2,49 +2,85 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 
 	"workout-manager-service/pb"
 )
 
+// The client exercises the movement RPCs by default. The import and export
+// subcommands move the movement catalog to and from CSV or NDJSON files,
+// import-history brings an athlete's workouts over from another app,
+// upload-activity records a watch's FIT or TCX file as a workout and watch
+// follows changes to the catalog.
 func main() {
+	userID := flag.String("user", "", "ID of the user to make requests as")
+	requestID := flag.String("request-id", "", "Request ID that makes CreateMovement safe to retry")
+	addr := flag.String("addr", ":8070", "Address of the workout manager server")
+	flag.Parse()
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-user-id", *userID)
+
 	var conn *grpc.ClientConn
-	conn, err := grpc.Dial(":8070", grpc.WithInsecure())
+	conn, err := grpc.Dial(*addr, grpc.WithInsecure())
 	if err != nil {
 		log.Fatalf("did not connect: %s", err)
 	}
 	defer conn.Close()
 	c := pb.NewWorkoutManagerClient(conn)
 
+	switch flag.Arg(0) {
+	case "":
+		demo(ctx, c, *requestID)
+	case "import":
+		err = runImport(ctx, c, flag.Args()[1:])
+	case "export":
+		err = runExport(ctx, c, flag.Args()[1:])
+	case "import-history":
+		err = runImportHistory(ctx, c, flag.Args()[1:])
+	case "upload-activity":
+		err = runUploadActivity(ctx, c, flag.Args()[1:])
+	case "watch":
+		err = runWatch(ctx, c, flag.Args()[1:])
+	default:
+		log.Fatalf("unknown command %q; expected import, export, import-history, upload-activity or watch", flag.Arg(0))
+	}
+	if err != nil {
+		log.Fatalf("%s failed: %s", flag.Arg(0), err)
+	}
+}
+
+func demo(ctx context.Context, c pb.WorkoutManagerClient, requestID string) {
 	createMovementReq := &pb.CreateMovementRequest{
 		TenantId:           "create tenant",
 		MovementName:       "bench press",
 		MovementCategoryId: "create category",
+		RequestId:          requestID,
 	}
-	createRes, err := c.CreateMovement(context.Background(), createMovementReq)
+	createRes, err := c.CreateMovement(ctx, createMovementReq)
 	if err != nil {
 		log.Fatalf("Error when calling CreateMovement: %s", err)
 	}
 	log.Printf("Response from server: %v", createRes)
 
 	getMovementReq := &pb.GetMovementRequest{Name: "test"}
-	getRes, err := c.GetMovement(context.Background(), getMovementReq)
+	getRes, err := c.GetMovement(ctx, getMovementReq)
 	if err != nil {
 		log.Fatalf("Error when calling GetMovement: %s", err)
 	}
 	log.Printf("Response from server: %v", getRes)
 
 	listReq := &pb.ListMovementsRequest{CategoryName: "test"}
-	listRes, err := c.ListMovements(context.Background(), listReq)
+	listRes, err := c.ListMovements(ctx, listReq)
 	if err != nil {
 		log.Fatalf("Error when calling GetMovement: %s", err)
 	}
 	log.Printf("Response from server: %v", listRes)
 
 	delMovementReq := &pb.DeleteMovementRequest{Name: "delete name"}
-	delRes, err := c.DeleteMovement(context.Background(), delMovementReq)
+	delRes, err := c.DeleteMovement(ctx, delMovementReq)
 	if err != nil {
 		log.Fatalf("Error when calling DeleteMovement: %s", err)
 	}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"workout-manager-service/pb"
+)
+
+// runWatch prints changes to the movement catalog as they happen. When the
+// connection drops it reconnects with the resume token of the last event it
+// printed, so no change is missed.
+func runWatch(ctx context.Context, c pb.WorkoutManagerClient, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	var (
+		token = fs.String("resume-token", "", "Resume token of the last event seen; the watch starts from now when empty")
+		retry = fs.Duration("retry", 2*time.Second, "How long to wait before reconnecting")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	for {
+		err := watch(ctx, c, token)
+		switch status.Code(err) {
+		case codes.Unavailable:
+			log.Printf("watch interrupted, resuming from %q: %s", *token, err)
+			time.Sleep(*retry)
+		case codes.OutOfRange:
+			return fmt.Errorf("%s; list the catalog again and start a new watch", status.Convert(err).Message())
+		default:
+			return err
+		}
+	}
+}
+
+// watch follows one WatchMovements stream, keeping token at the last event
+// received.
+func watch(ctx context.Context, c pb.WorkoutManagerClient, token *string) error {
+	stream, err := c.WatchMovements(ctx, &pb.WatchMovementsRequest{ResumeToken: *token})
+	if err != nil {
+		return err
+	}
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		*token = e.GetResumeToken()
+		at, _ := ptypes.Timestamp(e.GetEventTime())
+		kind := strings.ToLower(strings.TrimPrefix(e.GetType().String(), "MOVEMENT_EVENT_TYPE_"))
+		if e.GetType() == pb.MovementEventType_MOVEMENT_EVENT_TYPE_BOOKMARK {
+			fmt.Printf("%s\t%s\t\t\t%s\n", at.Format(time.RFC3339), kind, *token)
+			continue
+		}
+		m := e.GetMovement()
+		fmt.Printf("%s\t%s\t%s\t%s\t%s\n", at.Format(time.RFC3339), kind, m.GetName(), m.GetMovementName(), *token)
+	}
+}
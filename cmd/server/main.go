@@ -1,18 +1,28 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"net"
+	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	kitgrpc "github.com/go-kit/kit/transport/grpc"
+	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 
+	"workout-manager-service/cockroach"
+	"workout-manager-service/inmem"
+	"workout-manager-service/kafka"
 	"workout-manager-service/logging"
+	"workout-manager-service/nats"
 	"workout-manager-service/pb"
 	"workout-manager-service/pkg/endpoint"
 	"workout-manager-service/pkg/service"
@@ -24,11 +34,43 @@ const (
 	defaultGrpcAddr    = ":8072"
 )
 
+// repository collects every storage interface the services depend on. Both
+// the Cockroach and in-memory stores satisfy it.
+type repository interface {
+	service.TenantRepository
+	service.UserRepository
+	service.MovementRepository
+	service.WorkoutRepository
+	service.AuditRepository
+	service.IdempotencyRepository
+	service.OutboxRepository
+	service.WebhookRepository
+	service.AnalyticsRepository
+	service.MetricsRepository
+	service.LoadingRepository
+	service.TemplateRepository
+}
+
 func main() {
 	fs := flag.NewFlagSet("workout-manager-server", flag.ExitOnError)
 	var (
-		env      = fs.String("env", defaultEnvironment, "The execution environment")
-		grpcAddr = fs.String("grpc-addr", defaultGrpcAddr, "gRPC listen address")
+		env        = fs.String("env", defaultEnvironment, "The execution environment")
+		grpcAddr   = fs.String("grpc-addr", defaultGrpcAddr, "gRPC listen address")
+		proxyKey   = fs.String("proxy-secret", "", "Secret the authenticating proxy sends as x-proxy-secret; x-user-id is rejected from callers without it")
+		trustUsers = fs.Bool("insecure-trust-user-header", false, "Accept x-user-id from any caller; for local development only")
+		dbSource   = fs.String("db-source", "", "CockroachDB connection string; an in-memory store is used when empty")
+		operatorID = fs.String("operator-id", "", "UUID of a platform operator to create on startup if missing")
+		retention  = fs.Duration("movement-retention", 30*24*time.Hour, "How long deleted movements are kept before they are purged")
+		purgeEvery = fs.Duration("purge-interval", time.Hour, "How often deleted movements past retention are purged")
+		replayFor  = fs.Duration("idempotency-window", 24*time.Hour, "How long responses to requests with a request ID are kept for replay")
+		leaseFor   = fs.Duration("idempotency-lease", endpoint.DefaultIdempotencyLease, "How long a request ID stays reserved while its request runs; must exceed the longest request")
+		watchFrom  = fs.Int("watch-history", 10000, "How many recent movement events are kept for watchers to resume from; watchers only see changes made through the same server, so WatchMovements needs a single instance")
+		brokerURL  = fs.String("broker-url", "", "Where domain events are published: nats://host:4222?prefix=workout-manager&jetstream=true or kafka://host:9092,host:9092?topic=workout-manager; events are dropped in-process when empty")
+		relay      = fs.Bool("outbox-relay", true, "Publish domain events from the outbox; enable on only one server per database")
+		relayEvery = fs.Duration("relay-interval", time.Second, "How often the outbox is drained")
+		relayBatch = fs.Int("relay-batch", 100, "How many outbox events are published per batch")
+		hookEvery  = fs.Duration("webhook-interval", 5*time.Second, "How often due webhook deliveries are attempted; they are attempted wherever the outbox is relayed")
+		hookWait   = fs.Duration("webhook-timeout", 10*time.Second, "How long a webhook receiver has to answer a delivery")
 	)
 	fs.Usage = usageFor(fs, os.Args[0]+" [flags]")
 	if err := fs.Parse(os.Args[1:]); err != nil {
@@ -45,11 +87,79 @@ func main() {
 		}
 	}()
 
+	var repo repository
+	if *dbSource == "" {
+		repo = inmem.NewStore()
+	} else {
+		db, err := cockroach.NewCockroach(*dbSource)
+		if err != nil {
+			log.Panicf("failed to connect to database: %+v", err)
+		}
+		defer func() {
+			if err := db.Close(); err != nil {
+				log.Printf("failed to close database: %+v", err)
+			}
+		}()
+		repo = db
+	}
+
+	if err := service.SeedGlobalMovements(context.Background(), repo); err != nil {
+		log.Panicf("failed to seed global movements: %+v", err)
+	}
+
+	if *operatorID != "" {
+		if err := bootstrapOperator(context.Background(), repo, *operatorID); err != nil {
+			log.Panicf("failed to bootstrap operator: %+v", err)
+		}
+	}
+
+	jobs, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go service.RunMovementPurge(jobs, logger, repo, *retention, *purgeEvery)
+	if *relay {
+		broker, err := newBroker(*brokerURL)
+		if err != nil {
+			log.Panicf("failed to configure broker: %+v", err)
+		}
+		dispatcher := service.NewWebhookDispatcher(repo, repo)
+		go service.RunOutboxRelay(jobs, logger, repo, service.Brokers{broker, dispatcher}, *relayBatch, *relayEvery)
+		client := service.NewWebhookClient(*hookWait)
+		go service.RunWebhookDelivery(jobs, logger, repo, client, service.DefaultWebhookRetryPolicy, *relayBatch, *hookEvery)
+	}
+
 	var (
-		baseServer       = grpc.NewServer(grpc.UnaryInterceptor(kitgrpc.Interceptor))
-		movementSvc      = service.NewMovementService(logger)
-		movementEndpoint = endpoint.NewMovementSet(movementSvc)
-		grpcServer       = transport.NewGRPCServer(movementEndpoint)
+		proxy            = transport.TrustedProxy{Secret: *proxyKey, Insecure: *trustUsers}
+		baseServer       = grpc.NewServer(grpc.UnaryInterceptor(proxy.UnaryInterceptor(kitgrpc.Interceptor)), grpc.StreamInterceptor(proxy.StreamInterceptor))
+		tenantSvc        = service.NewTenantService(logger, repo, repo)
+		userSvc          = service.NewUserService(logger, repo, repo, repo)
+		movementSvc      = service.NewMovementService(logger, repo, repo, inmem.NewEventBus(*watchFrom))
+		workoutSvc       = service.NewWorkoutService(logger, repo, repo, repo, repo, repo, service.NewWorkloadMonitor(logger, repo, repo, repo))
+		auditSvc         = service.NewAuditService(logger, repo)
+		webhookSvc       = service.NewWebhookService(logger, repo, repo)
+		analyticsSvc     = service.NewAnalyticsService(logger, repo, repo, repo, repo)
+		metricsSvc       = service.NewMetricsService(logger, repo, repo, repo, repo, repo)
+		loadingSvc       = service.NewLoadingService(logger, repo, repo, repo, repo, repo, repo)
+		templateSvc      = service.NewTemplateService(logger, repo, repo, repo)
+		tenantEndpoint   = endpoint.NewTenantSet(tenantSvc, userSvc)
+		userEndpoint     = endpoint.NewUserSet(userSvc)
+		idempotency      = endpoint.Idempotency{Repo: repo, Window: *replayFor, Lease: *leaseFor}
+		movementEndpoint = endpoint.NewMovementSet(movementSvc, userSvc, idempotency)
+		workoutEndpoint  = endpoint.NewWorkoutSet(workoutSvc, userSvc)
+		auditEndpoint    = endpoint.NewAuditSet(auditSvc, userSvc)
+		webhookEndpoint  = endpoint.NewWebhookSet(webhookSvc, userSvc, idempotency)
+		statsEndpoint    = endpoint.NewAnalyticsSet(analyticsSvc, userSvc)
+		metricsEndpoint  = endpoint.NewMetricsSet(metricsSvc, userSvc, idempotency)
+		loadingEndpoint  = endpoint.NewLoadingSet(loadingSvc, userSvc)
+		templateEndpoint = endpoint.NewTemplateSet(templateSvc, userSvc, idempotency)
+		grpcServer       = transport.NewGRPCServer(movementEndpoint, workoutEndpoint)
+		tenantGRPCServer = transport.NewTenantGRPCServer(tenantEndpoint)
+		userGRPCServer   = transport.NewUserGRPCServer(userEndpoint)
+		auditGRPCServer  = transport.NewAuditGRPCServer(auditEndpoint)
+		hookGRPCServer   = transport.NewWebhookGRPCServer(webhookEndpoint)
+		statsGRPCServer  = transport.NewAnalyticsGRPCServer(statsEndpoint)
+		bodyGRPCServer   = transport.NewMetricsGRPCServer(metricsEndpoint)
+		plateGRPCServer  = transport.NewLoadingGRPCServer(loadingEndpoint)
+		planGRPCServer   = transport.NewTemplateGRPCServer(templateEndpoint)
 	)
 
 	grpcListener, err := net.Listen("tcp", *grpcAddr)
@@ -59,6 +169,14 @@ func main() {
 
 	go func() {
 		pb.RegisterWorkoutManagerServer(baseServer, grpcServer)
+		pb.RegisterTenantManagerServer(baseServer, tenantGRPCServer)
+		pb.RegisterUserManagerServer(baseServer, userGRPCServer)
+		pb.RegisterAuditManagerServer(baseServer, auditGRPCServer)
+		pb.RegisterWebhookManagerServer(baseServer, hookGRPCServer)
+		pb.RegisterAnalyticsManagerServer(baseServer, statsGRPCServer)
+		pb.RegisterMetricsManagerServer(baseServer, bodyGRPCServer)
+		pb.RegisterLoadingManagerServer(baseServer, plateGRPCServer)
+		pb.RegisterTemplateManagerServer(baseServer, planGRPCServer)
 		if err := baseServer.Serve(grpcListener); err != nil && err != grpc.ErrServerStopped {
 			log.Panicf("failed to register gRPC server: %+v", err)
 		}
@@ -71,10 +189,54 @@ func main() {
 	<-c
 
 	log.Println("shutting down")
+	stopJobs()
 	baseServer.GracefulStop()
 	os.Exit(0)
 }
 
+// bootstrapOperator makes sure a platform operator with the given ID exists so
+// that tenants can be provisioned on a fresh deployment.
+func bootstrapOperator(ctx context.Context, users service.UserRepository, id string) error {
+	_, err := users.GetUser(ctx, id)
+	if errors.Cause(err) != service.ErrNotFound {
+		return err
+	}
+	_, err = users.CreateUser(ctx, service.User{
+		Name:        id,
+		TenantID:    service.SystemTenantID,
+		DisplayName: "Operator",
+		Email:       "operator@" + service.SystemTenantID,
+		Role:        service.RoleOperator,
+	})
+	return err
+}
+
+// newBroker returns the broker a -broker-url names.
+func newBroker(rawurl string) (service.Broker, error) {
+	if rawurl == "" {
+		return inmem.NewBroker(), nil
+	}
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid broker URL")
+	}
+	q := u.Query()
+	switch u.Scheme {
+	case "nats":
+		jetStream := false
+		if v := q.Get("jetstream"); v != "" {
+			if jetStream, err = strconv.ParseBool(v); err != nil {
+				return nil, errors.Wrap(err, "invalid jetstream option")
+			}
+		}
+		u.RawQuery = ""
+		return nats.NewBroker(u.String(), nats.Options{Prefix: q.Get("prefix"), JetStream: jetStream})
+	case "kafka":
+		return kafka.NewBroker(strings.Split(u.Host, ","), q.Get("topic"), kafka.Options{ClientID: q.Get("client-id")})
+	}
+	return nil, errors.Errorf("unsupported broker URL %q; expected nats:// or kafka://", rawurl)
+}
+
 func usageFor(fs *flag.FlagSet, short string) func() {
 	return func() {
 		_, _ = fmt.Fprintf(os.Stderr, "USAGE\n")
@@ -0,0 +1,197 @@
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+// volumeSets selects every set of an athlete performed before the end of a
+// volume query, with its movement resolved to the tenant's fork and the
+// running estimate of the athlete's one-rep max of that movement. The
+// estimate mirrors service.EstimateOneRepMax.
+const volumeSets = `
+WITH sets AS (
+	SELECT
+		w.performed_at, s.workout_id, s.position, s.reps,
+		s.weight::FLOAT8 AS weight, s.rpe::FLOAT8 AS rpe,
+		COALESCE(f.id, s.movement_id)::STRING AS movement_id,
+		COALESCE(f.movement_category_id, m.movement_category_id, '') AS category_id,
+		COALESCE(f.primary_muscles, m.primary_muscles, ARRAY[]:::STRING[]) AS muscles
+	FROM workouts w
+	JOIN workout_sets s ON s.workout_id = w.id
+	LEFT JOIN movements m ON m.id = s.movement_id
+	LEFT JOIN movements f ON f.forked_from = s.movement_id AND f.tenant_id = w.tenant_id
+	WHERE w.tenant_id = $1 AND w.athlete_id = $2 AND w.performed_at < $4 AND NOT w.planned
+), rated AS (
+	SELECT *, COALESCE(MAX(
+		CASE
+			WHEN weight <= 0 OR reps <= 0 OR reps > 12 THEN NULL
+			WHEN reps = 1 THEN weight
+			ELSE weight * (1 + reps::FLOAT8 / 30)
+		END
+	) OVER (
+		PARTITION BY movement_id ORDER BY performed_at, workout_id, position
+		ROWS UNBOUNDED PRECEDING
+	), 0) AS e1rm
+	FROM sets
+), scoped AS (
+	SELECT *,
+		performed_at AT TIME ZONE $5 AS local_time,
+		reps > 0 AND CASE
+			WHEN rpe > 0 THEN rpe >= 7
+			ELSE weight > 0 AND e1rm > 0 AND 30 * (e1rm / weight - 1) - reps <= 3
+		END AS hard
+	FROM rated
+	WHERE performed_at >= $3
+)`
+
+// volumeAggregates aggregates the scoped sets by bucket and group, which
+// are filled in with the expressions for the query and its FROM clause.
+const volumeAggregates = `
+SELECT
+	%[1]s AS bucket,
+	%[2]s AS grp,
+	COUNT(*),
+	COUNT(*) FILTER (WHERE hard),
+	COALESCE(SUM(reps), 0),
+	COALESCE(SUM(weight * reps), 0),
+	COALESCE(
+		SUM(weight * reps) FILTER (WHERE weight > 0)
+		/ NULLIF(SUM(reps) FILTER (WHERE weight > 0), 0), 0),
+	COALESCE(
+		SUM(weight / e1rm * reps) FILTER (WHERE weight > 0 AND e1rm > 0)
+		/ NULLIF(SUM(reps) FILTER (WHERE weight > 0 AND e1rm > 0), 0), 0)
+FROM %[3]s
+GROUP BY bucket, grp
+ORDER BY bucket, grp`
+
+// AggregateVolume implements service.AnalyticsRepository. Buckets are
+// computed from the local time of each set, so that a day, week or month
+// follows the tenant's calendar.
+func (m Cockroach) AggregateVolume(ctx context.Context, q service.VolumeQuery) ([]service.VolumeAggregate, error) {
+	loc, err := time.LoadLocation(q.TimeZone)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load time zone %q", q.TimeZone)
+	}
+	args := []interface{}{q.TenantID, q.AthleteID, q.Start, q.End, q.TimeZone}
+
+	var bucket string
+	switch q.Bucket {
+	case service.BucketDay:
+		bucket = "local_time::DATE"
+	case service.BucketWeek:
+		args = append(args, int(q.WeekStart))
+		bucket = "local_time::DATE - ((EXTRACT(DOW FROM local_time)::INT - $6 + 7) % 7)"
+	case service.BucketMonth:
+		bucket = "date_trunc('month', local_time)::DATE"
+	default:
+		return nil, errors.Errorf("unknown bucket %q", q.Bucket)
+	}
+	group, from := "''", "scoped"
+	switch q.GroupBy {
+	case service.GroupByMovement:
+		group = "movement_id"
+	case service.GroupByCategory:
+		group = "category_id"
+	case service.GroupByMuscle:
+		group = "muscle"
+		from = `scoped, unnest(CASE
+			WHEN COALESCE(array_length(muscles, 1), 0) > 0 THEN muscles
+			ELSE ARRAY['']:::STRING[]
+		END) AS muscle`
+	}
+
+	rows, err := m.conn(ctx).QueryContext(ctx, volumeSets+fmt.Sprintf(volumeAggregates, bucket, group, from), args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to aggregate volume")
+	}
+	defer rows.Close()
+	var aggregates []service.VolumeAggregate
+	for rows.Next() {
+		var (
+			a   service.VolumeAggregate
+			day time.Time
+		)
+		err := rows.Scan(&day, &a.Group, &a.Sets, &a.HardSets, &a.Reps, &a.Tonnage, &a.AverageIntensity, &a.RelativeIntensity)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan volume aggregate")
+		}
+		a.BucketStart = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+		aggregates = append(aggregates, a)
+	}
+	return aggregates, errors.Wrap(rows.Err(), "failed to iterate volume aggregates")
+}
+
+// ListSessionLoads implements service.AnalyticsRepository. The fallbacks of
+// unrated sessions mirror service.SessionLoadOf.
+func (m Cockroach) ListSessionLoads(ctx context.Context, tenantID string, athleteID string, end time.Time) ([]service.SessionLoad, error) {
+	rows, err := m.conn(ctx).QueryContext(
+		ctx,
+		`SELECT
+			w.id, w.performed_at,
+			COALESCE(
+				NULLIF(w.session_rpe, 0),
+				(SELECT AVG(s.rpe) FROM workout_sets s WHERE s.workout_id = w.id AND s.rpe > 0),
+				0
+			)::FLOAT8,
+			CASE
+				WHEN w.duration_ms > 0 THEN w.duration_ms
+				ELSE COALESCE((SELECT SUM(c.duration_ms) FROM workout_conditioning c WHERE c.workout_id = w.id), 0)
+			END::INT8
+		FROM workouts w
+		WHERE w.tenant_id = $1 AND w.athlete_id = $2 AND w.performed_at < $3 AND NOT w.planned
+		ORDER BY w.performed_at, w.id`,
+		tenantID, athleteID, end,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select session loads")
+	}
+	defer rows.Close()
+	var loads []service.SessionLoad
+	for rows.Next() {
+		var (
+			l  service.SessionLoad
+			ms int64
+		)
+		if err := rows.Scan(&l.WorkoutID, &l.PerformedAt, &l.RPE, &ms); err != nil {
+			return nil, errors.Wrap(err, "failed to scan session load")
+		}
+		l.Duration = time.Duration(ms) * time.Millisecond
+		loads = append(loads, l)
+	}
+	return loads, errors.Wrap(rows.Err(), "failed to iterate session loads")
+}
+
+// GetWorkloadThresholds implements service.AnalyticsRepository.
+func (m Cockroach) GetWorkloadThresholds(ctx context.Context, tenantID string) (service.WorkloadThresholds, error) {
+	t := service.WorkloadThresholds{TenantID: tenantID}
+	err := m.conn(ctx).QueryRowContext(
+		ctx,
+		"SELECT max_acwr, min_acwr, max_monotony, max_strain, update_time FROM workload_thresholds WHERE tenant_id = $1",
+		tenantID,
+	).Scan(&t.MaxACWR, &t.MinACWR, &t.MaxMonotony, &t.MaxStrain, &t.UpdateTime)
+	if err == sql.ErrNoRows {
+		return service.WorkloadThresholds{}, service.ErrNotFound
+	}
+	if err != nil {
+		return service.WorkloadThresholds{}, errors.Wrap(err, "failed to select workload thresholds")
+	}
+	return t, nil
+}
+
+// SetWorkloadThresholds implements service.AnalyticsRepository.
+func (m Cockroach) SetWorkloadThresholds(ctx context.Context, t service.WorkloadThresholds) error {
+	_, err := m.conn(ctx).ExecContext(
+		ctx,
+		`UPSERT INTO workload_thresholds (tenant_id, max_acwr, min_acwr, max_monotony, max_strain, update_time)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		t.TenantID, t.MaxACWR, t.MinACWR, t.MaxMonotony, t.MaxStrain, t.UpdateTime,
+	)
+	return errors.Wrap(err, "failed to upsert workload thresholds")
+}
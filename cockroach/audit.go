@@ -0,0 +1,66 @@
+package cockroach
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+const auditColumns = "id, tenant_id, actor_id, method, resource, before, after, correlation_id, event_time"
+
+// CreateAuditEvent implements service.AuditRepository.
+func (m Cockroach) CreateAuditEvent(ctx context.Context, e service.AuditEvent) error {
+	_, err := m.conn(ctx).ExecContext(
+		ctx,
+		"INSERT INTO audit_events ("+auditColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		e.Name, e.TenantID, e.ActorID, e.Method, e.Resource,
+		nullJSON(e.Before), nullJSON(e.After), e.CorrelationID, e.Time,
+	)
+	return errors.Wrap(err, "failed to insert audit event")
+}
+
+// ListAuditEvents implements service.AuditRepository.
+func (m Cockroach) ListAuditEvents(ctx context.Context, tenantID string, filter service.AuditFilter) ([]service.AuditEvent, error) {
+	rows, err := m.conn(ctx).QueryContext(
+		ctx,
+		`SELECT `+auditColumns+` FROM audit_events
+		WHERE tenant_id = $1
+		AND ($2 = '' OR resource = $2)
+		AND ($3::TIMESTAMPTZ IS NULL OR event_time >= $3)
+		AND ($4::TIMESTAMPTZ IS NULL OR event_time < $4)
+		ORDER BY event_time DESC
+		LIMIT $5`,
+		tenantID, filter.Resource, nullTime(filter.Start), nullTime(filter.End), filter.Limit,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select audit events")
+	}
+	defer rows.Close()
+	var events []service.AuditEvent
+	for rows.Next() {
+		var (
+			e             service.AuditEvent
+			before, after []byte
+		)
+		err := rows.Scan(
+			&e.Name, &e.TenantID, &e.ActorID, &e.Method, &e.Resource,
+			&before, &after, &e.CorrelationID, &e.Time,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan audit event")
+		}
+		e.Before, e.After = before, after
+		events = append(events, e)
+	}
+	return events, errors.Wrap(rows.Err(), "failed to iterate audit events")
+}
+
+// nullJSON stores empty JSON documents as SQL NULL.
+func nullJSON(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
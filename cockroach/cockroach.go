@@ -1,6 +1,7 @@
 package cockroach
 
 import (
+	"context"
 	"database/sql"
 
 	_ "github.com/lib/pq" // Import underlying database driver.
@@ -26,3 +27,58 @@ func NewCockroach(dataSource string) (Cockroach, error) {
 func (m Cockroach) Close() error {
 	return m.db.Close()
 }
+
+type txContextKey struct{}
+
+// queryer is what statements run on: the database or a transaction.
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn returns the transaction InTx put in ctx, so that a call made within it
+// sees and joins its writes, or the database otherwise.
+func (m Cockroach) conn(ctx context.Context) queryer {
+	if tx, ok := ctx.Value(txContextKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return m.db
+}
+
+// InTx implements service.AuditRepository.
+func (m Cockroach) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.inTx(ctx, func(tx *sql.Tx) error {
+		return fn(context.WithValue(ctx, txContextKey{}, tx))
+	})
+}
+
+// inTx runs fn inside a transaction, committing if fn returns nil and rolling
+// back otherwise. Within a transaction InTx began, fn runs in a savepoint of
+// it instead, so that a failing call undoes only its own writes.
+func (m Cockroach) inTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	if tx, ok := ctx.Value(txContextKey{}).(*sql.Tx); ok {
+		return inSavepoint(ctx, tx, fn)
+	}
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return errors.Wrap(tx.Commit(), "failed to commit transaction")
+}
+
+func inSavepoint(ctx context.Context, tx *sql.Tx, fn func(*sql.Tx) error) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT nested"); err != nil {
+		return errors.Wrap(err, "failed to create savepoint")
+	}
+	if err := fn(tx); err != nil {
+		_, _ = tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT nested")
+		return err
+	}
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT nested")
+	return errors.Wrap(err, "failed to release savepoint")
+}
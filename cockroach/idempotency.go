@@ -0,0 +1,67 @@
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+// ReserveIdempotencyKey implements service.IdempotencyRepository. The lookup
+// and the write share a transaction so that concurrent retries on different
+// instances cannot both reserve the key.
+func (m Cockroach) ReserveIdempotencyKey(ctx context.Context, rec service.IdempotencyRecord) (service.IdempotencyRecord, bool, error) {
+	var (
+		existing service.IdempotencyRecord
+		reserved bool
+	)
+	err := m.inTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(
+			ctx,
+			`SELECT key, request_hash, response, complete, expire_time FROM idempotency_keys
+			WHERE key = $1 AND expire_time > now()
+			FOR UPDATE`,
+			rec.Key,
+		).Scan(&existing.Key, &existing.RequestHash, &existing.Response, &existing.Complete, &existing.ExpireTime)
+		switch {
+		case err == nil:
+			return nil
+		case err != sql.ErrNoRows:
+			return errors.Wrap(err, "failed to select idempotency key")
+		}
+		_, err = tx.ExecContext(
+			ctx,
+			`UPSERT INTO idempotency_keys (key, request_hash, response, complete, expire_time)
+			VALUES ($1, $2, NULL, false, $3)`,
+			rec.Key, rec.RequestHash, rec.ExpireTime,
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to reserve idempotency key")
+		}
+		existing, reserved = rec, true
+		return nil
+	})
+	return existing, reserved, err
+}
+
+// CompleteIdempotencyKey implements service.IdempotencyRepository.
+func (m Cockroach) CompleteIdempotencyKey(ctx context.Context, key string, response []byte, expireTime time.Time) error {
+	res, err := m.conn(ctx).ExecContext(
+		ctx,
+		"UPDATE idempotency_keys SET response = $2, complete = true, expire_time = $3 WHERE key = $1",
+		key, response, expireTime,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to complete idempotency key")
+	}
+	return requireAffected(res)
+}
+
+// ReleaseIdempotencyKey implements service.IdempotencyRepository.
+func (m Cockroach) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	_, err := m.conn(ctx).ExecContext(ctx, "DELETE FROM idempotency_keys WHERE key = $1", key)
+	return errors.Wrap(err, "failed to release idempotency key")
+}
@@ -0,0 +1,49 @@
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+// GetPlateInventory implements service.LoadingRepository.
+func (m Cockroach) GetPlateInventory(ctx context.Context, tenantID string) (service.PlateInventory, error) {
+	var (
+		inv    = service.PlateInventory{TenantID: tenantID}
+		plates []byte
+	)
+	err := m.conn(ctx).QueryRowContext(
+		ctx,
+		"SELECT bar_weight, bar_unit, plates, update_time FROM plate_inventories WHERE tenant_id = $1",
+		tenantID,
+	).Scan(&inv.Bar.Kilograms, &inv.Bar.Unit, &plates, &inv.UpdateTime)
+	if err == sql.ErrNoRows {
+		return service.PlateInventory{}, service.ErrNotFound
+	}
+	if err != nil {
+		return service.PlateInventory{}, errors.Wrap(err, "failed to select plate inventory")
+	}
+	if err := json.Unmarshal(plates, &inv.Plates); err != nil {
+		return service.PlateInventory{}, errors.Wrap(err, "failed to decode plates")
+	}
+	return inv, nil
+}
+
+// SetPlateInventory implements service.LoadingRepository.
+func (m Cockroach) SetPlateInventory(ctx context.Context, inv service.PlateInventory) error {
+	plates, err := json.Marshal(inv.Plates)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode plates")
+	}
+	_, err = m.conn(ctx).ExecContext(
+		ctx,
+		`UPSERT INTO plate_inventories (tenant_id, bar_weight, bar_unit, plates, update_time)
+		VALUES ($1, $2, $3, $4, $5)`,
+		inv.TenantID, inv.Bar.Kilograms, inv.Bar.Unit, string(plates), inv.UpdateTime,
+	)
+	return errors.Wrap(err, "failed to upsert plate inventory")
+}
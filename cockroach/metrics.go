@@ -0,0 +1,102 @@
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+const bodyMetricColumns = "id, tenant_id, athlete_id, metric_type, site, value, unit, readiness, measured_at, create_time"
+
+// CreateBodyMetric implements service.MetricsRepository.
+func (m Cockroach) CreateBodyMetric(ctx context.Context, b service.BodyMetric) error {
+	var readiness []byte
+	if b.Readiness != nil {
+		var err error
+		if readiness, err = json.Marshal(b.Readiness); err != nil {
+			return errors.Wrap(err, "failed to encode readiness answers")
+		}
+	}
+	_, err := m.conn(ctx).ExecContext(
+		ctx,
+		"INSERT INTO body_metrics ("+bodyMetricColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		b.Name, b.TenantID, b.AthleteID, string(b.Type), b.Site, b.Value, string(b.Unit),
+		nullJSON(readiness), b.MeasuredAt, b.CreateTime,
+	)
+	return errors.Wrap(err, "failed to insert body metric")
+}
+
+// GetBodyMetric implements service.MetricsRepository.
+func (m Cockroach) GetBodyMetric(ctx context.Context, id string) (service.BodyMetric, error) {
+	row := m.conn(ctx).QueryRowContext(ctx, "SELECT "+bodyMetricColumns+" FROM body_metrics WHERE id = $1", id)
+	b, err := scanBodyMetric(row)
+	if err == sql.ErrNoRows {
+		return service.BodyMetric{}, service.ErrNotFound
+	}
+	if err != nil {
+		return service.BodyMetric{}, errors.Wrap(err, "failed to select body metric")
+	}
+	return b, nil
+}
+
+// ListBodyMetrics implements service.MetricsRepository.
+func (m Cockroach) ListBodyMetrics(ctx context.Context, tenantID string, athleteID string, f service.BodyMetricFilter) ([]service.BodyMetric, error) {
+	rows, err := m.conn(ctx).QueryContext(
+		ctx,
+		`SELECT `+bodyMetricColumns+` FROM body_metrics
+		WHERE tenant_id = $1 AND athlete_id = $2
+		AND ($3 = '' OR metric_type = $3)
+		AND ($4 = '' OR site = $4)
+		AND ($5::TIMESTAMPTZ IS NULL OR measured_at >= $5)
+		AND ($6::TIMESTAMPTZ IS NULL OR measured_at < $6)
+		ORDER BY measured_at, id`,
+		tenantID, athleteID, string(f.Type), f.Site, nullTime(f.Start), nullTime(f.End),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select body metrics")
+	}
+	defer rows.Close()
+	var metrics []service.BodyMetric
+	for rows.Next() {
+		b, err := scanBodyMetric(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan body metric")
+		}
+		metrics = append(metrics, b)
+	}
+	return metrics, errors.Wrap(rows.Err(), "failed to iterate body metrics")
+}
+
+// DeleteBodyMetric implements service.MetricsRepository.
+func (m Cockroach) DeleteBodyMetric(ctx context.Context, id string) error {
+	res, err := m.conn(ctx).ExecContext(ctx, "DELETE FROM body_metrics WHERE id = $1", id)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete body metric")
+	}
+	return requireAffected(res)
+}
+
+func scanBodyMetric(s scanner) (service.BodyMetric, error) {
+	var (
+		b         service.BodyMetric
+		readiness []byte
+	)
+	err := s.Scan(
+		&b.Name, &b.TenantID, &b.AthleteID, &b.Type, &b.Site, &b.Value, &b.Unit,
+		&readiness, &b.MeasuredAt, &b.CreateTime,
+	)
+	if err != nil {
+		return service.BodyMetric{}, err
+	}
+	if len(readiness) > 0 {
+		b.Readiness = new(service.ReadinessAnswers)
+		if err := json.Unmarshal(readiness, b.Readiness); err != nil {
+			return service.BodyMetric{}, errors.Wrap(err, "failed to decode readiness answers")
+		}
+	}
+	return b, nil
+}
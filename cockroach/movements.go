@@ -0,0 +1,582 @@
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+const movementColumns = `id, tenant_id, movement_name, movement_category_id, forked_from,
+	equipment, primary_muscles, secondary_muscles, laterality, load_type, links, aliases, delete_time, version`
+
+// CreateMovement implements service.MovementRepository.
+func (m Cockroach) CreateMovement(ctx context.Context, mvm service.Movement) (service.Movement, error) {
+	mvm.Version = 1
+	err := m.inTx(ctx, func(tx *sql.Tx) error {
+		if err := insertMovement(ctx, tx, mvm); err != nil {
+			return err
+		}
+		return insertOutboxEvents(ctx, tx, service.NewMovementDomainEvent(service.MovementCreatedEvent, mvm))
+	})
+	if err != nil {
+		return service.Movement{}, err
+	}
+	return mvm, nil
+}
+
+// CreateMovements implements service.MovementRepository.
+func (m Cockroach) CreateMovements(ctx context.Context, ms []service.Movement) ([]service.Movement, error) {
+	created := make([]service.Movement, len(ms))
+	err := m.inTx(ctx, func(tx *sql.Tx) error {
+		for i, mvm := range ms {
+			mvm.Version = 1
+			if err := insertMovement(ctx, tx, mvm); err != nil {
+				return err
+			}
+			if err := insertOutboxEvents(ctx, tx, service.NewMovementDomainEvent(service.MovementCreatedEvent, mvm)); err != nil {
+				return err
+			}
+			created[i] = mvm
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetMovement implements service.MovementRepository.
+func (m Cockroach) GetMovement(ctx context.Context, id string) (service.Movement, error) {
+	row := m.conn(ctx).QueryRowContext(ctx, "SELECT "+movementColumns+" FROM movements WHERE id = $1", id)
+	mvm, err := scanMovement(row)
+	if err == sql.ErrNoRows {
+		return service.Movement{}, service.ErrNotFound
+	}
+	if err != nil {
+		return service.Movement{}, errors.Wrap(err, "failed to select movement")
+	}
+	return mvm, nil
+}
+
+// ListMovements implements service.MovementRepository.
+func (m Cockroach) ListMovements(ctx context.Context, tenantID string, filter service.MovementFilter) ([]service.Movement, error) {
+	rows, err := m.conn(ctx).QueryContext(
+		ctx,
+		`SELECT `+movementColumns+` FROM movements
+		WHERE tenant_id = $1
+		AND ($2 = '' OR movement_category_id = $2)
+		AND ($3 = '' OR equipment = $3)
+		AND ($4 = '' OR $4 = ANY(primary_muscles) OR $4 = ANY(secondary_muscles))
+		AND ($5 = '' OR laterality = $5)
+		AND ($6 = '' OR load_type = $6)
+		AND ($7 OR delete_time IS NULL)
+		ORDER BY movement_name`,
+		tenantID, filter.CategoryID, string(filter.Equipment), string(filter.Muscle),
+		string(filter.Laterality), string(filter.LoadType), filter.ShowDeleted,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select movements")
+	}
+	defer rows.Close()
+	var mvms []service.Movement
+	for rows.Next() {
+		mvm, err := scanMovement(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan movement")
+		}
+		mvms = append(mvms, mvm)
+	}
+	return mvms, errors.Wrap(rows.Err(), "failed to iterate movements")
+}
+
+// SearchMovements implements service.MovementRepository. Names and aliases
+// are normalized in SQL the way service.NormalizeSearch normalizes the query,
+// so that "push up" finds "Push-Up". Candidates are found with the trigram
+// index on normalized names, either as substrings or by similarity, and
+// ordered by similarity. Aliases are matched the same way but without the
+// help of the index. Trigrams miss swapped letters, so single-word queries
+// also match words of the tenant's movements within an edit distance of two;
+// the service keeps only those that service.SearchScore accepts.
+func (m Cockroach) SearchMovements(ctx context.Context, tenantID string, query string, limit int) ([]service.Movement, error) {
+	name, alias := normalized("movement_name"), normalized("a")
+	rows, err := m.conn(ctx).QueryContext(
+		ctx,
+		`SELECT `+movementColumns+` FROM movements
+		WHERE tenant_id = $1 AND delete_time IS NULL
+		AND (
+			`+name+` LIKE '%' || $2 || '%' OR `+name+` % $3
+			OR EXISTS (SELECT 1 FROM unnest(aliases) AS a WHERE `+alias+` LIKE '%' || $2 || '%' OR `+alias+` % $3)
+			OR ($5 AND EXISTS (
+				SELECT 1 FROM unnest(string_to_array(`+name+` || ' ' || `+normalized("array_to_string(aliases, ' ')")+`, ' ')) AS w
+				WHERE levenshtein(w, $3) <= 2
+			))
+		)
+		ORDER BY similarity(`+name+`, $3) DESC
+		LIMIT $4`,
+		tenantID, likeEscaper.Replace(query), query, limit, typoTolerant(query),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to search movements")
+	}
+	defer rows.Close()
+	var mvms []service.Movement
+	for rows.Next() {
+		mvm, err := scanMovement(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan movement")
+		}
+		mvms = append(mvms, mvm)
+	}
+	return mvms, errors.Wrap(rows.Err(), "failed to iterate movements")
+}
+
+// normalized returns the SQL expression normalizing a text expression the
+// way service.NormalizeSearch does. Migration 20 indexes it on movement names,
+// so it must not change without a migration rebuilding that index.
+func normalized(expr string) string {
+	return `btrim(regexp_replace(lower(` + expr + `), '[^\pL\pN]+', ' ', 'g'))`
+}
+
+// typoTolerant reports whether a normalized query is one word long enough for
+// service.SearchScore to match it by edit distance.
+func typoTolerant(query string) bool {
+	return !strings.Contains(query, " ") && len(query) >= 4
+}
+
+// likeEscaper escapes the characters LIKE treats specially.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// UpdateMovement implements service.MovementRepository.
+func (m Cockroach) UpdateMovement(ctx context.Context, mvm service.Movement) (service.Movement, error) {
+	var updated service.Movement
+	err := m.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		updated, err = updateMovement(ctx, tx, mvm)
+		return err
+	})
+	if err != nil {
+		return service.Movement{}, err
+	}
+	return updated, nil
+}
+
+// ImportMovements implements service.MovementRepository.
+func (m Cockroach) ImportMovements(ctx context.Context, creates []service.Movement, updates []service.Movement) error {
+	return m.inTx(ctx, func(tx *sql.Tx) error {
+		for _, mvm := range creates {
+			mvm.Version = 1
+			if err := insertMovement(ctx, tx, mvm); err != nil {
+				return err
+			}
+			if err := insertOutboxEvents(ctx, tx, service.NewMovementDomainEvent(service.MovementCreatedEvent, mvm)); err != nil {
+				return err
+			}
+		}
+		for _, mvm := range updates {
+			if _, err := updateMovement(ctx, tx, mvm); err != nil {
+				return errors.Wrapf(err, "failed to overwrite %s", mvm.Name)
+			}
+		}
+		return nil
+	})
+}
+
+// updateMovement writes mvm if the stored movement still has its version and
+// adds the update to the outbox.
+func updateMovement(ctx context.Context, tx *sql.Tx, mvm service.Movement) (service.Movement, error) {
+	row := tx.QueryRowContext(
+		ctx,
+		`UPDATE movements SET
+			movement_name = $3, movement_category_id = $4, equipment = $5, primary_muscles = $6,
+			secondary_muscles = $7, laterality = $8, load_type = $9, links = $10, aliases = $11,
+			version = version + 1
+		WHERE id = $1 AND version = $2
+		RETURNING `+movementColumns,
+		mvm.Name, mvm.Version, mvm.MovementName, mvm.MovementCategoryID, string(mvm.Equipment),
+		pq.Array(musclesToStrings(mvm.PrimaryMuscles)), pq.Array(musclesToStrings(mvm.SecondaryMuscles)),
+		string(mvm.Laterality), string(mvm.LoadType), pq.Array(mvm.Links), pq.Array(mvm.Aliases),
+	)
+	updated, err := scanMovement(row)
+	if err == sql.ErrNoRows {
+		return service.Movement{}, versionMismatch(ctx, tx, mvm.Name)
+	}
+	if err != nil {
+		return service.Movement{}, errors.Wrap(err, "failed to update movement")
+	}
+	return updated, insertOutboxEvents(ctx, tx, service.NewMovementDomainEvent(service.MovementUpdatedEvent, updated))
+}
+
+// SetMovementDeleteTime implements service.MovementRepository. A zero time
+// restores the movement.
+func (m Cockroach) SetMovementDeleteTime(ctx context.Context, id string, deleteTime time.Time, version int64) error {
+	return m.inTx(ctx, func(tx *sql.Tx) error {
+		return setDeleteTime(ctx, tx, id, deleteTime, version)
+	})
+}
+
+// SetMovementsDeleteTime implements service.MovementRepository.
+func (m Cockroach) SetMovementsDeleteTime(ctx context.Context, ms []service.Movement, deleteTime time.Time) error {
+	return m.inTx(ctx, func(tx *sql.Tx) error {
+		for _, mvm := range ms {
+			if err := setDeleteTime(ctx, tx, mvm.Name, deleteTime, mvm.Version); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// setDeleteTime deletes or restores a movement if it still has the given
+// version, and records which of the two happened in the outbox.
+func setDeleteTime(ctx context.Context, tx *sql.Tx, id string, deleteTime time.Time, version int64) error {
+	row := tx.QueryRowContext(
+		ctx,
+		"UPDATE movements SET delete_time = $1, version = version + 1 WHERE id = $2 AND version = $3 RETURNING "+movementColumns,
+		nullTime(deleteTime), id, version,
+	)
+	mvm, err := scanMovement(row)
+	if err == sql.ErrNoRows {
+		return versionMismatch(ctx, tx, id)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to update movement delete time")
+	}
+	t := service.MovementRestoredEvent
+	if mvm.Deleted() {
+		t = service.MovementDeletedEvent
+	}
+	return insertOutboxEvents(ctx, tx, service.NewMovementDomainEvent(t, mvm))
+}
+
+// versionMismatch explains why a compare-and-set on a movement touched no
+// rows: either the movement is gone or somebody else changed it first.
+func versionMismatch(ctx context.Context, db queryRower, id string) error {
+	var exists bool
+	err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM movements WHERE id = $1)", id).Scan(&exists)
+	switch {
+	case err != nil:
+		return errors.Wrap(err, "failed to select movement")
+	case !exists:
+		return service.ErrNotFound
+	}
+	return service.ErrConflict
+}
+
+// PurgeMovements implements service.MovementRepository. Overrides of purged
+// movements are removed by ON DELETE CASCADE and forks of them are detached
+// by ON DELETE SET NULL.
+func (m Cockroach) PurgeMovements(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var purged []service.Movement
+	err := m.inTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(
+			ctx,
+			`DELETE FROM movements
+			WHERE delete_time < $1
+			AND NOT EXISTS (SELECT 1 FROM workout_sets WHERE movement_id = movements.id)
+			AND NOT EXISTS (SELECT 1 FROM workout_conditioning WHERE movement_id = movements.id)
+			AND NOT EXISTS (
+				SELECT 1 FROM workout_templates
+				WHERE sets @> jsonb_build_array(jsonb_build_object('movementId', movements.id::STRING))
+			)
+			RETURNING `+movementColumns,
+			deletedBefore,
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to purge movements")
+		}
+		defer rows.Close()
+		for rows.Next() {
+			mvm, err := scanMovement(rows)
+			if err != nil {
+				return errors.Wrap(err, "failed to scan purged movement")
+			}
+			purged = append(purged, mvm)
+		}
+		if err := rows.Err(); err != nil {
+			return errors.Wrap(err, "failed to iterate purged movements")
+		}
+		rows.Close()
+		for _, mvm := range purged {
+			if err := insertOutboxEvents(ctx, tx, service.NewMovementDomainEvent(service.MovementPurgedEvent, mvm)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(purged)), nil
+}
+
+// GetFork implements service.MovementRepository.
+func (m Cockroach) GetFork(ctx context.Context, tenantID string, globalID string) (service.Movement, error) {
+	row := m.conn(ctx).QueryRowContext(
+		ctx,
+		"SELECT "+movementColumns+" FROM movements WHERE tenant_id = $1 AND forked_from = $2",
+		tenantID, globalID,
+	)
+	mvm, err := scanMovement(row)
+	if err == sql.ErrNoRows {
+		return service.Movement{}, service.ErrNotFound
+	}
+	if err != nil {
+		return service.Movement{}, errors.Wrap(err, "failed to select fork")
+	}
+	return mvm, nil
+}
+
+// ListForks implements service.MovementRepository.
+func (m Cockroach) ListForks(ctx context.Context, tenantID string) ([]service.Movement, error) {
+	rows, err := m.conn(ctx).QueryContext(
+		ctx,
+		"SELECT "+movementColumns+" FROM movements WHERE tenant_id = $1 AND forked_from IS NOT NULL",
+		tenantID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select forks")
+	}
+	defer rows.Close()
+	var forks []service.Movement
+	for rows.Next() {
+		mvm, err := scanMovement(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan fork")
+		}
+		forks = append(forks, mvm)
+	}
+	return forks, errors.Wrap(rows.Err(), "failed to iterate forks")
+}
+
+// GetMovementOverride implements service.MovementRepository.
+func (m Cockroach) GetMovementOverride(ctx context.Context, tenantID string, movementID string) (service.MovementOverride, error) {
+	o := service.MovementOverride{TenantID: tenantID, MovementID: movementID}
+	err := m.conn(ctx).QueryRowContext(
+		ctx,
+		"SELECT hidden, alias FROM movement_overrides WHERE tenant_id = $1 AND movement_id = $2",
+		tenantID, movementID,
+	).Scan(&o.Hidden, &o.Alias)
+	if err == sql.ErrNoRows {
+		return service.MovementOverride{}, service.ErrNotFound
+	}
+	if err != nil {
+		return service.MovementOverride{}, errors.Wrap(err, "failed to select movement override")
+	}
+	return o, nil
+}
+
+// ListMovementOverrides implements service.MovementRepository.
+func (m Cockroach) ListMovementOverrides(ctx context.Context, tenantID string) ([]service.MovementOverride, error) {
+	rows, err := m.conn(ctx).QueryContext(
+		ctx,
+		"SELECT movement_id, hidden, alias FROM movement_overrides WHERE tenant_id = $1",
+		tenantID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select movement overrides")
+	}
+	defer rows.Close()
+	var overrides []service.MovementOverride
+	for rows.Next() {
+		o := service.MovementOverride{TenantID: tenantID}
+		if err := rows.Scan(&o.MovementID, &o.Hidden, &o.Alias); err != nil {
+			return nil, errors.Wrap(err, "failed to scan movement override")
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, errors.Wrap(rows.Err(), "failed to iterate movement overrides")
+}
+
+// PutMovementOverride implements service.MovementRepository.
+func (m Cockroach) PutMovementOverride(ctx context.Context, o service.MovementOverride) error {
+	_, err := m.conn(ctx).ExecContext(
+		ctx,
+		"UPSERT INTO movement_overrides (tenant_id, movement_id, hidden, alias) VALUES ($1, $2, $3, $4)",
+		o.TenantID, o.MovementID, o.Hidden, o.Alias,
+	)
+	return errors.Wrap(err, "failed to upsert movement override")
+}
+
+// DeleteMovementOverride implements service.MovementRepository.
+func (m Cockroach) DeleteMovementOverride(ctx context.Context, tenantID string, movementID string) error {
+	res, err := m.conn(ctx).ExecContext(
+		ctx,
+		"DELETE FROM movement_overrides WHERE tenant_id = $1 AND movement_id = $2",
+		tenantID, movementID,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete movement override")
+	}
+	return requireAffected(res)
+}
+
+// MergeMovements implements service.MovementRepository. Sets, conditioning
+// efforts and the sets of templates are repointed, existing redirects to the
+// duplicates are flattened onto the canonical movement and the duplicates are
+// replaced by redirects, all in one transaction.
+func (m Cockroach) MergeMovements(ctx context.Context, merge service.MovementMerge) error {
+	return m.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(
+			ctx,
+			`UPDATE workout_sets SET movement_id = $1
+			WHERE movement_id = ANY($2) AND workout_id IN (SELECT id FROM workouts WHERE tenant_id = $3)`,
+			merge.CanonicalID, pq.Array(merge.DuplicateIDs), merge.TenantID,
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to repoint workout sets")
+		}
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE workout_conditioning SET movement_id = $1
+			WHERE movement_id = ANY($2) AND workout_id IN (SELECT id FROM workouts WHERE tenant_id = $3)`,
+			merge.CanonicalID, pq.Array(merge.DuplicateIDs), merge.TenantID,
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to repoint workout conditioning")
+		}
+		if err := repointTemplates(ctx, tx, merge); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(
+			ctx,
+			"UPDATE movement_redirects SET to_id = $1 WHERE tenant_id = $2 AND to_id = ANY($3)",
+			merge.CanonicalID, merge.TenantID, pq.Array(merge.DuplicateIDs),
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to flatten movement redirects")
+		}
+		for _, id := range merge.DuplicateIDs {
+			_, err = tx.ExecContext(
+				ctx,
+				"UPSERT INTO movement_redirects (tenant_id, from_id, to_id) VALUES ($1, $2, $3)",
+				merge.TenantID, id, merge.CanonicalID,
+			)
+			if err != nil {
+				return errors.Wrap(err, "failed to insert movement redirect")
+			}
+		}
+		res, err := tx.ExecContext(
+			ctx,
+			"DELETE FROM movements WHERE tenant_id = $1 AND id = ANY($2)",
+			merge.TenantID, pq.Array(merge.DuplicateIDs),
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to delete duplicate movements")
+		}
+		if err := requireAffected(res); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(
+			ctx,
+			"UPDATE movements SET aliases = $1, version = version + 1 WHERE id = $2 AND tenant_id = $3",
+			pq.Array(merge.Aliases), merge.CanonicalID, merge.TenantID,
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to update canonical aliases")
+		}
+		return insertOutboxEvents(ctx, tx, service.NewMergeDomainEvent(merge))
+	})
+}
+
+// GetMovementRedirect implements service.MovementRepository.
+func (m Cockroach) GetMovementRedirect(ctx context.Context, tenantID string, id string) (string, error) {
+	var to string
+	err := m.conn(ctx).QueryRowContext(
+		ctx,
+		"SELECT to_id FROM movement_redirects WHERE tenant_id = $1 AND from_id = $2",
+		tenantID, id,
+	).Scan(&to)
+	if err == sql.ErrNoRows {
+		return "", service.ErrNotFound
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "failed to select movement redirect")
+	}
+	return to, nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx so that inserts can be
+// shared between standalone statements and larger transactions.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx.
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func insertMovement(ctx context.Context, db execer, mvm service.Movement) error {
+	_, err := db.ExecContext(
+		ctx,
+		"INSERT INTO movements ("+movementColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)",
+		mvm.Name, mvm.TenantID, mvm.MovementName, mvm.MovementCategoryID, nullString(mvm.ForkedFrom),
+		string(mvm.Equipment), pq.Array(musclesToStrings(mvm.PrimaryMuscles)),
+		pq.Array(musclesToStrings(mvm.SecondaryMuscles)), string(mvm.Laterality),
+		string(mvm.LoadType), pq.Array(mvm.Links), pq.Array(mvm.Aliases), nullTime(mvm.DeleteTime),
+		mvm.Version,
+	)
+	return errors.Wrap(err, "failed to insert movement")
+}
+
+func scanMovement(s scanner) (service.Movement, error) {
+	var (
+		mvm                 service.Movement
+		forkedFrom          sql.NullString
+		primary, secondary  []string
+		equipment, lat, ldt string
+		deleteTime          pq.NullTime
+	)
+	err := s.Scan(
+		&mvm.Name, &mvm.TenantID, &mvm.MovementName, &mvm.MovementCategoryID, &forkedFrom,
+		&equipment, pq.Array(&primary), pq.Array(&secondary), &lat, &ldt,
+		pq.Array(&mvm.Links), pq.Array(&mvm.Aliases), &deleteTime, &mvm.Version,
+	)
+	mvm.ForkedFrom = forkedFrom.String
+	mvm.DeleteTime = deleteTime.Time
+	mvm.Equipment = service.Equipment(equipment)
+	mvm.PrimaryMuscles = stringsToMuscles(primary)
+	mvm.SecondaryMuscles = stringsToMuscles(secondary)
+	mvm.Laterality = service.Laterality(lat)
+	mvm.LoadType = service.LoadType(ldt)
+	return mvm, err
+}
+
+func musclesToStrings(muscles []service.MuscleGroup) []string {
+	ss := make([]string, len(muscles))
+	for i, g := range muscles {
+		ss[i] = string(g)
+	}
+	return ss
+}
+
+func stringsToMuscles(ss []string) []service.MuscleGroup {
+	var muscles []service.MuscleGroup
+	for _, s := range ss {
+		muscles = append(muscles, service.MuscleGroup(s))
+	}
+	return muscles
+}
+
+// nullTime stores zero times as SQL NULL.
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// nullString stores empty strings as SQL NULL.
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
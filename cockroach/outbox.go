@@ -0,0 +1,87 @@
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+// insertOutboxEvents adds events to the outbox. It is called with the
+// transaction of the write the events describe.
+func insertOutboxEvents(ctx context.Context, db execer, events ...service.DomainEvent) error {
+	for _, e := range events {
+		_, err := db.ExecContext(
+			ctx,
+			`INSERT INTO outbox (id, event_type, aggregate_type, aggregate_id, tenant_id, event_time, data)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			e.ID, string(e.Type), e.AggregateType, e.AggregateID, e.TenantID, e.Time, []byte(e.Data),
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to insert outbox event")
+		}
+	}
+	return nil
+}
+
+// AddOutboxEvents implements service.OutboxRepository.
+func (m Cockroach) AddOutboxEvents(ctx context.Context, events []service.DomainEvent) error {
+	return m.inTx(ctx, func(tx *sql.Tx) error {
+		for _, e := range events {
+			_, err := tx.ExecContext(
+				ctx,
+				`INSERT INTO outbox (id, event_type, aggregate_type, aggregate_id, tenant_id, event_time, data)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+				ON CONFLICT (id) DO NOTHING`,
+				e.ID, string(e.Type), e.AggregateType, e.AggregateID, e.TenantID, e.Time, []byte(e.Data),
+			)
+			if err != nil {
+				return errors.Wrap(err, "failed to insert outbox event")
+			}
+		}
+		return nil
+	})
+}
+
+// ListOutboxEvents implements service.OutboxRepository. Events are ordered by
+// the commit timestamp of the transaction that wrote them, which for two
+// writes of the same aggregate is the order they happened in, and then by
+// their insertion order within the transaction.
+func (m Cockroach) ListOutboxEvents(ctx context.Context, limit int) ([]service.DomainEvent, error) {
+	rows, err := m.conn(ctx).QueryContext(
+		ctx,
+		`SELECT id, event_type, aggregate_type, aggregate_id, tenant_id, event_time, data
+		FROM outbox
+		ORDER BY crdb_internal_mvcc_timestamp, seq
+		LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select outbox events")
+	}
+	defer rows.Close()
+	var events []service.DomainEvent
+	for rows.Next() {
+		var (
+			e    service.DomainEvent
+			t    string
+			data []byte
+		)
+		if err := rows.Scan(&e.ID, &t, &e.AggregateType, &e.AggregateID, &e.TenantID, &e.Time, &data); err != nil {
+			return nil, errors.Wrap(err, "failed to scan outbox event")
+		}
+		e.Type = service.DomainEventType(t)
+		e.Data = data
+		events = append(events, e)
+	}
+	return events, errors.Wrap(rows.Err(), "failed to iterate outbox events")
+}
+
+// DeleteOutboxEvents implements service.OutboxRepository.
+func (m Cockroach) DeleteOutboxEvents(ctx context.Context, ids []string) error {
+	_, err := m.conn(ctx).ExecContext(ctx, "DELETE FROM outbox WHERE id = ANY($1::UUID[])", pq.Array(ids))
+	return errors.Wrap(err, "failed to delete outbox events")
+}
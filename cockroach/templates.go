@@ -0,0 +1,171 @@
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+const templateColumns = "id, tenant_id, owner_id, title, scope, shared_with, sets, blocks, create_time, update_time"
+
+// CreateTemplate implements service.TemplateRepository.
+func (m Cockroach) CreateTemplate(ctx context.Context, t service.WorkoutTemplate) error {
+	sets, blocks, err := encodeTemplate(t)
+	if err != nil {
+		return err
+	}
+	_, err = m.conn(ctx).ExecContext(
+		ctx,
+		"INSERT INTO workout_templates ("+templateColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		t.Name, t.TenantID, t.OwnerID, t.Title, string(t.Scope), pq.Array(t.SharedWith),
+		sets, blocks, t.CreateTime, t.UpdateTime,
+	)
+	return errors.Wrap(err, "failed to insert template")
+}
+
+// GetTemplate implements service.TemplateRepository.
+func (m Cockroach) GetTemplate(ctx context.Context, id string) (service.WorkoutTemplate, error) {
+	row := m.conn(ctx).QueryRowContext(ctx, "SELECT "+templateColumns+" FROM workout_templates WHERE id = $1", id)
+	t, err := scanTemplate(row)
+	if err == sql.ErrNoRows {
+		return service.WorkoutTemplate{}, service.ErrNotFound
+	}
+	if err != nil {
+		return service.WorkoutTemplate{}, errors.Wrap(err, "failed to select template")
+	}
+	return t, nil
+}
+
+// ListTemplates implements service.TemplateRepository.
+func (m Cockroach) ListTemplates(ctx context.Context, tenantID string) ([]service.WorkoutTemplate, error) {
+	rows, err := m.conn(ctx).QueryContext(
+		ctx,
+		"SELECT "+templateColumns+" FROM workout_templates WHERE tenant_id = $1 ORDER BY update_time DESC, id",
+		tenantID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select templates")
+	}
+	defer rows.Close()
+	var templates []service.WorkoutTemplate
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan template")
+		}
+		templates = append(templates, t)
+	}
+	return templates, errors.Wrap(rows.Err(), "failed to iterate templates")
+}
+
+// UpdateTemplate implements service.TemplateRepository.
+func (m Cockroach) UpdateTemplate(ctx context.Context, t service.WorkoutTemplate) error {
+	sets, blocks, err := encodeTemplate(t)
+	if err != nil {
+		return err
+	}
+	res, err := m.conn(ctx).ExecContext(
+		ctx,
+		`UPDATE workout_templates SET title = $2, scope = $3, shared_with = $4, sets = $5, blocks = $6, update_time = $7
+		WHERE id = $1`,
+		t.Name, t.Title, string(t.Scope), pq.Array(t.SharedWith), sets, blocks, t.UpdateTime,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to update template")
+	}
+	return requireAffected(res)
+}
+
+// DeleteTemplate implements service.TemplateRepository.
+func (m Cockroach) DeleteTemplate(ctx context.Context, id string) error {
+	res, err := m.conn(ctx).ExecContext(ctx, "DELETE FROM workout_templates WHERE id = $1", id)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete template")
+	}
+	return requireAffected(res)
+}
+
+// repointTemplates rewrites the sets of a tenant's templates that use the
+// duplicates of a merge to use the canonical movement. Sets are kept as JSON,
+// so the templates are rewritten one by one.
+func repointTemplates(ctx context.Context, tx *sql.Tx, merge service.MovementMerge) error {
+	duplicates := make(map[string]bool, len(merge.DuplicateIDs))
+	for _, id := range merge.DuplicateIDs {
+		duplicates[id] = true
+	}
+	rows, err := tx.QueryContext(ctx, "SELECT "+templateColumns+" FROM workout_templates WHERE tenant_id = $1", merge.TenantID)
+	if err != nil {
+		return errors.Wrap(err, "failed to select templates")
+	}
+	defer rows.Close()
+	var repointed []service.WorkoutTemplate
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return errors.Wrap(err, "failed to scan template")
+		}
+		changed := false
+		for i := range t.Sets {
+			if duplicates[t.Sets[i].MovementID] {
+				t.Sets[i].MovementID = merge.CanonicalID
+				changed = true
+			}
+		}
+		if changed {
+			repointed = append(repointed, t)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "failed to iterate templates")
+	}
+	rows.Close()
+	for _, t := range repointed {
+		sets, _, err := encodeTemplate(t)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE workout_templates SET sets = $2 WHERE id = $1", t.Name, sets); err != nil {
+			return errors.Wrap(err, "failed to repoint template")
+		}
+	}
+	return nil
+}
+
+// encodeTemplate encodes the sets and blocks of a template as JSON.
+func encodeTemplate(t service.WorkoutTemplate) (string, string, error) {
+	sets, err := json.Marshal(t.Sets)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to encode sets")
+	}
+	blocks, err := json.Marshal(t.Blocks)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to encode blocks")
+	}
+	return string(sets), string(blocks), nil
+}
+
+func scanTemplate(s scanner) (service.WorkoutTemplate, error) {
+	var (
+		t            service.WorkoutTemplate
+		sets, blocks []byte
+	)
+	err := s.Scan(
+		&t.Name, &t.TenantID, &t.OwnerID, &t.Title, &t.Scope, pq.Array(&t.SharedWith),
+		&sets, &blocks, &t.CreateTime, &t.UpdateTime,
+	)
+	if err != nil {
+		return service.WorkoutTemplate{}, err
+	}
+	if err := json.Unmarshal(sets, &t.Sets); err != nil {
+		return service.WorkoutTemplate{}, errors.Wrap(err, "failed to decode sets")
+	}
+	if err := json.Unmarshal(blocks, &t.Blocks); err != nil {
+		return service.WorkoutTemplate{}, errors.Wrap(err, "failed to decode blocks")
+	}
+	return t, nil
+}
@@ -0,0 +1,157 @@
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+const tenantColumns = "id, display_name, state, default_unit, week_start, time_zone, create_time"
+
+// tenantTables lists every tenant-scoped table in the order rows must be
+// removed when a tenant is deleted. The child tables of workouts are listed
+// explicitly so that their rows are counted rather than removed silently by
+// the cascade.
+var tenantTables = []struct {
+	table string
+	query string
+}{
+	{"workout_sets", "DELETE FROM workout_sets WHERE workout_id IN (SELECT id FROM workouts WHERE tenant_id = $1)"},
+	{"workout_blocks", "DELETE FROM workout_blocks WHERE workout_id IN (SELECT id FROM workouts WHERE tenant_id = $1)"},
+	{"workout_laps", "DELETE FROM workout_laps WHERE workout_id IN (SELECT id FROM workouts WHERE tenant_id = $1)"},
+	{"workout_conditioning", "DELETE FROM workout_conditioning WHERE workout_id IN (SELECT id FROM workouts WHERE tenant_id = $1)"},
+	{"workouts", "DELETE FROM workouts WHERE tenant_id = $1"},
+	{"workload_thresholds", "DELETE FROM workload_thresholds WHERE tenant_id = $1"},
+	{"body_metrics", "DELETE FROM body_metrics WHERE tenant_id = $1"},
+	{"plate_inventories", "DELETE FROM plate_inventories WHERE tenant_id = $1"},
+	{"workout_templates", "DELETE FROM workout_templates WHERE tenant_id = $1"},
+	{"webhook_deliveries", "DELETE FROM webhook_deliveries WHERE tenant_id = $1"},
+	{"webhooks", "DELETE FROM webhooks WHERE tenant_id = $1"},
+	{"audit_events", "DELETE FROM audit_events WHERE tenant_id = $1"},
+	{"outbox", "DELETE FROM outbox WHERE tenant_id = $1"},
+	{"coach_athletes", "DELETE FROM coach_athletes WHERE tenant_id = $1"},
+	{"movement_overrides", "DELETE FROM movement_overrides WHERE tenant_id = $1"},
+	{"movement_redirects", "DELETE FROM movement_redirects WHERE tenant_id = $1"},
+	{"movements", "DELETE FROM movements WHERE tenant_id = $1"},
+	{"users", "DELETE FROM users WHERE tenant_id = $1"},
+	{"tenants", "DELETE FROM tenants WHERE id = $1"},
+}
+
+// CreateTenant implements service.TenantRepository. The tenant and its first
+// admin are written in a single transaction.
+func (m Cockroach) CreateTenant(ctx context.Context, t service.Tenant, admin service.User) error {
+	return m.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO tenants ("+tenantColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7)",
+			t.Name, t.DisplayName, t.State, t.Settings.DefaultUnit, int(t.Settings.WeekStart), t.Settings.TimeZone, t.CreateTime,
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to insert tenant")
+		}
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO users ("+userColumns+") VALUES ($1, $2, $3, $4, $5)",
+			admin.Name, admin.TenantID, admin.DisplayName, admin.Email, admin.Role,
+		)
+		return errors.Wrap(err, "failed to insert tenant admin")
+	})
+}
+
+// GetTenant implements service.TenantRepository.
+func (m Cockroach) GetTenant(ctx context.Context, id string) (service.Tenant, error) {
+	row := m.conn(ctx).QueryRowContext(ctx, "SELECT "+tenantColumns+" FROM tenants WHERE id = $1", id)
+	t, err := scanTenant(row)
+	if err == sql.ErrNoRows {
+		return service.Tenant{}, service.ErrNotFound
+	}
+	if err != nil {
+		return service.Tenant{}, errors.Wrap(err, "failed to select tenant")
+	}
+	return t, nil
+}
+
+// ListTenants implements service.TenantRepository.
+func (m Cockroach) ListTenants(ctx context.Context) ([]service.Tenant, error) {
+	rows, err := m.conn(ctx).QueryContext(ctx, "SELECT "+tenantColumns+" FROM tenants ORDER BY display_name")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select tenants")
+	}
+	defer rows.Close()
+	var tenants []service.Tenant
+	for rows.Next() {
+		t, err := scanTenant(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan tenant")
+		}
+		tenants = append(tenants, t)
+	}
+	return tenants, errors.Wrap(rows.Err(), "failed to iterate tenants")
+}
+
+// UpdateTenant implements service.TenantRepository.
+func (m Cockroach) UpdateTenant(ctx context.Context, t service.Tenant) error {
+	res, err := m.conn(ctx).ExecContext(
+		ctx,
+		`UPDATE tenants SET display_name = $2, state = $3, default_unit = $4, week_start = $5, time_zone = $6
+		WHERE id = $1`,
+		t.Name, t.DisplayName, t.State, t.Settings.DefaultUnit, int(t.Settings.WeekStart), t.Settings.TimeZone,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to update tenant")
+	}
+	return requireAffected(res)
+}
+
+// DeleteTenant implements service.TenantRepository. Every tenant-scoped table
+// is emptied in one transaction and the number of rows removed from each is
+// recorded in tenant_deletions alongside who asked for it and why.
+func (m Cockroach) DeleteTenant(ctx context.Context, d service.TenantDeletion) (service.TenantDeletion, error) {
+	d.RowCounts = make(map[string]int64)
+	err := m.inTx(ctx, func(tx *sql.Tx) error {
+		for _, t := range tenantTables {
+			res, err := tx.ExecContext(ctx, t.query, d.TenantID)
+			if err != nil {
+				return errors.Wrapf(err, "failed to delete from %s", t.table)
+			}
+			if d.RowCounts[t.table], err = res.RowsAffected(); err != nil {
+				return errors.Wrap(err, "failed to read affected rows")
+			}
+		}
+		if d.RowCounts["tenants"] == 0 {
+			return service.ErrNotFound
+		}
+		counts, err := json.Marshal(d.RowCounts)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode row counts")
+		}
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO tenant_deletions (tenant_id, display_name, requested_by, reason, delete_time, row_counts)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			d.TenantID, d.DisplayName, d.RequestedBy, d.Reason, d.DeleteTime, counts,
+		)
+		return errors.Wrap(err, "failed to insert tenant deletion")
+	})
+	if err != nil {
+		return service.TenantDeletion{}, err
+	}
+	return d, nil
+}
+
+func scanTenant(s scanner) (service.Tenant, error) {
+	var (
+		t         service.Tenant
+		weekStart int
+		created   time.Time
+	)
+	err := s.Scan(&t.Name, &t.DisplayName, &t.State, &t.Settings.DefaultUnit, &weekStart, &t.Settings.TimeZone, &created)
+	t.Settings.WeekStart = time.Weekday(weekStart)
+	t.CreateTime = created
+	return t, err
+}
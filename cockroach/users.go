@@ -0,0 +1,137 @@
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+const userColumns = "id, tenant_id, display_name, email, role"
+
+// CreateUser implements service.UserRepository.
+func (m Cockroach) CreateUser(ctx context.Context, u service.User) (service.User, error) {
+	_, err := m.conn(ctx).ExecContext(
+		ctx,
+		"INSERT INTO users ("+userColumns+") VALUES ($1, $2, $3, $4, $5)",
+		u.Name, u.TenantID, u.DisplayName, u.Email, u.Role,
+	)
+	if err != nil {
+		return service.User{}, errors.Wrap(err, "failed to insert user")
+	}
+	return u, nil
+}
+
+// GetUser implements service.UserRepository.
+func (m Cockroach) GetUser(ctx context.Context, id string) (service.User, error) {
+	row := m.conn(ctx).QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1", id)
+	u, err := scanUser(row)
+	if err == sql.ErrNoRows {
+		return service.User{}, service.ErrNotFound
+	}
+	if err != nil {
+		return service.User{}, errors.Wrap(err, "failed to select user")
+	}
+	return u, nil
+}
+
+// ListUsers implements service.UserRepository.
+func (m Cockroach) ListUsers(ctx context.Context, tenantID string, role service.Role) ([]service.User, error) {
+	rows, err := m.conn(ctx).QueryContext(
+		ctx,
+		"SELECT "+userColumns+" FROM users WHERE tenant_id = $1 AND ($2 = '' OR role = $2) ORDER BY display_name",
+		tenantID, role,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select users")
+	}
+	return scanUsers(rows)
+}
+
+// CreateCoaching implements service.UserRepository.
+func (m Cockroach) CreateCoaching(ctx context.Context, tenantID string, coachID string, athleteID string) error {
+	_, err := m.conn(ctx).ExecContext(
+		ctx,
+		"UPSERT INTO coach_athletes (tenant_id, coach_id, athlete_id) VALUES ($1, $2, $3)",
+		tenantID, coachID, athleteID,
+	)
+	return errors.Wrap(err, "failed to insert coach athlete relationship")
+}
+
+// DeleteCoaching implements service.UserRepository.
+func (m Cockroach) DeleteCoaching(ctx context.Context, tenantID string, coachID string, athleteID string) error {
+	res, err := m.conn(ctx).ExecContext(
+		ctx,
+		"DELETE FROM coach_athletes WHERE tenant_id = $1 AND coach_id = $2 AND athlete_id = $3",
+		tenantID, coachID, athleteID,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete coach athlete relationship")
+	}
+	return requireAffected(res)
+}
+
+// ListAthletes implements service.UserRepository.
+func (m Cockroach) ListAthletes(ctx context.Context, tenantID string, coachID string) ([]service.User, error) {
+	rows, err := m.conn(ctx).QueryContext(
+		ctx,
+		`SELECT u.id, u.tenant_id, u.display_name, u.email, u.role
+		FROM coach_athletes ca JOIN users u ON u.id = ca.athlete_id
+		WHERE ca.tenant_id = $1 AND ca.coach_id = $2
+		ORDER BY u.display_name`,
+		tenantID, coachID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select athletes")
+	}
+	return scanUsers(rows)
+}
+
+// IsCoachOf implements service.UserRepository.
+func (m Cockroach) IsCoachOf(ctx context.Context, tenantID string, coachID string, athleteID string) (bool, error) {
+	var exists bool
+	err := m.conn(ctx).QueryRowContext(
+		ctx,
+		"SELECT EXISTS (SELECT 1 FROM coach_athletes WHERE tenant_id = $1 AND coach_id = $2 AND athlete_id = $3)",
+		tenantID, coachID, athleteID,
+	).Scan(&exists)
+	return exists, errors.Wrap(err, "failed to select coach athlete relationship")
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(s scanner) (service.User, error) {
+	var u service.User
+	err := s.Scan(&u.Name, &u.TenantID, &u.DisplayName, &u.Email, &u.Role)
+	return u, err
+}
+
+func scanUsers(rows *sql.Rows) ([]service.User, error) {
+	defer rows.Close()
+	var users []service.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan user")
+		}
+		users = append(users, u)
+	}
+	return users, errors.Wrap(rows.Err(), "failed to iterate users")
+}
+
+// requireAffected converts a statement that touched no rows into
+// service.ErrNotFound.
+func requireAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "failed to read affected rows")
+	}
+	if n == 0 {
+		return service.ErrNotFound
+	}
+	return nil
+}
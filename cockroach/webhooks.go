@@ -0,0 +1,235 @@
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+const (
+	webhookColumns         = "id, tenant_id, url, event_types, description, secret, disabled, create_time, update_time"
+	webhookDeliveryColumns = "id, tenant_id, webhook_id, event_id, event_type, payload, state, failures, attempts, next_attempt_time, create_time, update_time"
+)
+
+// CreateWebhook implements service.WebhookRepository.
+func (m Cockroach) CreateWebhook(ctx context.Context, w service.Webhook) error {
+	_, err := m.conn(ctx).ExecContext(
+		ctx,
+		"INSERT INTO webhooks ("+webhookColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		w.Name, w.TenantID, w.URL, pq.Array(eventTypesToStrings(w.EventTypes)),
+		w.Description, w.Secret, w.Disabled, w.CreateTime, w.UpdateTime,
+	)
+	return errors.Wrap(err, "failed to insert webhook")
+}
+
+// GetWebhook implements service.WebhookRepository.
+func (m Cockroach) GetWebhook(ctx context.Context, id string) (service.Webhook, error) {
+	row := m.conn(ctx).QueryRowContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE id = $1", id)
+	w, err := scanWebhook(row)
+	if err == sql.ErrNoRows {
+		return service.Webhook{}, service.ErrNotFound
+	}
+	if err != nil {
+		return service.Webhook{}, errors.Wrap(err, "failed to select webhook")
+	}
+	return w, nil
+}
+
+// ListWebhooks implements service.WebhookRepository.
+func (m Cockroach) ListWebhooks(ctx context.Context, tenantID string) ([]service.Webhook, error) {
+	rows, err := m.conn(ctx).QueryContext(
+		ctx,
+		"SELECT "+webhookColumns+" FROM webhooks WHERE tenant_id = $1 ORDER BY create_time, id",
+		tenantID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select webhooks")
+	}
+	defer rows.Close()
+	var webhooks []service.Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan webhook")
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, errors.Wrap(rows.Err(), "failed to iterate webhooks")
+}
+
+// UpdateWebhook implements service.WebhookRepository.
+func (m Cockroach) UpdateWebhook(ctx context.Context, w service.Webhook) error {
+	res, err := m.conn(ctx).ExecContext(
+		ctx,
+		`UPDATE webhooks SET url = $2, event_types = $3, description = $4, secret = $5, disabled = $6, update_time = $7
+		WHERE id = $1`,
+		w.Name, w.URL, pq.Array(eventTypesToStrings(w.EventTypes)), w.Description, w.Secret, w.Disabled, w.UpdateTime,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to update webhook")
+	}
+	return requireAffected(res)
+}
+
+// DeleteWebhook implements service.WebhookRepository. Its deliveries are
+// removed by the cascade.
+func (m Cockroach) DeleteWebhook(ctx context.Context, id string) error {
+	res, err := m.conn(ctx).ExecContext(ctx, "DELETE FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete webhook")
+	}
+	return requireAffected(res)
+}
+
+// CreateWebhookDeliveries implements service.WebhookRepository. Deliveries
+// to webhooks deleted in the meantime are dropped as well.
+func (m Cockroach) CreateWebhookDeliveries(ctx context.Context, ds []service.WebhookDelivery) error {
+	return m.inTx(ctx, func(tx *sql.Tx) error {
+		for _, d := range ds {
+			attempts, err := json.Marshal(d.Attempts)
+			if err != nil {
+				return errors.Wrap(err, "failed to encode delivery attempts")
+			}
+			_, err = tx.ExecContext(
+				ctx,
+				`INSERT INTO webhook_deliveries (`+webhookDeliveryColumns+`)
+				SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+				WHERE EXISTS (SELECT 1 FROM webhooks WHERE id = $3)
+				ON CONFLICT (webhook_id, event_id) DO NOTHING`,
+				d.Name, d.TenantID, d.WebhookID, d.EventID, string(d.EventType), string(d.Payload),
+				string(d.State), d.Failures, string(attempts), d.NextAttemptTime, d.CreateTime, d.UpdateTime,
+			)
+			if err != nil {
+				return errors.Wrap(err, "failed to insert webhook delivery")
+			}
+		}
+		return nil
+	})
+}
+
+// GetWebhookDelivery implements service.WebhookRepository.
+func (m Cockroach) GetWebhookDelivery(ctx context.Context, id string) (service.WebhookDelivery, error) {
+	row := m.conn(ctx).QueryRowContext(ctx, "SELECT "+webhookDeliveryColumns+" FROM webhook_deliveries WHERE id = $1", id)
+	d, err := scanWebhookDelivery(row)
+	if err == sql.ErrNoRows {
+		return service.WebhookDelivery{}, service.ErrNotFound
+	}
+	if err != nil {
+		return service.WebhookDelivery{}, errors.Wrap(err, "failed to select webhook delivery")
+	}
+	return d, nil
+}
+
+// ListWebhookDeliveries implements service.WebhookRepository.
+func (m Cockroach) ListWebhookDeliveries(ctx context.Context, tenantID string, filter service.WebhookDeliveryFilter) ([]service.WebhookDelivery, error) {
+	rows, err := m.conn(ctx).QueryContext(
+		ctx,
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		WHERE tenant_id = $1
+		AND ($2 = '' OR webhook_id::STRING = $2)
+		AND ($3 = '' OR state = $3)
+		ORDER BY create_time DESC, id
+		LIMIT $4`,
+		tenantID, filter.WebhookID, string(filter.State), filter.Limit,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select webhook deliveries")
+	}
+	return scanWebhookDeliveries(rows)
+}
+
+// ListDueWebhookDeliveries implements service.WebhookRepository.
+func (m Cockroach) ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]service.WebhookDelivery, error) {
+	rows, err := m.conn(ctx).QueryContext(
+		ctx,
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		WHERE state = $1 AND next_attempt_time <= $2
+		AND webhook_id NOT IN (SELECT id FROM webhooks WHERE disabled)
+		ORDER BY next_attempt_time, id
+		LIMIT $3`,
+		string(service.WebhookDeliveryPending), now, limit,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select due webhook deliveries")
+	}
+	return scanWebhookDeliveries(rows)
+}
+
+// UpdateWebhookDelivery implements service.WebhookRepository.
+func (m Cockroach) UpdateWebhookDelivery(ctx context.Context, d service.WebhookDelivery) error {
+	attempts, err := json.Marshal(d.Attempts)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode delivery attempts")
+	}
+	res, err := m.conn(ctx).ExecContext(
+		ctx,
+		`UPDATE webhook_deliveries SET state = $2, failures = $3, attempts = $4, next_attempt_time = $5, update_time = $6
+		WHERE id = $1`,
+		d.Name, string(d.State), d.Failures, string(attempts), d.NextAttemptTime, d.UpdateTime,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to update webhook delivery")
+	}
+	return requireAffected(res)
+}
+
+func scanWebhook(s scanner) (service.Webhook, error) {
+	var (
+		w          service.Webhook
+		eventTypes []string
+	)
+	err := s.Scan(
+		&w.Name, &w.TenantID, &w.URL, pq.Array(&eventTypes), &w.Description,
+		&w.Secret, &w.Disabled, &w.CreateTime, &w.UpdateTime,
+	)
+	for _, t := range eventTypes {
+		w.EventTypes = append(w.EventTypes, service.DomainEventType(t))
+	}
+	return w, err
+}
+
+func scanWebhookDelivery(s scanner) (service.WebhookDelivery, error) {
+	var (
+		d                 service.WebhookDelivery
+		payload, attempts []byte
+	)
+	err := s.Scan(
+		&d.Name, &d.TenantID, &d.WebhookID, &d.EventID, &d.EventType, &payload,
+		&d.State, &d.Failures, &attempts, &d.NextAttemptTime, &d.CreateTime, &d.UpdateTime,
+	)
+	if err != nil {
+		return service.WebhookDelivery{}, err
+	}
+	d.Payload = payload
+	if err := json.Unmarshal(attempts, &d.Attempts); err != nil {
+		return service.WebhookDelivery{}, errors.Wrap(err, "failed to decode delivery attempts")
+	}
+	return d, nil
+}
+
+func scanWebhookDeliveries(rows *sql.Rows) ([]service.WebhookDelivery, error) {
+	defer rows.Close()
+	var deliveries []service.WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan webhook delivery")
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, errors.Wrap(rows.Err(), "failed to iterate webhook deliveries")
+}
+
+func eventTypesToStrings(types []service.DomainEventType) []string {
+	s := make([]string, len(types))
+	for i, t := range types {
+		s[i] = string(t)
+	}
+	return s
+}
@@ -0,0 +1,364 @@
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+const workoutColumns = "id, tenant_id, athlete_id, title, performed_at, session_rpe, duration_ms, planned"
+
+// CreateWorkout implements service.WorkoutRepository. The workout and its
+// sets are written in a single transaction.
+func (m Cockroach) CreateWorkout(ctx context.Context, w service.Workout) (service.Workout, error) {
+	err := m.inTx(ctx, func(tx *sql.Tx) error {
+		if err := insertWorkout(ctx, tx, w); err != nil {
+			return err
+		}
+		return insertOutboxEvents(ctx, tx, service.NewWorkoutDomainEvent(service.WorkoutCreatedEvent, w))
+	})
+	if err != nil {
+		return service.Workout{}, err
+	}
+	return w, nil
+}
+
+// CreateWorkouts implements service.WorkoutRepository. Every workout and
+// set is written in a single transaction.
+func (m Cockroach) CreateWorkouts(ctx context.Context, ws []service.Workout) ([]service.Workout, error) {
+	err := m.inTx(ctx, func(tx *sql.Tx) error {
+		for _, w := range ws {
+			if err := insertWorkout(ctx, tx, w); err != nil {
+				return errors.Wrapf(err, "workout %s", w.Name)
+			}
+			if err := insertOutboxEvents(ctx, tx, service.NewWorkoutDomainEvent(service.WorkoutCreatedEvent, w)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+// GetWorkout implements service.WorkoutRepository.
+func (m Cockroach) GetWorkout(ctx context.Context, id string) (service.Workout, error) {
+	var (
+		w  service.Workout
+		ms int64
+	)
+	err := m.conn(ctx).QueryRowContext(
+		ctx,
+		"SELECT "+workoutColumns+" FROM workouts WHERE id = $1",
+		id,
+	).Scan(&w.Name, &w.TenantID, &w.AthleteID, &w.Title, &w.PerformedAt, &w.SessionRPE, &ms, &w.Planned)
+	if err == sql.ErrNoRows {
+		return service.Workout{}, service.ErrNotFound
+	}
+	if err != nil {
+		return service.Workout{}, errors.Wrap(err, "failed to select workout")
+	}
+	w.Duration = time.Duration(ms) * time.Millisecond
+	if err := m.selectDetails(ctx, &w); err != nil {
+		return service.Workout{}, err
+	}
+	return w, nil
+}
+
+// ListWorkouts implements service.WorkoutRepository.
+func (m Cockroach) ListWorkouts(ctx context.Context, tenantID string, athleteID string) ([]service.Workout, error) {
+	rows, err := m.conn(ctx).QueryContext(
+		ctx,
+		`SELECT `+workoutColumns+` FROM workouts
+		WHERE tenant_id = $1 AND athlete_id = $2
+		ORDER BY performed_at DESC`,
+		tenantID, athleteID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select workouts")
+	}
+	defer rows.Close()
+	var workouts []service.Workout
+	for rows.Next() {
+		var (
+			w  service.Workout
+			ms int64
+		)
+		if err := rows.Scan(&w.Name, &w.TenantID, &w.AthleteID, &w.Title, &w.PerformedAt, &w.SessionRPE, &ms, &w.Planned); err != nil {
+			return nil, errors.Wrap(err, "failed to scan workout")
+		}
+		w.Duration = time.Duration(ms) * time.Millisecond
+		workouts = append(workouts, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to iterate workouts")
+	}
+	for i := range workouts {
+		if err := m.selectDetails(ctx, &workouts[i]); err != nil {
+			return nil, err
+		}
+	}
+	return workouts, nil
+}
+
+// ListPerformedWorkouts implements service.WorkoutRepository. It reads
+// workouts only, not their sets, so that checking for duplicates stays cheap
+// however long the athlete's history is.
+func (m Cockroach) ListPerformedWorkouts(ctx context.Context, tenantID string, athleteID string, from time.Time, to time.Time) ([]service.Workout, error) {
+	rows, err := m.conn(ctx).QueryContext(
+		ctx,
+		`SELECT `+workoutColumns+` FROM workouts
+		WHERE tenant_id = $1 AND athlete_id = $2 AND performed_at >= $3 AND performed_at < $4 AND NOT planned
+		ORDER BY performed_at DESC`,
+		tenantID, athleteID, from, to,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select workouts")
+	}
+	defer rows.Close()
+	var workouts []service.Workout
+	for rows.Next() {
+		var (
+			w  service.Workout
+			ms int64
+		)
+		if err := rows.Scan(&w.Name, &w.TenantID, &w.AthleteID, &w.Title, &w.PerformedAt, &w.SessionRPE, &ms, &w.Planned); err != nil {
+			return nil, errors.Wrap(err, "failed to scan workout")
+		}
+		w.Duration = time.Duration(ms) * time.Millisecond
+		workouts = append(workouts, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to iterate workouts")
+	}
+	return workouts, nil
+}
+
+// RateWorkout implements service.WorkoutRepository. The outbox event
+// carries the workout as rated.
+func (m Cockroach) RateWorkout(ctx context.Context, w service.Workout) error {
+	return m.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(
+			ctx,
+			"UPDATE workouts SET session_rpe = $2, duration_ms = $3 WHERE id = $1",
+			w.Name, w.SessionRPE, milliseconds(w.Duration),
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to rate workout")
+		}
+		if err := requireAffected(res); err != nil {
+			return err
+		}
+		return insertOutboxEvents(ctx, tx, service.NewWorkoutDomainEvent(service.WorkoutRatedEvent, w))
+	})
+}
+
+// DeleteWorkout implements service.WorkoutRepository. Sets, conditioning and
+// laps are removed by the foreign keys' ON DELETE CASCADE. Only the session
+// rating of a workout changes once it is recorded, so the one read before
+// the deletion is what the outbox event carries.
+func (m Cockroach) DeleteWorkout(ctx context.Context, id string) error {
+	w, err := m.GetWorkout(ctx, id)
+	if err != nil {
+		return err
+	}
+	return m.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM workouts WHERE id = $1", id)
+		if err != nil {
+			return errors.Wrap(err, "failed to delete workout")
+		}
+		if err := requireAffected(res); err != nil {
+			return err
+		}
+		return insertOutboxEvents(ctx, tx, service.NewWorkoutDomainEvent(service.WorkoutDeletedEvent, w))
+	})
+}
+
+func insertWorkout(ctx context.Context, tx *sql.Tx, w service.Workout) error {
+	_, err := tx.ExecContext(
+		ctx,
+		"INSERT INTO workouts ("+workoutColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		w.Name, w.TenantID, w.AthleteID, w.Title, w.PerformedAt, w.SessionRPE, milliseconds(w.Duration), w.Planned,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to insert workout")
+	}
+	for i, set := range w.Sets {
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO workout_sets (workout_id, position, movement_id, reps, weight, weight_unit, rpe, block) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+			w.Name, i, set.MovementID, set.Reps, set.Weight.Kilograms, set.Weight.Unit, set.RPE, set.Block,
+		)
+		if err != nil {
+			return errors.Wrapf(err, "failed to insert set %d", i)
+		}
+	}
+	for i, b := range w.Blocks {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO workout_blocks
+			(workout_id, position, label, type, rounds, interval_ms, time_cap_ms, score_rounds, score_reps, score_time_ms)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			w.Name, i, b.Label, string(b.Type), b.Rounds, milliseconds(b.Interval), milliseconds(b.TimeCap),
+			b.Score.Rounds, b.Score.Reps, milliseconds(b.Score.Time),
+		)
+		if err != nil {
+			return errors.Wrapf(err, "failed to insert block %s", b.Label)
+		}
+	}
+	for i, c := range w.Conditioning {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO workout_conditioning
+			(workout_id, position, movement_id, sport, start_time, duration_ms, distance, avg_heart_rate, max_heart_rate)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			w.Name, i, c.MovementID, c.Sport, c.StartTime, milliseconds(c.Duration), c.Distance, c.AvgHeartRate, c.MaxHeartRate,
+		)
+		if err != nil {
+			return errors.Wrapf(err, "failed to insert conditioning %d", i)
+		}
+		for j, l := range c.Laps {
+			_, err := tx.ExecContext(
+				ctx,
+				`INSERT INTO workout_laps
+				(workout_id, conditioning_position, position, start_time, duration_ms, distance, avg_heart_rate, max_heart_rate)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+				w.Name, i, j, l.StartTime, milliseconds(l.Duration), l.Distance, l.AvgHeartRate, l.MaxHeartRate,
+			)
+			if err != nil {
+				return errors.Wrapf(err, "failed to insert lap %d of conditioning %d", j, i)
+			}
+		}
+	}
+	return nil
+}
+
+func milliseconds(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
+
+// selectDetails fills in the sets, blocks and conditioning of a workout.
+func (m Cockroach) selectDetails(ctx context.Context, w *service.Workout) error {
+	var err error
+	if w.Sets, err = m.selectSets(ctx, w.Name); err != nil {
+		return err
+	}
+	if w.Blocks, err = m.selectBlocks(ctx, w.Name); err != nil {
+		return err
+	}
+	w.Conditioning, err = m.selectConditioning(ctx, w.Name)
+	return err
+}
+
+func (m Cockroach) selectSets(ctx context.Context, workoutID string) ([]service.WorkoutSet, error) {
+	rows, err := m.conn(ctx).QueryContext(
+		ctx,
+		"SELECT movement_id, reps, weight, weight_unit, rpe, block FROM workout_sets WHERE workout_id = $1 ORDER BY position",
+		workoutID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select sets")
+	}
+	defer rows.Close()
+	var sets []service.WorkoutSet
+	for rows.Next() {
+		var set service.WorkoutSet
+		if err := rows.Scan(&set.MovementID, &set.Reps, &set.Weight.Kilograms, &set.Weight.Unit, &set.RPE, &set.Block); err != nil {
+			return nil, errors.Wrap(err, "failed to scan set")
+		}
+		sets = append(sets, set)
+	}
+	return sets, errors.Wrap(rows.Err(), "failed to iterate sets")
+}
+
+func (m Cockroach) selectBlocks(ctx context.Context, workoutID string) ([]service.WorkoutBlock, error) {
+	rows, err := m.conn(ctx).QueryContext(
+		ctx,
+		`SELECT label, type, rounds, interval_ms, time_cap_ms, score_rounds, score_reps, score_time_ms
+		FROM workout_blocks WHERE workout_id = $1 ORDER BY position`,
+		workoutID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select blocks")
+	}
+	defer rows.Close()
+	var blocks []service.WorkoutBlock
+	for rows.Next() {
+		var (
+			b                          service.WorkoutBlock
+			interval, timeCap, scoreMS int64
+		)
+		if err := rows.Scan(&b.Label, &b.Type, &b.Rounds, &interval, &timeCap, &b.Score.Rounds, &b.Score.Reps, &scoreMS); err != nil {
+			return nil, errors.Wrap(err, "failed to scan block")
+		}
+		b.Interval = time.Duration(interval) * time.Millisecond
+		b.TimeCap = time.Duration(timeCap) * time.Millisecond
+		b.Score.Time = time.Duration(scoreMS) * time.Millisecond
+		blocks = append(blocks, b)
+	}
+	return blocks, errors.Wrap(rows.Err(), "failed to iterate blocks")
+}
+
+func (m Cockroach) selectConditioning(ctx context.Context, workoutID string) ([]service.Conditioning, error) {
+	rows, err := m.conn(ctx).QueryContext(
+		ctx,
+		`SELECT movement_id, sport, start_time, duration_ms, distance, avg_heart_rate, max_heart_rate
+		FROM workout_conditioning WHERE workout_id = $1 ORDER BY position`,
+		workoutID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select conditioning")
+	}
+	defer rows.Close()
+	var conditioning []service.Conditioning
+	for rows.Next() {
+		var (
+			c  service.Conditioning
+			ms int64
+		)
+		if err := rows.Scan(&c.MovementID, &c.Sport, &c.StartTime, &ms, &c.Distance, &c.AvgHeartRate, &c.MaxHeartRate); err != nil {
+			return nil, errors.Wrap(err, "failed to scan conditioning")
+		}
+		c.Duration = time.Duration(ms) * time.Millisecond
+		conditioning = append(conditioning, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to iterate conditioning")
+	}
+	if len(conditioning) == 0 {
+		return nil, nil
+	}
+
+	laps, err := m.conn(ctx).QueryContext(
+		ctx,
+		`SELECT conditioning_position, start_time, duration_ms, distance, avg_heart_rate, max_heart_rate
+		FROM workout_laps WHERE workout_id = $1 ORDER BY conditioning_position, position`,
+		workoutID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select laps")
+	}
+	defer laps.Close()
+	for laps.Next() {
+		var (
+			i  int
+			l  service.Lap
+			ms int64
+		)
+		if err := laps.Scan(&i, &l.StartTime, &ms, &l.Distance, &l.AvgHeartRate, &l.MaxHeartRate); err != nil {
+			return nil, errors.Wrap(err, "failed to scan lap")
+		}
+		if i < 0 || i >= len(conditioning) {
+			continue
+		}
+		l.Duration = time.Duration(ms) * time.Millisecond
+		conditioning[i].Laps = append(conditioning[i].Laps, l)
+	}
+	return conditioning, errors.Wrap(laps.Err(), "failed to iterate laps")
+}
@@ -6,6 +6,7 @@ require (
 	github.com/go-logfmt/logfmt v0.4.0 // indirect
 	github.com/go-stack/stack v1.8.0 // indirect
 	github.com/golang/protobuf v1.2.0
+	github.com/google/uuid v1.1.0
 	github.com/lib/pq v1.0.0
 	github.com/magefile/mage v1.8.0
 	github.com/pkg/errors v0.8.0
@@ -0,0 +1,180 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+// volumeKey identifies one bucket and group of a volume query.
+type volumeKey struct {
+	bucket time.Time
+	group  string
+}
+
+// volumeSums accumulates the sets of one bucket and group.
+type volumeSums struct {
+	aggregate                service.VolumeAggregate
+	loadedReps, relativeReps int64
+	relativeSum              float64
+}
+
+// AggregateVolume implements service.AnalyticsRepository.
+func (s *Store) AggregateVolume(_ context.Context, q service.VolumeQuery) ([]service.VolumeAggregate, error) {
+	loc, err := time.LoadLocation(q.TimeZone)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load time zone %q", q.TimeZone)
+	}
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	var workouts []service.Workout
+	for _, w := range s.workouts {
+		if w.TenantID == q.TenantID && w.AthleteID == q.AthleteID && w.PerformedAt.Before(q.End) && !w.Planned {
+			workouts = append(workouts, w)
+		}
+	}
+	sort.Slice(workouts, func(i, j int) bool {
+		if !workouts[i].PerformedAt.Equal(workouts[j].PerformedAt) {
+			return workouts[i].PerformedAt.Before(workouts[j].PerformedAt)
+		}
+		return workouts[i].Name < workouts[j].Name
+	})
+
+	forks := make(map[string]service.Movement)
+	for _, m := range s.movements {
+		if m.TenantID == q.TenantID && m.ForkedFrom != "" {
+			forks[m.ForkedFrom] = m
+		}
+	}
+	e1RMs := make(map[string]float64)
+	sums := make(map[volumeKey]*volumeSums)
+	for _, w := range workouts {
+		bucket := q.Bucket.StartOf(w.PerformedAt, loc, q.WeekStart)
+		for _, set := range w.Sets {
+			m := s.resolveMovement(forks, set.MovementID)
+			if e := service.EstimateOneRepMax(set.Weight.Kilograms, set.Reps); e > e1RMs[m.Name] {
+				e1RMs[m.Name] = e
+			}
+			if w.PerformedAt.Before(q.Start) {
+				continue
+			}
+			var groups []string
+			switch q.GroupBy {
+			case service.GroupByMovement:
+				groups = []string{m.Name}
+			case service.GroupByCategory:
+				groups = []string{m.MovementCategoryID}
+			case service.GroupByMuscle:
+				for _, g := range m.PrimaryMuscles {
+					groups = append(groups, string(g))
+				}
+				if len(groups) == 0 {
+					groups = []string{""}
+				}
+			default:
+				groups = []string{""}
+			}
+			for _, g := range groups {
+				key := volumeKey{bucket, g}
+				sum, ok := sums[key]
+				if !ok {
+					sum = &volumeSums{aggregate: service.VolumeAggregate{BucketStart: bucket, Group: g}}
+					sums[key] = sum
+				}
+				sum.add(set, e1RMs[m.Name])
+			}
+		}
+	}
+
+	aggregates := make([]service.VolumeAggregate, 0, len(sums))
+	for _, sum := range sums {
+		a := sum.aggregate
+		if sum.loadedReps > 0 {
+			a.AverageIntensity = a.Tonnage / float64(sum.loadedReps)
+		}
+		if sum.relativeReps > 0 {
+			a.RelativeIntensity = sum.relativeSum / float64(sum.relativeReps)
+		}
+		aggregates = append(aggregates, a)
+	}
+	sort.Slice(aggregates, func(i, j int) bool {
+		if !aggregates[i].BucketStart.Equal(aggregates[j].BucketStart) {
+			return aggregates[i].BucketStart.Before(aggregates[j].BucketStart)
+		}
+		return aggregates[i].Group < aggregates[j].Group
+	})
+	return aggregates, nil
+}
+
+func (sum *volumeSums) add(set service.WorkoutSet, e1RM float64) {
+	a := &sum.aggregate
+	a.Sets++
+	if service.IsHardSet(set, e1RM) {
+		a.HardSets++
+	}
+	a.Reps += int64(set.Reps)
+	a.Tonnage += set.Weight.Kilograms * float64(set.Reps)
+	if set.Weight.Kilograms > 0 {
+		sum.loadedReps += int64(set.Reps)
+		if e1RM > 0 {
+			sum.relativeReps += int64(set.Reps)
+			sum.relativeSum += set.Weight.Kilograms / e1RM * float64(set.Reps)
+		}
+	}
+}
+
+// resolveMovement returns the movement a set refers to, resolved to the
+// tenant's fork of it when there is one. Movements that no longer exist are
+// returned with only their ID.
+func (s *Store) resolveMovement(forks map[string]service.Movement, id string) service.Movement {
+	if m, ok := forks[id]; ok {
+		return m
+	}
+	if m, ok := s.movements[id]; ok {
+		return m
+	}
+	return service.Movement{Name: id}
+}
+
+// ListSessionLoads implements service.AnalyticsRepository.
+func (s *Store) ListSessionLoads(_ context.Context, tenantID string, athleteID string, end time.Time) ([]service.SessionLoad, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var loads []service.SessionLoad
+	for _, w := range s.workouts {
+		if w.TenantID == tenantID && w.AthleteID == athleteID && w.PerformedAt.Before(end) && !w.Planned {
+			loads = append(loads, service.SessionLoadOf(w))
+		}
+	}
+	sort.Slice(loads, func(i, j int) bool {
+		if !loads[i].PerformedAt.Equal(loads[j].PerformedAt) {
+			return loads[i].PerformedAt.Before(loads[j].PerformedAt)
+		}
+		return loads[i].WorkoutID < loads[j].WorkoutID
+	})
+	return loads, nil
+}
+
+// GetWorkloadThresholds implements service.AnalyticsRepository.
+func (s *Store) GetWorkloadThresholds(_ context.Context, tenantID string) (service.WorkloadThresholds, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	t, ok := s.thresholds[tenantID]
+	if !ok {
+		return service.WorkloadThresholds{}, service.ErrNotFound
+	}
+	return t, nil
+}
+
+// SetWorkloadThresholds implements service.AnalyticsRepository.
+func (s *Store) SetWorkloadThresholds(_ context.Context, t service.WorkloadThresholds) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.thresholds[t.TenantID] = t
+	return nil
+}
@@ -0,0 +1,50 @@
+package inmem
+
+import (
+	"context"
+
+	"workout-manager-service/pkg/service"
+)
+
+type txContextKey struct{}
+
+// InTx implements service.AuditRepository. The store cannot roll writes
+// back, so InTx only runs one fn at a time, which keeps an audited call's
+// snapshots consistent with its change. The events CreateAuditEvent records
+// cannot fail to be written.
+func (s *Store) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txContextKey{}) != nil {
+		return fn(ctx)
+	}
+	s.txMtx.Lock()
+	defer s.txMtx.Unlock()
+	return fn(context.WithValue(ctx, txContextKey{}, true))
+}
+
+// CreateAuditEvent implements service.AuditRepository.
+func (s *Store) CreateAuditEvent(_ context.Context, e service.AuditEvent) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.audit = append(s.audit, e)
+	return nil
+}
+
+// ListAuditEvents implements service.AuditRepository. Events are appended in
+// time order, so walking backwards yields the newest first.
+func (s *Store) ListAuditEvents(_ context.Context, tenantID string, filter service.AuditFilter) ([]service.AuditEvent, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var events []service.AuditEvent
+	for i := len(s.audit) - 1; i >= 0 && len(events) < filter.Limit; i-- {
+		e := s.audit[i]
+		switch {
+		case e.TenantID != tenantID:
+		case filter.Resource != "" && e.Resource != filter.Resource:
+		case !filter.Start.IsZero() && e.Time.Before(filter.Start):
+		case !filter.End.IsZero() && !e.Time.Before(filter.End):
+		default:
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
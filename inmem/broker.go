@@ -0,0 +1,45 @@
+package inmem
+
+import (
+	"context"
+	"sync"
+
+	"workout-manager-service/pkg/service"
+)
+
+// Broker implements service.Broker within a single process, for running the
+// server without a message broker and for trying consumers out locally.
+// Handlers are called in turn as each event is published; an error from any
+// of them fails the publication, so the outbox relay retries the event as it
+// would after a broker outage. Events published with no handlers are
+// dropped.
+type Broker struct {
+	mtx      sync.RWMutex
+	handlers []func(context.Context, service.DomainEvent) error
+}
+
+// NewBroker returns a Broker without handlers.
+func NewBroker() *Broker {
+	return &Broker{}
+}
+
+// Subscribe adds a handler that receives every event published from now on.
+// Handlers see a redelivered event again and should ignore IDs they have
+// handled.
+func (b *Broker) Subscribe(handler func(context.Context, service.DomainEvent) error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish implements service.Broker.
+func (b *Broker) Publish(ctx context.Context, e service.DomainEvent) error {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+	for _, h := range b.handlers {
+		if err := h(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package inmem
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+// EventBus implements service.EventBus within a single process. It numbers
+// events in the order they are published and keeps the most recent ones so
+// that watchers can resume. Resume tokens name the bus they were issued by,
+// so a token from before a restart is reported as expired rather than
+// silently resuming at the wrong place. Only writes made through the same
+// process are published to it, so watching is limited to a single instance.
+type EventBus struct {
+	mtx     sync.Mutex
+	epoch   string
+	seq     uint64
+	history []service.MovementEvent
+	size    int
+	subs    map[*subscription]bool
+}
+
+// NewEventBus returns an EventBus that keeps the given number of recent
+// events. A subscriber that falls that far behind is dropped.
+func NewEventBus(history int) *EventBus {
+	if history < 1 {
+		history = 1
+	}
+	return &EventBus{
+		epoch: strings.Replace(uuid.New().String(), "-", "", -1),
+		size:  history,
+		subs:  make(map[*subscription]bool),
+	}
+}
+
+func (b *EventBus) token(seq uint64) string {
+	return b.epoch + "." + strconv.FormatUint(seq, 10)
+}
+
+// Publish implements service.EventBus.
+func (b *EventBus) Publish(_ context.Context, events ...service.MovementEvent) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	for _, e := range events {
+		b.seq++
+		e.ResumeToken = b.token(b.seq)
+		if e.Time.IsZero() {
+			e.Time = time.Now().UTC()
+		}
+		b.history = append(b.history, e)
+		if len(b.history) > b.size {
+			b.history = append(b.history[:0], b.history[len(b.history)-b.size:]...)
+		}
+		for sub := range b.subs {
+			if sub.wants(e) {
+				sub.push(e, b.size)
+			}
+		}
+	}
+}
+
+// Subscribe implements service.EventBus. Events published after the resume
+// token are replayed before live ones.
+func (b *EventBus) Subscribe(_ context.Context, tenantID string, resumeToken string) (service.Subscription, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	sub := &subscription{
+		bus:      b,
+		tenantID: tenantID,
+		token:    b.token(b.seq),
+		notify:   make(chan struct{}, 1),
+	}
+	if resumeToken != "" {
+		seq, err := b.parseToken(resumeToken)
+		if err != nil {
+			return nil, err
+		}
+		oldest := b.seq - uint64(len(b.history))
+		if seq < oldest {
+			return nil, errors.Wrap(service.ErrExpired, "resume token is too old; list the catalog again and start a new watch")
+		}
+		for _, e := range b.history[seq-oldest:] {
+			if sub.wants(e) {
+				sub.queue = append(sub.queue, e)
+			}
+		}
+		sub.token = resumeToken
+	}
+	b.subs[sub] = true
+	return sub, nil
+}
+
+// parseToken returns the sequence number of a resume token issued by b.
+func (b *EventBus) parseToken(token string) (uint64, error) {
+	i := strings.LastIndex(token, ".")
+	if i < 0 {
+		return 0, errors.Wrap(service.ErrInvalidArgument, "malformed resume token")
+	}
+	seq, err := strconv.ParseUint(token[i+1:], 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(service.ErrInvalidArgument, "malformed resume token")
+	}
+	if token[:i] != b.epoch {
+		return 0, errors.Wrap(service.ErrExpired, "resume token was issued before the server restarted")
+	}
+	if seq > b.seq {
+		return 0, errors.Wrap(service.ErrInvalidArgument, "resume token is from the future")
+	}
+	return seq, nil
+}
+
+type subscription struct {
+	bus      *EventBus
+	tenantID string
+	token    string
+	notify   chan struct{}
+
+	// queue and expired are guarded by the bus's lock.
+	queue   []service.MovementEvent
+	expired bool
+}
+
+// wants reports whether an event is for the subscriber's tenant or for every
+// tenant.
+func (s *subscription) wants(e service.MovementEvent) bool {
+	return e.TenantID == s.tenantID || e.TenantID == service.SystemTenantID
+}
+
+func (s *subscription) push(e service.MovementEvent, max int) {
+	if s.expired {
+		return
+	}
+	if len(s.queue) >= max {
+		s.queue, s.expired = nil, true
+	} else {
+		s.queue = append(s.queue, e)
+	}
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Next implements service.Subscription.
+func (s *subscription) Next(ctx context.Context) (service.MovementEvent, error) {
+	for {
+		s.bus.mtx.Lock()
+		switch {
+		case s.expired:
+			s.bus.mtx.Unlock()
+			return service.MovementEvent{}, errors.Wrap(service.ErrExpired, "watcher fell too far behind; resume from the last event received")
+		case len(s.queue) > 0:
+			e := s.queue[0]
+			s.queue = s.queue[1:]
+			s.bus.mtx.Unlock()
+			return e, nil
+		}
+		s.bus.mtx.Unlock()
+		select {
+		case <-s.notify:
+		case <-ctx.Done():
+			return service.MovementEvent{}, ctx.Err()
+		}
+	}
+}
+
+// ResumeToken implements service.Subscription.
+func (s *subscription) ResumeToken() string {
+	return s.token
+}
+
+// Close implements service.Subscription.
+func (s *subscription) Close() {
+	s.bus.mtx.Lock()
+	defer s.bus.mtx.Unlock()
+	delete(s.bus.subs, s)
+	s.queue = nil
+}
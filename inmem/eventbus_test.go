@@ -0,0 +1,151 @@
+package inmem
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+func movementEvent(tenantID, movementID string) service.MovementEvent {
+	return service.MovementEvent{Type: service.MovementUpdated, TenantID: tenantID, Movement: service.Movement{Name: movementID}}
+}
+
+// nextMovements returns the movements of the next n events of sub.
+func nextMovements(t *testing.T, sub service.Subscription, n int) []string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var names []string
+	for i := 0; i < n; i++ {
+		e, err := sub.Next(ctx)
+		if err != nil {
+			t.Fatalf("Next() after %v: %v", names, err)
+		}
+		names = append(names, e.Movement.Name)
+	}
+	return names
+}
+
+func TestEventBusDeliversTheTenantsEvents(t *testing.T) {
+	ctx := context.Background()
+	b := NewEventBus(10)
+	sub, err := b.Subscribe(ctx, "t1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	b.Publish(ctx,
+		movementEvent("t1", "squat"),
+		movementEvent("t2", "press"),
+		movementEvent(service.SystemTenantID, "deadlift"),
+	)
+	if got, want := nextMovements(t, sub, 2), []string{"squat", "deadlift"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if e, err := sub.Next(short); err != context.DeadlineExceeded {
+		t.Errorf("Next() = %+v, %v, want to wait for the deadline", e, err)
+	}
+}
+
+func TestEventBusResumes(t *testing.T) {
+	ctx := context.Background()
+	b := NewEventBus(10)
+	first, err := b.Subscribe(ctx, "t1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := first.ResumeToken()
+	b.Publish(ctx, movementEvent("t1", "squat"), movementEvent("t2", "press"), movementEvent("t1", "bench"))
+	ctx2, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	squat, err := first.Next(ctx2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Close()
+	b.Publish(ctx, movementEvent("t1", "row"))
+
+	for _, tc := range []struct {
+		name  string
+		token string
+		want  []string
+	}{
+		{"from the start of a watch", start, []string{"squat", "bench", "row"}},
+		{"after an event", squat.ResumeToken, []string{"bench", "row"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sub, err := b.Subscribe(ctx, "t1", tc.token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sub.Close()
+			if sub.ResumeToken() != tc.token {
+				t.Errorf("ResumeToken() = %q, want %q", sub.ResumeToken(), tc.token)
+			}
+			if got := nextMovements(t, sub, len(tc.want)); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("events = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEventBusRejectsResumeTokens(t *testing.T) {
+	ctx := context.Background()
+	b := NewEventBus(2)
+	sub, err := b.Subscribe(ctx, "t1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub.Close()
+	b.Publish(ctx, movementEvent("t1", "squat"), movementEvent("t1", "bench"), movementEvent("t1", "row"))
+
+	other := NewEventBus(2)
+	other.Publish(ctx, movementEvent("t1", "squat"))
+	for _, tc := range []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"older than the history", sub.ResumeToken(), service.ErrExpired},
+		{"from another bus", other.token(1), service.ErrExpired},
+		{"from the future", b.token(4), service.ErrInvalidArgument},
+		{"without a sequence", b.epoch, service.ErrInvalidArgument},
+		{"with a malformed sequence", b.epoch + ".x", service.ErrInvalidArgument},
+	} {
+		if _, err := b.Subscribe(ctx, "t1", tc.token); errors.Cause(err) != tc.want {
+			t.Errorf("%s: Subscribe() = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+	if _, err := b.Subscribe(ctx, "t1", b.token(1)); err != nil {
+		t.Errorf("Subscribe() from the oldest event kept = %v, want nil", err)
+	}
+}
+
+func TestEventBusExpiresSlowSubscribers(t *testing.T) {
+	ctx := context.Background()
+	b := NewEventBus(2)
+	slow, err := b.Subscribe(ctx, "t1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slow.Close()
+	// Events of other tenants do not count towards falling behind.
+	b.Publish(ctx, movementEvent("t1", "squat"), movementEvent("t2", "press"), movementEvent("t1", "bench"))
+	if got, want := nextMovements(t, slow, 1), []string{"squat"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+
+	b.Publish(ctx, movementEvent("t1", "row"), movementEvent("t1", "deadlift"))
+	if _, err := slow.Next(ctx); errors.Cause(err) != service.ErrExpired {
+		t.Errorf("Next() = %v, want %v", err, service.ErrExpired)
+	}
+}
@@ -0,0 +1,48 @@
+package inmem
+
+import (
+	"context"
+	"time"
+
+	"workout-manager-service/pkg/service"
+)
+
+// ReserveIdempotencyKey implements service.IdempotencyRepository. Expired
+// records are swept on every reservation so the map stays bounded by the
+// replay window.
+func (s *Store) ReserveIdempotencyKey(_ context.Context, rec service.IdempotencyRecord) (service.IdempotencyRecord, bool, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	now := time.Now()
+	for key, r := range s.idempotency {
+		if !r.ExpireTime.After(now) {
+			delete(s.idempotency, key)
+		}
+	}
+	if existing, ok := s.idempotency[rec.Key]; ok {
+		return existing, false, nil
+	}
+	s.idempotency[rec.Key] = rec
+	return rec, true, nil
+}
+
+// CompleteIdempotencyKey implements service.IdempotencyRepository.
+func (s *Store) CompleteIdempotencyKey(_ context.Context, key string, response []byte, expireTime time.Time) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	rec, ok := s.idempotency[key]
+	if !ok {
+		return service.ErrNotFound
+	}
+	rec.Response, rec.Complete, rec.ExpireTime = response, true, expireTime
+	s.idempotency[key] = rec
+	return nil
+}
+
+// ReleaseIdempotencyKey implements service.IdempotencyRepository.
+func (s *Store) ReleaseIdempotencyKey(_ context.Context, key string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.idempotency, key)
+	return nil
+}
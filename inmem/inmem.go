@@ -0,0 +1,59 @@
+// Package inmem provides in-process implementations of the service
+// repositories. It is intended for local development and for running the
+// server without a database; nothing is persisted across restarts.
+package inmem
+
+import (
+	"sync"
+
+	"workout-manager-service/pkg/service"
+)
+
+// Store holds every repository's data in memory, guarded by a single lock.
+type Store struct {
+	mtx         sync.RWMutex
+	txMtx       sync.Mutex
+	tenants     map[string]service.Tenant
+	deletions   []service.TenantDeletion
+	users       map[string]service.User
+	coaching    map[string]map[string]bool
+	movements   map[string]service.Movement
+	overrides   map[movementKey]service.MovementOverride
+	redirects   map[movementKey]string
+	workouts    map[string]service.Workout
+	audit       []service.AuditEvent
+	outbox      []service.DomainEvent
+	webhooks    map[string]service.Webhook
+	deliveries  map[string]service.WebhookDelivery
+	idempotency map[string]service.IdempotencyRecord
+	thresholds  map[string]service.WorkloadThresholds
+	metrics     map[string]service.BodyMetric
+	inventories map[string]service.PlateInventory
+	templates   map[string]service.WorkoutTemplate
+}
+
+// movementKey identifies a movement as seen from one tenant.
+type movementKey struct {
+	tenantID   string
+	movementID string
+}
+
+// NewStore returns an empty Store.
+func NewStore() *Store {
+	return &Store{
+		tenants:     make(map[string]service.Tenant),
+		users:       make(map[string]service.User),
+		coaching:    make(map[string]map[string]bool),
+		movements:   make(map[string]service.Movement),
+		overrides:   make(map[movementKey]service.MovementOverride),
+		redirects:   make(map[movementKey]string),
+		workouts:    make(map[string]service.Workout),
+		webhooks:    make(map[string]service.Webhook),
+		deliveries:  make(map[string]service.WebhookDelivery),
+		idempotency: make(map[string]service.IdempotencyRecord),
+		thresholds:  make(map[string]service.WorkloadThresholds),
+		metrics:     make(map[string]service.BodyMetric),
+		inventories: make(map[string]service.PlateInventory),
+		templates:   make(map[string]service.WorkoutTemplate),
+	}
+}
@@ -0,0 +1,31 @@
+package inmem
+
+import (
+	"context"
+
+	"workout-manager-service/pkg/service"
+)
+
+// GetPlateInventory implements service.LoadingRepository.
+func (s *Store) GetPlateInventory(_ context.Context, tenantID string) (service.PlateInventory, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	inv, ok := s.inventories[tenantID]
+	if !ok {
+		return service.PlateInventory{}, service.ErrNotFound
+	}
+	return copyPlateInventory(inv), nil
+}
+
+// SetPlateInventory implements service.LoadingRepository.
+func (s *Store) SetPlateInventory(_ context.Context, inv service.PlateInventory) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.inventories[inv.TenantID] = copyPlateInventory(inv)
+	return nil
+}
+
+func copyPlateInventory(inv service.PlateInventory) service.PlateInventory {
+	inv.Plates = append([]service.Plate(nil), inv.Plates...)
+	return inv
+}
@@ -0,0 +1,74 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+
+	"workout-manager-service/pkg/service"
+)
+
+// CreateBodyMetric implements service.MetricsRepository.
+func (s *Store) CreateBodyMetric(_ context.Context, m service.BodyMetric) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.metrics[m.Name]; ok {
+		return service.ErrAlreadyExists
+	}
+	s.metrics[m.Name] = copyBodyMetric(m)
+	return nil
+}
+
+// GetBodyMetric implements service.MetricsRepository.
+func (s *Store) GetBodyMetric(_ context.Context, id string) (service.BodyMetric, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	m, ok := s.metrics[id]
+	if !ok {
+		return service.BodyMetric{}, service.ErrNotFound
+	}
+	return copyBodyMetric(m), nil
+}
+
+// ListBodyMetrics implements service.MetricsRepository.
+func (s *Store) ListBodyMetrics(_ context.Context, tenantID string, athleteID string, f service.BodyMetricFilter) ([]service.BodyMetric, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var metrics []service.BodyMetric
+	for _, m := range s.metrics {
+		switch {
+		case m.TenantID != tenantID || m.AthleteID != athleteID,
+			f.Type != "" && m.Type != f.Type,
+			f.Site != "" && m.Site != f.Site,
+			!f.Start.IsZero() && m.MeasuredAt.Before(f.Start),
+			!f.End.IsZero() && !m.MeasuredAt.Before(f.End):
+			continue
+		}
+		metrics = append(metrics, copyBodyMetric(m))
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		if !metrics[i].MeasuredAt.Equal(metrics[j].MeasuredAt) {
+			return metrics[i].MeasuredAt.Before(metrics[j].MeasuredAt)
+		}
+		return metrics[i].Name < metrics[j].Name
+	})
+	return metrics, nil
+}
+
+// DeleteBodyMetric implements service.MetricsRepository.
+func (s *Store) DeleteBodyMetric(_ context.Context, id string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.metrics[id]; !ok {
+		return service.ErrNotFound
+	}
+	delete(s.metrics, id)
+	return nil
+}
+
+func copyBodyMetric(m service.BodyMetric) service.BodyMetric {
+	if m.Readiness != nil {
+		answers := *m.Readiness
+		m.Readiness = &answers
+	}
+	return m
+}
@@ -0,0 +1,368 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"workout-manager-service/pkg/service"
+)
+
+// CreateMovement implements service.MovementRepository.
+func (s *Store) CreateMovement(_ context.Context, m service.Movement) (service.Movement, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	m.Version = 1
+	s.movements[m.Name] = m
+	s.emit(service.NewMovementDomainEvent(service.MovementCreatedEvent, m))
+	return m, nil
+}
+
+// CreateMovements implements service.MovementRepository.
+func (s *Store) CreateMovements(_ context.Context, ms []service.Movement) ([]service.Movement, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	created := make([]service.Movement, len(ms))
+	for i, m := range ms {
+		m.Version = 1
+		s.movements[m.Name] = m
+		s.emit(service.NewMovementDomainEvent(service.MovementCreatedEvent, m))
+		created[i] = m
+	}
+	return created, nil
+}
+
+// GetMovement implements service.MovementRepository.
+func (s *Store) GetMovement(_ context.Context, id string) (service.Movement, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	m, ok := s.movements[id]
+	if !ok {
+		return service.Movement{}, service.ErrNotFound
+	}
+	return m, nil
+}
+
+// ListMovements implements service.MovementRepository.
+func (s *Store) ListMovements(_ context.Context, tenantID string, filter service.MovementFilter) ([]service.Movement, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var mvms []service.Movement
+	for _, m := range s.movements {
+		if m.TenantID == tenantID && filter.Matches(m) {
+			mvms = append(mvms, m)
+		}
+	}
+	sort.Slice(mvms, func(i, j int) bool {
+		return mvms[i].MovementName < mvms[j].MovementName
+	})
+	return mvms, nil
+}
+
+// SearchMovements implements service.MovementRepository. Without an index to
+// lean on, it scores every movement in the tenant.
+func (s *Store) SearchMovements(_ context.Context, tenantID string, query string, limit int) ([]service.Movement, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var matches []service.MovementMatch
+	for _, m := range s.movements {
+		if m.TenantID != tenantID || m.Deleted() {
+			continue
+		}
+		if score := m.SearchScore(query); score > 0 {
+			matches = append(matches, service.MovementMatch{Movement: m, Score: score})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	mvms := make([]service.Movement, len(matches))
+	for i, match := range matches {
+		mvms[i] = match.Movement
+	}
+	return mvms, nil
+}
+
+// UpdateMovement implements service.MovementRepository.
+func (s *Store) UpdateMovement(_ context.Context, m service.Movement) (service.Movement, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	current, ok := s.movements[m.Name]
+	if !ok {
+		return service.Movement{}, service.ErrNotFound
+	}
+	if current.Version != m.Version {
+		return service.Movement{}, service.ErrConflict
+	}
+	m.Version++
+	s.movements[m.Name] = m
+	s.emit(service.NewMovementDomainEvent(service.MovementUpdatedEvent, m))
+	return m, nil
+}
+
+// ImportMovements implements service.MovementRepository. Every version is
+// checked before anything is written.
+func (s *Store) ImportMovements(_ context.Context, creates []service.Movement, updates []service.Movement) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for _, m := range updates {
+		current, ok := s.movements[m.Name]
+		if !ok {
+			return service.ErrNotFound
+		}
+		if current.Version != m.Version {
+			return service.ErrConflict
+		}
+	}
+	for _, m := range creates {
+		m.Version = 1
+		s.movements[m.Name] = m
+		s.emit(service.NewMovementDomainEvent(service.MovementCreatedEvent, m))
+	}
+	for _, m := range updates {
+		m.Version++
+		s.movements[m.Name] = m
+		s.emit(service.NewMovementDomainEvent(service.MovementUpdatedEvent, m))
+	}
+	return nil
+}
+
+// SetMovementDeleteTime implements service.MovementRepository.
+func (s *Store) SetMovementDeleteTime(_ context.Context, id string, deleteTime time.Time, version int64) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	m, ok := s.movements[id]
+	if !ok {
+		return service.ErrNotFound
+	}
+	if m.Version != version {
+		return service.ErrConflict
+	}
+	m.DeleteTime = deleteTime
+	m.Version++
+	s.movements[id] = m
+	s.emit(deletionEvent(m))
+	return nil
+}
+
+// SetMovementsDeleteTime implements service.MovementRepository. Every
+// version is checked before anything is written.
+func (s *Store) SetMovementsDeleteTime(_ context.Context, ms []service.Movement, deleteTime time.Time) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for _, m := range ms {
+		current, ok := s.movements[m.Name]
+		if !ok {
+			return service.ErrNotFound
+		}
+		if current.Version != m.Version {
+			return service.ErrConflict
+		}
+	}
+	for _, m := range ms {
+		current := s.movements[m.Name]
+		current.DeleteTime = deleteTime
+		current.Version++
+		s.movements[m.Name] = current
+		s.emit(deletionEvent(current))
+	}
+	return nil
+}
+
+// PurgeMovements implements service.MovementRepository.
+func (s *Store) PurgeMovements(_ context.Context, deletedBefore time.Time) (int64, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	referenced := make(map[string]bool)
+	for _, w := range s.workouts {
+		for _, set := range w.Sets {
+			referenced[set.MovementID] = true
+		}
+		for _, c := range w.Conditioning {
+			referenced[c.MovementID] = true
+		}
+	}
+	for _, t := range s.templates {
+		for _, set := range t.Sets {
+			referenced[set.MovementID] = true
+		}
+	}
+	var n int64
+	for id, m := range s.movements {
+		if !m.Deleted() || !m.DeleteTime.Before(deletedBefore) || referenced[id] {
+			continue
+		}
+		delete(s.movements, id)
+		s.emit(service.NewMovementDomainEvent(service.MovementPurgedEvent, m))
+		for key := range s.overrides {
+			if key.movementID == id {
+				delete(s.overrides, key)
+			}
+		}
+		for fid, fork := range s.movements {
+			if fork.ForkedFrom == id {
+				fork.ForkedFrom = ""
+				s.movements[fid] = fork
+			}
+		}
+		n++
+	}
+	return n, nil
+}
+
+// GetFork implements service.MovementRepository.
+func (s *Store) GetFork(_ context.Context, tenantID string, globalID string) (service.Movement, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	for _, m := range s.movements {
+		if m.TenantID == tenantID && m.ForkedFrom == globalID {
+			return m, nil
+		}
+	}
+	return service.Movement{}, service.ErrNotFound
+}
+
+// ListForks implements service.MovementRepository.
+func (s *Store) ListForks(_ context.Context, tenantID string) ([]service.Movement, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var forks []service.Movement
+	for _, m := range s.movements {
+		if m.TenantID == tenantID && m.ForkedFrom != "" {
+			forks = append(forks, m)
+		}
+	}
+	return forks, nil
+}
+
+// GetMovementOverride implements service.MovementRepository.
+func (s *Store) GetMovementOverride(_ context.Context, tenantID string, movementID string) (service.MovementOverride, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	o, ok := s.overrides[movementKey{tenantID, movementID}]
+	if !ok {
+		return service.MovementOverride{}, service.ErrNotFound
+	}
+	return o, nil
+}
+
+// ListMovementOverrides implements service.MovementRepository.
+func (s *Store) ListMovementOverrides(_ context.Context, tenantID string) ([]service.MovementOverride, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var overrides []service.MovementOverride
+	for key, o := range s.overrides {
+		if key.tenantID == tenantID {
+			overrides = append(overrides, o)
+		}
+	}
+	return overrides, nil
+}
+
+// PutMovementOverride implements service.MovementRepository.
+func (s *Store) PutMovementOverride(_ context.Context, o service.MovementOverride) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.overrides[movementKey{o.TenantID, o.MovementID}] = o
+	return nil
+}
+
+// DeleteMovementOverride implements service.MovementRepository.
+func (s *Store) DeleteMovementOverride(_ context.Context, tenantID string, movementID string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	key := movementKey{tenantID, movementID}
+	if _, ok := s.overrides[key]; !ok {
+		return service.ErrNotFound
+	}
+	delete(s.overrides, key)
+	return nil
+}
+
+// MergeMovements implements service.MovementRepository.
+func (s *Store) MergeMovements(_ context.Context, merge service.MovementMerge) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	duplicates := make(map[string]bool, len(merge.DuplicateIDs))
+	for _, id := range merge.DuplicateIDs {
+		if m, ok := s.movements[id]; !ok || m.TenantID != merge.TenantID {
+			return service.ErrNotFound
+		}
+		duplicates[id] = true
+	}
+	for id, w := range s.workouts {
+		if w.TenantID != merge.TenantID {
+			continue
+		}
+		sets := append([]service.WorkoutSet(nil), w.Sets...)
+		for i := range sets {
+			if duplicates[sets[i].MovementID] {
+				sets[i].MovementID = merge.CanonicalID
+			}
+		}
+		conditioning := append([]service.Conditioning(nil), w.Conditioning...)
+		for i := range conditioning {
+			if duplicates[conditioning[i].MovementID] {
+				conditioning[i].MovementID = merge.CanonicalID
+			}
+		}
+		w.Sets, w.Conditioning = sets, conditioning
+		s.workouts[id] = w
+	}
+	for id, t := range s.templates {
+		if t.TenantID != merge.TenantID {
+			continue
+		}
+		sets := append([]service.WorkoutSet(nil), t.Sets...)
+		for i := range sets {
+			if duplicates[sets[i].MovementID] {
+				sets[i].MovementID = merge.CanonicalID
+			}
+		}
+		t.Sets = sets
+		s.templates[id] = t
+	}
+	for key, to := range s.redirects {
+		if key.tenantID == merge.TenantID && duplicates[to] {
+			s.redirects[key] = merge.CanonicalID
+		}
+	}
+	for id := range duplicates {
+		s.redirects[movementKey{merge.TenantID, id}] = merge.CanonicalID
+		delete(s.movements, id)
+		for key := range s.overrides {
+			if key.movementID == id {
+				delete(s.overrides, key)
+			}
+		}
+	}
+	if m, ok := s.movements[merge.CanonicalID]; ok && m.TenantID == merge.TenantID {
+		m.Aliases = merge.Aliases
+		m.Version++
+		s.movements[m.Name] = m
+	}
+	s.emit(service.NewMergeDomainEvent(merge))
+	return nil
+}
+
+// GetMovementRedirect implements service.MovementRepository.
+func (s *Store) GetMovementRedirect(_ context.Context, tenantID string, id string) (string, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	to, ok := s.redirects[movementKey{tenantID, id}]
+	if !ok {
+		return "", service.ErrNotFound
+	}
+	return to, nil
+}
+
+// deletionEvent describes a change of a movement's delete time.
+func deletionEvent(m service.Movement) service.DomainEvent {
+	if m.Deleted() {
+		return service.NewMovementDomainEvent(service.MovementDeletedEvent, m)
+	}
+	return service.NewMovementDomainEvent(service.MovementRestoredEvent, m)
+}
@@ -0,0 +1,104 @@
+package inmem
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"workout-manager-service/pkg/service"
+)
+
+// newMovementStore returns a store of a tenant with the given movements, one
+// workout lifting and rowing them and a template of back squats and presses.
+func newMovementStore(t *testing.T, ctx context.Context, ids ...string) *Store {
+	t.Helper()
+	s := NewStore()
+	for _, id := range ids {
+		if _, err := s.CreateMovement(ctx, service.Movement{Name: id, TenantID: "t1", MovementName: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := s.CreateWorkout(ctx, service.Workout{
+		Name:      "w1",
+		TenantID:  "t1",
+		AthleteID: "a1",
+		Sets:      []service.WorkoutSet{{MovementID: "squat", Reps: 5}, {MovementID: "back-squat", Reps: 5}},
+		Conditioning: []service.Conditioning{
+			{MovementID: "erg", Sport: "rowing", Duration: time.Minute},
+			{MovementID: "rower", Sport: "rowing", Duration: time.Minute},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.CreateTemplate(ctx, service.WorkoutTemplate{
+		Name:     "template",
+		TenantID: "t1",
+		Sets:     []service.WorkoutSet{{MovementID: "back-squat", Reps: 5}, {MovementID: "press", Reps: 5}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestMergeMovementsRepoints(t *testing.T) {
+	ctx := context.Background()
+	s := newMovementStore(t, ctx, "squat", "back-squat", "erg", "rower", "press")
+	for _, merge := range []service.MovementMerge{
+		{TenantID: "t1", CanonicalID: "squat", DuplicateIDs: []string{"back-squat"}},
+		{TenantID: "t1", CanonicalID: "erg", DuplicateIDs: []string{"rower"}},
+	} {
+		if err := s.MergeMovements(ctx, merge); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := s.GetWorkout(ctx, "w1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, set := range w.Sets {
+		if set.MovementID != "squat" {
+			t.Errorf("a set still lifts %s", set.MovementID)
+		}
+	}
+	for _, c := range w.Conditioning {
+		if c.MovementID != "erg" {
+			t.Errorf("a conditioning effort still rows on %s", c.MovementID)
+		}
+	}
+	tmpl, err := s.GetTemplate(ctx, "template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.Sets[0].MovementID != "squat" || tmpl.Sets[1].MovementID != "press" {
+		t.Errorf("template sets = %+v, want squat and press", tmpl.Sets)
+	}
+	for from, to := range map[string]string{"back-squat": "squat", "rower": "erg"} {
+		if got, err := s.GetMovementRedirect(ctx, "t1", from); err != nil || got != to {
+			t.Errorf("redirect of %s = %q, %v, want %s", from, got, err, to)
+		}
+	}
+}
+
+func TestPurgeMovementsKeepsReferenced(t *testing.T) {
+	ctx := context.Background()
+	s := newMovementStore(t, ctx, "squat", "back-squat", "erg", "rower", "press", "unused")
+	deleted := time.Now().Add(-time.Hour)
+	for _, id := range []string{"squat", "erg", "press", "unused"} {
+		if err := s.SetMovementDeleteTime(ctx, id, deleted, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := s.PurgeMovements(ctx, time.Now())
+	if err != nil || n != 1 {
+		t.Fatalf("PurgeMovements() = %d, %v, want 1, nil", n, err)
+	}
+	for id, kept := range map[string]bool{"squat": true, "erg": true, "press": true, "unused": false} {
+		if _, err := s.GetMovement(ctx, id); (err == nil) != kept {
+			t.Errorf("after the purge, GetMovement(%s) = %v, want kept = %v", id, err, kept)
+		}
+	}
+}
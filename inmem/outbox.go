@@ -0,0 +1,59 @@
+package inmem
+
+import (
+	"context"
+
+	"workout-manager-service/pkg/service"
+)
+
+// emit adds events to the outbox. Callers hold the write lock, which makes
+// the events part of the same change as the write they describe.
+func (s *Store) emit(events ...service.DomainEvent) {
+	s.outbox = append(s.outbox, events...)
+}
+
+// AddOutboxEvents implements service.OutboxRepository.
+func (s *Store) AddOutboxEvents(_ context.Context, events []service.DomainEvent) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	queued := make(map[string]bool, len(s.outbox))
+	for _, e := range s.outbox {
+		queued[e.ID] = true
+	}
+	for _, e := range events {
+		if !queued[e.ID] {
+			queued[e.ID] = true
+			s.emit(e)
+		}
+	}
+	return nil
+}
+
+// ListOutboxEvents implements service.OutboxRepository.
+func (s *Store) ListOutboxEvents(_ context.Context, limit int) ([]service.DomainEvent, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	n := len(s.outbox)
+	if n > limit {
+		n = limit
+	}
+	return append([]service.DomainEvent(nil), s.outbox[:n]...), nil
+}
+
+// DeleteOutboxEvents implements service.OutboxRepository.
+func (s *Store) DeleteOutboxEvents(_ context.Context, ids []string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	published := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		published[id] = true
+	}
+	kept := s.outbox[:0]
+	for _, e := range s.outbox {
+		if !published[e.ID] {
+			kept = append(kept, e)
+		}
+	}
+	s.outbox = kept
+	return nil
+}
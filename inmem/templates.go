@@ -0,0 +1,78 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+
+	"workout-manager-service/pkg/service"
+)
+
+// CreateTemplate implements service.TemplateRepository.
+func (s *Store) CreateTemplate(_ context.Context, t service.WorkoutTemplate) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.templates[t.Name]; ok {
+		return service.ErrAlreadyExists
+	}
+	s.templates[t.Name] = copyTemplate(t)
+	return nil
+}
+
+// GetTemplate implements service.TemplateRepository.
+func (s *Store) GetTemplate(_ context.Context, id string) (service.WorkoutTemplate, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	t, ok := s.templates[id]
+	if !ok {
+		return service.WorkoutTemplate{}, service.ErrNotFound
+	}
+	return copyTemplate(t), nil
+}
+
+// ListTemplates implements service.TemplateRepository.
+func (s *Store) ListTemplates(_ context.Context, tenantID string) ([]service.WorkoutTemplate, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var templates []service.WorkoutTemplate
+	for _, t := range s.templates {
+		if t.TenantID == tenantID {
+			templates = append(templates, copyTemplate(t))
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		if !templates[i].UpdateTime.Equal(templates[j].UpdateTime) {
+			return templates[i].UpdateTime.After(templates[j].UpdateTime)
+		}
+		return templates[i].Name < templates[j].Name
+	})
+	return templates, nil
+}
+
+// UpdateTemplate implements service.TemplateRepository.
+func (s *Store) UpdateTemplate(_ context.Context, t service.WorkoutTemplate) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.templates[t.Name]; !ok {
+		return service.ErrNotFound
+	}
+	s.templates[t.Name] = copyTemplate(t)
+	return nil
+}
+
+// DeleteTemplate implements service.TemplateRepository.
+func (s *Store) DeleteTemplate(_ context.Context, id string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.templates[id]; !ok {
+		return service.ErrNotFound
+	}
+	delete(s.templates, id)
+	return nil
+}
+
+func copyTemplate(t service.WorkoutTemplate) service.WorkoutTemplate {
+	t.SharedWith = append([]string(nil), t.SharedWith...)
+	t.Sets = append([]service.WorkoutSet(nil), t.Sets...)
+	t.Blocks = append([]service.WorkoutBlock(nil), t.Blocks...)
+	return t
+}
@@ -0,0 +1,157 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+
+	"workout-manager-service/pkg/service"
+)
+
+// CreateTenant implements service.TenantRepository.
+func (s *Store) CreateTenant(_ context.Context, t service.Tenant, admin service.User) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.tenants[t.Name] = t
+	s.users[admin.Name] = admin
+	return nil
+}
+
+// GetTenant implements service.TenantRepository.
+func (s *Store) GetTenant(_ context.Context, id string) (service.Tenant, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	t, ok := s.tenants[id]
+	if !ok {
+		return service.Tenant{}, service.ErrNotFound
+	}
+	return t, nil
+}
+
+// ListTenants implements service.TenantRepository.
+func (s *Store) ListTenants(_ context.Context) ([]service.Tenant, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var tenants []service.Tenant
+	for _, t := range s.tenants {
+		tenants = append(tenants, t)
+	}
+	sort.Slice(tenants, func(i, j int) bool {
+		return tenants[i].DisplayName < tenants[j].DisplayName
+	})
+	return tenants, nil
+}
+
+// UpdateTenant implements service.TenantRepository.
+func (s *Store) UpdateTenant(_ context.Context, t service.Tenant) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.tenants[t.Name]; !ok {
+		return service.ErrNotFound
+	}
+	s.tenants[t.Name] = t
+	return nil
+}
+
+// DeleteTenant implements service.TenantRepository. Everything belonging to
+// the tenant is removed under a single lock, mirroring the transaction used
+// by the database implementation.
+func (s *Store) DeleteTenant(_ context.Context, d service.TenantDeletion) (service.TenantDeletion, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.tenants[d.TenantID]; !ok {
+		return service.TenantDeletion{}, service.ErrNotFound
+	}
+	d.RowCounts = make(map[string]int64)
+	for id, w := range s.workouts {
+		if w.TenantID == d.TenantID {
+			d.RowCounts["workout_sets"] += int64(len(w.Sets))
+			d.RowCounts["workout_blocks"] += int64(len(w.Blocks))
+			d.RowCounts["workout_conditioning"] += int64(len(w.Conditioning))
+			for _, c := range w.Conditioning {
+				d.RowCounts["workout_laps"] += int64(len(c.Laps))
+			}
+			d.RowCounts["workouts"]++
+			delete(s.workouts, id)
+		}
+	}
+	for key := range s.overrides {
+		if key.tenantID == d.TenantID {
+			d.RowCounts["movement_overrides"]++
+			delete(s.overrides, key)
+		}
+	}
+	for id, delivery := range s.deliveries {
+		if delivery.TenantID == d.TenantID {
+			d.RowCounts["webhook_deliveries"]++
+			delete(s.deliveries, id)
+		}
+	}
+	for id, w := range s.webhooks {
+		if w.TenantID == d.TenantID {
+			d.RowCounts["webhooks"]++
+			delete(s.webhooks, id)
+		}
+	}
+	if _, ok := s.thresholds[d.TenantID]; ok {
+		d.RowCounts["workload_thresholds"]++
+		delete(s.thresholds, d.TenantID)
+	}
+	for id, m := range s.metrics {
+		if m.TenantID == d.TenantID {
+			d.RowCounts["body_metrics"]++
+			delete(s.metrics, id)
+		}
+	}
+	if _, ok := s.inventories[d.TenantID]; ok {
+		d.RowCounts["plate_inventories"]++
+		delete(s.inventories, d.TenantID)
+	}
+	for id, t := range s.templates {
+		if t.TenantID == d.TenantID {
+			d.RowCounts["workout_templates"]++
+			delete(s.templates, id)
+		}
+	}
+	var audit []service.AuditEvent
+	for _, e := range s.audit {
+		if e.TenantID == d.TenantID {
+			d.RowCounts["audit_events"]++
+			continue
+		}
+		audit = append(audit, e)
+	}
+	s.audit = audit
+	outbox := s.outbox[:0]
+	for _, e := range s.outbox {
+		if e.TenantID == d.TenantID {
+			d.RowCounts["outbox"]++
+			continue
+		}
+		outbox = append(outbox, e)
+	}
+	s.outbox = outbox
+	for key := range s.redirects {
+		if key.tenantID == d.TenantID {
+			d.RowCounts["movement_redirects"]++
+			delete(s.redirects, key)
+		}
+	}
+	for id, m := range s.movements {
+		if m.TenantID == d.TenantID {
+			d.RowCounts["movements"]++
+			delete(s.movements, id)
+		}
+	}
+	for id, u := range s.users {
+		if u.TenantID == d.TenantID {
+			d.RowCounts["coach_athletes"] += int64(len(s.coaching[id]))
+			d.RowCounts["users"]++
+			delete(s.coaching, id)
+			delete(s.users, id)
+		}
+	}
+	delete(s.tenants, d.TenantID)
+	d.RowCounts["tenants"] = 1
+	s.deletions = append(s.deletions, d)
+	return d, nil
+}
@@ -0,0 +1,98 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+// CreateUser implements service.UserRepository.
+func (s *Store) CreateUser(_ context.Context, u service.User) (service.User, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for _, existing := range s.users {
+		if existing.TenantID == u.TenantID && existing.Email == u.Email {
+			return service.User{}, errors.Wrapf(service.ErrInvalidArgument, "email %q is already in use", u.Email)
+		}
+	}
+	s.users[u.Name] = u
+	return u, nil
+}
+
+// GetUser implements service.UserRepository.
+func (s *Store) GetUser(_ context.Context, id string) (service.User, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	u, ok := s.users[id]
+	if !ok {
+		return service.User{}, service.ErrNotFound
+	}
+	return u, nil
+}
+
+// ListUsers implements service.UserRepository.
+func (s *Store) ListUsers(_ context.Context, tenantID string, role service.Role) ([]service.User, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var users []service.User
+	for _, u := range s.users {
+		if u.TenantID == tenantID && (role == "" || u.Role == role) {
+			users = append(users, u)
+		}
+	}
+	sortUsers(users)
+	return users, nil
+}
+
+// CreateCoaching implements service.UserRepository.
+func (s *Store) CreateCoaching(_ context.Context, _ string, coachID string, athleteID string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.coaching[coachID] == nil {
+		s.coaching[coachID] = make(map[string]bool)
+	}
+	s.coaching[coachID][athleteID] = true
+	return nil
+}
+
+// DeleteCoaching implements service.UserRepository.
+func (s *Store) DeleteCoaching(_ context.Context, _ string, coachID string, athleteID string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if !s.coaching[coachID][athleteID] {
+		return service.ErrNotFound
+	}
+	delete(s.coaching[coachID], athleteID)
+	return nil
+}
+
+// ListAthletes implements service.UserRepository.
+func (s *Store) ListAthletes(_ context.Context, tenantID string, coachID string) ([]service.User, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var athletes []service.User
+	for id := range s.coaching[coachID] {
+		if u, ok := s.users[id]; ok && u.TenantID == tenantID {
+			athletes = append(athletes, u)
+		}
+	}
+	sortUsers(athletes)
+	return athletes, nil
+}
+
+// IsCoachOf implements service.UserRepository.
+func (s *Store) IsCoachOf(_ context.Context, tenantID string, coachID string, athleteID string) (bool, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	u, ok := s.users[athleteID]
+	return ok && u.TenantID == tenantID && s.coaching[coachID][athleteID], nil
+}
+
+func sortUsers(users []service.User) {
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].DisplayName < users[j].DisplayName
+	})
+}
@@ -0,0 +1,186 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"workout-manager-service/pkg/service"
+)
+
+// CreateWebhook implements service.WebhookRepository.
+func (s *Store) CreateWebhook(_ context.Context, w service.Webhook) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.webhooks[w.Name]; ok {
+		return service.ErrAlreadyExists
+	}
+	s.webhooks[w.Name] = copyWebhook(w)
+	return nil
+}
+
+// GetWebhook implements service.WebhookRepository.
+func (s *Store) GetWebhook(_ context.Context, id string) (service.Webhook, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	w, ok := s.webhooks[id]
+	if !ok {
+		return service.Webhook{}, service.ErrNotFound
+	}
+	return copyWebhook(w), nil
+}
+
+// ListWebhooks implements service.WebhookRepository. Webhooks are listed in
+// the order they were created.
+func (s *Store) ListWebhooks(_ context.Context, tenantID string) ([]service.Webhook, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var webhooks []service.Webhook
+	for _, w := range s.webhooks {
+		if w.TenantID == tenantID {
+			webhooks = append(webhooks, copyWebhook(w))
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		if !webhooks[i].CreateTime.Equal(webhooks[j].CreateTime) {
+			return webhooks[i].CreateTime.Before(webhooks[j].CreateTime)
+		}
+		return webhooks[i].Name < webhooks[j].Name
+	})
+	return webhooks, nil
+}
+
+// UpdateWebhook implements service.WebhookRepository.
+func (s *Store) UpdateWebhook(_ context.Context, w service.Webhook) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.webhooks[w.Name]; !ok {
+		return service.ErrNotFound
+	}
+	s.webhooks[w.Name] = copyWebhook(w)
+	return nil
+}
+
+// DeleteWebhook implements service.WebhookRepository.
+func (s *Store) DeleteWebhook(_ context.Context, id string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.webhooks[id]; !ok {
+		return service.ErrNotFound
+	}
+	delete(s.webhooks, id)
+	for did, d := range s.deliveries {
+		if d.WebhookID == id {
+			delete(s.deliveries, did)
+		}
+	}
+	return nil
+}
+
+// CreateWebhookDeliveries implements service.WebhookRepository.
+func (s *Store) CreateWebhookDeliveries(_ context.Context, ds []service.WebhookDelivery) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	existing := make(map[[2]string]bool)
+	for _, d := range s.deliveries {
+		existing[[2]string{d.WebhookID, d.EventID}] = true
+	}
+	for _, d := range ds {
+		if _, ok := s.webhooks[d.WebhookID]; !ok {
+			continue
+		}
+		key := [2]string{d.WebhookID, d.EventID}
+		if existing[key] {
+			continue
+		}
+		existing[key] = true
+		s.deliveries[d.Name] = copyDelivery(d)
+	}
+	return nil
+}
+
+// GetWebhookDelivery implements service.WebhookRepository.
+func (s *Store) GetWebhookDelivery(_ context.Context, id string) (service.WebhookDelivery, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	d, ok := s.deliveries[id]
+	if !ok {
+		return service.WebhookDelivery{}, service.ErrNotFound
+	}
+	return copyDelivery(d), nil
+}
+
+// ListWebhookDeliveries implements service.WebhookRepository.
+func (s *Store) ListWebhookDeliveries(_ context.Context, tenantID string, filter service.WebhookDeliveryFilter) ([]service.WebhookDelivery, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var deliveries []service.WebhookDelivery
+	for _, d := range s.deliveries {
+		switch {
+		case d.TenantID != tenantID:
+		case filter.WebhookID != "" && d.WebhookID != filter.WebhookID:
+		case filter.State != "" && d.State != filter.State:
+		default:
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreateTime.Equal(deliveries[j].CreateTime) {
+			return deliveries[i].CreateTime.After(deliveries[j].CreateTime)
+		}
+		return deliveries[i].Name < deliveries[j].Name
+	})
+	if len(deliveries) > filter.Limit {
+		deliveries = deliveries[:filter.Limit]
+	}
+	for i, d := range deliveries {
+		deliveries[i] = copyDelivery(d)
+	}
+	return deliveries, nil
+}
+
+// ListDueWebhookDeliveries implements service.WebhookRepository.
+func (s *Store) ListDueWebhookDeliveries(_ context.Context, now time.Time, limit int) ([]service.WebhookDelivery, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var deliveries []service.WebhookDelivery
+	for _, d := range s.deliveries {
+		if d.State == service.WebhookDeliveryPending && !d.NextAttemptTime.After(now) && !s.webhooks[d.WebhookID].Disabled {
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].NextAttemptTime.Equal(deliveries[j].NextAttemptTime) {
+			return deliveries[i].NextAttemptTime.Before(deliveries[j].NextAttemptTime)
+		}
+		return deliveries[i].Name < deliveries[j].Name
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	for i, d := range deliveries {
+		deliveries[i] = copyDelivery(d)
+	}
+	return deliveries, nil
+}
+
+// UpdateWebhookDelivery implements service.WebhookRepository.
+func (s *Store) UpdateWebhookDelivery(_ context.Context, d service.WebhookDelivery) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.deliveries[d.Name]; !ok {
+		return service.ErrNotFound
+	}
+	s.deliveries[d.Name] = copyDelivery(d)
+	return nil
+}
+
+func copyWebhook(w service.Webhook) service.Webhook {
+	w.EventTypes = append([]service.DomainEventType(nil), w.EventTypes...)
+	return w
+}
+
+func copyDelivery(d service.WebhookDelivery) service.WebhookDelivery {
+	d.Attempts = append([]service.WebhookAttempt(nil), d.Attempts...)
+	return d
+}
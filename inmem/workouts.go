@@ -0,0 +1,123 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"workout-manager-service/pkg/service"
+)
+
+// CreateWorkout implements service.WorkoutRepository.
+func (s *Store) CreateWorkout(_ context.Context, w service.Workout) (service.Workout, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	w = copyWorkout(w)
+	s.workouts[w.Name] = w
+	s.emit(service.NewWorkoutDomainEvent(service.WorkoutCreatedEvent, w))
+	return w, nil
+}
+
+// CreateWorkouts implements service.WorkoutRepository.
+func (s *Store) CreateWorkouts(_ context.Context, ws []service.Workout) ([]service.Workout, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	created := make([]service.Workout, len(ws))
+	for i, w := range ws {
+		w = copyWorkout(w)
+		s.workouts[w.Name] = w
+		s.emit(service.NewWorkoutDomainEvent(service.WorkoutCreatedEvent, w))
+		created[i] = w
+	}
+	return created, nil
+}
+
+// GetWorkout implements service.WorkoutRepository.
+func (s *Store) GetWorkout(_ context.Context, id string) (service.Workout, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	w, ok := s.workouts[id]
+	if !ok {
+		return service.Workout{}, service.ErrNotFound
+	}
+	return w, nil
+}
+
+// ListWorkouts implements service.WorkoutRepository.
+func (s *Store) ListWorkouts(_ context.Context, tenantID string, athleteID string) ([]service.Workout, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var workouts []service.Workout
+	for _, w := range s.workouts {
+		if w.TenantID == tenantID && w.AthleteID == athleteID {
+			workouts = append(workouts, w)
+		}
+	}
+	sort.Slice(workouts, func(i, j int) bool {
+		return workouts[i].PerformedAt.After(workouts[j].PerformedAt)
+	})
+	return workouts, nil
+}
+
+// ListPerformedWorkouts implements service.WorkoutRepository.
+func (s *Store) ListPerformedWorkouts(_ context.Context, tenantID string, athleteID string, from time.Time, to time.Time) ([]service.Workout, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var workouts []service.Workout
+	for _, w := range s.workouts {
+		if w.TenantID != tenantID || w.AthleteID != athleteID || w.Planned {
+			continue
+		}
+		if w.PerformedAt.Before(from) || !w.PerformedAt.Before(to) {
+			continue
+		}
+		w.Sets, w.Blocks, w.Conditioning = nil, nil, nil
+		workouts = append(workouts, w)
+	}
+	sort.Slice(workouts, func(i, j int) bool {
+		return workouts[i].PerformedAt.After(workouts[j].PerformedAt)
+	})
+	return workouts, nil
+}
+
+// RateWorkout implements service.WorkoutRepository.
+func (s *Store) RateWorkout(_ context.Context, w service.Workout) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	current, ok := s.workouts[w.Name]
+	if !ok {
+		return service.ErrNotFound
+	}
+	current.SessionRPE = w.SessionRPE
+	current.Duration = w.Duration
+	s.workouts[w.Name] = current
+	s.emit(service.NewWorkoutDomainEvent(service.WorkoutRatedEvent, current))
+	return nil
+}
+
+// DeleteWorkout implements service.WorkoutRepository.
+func (s *Store) DeleteWorkout(_ context.Context, id string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	w, ok := s.workouts[id]
+	if !ok {
+		return service.ErrNotFound
+	}
+	delete(s.workouts, id)
+	s.emit(service.NewWorkoutDomainEvent(service.WorkoutDeletedEvent, w))
+	return nil
+}
+
+// copyWorkout copies the slices of a workout so that callers cannot change
+// what is stored.
+func copyWorkout(w service.Workout) service.Workout {
+	w.Sets = append([]service.WorkoutSet(nil), w.Sets...)
+	w.Blocks = append([]service.WorkoutBlock(nil), w.Blocks...)
+	conditioning := w.Conditioning
+	w.Conditioning = nil
+	for _, c := range conditioning {
+		c.Laps = append([]service.Lap(nil), c.Laps...)
+		w.Conditioning = append(w.Conditioning, c)
+	}
+	return w
+}
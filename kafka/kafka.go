@@ -0,0 +1,304 @@
+// Package kafka publishes domain events to a Kafka topic. It speaks the Kafka
+// wire protocol directly and implements only what a producer needs: finding
+// the leaders of a topic's partitions and producing single records to them.
+//
+// Each event is produced as its JSON encoding, keyed by its aggregate ID and
+// with its type and ID as headers. Keys are assigned to partitions the way
+// the Java client does, so the events of an aggregate share a partition and
+// stay in order. Every record is acknowledged by all in-sync replicas before
+// Publish returns.
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+// Options configure a Broker.
+type Options struct {
+	// ClientID identifies the producer in the brokers' logs and quotas.
+	ClientID string
+	// Timeout bounds each request when the context has no earlier
+	// deadline. It defaults to ten seconds.
+	Timeout time.Duration
+}
+
+// Broker implements service.Broker. It keeps the topic's metadata and one
+// connection per Kafka broker, fetching or opening them on first use and
+// again after they turn out to be stale. Publications are serialized.
+type Broker struct {
+	bootstrap []string
+	topic     string
+	opts      Options
+
+	mtx        sync.Mutex
+	nodes      map[int32]string
+	leaders    []int32
+	conns      map[int32]net.Conn
+	correlator int32
+}
+
+// NewBroker returns a Broker that produces to topic, reaching the cluster
+// through any of the bootstrap addresses.
+func NewBroker(bootstrap []string, topic string, opts Options) (*Broker, error) {
+	if len(bootstrap) == 0 || topic == "" {
+		return nil, errors.New("a Kafka producer needs a bootstrap address and a topic")
+	}
+	if opts.ClientID == "" {
+		opts.ClientID = "workout-manager"
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	return &Broker{bootstrap: bootstrap, topic: topic, opts: opts, conns: make(map[int32]net.Conn)}, nil
+}
+
+// Error is an error code returned by Kafka.
+type Error int16
+
+func (e Error) Error() string {
+	return "Kafka error code " + strconv.Itoa(int(e))
+}
+
+// Publish implements service.Broker.
+func (b *Broker) Publish(ctx context.Context, e service.DomainEvent) error {
+	value, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode event")
+	}
+	key := []byte(e.AggregateID)
+	batch := recordBatch(key, value, []header{
+		{key: "event-id", value: []byte(e.ID)},
+		{key: "event-type", value: []byte(e.Type)},
+	}, e.Time)
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	deadline := time.Now().Add(b.opts.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if b.leaders == nil {
+		if err := b.refreshMetadata(ctx, deadline); err != nil {
+			return err
+		}
+	}
+	partition := partitionFor(key, len(b.leaders))
+	leader := b.leaders[partition]
+	if leader < 0 {
+		b.leaders = nil
+		return errors.Errorf("partition %d of %s has no leader", partition, b.topic)
+	}
+
+	var body encoder
+	body.nullString() // transactional ID
+	body.int16(-1)    // acks from all in-sync replicas
+	body.int32(int32(b.opts.Timeout / time.Millisecond))
+	body.int32(1)
+	body.string(b.topic)
+	body.int32(1)
+	body.int32(int32(partition))
+	body.bytes(batch)
+	res, err := b.roundTrip(ctx, deadline, leader, apiProduce, produceVersion, body.Bytes())
+	if err != nil {
+		return err
+	}
+	d := decoder{b: res}
+	code := int16(0)
+	for i, topics := 0, d.array(); i < topics; i++ {
+		d.string()
+		for j, partitions := 0, d.array(); j < partitions; j++ {
+			d.int32() // partition
+			if c := d.int16(); c != 0 {
+				code = c
+			}
+			d.int64() // base offset
+			d.int64() // log append time
+		}
+	}
+	if d.err != nil {
+		b.closeConn(leader)
+		return d.err
+	}
+	switch code {
+	case 0:
+		return nil
+	case errUnknownTopicOrPartition, errLeaderNotAvailable, errNotLeaderOrFollower:
+		b.leaders = nil
+	}
+	return errors.Wrapf(Error(code), "failed to produce to partition %d of %s", partition, b.topic)
+}
+
+// Close closes every connection.
+func (b *Broker) Close() error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	for id := range b.conns {
+		b.closeConn(id)
+	}
+	return nil
+}
+
+// bootstrapNode is the connection key of a bootstrap address, which is not
+// known as a node of the cluster yet.
+const bootstrapNode = -1
+
+// refreshMetadata learns the topic's partitions and their leaders from the
+// first bootstrap address that answers. Topics that do not exist are created
+// when the cluster allows it.
+func (b *Broker) refreshMetadata(ctx context.Context, deadline time.Time) error {
+	var body encoder
+	body.int32(1)
+	body.string(b.topic)
+	body.int8(1) // allow auto topic creation
+	var err error
+	for _, addr := range b.bootstrap {
+		b.nodes = map[int32]string{bootstrapNode: addr}
+		var res []byte
+		res, err = b.roundTrip(ctx, deadline, bootstrapNode, apiMetadata, metadataVersion, body.Bytes())
+		b.closeConn(bootstrapNode)
+		if err != nil {
+			continue
+		}
+		return b.decodeMetadata(res)
+	}
+	return errors.Wrap(err, "failed to fetch Kafka metadata")
+}
+
+func (b *Broker) decodeMetadata(res []byte) error {
+	d := decoder{b: res}
+	d.int32() // throttle time
+	nodes := make(map[int32]string)
+	for i, n := 0, d.array(); i < n; i++ {
+		id := d.int32()
+		host := d.string()
+		port := d.int32()
+		d.string() // rack
+		nodes[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	d.string() // cluster ID
+	d.int32()  // controller ID
+	var (
+		leaders []int32
+		code    int16
+	)
+	for i, n := 0, d.array(); i < n; i++ {
+		topicCode := d.int16()
+		name := d.string()
+		d.int8() // internal
+		partitions := d.array()
+		if name != b.topic {
+			d.err = errMalformed
+			break
+		}
+		code = topicCode
+		leaders = make([]int32, partitions)
+		for j := 0; j < partitions; j++ {
+			d.int16() // partition error
+			p := d.int32()
+			leader := d.int32()
+			for k, n := 0, d.array(); k < n; k++ {
+				d.int32() // replica
+			}
+			for k, n := 0, d.array(); k < n; k++ {
+				d.int32() // in-sync replica
+			}
+			if p < 0 || int(p) >= partitions {
+				d.err = errMalformed
+				break
+			}
+			leaders[p] = leader
+		}
+	}
+	if d.err != nil {
+		return d.err
+	}
+	if code != 0 {
+		return errors.Wrapf(Error(code), "failed to fetch metadata of %s", b.topic)
+	}
+	if len(leaders) == 0 {
+		return errors.Errorf("topic %s has no partitions", b.topic)
+	}
+	for _, c := range b.conns {
+		c.Close()
+	}
+	b.nodes, b.leaders, b.conns = nodes, leaders, make(map[int32]net.Conn)
+	return nil
+}
+
+// roundTrip sends a request to a node and returns the body of its response.
+// The connection is closed after any error so that the next request starts
+// on a clean one.
+func (b *Broker) roundTrip(ctx context.Context, deadline time.Time, node int32, api int16, version int16, body []byte) ([]byte, error) {
+	conn, err := b.conn(ctx, deadline, node)
+	if err != nil {
+		return nil, err
+	}
+	b.correlator++
+	correlationID := b.correlator
+	res, err := exchange(conn, deadline, request(api, version, correlationID, b.opts.ClientID, body))
+	if err == nil && (len(res) < 4 || int32(binary.BigEndian.Uint32(res)) != correlationID) {
+		err = errors.New("Kafka response does not match its request")
+	}
+	if err != nil {
+		b.closeConn(node)
+		return nil, errors.Wrapf(err, "request to Kafka broker %s failed", b.nodes[node])
+	}
+	return res[4:], nil
+}
+
+func exchange(conn net.Conn, deadline time.Time, req []byte) ([]byte, error) {
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+	var size [4]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > 64<<20 {
+		return nil, fmt.Errorf("Kafka response of %d bytes is too large", n)
+	}
+	res := make([]byte, n)
+	_, err := io.ReadFull(conn, res)
+	return res, err
+}
+
+func (b *Broker) conn(ctx context.Context, deadline time.Time, node int32) (net.Conn, error) {
+	if c, ok := b.conns[node]; ok {
+		return c, nil
+	}
+	addr, ok := b.nodes[node]
+	if !ok {
+		b.leaders = nil
+		return nil, errors.Errorf("Kafka broker %d is unknown", node)
+	}
+	d := net.Dialer{Deadline: deadline}
+	c, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to Kafka broker %s", addr)
+	}
+	b.conns[node] = c
+	return c, nil
+}
+
+func (b *Broker) closeConn(node int32) {
+	if c, ok := b.conns[node]; ok {
+		c.Close()
+		delete(b.conns, node)
+	}
+}
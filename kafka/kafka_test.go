@@ -0,0 +1,421 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"workout-manager-service/pkg/service"
+)
+
+func TestMurmur2(t *testing.T) {
+	// The hashes the Java client's Utils.murmur2 returns, as Kafka's own
+	// tests list them.
+	for _, tc := range []struct {
+		data string
+		want int32
+	}{
+		{"21", -973932308},
+		{"foobar", -790332482},
+		{"a-little-bit-long-string", -985981536},
+		{"a-little-bit-longer-string", -1486304829},
+		{"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8", -58897971},
+		{"abc", 479470107},
+	} {
+		if got := int32(murmur2([]byte(tc.data))); got != tc.want {
+			t.Errorf("murmur2(%q) = %d, want %d", tc.data, got, tc.want)
+		}
+	}
+}
+
+func TestPartitionFor(t *testing.T) {
+	for _, tc := range []struct {
+		key        string
+		partitions int
+		want       int
+	}{
+		// -973932308 & 0x7fffffff = 1173551340
+		{"21", 1, 0},
+		{"21", 3, 1173551340 % 3},
+		{"21", 7, 1173551340 % 7},
+		// 479470107 is positive already.
+		{"abc", 10, 479470107 % 10},
+		{"abc", 12, 479470107 % 12},
+	} {
+		if got := partitionFor([]byte(tc.key), tc.partitions); got != tc.want {
+			t.Errorf("partitionFor(%q, %d) = %d, want %d", tc.key, tc.partitions, got, tc.want)
+		}
+	}
+}
+
+// record is a record a fakeKafka received.
+type record struct {
+	partition int32
+	acks      int16
+	key       []byte
+	value     []byte
+	headers   map[string]string
+}
+
+// fakeKafka is a single node cluster holding one topic. It answers metadata
+// and produce requests, and can be told to fail the next produce request
+// with an error code or by dropping the connection.
+type fakeKafka struct {
+	t          *testing.T
+	ln         net.Listener
+	topic      string
+	partitions int32
+
+	mtx        sync.Mutex
+	metadata   int
+	conns      int
+	records    []record
+	produceErr int16
+	drop       bool
+}
+
+func newFakeKafka(t *testing.T, topic string, partitions int32) *fakeKafka {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := &fakeKafka{t: t, ln: ln, topic: topic, partitions: partitions}
+	go k.serve()
+	return k
+}
+
+func (k *fakeKafka) Close() { k.ln.Close() }
+
+func (k *fakeKafka) serve() {
+	for {
+		conn, err := k.ln.Accept()
+		if err != nil {
+			return
+		}
+		k.mtx.Lock()
+		k.conns++
+		k.mtx.Unlock()
+		go k.handle(conn)
+	}
+}
+
+func (k *fakeKafka) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return
+		}
+		req := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+		d := decoder{b: req}
+		api, version, correlationID := d.int16(), d.int16(), d.int32()
+		if clientID := d.string(); clientID != "test-client" {
+			k.t.Errorf("client ID = %q, want test-client", clientID)
+		}
+		var res []byte
+		switch {
+		case api == apiMetadata && version == metadataVersion:
+			res = k.handleMetadata(&d)
+		case api == apiProduce && version == produceVersion:
+			if res = k.handleProduce(&d); res == nil {
+				return
+			}
+		default:
+			k.t.Errorf("unexpected request of API %d version %d", api, version)
+			return
+		}
+		if d.err != nil || len(d.b) != 0 {
+			k.t.Errorf("malformed request of API %d: %v, %d bytes left", api, d.err, len(d.b))
+		}
+		var e encoder
+		e.int32(int32(4 + len(res)))
+		e.int32(correlationID)
+		e.Write(res)
+		if _, err := conn.Write(e.Bytes()); err != nil {
+			return
+		}
+	}
+}
+
+func (k *fakeKafka) handleMetadata(d *decoder) []byte {
+	k.mtx.Lock()
+	k.metadata++
+	k.mtx.Unlock()
+	if n := d.array(); n != 1 || d.string() != k.topic {
+		k.t.Errorf("metadata requested for %d topics, want %s", n, k.topic)
+	}
+	d.int8() // allow auto topic creation
+
+	host, port, _ := net.SplitHostPort(k.ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	var e encoder
+	e.int32(0) // throttle time
+	e.int32(1)
+	e.int32(0)
+	e.string(host)
+	e.int32(int32(p))
+	e.nullString()
+	e.string("cluster")
+	e.int32(0)
+	e.int32(1)
+	e.int16(0)
+	e.string(k.topic)
+	e.int8(0)
+	e.int32(k.partitions)
+	for i := int32(0); i < k.partitions; i++ {
+		e.int16(0)
+		e.int32(i)
+		e.int32(0) // leader
+		e.int32(1)
+		e.int32(0)
+		e.int32(1)
+		e.int32(0)
+	}
+	return e.Bytes()
+}
+
+// handleProduce decodes a produce request and answers it, or returns nil to
+// drop the connection.
+func (k *fakeKafka) handleProduce(d *decoder) []byte {
+	d.string() // transactional ID
+	acks := d.int16()
+	d.int32() // timeout
+	if n := d.array(); n != 1 || d.string() != k.topic {
+		k.t.Errorf("produced to %d topics, want %s", n, k.topic)
+	}
+	if n := d.array(); n != 1 {
+		k.t.Errorf("produced to %d partitions, want 1", n)
+	}
+	partition := d.int32()
+	batch := d.take(int(d.int32()))
+	r := decodeBatch(k.t, batch)
+	r.partition, r.acks = partition, acks
+
+	k.mtx.Lock()
+	defer k.mtx.Unlock()
+	if k.drop {
+		k.drop = false
+		return nil
+	}
+	code := k.produceErr
+	k.produceErr = 0
+	if code == 0 {
+		k.records = append(k.records, r)
+	}
+	var e encoder
+	e.int32(1)
+	e.string(k.topic)
+	e.int32(1)
+	e.int32(partition)
+	e.int16(code)
+	e.int64(int64(len(k.records)))
+	e.int64(-1)
+	e.int32(0) // throttle time
+	return e.Bytes()
+}
+
+// decodeBatch checks the framing of a record batch holding one record and
+// returns the record.
+func decodeBatch(t *testing.T, batch []byte) record {
+	d := decoder{b: batch}
+	if offset := d.int64(); offset != 0 {
+		t.Errorf("base offset = %d, want 0", offset)
+	}
+	if length := d.int32(); int(length) != len(d.b) {
+		t.Errorf("batch length = %d, want %d", length, len(d.b))
+	}
+	d.int32() // partition leader epoch
+	if magic := d.int8(); magic != recordBatchVersion {
+		t.Errorf("magic = %d, want %d", magic, recordBatchVersion)
+	}
+	crc := uint32(d.int32())
+	if want := crc32.Checksum(d.b, crc32.MakeTable(crc32.Castagnoli)); crc != want {
+		t.Errorf("batch CRC = %08x, want %08x", crc, want)
+	}
+	d.int16() // attributes
+	d.int32() // last offset delta
+	d.int64() // base timestamp
+	d.int64() // max timestamp
+	if producer := d.int64(); producer != -1 {
+		t.Errorf("producer ID = %d, want -1", producer)
+	}
+	d.int16() // producer epoch
+	d.int32() // base sequence
+	if n := d.int32(); n != 1 {
+		t.Fatalf("batch holds %d records, want 1", n)
+	}
+	if d.err != nil {
+		t.Fatal(d.err)
+	}
+
+	rd := bytes.NewReader(d.b)
+	varint := func() int64 {
+		v, err := binary.ReadVarint(rd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	varbytes := func() []byte {
+		n := varint()
+		if n < 0 {
+			return nil
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(rd, b); err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	if length := varint(); int(length) != rd.Len() {
+		t.Errorf("record length = %d, want %d", length, rd.Len())
+	}
+	rd.ReadByte() // attributes
+	varint()      // timestamp delta
+	varint()      // offset delta
+	r := record{key: varbytes(), value: varbytes(), headers: make(map[string]string)}
+	for i, n := 0, varint(); i < int(n); i++ {
+		r.headers[string(varbytes())] = string(varbytes())
+	}
+	if rd.Len() != 0 {
+		t.Errorf("%d bytes follow the record", rd.Len())
+	}
+	return r
+}
+
+func newTestBroker(t *testing.T, k *fakeKafka) *Broker {
+	b, err := NewBroker([]string{k.ln.Addr().String()}, k.topic, Options{ClientID: "test-client", Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func testEvent(aggregateID string) service.DomainEvent {
+	return service.DomainEvent{
+		ID:          "event-" + aggregateID,
+		Type:        service.MovementCreatedEvent,
+		AggregateID: aggregateID,
+		TenantID:    "t1",
+		Time:        time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestBrokerPublish(t *testing.T) {
+	k := newFakeKafka(t, "events", 12)
+	defer k.Close()
+	b := newTestBroker(t, k)
+	defer b.Close()
+
+	for _, id := range []string{"abc", "21", "abc"} {
+		if err := b.Publish(context.Background(), testEvent(id)); err != nil {
+			t.Fatalf("Publish(%s) = %v", id, err)
+		}
+	}
+	k.mtx.Lock()
+	defer k.mtx.Unlock()
+	if k.metadata != 1 {
+		t.Errorf("metadata was fetched %d times, want once", k.metadata)
+	}
+	if len(k.records) != 3 {
+		t.Fatalf("%d records were produced, want 3", len(k.records))
+	}
+	for _, r := range k.records {
+		if r.acks != -1 {
+			t.Errorf("acks = %d, want -1", r.acks)
+		}
+		if want := partitionFor(r.key, 12); int(r.partition) != want {
+			t.Errorf("record keyed %s went to partition %d, want %d", r.key, r.partition, want)
+		}
+		var e service.DomainEvent
+		if err := json.Unmarshal(r.value, &e); err != nil || e.AggregateID != string(r.key) {
+			t.Errorf("record value %s does not encode the event keyed %s: %v", r.value, r.key, err)
+		}
+		if r.headers["event-id"] != "event-"+string(r.key) || r.headers["event-type"] != string(service.MovementCreatedEvent) {
+			t.Errorf("record headers = %v", r.headers)
+		}
+	}
+	if k.records[0].partition != k.records[2].partition {
+		t.Error("the events of an aggregate went to different partitions")
+	}
+}
+
+func TestBrokerRefreshesStaleMetadata(t *testing.T) {
+	k := newFakeKafka(t, "events", 3)
+	defer k.Close()
+	b := newTestBroker(t, k)
+	defer b.Close()
+	ctx := context.Background()
+
+	if err := b.Publish(ctx, testEvent("a")); err != nil {
+		t.Fatal(err)
+	}
+	k.mtx.Lock()
+	k.produceErr = errNotLeaderOrFollower
+	k.mtx.Unlock()
+	if err := b.Publish(ctx, testEvent("b")); err == nil {
+		t.Fatal("Publish succeeded although the broker is not the leader")
+	}
+	if err := b.Publish(ctx, testEvent("c")); err != nil {
+		t.Fatal(err)
+	}
+	k.mtx.Lock()
+	defer k.mtx.Unlock()
+	if k.metadata != 2 || len(k.records) != 2 {
+		t.Errorf("metadata fetched %d times and %d records produced, want 2 and 2", k.metadata, len(k.records))
+	}
+}
+
+func TestBrokerReconnects(t *testing.T) {
+	k := newFakeKafka(t, "events", 3)
+	defer k.Close()
+	b := newTestBroker(t, k)
+	defer b.Close()
+	ctx := context.Background()
+
+	if err := b.Publish(ctx, testEvent("a")); err != nil {
+		t.Fatal(err)
+	}
+	k.mtx.Lock()
+	k.drop = true
+	k.mtx.Unlock()
+	if err := b.Publish(ctx, testEvent("b")); err == nil {
+		t.Fatal("Publish succeeded although the connection was dropped")
+	}
+	if err := b.Publish(ctx, testEvent("c")); err != nil {
+		t.Fatalf("Publish after a dropped connection = %v", err)
+	}
+	k.mtx.Lock()
+	defer k.mtx.Unlock()
+	// The bootstrap connection, the first to the leader and its replacement.
+	if k.conns != 3 || len(k.records) != 2 {
+		t.Errorf("%d connections and %d records, want 3 and 2", k.conns, len(k.records))
+	}
+}
+
+func TestBrokerFailsWithoutCluster(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	b, err := NewBroker([]string{addr}, "events", Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Publish(context.Background(), testEvent("a")); err == nil {
+		t.Fatal("Publish succeeded without a cluster")
+	}
+}
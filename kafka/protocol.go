@@ -0,0 +1,226 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// The Kafka APIs and versions spoken. Both are the oldest versions current
+// brokers still accept, and neither uses the flexible encoding.
+const (
+	apiProduce         = 0
+	apiMetadata        = 3
+	produceVersion     = 3
+	metadataVersion    = 4
+	recordBatchVersion = 2
+)
+
+// Error codes that mean the cached metadata is out of date.
+const (
+	errUnknownTopicOrPartition = 3
+	errLeaderNotAvailable      = 5
+	errNotLeaderOrFollower     = 6
+)
+
+var errMalformed = errors.New("malformed Kafka response")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// encoder writes the primitive types of the Kafka protocol.
+type encoder struct {
+	bytes.Buffer
+}
+
+func (e *encoder) int8(v int8)   { e.WriteByte(byte(v)) }
+func (e *encoder) int16(v int16) { e.Write([]byte{byte(v >> 8), byte(v)}) }
+
+func (e *encoder) int32(v int32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(v))
+	e.Write(b[:])
+}
+
+func (e *encoder) int64(v int64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(v))
+	e.Write(b[:])
+}
+
+// varint writes a zigzag encoded variable length integer, as records use.
+func (e *encoder) varint(v int64) {
+	var b [binary.MaxVarintLen64]byte
+	e.Write(b[:binary.PutVarint(b[:], v)])
+}
+
+func (e *encoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.WriteString(s)
+}
+
+func (e *encoder) nullString() { e.int16(-1) }
+
+func (e *encoder) bytes(b []byte) {
+	e.int32(int32(len(b)))
+	e.Write(b)
+}
+
+// varbytes writes bytes with a varint length, or a null for nil.
+func (e *encoder) varbytes(b []byte) {
+	if b == nil {
+		e.varint(-1)
+		return
+	}
+	e.varint(int64(len(b)))
+	e.Write(b)
+}
+
+// decoder reads the primitive types of the Kafka protocol. The first read
+// past the end makes every later read return zero and err report it.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err == nil && (n < 0 || n > len(d.b)) {
+		d.err = errMalformed
+	}
+	if d.err != nil {
+		// Zeros let decoding run to its end and check err once.
+		return make([]byte, 8)
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b
+}
+
+func (d *decoder) int8() int8   { return int8(d.take(1)[0]) }
+func (d *decoder) int16() int16 { return int16(binary.BigEndian.Uint16(d.take(2))) }
+func (d *decoder) int32() int32 { return int32(binary.BigEndian.Uint32(d.take(4))) }
+func (d *decoder) int64() int64 { return int64(binary.BigEndian.Uint64(d.take(8))) }
+
+func (d *decoder) string() string {
+	n := int(d.int16())
+	if n < 0 {
+		return ""
+	}
+	return string(d.take(n))
+}
+
+// array reads an array's length, guarding against lengths the rest of the
+// response could not hold.
+func (d *decoder) array() int {
+	n := int(d.int32())
+	if n < 0 {
+		return 0
+	}
+	if n > len(d.b) {
+		d.err = errMalformed
+		return 0
+	}
+	return n
+}
+
+// request frames a request of the given API with the request header.
+func request(api int16, version int16, correlationID int32, clientID string, body []byte) []byte {
+	var e encoder
+	e.int32(0)
+	e.int16(api)
+	e.int16(version)
+	e.int32(correlationID)
+	e.string(clientID)
+	e.Write(body)
+	b := e.Bytes()
+	binary.BigEndian.PutUint32(b, uint32(len(b)-4))
+	return b
+}
+
+// header is a record header.
+type header struct {
+	key   string
+	value []byte
+}
+
+// recordBatch encodes a single record in a batch of the v2 format, without
+// compression, transactions or idempotence.
+func recordBatch(key []byte, value []byte, headers []header, at time.Time) []byte {
+	var r encoder
+	r.int8(0)   // attributes
+	r.varint(0) // timestamp delta
+	r.varint(0) // offset delta
+	r.varbytes(key)
+	r.varbytes(value)
+	r.varint(int64(len(headers)))
+	for _, h := range headers {
+		r.varbytes([]byte(h.key))
+		r.varbytes(h.value)
+	}
+
+	var tail encoder
+	ms := at.UnixNano() / int64(time.Millisecond)
+	tail.int16(0)  // attributes
+	tail.int32(0)  // last offset delta
+	tail.int64(ms) // base timestamp
+	tail.int64(ms) // max timestamp
+	tail.int64(-1) // producer ID
+	tail.int16(-1) // producer epoch
+	tail.int32(-1) // base sequence
+	tail.int32(1)  // records
+	tail.varint(int64(r.Len()))
+	tail.Write(r.Bytes())
+
+	var e encoder
+	e.int64(0)                             // base offset
+	e.int32(int32(4 + 1 + 4 + tail.Len())) // batch length
+	e.int32(-1)                            // partition leader epoch
+	e.int8(recordBatchVersion)
+	e.int32(int32(crc32.Checksum(tail.Bytes(), castagnoli)))
+	e.Write(tail.Bytes())
+	return e.Bytes()
+}
+
+// murmur2 is the hash the Java client's default partitioner assigns keyed
+// records to partitions with, so that the events of an aggregate land in the
+// partition other Kafka clients would put them in.
+func murmur2(data []byte) uint32 {
+	const (
+		seed = 0x9747b28c
+		m    = 0x5bd1e995
+		r    = 24
+	)
+	length := len(data)
+	h := uint32(seed) ^ uint32(length)
+	for i := 0; i+4 <= length; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+	tail := data[length&^3:]
+	switch len(tail) {
+	case 3:
+		h ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(tail[0])
+		h *= m
+	}
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return h
+}
+
+// partitionFor picks the partition of a key the way the Java client does.
+func partitionFor(key []byte, partitions int) int {
+	return int(murmur2(key)&0x7fffffff) % partitions
+}
@@ -169,8 +169,18 @@ func ProtoCompile() error {
 		protocmd,
 		protoSrc,
 		compileOut,
+		"pb/analyticsservice.proto",
+		"pb/auditservice.proto",
 		"pb/common.proto",
+		"pb/load.proto",
+		"pb/loadingservice.proto",
+		"pb/metricsservice.proto",
 		"pb/movementservice.proto",
+		"pb/templateservice.proto",
+		"pb/tenantservice.proto",
+		"pb/userservice.proto",
+		"pb/webhookservice.proto",
+		"pb/workout.proto",
 	)
 	if err != nil {
 		return fmt.Errorf("error running protoc: %v", err)
@@ -186,8 +196,18 @@ func ProtoProxy() error {
 		protoSrc,
 		googleAPIs,
 		proxyOut,
+		"pb/analyticsservice.proto",
+		"pb/auditservice.proto",
 		"pb/common.proto",
+		"pb/load.proto",
+		"pb/loadingservice.proto",
+		"pb/metricsservice.proto",
 		"pb/movementservice.proto",
+		"pb/templateservice.proto",
+		"pb/tenantservice.proto",
+		"pb/userservice.proto",
+		"pb/webhookservice.proto",
+		"pb/workout.proto",
 	)
 	if err != nil {
 		return fmt.Errorf("error running protoc: %v", err)
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE movements ADD COLUMN version INT8 NOT NULL DEFAULT 1;
+
+-- +migrate Down
+ALTER TABLE movements DROP COLUMN version;
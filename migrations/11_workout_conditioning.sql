@@ -0,0 +1,31 @@
+-- +migrate Up
+CREATE TABLE workout_conditioning (
+    workout_id UUID NOT NULL REFERENCES workouts (id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    movement_id UUID NOT NULL,
+    sport STRING NOT NULL,
+    start_time TIMESTAMPTZ NOT NULL,
+    duration_ms INT8 NOT NULL,
+    distance FLOAT8 NOT NULL DEFAULT 0,
+    avg_heart_rate INT4 NOT NULL DEFAULT 0,
+    max_heart_rate INT4 NOT NULL DEFAULT 0,
+    PRIMARY KEY (workout_id, position),
+    INDEX (movement_id)
+);
+
+CREATE TABLE workout_laps (
+    workout_id UUID NOT NULL,
+    conditioning_position INT NOT NULL,
+    position INT NOT NULL,
+    start_time TIMESTAMPTZ NOT NULL,
+    duration_ms INT8 NOT NULL,
+    distance FLOAT8 NOT NULL DEFAULT 0,
+    avg_heart_rate INT4 NOT NULL DEFAULT 0,
+    max_heart_rate INT4 NOT NULL DEFAULT 0,
+    PRIMARY KEY (workout_id, conditioning_position, position),
+    FOREIGN KEY (workout_id, conditioning_position) REFERENCES workout_conditioning (workout_id, position) ON DELETE CASCADE
+);
+
+-- +migrate Down
+DROP TABLE workout_laps;
+DROP TABLE workout_conditioning;
@@ -0,0 +1,14 @@
+-- +migrate Up
+CREATE TABLE outbox (
+    id UUID PRIMARY KEY,
+    seq INT8 NOT NULL DEFAULT unique_rowid(),
+    event_type STRING NOT NULL,
+    aggregate_type STRING NOT NULL,
+    aggregate_id STRING NOT NULL,
+    tenant_id STRING NOT NULL,
+    event_time TIMESTAMPTZ NOT NULL,
+    data JSONB NOT NULL
+);
+
+-- +migrate Down
+DROP TABLE outbox;
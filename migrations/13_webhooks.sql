@@ -0,0 +1,35 @@
+-- +migrate Up
+CREATE TABLE webhooks (
+    id UUID PRIMARY KEY,
+    tenant_id STRING NOT NULL,
+    url STRING NOT NULL,
+    event_types STRING[] NOT NULL,
+    description STRING NOT NULL DEFAULT '',
+    secret STRING NOT NULL,
+    disabled BOOL NOT NULL DEFAULT false,
+    create_time TIMESTAMPTZ NOT NULL,
+    update_time TIMESTAMPTZ NOT NULL,
+    INDEX (tenant_id, create_time)
+);
+
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY,
+    tenant_id STRING NOT NULL,
+    webhook_id UUID NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    event_type STRING NOT NULL,
+    payload JSONB NOT NULL,
+    state STRING NOT NULL,
+    failures INT4 NOT NULL DEFAULT 0,
+    attempts JSONB NOT NULL DEFAULT '[]',
+    next_attempt_time TIMESTAMPTZ NOT NULL,
+    create_time TIMESTAMPTZ NOT NULL,
+    update_time TIMESTAMPTZ NOT NULL,
+    UNIQUE (webhook_id, event_id),
+    INDEX (state, next_attempt_time),
+    INDEX (tenant_id, create_time DESC)
+);
+
+-- +migrate Down
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
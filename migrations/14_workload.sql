@@ -0,0 +1,17 @@
+-- +migrate Up
+ALTER TABLE workouts ADD COLUMN session_rpe DECIMAL NOT NULL DEFAULT 0;
+ALTER TABLE workouts ADD COLUMN duration_ms INT8 NOT NULL DEFAULT 0;
+
+CREATE TABLE workload_thresholds (
+    tenant_id STRING PRIMARY KEY,
+    max_acwr FLOAT8 NOT NULL DEFAULT 0,
+    min_acwr FLOAT8 NOT NULL DEFAULT 0,
+    max_monotony FLOAT8 NOT NULL DEFAULT 0,
+    max_strain FLOAT8 NOT NULL DEFAULT 0,
+    update_time TIMESTAMPTZ NOT NULL
+);
+
+-- +migrate Down
+DROP TABLE workload_thresholds;
+ALTER TABLE workouts DROP COLUMN duration_ms;
+ALTER TABLE workouts DROP COLUMN session_rpe;
@@ -0,0 +1,17 @@
+-- +migrate Up
+CREATE TABLE body_metrics (
+    id UUID PRIMARY KEY,
+    tenant_id STRING NOT NULL,
+    athlete_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    metric_type STRING NOT NULL,
+    site STRING NOT NULL DEFAULT '',
+    value FLOAT8 NOT NULL,
+    unit STRING NOT NULL,
+    readiness JSONB,
+    measured_at TIMESTAMPTZ NOT NULL,
+    create_time TIMESTAMPTZ NOT NULL,
+    INDEX (tenant_id, athlete_id, metric_type, measured_at)
+);
+
+-- +migrate Down
+DROP TABLE body_metrics;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE workout_sets ADD COLUMN weight_unit STRING NOT NULL DEFAULT 'kg';
+
+-- +migrate Down
+ALTER TABLE workout_sets DROP COLUMN weight_unit;
@@ -0,0 +1,11 @@
+-- +migrate Up
+CREATE TABLE plate_inventories (
+    tenant_id STRING PRIMARY KEY,
+    bar_weight DECIMAL NOT NULL DEFAULT 0,
+    bar_unit STRING NOT NULL DEFAULT 'kg',
+    plates JSONB NOT NULL DEFAULT '[]',
+    update_time TIMESTAMPTZ NOT NULL
+);
+
+-- +migrate Down
+DROP TABLE plate_inventories;
@@ -0,0 +1,19 @@
+-- +migrate Up
+ALTER TABLE workouts ADD COLUMN planned BOOL NOT NULL DEFAULT false;
+
+CREATE TABLE workout_templates (
+    id UUID PRIMARY KEY,
+    tenant_id STRING NOT NULL,
+    owner_id STRING NOT NULL,
+    title STRING NOT NULL,
+    scope STRING NOT NULL DEFAULT 'user',
+    shared_with STRING[] NOT NULL DEFAULT ARRAY[],
+    sets JSONB NOT NULL DEFAULT '[]',
+    create_time TIMESTAMPTZ NOT NULL,
+    update_time TIMESTAMPTZ NOT NULL,
+    INDEX (tenant_id, update_time)
+);
+
+-- +migrate Down
+DROP TABLE workout_templates;
+ALTER TABLE workouts DROP COLUMN planned;
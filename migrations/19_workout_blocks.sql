@@ -0,0 +1,24 @@
+-- +migrate Up
+ALTER TABLE workout_sets ADD COLUMN block STRING NOT NULL DEFAULT '';
+
+CREATE TABLE workout_blocks (
+    workout_id UUID NOT NULL REFERENCES workouts (id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    label STRING NOT NULL,
+    type STRING NOT NULL,
+    rounds INT4 NOT NULL DEFAULT 0,
+    interval_ms INT8 NOT NULL DEFAULT 0,
+    time_cap_ms INT8 NOT NULL DEFAULT 0,
+    score_rounds INT4 NOT NULL DEFAULT 0,
+    score_reps INT4 NOT NULL DEFAULT 0,
+    score_time_ms INT8 NOT NULL DEFAULT 0,
+    PRIMARY KEY (workout_id, position),
+    UNIQUE (workout_id, label)
+);
+
+ALTER TABLE workout_templates ADD COLUMN blocks JSONB NOT NULL DEFAULT '[]';
+
+-- +migrate Down
+ALTER TABLE workout_templates DROP COLUMN blocks;
+DROP TABLE workout_blocks;
+ALTER TABLE workout_sets DROP COLUMN block;
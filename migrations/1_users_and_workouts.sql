@@ -0,0 +1,44 @@
+-- +migrate Up
+CREATE TABLE users (
+    id UUID PRIMARY KEY,
+    tenant_id STRING NOT NULL,
+    display_name STRING NOT NULL,
+    email STRING NOT NULL,
+    role STRING NOT NULL CHECK (role IN ('athlete', 'coach', 'admin')),
+    create_time TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, email)
+);
+
+CREATE TABLE coach_athletes (
+    tenant_id STRING NOT NULL,
+    coach_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    athlete_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    PRIMARY KEY (coach_id, athlete_id),
+    INDEX (athlete_id)
+);
+
+CREATE TABLE workouts (
+    id UUID PRIMARY KEY,
+    tenant_id STRING NOT NULL,
+    athlete_id UUID NOT NULL REFERENCES users (id),
+    title STRING NOT NULL DEFAULT '',
+    performed_at TIMESTAMPTZ NOT NULL,
+    INDEX (tenant_id, athlete_id, performed_at DESC)
+);
+
+CREATE TABLE workout_sets (
+    workout_id UUID NOT NULL REFERENCES workouts (id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    movement_id UUID NOT NULL,
+    reps INT NOT NULL,
+    weight DECIMAL NOT NULL DEFAULT 0,
+    rpe DECIMAL NOT NULL DEFAULT 0,
+    PRIMARY KEY (workout_id, position),
+    INDEX (movement_id)
+);
+
+-- +migrate Down
+DROP TABLE workout_sets;
+DROP TABLE workouts;
+DROP TABLE coach_athletes;
+DROP TABLE users;
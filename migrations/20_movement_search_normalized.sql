@@ -0,0 +1,7 @@
+-- +migrate Up
+DROP INDEX movements@movements_movement_name_trgm_idx;
+CREATE INVERTED INDEX movements_normalized_name_trgm_idx ON movements (btrim(regexp_replace(lower(movement_name), '[^\pL\pN]+', ' ', 'g')) gin_trgm_ops);
+
+-- +migrate Down
+DROP INDEX movements@movements_normalized_name_trgm_idx;
+CREATE INVERTED INDEX movements_movement_name_trgm_idx ON movements (lower(movement_name) gin_trgm_ops);
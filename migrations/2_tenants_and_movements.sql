@@ -0,0 +1,37 @@
+-- +migrate Up
+CREATE TABLE tenants (
+    id UUID PRIMARY KEY,
+    display_name STRING NOT NULL,
+    state STRING NOT NULL CHECK (state IN ('active', 'suspended')),
+    default_unit STRING NOT NULL CHECK (default_unit IN ('kg', 'lb')),
+    week_start INT NOT NULL CHECK (week_start BETWEEN 0 AND 6),
+    time_zone STRING NOT NULL,
+    create_time TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE tenant_deletions (
+    tenant_id UUID PRIMARY KEY,
+    display_name STRING NOT NULL,
+    requested_by UUID NOT NULL,
+    reason STRING NOT NULL,
+    delete_time TIMESTAMPTZ NOT NULL,
+    row_counts JSONB NOT NULL
+);
+
+CREATE TABLE movements (
+    id UUID PRIMARY KEY,
+    tenant_id STRING NOT NULL,
+    movement_name STRING NOT NULL,
+    movement_category_id STRING NOT NULL DEFAULT '',
+    INDEX (tenant_id, movement_category_id, movement_name)
+);
+
+ALTER TABLE users DROP CONSTRAINT check_role;
+ALTER TABLE users ADD CONSTRAINT check_role CHECK (role IN ('athlete', 'coach', 'admin', 'operator'));
+
+-- +migrate Down
+ALTER TABLE users DROP CONSTRAINT check_role;
+ALTER TABLE users ADD CONSTRAINT check_role CHECK (role IN ('athlete', 'coach', 'admin'));
+DROP TABLE movements;
+DROP TABLE tenant_deletions;
+DROP TABLE tenants;
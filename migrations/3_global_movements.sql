@@ -0,0 +1,16 @@
+-- +migrate Up
+ALTER TABLE movements ADD COLUMN forked_from UUID REFERENCES movements (id) ON DELETE SET NULL;
+CREATE UNIQUE INDEX movements_tenant_id_forked_from_key ON movements (tenant_id, forked_from);
+
+CREATE TABLE movement_overrides (
+    tenant_id STRING NOT NULL,
+    movement_id UUID NOT NULL REFERENCES movements (id) ON DELETE CASCADE,
+    hidden BOOL NOT NULL DEFAULT false,
+    alias STRING NOT NULL DEFAULT '',
+    PRIMARY KEY (tenant_id, movement_id)
+);
+
+-- +migrate Down
+DROP TABLE movement_overrides;
+DROP INDEX movements@movements_tenant_id_forked_from_key;
+ALTER TABLE movements DROP COLUMN forked_from;
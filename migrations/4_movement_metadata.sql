@@ -0,0 +1,19 @@
+-- +migrate Up
+ALTER TABLE movements ADD COLUMN equipment STRING NOT NULL DEFAULT '';
+ALTER TABLE movements ADD COLUMN primary_muscles STRING[] NOT NULL DEFAULT '{}';
+ALTER TABLE movements ADD COLUMN secondary_muscles STRING[] NOT NULL DEFAULT '{}';
+ALTER TABLE movements ADD COLUMN laterality STRING NOT NULL DEFAULT 'bilateral'
+    CHECK (laterality IN ('bilateral', 'unilateral'));
+ALTER TABLE movements ADD COLUMN load_type STRING NOT NULL DEFAULT 'external'
+    CHECK (load_type IN ('external', 'bodyweight', 'assisted', 'time', 'distance'));
+ALTER TABLE movements ADD COLUMN links STRING[] NOT NULL DEFAULT '{}';
+CREATE INDEX movements_tenant_id_equipment_idx ON movements (tenant_id, equipment);
+
+-- +migrate Down
+DROP INDEX movements@movements_tenant_id_equipment_idx;
+ALTER TABLE movements DROP COLUMN links;
+ALTER TABLE movements DROP COLUMN load_type;
+ALTER TABLE movements DROP COLUMN laterality;
+ALTER TABLE movements DROP COLUMN secondary_muscles;
+ALTER TABLE movements DROP COLUMN primary_muscles;
+ALTER TABLE movements DROP COLUMN equipment;
@@ -0,0 +1,5 @@
+-- +migrate Up
+CREATE INVERTED INDEX movements_movement_name_trgm_idx ON movements (lower(movement_name) gin_trgm_ops);
+
+-- +migrate Down
+DROP INDEX movements@movements_movement_name_trgm_idx;
@@ -0,0 +1,14 @@
+-- +migrate Up
+ALTER TABLE movements ADD COLUMN aliases STRING[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE movement_redirects (
+    tenant_id STRING NOT NULL,
+    from_id UUID NOT NULL,
+    to_id UUID NOT NULL,
+    PRIMARY KEY (tenant_id, from_id),
+    INDEX (tenant_id, to_id)
+);
+
+-- +migrate Down
+DROP TABLE movement_redirects;
+ALTER TABLE movements DROP COLUMN aliases;
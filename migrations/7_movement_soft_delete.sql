@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE movements ADD COLUMN delete_time TIMESTAMPTZ;
+CREATE INDEX movements_delete_time_idx ON movements (delete_time) WHERE delete_time IS NOT NULL;
+
+-- +migrate Down
+DROP INDEX movements@movements_delete_time_idx;
+ALTER TABLE movements DROP COLUMN delete_time;
@@ -0,0 +1,17 @@
+-- +migrate Up
+CREATE TABLE audit_events (
+    id UUID PRIMARY KEY,
+    tenant_id STRING NOT NULL,
+    actor_id STRING NOT NULL,
+    method STRING NOT NULL,
+    resource STRING NOT NULL,
+    before JSONB,
+    after JSONB,
+    correlation_id STRING NOT NULL DEFAULT '',
+    event_time TIMESTAMPTZ NOT NULL,
+    INDEX (tenant_id, event_time DESC),
+    INDEX (tenant_id, resource, event_time DESC)
+);
+
+-- +migrate Down
+DROP TABLE audit_events;
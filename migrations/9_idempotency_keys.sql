@@ -0,0 +1,11 @@
+-- +migrate Up
+CREATE TABLE idempotency_keys (
+    key STRING PRIMARY KEY,
+    request_hash STRING NOT NULL,
+    response BYTES,
+    complete BOOL NOT NULL DEFAULT false,
+    expire_time TIMESTAMPTZ NOT NULL
+) WITH (ttl_expiration_expression = 'expire_time');
+
+-- +migrate Down
+DROP TABLE idempotency_keys;
@@ -0,0 +1,355 @@
+// Package nats publishes domain events to a NATS server. It speaks the NATS
+// client protocol directly and implements only what publishing needs. Each
+// event is published to the subject of its type under a prefix, such as
+// "workout-manager.movement.created", as the JSON encoding of the event.
+//
+// With JetStream enabled, every event is acknowledged by the stream that
+// captures its subject, and its ID is sent as the Nats-Msg-Id header so that
+// the stream drops events the relay redelivers within its duplicate window.
+// Without it, a publication only waits until the server has processed it;
+// core NATS delivers to the subscribers connected at that moment.
+package nats
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+// DefaultPort is the port of NATS URLs that name none.
+const DefaultPort = "4222"
+
+// Options configure a Broker.
+type Options struct {
+	// Prefix is prepended to the subject of every event.
+	Prefix string
+	// JetStream waits for the acknowledgement of a stream.
+	JetStream bool
+	// Timeout bounds connecting and each publication when the context has
+	// no earlier deadline. It defaults to five seconds.
+	Timeout time.Duration
+}
+
+// Broker implements service.Broker on a single connection, which is opened
+// on first use and reopened after any error. Publications are serialized,
+// which keeps the events of an aggregate in order.
+type Broker struct {
+	addr    string
+	user    *url.Userinfo
+	opts    Options
+	mtx     sync.Mutex
+	conn    net.Conn
+	r       *bufio.Reader
+	inbox   string
+	replies int
+}
+
+// NewBroker returns a Broker for the server at a nats:// URL, which may carry
+// a user and password, or a token as its user.
+func NewBroker(rawurl string, opts Options) (*Broker, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid NATS URL")
+	}
+	if u.Scheme != "nats" || u.Hostname() == "" {
+		return nil, errors.Errorf("invalid NATS URL %q; expected nats://host:port", rawurl)
+	}
+	port := u.Port()
+	if port == "" {
+		port = DefaultPort
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	return &Broker{addr: net.JoinHostPort(u.Hostname(), port), user: u.User, opts: opts}, nil
+}
+
+// Subject returns the subject an event is published to.
+func (b *Broker) Subject(e service.DomainEvent) string {
+	if b.opts.Prefix == "" {
+		return string(e.Type)
+	}
+	return b.opts.Prefix + "." + string(e.Type)
+}
+
+// Publish implements service.Broker.
+func (b *Broker) Publish(ctx context.Context, e service.DomainEvent) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode event")
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	deadline := time.Now().Add(b.opts.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if b.conn == nil {
+		if err := b.connect(ctx, deadline); err != nil {
+			return err
+		}
+	}
+	if err := b.conn.SetDeadline(deadline); err != nil {
+		b.close()
+		return errors.Wrap(err, "failed to set NATS deadline")
+	}
+	if b.opts.JetStream {
+		err = b.publishJetStream(b.Subject(e), e.ID, payload)
+	} else {
+		err = b.publishCore(b.Subject(e), payload)
+	}
+	if err != nil {
+		if _, ok := err.(serverError); !ok {
+			b.close()
+		}
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection, if any.
+func (b *Broker) Close() error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.close()
+}
+
+func (b *Broker) close() error {
+	if b.conn == nil {
+		return nil
+	}
+	err := b.conn.Close()
+	b.conn, b.r = nil, nil
+	return err
+}
+
+// serverError is an error the server reported on a working connection.
+type serverError string
+
+func (e serverError) Error() string {
+	return "NATS: " + string(e)
+}
+
+// connect opens the connection, introduces the client and, for JetStream,
+// subscribes to the inbox acknowledgements are sent to.
+func (b *Broker) connect(ctx context.Context, deadline time.Time) error {
+	d := net.Dialer{Deadline: deadline}
+	conn, err := d.DialContext(ctx, "tcp", b.addr)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to NATS")
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return errors.Wrap(err, "failed to set NATS deadline")
+	}
+	b.conn, b.r = conn, bufio.NewReader(conn)
+	line, err := b.readLine()
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		b.close()
+		return errors.Errorf("%s is not a NATS server", b.addr)
+	}
+	connect := map[string]interface{}{
+		"verbose":       false,
+		"pedantic":      false,
+		"lang":          "go",
+		"name":          "workout-manager",
+		"protocol":      1,
+		"headers":       true,
+		"no_responders": true,
+	}
+	if b.user != nil {
+		if pass, ok := b.user.Password(); ok {
+			connect["user"], connect["pass"] = b.user.Username(), pass
+		} else {
+			connect["auth_token"] = b.user.Username()
+		}
+	}
+	options, _ := json.Marshal(connect)
+	cmd := "CONNECT " + string(options) + "\r\n"
+	if b.opts.JetStream {
+		b.inbox = "_INBOX." + strings.Replace(uuid.New().String(), "-", "", -1)
+		cmd += "SUB " + b.inbox + ".* 1\r\n"
+	}
+	err = b.write(cmd + "PING\r\n")
+	if err == nil {
+		err = b.awaitPong()
+	}
+	if err != nil {
+		b.close()
+		return errors.Wrap(err, "failed to connect to NATS")
+	}
+	return nil
+}
+
+// publishCore publishes a message and waits until the server has processed
+// it, which a PING sent after it tells.
+func (b *Broker) publishCore(subject string, payload []byte) error {
+	msg := fmt.Sprintf("PUB %s %d\r\n%s\r\nPING\r\n", subject, len(payload), payload)
+	if err := b.write(msg); err != nil {
+		return err
+	}
+	return b.awaitPong()
+}
+
+// publishJetStream publishes a message with its ID as a header and waits for
+// the stream's acknowledgement.
+func (b *Broker) publishJetStream(subject string, id string, payload []byte) error {
+	b.replies++
+	reply := b.inbox + "." + strconv.Itoa(b.replies)
+	header := "NATS/1.0\r\nNats-Msg-Id: " + id + "\r\n\r\n"
+	msg := fmt.Sprintf("HPUB %s %s %d %d\r\n%s%s\r\n", subject, reply, len(header), len(header)+len(payload), header, payload)
+	if err := b.write(msg); err != nil {
+		return err
+	}
+	for {
+		op, err := b.readOp()
+		if err != nil {
+			return err
+		}
+		if op.subject != reply {
+			// Only the reply to this publication answers it.
+			continue
+		}
+		if op.status != "" {
+			if strings.HasPrefix(op.status, "503") {
+				return serverError("no JetStream stream captures subject " + subject)
+			}
+			return serverError("publication failed with status " + op.status)
+		}
+		var ack struct {
+			Stream string `json:"stream"`
+			Error  *struct {
+				Code        int    `json:"code"`
+				Description string `json:"description"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(op.payload, &ack); err != nil {
+			return errors.Wrap(err, "invalid JetStream acknowledgement")
+		}
+		if ack.Error != nil {
+			return serverError(fmt.Sprintf("JetStream error %d: %s", ack.Error.Code, ack.Error.Description))
+		}
+		return nil
+	}
+}
+
+func (b *Broker) write(s string) error {
+	_, err := io.WriteString(b.conn, s)
+	return errors.Wrap(err, "failed to write to NATS")
+}
+
+func (b *Broker) readLine() (string, error) {
+	line, err := b.r.ReadString('\n')
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read from NATS")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// awaitPong reads until the server answers a PING.
+func (b *Broker) awaitPong() error {
+	for {
+		op, err := b.readOp()
+		if err != nil {
+			return err
+		}
+		if op.pong {
+			return nil
+		}
+	}
+}
+
+// op is a protocol message from the server that a publisher cares about:
+// a PONG or a message delivered to the inbox.
+type op struct {
+	pong    bool
+	subject string
+	status  string
+	payload []byte
+}
+
+// readOp reads the next PONG or message, answering PINGs and skipping the
+// rest. Errors the server reports end the read.
+func (b *Broker) readOp() (op, error) {
+	for {
+		line, err := b.readLine()
+		if err != nil {
+			return op{}, err
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "PING":
+			if err := b.write("PONG\r\n"); err != nil {
+				return op{}, err
+			}
+		case "PONG":
+			return op{pong: true}, nil
+		case "-ERR":
+			return op{}, serverError(strings.Trim(strings.TrimPrefix(line, fields[0]), " '"))
+		case "MSG":
+			// MSG <subject> <sid> [reply-to] <#bytes>
+			if len(fields) < 4 {
+				return op{}, errors.Errorf("malformed NATS message %q", line)
+			}
+			data, err := b.readPayload(fields[len(fields)-1])
+			if err != nil {
+				return op{}, err
+			}
+			return op{subject: fields[1], payload: data}, nil
+		case "HMSG":
+			// HMSG <subject> <sid> [reply-to] <#header bytes> <#total bytes>
+			if len(fields) < 5 {
+				return op{}, errors.Errorf("malformed NATS message %q", line)
+			}
+			data, err := b.readPayload(fields[len(fields)-1])
+			if err != nil {
+				return op{}, err
+			}
+			n, err := strconv.Atoi(fields[len(fields)-2])
+			if err != nil || n > len(data) {
+				return op{}, errors.Errorf("malformed NATS message %q", line)
+			}
+			return op{subject: fields[1], status: headerStatus(data[:n]), payload: data[n:]}, nil
+		}
+	}
+}
+
+// readPayload reads a message payload of the given size and the line break
+// that ends it.
+func (b *Broker) readPayload(size string) ([]byte, error) {
+	n, err := strconv.Atoi(size)
+	if err != nil || n < 0 {
+		return nil, errors.Errorf("malformed NATS message size %q", size)
+	}
+	data := make([]byte, n+2)
+	if _, err := io.ReadFull(b.r, data); err != nil {
+		return nil, errors.Wrap(err, "failed to read from NATS")
+	}
+	return data[:n], nil
+}
+
+// headerStatus returns the status of a message's headers, such as "503" for
+// a request nobody answered, or "" when the headers carry none.
+func headerStatus(header []byte) string {
+	line := string(header)
+	if i := strings.Index(line, "\r\n"); i >= 0 {
+		line = line[:i]
+	}
+	return strings.TrimSpace(strings.TrimPrefix(line, "NATS/1.0"))
+}
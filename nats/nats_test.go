@@ -0,0 +1,345 @@
+package nats
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"workout-manager-service/pkg/service"
+)
+
+// fakeNATS is a NATS server that hands every connection to a script, after
+// greeting it with INFO.
+type fakeNATS struct {
+	t      *testing.T
+	ln     net.Listener
+	script func(c *fakeConn)
+
+	mtx   sync.Mutex
+	conns int
+}
+
+func newFakeNATS(t *testing.T, script func(c *fakeConn)) *fakeNATS {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeNATS{t: t, ln: ln, script: script}
+	go s.serve()
+	return s
+}
+
+func (s *fakeNATS) URL(userinfo string) string {
+	return "nats://" + userinfo + s.ln.Addr().String()
+}
+
+func (s *fakeNATS) Close() { s.ln.Close() }
+
+func (s *fakeNATS) connections() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.conns
+}
+
+func (s *fakeNATS) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mtx.Lock()
+		s.conns++
+		n := s.conns
+		s.mtx.Unlock()
+		go func() {
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(5 * time.Second))
+			c := &fakeConn{t: s.t, n: n, conn: conn, r: bufio.NewReader(conn)}
+			c.write(`INFO {"server_id":"fake","version":"2.10.0","headers":true,"max_payload":1048576}` + "\r\n")
+			s.script(c)
+		}()
+	}
+}
+
+// fakeConn is the server side of a connection. The n-th connection a
+// fakeNATS accepts is numbered n.
+type fakeConn struct {
+	t    *testing.T
+	n    int
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func (c *fakeConn) write(s string) {
+	io.WriteString(c.conn, s)
+}
+
+func (c *fakeConn) line() string {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return ""
+	}
+	if !strings.HasSuffix(line, "\r\n") {
+		c.t.Errorf("line %q does not end with CRLF", line)
+	}
+	return strings.TrimSuffix(line, "\r\n")
+}
+
+// expect reads a line and checks that it starts with prefix.
+func (c *fakeConn) expect(prefix string) string {
+	line := c.line()
+	if !strings.HasPrefix(line, prefix) {
+		c.t.Errorf("got %q, want a line starting with %q", line, prefix)
+	}
+	return line
+}
+
+// payload reads a payload of the size in field i of line and its CRLF.
+func (c *fakeConn) payload(line string, i int) []byte {
+	fields := strings.Fields(line)
+	if i >= len(fields) {
+		c.t.Errorf("%q has no field %d", line, i)
+		return nil
+	}
+	n, err := strconv.Atoi(fields[i])
+	if err != nil {
+		c.t.Errorf("%q has a malformed size: %v", line, err)
+		return nil
+	}
+	data := make([]byte, n+2)
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		c.t.Errorf("failed to read payload: %v", err)
+		return nil
+	}
+	if string(data[n:]) != "\r\n" {
+		c.t.Errorf("payload of %q is not %d bytes followed by CRLF", line, n)
+	}
+	return data[:n]
+}
+
+// handshake reads the CONNECT a client opens with and returns its options.
+func (c *fakeConn) handshake() map[string]interface{} {
+	line := c.expect("CONNECT ")
+	var options map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "CONNECT ")), &options); err != nil {
+		c.t.Errorf("malformed CONNECT %q: %v", line, err)
+	}
+	return options
+}
+
+func testEvent(id string) service.DomainEvent {
+	return service.DomainEvent{
+		ID:          id,
+		Type:        service.MovementCreatedEvent,
+		AggregateID: "squat",
+		TenantID:    "t1",
+		Time:        time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestBrokerPublishCore(t *testing.T) {
+	published := make(chan []byte, 2)
+	server := newFakeNATS(t, func(c *fakeConn) {
+		options := c.handshake()
+		if options["user"] != "relay" || options["pass"] != "secret" || options["verbose"] != false {
+			t.Errorf("CONNECT options = %v", options)
+		}
+		c.expect("PING")
+		c.write("PONG\r\n")
+		for i := 0; i < 2; i++ {
+			line := c.expect("PUB ")
+			if fields := strings.Fields(line); len(fields) != 3 || fields[1] != "wm.movement.created" {
+				t.Errorf("got %q, want PUB wm.movement.created <size>", line)
+			}
+			published <- c.payload(line, 2)
+			c.expect("PING")
+			// A server may PING at any time; the client must answer before
+			// its own PING is answered.
+			c.write("PING\r\n")
+			c.expect("PONG")
+			c.write("PONG\r\n")
+		}
+	})
+	defer server.Close()
+	b, err := NewBroker(server.URL("relay:secret@"), Options{Prefix: "wm", Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	for _, id := range []string{"e1", "e2"} {
+		if err := b.Publish(context.Background(), testEvent(id)); err != nil {
+			t.Fatalf("Publish(%s) = %v", id, err)
+		}
+		var e service.DomainEvent
+		if err := json.Unmarshal(<-published, &e); err != nil || e.ID != id {
+			t.Fatalf("published %+v, %v, want event %s", e, err, id)
+		}
+	}
+	if n := server.connections(); n != 1 {
+		t.Errorf("%d connections were opened, want 1", n)
+	}
+}
+
+func TestBrokerPublishJetStream(t *testing.T) {
+	server := newFakeNATS(t, func(c *fakeConn) {
+		if options := c.handshake(); options["auth_token"] != "token" || options["headers"] != true {
+			t.Errorf("CONNECT options = %v", options)
+		}
+		sub := strings.Fields(c.expect("SUB "))
+		c.expect("PING")
+		c.write("PONG\r\n")
+		if len(sub) != 3 || !strings.HasPrefix(sub[1], "_INBOX.") || !strings.HasSuffix(sub[1], ".*") {
+			t.Errorf("subscribed with %v, want SUB _INBOX.<id>.* <sid>", sub)
+			return
+		}
+		for _, ack := range []string{
+			`{"stream":"EVENTS","seq":1}`,
+			`{"error":{"code":503,"description":"stream is offline"}}`,
+		} {
+			line := c.expect("HPUB ")
+			fields := strings.Fields(line)
+			if len(fields) != 5 || fields[1] != "movement.created" || !strings.HasPrefix(fields[2], strings.TrimSuffix(sub[1], "*")) {
+				t.Errorf("got %q, want HPUB movement.created <inbox reply> <sizes>", line)
+				return
+			}
+			data := c.payload(line, 4)
+			n, _ := strconv.Atoi(fields[3])
+			if header := string(data[:n]); header != "NATS/1.0\r\nNats-Msg-Id: e1\r\n\r\n" {
+				t.Errorf("headers = %q", header)
+			}
+			// A message for another reply is ignored.
+			c.write("MSG " + sub[1][:len(sub[1])-1] + "999 " + sub[2] + " 2\r\n{}\r\n")
+			c.write("MSG " + fields[2] + " " + sub[2] + " " + strconv.Itoa(len(ack)) + "\r\n" + ack + "\r\n")
+		}
+		line := c.expect("HPUB ")
+		c.payload(line, 4)
+		reply := strings.Fields(line)[2]
+		c.write("HMSG " + reply + " " + sub[2] + " 16 16\r\nNATS/1.0 503\r\n\r\n\r\n")
+	})
+	defer server.Close()
+	b, err := NewBroker(server.URL("token@"), Options{JetStream: true, Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	ctx := context.Background()
+
+	if err := b.Publish(ctx, testEvent("e1")); err != nil {
+		t.Fatalf("acknowledged publication = %v", err)
+	}
+	err = b.Publish(ctx, testEvent("e1"))
+	if _, ok := err.(serverError); !ok || !strings.Contains(err.Error(), "stream is offline") {
+		t.Fatalf("rejected publication = %v, want the JetStream error", err)
+	}
+	err = b.Publish(ctx, testEvent("e1"))
+	if _, ok := err.(serverError); !ok || !strings.Contains(err.Error(), "no JetStream stream") {
+		t.Fatalf("unanswered publication = %v, want no responders", err)
+	}
+	if n := server.connections(); n != 1 {
+		t.Errorf("%d connections were opened, want 1; server errors must not drop the connection", n)
+	}
+}
+
+func TestBrokerReconnects(t *testing.T) {
+	server := newFakeNATS(t, func(c *fakeConn) {
+		c.handshake()
+		c.expect("PING")
+		c.write("PONG\r\n")
+		line := c.expect("PUB ")
+		c.payload(line, 2)
+		c.expect("PING")
+		if c.n == 1 {
+			// The first connection dies before the publication is confirmed.
+			return
+		}
+		c.write("PONG\r\n")
+	})
+	defer server.Close()
+	b, err := NewBroker(server.URL(""), Options{Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	ctx := context.Background()
+
+	if err := b.Publish(ctx, testEvent("e1")); err == nil {
+		t.Fatal("Publish succeeded although the connection died")
+	}
+	if err := b.Publish(ctx, testEvent("e1")); err != nil {
+		t.Fatalf("Publish after the connection died = %v", err)
+	}
+	if n := server.connections(); n != 2 {
+		t.Errorf("%d connections were opened, want 2", n)
+	}
+}
+
+func TestBrokerServerError(t *testing.T) {
+	server := newFakeNATS(t, func(c *fakeConn) {
+		c.handshake()
+		c.expect("PING")
+		c.write("-ERR 'Authorization Violation'\r\n")
+	})
+	defer server.Close()
+	b, err := NewBroker(server.URL("relay:wrong@"), Options{Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	err = b.Publish(context.Background(), testEvent("e1"))
+	if err == nil || !strings.Contains(err.Error(), "Authorization Violation") {
+		t.Fatalf("Publish = %v, want the authorization error", err)
+	}
+}
+
+func TestBrokerTimesOut(t *testing.T) {
+	server := newFakeNATS(t, func(c *fakeConn) {
+		c.handshake()
+		c.expect("PING")
+		// Never answer.
+		c.line()
+	})
+	defer server.Close()
+	b, err := NewBroker(server.URL(""), Options{Timeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	begin := time.Now()
+	if err := b.Publish(context.Background(), testEvent("e1")); err == nil {
+		t.Fatal("Publish succeeded without an answer")
+	}
+	if took := time.Since(begin); took > time.Second {
+		t.Errorf("Publish gave up after %s, want about the timeout", took)
+	}
+}
+
+func TestNewBroker(t *testing.T) {
+	for _, tc := range []struct {
+		url  string
+		addr string
+	}{
+		{"nats://localhost", "localhost:" + DefaultPort},
+		{"nats://10.0.0.1:4333", "10.0.0.1:4333"},
+		{"nats://user:pass@[::1]:4222", "[::1]:4222"},
+		{"http://localhost:4222", ""},
+		{"nats://:4222", ""},
+	} {
+		b, err := NewBroker(tc.url, Options{})
+		switch {
+		case tc.addr == "" && err == nil:
+			t.Errorf("NewBroker(%s) accepted an invalid URL", tc.url)
+		case tc.addr != "" && err != nil:
+			t.Errorf("NewBroker(%s) = %v", tc.url, err)
+		case tc.addr != "" && b.addr != tc.addr:
+			t.Errorf("NewBroker(%s) connects to %s, want %s", tc.url, b.addr, tc.addr)
+		}
+	}
+}
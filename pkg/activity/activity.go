@@ -0,0 +1,198 @@
+// Package activity parses the activity files that GPS watches and
+// ergometers record, in Garmin's binary FIT format or the older TCX XML
+// format, into sessions of time, distance, heart rate and laps. It keeps
+// summaries only; the per-second samples of a file are used to fill in
+// summaries the device did not record and then dropped.
+package activity
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+// Format is an activity file format.
+type Format string
+
+// The activity file formats that can be parsed.
+const (
+	FIT Format = "fit"
+	TCX Format = "tcx"
+)
+
+// MaxFileSize bounds the size in bytes of a parsed activity file. A FIT file
+// of a day-long activity recorded every second stays well below it.
+const MaxFileSize = 32 << 20
+
+// Sport is the kind of activity a session records.
+type Sport string
+
+// The sports sessions are classified as. Devices record many more; those
+// fall back to OtherSport.
+const (
+	Running    Sport = "running"
+	Cycling    Sport = "cycling"
+	Rowing     Sport = "rowing"
+	Swimming   Sport = "swimming"
+	Walking    Sport = "walking"
+	Hiking     Sport = "hiking"
+	Skiing     Sport = "skiing"
+	Elliptical Sport = "elliptical"
+	OtherSport Sport = "other"
+)
+
+// Session is one continuous activity of a single sport. Duration is the time
+// the device's timer ran, excluding pauses, and Distance is in meters. Heart
+// rates are in beats per minute and zero when unrecorded.
+type Session struct {
+	Sport        Sport
+	StartTime    time.Time
+	Duration     time.Duration
+	Distance     float64
+	AvgHeartRate int32
+	MaxHeartRate int32
+	Laps         []Lap
+}
+
+// Lap is one lap or interval of a session, with the same units.
+type Lap struct {
+	StartTime    time.Time
+	Duration     time.Duration
+	Distance     float64
+	AvgHeartRate int32
+	MaxHeartRate int32
+}
+
+// Parse reads an activity file. Multisport files, such as a triathlon,
+// yield one session per sport, in the order they were performed.
+func Parse(format Format, r io.Reader) ([]Session, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, MaxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxFileSize {
+		return nil, fmt.Errorf("activity files are limited to %d bytes", MaxFileSize)
+	}
+	var sessions []Session
+	switch format {
+	case FIT:
+		sessions, err = parseFIT(data)
+	case TCX:
+		sessions, err = parseTCX(data)
+	default:
+		return nil, fmt.Errorf("unknown activity format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, fmt.Errorf("%s file records no activity", format)
+	}
+	return sessions, nil
+}
+
+// sample is a point of a recorded track. Distance is cumulative and, like
+// the heart rate, zero when unrecorded.
+type sample struct {
+	time      time.Time
+	distance  float64
+	heartRate int32
+}
+
+// window selects the samples recorded in [start, start+d].
+func window(samples []sample, start time.Time, d time.Duration) []sample {
+	var in []sample
+	end := start.Add(d)
+	for _, s := range samples {
+		if !s.time.Before(start) && !s.time.After(end) {
+			in = append(in, s)
+		}
+	}
+	return in
+}
+
+// heartRate averages and peaks the heart rate of samples, weighting each
+// sample by the time until the next one so that irregular recording does not
+// skew the average.
+func heartRate(samples []sample) (avg int32, max int32) {
+	var sum, weight float64
+	for i, s := range samples {
+		if s.heartRate <= 0 {
+			continue
+		}
+		if s.heartRate > max {
+			max = s.heartRate
+		}
+		w := 1.0
+		if i+1 < len(samples) {
+			if d := samples[i+1].time.Sub(s.time).Seconds(); d > 0 {
+				w = d
+			}
+		}
+		sum += float64(s.heartRate) * w
+		weight += w
+	}
+	if weight == 0 {
+		return 0, 0
+	}
+	return int32(sum/weight + 0.5), max
+}
+
+// distance is the distance covered in [start, start+d] according to samples
+// with cumulative distances: the last distance recorded in the window less
+// the last one recorded before it.
+func distance(samples []sample, start time.Time, d time.Duration) float64 {
+	var before, last float64
+	end := start.Add(d)
+	for _, s := range samples {
+		switch {
+		case s.distance <= 0:
+		case s.time.Before(start):
+			before = s.distance
+		case !s.time.After(end):
+			last = s.distance
+		}
+	}
+	if last < before {
+		return 0
+	}
+	return last - before
+}
+
+// fill completes a session and its laps with what the samples tell where the
+// device left summaries out.
+func fill(s *Session, samples []sample) {
+	for i := range s.Laps {
+		l := &s.Laps[i]
+		if l.AvgHeartRate == 0 {
+			l.AvgHeartRate, l.MaxHeartRate = heartRate(window(samples, l.StartTime, l.Duration))
+		}
+		if l.Distance == 0 {
+			l.Distance = distance(samples, l.StartTime, l.Duration)
+		}
+	}
+	if s.Duration == 0 {
+		for _, l := range s.Laps {
+			s.Duration += l.Duration
+		}
+	}
+	// Pauses make a session last longer than its timer ran, so its samples
+	// are taken up to the end of its last lap where there is one.
+	span := s.Duration
+	if len(s.Laps) > 0 {
+		last := s.Laps[len(s.Laps)-1]
+		span = last.StartTime.Add(last.Duration).Sub(s.StartTime)
+	}
+	if s.AvgHeartRate == 0 {
+		s.AvgHeartRate, s.MaxHeartRate = heartRate(window(samples, s.StartTime, span))
+	}
+	if s.Distance == 0 {
+		for _, l := range s.Laps {
+			s.Distance += l.Distance
+		}
+		if s.Distance == 0 {
+			s.Distance = distance(samples, s.StartTime, span)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package activity
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		file     string
+		format   Format
+		sport    Sport
+		start    time.Time
+		duration time.Duration
+		distance float64
+		avgHR    int32
+		laps     []Lap
+	}{
+		{
+			file:     "testdata/indoor-row-intervals.fit",
+			format:   FIT,
+			sport:    Rowing,
+			start:    time.Date(2024, 3, 4, 6, 30, 0, 0, time.UTC),
+			duration: 7*time.Minute + 20*time.Second,
+			distance: 2000,
+			avgHR:    154,
+			// The laps carry no heart rate; it comes from the records.
+			laps: []Lap{
+				{Duration: 110 * time.Second, Distance: 500, AvgHeartRate: 145},
+				{Duration: 110 * time.Second, Distance: 500, AvgHeartRate: 150},
+				{Duration: 110 * time.Second, Distance: 500, AvgHeartRate: 155},
+				{Duration: 110 * time.Second, Distance: 500, AvgHeartRate: 160},
+			},
+		},
+		{
+			file:     "testdata/easy-run-2k.tcx",
+			format:   TCX,
+			sport:    Running,
+			start:    time.Date(2024, 3, 2, 7, 15, 0, 0, time.UTC),
+			duration: 9*time.Minute + 45*time.Second,
+			distance: 2000,
+			avgHR:    155,
+			laps: []Lap{
+				{Duration: 5 * time.Minute, Distance: 1000, AvgHeartRate: 150},
+				{Duration: 4*time.Minute + 45*time.Second, Distance: 1000, AvgHeartRate: 162},
+			},
+		},
+	} {
+		t.Run(tc.file, func(t *testing.T) {
+			f, err := os.Open(tc.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			sessions, err := Parse(tc.format, f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sessions) != 1 {
+				t.Fatalf("got %d sessions, want 1", len(sessions))
+			}
+			s := sessions[0]
+			if s.Sport != tc.sport || !s.StartTime.Equal(tc.start) || s.Duration != tc.duration || s.Distance != tc.distance || s.AvgHeartRate != tc.avgHR {
+				t.Errorf("session = %s from %s for %s over %g m at %d bpm, want %s from %s for %s over %g m at %d bpm",
+					s.Sport, s.StartTime, s.Duration, s.Distance, s.AvgHeartRate,
+					tc.sport, tc.start, tc.duration, tc.distance, tc.avgHR)
+			}
+			if len(s.Laps) != len(tc.laps) {
+				t.Fatalf("got %d laps, want %d", len(s.Laps), len(tc.laps))
+			}
+			var total time.Duration
+			for i, want := range tc.laps {
+				l := s.Laps[i]
+				if l.Duration != want.Duration || l.Distance != want.Distance || l.AvgHeartRate != want.AvgHeartRate {
+					t.Errorf("lap %d = %s over %g m at %d bpm, want %s over %g m at %d bpm",
+						i+1, l.Duration, l.Distance, l.AvgHeartRate, want.Duration, want.Distance, want.AvgHeartRate)
+				}
+				if l.StartTime.Before(s.StartTime) || l.MaxHeartRate < l.AvgHeartRate {
+					t.Errorf("lap %d starts at %s with a maximum of %d bpm", i+1, l.StartTime, l.MaxHeartRate)
+				}
+				total += l.Duration
+			}
+			if total != s.Duration {
+				t.Errorf("laps last %s, want the session's %s", total, s.Duration)
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	fit, err := ioutil.ReadFile("testdata/indoor-row-intervals.fit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name   string
+		format Format
+		data   []byte
+	}{
+		{"unknown format", Format("gpx"), []byte("<gpx/>")},
+		{"empty FIT", FIT, nil},
+		{"truncated FIT", FIT, fit[:len(fit)/2]},
+		{"corrupt FIT", FIT, append(append([]byte(nil), fit[:20]...), bytes.Repeat([]byte{0xff}, 40)...)},
+		{"not XML", TCX, []byte("not an activity")},
+		{"no activities", TCX, []byte(`<TrainingCenterDatabase><Activities/></TrainingCenterDatabase>`)},
+	} {
+		if sessions, err := Parse(tc.format, bytes.NewReader(tc.data)); err == nil {
+			t.Errorf("%s: Parse() = %+v, want an error", tc.name, sessions)
+		}
+	}
+}
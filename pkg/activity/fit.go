@@ -0,0 +1,392 @@
+package activity
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// The global FIT message numbers and field numbers that sessions are built
+// from, as defined by the FIT SDK's profile.
+const (
+	fitFileID  = 0
+	fitSport   = 12
+	fitSession = 18
+	fitLap     = 19
+	fitRecord  = 20
+
+	fitTimestamp = 253
+
+	fitFileType     = 0
+	fitFileActivity = 4
+
+	fitStartTime       = 2
+	fitElapsedTime     = 7
+	fitTimerTime       = 8
+	fitTotalDistance   = 9
+	fitSessionSport    = 5
+	fitSessionSubSport = 6
+	fitSessionAvgHR    = 16
+	fitSessionMaxHR    = 17
+	fitLapAvgHR        = 15
+	fitLapMaxHR        = 16
+	fitSportSport      = 0
+	fitSportSubSport   = 1
+	fitRecordHeartRate = 3
+	fitRecordDistance  = 5
+	fitScaleTime       = 1000
+	fitScaleDistance   = 100
+)
+
+// fitEpoch is the zero of FIT timestamps.
+var fitEpoch = time.Date(1989, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+var errTruncated = errors.New("FIT file is truncated")
+
+// fitField is a field of a definition message.
+type fitField struct {
+	num      byte
+	size     int
+	baseType byte
+}
+
+// fitDefinition describes the layout of the data messages of a local
+// message type.
+type fitDefinition struct {
+	global    uint16
+	bigEndian bool
+	fields    []fitField
+	devSize   int
+}
+
+// fitMessage is a decoded data message. Only integer fields are kept, and
+// fields holding the invalid value of their type are left out.
+type fitMessage struct {
+	global uint16
+	values map[byte]uint64
+}
+
+func (m fitMessage) value(field byte) (uint64, bool) {
+	v, ok := m.values[field]
+	return v, ok
+}
+
+func (m fitMessage) time(field byte) time.Time {
+	if v, ok := m.values[field]; ok {
+		return fitEpoch.Add(time.Duration(v) * time.Second)
+	}
+	return time.Time{}
+}
+
+func (m fitMessage) duration(field byte) time.Duration {
+	v := m.values[field]
+	return time.Duration(v) * time.Second / fitScaleTime
+}
+
+func (m fitMessage) distance(field byte) float64 {
+	return float64(m.values[field]) / fitScaleDistance
+}
+
+func (m fitMessage) heartRate(field byte) int32 {
+	return int32(m.values[field])
+}
+
+// decodeFIT decodes the messages of a FIT file after checking its header and
+// checksum. Chained files, which some devices write, are decoded in turn.
+func decodeFIT(data []byte) ([]fitMessage, error) {
+	var messages []fitMessage
+	for len(data) > 0 {
+		if len(data) < 12 {
+			return nil, errTruncated
+		}
+		headerSize := int(data[0])
+		if (headerSize != 12 && headerSize != 14) || string(data[8:12]) != ".FIT" {
+			return nil, errors.New("not a FIT file")
+		}
+		end := headerSize + int(binary.LittleEndian.Uint32(data[4:8]))
+		if end+2 > len(data) || end < headerSize {
+			return nil, errTruncated
+		}
+		if fitCRC(data[:end+2]) != 0 {
+			return nil, errors.New("FIT file is corrupt: checksum mismatch")
+		}
+		ms, err := decodeFITRecords(data[headerSize:end])
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, ms...)
+		data = data[end+2:]
+	}
+	return messages, nil
+}
+
+func decodeFITRecords(data []byte) ([]fitMessage, error) {
+	var (
+		messages    []fitMessage
+		definitions [16]*fitDefinition
+		lastTime    uint32
+	)
+	for pos := 0; pos < len(data); {
+		header := data[pos]
+		pos++
+		var (
+			local      byte
+			compressed bool
+			offset     uint32
+		)
+		switch {
+		case header&0x80 != 0:
+			local, compressed, offset = (header>>5)&0x03, true, uint32(header&0x1f)
+		case header&0x40 != 0:
+			def, n, err := decodeFITDefinition(data[pos:], header&0x20 != 0)
+			if err != nil {
+				return nil, err
+			}
+			definitions[header&0x0f] = def
+			pos += n
+			continue
+		default:
+			local = header & 0x0f
+		}
+		def := definitions[local]
+		if def == nil {
+			return nil, fmt.Errorf("FIT data message uses undefined local type %d", local)
+		}
+		m := fitMessage{global: def.global, values: make(map[byte]uint64, len(def.fields))}
+		for _, f := range def.fields {
+			if pos+f.size > len(data) {
+				return nil, errTruncated
+			}
+			if v, ok := fitValue(data[pos:pos+f.size], f, def.bigEndian); ok {
+				m.values[f.num] = v
+			}
+			pos += f.size
+		}
+		if pos+def.devSize > len(data) {
+			return nil, errTruncated
+		}
+		pos += def.devSize
+		if compressed {
+			t := lastTime&^0x1f | offset
+			if offset < lastTime&0x1f {
+				t += 0x20
+			}
+			m.values[fitTimestamp] = uint64(t)
+		}
+		if t, ok := m.values[fitTimestamp]; ok {
+			lastTime = uint32(t)
+		}
+		messages = append(messages, m)
+	}
+	return messages, nil
+}
+
+// decodeFITDefinition decodes a definition message, returning it along with
+// its size in bytes.
+func decodeFITDefinition(data []byte, developer bool) (*fitDefinition, int, error) {
+	if len(data) < 5 {
+		return nil, 0, errTruncated
+	}
+	def := &fitDefinition{bigEndian: data[1] == 1}
+	if def.bigEndian {
+		def.global = binary.BigEndian.Uint16(data[2:4])
+	} else {
+		def.global = binary.LittleEndian.Uint16(data[2:4])
+	}
+	n := int(data[4])
+	pos := 5
+	if len(data) < pos+3*n {
+		return nil, 0, errTruncated
+	}
+	for i := 0; i < n; i++ {
+		def.fields = append(def.fields, fitField{num: data[pos], size: int(data[pos+1]), baseType: data[pos+2]})
+		pos += 3
+	}
+	if developer {
+		if len(data) < pos+1 {
+			return nil, 0, errTruncated
+		}
+		n := int(data[pos])
+		pos++
+		if len(data) < pos+3*n {
+			return nil, 0, errTruncated
+		}
+		for i := 0; i < n; i++ {
+			def.devSize += int(data[pos+1])
+			pos += 3
+		}
+	}
+	return def, pos, nil
+}
+
+// fitValue decodes a single unsigned integer or enum field. Arrays, strings
+// and floats are not needed and reported as absent, as are invalid values:
+// all bits set, or zero for the types whose invalid value is zero.
+func fitValue(b []byte, f fitField, bigEndian bool) (uint64, bool) {
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+	var v, invalid uint64
+	switch f.size {
+	case 1:
+		v, invalid = uint64(b[0]), 0xff
+	case 2:
+		v, invalid = uint64(order.Uint16(b)), 0xffff
+	case 4:
+		v, invalid = uint64(order.Uint32(b)), 0xffffffff
+	default:
+		return 0, false
+	}
+	switch f.baseType & 0x1f {
+	case 0x0a, 0x0b, 0x0c:
+		invalid = 0
+	case 0x00, 0x02, 0x04, 0x06:
+	default:
+		return 0, false
+	}
+	return v, v != invalid
+}
+
+// fitCRC computes the FIT SDK's CRC-16. It is zero over data that ends with
+// its own checksum.
+func fitCRC(data []byte) uint16 {
+	table := [16]uint16{
+		0x0000, 0xcc01, 0xd801, 0x1400, 0xf001, 0x3c00, 0x2800, 0xe401,
+		0xa001, 0x6c00, 0x7800, 0xb401, 0x5000, 0x9c01, 0x8801, 0x4400,
+	}
+	var crc uint16
+	for _, b := range data {
+		tmp := table[crc&0xf]
+		crc = (crc >> 4) & 0x0fff
+		crc = crc ^ tmp ^ table[b&0xf]
+		tmp = table[crc&0xf]
+		crc = (crc >> 4) & 0x0fff
+		crc = crc ^ tmp ^ table[(b>>4)&0xf]
+	}
+	return crc
+}
+
+// parseFIT reads a FIT activity file. Laps are assigned to the session they
+// started in. Files without session messages, which some older devices
+// write, are made into a single session from their laps.
+func parseFIT(data []byte) ([]Session, error) {
+	messages, err := decodeFIT(data)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		sessions []Session
+		elapsed  []time.Duration
+		laps     []Lap
+		samples  []sample
+		sport    = OtherSport
+	)
+	for _, m := range messages {
+		switch m.global {
+		case fitFileID:
+			if t, ok := m.value(fitFileType); ok && t != fitFileActivity {
+				return nil, fmt.Errorf("FIT file is not an activity but of type %d", t)
+			}
+		case fitSport:
+			s, _ := m.value(fitSportSport)
+			sub, _ := m.value(fitSportSubSport)
+			sport = fitSportOf(s, sub)
+		case fitSession:
+			s, _ := m.value(fitSessionSport)
+			sub, _ := m.value(fitSessionSubSport)
+			sessions = append(sessions, Session{
+				Sport:        fitSportOf(s, sub),
+				StartTime:    m.time(fitStartTime),
+				Duration:     timer(m),
+				Distance:     m.distance(fitTotalDistance),
+				AvgHeartRate: m.heartRate(fitSessionAvgHR),
+				MaxHeartRate: m.heartRate(fitSessionMaxHR),
+			})
+			elapsed = append(elapsed, m.duration(fitElapsedTime))
+		case fitLap:
+			laps = append(laps, Lap{
+				StartTime:    m.time(fitStartTime),
+				Duration:     timer(m),
+				Distance:     m.distance(fitTotalDistance),
+				AvgHeartRate: m.heartRate(fitLapAvgHR),
+				MaxHeartRate: m.heartRate(fitLapMaxHR),
+			})
+		case fitRecord:
+			if _, ok := m.value(fitTimestamp); !ok {
+				continue
+			}
+			samples = append(samples, sample{
+				time:      m.time(fitTimestamp),
+				distance:  m.distance(fitRecordDistance),
+				heartRate: m.heartRate(fitRecordHeartRate),
+			})
+		}
+	}
+	if len(sessions) == 0 && len(laps) > 0 {
+		sessions = append(sessions, Session{Sport: sport, StartTime: laps[0].StartTime})
+		elapsed = append(elapsed, 0)
+	}
+	for _, l := range laps {
+		i := 0
+		for j, s := range sessions {
+			if !l.StartTime.Before(s.StartTime) && (elapsed[j] == 0 || l.StartTime.Before(s.StartTime.Add(elapsed[j]))) {
+				i = j
+			}
+		}
+		if len(sessions) > 0 {
+			sessions[i].Laps = append(sessions[i].Laps, l)
+		}
+	}
+	for i := range sessions {
+		fill(&sessions[i], samples)
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].StartTime.Before(sessions[j].StartTime)
+	})
+	return sessions, nil
+}
+
+// timer is the timer time of a lap or session, or its elapsed time when the
+// device recorded no timer.
+func timer(m fitMessage) time.Duration {
+	if _, ok := m.value(fitTimerTime); ok {
+		return m.duration(fitTimerTime)
+	}
+	return m.duration(fitElapsedTime)
+}
+
+// fitSportOf classifies a FIT sport and sub-sport. Gym equipment is recorded
+// as the fitness_equipment sport, with the machine as its sub-sport.
+func fitSportOf(sport uint64, subSport uint64) Sport {
+	switch sport {
+	case 1:
+		return Running
+	case 2:
+		return Cycling
+	case 5:
+		return Swimming
+	case 11:
+		return Walking
+	case 12, 13:
+		return Skiing
+	case 15:
+		return Rowing
+	case 17:
+		return Hiking
+	case 4:
+		switch subSport {
+		case 1:
+			return Running
+		case 5, 6:
+			return Cycling
+		case 14:
+			return Rowing
+		case 15:
+			return Elliptical
+		}
+	}
+	return OtherSport
+}
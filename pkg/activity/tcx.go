@@ -0,0 +1,95 @@
+package activity
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The subset of Garmin's TrainingCenterDatabase v2 schema that sessions are
+// built from. Elements are matched by local name, so the schema's namespace
+// and its extensions are ignored.
+type tcxDatabase struct {
+	Activities []tcxActivity `xml:"Activities>Activity"`
+}
+
+type tcxActivity struct {
+	Sport string   `xml:"Sport,attr"`
+	ID    string   `xml:"Id"`
+	Laps  []tcxLap `xml:"Lap"`
+}
+
+type tcxLap struct {
+	StartTime        string       `xml:"StartTime,attr"`
+	TotalTimeSeconds float64      `xml:"TotalTimeSeconds"`
+	DistanceMeters   float64      `xml:"DistanceMeters"`
+	AverageHeartRate int32        `xml:"AverageHeartRateBpm>Value"`
+	MaximumHeartRate int32        `xml:"MaximumHeartRateBpm>Value"`
+	Trackpoints      []tcxTrackpt `xml:"Track>Trackpoint"`
+}
+
+type tcxTrackpt struct {
+	Time           string  `xml:"Time"`
+	DistanceMeters float64 `xml:"DistanceMeters"`
+	HeartRate      int32   `xml:"HeartRateBpm>Value"`
+}
+
+// TCX names only three sports; rowing and the rest are recorded as Other.
+var tcxSports = map[string]Sport{
+	"running": Running,
+	"biking":  Cycling,
+}
+
+// parseTCX reads a TCX file, making one session of every activity in it.
+func parseTCX(data []byte) ([]Session, error) {
+	var db tcxDatabase
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&db); err != nil {
+		return nil, fmt.Errorf("invalid TCX: %v", err)
+	}
+	var sessions []Session
+	for i, a := range db.Activities {
+		sport, ok := tcxSports[strings.ToLower(a.Sport)]
+		if !ok {
+			sport = OtherSport
+		}
+		s := Session{Sport: sport}
+		var samples []sample
+		for j, l := range a.Laps {
+			start, err := time.Parse(time.RFC3339, l.StartTime)
+			if err != nil {
+				return nil, fmt.Errorf("activity %d lap %d has invalid start time %q", i+1, j+1, l.StartTime)
+			}
+			s.Laps = append(s.Laps, Lap{
+				StartTime:    start.UTC(),
+				Duration:     seconds(l.TotalTimeSeconds),
+				Distance:     l.DistanceMeters,
+				AvgHeartRate: l.AverageHeartRate,
+				MaxHeartRate: l.MaximumHeartRate,
+			})
+			for _, p := range l.Trackpoints {
+				t, err := time.Parse(time.RFC3339, p.Time)
+				if err != nil {
+					return nil, fmt.Errorf("activity %d lap %d has invalid trackpoint time %q", i+1, j+1, p.Time)
+				}
+				samples = append(samples, sample{time: t.UTC(), distance: p.DistanceMeters, heartRate: p.HeartRate})
+			}
+		}
+		if len(s.Laps) == 0 {
+			continue
+		}
+		s.StartTime = s.Laps[0].StartTime
+		if id, err := time.Parse(time.RFC3339, a.ID); err == nil {
+			s.StartTime = id.UTC()
+		}
+		fill(&s, samples)
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
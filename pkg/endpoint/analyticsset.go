@@ -0,0 +1,167 @@
+package endpoint
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+
+	"workout-manager-service/pkg/service"
+)
+
+// AnalyticsSet is a helper struct that collects all of the Analytics
+// endpoints in the workout manager service.
+type AnalyticsSet struct {
+	VolumeEndpoint           endpoint.Endpoint
+	WorkloadEndpoint         endpoint.Endpoint
+	GetThresholdsEndpoint    endpoint.Endpoint
+	UpdateThresholdsEndpoint endpoint.Endpoint
+}
+
+// NewAnalyticsSet returns an AnalyticsSet that wraps the provided
+// AnalyticsService and wires in the endpoint middleware. Analytics follow
+// the same access rules as the workouts they are drawn from. Coaches may read
+// the workload thresholds of their tenant, and only its admins may change
+// them.
+func NewAnalyticsSet(svc service.AnalyticsService, users service.UserService) AnalyticsSet {
+	var (
+		authenticate  = Authenticate(users)
+		athleteAccess = Authorize(AthleteAccess(users, analyticsAthleteID))
+		staffOnly     = Authorize(RequireRole(service.RoleCoach, service.RoleAdmin))
+		adminOnly     = Authorize(RequireRole(service.RoleAdmin))
+	)
+	return AnalyticsSet{
+		VolumeEndpoint:           authenticate(athleteAccess(MakeTrainingVolumeEndpoint(svc))),
+		WorkloadEndpoint:         authenticate(athleteAccess(MakeWorkloadEndpoint(svc))),
+		GetThresholdsEndpoint:    authenticate(staffOnly(MakeGetWorkloadThresholdsEndpoint(svc))),
+		UpdateThresholdsEndpoint: authenticate(adminOnly(MakeUpdateWorkloadThresholdsEndpoint(svc))),
+	}
+}
+
+// analyticsAthleteID extracts the athlete an analytics request is addressed
+// to.
+func analyticsAthleteID(req interface{}) string {
+	switch r := req.(type) {
+	case TrainingVolumeRequest:
+		return r.AthleteID
+	case WorkloadRequest:
+		return r.AthleteID
+	}
+	return ""
+}
+
+// MakeTrainingVolumeEndpoint is a builder function that returns a
+// VolumeEndpoint.
+func MakeTrainingVolumeEndpoint(svc service.AnalyticsService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(TrainingVolumeRequest)
+		aggregates, err := svc.Volume(ctx, request.AthleteID, service.VolumeQuery{
+			Start:   request.Start,
+			End:     request.End,
+			Bucket:  request.Bucket,
+			GroupBy: request.GroupBy,
+		})
+		return TrainingVolumeResponse{Data: aggregates, Unit: service.PreferredUnit(ctx), Err: err}, nil
+	}
+}
+
+// MakeWorkloadEndpoint is a builder function that returns a
+// WorkloadEndpoint.
+func MakeWorkloadEndpoint(svc service.AnalyticsService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(WorkloadRequest)
+		days, err := svc.Workload(ctx, request.AthleteID, request.Start, request.End)
+		return WorkloadResponse{Data: days, Err: err}, nil
+	}
+}
+
+// MakeGetWorkloadThresholdsEndpoint is a builder function that returns a
+// GetThresholdsEndpoint.
+func MakeGetWorkloadThresholdsEndpoint(svc service.AnalyticsService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		t, err := svc.GetWorkloadThresholds(ctx)
+		return WorkloadThresholdsResponse{Data: t, Err: err}, nil
+	}
+}
+
+// MakeUpdateWorkloadThresholdsEndpoint is a builder function that returns
+// an UpdateThresholdsEndpoint.
+func MakeUpdateWorkloadThresholdsEndpoint(svc service.AnalyticsService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(UpdateWorkloadThresholdsRequest)
+		t, err := svc.UpdateWorkloadThresholds(ctx, request.Thresholds)
+		return WorkloadThresholdsResponse{Data: t, Err: err}, nil
+	}
+}
+
+// compile-time assertions for our response types implementing
+// endpoint.Failer.
+var (
+	_ endpoint.Failer = TrainingVolumeResponse{}
+	_ endpoint.Failer = WorkloadResponse{}
+	_ endpoint.Failer = WorkloadThresholdsResponse{}
+)
+
+// TrainingVolumeRequest collects the request parameters for the
+// TrainingVolume Endpoint.
+type TrainingVolumeRequest struct {
+	AthleteID string
+	Start     time.Time
+	End       time.Time
+	Bucket    service.Bucket
+	GroupBy   service.VolumeGrouping
+}
+
+// TrainingVolumeResponse collects the response parameters for the
+// TrainingVolume Endpoint.
+type TrainingVolumeResponse struct {
+	Data []service.VolumeAggregate `json:"data"`
+	Unit service.WeightUnit        `json:"unit"`
+	Err  error                     `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r TrainingVolumeResponse) Failed() error {
+	return r.Err
+}
+
+// WorkloadRequest collects the request parameters for the Workload Endpoint.
+type WorkloadRequest struct {
+	AthleteID string
+	Start     time.Time
+	End       time.Time
+}
+
+// WorkloadResponse collects the response parameters for the Workload
+// Endpoint.
+type WorkloadResponse struct {
+	Data []service.WorkloadDay `json:"data"`
+	Err  error                 `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r WorkloadResponse) Failed() error {
+	return r.Err
+}
+
+// GetWorkloadThresholdsRequest is an empty struct, since the thresholds are
+// those of the caller's tenant.
+type GetWorkloadThresholdsRequest struct{}
+
+// UpdateWorkloadThresholdsRequest collects the request parameters for the
+// UpdateWorkloadThresholds Endpoint.
+type UpdateWorkloadThresholdsRequest struct {
+	Thresholds service.WorkloadThresholds `json:"thresholds"`
+}
+
+// WorkloadThresholdsResponse collects the response parameters for both
+// workload threshold Endpoints.
+type WorkloadThresholdsResponse struct {
+	Data service.WorkloadThresholds `json:"data"`
+	Err  error                      `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r WorkloadThresholdsResponse) Failed() error {
+	return r.Err
+}
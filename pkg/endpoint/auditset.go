@@ -0,0 +1,71 @@
+package endpoint
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+
+	"workout-manager-service/pkg/service"
+)
+
+// AuditSet is a helper struct that collects all of the audit endpoints in the
+// workout manager service.
+type AuditSet struct {
+	ListEndpoint endpoint.Endpoint
+}
+
+// NewAuditSet returns an AuditSet that wraps the provided AuditService and
+// wires in the endpoint middleware. Coaches and admins may read their
+// tenant's audit log; operators read the log of platform actions.
+func NewAuditSet(svc service.AuditService, users service.UserService) AuditSet {
+	var (
+		authenticate = Authenticate(users)
+		staffOnly    = Authorize(RequireRole(service.RoleCoach, service.RoleAdmin, service.RoleOperator))
+	)
+	return AuditSet{
+		ListEndpoint: authenticate(staffOnly(MakeListAuditEventsEndpoint(svc))),
+	}
+}
+
+// MakeListAuditEventsEndpoint is a builder function that returns a
+// ListEndpoint.
+func MakeListAuditEventsEndpoint(svc service.AuditService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(ListAuditEventsRequest)
+		events, err := svc.List(ctx, service.AuditFilter{
+			Resource: request.Resource,
+			Start:    request.Start,
+			End:      request.End,
+			Limit:    request.Limit,
+		})
+		return ListAuditEventsResponse{Data: events, Err: err}, nil
+	}
+}
+
+// compile-time assertions for our response types implementing
+// endpoint.Failer.
+var (
+	_ endpoint.Failer = ListAuditEventsResponse{}
+)
+
+// ListAuditEventsRequest collects the request parameters for the
+// ListAuditEvents Endpoint.
+type ListAuditEventsRequest struct {
+	Resource string
+	Start    time.Time
+	End      time.Time
+	Limit    int
+}
+
+// ListAuditEventsResponse collects the response parameters for the
+// ListAuditEvents Endpoint.
+type ListAuditEventsResponse struct {
+	Data []service.AuditEvent `json:"data"`
+	Err  error                `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r ListAuditEventsResponse) Failed() error {
+	return r.Err
+}
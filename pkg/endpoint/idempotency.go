@@ -0,0 +1,135 @@
+package endpoint
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+// RequestIDContextKey holds the key used to store a client-supplied request
+// ID in the request context. Transports populate it from metadata; requests
+// that carry their own ID take precedence.
+const RequestIDContextKey contextKey = "requestID"
+
+// idempotencyKeyer is implemented by requests that carry a client-supplied
+// request ID in their body.
+type idempotencyKeyer interface {
+	IdempotencyKey() string
+}
+
+// DefaultIdempotencyLease is how long a request ID stays reserved while its
+// request runs when Idempotency.Lease is zero.
+const DefaultIdempotencyLease = time.Minute
+
+// Idempotency makes endpoints safe to retry. Successful responses to requests
+// carrying a request ID are stored in Repo for Window and replayed when the
+// same request ID is seen again. While the request runs its ID is reserved
+// for Lease only, so that a request whose server died does not block retries
+// for the whole window. Lease should exceed the longest request deadline.
+type Idempotency struct {
+	Repo   service.IdempotencyRepository
+	Window time.Duration
+	Lease  time.Duration
+}
+
+// Middleware returns endpoint middleware that deduplicates calls to the named
+// operation. response is a zero value of the endpoint's response type, used
+// to decode replayed responses. It must be wrapped by Authenticate because
+// request IDs are scoped to the calling user.
+//
+// Requests without a request ID pass straight through. Reusing a request ID
+// with a different request fails with ErrInvalidArgument, and retrying while
+// the original request is still running fails with ErrConflict. Failed
+// responses are not stored, so a failed request may be retried.
+func (i Idempotency) Middleware(operation string, response interface{}) endpoint.Middleware {
+	responseType := reflect.TypeOf(response)
+	lease := i.Lease
+	if lease <= 0 {
+		lease = DefaultIdempotencyLease
+	}
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			requestID := idempotencyKey(ctx, request)
+			if requestID == "" {
+				return next(ctx, request)
+			}
+			p, ok := service.PrincipalFromContext(ctx)
+			if !ok {
+				return nil, service.ErrUnauthenticated
+			}
+			hash, err := requestHash(request)
+			if err != nil {
+				return nil, err
+			}
+
+			key := strings.Join([]string{p.TenantID, p.UserID, operation, requestID}, "/")
+			existing, reserved, err := i.Repo.ReserveIdempotencyKey(ctx, service.IdempotencyRecord{
+				Key:         key,
+				RequestHash: hash,
+				ExpireTime:  time.Now().UTC().Add(lease),
+			})
+			if err != nil {
+				return nil, err
+			}
+			if !reserved {
+				return replay(existing, hash, responseType)
+			}
+
+			res, err := next(ctx, request)
+			if f, ok := res.(endpoint.Failer); err != nil || (ok && f.Failed() != nil) {
+				if rerr := i.Repo.ReleaseIdempotencyKey(ctx, key); rerr != nil {
+					return nil, errors.Wrap(rerr, "failed to release request ID")
+				}
+				return res, err
+			}
+			b, err := json.Marshal(res)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to encode response for replay")
+			}
+			if err := i.Repo.CompleteIdempotencyKey(ctx, key, b, time.Now().UTC().Add(i.Window)); err != nil {
+				return nil, errors.Wrap(err, "failed to store response for replay")
+			}
+			return res, nil
+		}
+	}
+}
+
+func idempotencyKey(ctx context.Context, request interface{}) string {
+	if k, ok := request.(idempotencyKeyer); ok && k.IdempotencyKey() != "" {
+		return k.IdempotencyKey()
+	}
+	id, _ := ctx.Value(RequestIDContextKey).(string)
+	return id
+}
+
+func requestHash(request interface{}) (string, error) {
+	b, err := json.Marshal(request)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to hash request")
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func replay(rec service.IdempotencyRecord, hash string, responseType reflect.Type) (interface{}, error) {
+	if rec.RequestHash != hash {
+		return nil, errors.Wrap(service.ErrInvalidArgument, "request ID was already used for a different request")
+	}
+	if !rec.Complete {
+		return nil, errors.Wrap(service.ErrConflict, "a request with this ID is still in progress")
+	}
+	res := reflect.New(responseType)
+	if err := json.Unmarshal(rec.Response, res.Interface()); err != nil {
+		return nil, errors.Wrap(err, "failed to decode replayed response")
+	}
+	return res.Elem().Interface(), nil
+}
@@ -0,0 +1,166 @@
+package endpoint
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/inmem"
+	"workout-manager-service/pkg/service"
+)
+
+// expiryRepo records the expiry every reservation and completion asks for.
+type expiryRepo struct {
+	*inmem.Store
+	reserved, completed []time.Time
+}
+
+func (r *expiryRepo) ReserveIdempotencyKey(ctx context.Context, rec service.IdempotencyRecord) (service.IdempotencyRecord, bool, error) {
+	r.reserved = append(r.reserved, rec.ExpireTime)
+	return r.Store.ReserveIdempotencyKey(ctx, rec)
+}
+
+func (r *expiryRepo) CompleteIdempotencyKey(ctx context.Context, key string, response []byte, expireTime time.Time) error {
+	r.completed = append(r.completed, expireTime)
+	return r.Store.CompleteIdempotencyKey(ctx, key, response, expireTime)
+}
+
+func principalContext() context.Context {
+	return service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: "u1", TenantID: "t1"})
+}
+
+// countingCreate is a create endpoint that answers with how often it ran,
+// failing when the movement is named "fail".
+func countingCreate(calls *int) func(context.Context, interface{}) (interface{}, error) {
+	return func(_ context.Context, req interface{}) (interface{}, error) {
+		*calls++
+		request := req.(CreateMovementRequest)
+		if request.MovementName == "fail" {
+			return CreateMovementResponse{Err: service.ErrInvalidArgument}, nil
+		}
+		return CreateMovementResponse{Data: service.Movement{Name: request.MovementName, Version: int64(*calls)}}, nil
+	}
+}
+
+func TestIdempotencyReplays(t *testing.T) {
+	ctx := principalContext()
+	var calls int
+	create := Idempotency{Repo: inmem.NewStore(), Window: time.Hour}.
+		Middleware("CreateMovement", CreateMovementResponse{})(countingCreate(&calls))
+
+	req := CreateMovementRequest{RequestID: "r1", MovementName: "squat"}
+	first, err := create(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := create(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 || again.(CreateMovementResponse).Data.Version != first.(CreateMovementResponse).Data.Version {
+		t.Errorf("retry ran the endpoint again (%d calls) or answered %+v instead of %+v", calls, again, first)
+	}
+
+	if _, err := create(ctx, CreateMovementRequest{RequestID: "r1", MovementName: "press"}); errors.Cause(err) != service.ErrInvalidArgument {
+		t.Errorf("reusing a request ID for another request = %v, want %v", err, service.ErrInvalidArgument)
+	}
+	if _, err := create(ctx, CreateMovementRequest{MovementName: "squat"}); err != nil || calls != 2 {
+		t.Errorf("a request without an ID = %v after %d calls, want it to run", err, calls)
+	}
+	other := service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: "u2", TenantID: "t1"})
+	if _, err := create(other, req); err != nil || calls != 3 {
+		t.Errorf("another user's request with the same ID = %v after %d calls, want it to run", err, calls)
+	}
+}
+
+func TestIdempotencyReleasesFailures(t *testing.T) {
+	ctx := principalContext()
+	var calls int
+	create := Idempotency{Repo: inmem.NewStore(), Window: time.Hour}.
+		Middleware("CreateMovement", CreateMovementResponse{})(countingCreate(&calls))
+
+	for i := 1; i <= 2; i++ {
+		res, err := create(ctx, CreateMovementRequest{RequestID: "r1", MovementName: "fail"})
+		if err != nil || res.(CreateMovementResponse).Err == nil || calls != i {
+			t.Fatalf("attempt %d = %+v, %v after %d calls, want the failure to be retried", i, res, err, calls)
+		}
+	}
+}
+
+func TestIdempotencyConflictsWhileRunning(t *testing.T) {
+	ctx := principalContext()
+	running, release := make(chan struct{}), make(chan struct{})
+	idempotency := Idempotency{Repo: inmem.NewStore(), Window: time.Hour}
+	create := idempotency.Middleware("CreateMovement", CreateMovementResponse{})(func(context.Context, interface{}) (interface{}, error) {
+		close(running)
+		<-release
+		return CreateMovementResponse{}, nil
+	})
+
+	req := CreateMovementRequest{RequestID: "r1", MovementName: "squat"}
+	done := make(chan error)
+	go func() {
+		_, err := create(ctx, req)
+		done <- err
+	}()
+	<-running
+	if _, err := create(ctx, req); errors.Cause(err) != service.ErrConflict {
+		t.Errorf("retry while running = %v, want %v", err, service.ErrConflict)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIdempotencyLease(t *testing.T) {
+	ctx := principalContext()
+	for _, tc := range []struct {
+		lease, want time.Duration
+	}{
+		{0, DefaultIdempotencyLease},
+		{5 * time.Second, 5 * time.Second},
+	} {
+		repo := &expiryRepo{Store: inmem.NewStore()}
+		var calls int
+		create := Idempotency{Repo: repo, Window: 24 * time.Hour, Lease: tc.lease}.
+			Middleware("CreateMovement", CreateMovementResponse{})(countingCreate(&calls))
+		begin := time.Now()
+		if _, err := create(ctx, CreateMovementRequest{RequestID: "r1", MovementName: "squat"}); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.reserved) != 1 || len(repo.completed) != 1 {
+			t.Fatalf("lease %s: %d reservations and %d completions, want one each", tc.lease, len(repo.reserved), len(repo.completed))
+		}
+		if held := repo.reserved[0].Sub(begin); held < tc.want || held > tc.want+time.Second {
+			t.Errorf("lease %s: reserved for %s, want %s", tc.lease, held, tc.want)
+		}
+		if kept := repo.completed[0].Sub(begin); kept < 24*time.Hour || kept > 24*time.Hour+time.Second {
+			t.Errorf("lease %s: response kept for %s, want the window", tc.lease, kept)
+		}
+	}
+}
+
+func TestBatchMovementsResponseJSON(t *testing.T) {
+	res := BatchMovementsResponse{Data: []service.MovementResult{
+		{Movement: service.Movement{Name: "squat"}},
+		{Err: errors.Wrap(service.ErrAlreadyExists, "press")},
+	}}
+	b, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got BatchMovementsResponse
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Data) != 2 || got.Data[0].Movement.Name != "squat" || got.Data[0].Err != nil {
+		t.Fatalf("decoded %+v, want %+v", got, res)
+	}
+	if got.Data[1].Err == nil || got.Data[1].Err.Error() != res.Data[1].Err.Error() {
+		t.Errorf("item error decoded as %v, want %v", got.Data[1].Err, res.Data[1].Err)
+	}
+}
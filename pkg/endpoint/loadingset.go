@@ -0,0 +1,183 @@
+package endpoint
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+
+	"workout-manager-service/pkg/service"
+)
+
+// LoadingSet is a helper struct that collects all of the bar loading
+// endpoints in the workout manager service.
+type LoadingSet struct {
+	CalculatePlatesEndpoint endpoint.Endpoint
+	GenerateWarmupsEndpoint endpoint.Endpoint
+	GetInventoryEndpoint    endpoint.Endpoint
+	UpdateInventoryEndpoint endpoint.Endpoint
+	SuggestNextLoadEndpoint endpoint.Endpoint
+}
+
+// NewLoadingSet returns a LoadingSet that wraps the provided LoadingService
+// and wires in the endpoint middleware. Anyone in a tenant may use its
+// calculators and read its plate inventory; only its admins may change it.
+// Load suggestions follow the same access rules as the workouts they are
+// drawn from.
+func NewLoadingSet(svc service.LoadingService, users service.UserService) LoadingSet {
+	var (
+		authenticate  = Authenticate(users)
+		adminOnly     = Authorize(RequireRole(service.RoleAdmin))
+		athleteAccess = Authorize(AthleteAccess(users, loadingAthleteID))
+	)
+	return LoadingSet{
+		CalculatePlatesEndpoint: authenticate(MakeCalculatePlatesEndpoint(svc)),
+		GenerateWarmupsEndpoint: authenticate(MakeGenerateWarmupsEndpoint(svc)),
+		GetInventoryEndpoint:    authenticate(MakeGetPlateInventoryEndpoint(svc)),
+		UpdateInventoryEndpoint: authenticate(adminOnly(MakeUpdatePlateInventoryEndpoint(svc))),
+		SuggestNextLoadEndpoint: authenticate(athleteAccess(MakeSuggestNextLoadEndpoint(svc))),
+	}
+}
+
+// loadingAthleteID extracts the athlete a loading request is addressed to.
+func loadingAthleteID(req interface{}) string {
+	if r, ok := req.(SuggestNextLoadRequest); ok {
+		return r.AthleteID
+	}
+	return ""
+}
+
+// MakeCalculatePlatesEndpoint is a builder function that returns a
+// CalculatePlatesEndpoint.
+func MakeCalculatePlatesEndpoint(svc service.LoadingService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(CalculatePlatesRequest)
+		loading, err := svc.CalculatePlates(ctx, request.Query)
+		return CalculatePlatesResponse{Data: loading, Err: err}, nil
+	}
+}
+
+// MakeGenerateWarmupsEndpoint is a builder function that returns a
+// GenerateWarmupsEndpoint.
+func MakeGenerateWarmupsEndpoint(svc service.LoadingService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(GenerateWarmupsRequest)
+		ladder, err := svc.GenerateWarmups(ctx, request.Query)
+		return GenerateWarmupsResponse{Data: ladder, Err: err}, nil
+	}
+}
+
+// MakeGetPlateInventoryEndpoint is a builder function that returns a
+// GetInventoryEndpoint.
+func MakeGetPlateInventoryEndpoint(svc service.LoadingService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		inv, err := svc.GetPlateInventory(ctx)
+		return PlateInventoryResponse{Data: inv, Err: err}, nil
+	}
+}
+
+// MakeUpdatePlateInventoryEndpoint is a builder function that returns an
+// UpdateInventoryEndpoint.
+func MakeUpdatePlateInventoryEndpoint(svc service.LoadingService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(UpdatePlateInventoryRequest)
+		inv, err := svc.UpdatePlateInventory(ctx, request.Inventory)
+		return PlateInventoryResponse{Data: inv, Err: err}, nil
+	}
+}
+
+// MakeSuggestNextLoadEndpoint is a builder function that returns a
+// SuggestNextLoadEndpoint.
+func MakeSuggestNextLoadEndpoint(svc service.LoadingService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(SuggestNextLoadRequest)
+		suggestion, err := svc.SuggestNextLoad(ctx, request.AthleteID, request.Query)
+		return SuggestNextLoadResponse{Data: suggestion, Unit: service.PreferredUnit(ctx), Err: err}, nil
+	}
+}
+
+// compile-time assertions for our response types implementing
+// endpoint.Failer.
+var (
+	_ endpoint.Failer = CalculatePlatesResponse{}
+	_ endpoint.Failer = GenerateWarmupsResponse{}
+	_ endpoint.Failer = PlateInventoryResponse{}
+	_ endpoint.Failer = SuggestNextLoadResponse{}
+)
+
+// CalculatePlatesRequest collects the request parameters for the
+// CalculatePlates Endpoint.
+type CalculatePlatesRequest struct {
+	Query service.PlateQuery
+}
+
+// CalculatePlatesResponse collects the response parameters for the
+// CalculatePlates Endpoint.
+type CalculatePlatesResponse struct {
+	Data service.PlateLoading `json:"data"`
+	Err  error                `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r CalculatePlatesResponse) Failed() error {
+	return r.Err
+}
+
+// GenerateWarmupsRequest collects the request parameters for the
+// GenerateWarmups Endpoint.
+type GenerateWarmupsRequest struct {
+	Query service.WarmupQuery
+}
+
+// GenerateWarmupsResponse collects the response parameters for the
+// GenerateWarmups Endpoint.
+type GenerateWarmupsResponse struct {
+	Data service.WarmupLadder `json:"data"`
+	Err  error                `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r GenerateWarmupsResponse) Failed() error {
+	return r.Err
+}
+
+// GetPlateInventoryRequest is an empty struct, since the inventory is that of
+// the caller's tenant.
+type GetPlateInventoryRequest struct{}
+
+// UpdatePlateInventoryRequest collects the request parameters for the
+// UpdatePlateInventory Endpoint.
+type UpdatePlateInventoryRequest struct {
+	Inventory service.PlateInventory `json:"inventory"`
+}
+
+// PlateInventoryResponse collects the response parameters for both plate
+// inventory Endpoints.
+type PlateInventoryResponse struct {
+	Data service.PlateInventory `json:"data"`
+	Err  error                  `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r PlateInventoryResponse) Failed() error {
+	return r.Err
+}
+
+// SuggestNextLoadRequest collects the request parameters for the
+// SuggestNextLoad Endpoint.
+type SuggestNextLoadRequest struct {
+	AthleteID string
+	Query     service.ProgressionQuery
+}
+
+// SuggestNextLoadResponse collects the response parameters for the
+// SuggestNextLoad Endpoint.
+type SuggestNextLoadResponse struct {
+	Data service.LoadSuggestion `json:"data"`
+	Unit service.WeightUnit     `json:"unit"`
+	Err  error                  `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r SuggestNextLoadResponse) Failed() error {
+	return r.Err
+}
@@ -0,0 +1,217 @@
+package endpoint
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+
+	"workout-manager-service/pkg/service"
+)
+
+// MetricsSet is a helper struct that collects all of the body metrics
+// endpoints in the workout manager service.
+type MetricsSet struct {
+	RecordEndpoint           endpoint.Endpoint
+	ListEndpoint             endpoint.Endpoint
+	DeleteEndpoint           endpoint.Endpoint
+	TrendEndpoint            endpoint.Endpoint
+	RelativeStrengthEndpoint endpoint.Endpoint
+}
+
+// NewMetricsSet returns a MetricsSet that wraps the provided MetricsService
+// and wires in the endpoint middleware. Body metrics follow the same access
+// rules as workouts. Recording a metric is idempotent when the client
+// supplies a request ID.
+func NewMetricsSet(svc service.MetricsService, users service.UserService, idempotency Idempotency) MetricsSet {
+	var (
+		authenticate  = Authenticate(users)
+		athleteAccess = Authorize(AthleteAccess(users, metricsAthleteID))
+		recordOnce    = idempotency.Middleware("RecordBodyMetric", BodyMetricResponse{})
+	)
+	return MetricsSet{
+		RecordEndpoint:           authenticate(athleteAccess(recordOnce(MakeRecordBodyMetricEndpoint(svc)))),
+		ListEndpoint:             authenticate(athleteAccess(MakeListBodyMetricsEndpoint(svc))),
+		DeleteEndpoint:           authenticate(athleteAccess(MakeDeleteBodyMetricEndpoint(svc))),
+		TrendEndpoint:            authenticate(athleteAccess(MakeMetricTrendEndpoint(svc))),
+		RelativeStrengthEndpoint: authenticate(athleteAccess(MakeRelativeStrengthEndpoint(svc))),
+	}
+}
+
+// metricsAthleteID extracts the athlete a body metrics request is addressed
+// to.
+func metricsAthleteID(req interface{}) string {
+	switch r := req.(type) {
+	case RecordBodyMetricRequest:
+		return r.AthleteID
+	case ListBodyMetricsRequest:
+		return r.AthleteID
+	case DeleteBodyMetricRequest:
+		return r.AthleteID
+	case MetricTrendRequest:
+		return r.AthleteID
+	case RelativeStrengthRequest:
+		return r.AthleteID
+	}
+	return ""
+}
+
+// MakeRecordBodyMetricEndpoint is a builder function that returns a
+// RecordEndpoint.
+func MakeRecordBodyMetricEndpoint(svc service.MetricsService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(RecordBodyMetricRequest)
+		m, err := svc.Record(ctx, request.AthleteID, request.Metric)
+		return BodyMetricResponse{Data: m, Err: err}, nil
+	}
+}
+
+// MakeListBodyMetricsEndpoint is a builder function that returns a
+// ListEndpoint.
+func MakeListBodyMetricsEndpoint(svc service.MetricsService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(ListBodyMetricsRequest)
+		metrics, err := svc.List(ctx, request.AthleteID, request.Filter, request.Unit)
+		return ListBodyMetricsResponse{Data: metrics, Err: err}, nil
+	}
+}
+
+// MakeDeleteBodyMetricEndpoint is a builder function that returns a
+// DeleteEndpoint.
+func MakeDeleteBodyMetricEndpoint(svc service.MetricsService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(DeleteBodyMetricRequest)
+		err := svc.Delete(ctx, request.AthleteID, request.Name)
+		return DeleteBodyMetricResponse{Err: err}, nil
+	}
+}
+
+// MakeMetricTrendEndpoint is a builder function that returns a
+// TrendEndpoint.
+func MakeMetricTrendEndpoint(svc service.MetricsService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(MetricTrendRequest)
+		trend, err := svc.Trend(ctx, request.AthleteID, request.Query)
+		return MetricTrendResponse{Data: trend, Err: err}, nil
+	}
+}
+
+// MakeRelativeStrengthEndpoint is a builder function that returns a
+// RelativeStrengthEndpoint.
+func MakeRelativeStrengthEndpoint(svc service.MetricsService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(RelativeStrengthRequest)
+		report, err := svc.RelativeStrength(ctx, request.AthleteID, request.Query)
+		return RelativeStrengthResponse{Data: report, Unit: service.PreferredUnit(ctx), Err: err}, nil
+	}
+}
+
+// compile-time assertions for our response types implementing
+// endpoint.Failer.
+var (
+	_ endpoint.Failer = BodyMetricResponse{}
+	_ endpoint.Failer = ListBodyMetricsResponse{}
+	_ endpoint.Failer = DeleteBodyMetricResponse{}
+	_ endpoint.Failer = MetricTrendResponse{}
+	_ endpoint.Failer = RelativeStrengthResponse{}
+)
+
+// RecordBodyMetricRequest collects the request parameters for the
+// RecordBodyMetric Endpoint.
+type RecordBodyMetricRequest struct {
+	RequestID string
+	AthleteID string
+	Metric    service.BodyMetric
+}
+
+// IdempotencyKey returns the client-supplied request ID, if any.
+func (r RecordBodyMetricRequest) IdempotencyKey() string {
+	return r.RequestID
+}
+
+// BodyMetricResponse collects the response parameters for the
+// RecordBodyMetric Endpoint.
+type BodyMetricResponse struct {
+	Data service.BodyMetric `json:"data"`
+	Err  error              `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r BodyMetricResponse) Failed() error {
+	return r.Err
+}
+
+// ListBodyMetricsRequest collects the request parameters for the
+// ListBodyMetrics Endpoint.
+type ListBodyMetricsRequest struct {
+	AthleteID string
+	Filter    service.BodyMetricFilter
+	Unit      service.MeasurementUnit
+}
+
+// ListBodyMetricsResponse collects the response parameters for the
+// ListBodyMetrics Endpoint.
+type ListBodyMetricsResponse struct {
+	Data []service.BodyMetric `json:"data"`
+	Err  error                `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r ListBodyMetricsResponse) Failed() error {
+	return r.Err
+}
+
+// DeleteBodyMetricRequest collects the request parameters for the
+// DeleteBodyMetric Endpoint.
+type DeleteBodyMetricRequest struct {
+	AthleteID string
+	Name      string
+}
+
+// DeleteBodyMetricResponse allows endpoint.Failer to be implemented.
+type DeleteBodyMetricResponse struct {
+	Err error `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r DeleteBodyMetricResponse) Failed() error {
+	return r.Err
+}
+
+// MetricTrendRequest collects the request parameters for the MetricTrend
+// Endpoint.
+type MetricTrendRequest struct {
+	AthleteID string
+	Query     service.TrendQuery
+}
+
+// MetricTrendResponse collects the response parameters for the MetricTrend
+// Endpoint.
+type MetricTrendResponse struct {
+	Data service.MetricTrend `json:"data"`
+	Err  error               `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r MetricTrendResponse) Failed() error {
+	return r.Err
+}
+
+// RelativeStrengthRequest collects the request parameters for the
+// RelativeStrength Endpoint.
+type RelativeStrengthRequest struct {
+	AthleteID string
+	Query     service.RelativeStrengthQuery
+}
+
+// RelativeStrengthResponse collects the response parameters for the
+// RelativeStrength Endpoint.
+type RelativeStrengthResponse struct {
+	Data service.RelativeStrengthReport `json:"data"`
+	Unit service.WeightUnit             `json:"unit"`
+	Err  error                          `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r RelativeStrengthResponse) Failed() error {
+	return r.Err
+}
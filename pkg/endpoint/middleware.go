@@ -0,0 +1,132 @@
+package endpoint
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+type contextKey string
+
+// UserIDContextKey holds the key used to store the caller's user ID in the
+// request context. Transports are responsible for populating it.
+const UserIDContextKey contextKey = "userID"
+
+// WeightUnitContextKey holds the key used to store the weight unit a request
+// asks for loads in, overriding the caller's preferred one.
+const WeightUnitContextKey contextKey = "weightUnit"
+
+// Authenticate returns endpoint middleware that resolves the user ID placed in
+// the context by the transport into a service.Principal.
+func Authenticate(users service.UserService) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			userID, _ := ctx.Value(UserIDContextKey).(string)
+			p, err := users.Authenticate(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			if unit, _ := ctx.Value(WeightUnitContextKey).(string); unit != "" {
+				if _, ok := service.PlateIncrements[service.WeightUnit(unit)]; !ok {
+					return nil, errors.Wrapf(service.ErrInvalidArgument, "unknown weight unit %q", unit)
+				}
+				p.Unit = service.WeightUnit(unit)
+			}
+			return next(service.NewContextWithPrincipal(ctx, p), request)
+		}
+	}
+}
+
+// Policy decides whether the principal in ctx may invoke an endpoint with the
+// given request. It returns nil to allow the call.
+type Policy func(ctx context.Context, p service.Principal, request interface{}) error
+
+// Authorize returns endpoint middleware that enforces policy. It must be
+// wrapped by Authenticate so that a principal is present.
+func Authorize(policy Policy) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			p, ok := service.PrincipalFromContext(ctx)
+			if !ok {
+				return nil, service.ErrUnauthenticated
+			}
+			if err := policy(ctx, p, request); err != nil {
+				return nil, err
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// RequireRole allows principals holding any of the given roles.
+func RequireRole(roles ...service.Role) Policy {
+	return func(_ context.Context, p service.Principal, _ interface{}) error {
+		if p.HasRole(roles...) {
+			return nil
+		}
+		return service.ErrPermissionDenied
+	}
+}
+
+// Self allows principals acting on their own user, as identified by userID.
+func Self(userID func(request interface{}) string) Policy {
+	return func(_ context.Context, p service.Principal, request interface{}) error {
+		if p.UserID == userID(request) {
+			return nil
+		}
+		return service.ErrPermissionDenied
+	}
+}
+
+// AthleteAccess allows the admins of the athlete's tenant, the athlete
+// identified by athleteID, and any coach that has the athlete on their
+// roster.
+func AthleteAccess(users service.UserService, athleteID func(request interface{}) string) Policy {
+	return func(ctx context.Context, p service.Principal, request interface{}) error {
+		id := athleteID(request)
+		switch {
+		case p.Role == service.RoleAdmin:
+			// Get only finds users of the caller's tenant.
+			_, err := users.Get(ctx, id)
+			return err
+		case p.UserID == id:
+			return nil
+		case p.Role == service.RoleCoach:
+			ok, err := users.IsCoachOf(ctx, p.UserID, id)
+			if err != nil {
+				return err
+			}
+			if ok {
+				return nil
+			}
+		}
+		return service.ErrPermissionDenied
+	}
+}
+
+// AnyOf allows a request if at least one of the policies allows it. The error
+// from the last policy is returned otherwise.
+func AnyOf(policies ...Policy) Policy {
+	return func(ctx context.Context, p service.Principal, request interface{}) error {
+		err := service.ErrPermissionDenied
+		for _, policy := range policies {
+			if err = policy(ctx, p, request); err == nil {
+				return nil
+			}
+		}
+		return err
+	}
+}
+
+// TenantAdmin allows admins of the tenant identified by tenantID.
+func TenantAdmin(tenantID func(request interface{}) string) Policy {
+	return func(_ context.Context, p service.Principal, request interface{}) error {
+		if p.Role == service.RoleAdmin && p.TenantID == tenantID(request) {
+			return nil
+		}
+		return service.ErrPermissionDenied
+	}
+}
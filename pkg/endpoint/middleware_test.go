@@ -0,0 +1,56 @@
+package endpoint
+
+import (
+	"context"
+	"testing"
+
+	"workout-manager-service/inmem"
+	"workout-manager-service/pkg/service"
+)
+
+func TestAthleteAccess(t *testing.T) {
+	ctx := context.Background()
+	s := inmem.NewStore()
+	for _, u := range []service.User{
+		{Name: "admin", TenantID: "t1", Role: service.RoleAdmin},
+		{Name: "coach", TenantID: "t1", Role: service.RoleCoach},
+		{Name: "other-coach", TenantID: "t1", Role: service.RoleCoach},
+		{Name: "athlete", TenantID: "t1", Role: service.RoleAthlete},
+		{Name: "foreign-admin", TenantID: "t2", Role: service.RoleAdmin},
+		{Name: "foreign-athlete", TenantID: "t2", Role: service.RoleAthlete},
+	} {
+		u.Email = u.Name + "@example.com"
+		if _, err := s.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.CreateCoaching(ctx, "t1", "coach", "athlete"); err != nil {
+		t.Fatal(err)
+	}
+	policy := AthleteAccess(service.NewBasicUserService(s, s), func(request interface{}) string {
+		return request.(string)
+	})
+
+	for _, tc := range []struct {
+		caller, athlete string
+		ok              bool
+	}{
+		{"athlete", "athlete", true},
+		{"coach", "athlete", true},
+		{"other-coach", "athlete", false},
+		{"admin", "athlete", true},
+		{"admin", "foreign-athlete", false},
+		{"foreign-admin", "athlete", false},
+		{"foreign-admin", "foreign-athlete", true},
+	} {
+		u, err := s.GetUser(ctx, tc.caller)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := service.Principal{UserID: u.Name, TenantID: u.TenantID, Role: u.Role}
+		err = policy(service.NewContextWithPrincipal(ctx, p), p, tc.athlete)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s reaching %s = %v, want allowed = %v", tc.caller, tc.athlete, err, tc.ok)
+		}
+	}
+}
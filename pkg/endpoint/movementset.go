@@ -2,8 +2,10 @@ package endpoint
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
 
 	"workout-manager-service/pkg/service"
 )
@@ -11,20 +13,53 @@ import (
 // MovementSet is a helper struct that collects all of the Movement endpoints
 // in the workout manager service.
 type MovementSet struct {
-	CreateEndpoint endpoint.Endpoint
-	GetEndpoint    endpoint.Endpoint
-	ListEndpoint   endpoint.Endpoint
-	DeleteEndpoint endpoint.Endpoint
+	CreateEndpoint      endpoint.Endpoint
+	UpdateEndpoint      endpoint.Endpoint
+	GetEndpoint         endpoint.Endpoint
+	ListEndpoint        endpoint.Endpoint
+	SearchEndpoint      endpoint.Endpoint
+	DeleteEndpoint      endpoint.Endpoint
+	UndeleteEndpoint    endpoint.Endpoint
+	OverrideEndpoint    endpoint.Endpoint
+	ForkEndpoint        endpoint.Endpoint
+	MergeEndpoint       endpoint.Endpoint
+	BatchCreateEndpoint endpoint.Endpoint
+	BatchGetEndpoint    endpoint.Endpoint
+	BatchDeleteEndpoint endpoint.Endpoint
+	ImportEndpoint      endpoint.Endpoint
+	ExportEndpoint      endpoint.Endpoint
+	WatchEndpoint       endpoint.Endpoint
 }
 
 // NewMovementSet returns a MovementSet that wraps the provided
-// MovementService and wires in the endpoint middleware.
-func NewMovementSet(svc service.MovementService) MovementSet {
+// MovementService and wires in the endpoint middleware. Any authenticated
+// user may read the movement catalog, but only coaches and admins may change
+// it. Operators maintain the global catalog. Creating movements, one at a
+// time or in a batch, is idempotent when the client supplies a request ID.
+func NewMovementSet(svc service.MovementService, users service.UserService, idempotency Idempotency) MovementSet {
+	var (
+		authenticate = Authenticate(users)
+		catalogWrite = Authorize(RequireRole(service.RoleCoach, service.RoleAdmin, service.RoleOperator))
+		createOnce   = idempotency.Middleware("CreateMovement", CreateMovementResponse{})
+		batchOnce    = idempotency.Middleware("BatchCreateMovements", BatchMovementsResponse{})
+	)
 	return MovementSet{
-		CreateEndpoint: MakeCreateMovementEndpoint(svc),
-		GetEndpoint:    MakeGetMovementEndpoint(svc),
-		ListEndpoint:   MakeListMovementsEndpoint(svc),
-		DeleteEndpoint: MakeDeleteMovementEndpoint(svc),
+		CreateEndpoint:      authenticate(catalogWrite(createOnce(MakeCreateMovementEndpoint(svc)))),
+		UpdateEndpoint:      authenticate(catalogWrite(MakeUpdateMovementEndpoint(svc))),
+		GetEndpoint:         authenticate(MakeGetMovementEndpoint(svc)),
+		ListEndpoint:        authenticate(MakeListMovementsEndpoint(svc)),
+		SearchEndpoint:      authenticate(MakeSearchMovementsEndpoint(svc)),
+		DeleteEndpoint:      authenticate(catalogWrite(MakeDeleteMovementEndpoint(svc))),
+		UndeleteEndpoint:    authenticate(catalogWrite(MakeUndeleteMovementEndpoint(svc))),
+		OverrideEndpoint:    authenticate(catalogWrite(MakeOverrideMovementEndpoint(svc))),
+		ForkEndpoint:        authenticate(catalogWrite(MakeForkMovementEndpoint(svc))),
+		MergeEndpoint:       authenticate(catalogWrite(MakeMergeMovementsEndpoint(svc))),
+		BatchCreateEndpoint: authenticate(catalogWrite(batchOnce(MakeBatchCreateMovementsEndpoint(svc)))),
+		BatchGetEndpoint:    authenticate(MakeBatchGetMovementsEndpoint(svc)),
+		BatchDeleteEndpoint: authenticate(catalogWrite(MakeBatchDeleteMovementsEndpoint(svc))),
+		ImportEndpoint:      authenticate(catalogWrite(MakeImportMovementsEndpoint(svc))),
+		ExportEndpoint:      authenticate(MakeExportMovementsEndpoint(svc)),
+		WatchEndpoint:       authenticate(MakeWatchMovementsEndpoint(svc)),
 	}
 }
 
@@ -33,32 +68,125 @@ func NewMovementSet(svc service.MovementService) MovementSet {
 func MakeCreateMovementEndpoint(svc service.MovementService) endpoint.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
 		request := req.(CreateMovementRequest)
-		mvm, err := svc.Create(ctx, request.MovementName, request.MovementCategoryID)
+		mvm, err := svc.Create(ctx, request.movement())
 		return CreateMovementResponse{
-			Data: service.Movement{
-				Name:               mvm.Name,
-				TenantID:           mvm.TenantID,
-				MovementName:       mvm.MovementName,
-				MovementCategoryID: mvm.MovementCategoryID,
-			},
-			Err: err,
+			Data: mvm,
+			Err:  err,
 		}, nil
 	}
 }
 
+// MakeBatchCreateMovementsEndpoint is a builder function that returns a
+// BatchCreateEndpoint.
+func MakeBatchCreateMovementsEndpoint(svc service.MovementService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(BatchCreateMovementsRequest)
+		mvms := make([]service.Movement, len(request.Requests))
+		for i, r := range request.Requests {
+			mvms[i] = r.movement()
+		}
+		results, err := svc.BatchCreate(ctx, mvms, request.Partial)
+		return BatchMovementsResponse{Data: results, Err: err}, nil
+	}
+}
+
+// MakeBatchGetMovementsEndpoint is a builder function that returns a
+// BatchGetEndpoint.
+func MakeBatchGetMovementsEndpoint(svc service.MovementService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(BatchGetMovementsRequest)
+		results, err := svc.BatchGet(ctx, request.Names, request.ShowDeleted, request.Partial)
+		return BatchMovementsResponse{Data: results, Err: err}, nil
+	}
+}
+
+// MakeBatchDeleteMovementsEndpoint is a builder function that returns a
+// BatchDeleteEndpoint.
+func MakeBatchDeleteMovementsEndpoint(svc service.MovementService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(BatchDeleteMovementsRequest)
+		deletions := make([]service.MovementDeletion, len(request.Requests))
+		for i, r := range request.Requests {
+			deletions[i] = service.MovementDeletion{ID: r.Name, Etag: r.Etag}
+		}
+		results, err := svc.BatchDelete(ctx, deletions, request.Partial)
+		return BatchMovementsResponse{Data: results, Err: err}, nil
+	}
+}
+
+// MakeUpdateMovementEndpoint is a builder function that returns an
+// UpdateEndpoint.
+func MakeUpdateMovementEndpoint(svc service.MovementService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(UpdateMovementRequest)
+		mvm, err := svc.Update(ctx, service.Movement{
+			Name:               request.Name,
+			MovementName:       request.MovementName,
+			MovementCategoryID: request.MovementCategoryID,
+			Equipment:          request.Equipment,
+			PrimaryMuscles:     request.PrimaryMuscles,
+			SecondaryMuscles:   request.SecondaryMuscles,
+			Laterality:         request.Laterality,
+			LoadType:           request.LoadType,
+			Links:              request.Links,
+			Aliases:            request.Aliases,
+		}, request.Etag)
+		return UpdateMovementResponse{Data: mvm, Err: err}, nil
+	}
+}
+
+// MakeImportMovementsEndpoint is a builder function that returns an
+// ImportEndpoint.
+func MakeImportMovementsEndpoint(svc service.MovementService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(ImportMovementsRequest)
+		report, err := svc.Import(ctx, service.MovementImport{
+			Format:  request.Format,
+			Columns: request.Columns,
+			Policy:  request.Policy,
+			DryRun:  request.DryRun,
+			Data:    request.Data,
+		})
+		return ImportMovementsResponse{Data: report, Err: err}, nil
+	}
+}
+
+// MakeExportMovementsEndpoint is a builder function that returns an
+// ExportEndpoint.
+func MakeExportMovementsEndpoint(svc service.MovementService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(ExportMovementsRequest)
+		data, err := svc.Export(ctx, request.Format, service.MovementFilter{
+			CategoryID:  request.CategoryName,
+			Equipment:   request.Equipment,
+			Muscle:      request.Muscle,
+			Laterality:  request.Laterality,
+			LoadType:    request.LoadType,
+			ShowDeleted: request.ShowDeleted,
+		})
+		return ExportMovementsResponse{Data: data, Err: err}, nil
+	}
+}
+
+// MakeWatchMovementsEndpoint is a builder function that returns a
+// WatchEndpoint. The endpoint returns once the watch ends; events are handed
+// to the request's Send function as they happen.
+func MakeWatchMovementsEndpoint(svc service.MovementService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(WatchMovementsRequest)
+		err := svc.Watch(ctx, request.ResumeToken, request.Send)
+		return WatchMovementsResponse{Err: err}, nil
+	}
+}
+
 // MakeGetMovementEndpoint is a builder function that returns a GetEndpoint.
 func MakeGetMovementEndpoint(svc service.MovementService) endpoint.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
 		request := req.(GetMovementRequest)
-		mvm, err := svc.Get(ctx, request.Name)
+		mvm, err := svc.Get(ctx, request.Name, request.ShowDeleted)
 		return GetMovementResponse{
-			Data: service.Movement{
-				Name:               mvm.Name,
-				TenantID:           mvm.TenantID,
-				MovementName:       mvm.MovementName,
-				MovementCategoryID: mvm.MovementCategoryID,
-			},
-			Err: err,
+			Data: mvm,
+			Err:  err,
 		}, nil
 	}
 }
@@ -68,7 +196,14 @@ func MakeGetMovementEndpoint(svc service.MovementService) endpoint.Endpoint {
 func MakeListMovementsEndpoint(svc service.MovementService) endpoint.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
 		request := req.(ListMovementsRequest)
-		mvms, err := svc.List(ctx, request.CategoryName)
+		mvms, err := svc.List(ctx, service.MovementFilter{
+			CategoryID:  request.CategoryName,
+			Equipment:   request.Equipment,
+			Muscle:      request.Muscle,
+			Laterality:  request.Laterality,
+			LoadType:    request.LoadType,
+			ShowDeleted: request.ShowDeleted,
+		})
 		return ListMovementsResponse{
 			Data: mvms,
 			Err:  err,
@@ -76,29 +211,116 @@ func MakeListMovementsEndpoint(svc service.MovementService) endpoint.Endpoint {
 	}
 }
 
+// MakeSearchMovementsEndpoint is a builder function that returns a
+// SearchEndpoint.
+func MakeSearchMovementsEndpoint(svc service.MovementService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(SearchMovementsRequest)
+		matches, err := svc.Search(ctx, request.Query, request.Limit)
+		return SearchMovementsResponse{Data: matches, Err: err}, nil
+	}
+}
+
+// MakeOverrideMovementEndpoint is a builder function that returns an
+// OverrideEndpoint.
+func MakeOverrideMovementEndpoint(svc service.MovementService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(OverrideMovementRequest)
+		mvm, err := svc.Override(ctx, request.Name, request.Hidden, request.Alias)
+		return OverrideMovementResponse{Data: mvm, Err: err}, nil
+	}
+}
+
+// MakeForkMovementEndpoint is a builder function that returns a
+// ForkEndpoint.
+func MakeForkMovementEndpoint(svc service.MovementService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(ForkMovementRequest)
+		mvm, err := svc.Fork(ctx, request.Name)
+		return ForkMovementResponse{Data: mvm, Err: err}, nil
+	}
+}
+
+// MakeMergeMovementsEndpoint is a builder function that returns a
+// MergeEndpoint.
+func MakeMergeMovementsEndpoint(svc service.MovementService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(MergeMovementsRequest)
+		mvm, err := svc.Merge(ctx, request.CanonicalID, request.DuplicateIDs)
+		return MergeMovementsResponse{Data: mvm, Err: err}, nil
+	}
+}
+
 // MakeDeleteMovementEndpoint is a builder function that returns a
 // DeleteEndpoint.
 func MakeDeleteMovementEndpoint(svc service.MovementService) endpoint.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
 		request := req.(DeleteMovementRequest)
-		err := svc.Delete(ctx, request.Name)
+		err := svc.Delete(ctx, request.Name, request.Etag)
 		return DeleteMovementResponse{Err: err}, nil
 	}
 }
 
+// MakeUndeleteMovementEndpoint is a builder function that returns an
+// UndeleteEndpoint.
+func MakeUndeleteMovementEndpoint(svc service.MovementService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(UndeleteMovementRequest)
+		mvm, err := svc.Undelete(ctx, request.Name)
+		return UndeleteMovementResponse{Data: mvm, Err: err}, nil
+	}
+}
+
 // compile-time assertions for our response types implementing
 // endpoint.Failer.
 var (
 	_ endpoint.Failer = CreateMovementResponse{}
+	_ endpoint.Failer = UpdateMovementResponse{}
 	_ endpoint.Failer = GetMovementResponse{}
+	_ endpoint.Failer = SearchMovementsResponse{}
+	_ endpoint.Failer = OverrideMovementResponse{}
+	_ endpoint.Failer = ForkMovementResponse{}
+	_ endpoint.Failer = MergeMovementsResponse{}
+	_ endpoint.Failer = UndeleteMovementResponse{}
+	_ endpoint.Failer = BatchMovementsResponse{}
+	_ endpoint.Failer = ImportMovementsResponse{}
+	_ endpoint.Failer = ExportMovementsResponse{}
+	_ endpoint.Failer = WatchMovementsResponse{}
 )
 
 // CreateMovementRequest collects the request parameters for the
 // CreateMovement Endpoint.
 type CreateMovementRequest struct {
-	TenantID           string `json:"tenantId"`
-	MovementName       string `json:"name"`
-	MovementCategoryID string `json:"movementCategoryId"`
+	RequestID          string                `json:"requestId"`
+	TenantID           string                `json:"tenantId"`
+	MovementName       string                `json:"name"`
+	MovementCategoryID string                `json:"movementCategoryId"`
+	Equipment          service.Equipment     `json:"equipment"`
+	PrimaryMuscles     []service.MuscleGroup `json:"primaryMuscles"`
+	SecondaryMuscles   []service.MuscleGroup `json:"secondaryMuscles"`
+	Laterality         service.Laterality    `json:"laterality"`
+	LoadType           service.LoadType      `json:"loadType"`
+	Links              []string              `json:"links"`
+	Aliases            []string              `json:"aliases"`
+}
+
+// IdempotencyKey returns the client-supplied request ID, if any.
+func (r CreateMovementRequest) IdempotencyKey() string {
+	return r.RequestID
+}
+
+func (r CreateMovementRequest) movement() service.Movement {
+	return service.Movement{
+		MovementName:       r.MovementName,
+		MovementCategoryID: r.MovementCategoryID,
+		Equipment:          r.Equipment,
+		PrimaryMuscles:     r.PrimaryMuscles,
+		SecondaryMuscles:   r.SecondaryMuscles,
+		Laterality:         r.Laterality,
+		LoadType:           r.LoadType,
+		Links:              r.Links,
+		Aliases:            r.Aliases,
+	}
 }
 
 // CreateMovementResponse collects the response parameters for the Create
@@ -113,9 +335,38 @@ func (r CreateMovementResponse) Failed() error {
 	return r.Err
 }
 
+// UpdateMovementRequest collects the request parameters for the Update
+// Endpoint. Etag is the movement's etag as the caller last saw it.
+type UpdateMovementRequest struct {
+	Name               string                `json:"id"`
+	Etag               string                `json:"etag"`
+	MovementName       string                `json:"name"`
+	MovementCategoryID string                `json:"movementCategoryId"`
+	Equipment          service.Equipment     `json:"equipment"`
+	PrimaryMuscles     []service.MuscleGroup `json:"primaryMuscles"`
+	SecondaryMuscles   []service.MuscleGroup `json:"secondaryMuscles"`
+	Laterality         service.Laterality    `json:"laterality"`
+	LoadType           service.LoadType      `json:"loadType"`
+	Links              []string              `json:"links"`
+	Aliases            []string              `json:"aliases"`
+}
+
+// UpdateMovementResponse collects the response parameters for the Update
+// Endpoint.
+type UpdateMovementResponse struct {
+	Data service.Movement `json:"data"`
+	Err  error            `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r UpdateMovementResponse) Failed() error {
+	return r.Err
+}
+
 // GetMovementRequest collects the request parameters for the Get Endpoint.
 type GetMovementRequest struct {
-	Name string
+	Name        string
+	ShowDeleted bool
 }
 
 // GetMovementResponse collects the response parameters for the Get Endpoint.
@@ -132,6 +383,11 @@ func (r GetMovementResponse) Failed() error {
 // ListMovementsRequest collects the request parameters for the List Endpoint.
 type ListMovementsRequest struct {
 	CategoryName string
+	Equipment    service.Equipment
+	Muscle       service.MuscleGroup
+	Laterality   service.Laterality
+	LoadType     service.LoadType
+	ShowDeleted  bool
 }
 
 // ListMovementsResponse collects the response parameters for the List
@@ -146,10 +402,30 @@ func (r ListMovementsResponse) Failed() error {
 	return r.Err
 }
 
+// SearchMovementsRequest collects the request parameters for the Search
+// Endpoint.
+type SearchMovementsRequest struct {
+	Query string
+	Limit int
+}
+
+// SearchMovementsResponse collects the response parameters for the Search
+// Endpoint.
+type SearchMovementsResponse struct {
+	Data []service.MovementMatch `json:"data"`
+	Err  error                   `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r SearchMovementsResponse) Failed() error {
+	return r.Err
+}
+
 // DeleteMovementRequest collects the request parameters for the Delete
 // Endpoint.
 type DeleteMovementRequest struct {
 	Name string
+	Etag string
 }
 
 // DeleteMovementResponse is an empty struct that allows endpoint.Failer to be
@@ -162,3 +438,224 @@ type DeleteMovementResponse struct {
 func (r DeleteMovementResponse) Failed() error {
 	return r.Err
 }
+
+// OverrideMovementRequest collects the request parameters for the Override
+// Endpoint.
+type OverrideMovementRequest struct {
+	Name   string `json:"id"`
+	Hidden bool   `json:"hidden"`
+	Alias  string `json:"alias"`
+}
+
+// OverrideMovementResponse collects the response parameters for the Override
+// Endpoint.
+type OverrideMovementResponse struct {
+	Data service.Movement `json:"data"`
+	Err  error            `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r OverrideMovementResponse) Failed() error {
+	return r.Err
+}
+
+// ForkMovementRequest collects the request parameters for the Fork Endpoint.
+type ForkMovementRequest struct {
+	Name string
+}
+
+// ForkMovementResponse collects the response parameters for the Fork
+// Endpoint.
+type ForkMovementResponse struct {
+	Data service.Movement `json:"data"`
+	Err  error            `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r ForkMovementResponse) Failed() error {
+	return r.Err
+}
+
+// MergeMovementsRequest collects the request parameters for the Merge
+// Endpoint.
+type MergeMovementsRequest struct {
+	CanonicalID  string   `json:"canonicalId"`
+	DuplicateIDs []string `json:"duplicateIds"`
+}
+
+// MergeMovementsResponse collects the response parameters for the Merge
+// Endpoint.
+type MergeMovementsResponse struct {
+	Data service.Movement `json:"data"`
+	Err  error            `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r MergeMovementsResponse) Failed() error {
+	return r.Err
+}
+
+// UndeleteMovementRequest collects the request parameters for the Undelete
+// Endpoint.
+type UndeleteMovementRequest struct {
+	Name string
+}
+
+// UndeleteMovementResponse collects the response parameters for the Undelete
+// Endpoint.
+type UndeleteMovementResponse struct {
+	Data service.Movement `json:"data"`
+	Err  error            `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r UndeleteMovementResponse) Failed() error {
+	return r.Err
+}
+
+// BatchCreateMovementsRequest collects the request parameters for the
+// BatchCreate Endpoint. Partial asks for per-item results instead of an all or
+// nothing batch. RequestID makes the whole batch safe to retry; the request
+// IDs of the items are ignored.
+type BatchCreateMovementsRequest struct {
+	RequestID string                  `json:"requestId"`
+	Requests  []CreateMovementRequest `json:"requests"`
+	Partial   bool                    `json:"partial"`
+}
+
+// IdempotencyKey returns the client-supplied request ID, if any.
+func (r BatchCreateMovementsRequest) IdempotencyKey() string {
+	return r.RequestID
+}
+
+// BatchGetMovementsRequest collects the request parameters for the BatchGet
+// Endpoint.
+type BatchGetMovementsRequest struct {
+	Names       []string `json:"ids"`
+	ShowDeleted bool     `json:"showDeleted"`
+	Partial     bool     `json:"partial"`
+}
+
+// BatchDeleteMovementsRequest collects the request parameters for the
+// BatchDelete Endpoint.
+type BatchDeleteMovementsRequest struct {
+	Requests []DeleteMovementRequest `json:"requests"`
+	Partial  bool                    `json:"partial"`
+}
+
+// BatchMovementsResponse collects the response parameters for the batch
+// Endpoints: one result per requested item, in order.
+type BatchMovementsResponse struct {
+	Data []service.MovementResult `json:"data"`
+	Err  error                    `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r BatchMovementsResponse) Failed() error {
+	return r.Err
+}
+
+// batchResultJSON is how a batch result is encoded for replay. Clients only
+// ever see the message of an item error, so that is all that is kept.
+type batchResultJSON struct {
+	Movement service.Movement `json:"movement"`
+	Err      string           `json:"err,omitempty"`
+}
+
+// MarshalJSON encodes the response with its item errors, which
+// service.MovementResult leaves out, so that a replayed partial batch reports
+// the same failures as the original.
+func (r BatchMovementsResponse) MarshalJSON() ([]byte, error) {
+	data := make([]batchResultJSON, len(r.Data))
+	for i, result := range r.Data {
+		data[i].Movement = result.Movement
+		if result.Err != nil {
+			data[i].Err = result.Err.Error()
+		}
+	}
+	return json.Marshal(struct {
+		Data []batchResultJSON `json:"data"`
+	}{data})
+}
+
+// UnmarshalJSON decodes a response encoded by MarshalJSON.
+func (r *BatchMovementsResponse) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Data []batchResultJSON `json:"data"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	r.Data = make([]service.MovementResult, len(v.Data))
+	for i, result := range v.Data {
+		r.Data[i].Movement = result.Movement
+		if result.Err != "" {
+			r.Data[i].Err = errors.New(result.Err)
+		}
+	}
+	return nil
+}
+
+// ImportMovementsRequest collects the request parameters for the Import
+// Endpoint. Data holds the whole file being imported.
+type ImportMovementsRequest struct {
+	Format  service.MovementFormat `json:"format"`
+	Columns map[string]string      `json:"columns"`
+	Policy  service.ConflictPolicy `json:"policy"`
+	DryRun  bool                   `json:"dryRun"`
+	Data    []byte                 `json:"data"`
+}
+
+// ImportMovementsResponse collects the response parameters for the Import
+// Endpoint.
+type ImportMovementsResponse struct {
+	Data service.ImportReport `json:"data"`
+	Err  error                `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r ImportMovementsResponse) Failed() error {
+	return r.Err
+}
+
+// ExportMovementsRequest collects the request parameters for the Export
+// Endpoint.
+type ExportMovementsRequest struct {
+	Format       service.MovementFormat
+	CategoryName string
+	Equipment    service.Equipment
+	Muscle       service.MuscleGroup
+	Laterality   service.Laterality
+	LoadType     service.LoadType
+	ShowDeleted  bool
+}
+
+// ExportMovementsResponse collects the response parameters for the Export
+// Endpoint. Data holds the whole exported file.
+type ExportMovementsResponse struct {
+	Data []byte `json:"data"`
+	Err  error  `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r ExportMovementsResponse) Failed() error {
+	return r.Err
+}
+
+// WatchMovementsRequest collects the request parameters for the Watch
+// Endpoint. Send delivers each event to the client.
+type WatchMovementsRequest struct {
+	ResumeToken string                            `json:"resumeToken"`
+	Send        func(service.MovementEvent) error `json:"-"`
+}
+
+// WatchMovementsResponse collects the response parameters for the Watch
+// Endpoint.
+type WatchMovementsResponse struct {
+	Err error `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r WatchMovementsResponse) Failed() error {
+	return r.Err
+}
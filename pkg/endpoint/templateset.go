@@ -0,0 +1,164 @@
+package endpoint
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+
+	"workout-manager-service/pkg/service"
+)
+
+// TemplateSet is a helper struct that collects all of the Template endpoints
+// in the workout manager service.
+type TemplateSet struct {
+	CreateEndpoint endpoint.Endpoint
+	GetEndpoint    endpoint.Endpoint
+	ListEndpoint   endpoint.Endpoint
+	UpdateEndpoint endpoint.Endpoint
+	DeleteEndpoint endpoint.Endpoint
+}
+
+// NewTemplateSet returns a TemplateSet that wraps the provided
+// TemplateService and wires in the endpoint middleware. Any user may save
+// templates; the service decides who can see and change them. Creating a
+// template is idempotent when the client supplies a request ID.
+func NewTemplateSet(svc service.TemplateService, users service.UserService, idempotency Idempotency) TemplateSet {
+	var (
+		authenticate = Authenticate(users)
+		createOnce   = idempotency.Middleware("CreateTemplate", TemplateResponse{})
+	)
+	return TemplateSet{
+		CreateEndpoint: authenticate(createOnce(MakeCreateTemplateEndpoint(svc))),
+		GetEndpoint:    authenticate(MakeGetTemplateEndpoint(svc)),
+		ListEndpoint:   authenticate(MakeListTemplatesEndpoint(svc)),
+		UpdateEndpoint: authenticate(MakeUpdateTemplateEndpoint(svc)),
+		DeleteEndpoint: authenticate(MakeDeleteTemplateEndpoint(svc)),
+	}
+}
+
+// MakeCreateTemplateEndpoint is a builder function that returns a
+// CreateEndpoint.
+func MakeCreateTemplateEndpoint(svc service.TemplateService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(CreateTemplateRequest)
+		t, err := svc.Create(ctx, request.Template)
+		return TemplateResponse{Data: t, Unit: service.PreferredUnit(ctx), Err: err}, nil
+	}
+}
+
+// MakeGetTemplateEndpoint is a builder function that returns a GetEndpoint.
+func MakeGetTemplateEndpoint(svc service.TemplateService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(GetTemplateRequest)
+		t, err := svc.Get(ctx, request.Name)
+		return TemplateResponse{Data: t, Unit: service.PreferredUnit(ctx), Err: err}, nil
+	}
+}
+
+// MakeListTemplatesEndpoint is a builder function that returns a
+// ListEndpoint.
+func MakeListTemplatesEndpoint(svc service.TemplateService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		templates, err := svc.List(ctx)
+		return ListTemplatesResponse{Data: templates, Unit: service.PreferredUnit(ctx), Err: err}, nil
+	}
+}
+
+// MakeUpdateTemplateEndpoint is a builder function that returns an
+// UpdateEndpoint.
+func MakeUpdateTemplateEndpoint(svc service.TemplateService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(UpdateTemplateRequest)
+		t, err := svc.Update(ctx, request.Template)
+		return TemplateResponse{Data: t, Unit: service.PreferredUnit(ctx), Err: err}, nil
+	}
+}
+
+// MakeDeleteTemplateEndpoint is a builder function that returns a
+// DeleteEndpoint.
+func MakeDeleteTemplateEndpoint(svc service.TemplateService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(DeleteTemplateRequest)
+		err := svc.Delete(ctx, request.Name)
+		return DeleteTemplateResponse{Err: err}, nil
+	}
+}
+
+// compile-time assertions for our response types implementing
+// endpoint.Failer.
+var (
+	_ endpoint.Failer = TemplateResponse{}
+	_ endpoint.Failer = ListTemplatesResponse{}
+	_ endpoint.Failer = DeleteTemplateResponse{}
+)
+
+// CreateTemplateRequest collects the request parameters for the
+// CreateTemplate Endpoint. Only the title, scope, sharing and sets of
+// Template are used.
+type CreateTemplateRequest struct {
+	RequestID string                  `json:"requestId"`
+	Template  service.WorkoutTemplate `json:"template"`
+}
+
+// IdempotencyKey returns the client-supplied request ID, if any.
+func (r CreateTemplateRequest) IdempotencyKey() string {
+	return r.RequestID
+}
+
+// GetTemplateRequest collects the request parameters for the GetTemplate
+// Endpoint.
+type GetTemplateRequest struct {
+	Name string
+}
+
+// ListTemplatesRequest is an empty struct that allows filters to be added if
+// the need arises.
+type ListTemplatesRequest struct{}
+
+// ListTemplatesResponse collects the response parameters for the
+// ListTemplates Endpoint.
+type ListTemplatesResponse struct {
+	Data []service.WorkoutTemplate `json:"data"`
+	Unit service.WeightUnit        `json:"unit"`
+	Err  error                     `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r ListTemplatesResponse) Failed() error {
+	return r.Err
+}
+
+// UpdateTemplateRequest collects the request parameters for the
+// UpdateTemplate Endpoint. The template is identified by its Name.
+type UpdateTemplateRequest struct {
+	Template service.WorkoutTemplate `json:"template"`
+}
+
+// DeleteTemplateRequest collects the request parameters for the
+// DeleteTemplate Endpoint.
+type DeleteTemplateRequest struct {
+	Name string
+}
+
+// DeleteTemplateResponse allows endpoint.Failer to be implemented.
+type DeleteTemplateResponse struct {
+	Err error `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r DeleteTemplateResponse) Failed() error {
+	return r.Err
+}
+
+// TemplateResponse collects the response parameters for every Template
+// Endpoint that returns a single template.
+type TemplateResponse struct {
+	Data service.WorkoutTemplate `json:"data"`
+	Unit service.WeightUnit      `json:"unit"`
+	Err  error                   `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r TemplateResponse) Failed() error {
+	return r.Err
+}
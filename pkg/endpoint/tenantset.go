@@ -0,0 +1,222 @@
+package endpoint
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+
+	"workout-manager-service/pkg/service"
+)
+
+// TenantSet is a helper struct that collects all of the Tenant endpoints in
+// the workout manager service.
+type TenantSet struct {
+	CreateEndpoint         endpoint.Endpoint
+	GetEndpoint            endpoint.Endpoint
+	ListEndpoint           endpoint.Endpoint
+	UpdateSettingsEndpoint endpoint.Endpoint
+	SuspendEndpoint        endpoint.Endpoint
+	ResumeEndpoint         endpoint.Endpoint
+	DeleteEndpoint         endpoint.Endpoint
+}
+
+// NewTenantSet returns a TenantSet that wraps the provided TenantService and
+// wires in the endpoint middleware. Provisioning is reserved for platform
+// operators, while a tenant's own admins may read and configure it.
+func NewTenantSet(svc service.TenantService, users service.UserService) TenantSet {
+	var (
+		authenticate = Authenticate(users)
+		operatorOnly = Authorize(RequireRole(service.RoleOperator))
+		tenantAccess = Authorize(AnyOf(
+			RequireRole(service.RoleOperator),
+			TenantAdmin(func(req interface{}) string {
+				switch r := req.(type) {
+				case GetTenantRequest:
+					return r.Name
+				case UpdateTenantSettingsRequest:
+					return r.Name
+				}
+				return ""
+			}),
+		))
+	)
+	return TenantSet{
+		CreateEndpoint:         authenticate(operatorOnly(MakeCreateTenantEndpoint(svc))),
+		GetEndpoint:            authenticate(tenantAccess(MakeGetTenantEndpoint(svc))),
+		ListEndpoint:           authenticate(operatorOnly(MakeListTenantsEndpoint(svc))),
+		UpdateSettingsEndpoint: authenticate(tenantAccess(MakeUpdateTenantSettingsEndpoint(svc))),
+		SuspendEndpoint:        authenticate(operatorOnly(MakeSuspendTenantEndpoint(svc))),
+		ResumeEndpoint:         authenticate(operatorOnly(MakeResumeTenantEndpoint(svc))),
+		DeleteEndpoint:         authenticate(operatorOnly(MakeDeleteTenantEndpoint(svc))),
+	}
+}
+
+// MakeCreateTenantEndpoint is a builder function that returns a
+// CreateEndpoint.
+func MakeCreateTenantEndpoint(svc service.TenantService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(CreateTenantRequest)
+		t, admin, err := svc.Create(ctx, request.DisplayName, request.Settings, request.Admin)
+		return CreateTenantResponse{Data: t, Admin: admin, Err: err}, nil
+	}
+}
+
+// MakeGetTenantEndpoint is a builder function that returns a GetEndpoint.
+func MakeGetTenantEndpoint(svc service.TenantService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(GetTenantRequest)
+		t, err := svc.Get(ctx, request.Name)
+		return TenantResponse{Data: t, Err: err}, nil
+	}
+}
+
+// MakeListTenantsEndpoint is a builder function that returns a ListEndpoint.
+func MakeListTenantsEndpoint(svc service.TenantService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		tenants, err := svc.List(ctx)
+		return ListTenantsResponse{Data: tenants, Err: err}, nil
+	}
+}
+
+// MakeUpdateTenantSettingsEndpoint is a builder function that returns an
+// UpdateSettingsEndpoint.
+func MakeUpdateTenantSettingsEndpoint(svc service.TenantService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(UpdateTenantSettingsRequest)
+		t, err := svc.UpdateSettings(ctx, request.Name, request.Settings, request.UpdateMask)
+		return TenantResponse{Data: t, Err: err}, nil
+	}
+}
+
+// MakeSuspendTenantEndpoint is a builder function that returns a
+// SuspendEndpoint.
+func MakeSuspendTenantEndpoint(svc service.TenantService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(SuspendTenantRequest)
+		t, err := svc.Suspend(ctx, request.Name)
+		return TenantResponse{Data: t, Err: err}, nil
+	}
+}
+
+// MakeResumeTenantEndpoint is a builder function that returns a
+// ResumeEndpoint.
+func MakeResumeTenantEndpoint(svc service.TenantService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(ResumeTenantRequest)
+		t, err := svc.Resume(ctx, request.Name)
+		return TenantResponse{Data: t, Err: err}, nil
+	}
+}
+
+// MakeDeleteTenantEndpoint is a builder function that returns a
+// DeleteEndpoint.
+func MakeDeleteTenantEndpoint(svc service.TenantService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(DeleteTenantRequest)
+		d, err := svc.Delete(ctx, request.Name, request.Reason)
+		return DeleteTenantResponse{Data: d, Err: err}, nil
+	}
+}
+
+// compile-time assertions for our response types implementing
+// endpoint.Failer.
+var (
+	_ endpoint.Failer = CreateTenantResponse{}
+	_ endpoint.Failer = TenantResponse{}
+	_ endpoint.Failer = ListTenantsResponse{}
+	_ endpoint.Failer = DeleteTenantResponse{}
+)
+
+// CreateTenantRequest collects the request parameters for the CreateTenant
+// Endpoint. Only the display name and email of Admin are used.
+type CreateTenantRequest struct {
+	DisplayName string                 `json:"displayName"`
+	Settings    service.TenantSettings `json:"settings"`
+	Admin       service.User           `json:"admin"`
+}
+
+// CreateTenantResponse collects the response parameters for the
+// CreateTenant Endpoint.
+type CreateTenantResponse struct {
+	Data  service.Tenant `json:"data"`
+	Admin service.User   `json:"admin"`
+	Err   error          `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r CreateTenantResponse) Failed() error {
+	return r.Err
+}
+
+// GetTenantRequest collects the request parameters for the GetTenant
+// Endpoint.
+type GetTenantRequest struct {
+	Name string
+}
+
+// ListTenantsRequest is an empty struct that allows filters to be added if
+// the need arises.
+type ListTenantsRequest struct{}
+
+// ListTenantsResponse collects the response parameters for the ListTenants
+// Endpoint.
+type ListTenantsResponse struct {
+	Data []service.Tenant `json:"data"`
+	Err  error            `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r ListTenantsResponse) Failed() error {
+	return r.Err
+}
+
+// UpdateTenantSettingsRequest collects the request parameters for the
+// UpdateTenantSettings Endpoint.
+type UpdateTenantSettingsRequest struct {
+	Name       string                 `json:"id"`
+	Settings   service.TenantSettings `json:"settings"`
+	UpdateMask []string               `json:"updateMask"`
+}
+
+// SuspendTenantRequest collects the request parameters for the
+// SuspendTenant Endpoint.
+type SuspendTenantRequest struct {
+	Name string
+}
+
+// ResumeTenantRequest collects the request parameters for the ResumeTenant
+// Endpoint.
+type ResumeTenantRequest struct {
+	Name string
+}
+
+// TenantResponse collects the response parameters for every Tenant Endpoint
+// that returns a single tenant.
+type TenantResponse struct {
+	Data service.Tenant `json:"data"`
+	Err  error          `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r TenantResponse) Failed() error {
+	return r.Err
+}
+
+// DeleteTenantRequest collects the request parameters for the DeleteTenant
+// Endpoint. A reason is mandatory and is kept in the deletion's audit record.
+type DeleteTenantRequest struct {
+	Name   string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// DeleteTenantResponse collects the response parameters for the
+// DeleteTenant Endpoint.
+type DeleteTenantResponse struct {
+	Data service.TenantDeletion `json:"data"`
+	Err  error                  `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r DeleteTenantResponse) Failed() error {
+	return r.Err
+}
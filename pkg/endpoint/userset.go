@@ -0,0 +1,220 @@
+package endpoint
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+
+	"workout-manager-service/pkg/service"
+)
+
+// UserSet is a helper struct that collects all of the User endpoints in the
+// workout manager service.
+type UserSet struct {
+	CreateEndpoint        endpoint.Endpoint
+	GetEndpoint           endpoint.Endpoint
+	ListEndpoint          endpoint.Endpoint
+	AssignCoachEndpoint   endpoint.Endpoint
+	UnassignCoachEndpoint endpoint.Endpoint
+	ListRosterEndpoint    endpoint.Endpoint
+}
+
+// NewUserSet returns a UserSet that wraps the provided UserService and wires
+// in the endpoint middleware. Admins manage users and rosters; coaches may
+// see their own roster and the athletes on it; everyone may see themselves.
+func NewUserSet(svc service.UserService) UserSet {
+	var (
+		authenticate = Authenticate(svc)
+		adminOnly    = Authorize(RequireRole(service.RoleAdmin))
+		staffOnly    = Authorize(RequireRole(service.RoleCoach, service.RoleAdmin))
+		userAccess   = Authorize(AthleteAccess(svc, func(req interface{}) string {
+			return req.(GetUserRequest).Name
+		}))
+		rosterAccess = Authorize(AnyOf(
+			RequireRole(service.RoleAdmin),
+			Self(func(req interface{}) string { return req.(ListRosterRequest).CoachID }),
+		))
+	)
+	return UserSet{
+		CreateEndpoint:        authenticate(adminOnly(MakeCreateUserEndpoint(svc))),
+		GetEndpoint:           authenticate(userAccess(MakeGetUserEndpoint(svc))),
+		ListEndpoint:          authenticate(staffOnly(MakeListUsersEndpoint(svc))),
+		AssignCoachEndpoint:   authenticate(adminOnly(MakeAssignCoachEndpoint(svc))),
+		UnassignCoachEndpoint: authenticate(adminOnly(MakeUnassignCoachEndpoint(svc))),
+		ListRosterEndpoint:    authenticate(rosterAccess(MakeListRosterEndpoint(svc))),
+	}
+}
+
+// MakeCreateUserEndpoint is a builder function that returns a CreateEndpoint.
+func MakeCreateUserEndpoint(svc service.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(CreateUserRequest)
+		u, err := svc.Create(ctx, request.DisplayName, request.Email, request.Role)
+		return CreateUserResponse{Data: u, Err: err}, nil
+	}
+}
+
+// MakeGetUserEndpoint is a builder function that returns a GetEndpoint.
+func MakeGetUserEndpoint(svc service.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(GetUserRequest)
+		u, err := svc.Get(ctx, request.Name)
+		return GetUserResponse{Data: u, Err: err}, nil
+	}
+}
+
+// MakeListUsersEndpoint is a builder function that returns a ListEndpoint.
+func MakeListUsersEndpoint(svc service.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(ListUsersRequest)
+		users, err := svc.List(ctx, request.Role)
+		return ListUsersResponse{Data: users, Err: err}, nil
+	}
+}
+
+// MakeAssignCoachEndpoint is a builder function that returns an
+// AssignCoachEndpoint.
+func MakeAssignCoachEndpoint(svc service.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(AssignCoachRequest)
+		err := svc.AssignCoach(ctx, request.CoachID, request.AthleteID)
+		return AssignCoachResponse{Err: err}, nil
+	}
+}
+
+// MakeUnassignCoachEndpoint is a builder function that returns an
+// UnassignCoachEndpoint.
+func MakeUnassignCoachEndpoint(svc service.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(UnassignCoachRequest)
+		err := svc.UnassignCoach(ctx, request.CoachID, request.AthleteID)
+		return UnassignCoachResponse{Err: err}, nil
+	}
+}
+
+// MakeListRosterEndpoint is a builder function that returns a
+// ListRosterEndpoint.
+func MakeListRosterEndpoint(svc service.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(ListRosterRequest)
+		athletes, err := svc.ListRoster(ctx, request.CoachID)
+		return ListRosterResponse{Data: athletes, Err: err}, nil
+	}
+}
+
+// compile-time assertions for our response types implementing
+// endpoint.Failer.
+var (
+	_ endpoint.Failer = CreateUserResponse{}
+	_ endpoint.Failer = GetUserResponse{}
+	_ endpoint.Failer = ListUsersResponse{}
+	_ endpoint.Failer = AssignCoachResponse{}
+	_ endpoint.Failer = UnassignCoachResponse{}
+	_ endpoint.Failer = ListRosterResponse{}
+)
+
+// CreateUserRequest collects the request parameters for the CreateUser
+// Endpoint.
+type CreateUserRequest struct {
+	DisplayName string       `json:"displayName"`
+	Email       string       `json:"email"`
+	Role        service.Role `json:"role"`
+}
+
+// CreateUserResponse collects the response parameters for the CreateUser
+// Endpoint.
+type CreateUserResponse struct {
+	Data service.User `json:"data"`
+	Err  error        `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r CreateUserResponse) Failed() error {
+	return r.Err
+}
+
+// GetUserRequest collects the request parameters for the GetUser Endpoint.
+type GetUserRequest struct {
+	Name string
+}
+
+// GetUserResponse collects the response parameters for the GetUser Endpoint.
+type GetUserResponse struct {
+	Data service.User `json:"data"`
+	Err  error        `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r GetUserResponse) Failed() error {
+	return r.Err
+}
+
+// ListUsersRequest collects the request parameters for the ListUsers
+// Endpoint.
+type ListUsersRequest struct {
+	Role service.Role
+}
+
+// ListUsersResponse collects the response parameters for the ListUsers
+// Endpoint.
+type ListUsersResponse struct {
+	Data []service.User `json:"data"`
+	Err  error          `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r ListUsersResponse) Failed() error {
+	return r.Err
+}
+
+// AssignCoachRequest collects the request parameters for the AssignCoach
+// Endpoint.
+type AssignCoachRequest struct {
+	CoachID   string `json:"coachId"`
+	AthleteID string `json:"athleteId"`
+}
+
+// AssignCoachResponse allows endpoint.Failer to be implemented.
+type AssignCoachResponse struct {
+	Err error `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r AssignCoachResponse) Failed() error {
+	return r.Err
+}
+
+// UnassignCoachRequest collects the request parameters for the UnassignCoach
+// Endpoint.
+type UnassignCoachRequest struct {
+	CoachID   string `json:"coachId"`
+	AthleteID string `json:"athleteId"`
+}
+
+// UnassignCoachResponse allows endpoint.Failer to be implemented.
+type UnassignCoachResponse struct {
+	Err error `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r UnassignCoachResponse) Failed() error {
+	return r.Err
+}
+
+// ListRosterRequest collects the request parameters for the ListRoster
+// Endpoint.
+type ListRosterRequest struct {
+	CoachID string
+}
+
+// ListRosterResponse collects the response parameters for the ListRoster
+// Endpoint.
+type ListRosterResponse struct {
+	Data []service.User `json:"data"`
+	Err  error          `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r ListRosterResponse) Failed() error {
+	return r.Err
+}
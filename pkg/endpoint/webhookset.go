@@ -0,0 +1,249 @@
+package endpoint
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+
+	"workout-manager-service/pkg/service"
+)
+
+// WebhookSet is a helper struct that collects all of the Webhook endpoints
+// in the workout manager service.
+type WebhookSet struct {
+	CreateEndpoint         endpoint.Endpoint
+	GetEndpoint            endpoint.Endpoint
+	ListEndpoint           endpoint.Endpoint
+	UpdateEndpoint         endpoint.Endpoint
+	DeleteEndpoint         endpoint.Endpoint
+	RotateSecretEndpoint   endpoint.Endpoint
+	ListDeliveriesEndpoint endpoint.Endpoint
+	RedeliverEndpoint      endpoint.Endpoint
+}
+
+// NewWebhookSet returns a WebhookSet that wraps the provided WebhookService
+// and wires in the endpoint middleware. Webhooks send a tenant's data to
+// other systems, so only its admins may manage them. Creating a webhook is
+// idempotent when the client supplies a request ID.
+func NewWebhookSet(svc service.WebhookService, users service.UserService, idempotency Idempotency) WebhookSet {
+	var (
+		authenticate = Authenticate(users)
+		adminOnly    = Authorize(RequireRole(service.RoleAdmin))
+		createOnce   = idempotency.Middleware("CreateWebhook", WebhookResponse{})
+	)
+	return WebhookSet{
+		CreateEndpoint:         authenticate(adminOnly(createOnce(MakeCreateWebhookEndpoint(svc)))),
+		GetEndpoint:            authenticate(adminOnly(MakeGetWebhookEndpoint(svc))),
+		ListEndpoint:           authenticate(adminOnly(MakeListWebhooksEndpoint(svc))),
+		UpdateEndpoint:         authenticate(adminOnly(MakeUpdateWebhookEndpoint(svc))),
+		DeleteEndpoint:         authenticate(adminOnly(MakeDeleteWebhookEndpoint(svc))),
+		RotateSecretEndpoint:   authenticate(adminOnly(MakeRotateWebhookSecretEndpoint(svc))),
+		ListDeliveriesEndpoint: authenticate(adminOnly(MakeListWebhookDeliveriesEndpoint(svc))),
+		RedeliverEndpoint:      authenticate(adminOnly(MakeRedeliverWebhookEndpoint(svc))),
+	}
+}
+
+// MakeCreateWebhookEndpoint is a builder function that returns a
+// CreateEndpoint.
+func MakeCreateWebhookEndpoint(svc service.WebhookService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(CreateWebhookRequest)
+		w, err := svc.Create(ctx, request.Webhook)
+		return WebhookResponse{Data: w, Err: err}, nil
+	}
+}
+
+// MakeGetWebhookEndpoint is a builder function that returns a GetEndpoint.
+func MakeGetWebhookEndpoint(svc service.WebhookService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(GetWebhookRequest)
+		w, err := svc.Get(ctx, request.Name)
+		return WebhookResponse{Data: w, Err: err}, nil
+	}
+}
+
+// MakeListWebhooksEndpoint is a builder function that returns a
+// ListEndpoint.
+func MakeListWebhooksEndpoint(svc service.WebhookService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		webhooks, err := svc.List(ctx)
+		return ListWebhooksResponse{Data: webhooks, Err: err}, nil
+	}
+}
+
+// MakeUpdateWebhookEndpoint is a builder function that returns an
+// UpdateEndpoint.
+func MakeUpdateWebhookEndpoint(svc service.WebhookService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(UpdateWebhookRequest)
+		w, err := svc.Update(ctx, request.Webhook)
+		return WebhookResponse{Data: w, Err: err}, nil
+	}
+}
+
+// MakeDeleteWebhookEndpoint is a builder function that returns a
+// DeleteEndpoint.
+func MakeDeleteWebhookEndpoint(svc service.WebhookService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(DeleteWebhookRequest)
+		err := svc.Delete(ctx, request.Name)
+		return DeleteWebhookResponse{Err: err}, nil
+	}
+}
+
+// MakeRotateWebhookSecretEndpoint is a builder function that returns a
+// RotateSecretEndpoint.
+func MakeRotateWebhookSecretEndpoint(svc service.WebhookService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(RotateWebhookSecretRequest)
+		w, err := svc.RotateSecret(ctx, request.Name)
+		return WebhookResponse{Data: w, Err: err}, nil
+	}
+}
+
+// MakeListWebhookDeliveriesEndpoint is a builder function that returns a
+// ListDeliveriesEndpoint.
+func MakeListWebhookDeliveriesEndpoint(svc service.WebhookService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(ListWebhookDeliveriesRequest)
+		deliveries, err := svc.ListDeliveries(ctx, service.WebhookDeliveryFilter{
+			WebhookID: request.WebhookID,
+			State:     request.State,
+			Limit:     request.Limit,
+		})
+		return ListWebhookDeliveriesResponse{Data: deliveries, Err: err}, nil
+	}
+}
+
+// MakeRedeliverWebhookEndpoint is a builder function that returns a
+// RedeliverEndpoint.
+func MakeRedeliverWebhookEndpoint(svc service.WebhookService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(RedeliverWebhookRequest)
+		d, err := svc.Redeliver(ctx, request.Name)
+		return WebhookDeliveryResponse{Data: d, Err: err}, nil
+	}
+}
+
+// compile-time assertions for our response types implementing
+// endpoint.Failer.
+var (
+	_ endpoint.Failer = WebhookResponse{}
+	_ endpoint.Failer = ListWebhooksResponse{}
+	_ endpoint.Failer = DeleteWebhookResponse{}
+	_ endpoint.Failer = ListWebhookDeliveriesResponse{}
+	_ endpoint.Failer = WebhookDeliveryResponse{}
+)
+
+// CreateWebhookRequest collects the request parameters for the
+// CreateWebhook Endpoint. Only the URL, event types, description and
+// disabled flag of Webhook are used.
+type CreateWebhookRequest struct {
+	RequestID string          `json:"requestId"`
+	Webhook   service.Webhook `json:"webhook"`
+}
+
+// IdempotencyKey returns the client-supplied request ID, if any.
+func (r CreateWebhookRequest) IdempotencyKey() string {
+	return r.RequestID
+}
+
+// GetWebhookRequest collects the request parameters for the GetWebhook
+// Endpoint.
+type GetWebhookRequest struct {
+	Name string
+}
+
+// ListWebhooksRequest is an empty struct that allows filters to be added if
+// the need arises.
+type ListWebhooksRequest struct{}
+
+// ListWebhooksResponse collects the response parameters for the
+// ListWebhooks Endpoint.
+type ListWebhooksResponse struct {
+	Data []service.Webhook `json:"data"`
+	Err  error             `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r ListWebhooksResponse) Failed() error {
+	return r.Err
+}
+
+// UpdateWebhookRequest collects the request parameters for the
+// UpdateWebhook Endpoint. The webhook is identified by its Name.
+type UpdateWebhookRequest struct {
+	Webhook service.Webhook `json:"webhook"`
+}
+
+// DeleteWebhookRequest collects the request parameters for the
+// DeleteWebhook Endpoint.
+type DeleteWebhookRequest struct {
+	Name string
+}
+
+// DeleteWebhookResponse allows endpoint.Failer to be implemented.
+type DeleteWebhookResponse struct {
+	Err error `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r DeleteWebhookResponse) Failed() error {
+	return r.Err
+}
+
+// RotateWebhookSecretRequest collects the request parameters for the
+// RotateWebhookSecret Endpoint.
+type RotateWebhookSecretRequest struct {
+	Name string
+}
+
+// WebhookResponse collects the response parameters for every Webhook
+// Endpoint that returns a single webhook.
+type WebhookResponse struct {
+	Data service.Webhook `json:"data"`
+	Err  error           `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r WebhookResponse) Failed() error {
+	return r.Err
+}
+
+// ListWebhookDeliveriesRequest collects the request parameters for the
+// ListWebhookDeliveries Endpoint.
+type ListWebhookDeliveriesRequest struct {
+	WebhookID string
+	State     service.WebhookDeliveryState
+	Limit     int
+}
+
+// ListWebhookDeliveriesResponse collects the response parameters for the
+// ListWebhookDeliveries Endpoint.
+type ListWebhookDeliveriesResponse struct {
+	Data []service.WebhookDelivery `json:"data"`
+	Err  error                     `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r ListWebhookDeliveriesResponse) Failed() error {
+	return r.Err
+}
+
+// RedeliverWebhookRequest collects the request parameters for the
+// RedeliverWebhook Endpoint.
+type RedeliverWebhookRequest struct {
+	Name string
+}
+
+// WebhookDeliveryResponse collects the response parameters for the
+// RedeliverWebhook Endpoint.
+type WebhookDeliveryResponse struct {
+	Data service.WebhookDelivery `json:"data"`
+	Err  error                   `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r WebhookDeliveryResponse) Failed() error {
+	return r.Err
+}
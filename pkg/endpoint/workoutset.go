@@ -0,0 +1,314 @@
+package endpoint
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+
+	"workout-manager-service/pkg/activity"
+	"workout-manager-service/pkg/importer"
+	"workout-manager-service/pkg/service"
+)
+
+// WorkoutSet is a helper struct that collects all of the Workout endpoints in
+// the workout manager service.
+type WorkoutSet struct {
+	CreateEndpoint endpoint.Endpoint
+	GetEndpoint    endpoint.Endpoint
+	ListEndpoint   endpoint.Endpoint
+	DeleteEndpoint endpoint.Endpoint
+	ImportEndpoint endpoint.Endpoint
+	UploadEndpoint endpoint.Endpoint
+	RateEndpoint   endpoint.Endpoint
+	CloneEndpoint  endpoint.Endpoint
+}
+
+// NewWorkoutSet returns a WorkoutSet that wraps the provided WorkoutService
+// and wires in the endpoint middleware. Athletes may only reach their own
+// workouts and coaches may only reach the workouts of athletes on their
+// roster.
+func NewWorkoutSet(svc service.WorkoutService, users service.UserService) WorkoutSet {
+	var (
+		authenticate  = Authenticate(users)
+		athleteAccess = Authorize(AthleteAccess(users, workoutAthleteID))
+	)
+	return WorkoutSet{
+		CreateEndpoint: authenticate(athleteAccess(MakeCreateWorkoutEndpoint(svc))),
+		GetEndpoint:    authenticate(athleteAccess(MakeGetWorkoutEndpoint(svc))),
+		ListEndpoint:   authenticate(athleteAccess(MakeListWorkoutsEndpoint(svc))),
+		DeleteEndpoint: authenticate(athleteAccess(MakeDeleteWorkoutEndpoint(svc))),
+		ImportEndpoint: authenticate(athleteAccess(MakeImportWorkoutHistoryEndpoint(svc))),
+		UploadEndpoint: authenticate(athleteAccess(MakeUploadActivityEndpoint(svc))),
+		RateEndpoint:   authenticate(athleteAccess(MakeRateWorkoutSessionEndpoint(svc))),
+		CloneEndpoint:  authenticate(athleteAccess(MakeCloneWorkoutEndpoint(svc))),
+	}
+}
+
+// workoutAthleteID extracts the athlete a workout request is addressed to.
+func workoutAthleteID(req interface{}) string {
+	switch r := req.(type) {
+	case CreateWorkoutRequest:
+		return r.AthleteID
+	case GetWorkoutRequest:
+		return r.AthleteID
+	case ListWorkoutsRequest:
+		return r.AthleteID
+	case DeleteWorkoutRequest:
+		return r.AthleteID
+	case ImportWorkoutHistoryRequest:
+		return r.AthleteID
+	case UploadActivityRequest:
+		return r.AthleteID
+	case RateWorkoutSessionRequest:
+		return r.AthleteID
+	case CloneWorkoutRequest:
+		return r.AthleteID
+	}
+	return ""
+}
+
+// MakeCreateWorkoutEndpoint is a builder function that returns a
+// CreateEndpoint.
+func MakeCreateWorkoutEndpoint(svc service.WorkoutService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(CreateWorkoutRequest)
+		w, err := svc.Create(ctx, request.AthleteID, request.Title, request.PerformedAt, request.Sets, request.Blocks)
+		return CreateWorkoutResponse{Data: w, Unit: service.PreferredUnit(ctx), Err: err}, nil
+	}
+}
+
+// MakeGetWorkoutEndpoint is a builder function that returns a GetEndpoint.
+func MakeGetWorkoutEndpoint(svc service.WorkoutService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(GetWorkoutRequest)
+		w, err := svc.Get(ctx, request.AthleteID, request.Name)
+		return GetWorkoutResponse{Data: w, Unit: service.PreferredUnit(ctx), Err: err}, nil
+	}
+}
+
+// MakeListWorkoutsEndpoint is a builder function that returns a ListEndpoint.
+func MakeListWorkoutsEndpoint(svc service.WorkoutService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(ListWorkoutsRequest)
+		ws, err := svc.List(ctx, request.AthleteID)
+		return ListWorkoutsResponse{Data: ws, Unit: service.PreferredUnit(ctx), Err: err}, nil
+	}
+}
+
+// MakeDeleteWorkoutEndpoint is a builder function that returns a
+// DeleteEndpoint.
+func MakeDeleteWorkoutEndpoint(svc service.WorkoutService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(DeleteWorkoutRequest)
+		err := svc.Delete(ctx, request.AthleteID, request.Name)
+		return DeleteWorkoutResponse{Err: err}, nil
+	}
+}
+
+// MakeImportWorkoutHistoryEndpoint is a builder function that returns an
+// ImportEndpoint.
+func MakeImportWorkoutHistoryEndpoint(svc service.WorkoutService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(ImportWorkoutHistoryRequest)
+		report, err := svc.ImportHistory(ctx, request.AthleteID, service.HistoryImport{
+			Source:         request.Source,
+			Unit:           request.Unit,
+			TimeZone:       request.TimeZone,
+			Mappings:       request.Mappings,
+			SkipUnresolved: request.SkipUnresolved,
+			DryRun:         request.DryRun,
+			Data:           request.Data,
+		})
+		return ImportWorkoutHistoryResponse{Data: report, Err: err}, nil
+	}
+}
+
+// MakeUploadActivityEndpoint is a builder function that returns an
+// UploadEndpoint.
+func MakeUploadActivityEndpoint(svc service.WorkoutService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(UploadActivityRequest)
+		w, err := svc.UploadActivity(ctx, request.AthleteID, service.ActivityUpload{
+			Format:     request.Format,
+			MovementID: request.MovementID,
+			Title:      request.Title,
+			Data:       request.Data,
+		})
+		return UploadActivityResponse{Data: w, Unit: service.PreferredUnit(ctx), Err: err}, nil
+	}
+}
+
+// MakeRateWorkoutSessionEndpoint is a builder function that returns a
+// RateEndpoint.
+func MakeRateWorkoutSessionEndpoint(svc service.WorkoutService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(RateWorkoutSessionRequest)
+		w, err := svc.RateSession(ctx, request.AthleteID, request.Name, request.SessionRPE, request.Duration)
+		return GetWorkoutResponse{Data: w, Unit: service.PreferredUnit(ctx), Err: err}, nil
+	}
+}
+
+// MakeCloneWorkoutEndpoint is a builder function that returns a
+// CloneEndpoint.
+func MakeCloneWorkoutEndpoint(svc service.WorkoutService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request := req.(CloneWorkoutRequest)
+		w, err := svc.CloneWorkout(ctx, request.AthleteID, request.Clone)
+		return CreateWorkoutResponse{Data: w, Unit: service.PreferredUnit(ctx), Err: err}, nil
+	}
+}
+
+// compile-time assertions for our response types implementing
+// endpoint.Failer.
+var (
+	_ endpoint.Failer = CreateWorkoutResponse{}
+	_ endpoint.Failer = GetWorkoutResponse{}
+	_ endpoint.Failer = ListWorkoutsResponse{}
+	_ endpoint.Failer = DeleteWorkoutResponse{}
+	_ endpoint.Failer = ImportWorkoutHistoryResponse{}
+	_ endpoint.Failer = UploadActivityResponse{}
+)
+
+// CreateWorkoutRequest collects the request parameters for the CreateWorkout
+// Endpoint.
+type CreateWorkoutRequest struct {
+	AthleteID   string                 `json:"athleteId"`
+	Title       string                 `json:"title"`
+	PerformedAt time.Time              `json:"performedAt"`
+	Sets        []service.WorkoutSet   `json:"sets"`
+	Blocks      []service.WorkoutBlock `json:"blocks"`
+}
+
+// CreateWorkoutResponse collects the response parameters for the
+// CreateWorkout Endpoint.
+type CreateWorkoutResponse struct {
+	Data service.Workout    `json:"data"`
+	Unit service.WeightUnit `json:"unit"`
+	Err  error              `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r CreateWorkoutResponse) Failed() error {
+	return r.Err
+}
+
+// GetWorkoutRequest collects the request parameters for the GetWorkout
+// Endpoint.
+type GetWorkoutRequest struct {
+	AthleteID string
+	Name      string
+}
+
+// GetWorkoutResponse collects the response parameters for the GetWorkout
+// Endpoint.
+type GetWorkoutResponse struct {
+	Data service.Workout    `json:"data"`
+	Unit service.WeightUnit `json:"unit"`
+	Err  error              `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r GetWorkoutResponse) Failed() error {
+	return r.Err
+}
+
+// ListWorkoutsRequest collects the request parameters for the ListWorkouts
+// Endpoint.
+type ListWorkoutsRequest struct {
+	AthleteID string
+}
+
+// ListWorkoutsResponse collects the response parameters for the ListWorkouts
+// Endpoint.
+type ListWorkoutsResponse struct {
+	Data []service.Workout  `json:"data"`
+	Unit service.WeightUnit `json:"unit"`
+	Err  error              `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r ListWorkoutsResponse) Failed() error {
+	return r.Err
+}
+
+// DeleteWorkoutRequest collects the request parameters for the DeleteWorkout
+// Endpoint.
+type DeleteWorkoutRequest struct {
+	AthleteID string
+	Name      string
+}
+
+// DeleteWorkoutResponse allows endpoint.Failer to be implemented.
+type DeleteWorkoutResponse struct {
+	Err error `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r DeleteWorkoutResponse) Failed() error {
+	return r.Err
+}
+
+// RateWorkoutSessionRequest collects the request parameters for the
+// RateWorkoutSession Endpoint. It responds with a GetWorkoutResponse.
+type RateWorkoutSessionRequest struct {
+	AthleteID  string
+	Name       string
+	SessionRPE float64
+	Duration   time.Duration
+}
+
+// ImportWorkoutHistoryRequest collects the request parameters for the
+// ImportWorkoutHistory Endpoint. Data holds the whole export being imported.
+type ImportWorkoutHistoryRequest struct {
+	AthleteID      string              `json:"athleteId"`
+	Source         importer.Source     `json:"source"`
+	Unit           importer.WeightUnit `json:"unit"`
+	TimeZone       string              `json:"timeZone"`
+	Mappings       map[string]string   `json:"mappings"`
+	SkipUnresolved bool                `json:"skipUnresolved"`
+	DryRun         bool                `json:"dryRun"`
+	Data           []byte              `json:"data"`
+}
+
+// ImportWorkoutHistoryResponse collects the response parameters for the
+// ImportWorkoutHistory Endpoint.
+type ImportWorkoutHistoryResponse struct {
+	Data service.HistoryReport `json:"data"`
+	Err  error                 `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r ImportWorkoutHistoryResponse) Failed() error {
+	return r.Err
+}
+
+// UploadActivityRequest collects the request parameters for the
+// UploadActivity Endpoint. Data holds the whole activity file.
+type UploadActivityRequest struct {
+	AthleteID  string          `json:"athleteId"`
+	Format     activity.Format `json:"format"`
+	MovementID string          `json:"movementId"`
+	Title      string          `json:"title"`
+	Data       []byte          `json:"data"`
+}
+
+// UploadActivityResponse collects the response parameters for the
+// UploadActivity Endpoint.
+type UploadActivityResponse struct {
+	Data service.Workout    `json:"data"`
+	Unit service.WeightUnit `json:"unit"`
+	Err  error              `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r UploadActivityResponse) Failed() error {
+	return r.Err
+}
+
+// CloneWorkoutRequest collects the request parameters for the CloneWorkout
+// Endpoint. It responds with a CreateWorkoutResponse.
+type CloneWorkoutRequest struct {
+	AthleteID string               `json:"athleteId"`
+	Clone     service.WorkoutClone `json:"clone"`
+}
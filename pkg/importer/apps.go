@@ -0,0 +1,130 @@
+package importer
+
+import "strings"
+
+// parseStrong reads a Strong export, which has one row per set:
+//
+//	Date,Workout Name,Duration,Exercise Name,Set Order,Weight,Reps,Distance,Seconds,Notes,Workout Notes,RPE
+//
+// Weights are in the unit the athlete chose in the app.
+func parseStrong(t table, opts Options) (History, error) {
+	if err := t.require("date", "workout name", "exercise name", "weight", "reps"); err != nil {
+		return History{}, err
+	}
+	var (
+		h History
+		s sessions
+	)
+	for i, row := range t.rows {
+		n := i + 1
+		date := t.cell(row, "date")
+		at, err := parseTime(n, date, opts.Location, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02")
+		if err != nil {
+			return History{}, err
+		}
+		set, ok, err := parseSet(n, t.cell(row, "exercise name"), t.cell(row, "reps"), t.cell(row, "weight"), t.cell(row, "rpe"), opts.Unit)
+		if err != nil {
+			return History{}, err
+		}
+		if !ok {
+			h.Ignored++
+			continue
+		}
+		title := t.cell(row, "workout name")
+		s.add(date+"\x00"+title, title, at, set)
+	}
+	h.Sessions = s.list()
+	return h, nil
+}
+
+// parseHevy reads a Hevy export, which has one row per set:
+//
+//	title,start_time,end_time,description,exercise_title,superset_id,exercise_notes,set_index,set_type,weight_kg,reps,distance_km,duration_seconds,rpe
+//
+// Accounts set to imperial units export weight_lbs instead of weight_kg.
+func parseHevy(t table, opts Options) (History, error) {
+	if err := t.require("title", "start_time", "exercise_title", "reps"); err != nil {
+		return History{}, err
+	}
+	weightColumn, unit := "weight_kg", Kilograms
+	switch {
+	case t.has("weight_kg"):
+	case t.has("weight_lbs"):
+		weightColumn, unit = "weight_lbs", Pounds
+	default:
+		return History{}, t.require("weight_kg")
+	}
+	var (
+		h History
+		s sessions
+	)
+	for i, row := range t.rows {
+		n := i + 1
+		start := t.cell(row, "start_time")
+		at, err := parseTime(n, start, opts.Location, "2 Jan 2006, 15:04", "Jan 2, 2006, 15:04", "2006-01-02 15:04:05", "2006-01-02T15:04:05Z07:00")
+		if err != nil {
+			return History{}, err
+		}
+		set, ok, err := parseSet(n, t.cell(row, "exercise_title"), t.cell(row, "reps"), t.cell(row, weightColumn), t.cell(row, "rpe"), unit)
+		if err != nil {
+			return History{}, err
+		}
+		if !ok {
+			h.Ignored++
+			continue
+		}
+		title := t.cell(row, "title")
+		s.add(start+"\x00"+title, title, at, set)
+	}
+	h.Sessions = s.list()
+	return h, nil
+}
+
+// parseFitNotes reads a FitNotes export, which has one row per set and no
+// time of day, so every day becomes one session:
+//
+//	Date,Exercise,Category,Weight (kgs),Weight (lbs),Reps,Distance,Distance Unit,Time,Comment
+//
+// Older versions write a single Weight column alongside a Weight Unit column.
+func parseFitNotes(t table, opts Options) (History, error) {
+	if err := t.require("date", "exercise", "reps"); err != nil {
+		return History{}, err
+	}
+	weight := func(row []string) (string, WeightUnit) {
+		switch {
+		case t.cell(row, "weight (kgs)") != "":
+			return t.cell(row, "weight (kgs)"), Kilograms
+		case t.cell(row, "weight (lbs)") != "":
+			return t.cell(row, "weight (lbs)"), Pounds
+		case strings.HasPrefix(strings.ToLower(t.cell(row, "weight unit")), "lb"):
+			return t.cell(row, "weight"), Pounds
+		case t.has("weight unit"):
+			return t.cell(row, "weight"), Kilograms
+		}
+		return t.cell(row, "weight"), opts.Unit
+	}
+	var (
+		h History
+		s sessions
+	)
+	for i, row := range t.rows {
+		n := i + 1
+		date := t.cell(row, "date")
+		at, err := parseTime(n, date, opts.Location, "2006-01-02")
+		if err != nil {
+			return History{}, err
+		}
+		w, unit := weight(row)
+		set, ok, err := parseSet(n, t.cell(row, "exercise"), t.cell(row, "reps"), w, "", unit)
+		if err != nil {
+			return History{}, err
+		}
+		if !ok {
+			h.Ignored++
+			continue
+		}
+		s.add(date, at.Format("Monday 2 January 2006"), at, set)
+	}
+	h.Sessions = s.list()
+	return h, nil
+}
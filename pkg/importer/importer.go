@@ -0,0 +1,243 @@
+// Package importer parses the workout history that consumer lifting apps
+// export as CSV. Each supported app has its own parser; they all produce the
+// same app-neutral History, which the workout service resolves against the
+// movement catalog and stores.
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Source is an app whose CSV export can be imported.
+type Source string
+
+// The apps whose exports can be imported.
+const (
+	Strong   Source = "strong"
+	Hevy     Source = "hevy"
+	FitNotes Source = "fitnotes"
+)
+
+// WeightUnit is the unit weights are recorded in when an export does not say.
+type WeightUnit string
+
+// The weight units an export may use.
+const (
+	Kilograms WeightUnit = "kg"
+	Pounds    WeightUnit = "lb"
+)
+
+// Options tune parsing for exports that leave details to the app's settings.
+// Unit defaults to Kilograms and Location, used for timestamps without a
+// zone, to UTC.
+type Options struct {
+	Unit     WeightUnit
+	Location *time.Location
+}
+
+// History is the workout history found in an export. Ignored counts rows
+// that carry no reps or weight, such as cardio recorded only by time or
+// distance.
+type History struct {
+	Sessions []Session
+	Ignored  int
+}
+
+// Session is one workout as the source app recorded it.
+type Session struct {
+	Title       string
+	PerformedAt time.Time
+	Sets        []Set
+}
+
+// Set is one set of an exercise, named as the source app names it. Weight is
+// as the app recorded it, in Unit, and RPE is zero when unrecorded.
+type Set struct {
+	Exercise string
+	Reps     int32
+	Weight   float64
+	Unit     WeightUnit
+	RPE      float64
+}
+
+// Parse reads the CSV export of the given app. Sessions are returned oldest
+// first, with sets in the order they were performed.
+func Parse(src Source, r io.Reader, opts Options) (History, error) {
+	switch opts.Unit {
+	case "":
+		opts.Unit = Kilograms
+	case Kilograms, Pounds:
+	default:
+		return History{}, fmt.Errorf("unknown weight unit %q", opts.Unit)
+	}
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	var parse func(table, Options) (History, error)
+	switch src {
+	case Strong:
+		parse = parseStrong
+	case Hevy:
+		parse = parseHevy
+	case FitNotes:
+		parse = parseFitNotes
+	default:
+		return History{}, fmt.Errorf("unknown source %q", src)
+	}
+	t, err := readTable(r)
+	if err != nil {
+		return History{}, err
+	}
+	h, err := parse(t, opts)
+	if err != nil {
+		return History{}, err
+	}
+	sort.SliceStable(h.Sessions, func(i, j int) bool {
+		return h.Sessions[i].PerformedAt.Before(h.Sessions[j].PerformedAt)
+	})
+	return h, nil
+}
+
+// table is a CSV file whose cells are addressed by header.
+type table struct {
+	columns map[string]int
+	rows    [][]string
+}
+
+// readTable reads a CSV file with a header row. Strong has exported with
+// semicolons as well as commas, so the delimiter is sniffed from the header.
+func readTable(r io.Reader) (table, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return table{}, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	header := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		header = data[:i]
+	}
+	cr := csv.NewReader(bytes.NewReader(data))
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		cr.Comma = ';'
+	}
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return table{}, fmt.Errorf("invalid CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return table{}, fmt.Errorf("export is empty")
+	}
+	t := table{columns: make(map[string]int), rows: records[1:]}
+	for i, h := range records[0] {
+		t.columns[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	return t, nil
+}
+
+// require fails unless the table has every named column.
+func (t table) require(names ...string) error {
+	for _, n := range names {
+		if _, ok := t.columns[n]; !ok {
+			return fmt.Errorf("export has no %q column; is it from the right app?", n)
+		}
+	}
+	return nil
+}
+
+// has reports whether the table has the named column.
+func (t table) has(name string) bool {
+	_, ok := t.columns[name]
+	return ok
+}
+
+// cell returns the trimmed value of the named column in a row, or an empty
+// string when the row or the table lacks it.
+func (t table) cell(row []string, name string) string {
+	i, ok := t.columns[name]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// sessions groups sets into sessions by a key, keeping first-seen order.
+type sessions struct {
+	order []string
+	byKey map[string]*Session
+}
+
+func (s *sessions) add(key string, title string, at time.Time, set Set) {
+	if s.byKey == nil {
+		s.byKey = make(map[string]*Session)
+	}
+	session, ok := s.byKey[key]
+	if !ok {
+		session = &Session{Title: title, PerformedAt: at}
+		s.byKey[key] = session
+		s.order = append(s.order, key)
+	}
+	session.Sets = append(session.Sets, set)
+}
+
+func (s *sessions) list() []Session {
+	list := make([]Session, len(s.order))
+	for i, key := range s.order {
+		list[i] = *s.byKey[key]
+	}
+	return list
+}
+
+// parseSet builds a set from the raw reps, weight and RPE cells of a row. A
+// row with neither reps nor weight is not a lifting set and reports false.
+func parseSet(row int, exercise, reps, weight, rpe string, unit WeightUnit) (Set, bool, error) {
+	set := Set{Exercise: exercise, Unit: unit}
+	if exercise == "" {
+		return Set{}, false, fmt.Errorf("row %d has no exercise", row)
+	}
+	if reps != "" {
+		n, err := strconv.ParseFloat(reps, 64)
+		if err != nil || n < 0 {
+			return Set{}, false, fmt.Errorf("row %d has invalid reps %q", row, reps)
+		}
+		set.Reps = int32(n)
+	}
+	if weight != "" {
+		w, err := parseNumber(weight)
+		if err != nil || w < 0 {
+			return Set{}, false, fmt.Errorf("row %d has invalid weight %q", row, weight)
+		}
+		set.Weight = w
+	}
+	if rpe != "" {
+		r, err := parseNumber(rpe)
+		if err != nil || r < 0 || r > 10 {
+			return Set{}, false, fmt.Errorf("row %d has invalid RPE %q", row, rpe)
+		}
+		set.RPE = r
+	}
+	return set, set.Reps > 0 || set.Weight > 0, nil
+}
+
+// parseNumber accepts decimal commas, which apps write in some locales.
+func parseNumber(s string) (float64, error) {
+	return strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+}
+
+// parseTime tries each layout in turn, reading zoneless times in loc.
+func parseTime(row int, value string, loc *time.Location, layouts ...string) (time.Time, error) {
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("row %d has unrecognized date %q", row, value)
+}
@@ -0,0 +1,232 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const strongExport = `Date,Workout Name,Duration,Exercise Name,Set Order,Weight,Reps,Distance,Seconds,Notes,Workout Notes,RPE
+2024-03-04 18:00:00,"Push Day",1h 5m,"Bench Press (Barbell)",1,100,5,0,0,"","",8
+2024-03-04 18:00:00,"Push Day",1h 5m,"Bench Press (Barbell)",2,100,5,0,0,"","",
+2024-03-01 07:30:00,"Legs",50m,"Squat (Barbell)",1,140,3,0,0,"","",9.5
+2024-03-01 07:30:00,"Legs",50m,"Rowing (Machine)",1,0,0,2000,480,"","",
+`
+
+// Strong writes semicolons and decimal commas in locales that use the comma
+// as decimal separator.
+const strongSemicolonExport = "\xef\xbb\xbf" + `Date;Workout Name;Duration;Exercise Name;Set Order;Weight;Reps;Distance;Seconds;Notes;Workout Notes;RPE
+2024-03-01 07:30:00;"Beine";50m;"Squat (Barbell)";1;142,5;3;0;0;"";"";8,5
+`
+
+const hevyExport = `"title","start_time","end_time","description","exercise_title","superset_id","exercise_notes","set_index","set_type","weight_lbs","reps","distance_miles","duration_seconds","rpe"
+"Upper","2 Mar 2024, 17:45","2 Mar 2024, 18:40","","Overhead Press (Barbell)",,"",0,"normal",135,5,,,
+"Upper","2 Mar 2024, 17:45","2 Mar 2024, 18:40","","Pull Up",,"",1,"normal",,8,,,7
+"Upper","2 Mar 2024, 17:45","2 Mar 2024, 18:40","","Treadmill",,"",0,"normal",,,1.2,600,
+`
+
+const fitNotesExport = `Date,Exercise,Category,Weight (kgs),Weight (lbs),Reps,Distance,Distance Unit,Time,Comment
+2024-03-03,Deadlift,Back,180.0,,3,,,,
+2024-03-03,Deadlift,Back,,405.0,1,,,,"Belt"
+2024-03-05,Dumbbell Curl,Biceps,16.0,,12,,,,
+`
+
+const oldFitNotesExport = `Date,Exercise,Category,Weight,Weight Unit,Reps,Distance,Distance Unit,Time
+2024-03-03,Deadlift,Back,315.0,lbs,3,,,
+`
+
+func TestParse(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		src  Source
+		data string
+		opts Options
+		want History
+	}{
+		{
+			name: "Strong",
+			src:  Strong,
+			data: strongExport,
+			want: History{
+				Ignored: 1,
+				Sessions: []Session{
+					{Title: "Legs", PerformedAt: time.Date(2024, 3, 1, 7, 30, 0, 0, time.UTC), Sets: []Set{
+						{Exercise: "Squat (Barbell)", Reps: 3, Weight: 140, Unit: Kilograms, RPE: 9.5},
+					}},
+					{Title: "Push Day", PerformedAt: time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC), Sets: []Set{
+						{Exercise: "Bench Press (Barbell)", Reps: 5, Weight: 100, Unit: Kilograms, RPE: 8},
+						{Exercise: "Bench Press (Barbell)", Reps: 5, Weight: 100, Unit: Kilograms},
+					}},
+				},
+			},
+		},
+		{
+			name: "Strong in pounds",
+			src:  Strong,
+			data: strongExport,
+			opts: Options{Unit: Pounds, Location: berlin},
+			want: History{
+				Ignored: 1,
+				Sessions: []Session{
+					{Title: "Legs", PerformedAt: time.Date(2024, 3, 1, 7, 30, 0, 0, berlin), Sets: []Set{
+						{Exercise: "Squat (Barbell)", Reps: 3, Weight: 140, Unit: Pounds, RPE: 9.5},
+					}},
+					{Title: "Push Day", PerformedAt: time.Date(2024, 3, 4, 18, 0, 0, 0, berlin), Sets: []Set{
+						{Exercise: "Bench Press (Barbell)", Reps: 5, Weight: 100, Unit: Pounds, RPE: 8},
+						{Exercise: "Bench Press (Barbell)", Reps: 5, Weight: 100, Unit: Pounds},
+					}},
+				},
+			},
+		},
+		{
+			name: "Strong with semicolons and decimal commas",
+			src:  Strong,
+			data: strongSemicolonExport,
+			want: History{Sessions: []Session{
+				{Title: "Beine", PerformedAt: time.Date(2024, 3, 1, 7, 30, 0, 0, time.UTC), Sets: []Set{
+					{Exercise: "Squat (Barbell)", Reps: 3, Weight: 142.5, Unit: Kilograms, RPE: 8.5},
+				}},
+			}},
+		},
+		{
+			name: "Hevy in pounds",
+			src:  Hevy,
+			data: hevyExport,
+			want: History{
+				Ignored: 1,
+				Sessions: []Session{
+					{Title: "Upper", PerformedAt: time.Date(2024, 3, 2, 17, 45, 0, 0, time.UTC), Sets: []Set{
+						{Exercise: "Overhead Press (Barbell)", Reps: 5, Weight: 135, Unit: Pounds},
+						{Exercise: "Pull Up", Reps: 8, Unit: Pounds, RPE: 7},
+					}},
+				},
+			},
+		},
+		{
+			name: "Hevy in kilograms",
+			src:  Hevy,
+			data: strings.Replace(hevyExport, "weight_lbs", "weight_kg", 1),
+			want: History{
+				Ignored: 1,
+				Sessions: []Session{
+					{Title: "Upper", PerformedAt: time.Date(2024, 3, 2, 17, 45, 0, 0, time.UTC), Sets: []Set{
+						{Exercise: "Overhead Press (Barbell)", Reps: 5, Weight: 135, Unit: Kilograms},
+						{Exercise: "Pull Up", Reps: 8, Unit: Kilograms, RPE: 7},
+					}},
+				},
+			},
+		},
+		{
+			name: "FitNotes",
+			src:  FitNotes,
+			data: fitNotesExport,
+			want: History{Sessions: []Session{
+				{Title: "Sunday 3 March 2024", PerformedAt: time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC), Sets: []Set{
+					{Exercise: "Deadlift", Reps: 3, Weight: 180, Unit: Kilograms},
+					{Exercise: "Deadlift", Reps: 1, Weight: 405, Unit: Pounds},
+				}},
+				{Title: "Tuesday 5 March 2024", PerformedAt: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), Sets: []Set{
+					{Exercise: "Dumbbell Curl", Reps: 12, Weight: 16, Unit: Kilograms},
+				}},
+			}},
+		},
+		{
+			name: "FitNotes with a weight unit column",
+			src:  FitNotes,
+			data: oldFitNotesExport,
+			want: History{Sessions: []Session{
+				{Title: "Sunday 3 March 2024", PerformedAt: time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC), Sets: []Set{
+					{Exercise: "Deadlift", Reps: 3, Weight: 315, Unit: Pounds},
+				}},
+			}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := Parse(tc.src, strings.NewReader(tc.data), tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(h, tc.want) {
+				t.Errorf("Parse() = %+v, want %+v", h, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		src  Source
+		data string
+		opts Options
+	}{
+		{"unknown source", "runkeeper", strongExport, Options{}},
+		{"unknown unit", Strong, strongExport, Options{Unit: "st"}},
+		{"empty export", Strong, "", Options{}},
+		{"export of another app", Strong, hevyExport, Options{}},
+		{"unknown header", Hevy, strings.Replace(hevyExport, "weight_lbs", "weight_stone", 1), Options{}},
+		{"unterminated quote", Strong, strongExport + "2024-03-05 18:00:00,\"Push\n", Options{}},
+		{"bad date", Strong, strongExport + "yesterday,Push,1h,Bench Press,1,100,5,0,0,,,\n", Options{}},
+		{"bad reps", Strong, strongExport + "2024-03-05 18:00:00,Push,1h,Bench Press,1,100,five,0,0,,,\n", Options{}},
+		{"negative weight", FitNotes, fitNotesExport + "2024-03-06,Deadlift,Back,-20,,3,,,,\n", Options{}},
+		{"RPE above 10", Strong, strongExport + "2024-03-05 18:00:00,Push,1h,Bench Press,1,100,5,0,0,,,11\n", Options{}},
+		{"no exercise", FitNotes, fitNotesExport + "2024-03-06,,Back,180,,3,,,,\n", Options{}},
+	} {
+		if h, err := Parse(tc.src, strings.NewReader(tc.data), tc.opts); err == nil {
+			t.Errorf("%s: Parse() = %+v, want an error", tc.name, h)
+		}
+	}
+}
+
+func TestParseSet(t *testing.T) {
+	for _, tc := range []struct {
+		name                   string
+		exercise, reps, w, rpe string
+		unit                   WeightUnit
+		want                   Set
+		lifting, ok            bool
+	}{
+		{"weighted", "Squat", "5", "100", "8", Kilograms, Set{Exercise: "Squat", Reps: 5, Weight: 100, Unit: Kilograms, RPE: 8}, true, true},
+		{"decimal comma", "Squat", "5", "102,5", "8,5", Kilograms, Set{Exercise: "Squat", Reps: 5, Weight: 102.5, Unit: Kilograms, RPE: 8.5}, true, true},
+		{"pounds are kept", "Squat", "5", "225", "", Pounds, Set{Exercise: "Squat", Reps: 5, Weight: 225, Unit: Pounds}, true, true},
+		{"fractional reps", "Squat", "5.0", "", "", Kilograms, Set{Exercise: "Squat", Reps: 5, Unit: Kilograms}, true, true},
+		{"held for time", "Plank", "", "0", "", Kilograms, Set{}, false, true},
+		{"no exercise", "", "5", "100", "", Kilograms, Set{}, false, false},
+		{"negative reps", "Squat", "-5", "100", "", Kilograms, Set{}, false, false},
+		{"bad weight", "Squat", "5", "heavy", "", Kilograms, Set{}, false, false},
+		{"bad RPE", "Squat", "5", "100", "-1", Kilograms, Set{}, false, false},
+	} {
+		set, lifting, err := parseSet(1, tc.exercise, tc.reps, tc.w, tc.rpe, tc.unit)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: parseSet() error = %v, want ok %v", tc.name, err, tc.ok)
+			continue
+		}
+		if lifting != tc.lifting || (tc.lifting && set != tc.want) {
+			t.Errorf("%s: parseSet() = %+v, %v, want %+v, %v", tc.name, set, lifting, tc.want, tc.lifting)
+		}
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		want float64
+		ok   bool
+	}{
+		{"100", 100, true},
+		{"102.5", 102.5, true},
+		{"102,5", 102.5, true},
+		{"1,000.5", 0, false},
+		{"", 0, false},
+	} {
+		got, err := parseNumber(tc.s)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("parseNumber(%q) = %g, %v, want %g, ok %v", tc.s, got, err, tc.want, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"workout-manager-service/logging"
+)
+
+type analyticsAuditService struct {
+	auditor
+	service AnalyticsService
+}
+
+// NewAnalyticsAuditService takes an AuditRepository as a dependency and
+// returns an AnalyticsService that records every successful mutation.
+func NewAnalyticsAuditService(logger logging.IshiLogger, repo AuditRepository, s AnalyticsService) AnalyticsService {
+	return analyticsAuditService{
+		auditor: newAuditor(logger, repo),
+		service: s,
+	}
+}
+
+// Volume is not audited.
+func (as analyticsAuditService) Volume(ctx context.Context, athleteID string, q VolumeQuery) ([]VolumeAggregate, error) {
+	return as.service.Volume(ctx, athleteID, q)
+}
+
+// Workload is not audited.
+func (as analyticsAuditService) Workload(ctx context.Context, athleteID string, start time.Time, end time.Time) ([]WorkloadDay, error) {
+	return as.service.Workload(ctx, athleteID, start, end)
+}
+
+// GetWorkloadThresholds is not audited.
+func (as analyticsAuditService) GetWorkloadThresholds(ctx context.Context) (WorkloadThresholds, error) {
+	return as.service.GetWorkloadThresholds(ctx)
+}
+
+// UpdateWorkloadThresholds records the thresholds before and after they
+// changed.
+func (as analyticsAuditService) UpdateWorkloadThresholds(ctx context.Context, t WorkloadThresholds) (after WorkloadThresholds, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		before, _ := as.service.GetWorkloadThresholds(ctx)
+		if after, err = as.service.UpdateWorkloadThresholds(ctx, t); err != nil {
+			return err
+		}
+		return as.record(ctx, "UpdateWorkloadThresholds", "workloadThresholds", before, after)
+	})
+	return after, err
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"workout-manager-service/logging"
+)
+
+type analyticsLoggingService struct {
+	logger  logging.IshiLogger
+	service AnalyticsService
+}
+
+// NewAnalyticsLoggingService takes an IshiLogger as a dependency and returns
+// an AnalyticsService.
+func NewAnalyticsLoggingService(logger logging.IshiLogger, s AnalyticsService) AnalyticsService {
+	return analyticsLoggingService{
+		logger:  logger.WithFields("service", "analytics"),
+		service: s,
+	}
+}
+
+// Volume provides informative logging when requests are made to the volume
+// endpoint.
+func (ls analyticsLoggingService) Volume(ctx context.Context, athleteID string, q VolumeQuery) ([]VolumeAggregate, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Volume",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"athleteID", athleteID,
+			"bucket", q.Bucket,
+			"groupBy", q.GroupBy,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Volume(ctx, athleteID, q)
+}
+
+// Workload provides informative logging when requests are made to the
+// workload endpoint.
+func (ls analyticsLoggingService) Workload(ctx context.Context, athleteID string, start time.Time, end time.Time) ([]WorkloadDay, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Workload",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"athleteID", athleteID,
+			"start", start,
+			"end", end,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Workload(ctx, athleteID, start, end)
+}
+
+// GetWorkloadThresholds provides informative logging when requests are made
+// to the get workload thresholds endpoint.
+func (ls analyticsLoggingService) GetWorkloadThresholds(ctx context.Context) (WorkloadThresholds, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "GetWorkloadThresholds",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.GetWorkloadThresholds(ctx)
+}
+
+// UpdateWorkloadThresholds provides informative logging when requests are
+// made to the update workload thresholds endpoint.
+func (ls analyticsLoggingService) UpdateWorkloadThresholds(ctx context.Context, t WorkloadThresholds) (WorkloadThresholds, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "UpdateWorkloadThresholds",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"thresholds", fmt.Sprintf("%+v", t),
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.UpdateWorkloadThresholds(ctx, t)
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/logging"
+)
+
+// Bucket is the length of the periods training volume is aggregated over.
+type Bucket string
+
+// The buckets volume may be aggregated by. Buckets follow the calendar of
+// the tenant's time zone, and weeks begin on the tenant's week start day.
+const (
+	BucketDay   Bucket = "day"
+	BucketWeek  Bucket = "week"
+	BucketMonth Bucket = "month"
+)
+
+// VolumeGrouping splits each bucket's volume by what was trained.
+type VolumeGrouping string
+
+// The groupings volume may be split by. Without a grouping each bucket holds
+// all of the athlete's sets. Muscle groups are the primary muscles of each
+// movement, so a set of a movement with several counts toward each of them;
+// sets of movements without any are grouped under an empty muscle group.
+const (
+	GroupByNone     VolumeGrouping = ""
+	GroupByMovement VolumeGrouping = "movement"
+	GroupByCategory VolumeGrouping = "category"
+	GroupByMuscle   VolumeGrouping = "muscle"
+)
+
+// VolumeQuery selects the training volume to aggregate. Start is inclusive
+// and End is exclusive. TimeZone and WeekStart are taken from the tenant's
+// settings.
+type VolumeQuery struct {
+	TenantID  string         `json:"tenantId"`
+	AthleteID string         `json:"athleteId"`
+	Start     time.Time      `json:"start"`
+	End       time.Time      `json:"end"`
+	Bucket    Bucket         `json:"bucket"`
+	GroupBy   VolumeGrouping `json:"groupBy"`
+	TimeZone  string         `json:"timeZone"`
+	WeekStart time.Weekday   `json:"weekStart"`
+}
+
+// VolumeAggregate is the volume of one bucket and group. Group is the ID of
+// the movement, the movement category or the muscle group, as the query
+// grouped by, and is empty without a grouping. A movement is resolved to the
+// tenant's fork of it when there is one.
+//
+// Tonnage is the sum of weight times reps in kilograms. HardSets counts the
+// sets taken close to failure, as IsHardSet decides. AverageIntensity is the
+// mean weight of every loaded rep, and RelativeIntensity is the mean, over
+// the loaded reps of movements the athlete has an estimate for, of each set's
+// weight as a fraction of their estimated one-rep max of the movement at the
+// time, counting the set itself.
+type VolumeAggregate struct {
+	BucketStart       time.Time `json:"bucketStart"`
+	Group             string    `json:"group"`
+	Sets              int64     `json:"sets"`
+	HardSets          int64     `json:"hardSets"`
+	Reps              int64     `json:"reps"`
+	Tonnage           float64   `json:"tonnage"`
+	AverageIntensity  float64   `json:"averageIntensity"`
+	RelativeIntensity float64   `json:"relativeIntensity"`
+}
+
+// StartOf returns the start of the bucket t falls in, which is midnight in
+// loc of the bucket's first day.
+func (b Bucket) StartOf(t time.Time, loc *time.Location, weekStart time.Weekday) time.Time {
+	t = t.In(loc)
+	year, month, day := t.Date()
+	switch b {
+	case BucketWeek:
+		day -= (int(t.Weekday()) - int(weekStart) + 7) % 7
+	case BucketMonth:
+		day = 1
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// AnalyticsRepository aggregates training data. AggregateVolume returns an
+// aggregate for every bucket and group with at least one set, ordered by
+// bucket and then group. Estimated one-rep maxes take the athlete's whole
+// history into account, including sets before Start. ListSessionLoads
+// returns the SessionLoadOf every workout an athlete performed before end,
+// oldest first. GetWorkloadThresholds returns ErrNotFound for tenants that
+// have not set their own.
+type AnalyticsRepository interface {
+	AggregateVolume(ctx context.Context, q VolumeQuery) ([]VolumeAggregate, error)
+	ListSessionLoads(ctx context.Context, tenantID string, athleteID string, end time.Time) ([]SessionLoad, error)
+	GetWorkloadThresholds(ctx context.Context, tenantID string) (WorkloadThresholds, error)
+	SetWorkloadThresholds(ctx context.Context, t WorkloadThresholds) error
+}
+
+// maxE1RMReps is the most reps a set may have for it to estimate a one-rep
+// max; estimates from longer sets are too unreliable.
+const maxE1RMReps = 12
+
+// EstimateOneRepMax returns the one-rep max a set suggests by the Epley
+// formula, or zero when the set cannot be used to estimate one.
+func EstimateOneRepMax(weight float64, reps int32) float64 {
+	switch {
+	case weight <= 0 || reps <= 0 || reps > maxE1RMReps:
+		return 0
+	case reps == 1:
+		return weight
+	}
+	return weight * (1 + float64(reps)/30)
+}
+
+// hardSetRIR is the most reps in reserve a hard set may leave, which is an
+// RPE of at least 7.
+const hardSetRIR = 3
+
+// IsHardSet reports whether a set was taken close to failure. Sets with an
+// RPE are hard from RPE 7. Without one, the reps left in reserve are
+// estimated from the one-rep max by inverting the Epley formula; sets that
+// cannot be estimated, such as unloaded ones, do not count.
+func IsHardSet(set WorkoutSet, e1RM float64) bool {
+	if set.Reps <= 0 {
+		return false
+	}
+	if set.RPE > 0 {
+		return set.RPE >= 10-hardSetRIR
+	}
+	if set.Weight.Kilograms <= 0 || e1RM <= 0 {
+		return false
+	}
+	return 30*(e1RM/set.Weight.Kilograms-1)-float64(set.Reps) <= hardSetRIR
+}
+
+// AnalyticsService describes a service that summarizes an athlete's
+// training.
+type AnalyticsService interface {
+	Volume(ctx context.Context, athleteID string, q VolumeQuery) ([]VolumeAggregate, error)
+	Workload(ctx context.Context, athleteID string, start time.Time, end time.Time) ([]WorkloadDay, error)
+	GetWorkloadThresholds(ctx context.Context) (WorkloadThresholds, error)
+	UpdateWorkloadThresholds(ctx context.Context, t WorkloadThresholds) (WorkloadThresholds, error)
+}
+
+// NewAnalyticsService returns a basic AnalyticsService with middleware wired
+// in.
+func NewAnalyticsService(logger logging.IshiLogger, repo AnalyticsRepository, users UserRepository, tenants TenantRepository, audit AuditRepository) AnalyticsService {
+	var svc AnalyticsService
+	{
+		svc = NewBasicAnalyticsService(repo, users, tenants)
+		svc = NewAnalyticsAuditService(logger, audit, svc)
+		svc = NewAnalyticsLoggingService(logger, svc)
+	}
+	return svc
+}
+
+// NewBasicAnalyticsService returns an implementation of AnalyticsService
+// backed by the given repositories.
+func NewBasicAnalyticsService(repo AnalyticsRepository, users UserRepository, tenants TenantRepository) AnalyticsService {
+	return basicAnalyticsService{repo: repo, users: users, tenants: tenants}
+}
+
+type basicAnalyticsService struct {
+	repo    AnalyticsRepository
+	users   UserRepository
+	tenants TenantRepository
+}
+
+// The default period of a volume query, and the most buckets one may span.
+const (
+	defaultVolumePeriod = 12 * 7 * 24 * time.Hour
+	maxVolumeBuckets    = 1000
+)
+
+// Volume aggregates an athlete's training volume by bucket and grouping.
+// Without a start and end, it covers the twelve weeks up to now, by week.
+func (s basicAnalyticsService) Volume(ctx context.Context, athleteID string, q VolumeQuery) ([]VolumeAggregate, error) {
+	p, t, err := s.athleteTenant(ctx, athleteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.Bucket == "" {
+		q.Bucket = BucketWeek
+	}
+	var bucketLength time.Duration
+	switch q.Bucket {
+	case BucketDay:
+		bucketLength = 24 * time.Hour
+	case BucketWeek:
+		bucketLength = 7 * 24 * time.Hour
+	case BucketMonth:
+		bucketLength = 28 * 24 * time.Hour
+	default:
+		return nil, errors.Wrapf(ErrInvalidArgument, "unknown bucket %q", q.Bucket)
+	}
+	switch q.GroupBy {
+	case GroupByNone, GroupByMovement, GroupByCategory, GroupByMuscle:
+	default:
+		return nil, errors.Wrapf(ErrInvalidArgument, "unknown grouping %q", q.GroupBy)
+	}
+	if q.End.IsZero() {
+		q.End = time.Now()
+	}
+	if q.Start.IsZero() {
+		q.Start = q.End.Add(-defaultVolumePeriod)
+	}
+	if !q.Start.Before(q.End) {
+		return nil, errors.Wrap(ErrInvalidArgument, "start time must be before end time")
+	}
+	if q.End.Sub(q.Start)/bucketLength > maxVolumeBuckets {
+		return nil, errors.Wrapf(ErrInvalidArgument, "a query may span at most %d buckets", maxVolumeBuckets)
+	}
+	q.TenantID = p.TenantID
+	q.AthleteID = athleteID
+	q.Start, q.End = q.Start.UTC(), q.End.UTC()
+	q.TimeZone = timeZone(t)
+	q.WeekStart = t.Settings.WeekStart
+	return s.repo.AggregateVolume(ctx, q)
+}
+
+// athleteTenant fails unless athleteID is an athlete of the caller's tenant,
+// and returns the caller and their tenant.
+func (s basicAnalyticsService) athleteTenant(ctx context.Context, athleteID string) (Principal, Tenant, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return Principal{}, Tenant{}, err
+	}
+	athlete, err := s.users.GetUser(ctx, athleteID)
+	if err != nil {
+		return Principal{}, Tenant{}, errors.Wrap(err, "failed to look up athlete")
+	}
+	if athlete.TenantID != p.TenantID || athlete.Role != RoleAthlete {
+		return Principal{}, Tenant{}, errors.Wrapf(ErrInvalidArgument, "user %s is not an athlete", athleteID)
+	}
+	t, err := s.tenants.GetTenant(ctx, p.TenantID)
+	if err != nil {
+		return Principal{}, Tenant{}, errors.Wrap(err, "failed to look up tenant")
+	}
+	return p, t, nil
+}
+
+// timeZone returns the time zone of a tenant, which is UTC for tenants
+// created before it could be set.
+func timeZone(t Tenant) string {
+	if t.Settings.TimeZone == "" {
+		return "UTC"
+	}
+	return t.Settings.TimeZone
+}
+
+// The default period of a workload query, and the longest one may span.
+const (
+	defaultWorkloadDays = chronicWindow
+	maxWorkloadDays     = 366
+)
+
+// Workload returns an athlete's workload on every day from start until end.
+// Without a start and end, it covers the last 28 days up to and including
+// today.
+func (s basicAnalyticsService) Workload(ctx context.Context, athleteID string, start time.Time, end time.Time) ([]WorkloadDay, error) {
+	p, t, err := s.athleteTenant(ctx, athleteID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(timeZone(t))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load time zone %q", timeZone(t))
+	}
+	if end.IsZero() {
+		end = BucketDay.StartOf(time.Now(), loc, t.Settings.WeekStart).AddDate(0, 0, 1)
+	}
+	if start.IsZero() {
+		start = end.AddDate(0, 0, -defaultWorkloadDays)
+	}
+	if !start.Before(end) {
+		return nil, errors.Wrap(ErrInvalidArgument, "start time must be before end time")
+	}
+	if end.Sub(start) > maxWorkloadDays*24*time.Hour {
+		return nil, errors.Wrapf(ErrInvalidArgument, "a query may span at most %d days", maxWorkloadDays)
+	}
+	sessions, err := s.repo.ListSessionLoads(ctx, p.TenantID, athleteID, end)
+	if err != nil {
+		return nil, err
+	}
+	return ComputeWorkload(sessions, loc, start, end), nil
+}
+
+// GetWorkloadThresholds returns the workload thresholds of the caller's
+// tenant.
+func (s basicAnalyticsService) GetWorkloadThresholds(ctx context.Context) (WorkloadThresholds, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return WorkloadThresholds{}, err
+	}
+	return workloadThresholds(ctx, s.repo, p.TenantID)
+}
+
+// UpdateWorkloadThresholds replaces the workload thresholds of the caller's
+// tenant.
+func (s basicAnalyticsService) UpdateWorkloadThresholds(ctx context.Context, t WorkloadThresholds) (WorkloadThresholds, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return WorkloadThresholds{}, err
+	}
+	if t.MaxACWR < 0 || t.MinACWR < 0 || t.MaxMonotony < 0 || t.MaxStrain < 0 {
+		return WorkloadThresholds{}, errors.Wrap(ErrInvalidArgument, "thresholds may not be negative")
+	}
+	if t.MaxACWR > 0 && t.MinACWR >= t.MaxACWR {
+		return WorkloadThresholds{}, errors.Wrap(ErrInvalidArgument, "the minimum ACWR must be below the maximum")
+	}
+	t.TenantID = p.TenantID
+	t.UpdateTime = time.Now().UTC()
+	if err := s.repo.SetWorkloadThresholds(ctx, t); err != nil {
+		return WorkloadThresholds{}, err
+	}
+	return t, nil
+}
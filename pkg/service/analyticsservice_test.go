@@ -0,0 +1,200 @@
+package service_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/inmem"
+	"workout-manager-service/pkg/service"
+)
+
+// newTrainingStore returns an athlete store with a squat and a bench press
+// and a month of the athlete's training. The heavy single in February only
+// sets the squat's estimated one-rep max of 120kg.
+func newTrainingStore(t *testing.T) (*inmem.Store, context.Context) {
+	t.Helper()
+	s, ctx := newAthleteStore(t)
+	for _, m := range []service.Movement{
+		{Name: "squat", TenantID: "t1", MovementName: "Squat", MovementCategoryID: "legs", PrimaryMuscles: []service.MuscleGroup{service.Quadriceps, service.Glutes}},
+		{Name: "bench", TenantID: "t1", MovementName: "Bench Press", MovementCategoryID: "push", PrimaryMuscles: []service.MuscleGroup{service.Chest}},
+	} {
+		if _, err := s.CreateMovement(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	kg := func(w float64) service.Load { return service.Load{Kilograms: w} }
+	for _, w := range []service.Workout{
+		{AthleteID: "a1", PerformedAt: time.Date(2024, 2, 20, 18, 0, 0, 0, time.UTC), Sets: []service.WorkoutSet{
+			{MovementID: "squat", Reps: 1, Weight: kg(120), RPE: 10},
+		}},
+		{AthleteID: "a1", PerformedAt: time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC), Sets: []service.WorkoutSet{
+			{MovementID: "squat", Reps: 5, Weight: kg(100), RPE: 8},
+			{MovementID: "squat", Reps: 5, Weight: kg(100)},
+			{MovementID: "bench", Reps: 10, Weight: kg(60), RPE: 6},
+		}},
+		{AthleteID: "a1", PerformedAt: time.Date(2024, 3, 5, 18, 0, 0, 0, time.UTC), Planned: true, Sets: []service.WorkoutSet{
+			{MovementID: "squat", Reps: 5, Weight: kg(105)},
+		}},
+		{AthleteID: "a1", PerformedAt: time.Date(2024, 3, 12, 18, 0, 0, 0, time.UTC), Sets: []service.WorkoutSet{
+			{MovementID: "squat", Reps: 3, Weight: kg(110), RPE: 9},
+		}},
+		{AthleteID: "admin", PerformedAt: time.Date(2024, 3, 12, 18, 0, 0, 0, time.UTC), Sets: []service.WorkoutSet{
+			{MovementID: "squat", Reps: 5, Weight: kg(140)},
+		}},
+	} {
+		w.Name, w.TenantID = w.AthleteID+"-"+w.PerformedAt.Format("0102"), "t1"
+		if _, err := s.CreateWorkout(ctx, w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s, ctx
+}
+
+var (
+	march = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	april = time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func TestVolume(t *testing.T) {
+	s, ctx := newTrainingStore(t)
+	svc := service.NewBasicAnalyticsService(s, s, s)
+	// Weeks start on the tenant's default of Sunday.
+	week1, week2 := time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name    string
+		groupBy service.VolumeGrouping
+		want    []service.VolumeAggregate
+	}{
+		{
+			name: "everything",
+			want: []service.VolumeAggregate{
+				{BucketStart: week1, Sets: 3, HardSets: 2, Reps: 20, Tonnage: 1600, AverageIntensity: 80, RelativeIntensity: (10*100.0/120 + 10*60.0/80) / 20},
+				{BucketStart: week2, Sets: 1, HardSets: 1, Reps: 3, Tonnage: 330, AverageIntensity: 110, RelativeIntensity: 110.0 / 121},
+			},
+		},
+		{
+			name:    "by movement",
+			groupBy: service.GroupByMovement,
+			want: []service.VolumeAggregate{
+				{BucketStart: week1, Group: "bench", Sets: 1, Reps: 10, Tonnage: 600, AverageIntensity: 60, RelativeIntensity: 0.75},
+				{BucketStart: week1, Group: "squat", Sets: 2, HardSets: 2, Reps: 10, Tonnage: 1000, AverageIntensity: 100, RelativeIntensity: 100.0 / 120},
+				{BucketStart: week2, Group: "squat", Sets: 1, HardSets: 1, Reps: 3, Tonnage: 330, AverageIntensity: 110, RelativeIntensity: 110.0 / 121},
+			},
+		},
+		{
+			name:    "by category",
+			groupBy: service.GroupByCategory,
+			want: []service.VolumeAggregate{
+				{BucketStart: week1, Group: "legs", Sets: 2, HardSets: 2, Reps: 10, Tonnage: 1000, AverageIntensity: 100, RelativeIntensity: 100.0 / 120},
+				{BucketStart: week1, Group: "push", Sets: 1, Reps: 10, Tonnage: 600, AverageIntensity: 60, RelativeIntensity: 0.75},
+				{BucketStart: week2, Group: "legs", Sets: 1, HardSets: 1, Reps: 3, Tonnage: 330, AverageIntensity: 110, RelativeIntensity: 110.0 / 121},
+			},
+		},
+		{
+			name:    "by muscle",
+			groupBy: service.GroupByMuscle,
+			want: []service.VolumeAggregate{
+				{BucketStart: week1, Group: "chest", Sets: 1, Reps: 10, Tonnage: 600, AverageIntensity: 60, RelativeIntensity: 0.75},
+				{BucketStart: week1, Group: "glutes", Sets: 2, HardSets: 2, Reps: 10, Tonnage: 1000, AverageIntensity: 100, RelativeIntensity: 100.0 / 120},
+				{BucketStart: week1, Group: "quadriceps", Sets: 2, HardSets: 2, Reps: 10, Tonnage: 1000, AverageIntensity: 100, RelativeIntensity: 100.0 / 120},
+				{BucketStart: week2, Group: "glutes", Sets: 1, HardSets: 1, Reps: 3, Tonnage: 330, AverageIntensity: 110, RelativeIntensity: 110.0 / 121},
+				{BucketStart: week2, Group: "quadriceps", Sets: 1, HardSets: 1, Reps: 3, Tonnage: 330, AverageIntensity: 110, RelativeIntensity: 110.0 / 121},
+			},
+		},
+	} {
+		got, err := svc.Volume(ctx, "a1", service.VolumeQuery{Start: march, End: april, GroupBy: tc.groupBy})
+		if err != nil {
+			t.Fatalf("%s: Volume() = %v", tc.name, err)
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: aggregates = %+v, want %+v", tc.name, got, tc.want)
+			continue
+		}
+		for i, a := range got {
+			if !sameAggregate(a, tc.want[i]) {
+				t.Errorf("%s: aggregate %d = %+v, want %+v", tc.name, i, a, tc.want[i])
+			}
+		}
+	}
+}
+
+func sameAggregate(a, b service.VolumeAggregate) bool {
+	const eps = 1e-9
+	return a.BucketStart.Equal(b.BucketStart) && a.Group == b.Group &&
+		a.Sets == b.Sets && a.HardSets == b.HardSets && a.Reps == b.Reps &&
+		math.Abs(a.Tonnage-b.Tonnage) < eps &&
+		math.Abs(a.AverageIntensity-b.AverageIntensity) < eps &&
+		math.Abs(a.RelativeIntensity-b.RelativeIntensity) < eps
+}
+
+func TestVolumeBucketsFollowTheTenant(t *testing.T) {
+	s, ctx := newTrainingStore(t)
+	tenant, err := s.GetTenant(ctx, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenant.Settings.TimeZone, tenant.Settings.WeekStart = "America/New_York", time.Tuesday
+	if err := s.UpdateTenant(ctx, tenant); err != nil {
+		t.Fatal(err)
+	}
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := service.NewBasicAnalyticsService(s, s, s)
+
+	for _, tc := range []struct {
+		bucket service.Bucket
+		want   []time.Time
+	}{
+		{service.BucketDay, []time.Time{time.Date(2024, 3, 4, 0, 0, 0, 0, ny), time.Date(2024, 3, 12, 0, 0, 0, 0, ny)}},
+		{service.BucketWeek, []time.Time{time.Date(2024, 2, 27, 0, 0, 0, 0, ny), time.Date(2024, 3, 12, 0, 0, 0, 0, ny)}},
+		{service.BucketMonth, []time.Time{time.Date(2024, 3, 1, 0, 0, 0, 0, ny)}},
+	} {
+		got, err := svc.Volume(ctx, "a1", service.VolumeQuery{Start: march, End: april, Bucket: tc.bucket})
+		if err != nil {
+			t.Fatalf("%s: Volume() = %v", tc.bucket, err)
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: aggregates = %+v, want buckets %v", tc.bucket, got, tc.want)
+			continue
+		}
+		for i, a := range got {
+			if !a.BucketStart.Equal(tc.want[i]) {
+				t.Errorf("%s: bucket %d starts %v, want %v", tc.bucket, i, a.BucketStart, tc.want[i])
+			}
+		}
+	}
+}
+
+func TestVolumeErrors(t *testing.T) {
+	s, ctx := newTrainingStore(t)
+	svc := service.NewBasicAnalyticsService(s, s, s)
+	other := service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: "u2", TenantID: "t2", Role: service.RoleAdmin})
+
+	for _, tc := range []struct {
+		name    string
+		ctx     context.Context
+		athlete string
+		q       service.VolumeQuery
+		err     error
+	}{
+		{"unknown bucket", ctx, "a1", service.VolumeQuery{Bucket: "year"}, service.ErrInvalidArgument},
+		{"unknown grouping", ctx, "a1", service.VolumeQuery{GroupBy: "equipment"}, service.ErrInvalidArgument},
+		{"empty range", ctx, "a1", service.VolumeQuery{Start: april, End: march}, service.ErrInvalidArgument},
+		{"too many buckets", ctx, "a1", service.VolumeQuery{Start: march.AddDate(-3, 0, 0), End: april, Bucket: service.BucketDay}, service.ErrInvalidArgument},
+		{"not an athlete", ctx, "admin", service.VolumeQuery{}, service.ErrInvalidArgument},
+		{"unknown athlete", ctx, "nope", service.VolumeQuery{}, service.ErrNotFound},
+		{"athlete of another tenant", other, "a1", service.VolumeQuery{}, service.ErrInvalidArgument},
+		{"no caller", context.Background(), "a1", service.VolumeQuery{}, service.ErrUnauthenticated},
+	} {
+		if _, err := svc.Volume(tc.ctx, tc.athlete, tc.q); errors.Cause(err) != tc.err {
+			t.Errorf("%s: Volume() = %v, want %v", tc.name, err, tc.err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"workout-manager-service/logging"
+)
+
+type auditLoggingService struct {
+	logger  logging.IshiLogger
+	service AuditService
+}
+
+// NewAuditLoggingService takes an IshiLogger as a dependency and returns an
+// AuditService.
+func NewAuditLoggingService(logger logging.IshiLogger, s AuditService) AuditService {
+	return auditLoggingService{
+		logger:  logger.WithFields("service", "audit"),
+		service: s,
+	}
+}
+
+// List provides informative logging when requests are made to the list
+// endpoint.
+func (ls auditLoggingService) List(ctx context.Context, filter AuditFilter) ([]AuditEvent, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "List",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"filter", fmt.Sprintf("%+v", filter),
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.List(ctx, filter)
+}
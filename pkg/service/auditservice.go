@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/logging"
+)
+
+type correlationIDContextKey struct{}
+
+// NewContextWithCorrelationID returns a copy of ctx that carries the ID used
+// to correlate everything a single request caused.
+func NewContextWithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDContextKey{}, id)
+}
+
+// CorrelationIDFromContext returns the correlation ID stored in ctx, if any.
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDContextKey{}).(string)
+	return id
+}
+
+// AuditEvent records a single mutating call: who made it, what it touched and
+// what the resource looked like before and after. Events belong to the
+// tenant of the actor, so operator actions are kept in the SystemTenantID.
+// Before is empty for creations and After is empty for deletions.
+type AuditEvent struct {
+	Name          string          `json:"id"`
+	TenantID      string          `json:"tenantId"`
+	ActorID       string          `json:"actorId"`
+	Method        string          `json:"method"`
+	Resource      string          `json:"resource"`
+	Before        json.RawMessage `json:"before,omitempty"`
+	After         json.RawMessage `json:"after,omitempty"`
+	CorrelationID string          `json:"correlationId"`
+	Time          time.Time       `json:"time"`
+}
+
+// AuditFilter narrows the audit events returned by a listing. Empty fields
+// match every event; Start is inclusive and End is exclusive.
+type AuditFilter struct {
+	Resource string    `json:"resource"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Limit    int       `json:"limit"`
+}
+
+// AuditRepository persists audit events. Listings are ordered newest first.
+// InTx runs fn in a transaction which every repository call made with the
+// context fn is given joins, so that an audited change, the snapshot of what
+// it changed and its audit event are written together or not at all.
+type AuditRepository interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	CreateAuditEvent(ctx context.Context, e AuditEvent) error
+	ListAuditEvents(ctx context.Context, tenantID string, filter AuditFilter) ([]AuditEvent, error)
+}
+
+// AuditService describes a service that answers questions about who changed
+// what within the caller's tenant.
+type AuditService interface {
+	List(ctx context.Context, filter AuditFilter) ([]AuditEvent, error)
+}
+
+// NewAuditService returns a basic AuditService with middleware wired in.
+func NewAuditService(logger logging.IshiLogger, repo AuditRepository) AuditService {
+	var svc AuditService
+	{
+		svc = NewBasicAuditService(repo)
+		svc = NewAuditLoggingService(logger, svc)
+	}
+	return svc
+}
+
+// NewBasicAuditService returns an implementation of AuditService backed by
+// the given repository.
+func NewBasicAuditService(repo AuditRepository) AuditService {
+	return basicAuditService{repo: repo}
+}
+
+type basicAuditService struct {
+	repo AuditRepository
+}
+
+// The bounds on the number of events a listing returns.
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// List retrieves the audit events of the caller's tenant, newest first.
+func (s basicAuditService) List(ctx context.Context, filter AuditFilter) ([]AuditEvent, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !filter.Start.IsZero() && !filter.End.IsZero() && !filter.Start.Before(filter.End) {
+		return nil, errors.Wrap(ErrInvalidArgument, "start time must be before end time")
+	}
+	if filter.Limit <= 0 || filter.Limit > maxAuditLimit {
+		filter.Limit = defaultAuditLimit
+	}
+	return s.repo.ListAuditEvents(ctx, p.TenantID, filter)
+}
+
+// auditor records audit events on behalf of the audit middlewares. Each
+// middleware runs the call it audits and records its event within one
+// AuditRepository transaction, so a call whose event cannot be recorded fails
+// and changes nothing.
+type auditor struct {
+	repo   AuditRepository
+	logger logging.IshiLogger
+}
+
+func newAuditor(logger logging.IshiLogger, repo AuditRepository) auditor {
+	return auditor{repo: repo, logger: logger.WithFields("component", "audit")}
+}
+
+// record stores an event for a successful call within the transaction ctx
+// carries. A nil before or after leaves the corresponding snapshot empty.
+func (a auditor) record(ctx context.Context, call string, resource string, before interface{}, after interface{}) error {
+	p, _ := PrincipalFromContext(ctx)
+	e := AuditEvent{
+		Name:          uuid.New().String(),
+		TenantID:      p.TenantID,
+		ActorID:       p.UserID,
+		Method:        call,
+		Resource:      resource,
+		CorrelationID: CorrelationIDFromContext(ctx),
+		Time:          time.Now().UTC(),
+	}
+	var err error
+	if e.Before, err = snapshot(before); err == nil {
+		e.After, err = snapshot(after)
+	}
+	if err == nil {
+		err = a.repo.CreateAuditEvent(ctx, e)
+	}
+	if err != nil {
+		a.logger.Error("failed to record audit event", method, call, "resource", resource, "err", err)
+		return errors.Wrap(err, "failed to record audit event")
+	}
+	return nil
+}
+
+func snapshot(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,195 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/inmem"
+	"workout-manager-service/logging"
+	"workout-manager-service/pkg/service"
+)
+
+func newAuditedMovements(t *testing.T, repo interface {
+	service.MovementRepository
+	service.AuditRepository
+}) (service.MovementService, context.Context) {
+	t.Helper()
+	logger, err := logging.NewZap("prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: "u1", TenantID: "t1", Role: service.RoleAdmin})
+	ctx = service.NewContextWithCorrelationID(ctx, "c1")
+	return service.NewMovementAuditService(logger, repo, service.NewBasicMovementService(repo, nil)), ctx
+}
+
+func TestMovementAuditEvents(t *testing.T) {
+	s := inmem.NewStore()
+	svc, ctx := newAuditedMovements(t, s)
+	squat, err := svc.Create(ctx, service.Movement{MovementName: "Squat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Create(ctx, service.Movement{MovementName: "Bench Press"}); err != nil {
+		t.Fatal(err)
+	}
+	squat.MovementName = "Back Squat"
+	if _, err := svc.Update(ctx, squat, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Update(ctx, service.Movement{Name: "nope", MovementName: "Row"}, ""); errors.Cause(err) != service.ErrNotFound {
+		t.Fatalf("Update() of an unknown movement = %v, want %v", err, service.ErrNotFound)
+	}
+	if err := svc.Delete(ctx, squat.Name, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	audit := service.NewBasicAuditService(s)
+	resource := "movements/" + squat.Name
+	events, err := audit.List(ctx, service.AuditFilter{Resource: resource})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An empty name means the event has no such snapshot.
+	want := []struct{ method, before, after string }{
+		{"DeleteMovement", "Back Squat", ""},
+		{"UpdateMovement", "Squat", "Back Squat"},
+		{"CreateMovement", "", "Squat"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v, want %d", events, len(want))
+	}
+	for i, e := range events {
+		w := want[i]
+		if e.Method != w.method || e.Resource != resource || e.TenantID != "t1" || e.ActorID != "u1" || e.CorrelationID != "c1" {
+			t.Errorf("event %d = %+v, want %s of %s by u1", i, e, w.method, resource)
+		}
+		if got := snapshotName(t, e.Before); got != w.before {
+			t.Errorf("%s before = %q, want %q", e.Method, got, w.before)
+		}
+		if got := snapshotName(t, e.After); got != w.after {
+			t.Errorf("%s after = %q, want %q", e.Method, got, w.after)
+		}
+	}
+}
+
+// snapshotName returns the name of the movement in an audit snapshot.
+func snapshotName(t *testing.T, snapshot json.RawMessage) string {
+	t.Helper()
+	if len(snapshot) == 0 {
+		return ""
+	}
+	var m service.Movement
+	if err := json.Unmarshal(snapshot, &m); err != nil {
+		t.Fatal(err)
+	}
+	return m.MovementName
+}
+
+func TestListAuditEventsFilters(t *testing.T) {
+	s := inmem.NewStore()
+	svc, ctx := newAuditedMovements(t, s)
+	for _, name := range []string{"Squat", "Bench Press", "Deadlift"} {
+		if _, err := svc.Create(ctx, service.Movement{MovementName: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: "u2", TenantID: "t2", Role: service.RoleAdmin})
+	if _, err := svc.Create(other, service.Movement{MovementName: "Row"}); err != nil {
+		t.Fatal(err)
+	}
+	audit := service.NewBasicAuditService(s)
+	now := time.Now()
+
+	for _, tc := range []struct {
+		name   string
+		filter service.AuditFilter
+		want   int
+	}{
+		{"everything of the tenant", service.AuditFilter{}, 3},
+		{"limit", service.AuditFilter{Limit: 2}, 2},
+		{"unknown resource", service.AuditFilter{Resource: "movements/nope"}, 0},
+		{"started", service.AuditFilter{Start: now.Add(-time.Hour), End: now.Add(time.Hour)}, 3},
+		{"after the events", service.AuditFilter{Start: now.Add(time.Hour)}, 0},
+		{"before the events", service.AuditFilter{End: now.Add(-time.Hour)}, 0},
+	} {
+		events, err := audit.List(ctx, tc.filter)
+		if err != nil || len(events) != tc.want {
+			t.Errorf("%s: List() = %d events, %v, want %d", tc.name, len(events), err, tc.want)
+		}
+	}
+	events, _ := audit.List(ctx, service.AuditFilter{Limit: 1})
+	if len(events) != 1 || events[0].Method != "CreateMovement" || events[0].TenantID != "t1" {
+		t.Fatalf("events = %+v, want the newest creation", events)
+	}
+	if got := snapshotName(t, events[0].After); got != "Deadlift" {
+		t.Errorf("newest event is of %q, want the deadlift", got)
+	}
+	if byResource, _ := audit.List(ctx, service.AuditFilter{Resource: events[0].Resource}); len(byResource) != 1 {
+		t.Errorf("events of %s = %+v, want one", events[0].Resource, byResource)
+	}
+
+	if _, err := audit.List(ctx, service.AuditFilter{Start: now, End: now}); errors.Cause(err) != service.ErrInvalidArgument {
+		t.Errorf("List() of an empty range = %v, want %v", err, service.ErrInvalidArgument)
+	}
+	if _, err := audit.List(context.Background(), service.AuditFilter{}); err == nil {
+		t.Errorf("List() without a caller succeeded")
+	}
+}
+
+type txKey struct{}
+
+// txStore checks that audited writes and their events share a transaction,
+// and can fail to record events.
+type txStore struct {
+	*inmem.Store
+	t         *testing.T
+	failAudit bool
+}
+
+func (s txStore) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.Store.InTx(ctx, func(ctx context.Context) error {
+		return fn(context.WithValue(ctx, txKey{}, true))
+	})
+}
+
+func (s txStore) UpdateMovement(ctx context.Context, m service.Movement) (service.Movement, error) {
+	if ctx.Value(txKey{}) == nil {
+		s.t.Errorf("UpdateMovement() ran outside the audit transaction")
+	}
+	return s.Store.UpdateMovement(ctx, m)
+}
+
+func (s txStore) CreateAuditEvent(ctx context.Context, e service.AuditEvent) error {
+	if ctx.Value(txKey{}) == nil {
+		s.t.Errorf("CreateAuditEvent() ran outside the audit transaction")
+	}
+	if s.failAudit {
+		return errors.New("audit log unavailable")
+	}
+	return s.Store.CreateAuditEvent(ctx, e)
+}
+
+func TestAuditEventsShareTheTransaction(t *testing.T) {
+	s := txStore{Store: inmem.NewStore(), t: t}
+	svc, ctx := newAuditedMovements(t, s)
+	squat, err := svc.Create(ctx, service.Movement{MovementName: "Squat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	squat.MovementName = "Back Squat"
+	if _, err := svc.Update(ctx, squat, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	s.failAudit = true
+	svc, ctx = newAuditedMovements(t, s)
+	squat.MovementName = "Low-Bar Squat"
+	if _, err := svc.Update(ctx, squat, ""); err == nil {
+		t.Errorf("Update() succeeded although its audit event was not recorded")
+	}
+}
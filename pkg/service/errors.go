@@ -0,0 +1,16 @@
+package service
+
+import "github.com/pkg/errors"
+
+// Domain errors returned by every service. Callers should compare against
+// errors.Cause(err) because implementations are free to wrap them with
+// additional context.
+var (
+	ErrNotFound         = errors.New("resource not found")
+	ErrInvalidArgument  = errors.New("invalid argument")
+	ErrUnauthenticated  = errors.New("caller is not authenticated")
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrConflict         = errors.New("resource was changed concurrently")
+	ErrAlreadyExists    = errors.New("resource already exists")
+	ErrExpired          = errors.New("resource has expired")
+)
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyRecord remembers the outcome of a request made with a
+// client-supplied request ID so that retries can be answered without doing
+// the work twice. Response is empty until the request completes. A record
+// that is still in progress expires after a short lease, so that a request
+// whose server died can be retried; completing it extends ExpireTime to the
+// replay window.
+type IdempotencyRecord struct {
+	Key         string    `json:"key"`
+	RequestHash string    `json:"requestHash"`
+	Response    []byte    `json:"response"`
+	Complete    bool      `json:"complete"`
+	ExpireTime  time.Time `json:"expireTime"`
+}
+
+// IdempotencyRepository persists idempotency records where every server
+// instance can see them. ReserveIdempotencyKey stores rec unless a live
+// record with the same key exists, in which case it returns that record and
+// false. CompleteIdempotencyKey stores the response and moves the record's
+// expiry to expireTime. Expired records are treated as absent.
+type IdempotencyRepository interface {
+	ReserveIdempotencyKey(ctx context.Context, rec IdempotencyRecord) (IdempotencyRecord, bool, error)
+	CompleteIdempotencyKey(ctx context.Context, key string, response []byte, expireTime time.Time) error
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// KilogramsPerPound is the exact definition of the international pound.
+const KilogramsPerPound = 0.45359237
+
+// loadPrecision is the finest fraction of a unit a load is read in, which
+// hides the float rounding of converting to kilograms and back.
+const loadPrecision = 1e6
+
+// PlateIncrements are the smallest jumps plates can load in each unit: a
+// pair of 0.25 kg or 0.5 lb fractional plates.
+var PlateIncrements = map[WeightUnit]float64{
+	Kilograms: 0.5,
+	Pounds:    1,
+}
+
+// Load is a weight. It keeps the weight in kilograms at full precision,
+// which is what every calculation uses, along with the unit it was entered
+// in, so that it reads back exactly as it was entered.
+type Load struct {
+	Kilograms float64    `json:"kilograms"`
+	Unit      WeightUnit `json:"unit"`
+}
+
+// NewLoad returns the load of amount entered in unit, taken to be kilograms
+// when it is empty.
+func NewLoad(amount float64, unit WeightUnit) (Load, error) {
+	switch unit {
+	case "", Kilograms:
+		return Load{Kilograms: amount, Unit: Kilograms}, nil
+	case Pounds:
+		return Load{Kilograms: amount * KilogramsPerPound, Unit: Pounds}, nil
+	}
+	return Load{}, errors.Wrapf(ErrInvalidArgument, "unknown unit %q", unit)
+}
+
+// Kilos returns a load of kg kilograms, for weights that were computed rather
+// than entered.
+func Kilos(kg float64) Load {
+	return Load{Kilograms: kg, Unit: Kilograms}
+}
+
+// In returns the load in unit, or in the unit it was entered in when unit is
+// empty.
+func (l Load) In(unit WeightUnit) float64 {
+	if unit == "" {
+		unit = l.Unit
+	}
+	amount := l.Kilograms
+	if unit == Pounds {
+		amount /= KilogramsPerPound
+	}
+	return math.Round(amount*loadPrecision) / loadPrecision
+}
+
+// Loadable returns the load in unit like In, except that a load converted
+// from another unit is rounded to the nearest plate increment, since nobody
+// loads a bar with the 102.058 kg that 225 lb comes to.
+func (l Load) Loadable(unit WeightUnit) float64 {
+	if unit == "" || unit == l.Unit {
+		return l.In(unit)
+	}
+	return RoundToIncrement(l.In(unit), PlateIncrements[unit])
+}
+
+// RoundToIncrement rounds amount to the nearest multiple of increment, or
+// returns it as is when increment is not positive.
+func RoundToIncrement(amount float64, increment float64) float64 {
+	if increment <= 0 {
+		return amount
+	}
+	return math.Round(amount/increment) * increment
+}
+
+// PreferredUnit returns the unit the caller of ctx prefers loads in, which is
+// kilograms when there is no caller or they have no preference.
+func PreferredUnit(ctx context.Context) WeightUnit {
+	if p, ok := PrincipalFromContext(ctx); ok && p.Unit != "" {
+		return p.Unit
+	}
+	return Kilograms
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+
+	"workout-manager-service/logging"
+)
+
+type loadingAuditService struct {
+	auditor
+	service LoadingService
+}
+
+// NewLoadingAuditService takes an AuditRepository as a dependency and returns
+// a LoadingService that records every successful mutation.
+func NewLoadingAuditService(logger logging.IshiLogger, repo AuditRepository, s LoadingService) LoadingService {
+	return loadingAuditService{
+		auditor: newAuditor(logger, repo),
+		service: s,
+	}
+}
+
+// CalculatePlates is not audited.
+func (as loadingAuditService) CalculatePlates(ctx context.Context, q PlateQuery) (PlateLoading, error) {
+	return as.service.CalculatePlates(ctx, q)
+}
+
+// GenerateWarmups is not audited.
+func (as loadingAuditService) GenerateWarmups(ctx context.Context, q WarmupQuery) (WarmupLadder, error) {
+	return as.service.GenerateWarmups(ctx, q)
+}
+
+// GetPlateInventory is not audited.
+func (as loadingAuditService) GetPlateInventory(ctx context.Context) (PlateInventory, error) {
+	return as.service.GetPlateInventory(ctx)
+}
+
+// UpdatePlateInventory records the inventory before and after it changed.
+func (as loadingAuditService) UpdatePlateInventory(ctx context.Context, inv PlateInventory) (after PlateInventory, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		before, _ := as.service.GetPlateInventory(ctx)
+		if after, err = as.service.UpdatePlateInventory(ctx, inv); err != nil {
+			return err
+		}
+		return as.record(ctx, "UpdatePlateInventory", "plateInventory", before, after)
+	})
+	return after, err
+}
+
+// SuggestNextLoad is not audited.
+func (as loadingAuditService) SuggestNextLoad(ctx context.Context, athleteID string, q ProgressionQuery) (LoadSuggestion, error) {
+	return as.service.SuggestNextLoad(ctx, athleteID, q)
+}
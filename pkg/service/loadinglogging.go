@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"workout-manager-service/logging"
+)
+
+type loadingLoggingService struct {
+	logger  logging.IshiLogger
+	service LoadingService
+}
+
+// NewLoadingLoggingService takes an IshiLogger as a dependency and returns a
+// LoadingService.
+func NewLoadingLoggingService(logger logging.IshiLogger, s LoadingService) LoadingService {
+	return loadingLoggingService{
+		logger:  logger.WithFields("service", "loading"),
+		service: s,
+	}
+}
+
+// CalculatePlates provides informative logging when requests are made to the
+// calculate plates endpoint.
+func (ls loadingLoggingService) CalculatePlates(ctx context.Context, q PlateQuery) (PlateLoading, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "CalculatePlates",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"target", q.Target.Kilograms,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.CalculatePlates(ctx, q)
+}
+
+// GenerateWarmups provides informative logging when requests are made to the
+// generate warmups endpoint.
+func (ls loadingLoggingService) GenerateWarmups(ctx context.Context, q WarmupQuery) (WarmupLadder, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "GenerateWarmups",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"movementID", q.MovementID,
+			"workingWeight", q.WorkingWeight.Kilograms,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.GenerateWarmups(ctx, q)
+}
+
+// GetPlateInventory provides informative logging when requests are made to
+// the get plate inventory endpoint.
+func (ls loadingLoggingService) GetPlateInventory(ctx context.Context) (PlateInventory, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "GetPlateInventory",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.GetPlateInventory(ctx)
+}
+
+// UpdatePlateInventory provides informative logging when requests are made
+// to the update plate inventory endpoint.
+func (ls loadingLoggingService) UpdatePlateInventory(ctx context.Context, inv PlateInventory) (PlateInventory, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "UpdatePlateInventory",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"plates", len(inv.Plates),
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.UpdatePlateInventory(ctx, inv)
+}
+
+// SuggestNextLoad provides informative logging when requests are made to the
+// suggest next load endpoint.
+func (ls loadingLoggingService) SuggestNextLoad(ctx context.Context, athleteID string, q ProgressionQuery) (LoadSuggestion, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "SuggestNextLoad",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"athleteID", athleteID,
+			"movementID", q.MovementID,
+			"scheme", q.Rule.Scheme,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.SuggestNextLoad(ctx, athleteID, q)
+}
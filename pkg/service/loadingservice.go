@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/logging"
+)
+
+// LoadingRepository persists the plate inventories of tenants.
+// GetPlateInventory returns ErrNotFound for tenants that have not stocked
+// their own.
+type LoadingRepository interface {
+	GetPlateInventory(ctx context.Context, tenantID string) (PlateInventory, error)
+	SetPlateInventory(ctx context.Context, inv PlateInventory) error
+}
+
+// PlateQuery asks how to load a bar for Target. A nil Bar uses the bar of
+// the caller's plate inventory.
+type PlateQuery struct {
+	Target Load
+	Bar    *Load
+}
+
+// WarmupQuery asks for the warm-up sets leading to WorkingReps of a movement
+// at WorkingWeight. A nil Bar uses the bar of the caller's plate inventory;
+// it only matters for barbell movements.
+type WarmupQuery struct {
+	MovementID    string
+	WorkingWeight Load
+	WorkingReps   int32
+	Bar           *Load
+}
+
+// WarmupLadder is the warm-up to a working set of a movement, lightest set
+// first.
+type WarmupLadder struct {
+	MovementID    string      `json:"movementId"`
+	Equipment     Equipment   `json:"equipment"`
+	WorkingWeight Load        `json:"workingWeight"`
+	WorkingReps   int32       `json:"workingReps"`
+	Bar           Load        `json:"bar"`
+	Sets          []WarmupSet `json:"sets"`
+}
+
+// LoadingService describes a service that works out how to load the bar,
+// replacing the plate maths gym tablets would otherwise do themselves.
+// Calculations use the plate inventory of the caller's tenant, which its
+// admins maintain. SuggestNextLoad is addressed by athlete so that
+// authorization policies can decide access before the service is invoked.
+type LoadingService interface {
+	CalculatePlates(ctx context.Context, q PlateQuery) (PlateLoading, error)
+	GenerateWarmups(ctx context.Context, q WarmupQuery) (WarmupLadder, error)
+	GetPlateInventory(ctx context.Context) (PlateInventory, error)
+	UpdatePlateInventory(ctx context.Context, inv PlateInventory) (PlateInventory, error)
+	SuggestNextLoad(ctx context.Context, athleteID string, q ProgressionQuery) (LoadSuggestion, error)
+}
+
+// NewLoadingService returns a basic LoadingService with middleware wired in.
+func NewLoadingService(logger logging.IshiLogger, repo LoadingRepository, workouts WorkoutRepository, movements MovementRepository, users UserRepository, tenants TenantRepository, audit AuditRepository) LoadingService {
+	var svc LoadingService
+	{
+		svc = NewBasicLoadingService(repo, workouts, movements, users, tenants)
+		svc = NewLoadingAuditService(logger, audit, svc)
+		svc = NewLoadingLoggingService(logger, svc)
+	}
+	return svc
+}
+
+// NewBasicLoadingService returns an implementation of LoadingService backed
+// by the given repositories. Movements are read through a basic
+// MovementService so that warm-ups and suggestions see the same catalog as
+// the athlete.
+func NewBasicLoadingService(repo LoadingRepository, workouts WorkoutRepository, movements MovementRepository, users UserRepository, tenants TenantRepository) LoadingService {
+	return basicLoadingService{
+		repo:     repo,
+		workouts: workouts,
+		catalog:  NewBasicMovementService(movements, nil),
+		users:    users,
+		tenants:  tenants,
+	}
+}
+
+type basicLoadingService struct {
+	repo     LoadingRepository
+	workouts WorkoutRepository
+	catalog  MovementService
+	users    UserRepository
+	tenants  TenantRepository
+}
+
+// CalculatePlates returns the plates to load on each side of the bar for a
+// target load. When the plates cannot make the target exactly, the heaviest
+// load under it is returned instead.
+func (s basicLoadingService) CalculatePlates(ctx context.Context, q PlateQuery) (PlateLoading, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return PlateLoading{}, err
+	}
+	inv, err := s.plateInventory(ctx, p.TenantID)
+	if err != nil {
+		return PlateLoading{}, err
+	}
+	bar := inv.Bar
+	if q.Bar != nil {
+		bar = *q.Bar
+	}
+	if bar.Kilograms < 0 {
+		return PlateLoading{}, errors.Wrap(ErrInvalidArgument, "the bar may not weigh less than nothing")
+	}
+	if q.Target.Kilograms < bar.Kilograms {
+		return PlateLoading{}, errors.Wrap(ErrInvalidArgument, "the target is lighter than the bar")
+	}
+	return LoadPlates(q.Target, bar, inv.Plates), nil
+}
+
+// GenerateWarmups builds the warm-up ladder to a working set of a movement.
+// Only movements loaded with external weight can be warmed up to.
+func (s basicLoadingService) GenerateWarmups(ctx context.Context, q WarmupQuery) (WarmupLadder, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return WarmupLadder{}, err
+	}
+	if q.WorkingWeight.Kilograms <= 0 || q.WorkingReps < 0 {
+		return WarmupLadder{}, errors.Wrap(ErrInvalidArgument, "the working set is malformed")
+	}
+	m, err := s.catalog.Get(ctx, q.MovementID, false)
+	if err != nil {
+		return WarmupLadder{}, err
+	}
+	if m.LoadType != ExternalLoad || m.Equipment == Bodyweight || m.Equipment == Band || m.Equipment == Ergometer {
+		return WarmupLadder{}, errors.Wrapf(ErrInvalidArgument, "movement %s is not loaded with weight", m.Name)
+	}
+	inv, err := s.plateInventory(ctx, p.TenantID)
+	if err != nil {
+		return WarmupLadder{}, err
+	}
+	ladder := WarmupLadder{
+		MovementID:    m.Name,
+		Equipment:     m.Equipment,
+		WorkingWeight: q.WorkingWeight,
+		WorkingReps:   q.WorkingReps,
+	}
+	if m.Equipment == Barbell {
+		ladder.Bar = inv.Bar
+		if q.Bar != nil {
+			ladder.Bar = *q.Bar
+		}
+		if q.WorkingWeight.Kilograms < ladder.Bar.Kilograms {
+			return WarmupLadder{}, errors.Wrap(ErrInvalidArgument, "the working weight is lighter than the bar")
+		}
+	}
+	ladder.Sets = BuildWarmups(m.Equipment, q.WorkingWeight, q.WorkingReps, ladder.Bar, inv.Plates)
+	return ladder, nil
+}
+
+// GetPlateInventory returns the plate inventory of the caller's tenant.
+func (s basicLoadingService) GetPlateInventory(ctx context.Context) (PlateInventory, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return PlateInventory{}, err
+	}
+	return s.plateInventory(ctx, p.TenantID)
+}
+
+// UpdatePlateInventory replaces the plate inventory of the caller's tenant.
+// Each plate weight may only be listed once.
+func (s basicLoadingService) UpdatePlateInventory(ctx context.Context, inv PlateInventory) (PlateInventory, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return PlateInventory{}, err
+	}
+	if err := validatePlateInventory(inv); err != nil {
+		return PlateInventory{}, err
+	}
+	inv.TenantID = p.TenantID
+	inv.UpdateTime = time.Now().UTC()
+	if err := s.repo.SetPlateInventory(ctx, inv); err != nil {
+		return PlateInventory{}, err
+	}
+	return inv, nil
+}
+
+// SuggestNextLoad proposes the working load of an athlete's next session of
+// a movement from their logged sets of it, with the reasoning behind it.
+func (s basicLoadingService) SuggestNextLoad(ctx context.Context, athleteID string, q ProgressionQuery) (LoadSuggestion, error) {
+	p, err := s.athlete(ctx, athleteID)
+	if err != nil {
+		return LoadSuggestion{}, err
+	}
+	if err := validateProgression(q); err != nil {
+		return LoadSuggestion{}, err
+	}
+	m, err := s.catalog.Get(ctx, q.MovementID, true)
+	if err != nil {
+		return LoadSuggestion{}, err
+	}
+	q.MovementID = m.Name
+	history, err := s.workouts.ListWorkouts(ctx, p.TenantID, athleteID)
+	if err != nil {
+		return LoadSuggestion{}, err
+	}
+	return SuggestLoad(q, history)
+}
+
+// athlete checks that athleteID is an athlete of the caller's tenant.
+func (s basicLoadingService) athlete(ctx context.Context, athleteID string) (Principal, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return Principal{}, err
+	}
+	athlete, err := s.users.GetUser(ctx, athleteID)
+	if err != nil {
+		return Principal{}, errors.Wrap(err, "failed to look up athlete")
+	}
+	if athlete.TenantID != p.TenantID || athlete.Role != RoleAthlete {
+		return Principal{}, errors.Wrapf(ErrInvalidArgument, "user %s is not an athlete", athleteID)
+	}
+	return p, nil
+}
+
+// plateInventory returns the plate inventory of a tenant, falling back to
+// the default one for its unit.
+func (s basicLoadingService) plateInventory(ctx context.Context, tenantID string) (PlateInventory, error) {
+	inv, err := s.repo.GetPlateInventory(ctx, tenantID)
+	if err == nil {
+		return inv, nil
+	}
+	if errors.Cause(err) != ErrNotFound {
+		return PlateInventory{}, errors.Wrap(err, "failed to look up plate inventory")
+	}
+	t, err := s.tenants.GetTenant(ctx, tenantID)
+	if err != nil {
+		return PlateInventory{}, err
+	}
+	inv, ok := DefaultPlateInventories[t.Settings.DefaultUnit]
+	if !ok {
+		inv = DefaultPlateInventories[DefaultTenantSettings.DefaultUnit]
+	}
+	inv.TenantID = tenantID
+	return inv, nil
+}
+
+func validatePlateInventory(inv PlateInventory) error {
+	if inv.Bar.Kilograms < 0 {
+		return errors.Wrap(ErrInvalidArgument, "the bar may not weigh less than nothing")
+	}
+	if len(inv.Plates) == 0 {
+		return errors.Wrap(ErrInvalidArgument, "an inventory needs at least one plate")
+	}
+	if len(inv.Plates) > MaxPlateSizes {
+		return errors.Wrapf(ErrInvalidArgument, "an inventory holds at most %d sizes of plate", MaxPlateSizes)
+	}
+	seen := make(map[Load]bool)
+	for i, p := range inv.Plates {
+		if p.Weight.Kilograms <= 0 || p.Pairs <= 0 {
+			return errors.Wrapf(ErrInvalidArgument, "plate %d is malformed", i)
+		}
+		if p.Pairs > MaxPlatePairs {
+			return errors.Wrapf(ErrInvalidArgument, "plate %d has more than %d pairs", i, MaxPlatePairs)
+		}
+		if _, ok := PlateIncrements[p.Weight.Unit]; !ok {
+			return errors.Wrapf(ErrInvalidArgument, "plate %d has unknown unit %q", i, p.Weight.Unit)
+		}
+		if seen[p.Weight] {
+			return errors.Wrapf(ErrInvalidArgument, "plate %d is listed twice", i)
+		}
+		seen[p.Weight] = true
+	}
+	return nil
+}
+
+func validateProgression(q ProgressionQuery) error {
+	switch {
+	case q.TargetReps < 0 || q.TargetReps > maxE1RMReps:
+		return errors.Wrapf(ErrInvalidArgument, "target reps must be between 1 and %d", maxE1RMReps)
+	case q.TargetRPE != 0 && (q.TargetRPE < 5 || q.TargetRPE > 10):
+		return errors.Wrap(ErrInvalidArgument, "target RPE must be between 5 and 10")
+	case q.Rule.Increment.Kilograms < 0 || q.Rule.MinReps < 0 || q.Rule.MaxReps < 0:
+		return errors.Wrap(ErrInvalidArgument, "the progression rule is malformed")
+	case q.Rule.Deload < 0 || q.Rule.Deload >= 1:
+		return errors.Wrap(ErrInvalidArgument, "the deload must be a fraction below 1")
+	}
+	if _, ok := PlateIncrements[q.Rule.Increment.Unit]; !ok && q.Rule.Increment.Unit != "" {
+		return errors.Wrapf(ErrInvalidArgument, "unknown unit %q", q.Rule.Increment.Unit)
+	}
+	return nil
+}
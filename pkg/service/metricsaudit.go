@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+
+	"workout-manager-service/logging"
+)
+
+type metricsAuditService struct {
+	auditor
+	service MetricsService
+}
+
+// NewMetricsAuditService takes an AuditRepository as a dependency and
+// returns a MetricsService that records every successful mutation.
+func NewMetricsAuditService(logger logging.IshiLogger, repo AuditRepository, s MetricsService) MetricsService {
+	return metricsAuditService{
+		auditor: newAuditor(logger, repo),
+		service: s,
+	}
+}
+
+func bodyMetricResource(athleteID string, id string) string {
+	return "athletes/" + athleteID + "/bodyMetrics/" + id
+}
+
+// Record records the measurement that was recorded.
+func (as metricsAuditService) Record(ctx context.Context, athleteID string, m BodyMetric) (recorded BodyMetric, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		if recorded, err = as.service.Record(ctx, athleteID, m); err != nil {
+			return err
+		}
+		return as.record(ctx, "RecordBodyMetric", bodyMetricResource(athleteID, recorded.Name), nil, recorded)
+	})
+	return recorded, err
+}
+
+// List is not audited.
+func (as metricsAuditService) List(ctx context.Context, athleteID string, f BodyMetricFilter, unit MeasurementUnit) ([]BodyMetric, error) {
+	return as.service.List(ctx, athleteID, f, unit)
+}
+
+// Delete records which measurement was deleted.
+func (as metricsAuditService) Delete(ctx context.Context, athleteID string, id string) error {
+	return as.repo.InTx(ctx, func(ctx context.Context) error {
+		if err := as.service.Delete(ctx, athleteID, id); err != nil {
+			return err
+		}
+		return as.record(ctx, "DeleteBodyMetric", bodyMetricResource(athleteID, id), nil, nil)
+	})
+}
+
+// Trend is not audited.
+func (as metricsAuditService) Trend(ctx context.Context, athleteID string, q TrendQuery) (MetricTrend, error) {
+	return as.service.Trend(ctx, athleteID, q)
+}
+
+// RelativeStrength is not audited.
+func (as metricsAuditService) RelativeStrength(ctx context.Context, athleteID string, q RelativeStrengthQuery) (RelativeStrengthReport, error) {
+	return as.service.RelativeStrength(ctx, athleteID, q)
+}
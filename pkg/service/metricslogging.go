@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"workout-manager-service/logging"
+)
+
+type metricsLoggingService struct {
+	logger  logging.IshiLogger
+	service MetricsService
+}
+
+// NewMetricsLoggingService takes an IshiLogger as a dependency and returns a
+// MetricsService.
+func NewMetricsLoggingService(logger logging.IshiLogger, s MetricsService) MetricsService {
+	return metricsLoggingService{
+		logger:  logger.WithFields("service", "metrics"),
+		service: s,
+	}
+}
+
+// Record provides informative logging when requests are made to the record
+// body metric endpoint.
+func (ls metricsLoggingService) Record(ctx context.Context, athleteID string, m BodyMetric) (BodyMetric, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Record",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"athleteID", athleteID,
+			"type", m.Type,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Record(ctx, athleteID, m)
+}
+
+// List provides informative logging when requests are made to the list body
+// metrics endpoint.
+func (ls metricsLoggingService) List(ctx context.Context, athleteID string, f BodyMetricFilter, unit MeasurementUnit) ([]BodyMetric, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "List",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"athleteID", athleteID,
+			"type", f.Type,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.List(ctx, athleteID, f, unit)
+}
+
+// Delete provides informative logging when requests are made to the delete
+// body metric endpoint.
+func (ls metricsLoggingService) Delete(ctx context.Context, athleteID string, id string) error {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Delete",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"athleteID", athleteID,
+			"id", id,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Delete(ctx, athleteID, id)
+}
+
+// Trend provides informative logging when requests are made to the body
+// metric trend endpoint.
+func (ls metricsLoggingService) Trend(ctx context.Context, athleteID string, q TrendQuery) (MetricTrend, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Trend",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"athleteID", athleteID,
+			"type", q.Type,
+			"window", q.Window,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Trend(ctx, athleteID, q)
+}
+
+// RelativeStrength provides informative logging when requests are made to
+// the relative strength endpoint.
+func (ls metricsLoggingService) RelativeStrength(ctx context.Context, athleteID string, q RelativeStrengthQuery) (RelativeStrengthReport, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "RelativeStrength",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"athleteID", athleteID,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.RelativeStrength(ctx, athleteID, q)
+}
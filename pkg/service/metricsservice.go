@@ -0,0 +1,515 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/logging"
+)
+
+// BodyMetricType is what a BodyMetric measures.
+type BodyMetricType string
+
+// The body metrics an athlete may record. Readiness is the score of a
+// wellness questionnaire, from 0 to 100.
+const (
+	BodyweightMetric    BodyMetricType = "bodyweight"
+	BodyFatMetric       BodyMetricType = "body_fat"
+	CircumferenceMetric BodyMetricType = "circumference"
+	SleepMetric         BodyMetricType = "sleep"
+	HRVMetric           BodyMetricType = "hrv"
+	ReadinessMetric     BodyMetricType = "readiness"
+)
+
+// MeasurementUnit is the unit a body metric is expressed in.
+type MeasurementUnit string
+
+// The units body metrics may be recorded and read in.
+const (
+	UnitKilograms    MeasurementUnit = "kg"
+	UnitPounds       MeasurementUnit = "lb"
+	UnitPercent      MeasurementUnit = "%"
+	UnitCentimeters  MeasurementUnit = "cm"
+	UnitInches       MeasurementUnit = "in"
+	UnitHours        MeasurementUnit = "h"
+	UnitMinutes      MeasurementUnit = "min"
+	UnitMilliseconds MeasurementUnit = "ms"
+	UnitScore        MeasurementUnit = "score"
+)
+
+// bodyMetricUnits lists the units of each metric with the factor that
+// converts them to the first, canonical one, which metrics are stored in.
+var bodyMetricUnits = map[BodyMetricType][]struct {
+	unit   MeasurementUnit
+	factor float64
+}{
+	BodyweightMetric:    {{UnitKilograms, 1}, {UnitPounds, KilogramsPerPound}},
+	BodyFatMetric:       {{UnitPercent, 1}},
+	CircumferenceMetric: {{UnitCentimeters, 1}, {UnitInches, 2.54}},
+	SleepMetric:         {{UnitHours, 1}, {UnitMinutes, 1.0 / 60}},
+	HRVMetric:           {{UnitMilliseconds, 1}},
+	ReadinessMetric:     {{UnitScore, 1}},
+}
+
+// bodyMetricRanges bounds the canonical values of each metric, to catch
+// values recorded in the wrong unit.
+var bodyMetricRanges = map[BodyMetricType][2]float64{
+	BodyweightMetric:    {20, 400},
+	BodyFatMetric:       {1, 75},
+	CircumferenceMetric: {5, 300},
+	SleepMetric:         {0, 24},
+	HRVMetric:           {1, 500},
+	ReadinessMetric:     {0, 100},
+}
+
+// CircumferenceSites are the places circumferences may be measured at.
+var CircumferenceSites = []string{"neck", "chest", "waist", "hips", "arm", "forearm", "thigh", "calf"}
+
+// CanonicalUnit returns the unit a metric is stored in.
+func (t BodyMetricType) CanonicalUnit() MeasurementUnit {
+	units := bodyMetricUnits[t]
+	if len(units) == 0 {
+		return ""
+	}
+	return units[0].unit
+}
+
+// ConvertBodyMetric converts a value of a metric from one of its units to
+// another.
+func ConvertBodyMetric(t BodyMetricType, value float64, from MeasurementUnit, to MeasurementUnit) (float64, error) {
+	var fromFactor, toFactor float64
+	for _, u := range bodyMetricUnits[t] {
+		if u.unit == from {
+			fromFactor = u.factor
+		}
+		if u.unit == to {
+			toFactor = u.factor
+		}
+	}
+	if fromFactor == 0 || toFactor == 0 {
+		return 0, errors.Wrapf(ErrInvalidArgument, "%s cannot be converted from %q to %q", t, from, to)
+	}
+	return value * fromFactor / toFactor, nil
+}
+
+// ReadinessAnswers are the answers to a wellness questionnaire, each from 1,
+// the worst, to 5, the best: how well the athlete slept, and how fresh,
+// free of soreness, relaxed and in good spirits they feel.
+type ReadinessAnswers struct {
+	SleepQuality int32 `json:"sleepQuality"`
+	Fatigue      int32 `json:"fatigue"`
+	Soreness     int32 `json:"soreness"`
+	Stress       int32 `json:"stress"`
+	Mood         int32 `json:"mood"`
+}
+
+// Score returns the readiness the answers add up to, from 0 to 100.
+func (a ReadinessAnswers) Score() (float64, error) {
+	answers := []int32{a.SleepQuality, a.Fatigue, a.Soreness, a.Stress, a.Mood}
+	var total int32
+	for _, answer := range answers {
+		if answer < 1 || answer > 5 {
+			return 0, errors.Wrap(ErrInvalidArgument, "readiness answers must be between 1 and 5")
+		}
+		total += answer - 1
+	}
+	return float64(total) * 100 / float64(4*len(answers)), nil
+}
+
+// BodyMetric is one measurement of an athlete's body or readiness at
+// MeasuredAt. Site is where a circumference was measured and empty for every
+// other metric. Readiness holds the questionnaire a readiness score was
+// computed from, if it was.
+type BodyMetric struct {
+	Name       string            `json:"id"`
+	TenantID   string            `json:"tenantId"`
+	AthleteID  string            `json:"athleteId"`
+	Type       BodyMetricType    `json:"type"`
+	Site       string            `json:"site"`
+	Value      float64           `json:"value"`
+	Unit       MeasurementUnit   `json:"unit"`
+	Readiness  *ReadinessAnswers `json:"readiness"`
+	MeasuredAt time.Time         `json:"measuredAt"`
+	CreateTime time.Time         `json:"createTime"`
+}
+
+// BodyMetricFilter narrows the body metrics listed. Empty fields do not
+// filter; Start is inclusive and End exclusive.
+type BodyMetricFilter struct {
+	Type  BodyMetricType `json:"type"`
+	Site  string         `json:"site"`
+	Start time.Time      `json:"start"`
+	End   time.Time      `json:"end"`
+}
+
+// MetricsRepository persists body metrics, in their canonical units.
+// ListBodyMetrics returns the oldest measurements first.
+type MetricsRepository interface {
+	CreateBodyMetric(ctx context.Context, m BodyMetric) error
+	GetBodyMetric(ctx context.Context, id string) (BodyMetric, error)
+	ListBodyMetrics(ctx context.Context, tenantID string, athleteID string, f BodyMetricFilter) ([]BodyMetric, error)
+	DeleteBodyMetric(ctx context.Context, id string) error
+}
+
+// MetricsService describes a service that tracks athletes' body metrics and
+// readiness, and relates their strength to their bodyweight. Every method is
+// addressed by athlete so that authorization policies can decide access
+// before the service is invoked.
+type MetricsService interface {
+	Record(ctx context.Context, athleteID string, m BodyMetric) (BodyMetric, error)
+	List(ctx context.Context, athleteID string, f BodyMetricFilter, unit MeasurementUnit) ([]BodyMetric, error)
+	Delete(ctx context.Context, athleteID string, id string) error
+	Trend(ctx context.Context, athleteID string, q TrendQuery) (MetricTrend, error)
+	RelativeStrength(ctx context.Context, athleteID string, q RelativeStrengthQuery) (RelativeStrengthReport, error)
+}
+
+// NewMetricsService returns a basic MetricsService with middleware wired in.
+func NewMetricsService(logger logging.IshiLogger, repo MetricsRepository, workouts WorkoutRepository, users UserRepository, tenants TenantRepository, audit AuditRepository) MetricsService {
+	var svc MetricsService
+	{
+		svc = NewBasicMetricsService(repo, workouts, users, tenants)
+		svc = NewMetricsAuditService(logger, audit, svc)
+		svc = NewMetricsLoggingService(logger, svc)
+	}
+	return svc
+}
+
+// NewBasicMetricsService returns an implementation of MetricsService backed
+// by the given repositories.
+func NewBasicMetricsService(repo MetricsRepository, workouts WorkoutRepository, users UserRepository, tenants TenantRepository) MetricsService {
+	return basicMetricsService{repo: repo, workouts: workouts, users: users, tenants: tenants}
+}
+
+type basicMetricsService struct {
+	repo     MetricsRepository
+	workouts WorkoutRepository
+	users    UserRepository
+	tenants  TenantRepository
+}
+
+// Record stores a measurement of an athlete. The value may be given in any
+// unit of its metric and is converted to the canonical one; without a unit
+// it is taken to be in the canonical unit. A readiness score may be given
+// directly or computed from the questionnaire's answers.
+func (s basicMetricsService) Record(ctx context.Context, athleteID string, m BodyMetric) (BodyMetric, error) {
+	p, _, err := s.athleteTenant(ctx, athleteID)
+	if err != nil {
+		return BodyMetric{}, err
+	}
+	if _, ok := bodyMetricUnits[m.Type]; !ok {
+		return BodyMetric{}, errors.Wrapf(ErrInvalidArgument, "unknown body metric %q", m.Type)
+	}
+	if err := checkSite(m.Type, m.Site); err != nil {
+		return BodyMetric{}, err
+	}
+	if m.Readiness != nil {
+		if m.Type != ReadinessMetric {
+			return BodyMetric{}, errors.Wrap(ErrInvalidArgument, "only readiness has questionnaire answers")
+		}
+		if m.Value, err = m.Readiness.Score(); err != nil {
+			return BodyMetric{}, err
+		}
+		m.Unit = UnitScore
+	}
+	if m.Unit == "" {
+		m.Unit = m.Type.CanonicalUnit()
+	}
+	if m.Value, err = ConvertBodyMetric(m.Type, m.Value, m.Unit, m.Type.CanonicalUnit()); err != nil {
+		return BodyMetric{}, err
+	}
+	if r := bodyMetricRanges[m.Type]; m.Value < r[0] || m.Value > r[1] {
+		return BodyMetric{}, errors.Wrapf(ErrInvalidArgument, "%s must be between %g and %g %s", m.Type, r[0], r[1], m.Type.CanonicalUnit())
+	}
+	now := time.Now().UTC()
+	if m.MeasuredAt.IsZero() {
+		m.MeasuredAt = now
+	}
+	m.Name = uuid.New().String()
+	m.TenantID = p.TenantID
+	m.AthleteID = athleteID
+	m.Unit = m.Type.CanonicalUnit()
+	m.MeasuredAt = m.MeasuredAt.UTC()
+	m.CreateTime = now
+	if err := s.repo.CreateBodyMetric(ctx, m); err != nil {
+		return BodyMetric{}, err
+	}
+	return m, nil
+}
+
+func checkSite(t BodyMetricType, site string) error {
+	if t != CircumferenceMetric {
+		if site != "" {
+			return errors.Wrapf(ErrInvalidArgument, "%s is not measured at a site", t)
+		}
+		return nil
+	}
+	for _, s := range CircumferenceSites {
+		if s == site {
+			return nil
+		}
+	}
+	return errors.Wrapf(ErrInvalidArgument, "unknown circumference site %q", site)
+}
+
+// athleteTenant fails unless athleteID is an athlete of the caller's tenant,
+// and returns the caller and their tenant.
+func (s basicMetricsService) athleteTenant(ctx context.Context, athleteID string) (Principal, Tenant, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return Principal{}, Tenant{}, err
+	}
+	athlete, err := s.users.GetUser(ctx, athleteID)
+	if err != nil {
+		return Principal{}, Tenant{}, errors.Wrap(err, "failed to look up athlete")
+	}
+	if athlete.TenantID != p.TenantID || athlete.Role != RoleAthlete {
+		return Principal{}, Tenant{}, errors.Wrapf(ErrInvalidArgument, "user %s is not an athlete", athleteID)
+	}
+	t, err := s.tenants.GetTenant(ctx, p.TenantID)
+	if err != nil {
+		return Principal{}, Tenant{}, errors.Wrap(err, "failed to look up tenant")
+	}
+	return p, t, nil
+}
+
+// List returns an athlete's measurements, oldest first. They are converted to
+// unit when one is given, which requires filtering by a metric; bodyweight
+// is otherwise shown in the caller's preferred unit and every other metric in
+// its canonical unit.
+func (s basicMetricsService) List(ctx context.Context, athleteID string, f BodyMetricFilter, unit MeasurementUnit) ([]BodyMetric, error) {
+	p, t, err := s.athleteTenant(ctx, athleteID)
+	if err != nil {
+		return nil, err
+	}
+	if unit != "" && f.Type == "" {
+		return nil, errors.Wrap(ErrInvalidArgument, "a unit requires a metric to filter by")
+	}
+	metrics, err := s.repo.ListBodyMetrics(ctx, p.TenantID, athleteID, f)
+	if err != nil {
+		return nil, err
+	}
+	bodyweightUnit := unit
+	if bodyweightUnit == "" {
+		bodyweightUnit = preferredMass(p, t)
+	}
+	for i, m := range metrics {
+		to := unit
+		if m.Type == BodyweightMetric {
+			to = bodyweightUnit
+		}
+		if metrics[i], err = convertBodyMetric(m, to); err != nil {
+			return nil, err
+		}
+	}
+	return metrics, nil
+}
+
+// preferredMass returns the unit the caller prefers bodyweight in, which is
+// their tenant's default unless they asked for another.
+func preferredMass(p Principal, t Tenant) MeasurementUnit {
+	if p.Unit != "" {
+		return MeasurementUnit(p.Unit)
+	}
+	return MeasurementUnit(t.Settings.DefaultUnit)
+}
+
+// convertBodyMetric returns m in unit, or as it is without one.
+func convertBodyMetric(m BodyMetric, unit MeasurementUnit) (BodyMetric, error) {
+	if unit == "" || unit == m.Unit {
+		return m, nil
+	}
+	v, err := ConvertBodyMetric(m.Type, m.Value, m.Unit, unit)
+	if err != nil {
+		return BodyMetric{}, err
+	}
+	m.Value, m.Unit = v, unit
+	return m, nil
+}
+
+// Delete removes one of an athlete's measurements.
+func (s basicMetricsService) Delete(ctx context.Context, athleteID string, id string) error {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	m, err := s.repo.GetBodyMetric(ctx, id)
+	if err != nil {
+		return err
+	}
+	if m.TenantID != p.TenantID || m.AthleteID != athleteID {
+		return ErrNotFound
+	}
+	return s.repo.DeleteBodyMetric(ctx, id)
+}
+
+// TrendQuery selects the measurements a trend is drawn from. Window is the
+// number of days the rolling average spans, 7 when zero. Unit is as for List.
+type TrendQuery struct {
+	Type   BodyMetricType  `json:"type"`
+	Site   string          `json:"site"`
+	Start  time.Time       `json:"start"`
+	End    time.Time       `json:"end"`
+	Window int             `json:"window"`
+	Unit   MeasurementUnit `json:"unit"`
+}
+
+// TrendPoint is the mean of the measurements of one day of the tenant's
+// calendar, and the mean of the daily means of the Window days up to and
+// including it.
+type TrendPoint struct {
+	Date           time.Time `json:"date"`
+	Value          float64   `json:"value"`
+	RollingAverage float64   `json:"rollingAverage"`
+	Count          int       `json:"count"`
+}
+
+// MetricTrend is how a metric developed over a period. Change is the
+// difference between the last and first rolling averages, and WeeklyRate the
+// least-squares slope of the daily means per week.
+type MetricTrend struct {
+	Type       BodyMetricType  `json:"type"`
+	Site       string          `json:"site"`
+	Unit       MeasurementUnit `json:"unit"`
+	Points     []TrendPoint    `json:"points"`
+	Change     float64         `json:"change"`
+	WeeklyRate float64         `json:"weeklyRate"`
+}
+
+// The default span of a trend and its rolling average, and the longest
+// rolling average.
+const (
+	defaultTrendPeriod = 90 * 24 * time.Hour
+	defaultTrendWindow = 7
+	maxTrendWindow     = 90
+)
+
+// Trend returns the daily means and rolling average of one of an athlete's
+// metrics, with a point for every day it was measured. Without a start and
+// end it covers the last 90 days.
+func (s basicMetricsService) Trend(ctx context.Context, athleteID string, q TrendQuery) (MetricTrend, error) {
+	p, t, err := s.athleteTenant(ctx, athleteID)
+	if err != nil {
+		return MetricTrend{}, err
+	}
+	if _, ok := bodyMetricUnits[q.Type]; !ok {
+		return MetricTrend{}, errors.Wrapf(ErrInvalidArgument, "unknown body metric %q", q.Type)
+	}
+	if q.Window == 0 {
+		q.Window = defaultTrendWindow
+	}
+	if q.Window < 1 || q.Window > maxTrendWindow {
+		return MetricTrend{}, errors.Wrapf(ErrInvalidArgument, "the rolling window must be between 1 and %d days", maxTrendWindow)
+	}
+	if q.End.IsZero() {
+		q.End = time.Now()
+	}
+	if q.Start.IsZero() {
+		q.Start = q.End.Add(-defaultTrendPeriod)
+	}
+	if !q.Start.Before(q.End) {
+		return MetricTrend{}, errors.Wrap(ErrInvalidArgument, "start time must be before end time")
+	}
+	loc, err := time.LoadLocation(timeZone(t))
+	if err != nil {
+		return MetricTrend{}, errors.Wrapf(err, "failed to load time zone %q", timeZone(t))
+	}
+	if q.Unit == "" {
+		q.Unit = q.Type.CanonicalUnit()
+		if q.Type == BodyweightMetric {
+			q.Unit = preferredMass(p, t)
+		}
+	}
+	first := BucketDay.StartOf(q.Start, loc, t.Settings.WeekStart)
+	metrics, err := s.repo.ListBodyMetrics(ctx, p.TenantID, athleteID, BodyMetricFilter{
+		Type:  q.Type,
+		Site:  q.Site,
+		Start: first.AddDate(0, 0, -q.Window+1),
+		End:   q.End,
+	})
+	if err != nil {
+		return MetricTrend{}, err
+	}
+
+	var days []TrendPoint
+	for _, m := range metrics {
+		if m, err = convertBodyMetric(m, q.Unit); err != nil {
+			return MetricTrend{}, err
+		}
+		day := BucketDay.StartOf(m.MeasuredAt, loc, t.Settings.WeekStart)
+		if n := len(days); n > 0 && days[n-1].Date.Equal(day) {
+			days[n-1].Value += m.Value
+			days[n-1].Count++
+			continue
+		}
+		days = append(days, TrendPoint{Date: day, Value: m.Value, Count: 1})
+	}
+	trend := MetricTrend{Type: q.Type, Site: q.Site, Unit: q.Unit}
+	for i := range days {
+		days[i].Value /= float64(days[i].Count)
+		var sum float64
+		var n int
+		for j := i; j >= 0 && days[j].Date.After(days[i].Date.AddDate(0, 0, -q.Window)); j-- {
+			sum += days[j].Value
+			n++
+		}
+		days[i].RollingAverage = sum / float64(n)
+		if !days[i].Date.Before(first) {
+			trend.Points = append(trend.Points, days[i])
+		}
+	}
+	if n := len(trend.Points); n > 0 {
+		trend.Change = trend.Points[n-1].RollingAverage - trend.Points[0].RollingAverage
+		trend.WeeklyRate = weeklySlope(trend.Points)
+	}
+	return trend, nil
+}
+
+// weeklySlope fits a line through the daily means by least squares and
+// returns its slope per week.
+func weeklySlope(points []TrendPoint) float64 {
+	if len(points) < 2 {
+		return 0
+	}
+	var sx, sy, sxx, sxy float64
+	for _, p := range points {
+		x := p.Date.Sub(points[0].Date).Hours() / (24 * 7)
+		sx += x
+		sy += p.Value
+		sxx += x * x
+		sxy += x * p.Value
+	}
+	n := float64(len(points))
+	d := n*sxx - sx*sx
+	if d == 0 {
+		return 0
+	}
+	return (n*sxy - sx*sy) / d
+}
+
+// bodyweightAt returns an athlete's bodyweight at t from their bodyweight
+// measurements, oldest first: the mean of the week before t, else the last
+// before it, else the first after it, else zero.
+func bodyweightAt(bodyweights []BodyMetric, t time.Time) float64 {
+	i := sort.Search(len(bodyweights), func(i int) bool {
+		return bodyweights[i].MeasuredAt.After(t)
+	})
+	var sum float64
+	var n int
+	for j := i - 1; j >= 0 && !bodyweights[j].MeasuredAt.Before(t.AddDate(0, 0, -7)); j-- {
+		sum += bodyweights[j].Value
+		n++
+	}
+	switch {
+	case n > 0:
+		return sum / float64(n)
+	case i > 0:
+		return bodyweights[i-1].Value
+	case i < len(bodyweights):
+		return bodyweights[i].Value
+	}
+	return 0
+}
@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"workout-manager-service/inmem"
+	"workout-manager-service/pkg/service"
+)
+
+func TestRelativeStrengthScoresOneRepMaxes(t *testing.T) {
+	ctx := context.Background()
+	s := inmem.NewStore()
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	if err := s.CreateTenant(ctx, service.Tenant{Name: "t1"}, service.User{Name: "admin", TenantID: "t1", Role: service.RoleAdmin}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateUser(ctx, service.User{Name: "a1", TenantID: "t1", Email: "a1@example.com", Role: service.RoleAthlete}); err != nil {
+		t.Fatal(err)
+	}
+	err := s.CreateBodyMetric(ctx, service.BodyMetric{Name: "bw", TenantID: "t1", AthleteID: "a1", Type: service.BodyweightMetric, Value: 100, MeasuredAt: day})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, sets := range [][]service.WorkoutSet{
+		{
+			{MovementID: "squat", Reps: 1, Weight: service.Kilos(200)},
+			{MovementID: "bench", Reps: 1, Weight: service.Kilos(130)},
+			{MovementID: "deadlift", Reps: 1, Weight: service.Kilos(240)},
+		},
+		{
+			// 180 kg for 5 is an estimated 210 kg, more than the 200 kg single.
+			{MovementID: "squat", Reps: 5, Weight: service.Kilos(180)},
+			// The same weight for two outscores the single.
+			{MovementID: "bench", Reps: 2, Weight: service.Kilos(130)},
+			// Too many reps to estimate from, however heavy.
+			{MovementID: "deadlift", Reps: 15, Weight: service.Kilos(250)},
+		},
+	} {
+		_, err := s.CreateWorkout(ctx, service.Workout{
+			Name:        []string{"w1", "w2"}[i],
+			TenantID:    "t1",
+			AthleteID:   "a1",
+			PerformedAt: day.AddDate(0, 0, i+1),
+			Sets:        sets,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svc := service.NewBasicMetricsService(s, s, s, s)
+	ctx = service.NewContextWithPrincipal(ctx, service.Principal{UserID: "a1", TenantID: "t1", Role: service.RoleAthlete})
+	report, err := svc.RelativeStrength(ctx, "a1", service.RelativeStrengthQuery{
+		Sex:              service.SexMale,
+		TotalMovementIDs: []string{"squat", "bench", "deadlift"},
+		BenchMovementID:  "bench",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		movement, workout string
+		reps              int32
+		e1RM              float64
+	}{
+		{"deadlift", "w1", 1, 240},
+		{"squat", "w2", 5, 210},
+		{"bench", "w2", 2, 130 * (1 + 2.0/30)},
+	}
+	if len(report.Records) != len(want) {
+		t.Fatalf("records = %+v, want %d", report.Records, len(want))
+	}
+	for i, w := range want {
+		r := report.Records[i]
+		if r.MovementID != w.movement || r.WorkoutID != w.workout || r.Reps != w.reps || math.Abs(r.OneRepMax.Kilograms-w.e1RM) > 1e-9 {
+			t.Errorf("record %d = %s in %s for %d, e1RM %g, want %s in %s for %d, e1RM %g",
+				i, r.MovementID, r.WorkoutID, r.Reps, r.OneRepMax.Kilograms, w.movement, w.workout, w.reps, w.e1RM)
+		}
+		if math.Abs(r.Wilks-service.Wilks(service.SexMale, 100, w.e1RM)) > 1e-9 {
+			t.Errorf("%s Wilks = %g, want the score of its one-rep max", r.MovementID, r.Wilks)
+		}
+		if (r.IPFGL != 0) != (r.MovementID == "bench") {
+			t.Errorf("%s IPF GL = %g, want it for the bench press only", r.MovementID, r.IPFGL)
+		}
+	}
+
+	total := 240 + 210 + 130*(1+2.0/30)
+	if report.Total == nil || math.Abs(report.Total.OneRepMax.Kilograms-total) > 1e-9 || report.Total.Weight != report.Total.OneRepMax {
+		t.Fatalf("total = %+v, want %g kg", report.Total, total)
+	}
+	if !report.Total.PerformedAt.Equal(day.AddDate(0, 0, 2)) {
+		t.Errorf("total performed at %s, want its latest record", report.Total.PerformedAt)
+	}
+	if want := service.IPFGL(service.SexMale, false, false, 100, total); math.Abs(report.Total.IPFGL-want) > 1e-9 {
+		t.Errorf("total IPF GL = %g, want %g", report.Total.IPFGL, want)
+	}
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+
+	"workout-manager-service/logging"
+)
+
+type movementAuditService struct {
+	auditor
+	service MovementService
+}
+
+// NewMovementAuditService takes an AuditRepository as a dependency and returns
+// a MovementService that records every successful mutation.
+func NewMovementAuditService(logger logging.IshiLogger, repo AuditRepository, s MovementService) MovementService {
+	return movementAuditService{
+		auditor: newAuditor(logger, repo),
+		service: s,
+	}
+}
+
+func movementResource(id string) string {
+	return "movements/" + id
+}
+
+// Create records the movement that was created.
+func (as movementAuditService) Create(ctx context.Context, m Movement) (created Movement, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		if created, err = as.service.Create(ctx, m); err != nil {
+			return err
+		}
+		return as.record(ctx, "CreateMovement", movementResource(created.Name), nil, created)
+	})
+	return created, err
+}
+
+// Update records the movement before and after it was updated.
+func (as movementAuditService) Update(ctx context.Context, m Movement, etag string) (after Movement, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		before, _ := as.service.Get(ctx, m.Name, false)
+		if after, err = as.service.Update(ctx, m, etag); err != nil {
+			return err
+		}
+		return as.record(ctx, "UpdateMovement", movementResource(m.Name), before, after)
+	})
+	return after, err
+}
+
+// Get is not audited.
+func (as movementAuditService) Get(ctx context.Context, id string, showDeleted bool) (Movement, error) {
+	return as.service.Get(ctx, id, showDeleted)
+}
+
+// List is not audited.
+func (as movementAuditService) List(ctx context.Context, filter MovementFilter) ([]Movement, error) {
+	return as.service.List(ctx, filter)
+}
+
+// Search is not audited.
+func (as movementAuditService) Search(ctx context.Context, query string, limit int) ([]MovementMatch, error) {
+	return as.service.Search(ctx, query, limit)
+}
+
+// Delete records the movement as it was before it was deleted.
+func (as movementAuditService) Delete(ctx context.Context, id string, etag string) error {
+	return as.repo.InTx(ctx, func(ctx context.Context) error {
+		before, _ := as.service.Get(ctx, id, false)
+		if err := as.service.Delete(ctx, id, etag); err != nil {
+			return err
+		}
+		return as.record(ctx, "DeleteMovement", movementResource(id), before, nil)
+	})
+}
+
+// Undelete records the movement before and after it was restored.
+func (as movementAuditService) Undelete(ctx context.Context, id string) (restored Movement, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		before, _ := as.service.Get(ctx, id, true)
+		if restored, err = as.service.Undelete(ctx, id); err != nil {
+			return err
+		}
+		return as.record(ctx, "UndeleteMovement", movementResource(id), before, restored)
+	})
+	return restored, err
+}
+
+// BatchCreate records every movement that was created.
+func (as movementAuditService) BatchCreate(ctx context.Context, ms []Movement, partial bool) (results []MovementResult, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		if results, err = as.service.BatchCreate(ctx, ms, partial); err != nil {
+			return err
+		}
+		for _, r := range results {
+			if r.Err != nil {
+				continue
+			}
+			if err := as.record(ctx, "BatchCreateMovements", movementResource(r.Movement.Name), nil, r.Movement); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return results, err
+}
+
+// BatchGet is not audited.
+func (as movementAuditService) BatchGet(ctx context.Context, ids []string, showDeleted bool, partial bool) ([]MovementResult, error) {
+	return as.service.BatchGet(ctx, ids, showDeleted, partial)
+}
+
+// BatchDelete records every movement as it was before it was deleted.
+func (as movementAuditService) BatchDelete(ctx context.Context, deletions []MovementDeletion, partial bool) (results []MovementResult, err error) {
+	ids := make([]string, len(deletions))
+	for i, d := range deletions {
+		ids[i] = d.ID
+	}
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		before, _ := as.service.BatchGet(ctx, ids, false, true)
+		if results, err = as.service.BatchDelete(ctx, deletions, partial); err != nil {
+			return err
+		}
+		for i, r := range results {
+			if r.Err != nil {
+				continue
+			}
+			var snapshot interface{}
+			if i < len(before) && before[i].Err == nil {
+				snapshot = before[i].Movement
+			}
+			if err := as.record(ctx, "BatchDeleteMovements", movementResource(r.Movement.Name), snapshot, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return results, err
+}
+
+// Import records the report of every import that wrote something.
+func (as movementAuditService) Import(ctx context.Context, imp MovementImport) (report ImportReport, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		if report, err = as.service.Import(ctx, imp); err != nil || !report.Committed {
+			return err
+		}
+		return as.record(ctx, "ImportMovements", "movements", nil, report)
+	})
+	return report, err
+}
+
+// Export is not audited.
+func (as movementAuditService) Export(ctx context.Context, format MovementFormat, filter MovementFilter) ([]byte, error) {
+	return as.service.Export(ctx, format, filter)
+}
+
+// Override records the tenant's view of the movement before and after it was
+// overridden.
+func (as movementAuditService) Override(ctx context.Context, id string, hidden bool, alias string) (after Movement, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		before, _ := as.service.Get(ctx, id, false)
+		if after, err = as.service.Override(ctx, id, hidden, alias); err != nil {
+			return err
+		}
+		return as.record(ctx, "OverrideMovement", movementResource(id), before, after)
+	})
+	return after, err
+}
+
+// Fork records the fork that was created.
+func (as movementAuditService) Fork(ctx context.Context, id string) (fork Movement, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		if fork, err = as.service.Fork(ctx, id); err != nil {
+			return err
+		}
+		return as.record(ctx, "ForkMovement", movementResource(fork.Name), nil, fork)
+	})
+	return fork, err
+}
+
+// Merge records the canonical movement before and after the merge, and each
+// duplicate as it was before it was merged away.
+func (as movementAuditService) Merge(ctx context.Context, canonicalID string, duplicateIDs []string) (merged Movement, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		before, _ := as.service.Get(ctx, canonicalID, false)
+		duplicates := make([]Movement, 0, len(duplicateIDs))
+		for _, id := range duplicateIDs {
+			if dup, err := as.service.Get(ctx, id, true); err == nil {
+				duplicates = append(duplicates, dup)
+			}
+		}
+		if merged, err = as.service.Merge(ctx, canonicalID, duplicateIDs); err != nil {
+			return err
+		}
+		if err := as.record(ctx, "MergeMovements", movementResource(merged.Name), before, merged); err != nil {
+			return err
+		}
+		for _, dup := range duplicates {
+			if err := as.record(ctx, "MergeMovements", movementResource(dup.Name), dup, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return merged, err
+}
+
+// Watch is not audited.
+func (as movementAuditService) Watch(ctx context.Context, resumeToken string, send func(MovementEvent) error) error {
+	return as.service.Watch(ctx, resumeToken, send)
+}
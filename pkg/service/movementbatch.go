@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxBatchSize bounds the number of items a single batch call may carry.
+const maxBatchSize = 500
+
+// MovementResult is the outcome of one item of a batch call. Exactly one of
+// Movement and Err is set.
+type MovementResult struct {
+	Movement Movement `json:"movement"`
+	Err      error    `json:"-"`
+}
+
+// MovementDeletion identifies a movement to delete in a batch. Etag is
+// optional and checked the same way Delete checks it.
+type MovementDeletion struct {
+	ID   string `json:"id"`
+	Etag string `json:"etag"`
+}
+
+// BatchCreate creates several movements in one transaction. By default the
+// batch is all or nothing: the first invalid item fails the whole call and
+// nothing is written. With partial set, invalid items are reported in their
+// result and the rest are still created together.
+func (s basicMovementService) BatchCreate(ctx context.Context, ms []Movement, partial bool) ([]MovementResult, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBatchSize(len(ms)); err != nil {
+		return nil, err
+	}
+	results := make([]MovementResult, len(ms))
+	var (
+		valid []Movement
+		index []int
+	)
+	for i, m := range ms {
+		m, err := newMovement(p, m)
+		if err != nil {
+			if !partial {
+				return nil, errors.Wrapf(err, "movement %d", i)
+			}
+			results[i].Err = err
+			continue
+		}
+		valid = append(valid, m)
+		index = append(index, i)
+	}
+	if len(valid) > 0 {
+		created, err := s.repo.CreateMovements(ctx, valid)
+		if err != nil {
+			return nil, err
+		}
+		for j, m := range created {
+			results[index[j]].Movement = m
+		}
+	}
+	return results, nil
+}
+
+// BatchGet retrieves several movements the way Get does. By default a
+// movement that cannot be retrieved fails the whole call; with partial set
+// it is reported in its result instead.
+func (s basicMovementService) BatchGet(ctx context.Context, ids []string, showDeleted bool, partial bool) ([]MovementResult, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBatchSize(len(ids)); err != nil {
+		return nil, err
+	}
+	results := make([]MovementResult, len(ids))
+	for i, id := range ids {
+		m, err := s.get(ctx, p, id, showDeleted)
+		switch {
+		case err == nil:
+			results[i].Movement = m
+		case !partial:
+			return nil, errors.Wrapf(err, "movement %s", id)
+		case isDomainError(err):
+			results[i].Err = err
+		default:
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// BatchDelete soft deletes several movements in one transaction, applying the
+// same rules as Delete to each. By default the batch is all or nothing; with
+// partial set, movements that may not be deleted are reported in their result
+// and the rest are still deleted together. A movement that changes between
+// the checks and the write fails the whole call with ErrConflict either way.
+func (s basicMovementService) BatchDelete(ctx context.Context, deletions []MovementDeletion, partial bool) ([]MovementResult, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBatchSize(len(deletions)); err != nil {
+		return nil, err
+	}
+	results := make([]MovementResult, len(deletions))
+	seen := make(map[string]bool, len(deletions))
+	var (
+		valid []Movement
+		index []int
+	)
+	for i, d := range deletions {
+		m, err := s.ownMovement(ctx, p, d.ID)
+		switch {
+		case err == nil:
+			err = checkEtag(m, d.Etag)
+		case !isDomainError(err):
+			return nil, err
+		}
+		if err == nil && seen[d.ID] {
+			err = errors.Wrap(ErrInvalidArgument, "movement appears more than once in the batch")
+		}
+		if err != nil {
+			if !partial {
+				return nil, errors.Wrapf(err, "movement %s", d.ID)
+			}
+			results[i].Err = err
+			continue
+		}
+		seen[d.ID] = true
+		valid = append(valid, m)
+		index = append(index, i)
+	}
+	if len(valid) > 0 {
+		deleteTime := time.Now().UTC()
+		if err := s.repo.SetMovementsDeleteTime(ctx, valid, deleteTime); err != nil {
+			return nil, err
+		}
+		for j, m := range valid {
+			m.DeleteTime = deleteTime
+			m.Version++
+			results[index[j]].Movement = m
+		}
+	}
+	return results, nil
+}
+
+func checkBatchSize(n int) error {
+	switch {
+	case n == 0:
+		return errors.Wrap(ErrInvalidArgument, "a batch needs at least one item")
+	case n > maxBatchSize:
+		return errors.Wrapf(ErrInvalidArgument, "a batch may hold at most %d items", maxBatchSize)
+	}
+	return nil
+}
+
+// isDomainError reports whether err is one of the errors a caller can act
+// on, as opposed to a storage failure.
+func isDomainError(err error) bool {
+	switch errors.Cause(err) {
+	case ErrNotFound, ErrInvalidArgument, ErrPermissionDenied, ErrConflict:
+		return true
+	}
+	return false
+}
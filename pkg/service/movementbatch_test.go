@@ -0,0 +1,145 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/service"
+)
+
+func TestBatchCreateMovements(t *testing.T) {
+	s, ctx := newCatalogStore(t)
+	svc := service.NewBasicMovementService(s, nil)
+	batch := []service.Movement{
+		{MovementName: "Deadlift"},
+		{},
+		{MovementName: "Row", Equipment: "trampoline"},
+		{MovementName: "Press"},
+	}
+
+	before, _ := s.ListMovements(ctx, "t1", service.MovementFilter{})
+	if _, err := svc.BatchCreate(ctx, batch, false); errors.Cause(err) != service.ErrInvalidArgument {
+		t.Fatalf("BatchCreate() = %v, want %v", err, service.ErrInvalidArgument)
+	}
+	if after, _ := s.ListMovements(ctx, "t1", service.MovementFilter{}); len(after) != len(before) {
+		t.Fatalf("movements = %+v, want nothing created by a failed batch", after)
+	}
+
+	results, err := svc.BatchCreate(ctx, batch, true)
+	if err != nil || len(results) != len(batch) {
+		t.Fatalf("partial BatchCreate() = %+v, %v", results, err)
+	}
+	for i, want := range []string{"Deadlift", "", "", "Press"} {
+		r := results[i]
+		if want == "" {
+			if errors.Cause(r.Err) != service.ErrInvalidArgument {
+				t.Errorf("result %d = %+v, want %v", i, r, service.ErrInvalidArgument)
+			}
+			continue
+		}
+		if r.Err != nil || r.Movement.MovementName != want || r.Movement.TenantID != "t1" {
+			t.Errorf("result %d = %+v, want %s created for t1", i, r, want)
+			continue
+		}
+		if got, err := s.GetMovement(ctx, r.Movement.Name); err != nil || got.MovementName != want {
+			t.Errorf("stored %s = %+v, %v", r.Movement.Name, got, err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name  string
+		ctx   context.Context
+		batch []service.Movement
+		err   error
+	}{
+		{"empty batch", ctx, nil, service.ErrInvalidArgument},
+		{"oversized batch", ctx, make([]service.Movement, 501), service.ErrInvalidArgument},
+		{"no caller", context.Background(), batch[:1], service.ErrUnauthenticated},
+	} {
+		if _, err := svc.BatchCreate(tc.ctx, tc.batch, true); errors.Cause(err) != tc.err {
+			t.Errorf("%s: BatchCreate() = %v, want %v", tc.name, err, tc.err)
+		}
+	}
+}
+
+func TestBatchGetMovements(t *testing.T) {
+	s, ctx := newCatalogStore(t)
+	svc := service.NewBasicMovementService(s, nil)
+	ids := []string{"squat", "nope", "bench"}
+
+	if _, err := svc.BatchGet(ctx, ids, false, false); errors.Cause(err) != service.ErrNotFound {
+		t.Errorf("BatchGet() = %v, want %v", err, service.ErrNotFound)
+	}
+	results, err := svc.BatchGet(ctx, ids, false, true)
+	if err != nil || len(results) != len(ids) {
+		t.Fatalf("partial BatchGet() = %+v, %v", results, err)
+	}
+	if results[0].Movement.Name != "squat" || results[2].Movement.Name != "bench" {
+		t.Errorf("results = %+v, want the squat and the global bench press", results)
+	}
+	if errors.Cause(results[1].Err) != service.ErrNotFound {
+		t.Errorf("unknown movement = %+v, want %v", results[1], service.ErrNotFound)
+	}
+
+	if err := svc.Delete(ctx, "squat", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.BatchGet(ctx, []string{"squat"}, false, false); errors.Cause(err) != service.ErrNotFound {
+		t.Errorf("BatchGet() of a deleted movement = %v, want %v", err, service.ErrNotFound)
+	}
+	if results, err := svc.BatchGet(ctx, []string{"squat"}, true, false); err != nil || !results[0].Movement.Deleted() {
+		t.Errorf("BatchGet() showing deleted = %+v, %v, want the deleted squat", results, err)
+	}
+	if _, err := svc.BatchGet(ctx, nil, false, true); errors.Cause(err) != service.ErrInvalidArgument {
+		t.Errorf("BatchGet() of nothing = %v, want %v", err, service.ErrInvalidArgument)
+	}
+}
+
+func TestBatchDeleteMovements(t *testing.T) {
+	s, ctx := newCatalogStore(t)
+	svc := service.NewBasicMovementService(s, nil)
+	press, err := svc.Create(ctx, service.Movement{MovementName: "Press"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deletions := []service.MovementDeletion{
+		{ID: "squat"},
+		{ID: "bench"},
+		{ID: "nope"},
+		{ID: press.Name, Etag: "stale"},
+		{ID: "squat"},
+	}
+
+	if _, err := svc.BatchDelete(ctx, deletions, false); errors.Cause(err) != service.ErrPermissionDenied {
+		t.Fatalf("BatchDelete() = %v, want %v", err, service.ErrPermissionDenied)
+	}
+	if squat, _ := s.GetMovement(ctx, "squat"); squat.Deleted() {
+		t.Fatalf("squat was deleted by a failed batch")
+	}
+
+	results, err := svc.BatchDelete(ctx, deletions, true)
+	if err != nil || len(results) != len(deletions) {
+		t.Fatalf("partial BatchDelete() = %+v, %v", results, err)
+	}
+	if !results[0].Movement.Deleted() || results[0].Err != nil {
+		t.Errorf("squat = %+v, want it deleted", results[0])
+	}
+	// The global, unknown, stale and repeated movements are each rejected.
+	for i, want := range []error{service.ErrPermissionDenied, service.ErrNotFound, service.ErrConflict, service.ErrInvalidArgument} {
+		if r := results[i+1]; errors.Cause(r.Err) != want {
+			t.Errorf("result %d = %+v, want %v", i+1, r, want)
+		}
+	}
+	if squat, _ := s.GetMovement(ctx, "squat"); !squat.Deleted() {
+		t.Errorf("squat = %+v, want it deleted", squat)
+	}
+	if got, _ := s.GetMovement(ctx, press.Name); got.Deleted() {
+		t.Errorf("press with a stale etag was deleted")
+	}
+
+	if results, err := svc.BatchDelete(ctx, []service.MovementDeletion{{ID: press.Name, Etag: press.Etag()}}, false); err != nil || !results[0].Movement.Deleted() {
+		t.Errorf("BatchDelete() with the current etag = %+v, %v", results, err)
+	}
+}
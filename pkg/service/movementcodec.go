@@ -0,0 +1,312 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MovementFormat is a serialization of the movement catalog used by bulk
+// import and export.
+type MovementFormat string
+
+// The formats the movement catalog can be imported from and exported to.
+// NDJSON holds one JSON object per line.
+const (
+	CSVFormat    MovementFormat = "csv"
+	NDJSONFormat MovementFormat = "ndjson"
+)
+
+// The columns of an exported catalog. Imports recognize them by default and
+// map other headers onto them. List columns hold several values, separated
+// by listSeparator in CSV and as arrays in NDJSON.
+const (
+	ColumnID               = "id"
+	ColumnName             = "name"
+	ColumnCategory         = "category"
+	ColumnEquipment        = "equipment"
+	ColumnPrimaryMuscles   = "primary_muscles"
+	ColumnSecondaryMuscles = "secondary_muscles"
+	ColumnLaterality       = "laterality"
+	ColumnLoadType         = "load_type"
+	ColumnLinks            = "links"
+	ColumnAliases          = "aliases"
+)
+
+var movementColumns = []string{
+	ColumnID, ColumnName, ColumnCategory, ColumnEquipment, ColumnPrimaryMuscles,
+	ColumnSecondaryMuscles, ColumnLaterality, ColumnLoadType, ColumnLinks, ColumnAliases,
+}
+
+var listColumns = map[string]bool{
+	ColumnPrimaryMuscles:   true,
+	ColumnSecondaryMuscles: true,
+	ColumnLinks:            true,
+	ColumnAliases:          true,
+}
+
+const listSeparator = ";"
+
+// movementRecord is one row of an import, keyed by movement column. Row
+// counts CSV data rows from 1, not counting the header, and NDJSON lines.
+type movementRecord struct {
+	row    int
+	fields map[string][]string
+}
+
+func (r movementRecord) value(column string) string {
+	if v := r.fields[column]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// movement builds the movement a record describes. Enumerations are matched
+// case-insensitively; validation is left to the service.
+func (r movementRecord) movement() Movement {
+	lower := func(column string) string {
+		return strings.ToLower(r.value(column))
+	}
+	muscles := func(column string) []MuscleGroup {
+		var groups []MuscleGroup
+		for _, v := range r.fields[column] {
+			groups = append(groups, MuscleGroup(strings.ToLower(v)))
+		}
+		return groups
+	}
+	return Movement{
+		Name:               r.value(ColumnID),
+		MovementName:       r.value(ColumnName),
+		MovementCategoryID: r.value(ColumnCategory),
+		Equipment:          Equipment(lower(ColumnEquipment)),
+		PrimaryMuscles:     muscles(ColumnPrimaryMuscles),
+		SecondaryMuscles:   muscles(ColumnSecondaryMuscles),
+		Laterality:         Laterality(lower(ColumnLaterality)),
+		LoadType:           LoadType(lower(ColumnLoadType)),
+		Links:              r.fields[ColumnLinks],
+		Aliases:            r.fields[ColumnAliases],
+	}
+}
+
+// columnMapper resolves source headers to movement columns. Headers are
+// compared ignoring case, surrounding space and the choice of space, dash or
+// underscore between words. Headers that map to nothing are ignored so that
+// spreadsheets may carry notes of their own.
+type columnMapper map[string]string
+
+func newColumnMapper(mapping map[string]string) (columnMapper, error) {
+	known := make(map[string]bool, len(movementColumns))
+	m := make(columnMapper, len(movementColumns)+len(mapping))
+	for _, c := range movementColumns {
+		known[c] = true
+		m[normalizeHeader(c)] = c
+	}
+	for from, to := range mapping {
+		to = normalizeHeader(to)
+		if !known[to] {
+			return nil, errors.Wrapf(ErrInvalidArgument, "header %q is mapped to unknown column %q", from, to)
+		}
+		m[normalizeHeader(from)] = to
+	}
+	return m, nil
+}
+
+func (m columnMapper) column(header string) string {
+	return m[normalizeHeader(header)]
+}
+
+var headerReplacer = strings.NewReplacer(" ", "_", "-", "_")
+
+func normalizeHeader(h string) string {
+	return headerReplacer.Replace(strings.ToLower(strings.TrimSpace(h)))
+}
+
+// decodeMovements parses an import. Structural problems, such as a CSV
+// without a name column, fail the whole import; problems with individual
+// values are left for validation to report per row.
+func decodeMovements(format MovementFormat, data []byte, mapping map[string]string) ([]movementRecord, error) {
+	columns, err := newColumnMapper(mapping)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case CSVFormat:
+		return decodeCSV(data, columns)
+	case NDJSONFormat:
+		return decodeNDJSON(data, columns)
+	}
+	return nil, errors.Wrapf(ErrInvalidArgument, "unknown format %q", format)
+}
+
+func decodeCSV(data []byte, columns columnMapper) ([]movementRecord, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, errors.Wrap(ErrInvalidArgument, "import is empty")
+	}
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidArgument, "invalid CSV header: %v", err)
+	}
+	targets := make([]string, len(header))
+	named := false
+	for i, h := range header {
+		targets[i] = columns.column(h)
+		named = named || targets[i] == ColumnName
+	}
+	if !named {
+		return nil, errors.Wrap(ErrInvalidArgument, "no header maps to the name column")
+	}
+	var records []movementRecord
+	for row := 1; ; row++ {
+		cells, err := r.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidArgument, "invalid CSV: %v", err)
+		}
+		rec := movementRecord{row: row, fields: make(map[string][]string)}
+		for i, cell := range cells {
+			if i >= len(targets) || targets[i] == "" {
+				continue
+			}
+			rec.fields[targets[i]] = splitCell(targets[i], cell)
+		}
+		records = append(records, rec)
+	}
+}
+
+func decodeNDJSON(data []byte, columns columnMapper) ([]movementRecord, error) {
+	var records []movementRecord
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Buffer(make([]byte, 64*1024), len(data)+1)
+	for row := 1; s.Scan(); row++ {
+		line := bytes.TrimSpace(s.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal(line, &obj); err != nil {
+			return nil, errors.Wrapf(ErrInvalidArgument, "invalid JSON on line %d: %v", row, err)
+		}
+		rec := movementRecord{row: row, fields: make(map[string][]string)}
+		for key, v := range obj {
+			column := columns.column(key)
+			if column == "" {
+				continue
+			}
+			switch v := v.(type) {
+			case nil:
+			case []interface{}:
+				for _, item := range v {
+					if s := strings.TrimSpace(fmt.Sprint(item)); s != "" {
+						rec.fields[column] = append(rec.fields[column], s)
+					}
+				}
+			default:
+				rec.fields[column] = splitCell(column, fmt.Sprint(v))
+			}
+		}
+		records = append(records, rec)
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrapf(ErrInvalidArgument, "invalid NDJSON: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.Wrap(ErrInvalidArgument, "import is empty")
+	}
+	return records, nil
+}
+
+// splitCell splits list columns on listSeparator and trims every value.
+func splitCell(column string, cell string) []string {
+	values := []string{cell}
+	if listColumns[column] {
+		values = strings.Split(cell, listSeparator)
+	}
+	var trimmed []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			trimmed = append(trimmed, v)
+		}
+	}
+	return trimmed
+}
+
+// encodeMovements writes movements in the given format, using the columns
+// an import recognizes by default.
+func encodeMovements(w io.Writer, format MovementFormat, ms []Movement) error {
+	switch format {
+	case CSVFormat:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(movementColumns); err != nil {
+			return errors.Wrap(err, "failed to write CSV header")
+		}
+		for _, m := range ms {
+			fields := movementFields(m)
+			row := make([]string, len(movementColumns))
+			for i, c := range movementColumns {
+				row[i] = strings.Join(fields[c], listSeparator)
+			}
+			if err := cw.Write(row); err != nil {
+				return errors.Wrap(err, "failed to write CSV row")
+			}
+		}
+		cw.Flush()
+		return errors.Wrap(cw.Error(), "failed to write CSV")
+	case NDJSONFormat:
+		enc := json.NewEncoder(w)
+		for _, m := range ms {
+			fields := movementFields(m)
+			obj := make(map[string]interface{}, len(fields))
+			for c, v := range fields {
+				if listColumns[c] {
+					obj[c] = v
+				} else if len(v) > 0 {
+					obj[c] = v[0]
+				}
+			}
+			if err := enc.Encode(obj); err != nil {
+				return errors.Wrap(err, "failed to write NDJSON row")
+			}
+		}
+		return nil
+	}
+	return errors.Wrapf(ErrInvalidArgument, "unknown format %q", format)
+}
+
+func movementFields(m Movement) map[string][]string {
+	muscles := func(groups []MuscleGroup) []string {
+		ss := make([]string, len(groups))
+		for i, g := range groups {
+			ss[i] = string(g)
+		}
+		return ss
+	}
+	strs := func(ss []string) []string {
+		if ss == nil {
+			return []string{}
+		}
+		return ss
+	}
+	return map[string][]string{
+		ColumnID:               {m.Name},
+		ColumnName:             {m.MovementName},
+		ColumnCategory:         {m.MovementCategoryID},
+		ColumnEquipment:        {string(m.Equipment)},
+		ColumnPrimaryMuscles:   muscles(m.PrimaryMuscles),
+		ColumnSecondaryMuscles: muscles(m.SecondaryMuscles),
+		ColumnLaterality:       {string(m.Laterality)},
+		ColumnLoadType:         {string(m.LoadType)},
+		ColumnLinks:            strs(m.Links),
+		ColumnAliases:          strs(m.Aliases),
+	}
+}
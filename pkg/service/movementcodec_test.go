@@ -0,0 +1,103 @@
+package service
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestMovementCodecRoundTrip(t *testing.T) {
+	ms := []Movement{
+		{
+			Name:               "squat",
+			MovementName:       "Back Squat",
+			MovementCategoryID: "legs",
+			Equipment:          Barbell,
+			PrimaryMuscles:     []MuscleGroup{"quadriceps", "glutes"},
+			SecondaryMuscles:   []MuscleGroup{"hamstrings"},
+			Laterality:         Bilateral,
+			LoadType:           ExternalLoad,
+			Links:              []string{"https://example.com/squat?a=1;b=2"},
+			Aliases:            []string{"Squat, \"high bar\"", "BS"},
+		},
+		{Name: "plank", MovementName: "Plank", LoadType: TimeLoad},
+	}
+	for _, format := range []MovementFormat{CSVFormat, NDJSONFormat} {
+		var buf bytes.Buffer
+		if err := encodeMovements(&buf, format, ms); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		records, err := decodeMovements(format, buf.Bytes(), nil)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if len(records) != len(ms) {
+			t.Fatalf("%s: decoded %d records, want %d", format, len(records), len(ms))
+		}
+		for i, rec := range records {
+			want := ms[i]
+			if format == CSVFormat {
+				// CSV splits list cells on semicolons, even within a link.
+				want.Links = nil
+				for _, l := range ms[i].Links {
+					want.Links = append(want.Links, splitCell(ColumnLinks, l)...)
+				}
+			}
+			if got := rec.movement(); !reflect.DeepEqual(movementFields(got), movementFields(want)) {
+				t.Errorf("%s: row %d = %+v, want %+v", format, rec.row, got, want)
+			}
+			if rec.row != i+1 {
+				t.Errorf("%s: record %d is row %d", format, i, rec.row)
+			}
+		}
+	}
+}
+
+func TestDecodeMovementsMapsHeaders(t *testing.T) {
+	mapping := map[string]string{"Exercise": "name", "Muscles": "Primary Muscles", "Also known as": "aliases"}
+	for _, tc := range []struct {
+		format MovementFormat
+		data   string
+	}{
+		{CSVFormat, "Exercise,Muscles,Load-Type,Equipment , Also known as,Notes\nPull-Up,LATS; biceps,BODYWEIGHT,Bodyweight,Chin-Up;,my favourite\n"},
+		{NDJSONFormat, "\n{\"Exercise\":\"Pull-Up\",\"Muscles\":[\"LATS\",\" biceps\"],\"load type\":\"BODYWEIGHT\",\"equipment\":\"Bodyweight\",\"Also known as\":\"Chin-Up;\",\"Notes\":\"my favourite\"}\n"},
+	} {
+		records, err := decodeMovements(tc.format, []byte(tc.data), mapping)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.format, err)
+		}
+		want := Movement{
+			MovementName:   "Pull-Up",
+			PrimaryMuscles: []MuscleGroup{"lats", "biceps"},
+			LoadType:       BodyweightLoad,
+			Equipment:      Bodyweight,
+			Aliases:        []string{"Chin-Up"},
+		}
+		if len(records) != 1 || !reflect.DeepEqual(records[0].movement(), want) {
+			t.Errorf("%s: records = %+v, want %+v", tc.format, records, want)
+		}
+	}
+}
+
+func TestDecodeMovementsErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		format  MovementFormat
+		data    string
+		mapping map[string]string
+	}{
+		{"unknown format", "xlsx", "name\nSquat\n", nil},
+		{"empty CSV", CSVFormat, "", nil},
+		{"CSV without a name column", CSVFormat, "exercise,equipment\nSquat,barbell\n", nil},
+		{"malformed CSV", CSVFormat, "name\n\"Squat\n", nil},
+		{"mapping to an unknown column", CSVFormat, "exercise\nSquat\n", map[string]string{"exercise": "title"}},
+		{"empty NDJSON", NDJSONFormat, "\n\n", nil},
+		{"malformed NDJSON", NDJSONFormat, "{\"name\":\"Squat\"}\n{name: Bench}\n", nil},
+	} {
+		if _, err := decodeMovements(tc.format, []byte(tc.data), tc.mapping); errors.Cause(err) != ErrInvalidArgument {
+			t.Errorf("%s: decodeMovements() = %v, want %v", tc.name, err, ErrInvalidArgument)
+		}
+	}
+}
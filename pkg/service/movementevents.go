@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MovementEventType says how a movement changed.
+type MovementEventType string
+
+// The kinds of movement events. Created and updated events both carry the
+// movement as it now is, so watchers may treat them alike; deleted events
+// carry it as it was. A bookmark carries no movement, only a resume token:
+// it is sent first on a fresh watch so that clients have a token before
+// anything changes.
+const (
+	MovementCreated  MovementEventType = "created"
+	MovementUpdated  MovementEventType = "updated"
+	MovementDeleted  MovementEventType = "deleted"
+	MovementBookmark MovementEventType = "bookmark"
+)
+
+// MovementEvent is a change to the movement catalog as one tenant sees it.
+// Events of the SystemTenantID are changes to the global catalog and are
+// delivered to every tenant. ResumeToken is assigned by the EventBus and
+// resumes a watch right after the event.
+type MovementEvent struct {
+	Type        MovementEventType `json:"type"`
+	TenantID    string            `json:"tenantId"`
+	Movement    Movement          `json:"movement"`
+	Time        time.Time         `json:"time"`
+	ResumeToken string            `json:"resumeToken"`
+}
+
+// EventBus fans movement events out to the watchers of every tenant. It
+// keeps a bounded history of events so that a watcher which reconnects with
+// the resume token of the last event it saw misses nothing; Subscribe fails
+// with ErrExpired once a token has fallen out of that history.
+type EventBus interface {
+	Publish(ctx context.Context, events ...MovementEvent)
+	Subscribe(ctx context.Context, tenantID string, resumeToken string) (Subscription, error)
+}
+
+// Subscription is a tenant's feed of movement events. Next blocks until an
+// event arrives or ctx is done, and fails with ErrExpired when the
+// subscriber fell so far behind that events were dropped. ResumeToken
+// resumes right where the subscription started.
+type Subscription interface {
+	Next(ctx context.Context) (MovementEvent, error)
+	ResumeToken() string
+	Close()
+}
+
+// Watch streams changes to the caller's view of the catalog to send until
+// ctx is done or send fails. Without a resume token the watch starts with a
+// bookmark and then follows live changes; with one it first replays what
+// happened since. Changes to global movements the caller has hidden or
+// forked are left out, and the rest are shown with the caller's overrides
+// applied.
+func (s basicMovementService) Watch(ctx context.Context, resumeToken string, send func(MovementEvent) error) error {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if s.events == nil {
+		return errors.New("movement events are not published")
+	}
+	sub, err := s.events.Subscribe(ctx, p.TenantID, resumeToken)
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+	if resumeToken == "" {
+		if err := send(MovementEvent{Type: MovementBookmark, TenantID: p.TenantID, Time: time.Now().UTC(), ResumeToken: sub.ResumeToken()}); err != nil {
+			return err
+		}
+	}
+	for {
+		e, err := sub.Next(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if e.TenantID == SystemTenantID {
+			view, err := s.resolveGlobal(ctx, p.TenantID, e.Movement, true)
+			if err != nil {
+				return err
+			}
+			if view.Name != e.Movement.Name || view.Hidden {
+				continue
+			}
+			e.Movement = view
+		}
+		if err := send(e); err != nil {
+			return err
+		}
+	}
+}
+
+type movementEventService struct {
+	events  EventBus
+	service MovementService
+}
+
+// NewMovementEventService takes an EventBus as a dependency and returns a
+// MovementService that publishes every successful change to the catalog.
+// Events are published after the change is stored, so a crash in between
+// loses them; watchers that must not miss a change should re-list after
+// reconnecting when their resume token has expired.
+func NewMovementEventService(events EventBus, s MovementService) MovementService {
+	return movementEventService{events: events, service: s}
+}
+
+// publish publishes events of the given type for movements written by the
+// caller. Movements of the global catalog are published to every tenant.
+func (es movementEventService) publish(ctx context.Context, t MovementEventType, ms ...Movement) {
+	events := make([]MovementEvent, len(ms))
+	for i, m := range ms {
+		events[i] = MovementEvent{Type: t, TenantID: m.TenantID, Movement: m}
+	}
+	es.events.Publish(ctx, events...)
+}
+
+// publishView publishes an event for a change to the caller's own view of a
+// movement, such as an override of a global one.
+func (es movementEventService) publishView(ctx context.Context, t MovementEventType, m Movement) {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return
+	}
+	es.events.Publish(ctx, MovementEvent{Type: t, TenantID: p.TenantID, Movement: m})
+}
+
+// Create publishes the movement that was created.
+func (es movementEventService) Create(ctx context.Context, m Movement) (Movement, error) {
+	created, err := es.service.Create(ctx, m)
+	if err == nil {
+		es.publish(ctx, MovementCreated, created)
+	}
+	return created, err
+}
+
+// Update publishes the movement as it was updated.
+func (es movementEventService) Update(ctx context.Context, m Movement, etag string) (Movement, error) {
+	updated, err := es.service.Update(ctx, m, etag)
+	if err == nil {
+		es.publish(ctx, MovementUpdated, updated)
+	}
+	return updated, err
+}
+
+// Get publishes nothing.
+func (es movementEventService) Get(ctx context.Context, id string, showDeleted bool) (Movement, error) {
+	return es.service.Get(ctx, id, showDeleted)
+}
+
+// List publishes nothing.
+func (es movementEventService) List(ctx context.Context, filter MovementFilter) ([]Movement, error) {
+	return es.service.List(ctx, filter)
+}
+
+// Search publishes nothing.
+func (es movementEventService) Search(ctx context.Context, query string, limit int) ([]MovementMatch, error) {
+	return es.service.Search(ctx, query, limit)
+}
+
+// Delete publishes the movement as it was before it was deleted.
+func (es movementEventService) Delete(ctx context.Context, id string, etag string) error {
+	before, _ := es.service.Get(ctx, id, false)
+	err := es.service.Delete(ctx, id, etag)
+	if err == nil && before.Name != "" {
+		es.publish(ctx, MovementDeleted, before)
+	}
+	return err
+}
+
+// Undelete publishes the restored movement as created, since it reappears
+// in listings.
+func (es movementEventService) Undelete(ctx context.Context, id string) (Movement, error) {
+	restored, err := es.service.Undelete(ctx, id)
+	if err == nil {
+		es.publish(ctx, MovementCreated, restored)
+	}
+	return restored, err
+}
+
+// BatchCreate publishes every movement that was created.
+func (es movementEventService) BatchCreate(ctx context.Context, ms []Movement, partial bool) ([]MovementResult, error) {
+	results, err := es.service.BatchCreate(ctx, ms, partial)
+	es.publish(ctx, MovementCreated, succeeded(results)...)
+	return results, err
+}
+
+// BatchGet publishes nothing.
+func (es movementEventService) BatchGet(ctx context.Context, ids []string, showDeleted bool, partial bool) ([]MovementResult, error) {
+	return es.service.BatchGet(ctx, ids, showDeleted, partial)
+}
+
+// BatchDelete publishes every movement that was deleted.
+func (es movementEventService) BatchDelete(ctx context.Context, deletions []MovementDeletion, partial bool) ([]MovementResult, error) {
+	results, err := es.service.BatchDelete(ctx, deletions, partial)
+	es.publish(ctx, MovementDeleted, succeeded(results)...)
+	return results, err
+}
+
+// succeeded collects the movements of the results that did not fail.
+func succeeded(results []MovementResult) []Movement {
+	var ms []Movement
+	for _, r := range results {
+		if r.Err == nil && r.Movement.Name != "" {
+			ms = append(ms, r.Movement)
+		}
+	}
+	return ms
+}
+
+// Import publishes every movement a committed import created or updated.
+func (es movementEventService) Import(ctx context.Context, imp MovementImport) (ImportReport, error) {
+	report, err := es.service.Import(ctx, imp)
+	if err != nil || !report.Committed {
+		return report, err
+	}
+	for _, row := range report.Rows {
+		t := MovementCreated
+		switch row.Action {
+		case ImportCreated:
+		case ImportUpdated:
+			t = MovementUpdated
+		default:
+			continue
+		}
+		if m, err := es.service.Get(ctx, row.MovementID, false); err == nil {
+			es.publish(ctx, t, m)
+		}
+	}
+	return report, nil
+}
+
+// Export publishes nothing.
+func (es movementEventService) Export(ctx context.Context, format MovementFormat, filter MovementFilter) ([]byte, error) {
+	return es.service.Export(ctx, format, filter)
+}
+
+// Override publishes the caller's new view of the movement. Hiding a
+// movement removes it from the caller's listings and so is published as a
+// deletion; showing it again as a creation.
+func (es movementEventService) Override(ctx context.Context, id string, hidden bool, alias string) (Movement, error) {
+	before, _ := es.service.Get(ctx, id, false)
+	after, err := es.service.Override(ctx, id, hidden, alias)
+	if err != nil {
+		return after, err
+	}
+	switch {
+	case after.Hidden && !before.Hidden:
+		es.publishView(ctx, MovementDeleted, after)
+	case !after.Hidden && before.Hidden:
+		es.publishView(ctx, MovementCreated, after)
+	case !after.Hidden:
+		es.publishView(ctx, MovementUpdated, after)
+	}
+	return after, nil
+}
+
+// Fork publishes the fork as created and the global movement it replaces in
+// the caller's view as deleted.
+func (es movementEventService) Fork(ctx context.Context, id string) (Movement, error) {
+	before, _ := es.service.Get(ctx, id, false)
+	fork, err := es.service.Fork(ctx, id)
+	if err != nil {
+		return fork, err
+	}
+	es.publish(ctx, MovementCreated, fork)
+	if before.Name == id && !before.Hidden {
+		es.publishView(ctx, MovementDeleted, before)
+	}
+	return fork, nil
+}
+
+// Merge publishes the canonical movement as updated and each duplicate as
+// deleted. The canonical movement may be global, but only the caller's view
+// of it changed.
+func (es movementEventService) Merge(ctx context.Context, canonicalID string, duplicateIDs []string) (Movement, error) {
+	duplicates := make([]Movement, 0, len(duplicateIDs))
+	for _, id := range duplicateIDs {
+		if dup, err := es.service.Get(ctx, id, false); err == nil {
+			duplicates = append(duplicates, dup)
+		}
+	}
+	merged, err := es.service.Merge(ctx, canonicalID, duplicateIDs)
+	if err != nil {
+		return merged, err
+	}
+	es.publishView(ctx, MovementUpdated, merged)
+	es.publish(ctx, MovementDeleted, duplicates...)
+	return merged, nil
+}
+
+// Watch passes straight through.
+func (es movementEventService) Watch(ctx context.Context, resumeToken string, send func(MovementEvent) error) error {
+	return es.service.Watch(ctx, resumeToken, send)
+}
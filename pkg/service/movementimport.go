@@ -0,0 +1,209 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// ConflictPolicy says what an import does with a row that matches a movement
+// the caller can already see, by ID or by name.
+type ConflictPolicy string
+
+// The conflict policies an import may use. Overwriting replaces the
+// existing movement's fields with the row's; it is only possible for the
+// caller's own movements, so global movements have to be forked first. Rows
+// that would not change anything are skipped.
+const (
+	FailOnConflict     ConflictPolicy = "fail"
+	SkipConflicts      ConflictPolicy = "skip"
+	OverwriteConflicts ConflictPolicy = "overwrite"
+)
+
+// MaxImportSize bounds the size in bytes of a single import.
+const MaxImportSize = 16 << 20
+
+// MovementImport describes a bulk import. Columns maps headers of the source
+// to movement columns, for headers that do not already name one. Policy
+// defaults to FailOnConflict. Rows with an ID must refer to a movement the
+// caller can see, so an export is imported into another tenant without its
+// ID column.
+type MovementImport struct {
+	Format  MovementFormat    `json:"format"`
+	Columns map[string]string `json:"columns"`
+	Policy  ConflictPolicy    `json:"policy"`
+	DryRun  bool              `json:"dryRun"`
+	Data    []byte            `json:"-"`
+}
+
+// ImportAction is what an import did, or would do, with one row.
+type ImportAction string
+
+// The outcomes of an imported row.
+const (
+	ImportCreated  ImportAction = "created"
+	ImportUpdated  ImportAction = "updated"
+	ImportSkipped  ImportAction = "skipped"
+	ImportRejected ImportAction = "rejected"
+)
+
+// ImportRow reports the outcome of one row of an import. MovementID is the
+// movement that was, or would be, created or updated; Err explains a
+// rejection.
+type ImportRow struct {
+	Row        int          `json:"row"`
+	Name       string       `json:"name"`
+	MovementID string       `json:"movementId"`
+	Action     ImportAction `json:"action"`
+	Err        string       `json:"error,omitempty"`
+}
+
+// ImportReport summarizes an import. Nothing is written unless every row is
+// accepted and the import is not a dry run; Committed reports whether the
+// changes were written.
+type ImportReport struct {
+	DryRun    bool        `json:"dryRun"`
+	Committed bool        `json:"committed"`
+	Created   int         `json:"created"`
+	Updated   int         `json:"updated"`
+	Skipped   int         `json:"skipped"`
+	Rejected  int         `json:"rejected"`
+	Rows      []ImportRow `json:"rows"`
+}
+
+func (r *ImportReport) add(row ImportRow) {
+	switch row.Action {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	case ImportSkipped:
+		r.Skipped++
+	case ImportRejected:
+		r.Rejected++
+	}
+	r.Rows = append(r.Rows, row)
+}
+
+// Import validates a bulk import against the caller's view of the catalog
+// and, unless it is a dry run or a row was rejected, writes it. New
+// movements and overwrites are written in a single transaction, which fails
+// with ErrConflict if an overwritten movement changes concurrently.
+func (s basicMovementService) Import(ctx context.Context, imp MovementImport) (ImportReport, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	switch imp.Policy {
+	case "":
+		imp.Policy = FailOnConflict
+	case FailOnConflict, SkipConflicts, OverwriteConflicts:
+	default:
+		return ImportReport{}, errors.Wrapf(ErrInvalidArgument, "unknown conflict policy %q", imp.Policy)
+	}
+	if len(imp.Data) > MaxImportSize {
+		return ImportReport{}, errors.Wrapf(ErrInvalidArgument, "imports are limited to %d bytes", MaxImportSize)
+	}
+	records, err := decodeMovements(imp.Format, imp.Data, imp.Columns)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	existing, err := s.List(ctx, MovementFilter{})
+	if err != nil {
+		return ImportReport{}, err
+	}
+	byID := make(map[string]Movement, len(existing))
+	byName := make(map[string]Movement, len(existing))
+	for _, m := range existing {
+		byID[m.Name] = m
+		byName[NormalizeSearch(m.MovementName)] = m
+	}
+
+	report := ImportReport{DryRun: imp.DryRun}
+	seen := make(map[string]int, len(records))
+	var creates, updates []Movement
+	for _, rec := range records {
+		m := rec.movement()
+		row := ImportRow{Row: rec.row, Name: m.MovementName}
+		reject := func(err error) {
+			row.Action, row.Err = ImportRejected, err.Error()
+			report.add(row)
+		}
+		key := NormalizeSearch(m.MovementName)
+		if first, ok := seen[key]; ok && key != "" {
+			reject(fmt.Errorf("duplicates row %d", first))
+			continue
+		}
+		seen[key] = rec.row
+
+		current, conflict := byName[key]
+		if m.Name != "" {
+			if current, conflict = byID[m.Name]; !conflict {
+				reject(fmt.Errorf("unknown movement ID %q", m.Name))
+				continue
+			}
+		}
+		valid, err := newMovement(p, m)
+		if err != nil {
+			reject(err)
+			continue
+		}
+		switch {
+		case !conflict:
+			row.MovementID, row.Action = valid.Name, ImportCreated
+			creates = append(creates, valid)
+		case imp.Policy == SkipConflicts:
+			row.MovementID, row.Action = current.Name, ImportSkipped
+		case imp.Policy == FailOnConflict:
+			reject(fmt.Errorf("conflicts with existing movement %s", current.Name))
+			continue
+		case unchanged(current, valid):
+			row.MovementID, row.Action = current.Name, ImportSkipped
+		case current.TenantID != p.TenantID:
+			reject(fmt.Errorf("global movement %s must be forked before it can be overwritten", current.Name))
+			continue
+		default:
+			valid.Name = current.Name
+			valid.ForkedFrom = current.ForkedFrom
+			valid.Version = current.Version
+			row.MovementID, row.Action = current.Name, ImportUpdated
+			updates = append(updates, valid)
+		}
+		report.add(row)
+	}
+	if imp.DryRun || report.Rejected > 0 {
+		return report, nil
+	}
+
+	if len(creates) > 0 || len(updates) > 0 {
+		if err := s.repo.ImportMovements(ctx, creates, updates); err != nil {
+			return ImportReport{}, err
+		}
+	}
+	report.Committed = true
+	return report, nil
+}
+
+// unchanged reports whether overwriting current with m would change nothing
+// an export shows.
+func unchanged(current Movement, m Movement) bool {
+	m.Name = current.Name
+	return reflect.DeepEqual(movementFields(current), movementFields(m))
+}
+
+// Export serializes the caller's view of the movement catalog, as List would
+// return it, in the given format.
+func (s basicMovementService) Export(ctx context.Context, format MovementFormat, filter MovementFilter) ([]byte, error) {
+	ms, err := s.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := encodeMovements(&buf, format, ms); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
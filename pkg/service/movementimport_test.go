@@ -0,0 +1,182 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/inmem"
+	"workout-manager-service/pkg/service"
+)
+
+// newCatalogStore returns a store in which tenant t1 has its own squat and
+// sees the global bench press, and a context in which t1's admin is the
+// caller.
+func newCatalogStore(t *testing.T) (*inmem.Store, context.Context) {
+	t.Helper()
+	ctx := service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: "u1", TenantID: "t1", Role: service.RoleAdmin})
+	s := inmem.NewStore()
+	for _, m := range []service.Movement{
+		{Name: "squat", TenantID: "t1", MovementName: "Squat"},
+		{Name: "bench", TenantID: service.SystemTenantID, MovementName: "Bench Press"},
+	} {
+		m.Equipment, m.Laterality, m.LoadType = service.Barbell, service.Bilateral, service.ExternalLoad
+		if _, err := s.CreateMovement(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s, ctx
+}
+
+// The squat is matched by its normalized name and the bench press is
+// unchanged.
+const catalogImport = `name,equipment
+squat,dumbbell
+Bench Press,barbell
+Deadlift,barbell
+`
+
+func TestImportMovementsPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		policy    service.ConflictPolicy
+		actions   []service.ImportAction
+		committed bool
+		squat     service.Equipment
+	}{
+		{
+			policy:  service.FailOnConflict,
+			actions: []service.ImportAction{service.ImportRejected, service.ImportRejected, service.ImportCreated},
+			squat:   service.Barbell,
+		},
+		{
+			policy:    service.SkipConflicts,
+			actions:   []service.ImportAction{service.ImportSkipped, service.ImportSkipped, service.ImportCreated},
+			committed: true,
+			squat:     service.Barbell,
+		},
+		{
+			// The bench press is global but unchanged, so it is skipped
+			// rather than rejected.
+			policy:    service.OverwriteConflicts,
+			actions:   []service.ImportAction{service.ImportUpdated, service.ImportSkipped, service.ImportCreated},
+			committed: true,
+			squat:     service.Dumbbell,
+		},
+	} {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			s, ctx := newCatalogStore(t)
+			svc := service.NewBasicMovementService(s, nil)
+			report, err := svc.Import(ctx, service.MovementImport{Format: service.CSVFormat, Policy: tc.policy, Data: []byte(catalogImport)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Committed != tc.committed || len(report.Rows) != len(tc.actions) {
+				t.Fatalf("report = %+v, want %d rows, committed %v", report, len(tc.actions), tc.committed)
+			}
+			for i, row := range report.Rows {
+				if row.Action != tc.actions[i] || row.Row != i+1 {
+					t.Errorf("row %d = %+v, want it %s", i+1, row, tc.actions[i])
+				}
+			}
+			if squat, _ := s.GetMovement(ctx, "squat"); squat.Equipment != tc.squat {
+				t.Errorf("squat equipment = %s, want %s", squat.Equipment, tc.squat)
+			}
+			found, _ := s.SearchMovements(ctx, "t1", "deadlift", 1)
+			if (len(found) == 1) != tc.committed {
+				t.Errorf("deadlift created = %v, want %v", len(found) == 1, tc.committed)
+			}
+		})
+	}
+}
+
+func TestImportMovementsRejections(t *testing.T) {
+	s, ctx := newCatalogStore(t)
+	svc := service.NewBasicMovementService(s, nil)
+	data := `id,name,equipment
+,Deadlift,barbell
+,deadlift,
+,Row,sled
+nope,Clean,barbell
+bench,Bench Press,dumbbell
+,,barbell
+`
+	report, err := svc.Import(ctx, service.MovementImport{Format: service.CSVFormat, Policy: service.OverwriteConflicts, Data: []byte(data)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Committed || report.Created != 1 || report.Rejected != 5 {
+		t.Fatalf("report = %+v, want 1 created, 5 rejected and nothing committed", report)
+	}
+	for _, row := range report.Rows[1:] {
+		if row.Action != service.ImportRejected || row.Err == "" {
+			t.Errorf("row %d = %+v, want it rejected with a reason", row.Row, row)
+		}
+	}
+	if found, _ := s.SearchMovements(ctx, "t1", "deadlift", 1); len(found) != 0 {
+		t.Errorf("a rejected import created %+v", found)
+	}
+}
+
+func TestImportMovementsDryRun(t *testing.T) {
+	s, ctx := newCatalogStore(t)
+	svc := service.NewBasicMovementService(s, nil)
+	report, err := svc.Import(ctx, service.MovementImport{Format: service.CSVFormat, Policy: service.OverwriteConflicts, DryRun: true, Data: []byte(catalogImport)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Committed || !report.DryRun || report.Created != 1 || report.Updated != 1 {
+		t.Fatalf("report = %+v, want a created and an updated row and nothing committed", report)
+	}
+	if squat, _ := s.GetMovement(ctx, "squat"); squat.Equipment != service.Barbell || squat.Version != 1 {
+		t.Errorf("a dry run changed the squat to %+v", squat)
+	}
+}
+
+// racingStore edits the squat just before an import is written.
+type racingStore struct {
+	*inmem.Store
+}
+
+func (s racingStore) ImportMovements(ctx context.Context, creates []service.Movement, updates []service.Movement) error {
+	squat, err := s.GetMovement(ctx, "squat")
+	if err != nil {
+		return err
+	}
+	squat.Equipment = service.Kettlebell
+	if _, err := s.UpdateMovement(ctx, squat); err != nil {
+		return err
+	}
+	return s.Store.ImportMovements(ctx, creates, updates)
+}
+
+func TestImportMovementsIsAllOrNothing(t *testing.T) {
+	s, ctx := newCatalogStore(t)
+	svc := service.NewBasicMovementService(racingStore{s}, nil)
+	_, err := svc.Import(ctx, service.MovementImport{Format: service.CSVFormat, Policy: service.OverwriteConflicts, Data: []byte(catalogImport)})
+	if errors.Cause(err) != service.ErrConflict {
+		t.Fatalf("Import() = %v, want %v", err, service.ErrConflict)
+	}
+	if found, _ := s.SearchMovements(ctx, "t1", "deadlift", 1); len(found) != 0 {
+		t.Errorf("the deadlift was created although the overwrite failed")
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	for _, format := range []service.MovementFormat{service.CSVFormat, service.NDJSONFormat} {
+		s, ctx := newCatalogStore(t)
+		svc := service.NewBasicMovementService(s, nil)
+		data, err := svc.Export(ctx, format, service.MovementFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Importing an export back changes nothing.
+		report, err := svc.Import(ctx, service.MovementImport{Format: format, Policy: service.OverwriteConflicts, Data: data})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Skipped != 2 || report.Created+report.Updated+report.Rejected != 0 {
+			t.Errorf("%s: reimport = %+v, want both movements skipped", format, report)
+		}
+	}
+}
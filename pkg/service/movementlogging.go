@@ -24,57 +24,242 @@ func NewMovementLoggingService(logger logging.IshiLogger, s MovementService) Mov
 
 // Create provides informative logging when requests are made to the create
 // endpoint.
-func (ls movementLoggingService) Create(ctx context.Context, movementName string, categoryID string) (Movement, error) {
+func (ls movementLoggingService) Create(ctx context.Context, m Movement) (Movement, error) {
 	defer func(begin time.Time) {
 		ls.logger.Info(
 			method, "Create",
 			requestContext, fmt.Sprintf("%+v", ctx),
-			"movementName", movementName,
-			"categoryID", categoryID,
+			"movementName", m.MovementName,
+			"categoryID", m.MovementCategoryID,
 			took, time.Since(begin),
 		)
 	}(time.Now())
-	return ls.service.Create(ctx, movementName, categoryID)
+	return ls.service.Create(ctx, m)
+}
+
+// Update provides informative logging when requests are made to the update
+// endpoint.
+func (ls movementLoggingService) Update(ctx context.Context, m Movement, etag string) (Movement, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Update",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"id", m.Name,
+			"movementName", m.MovementName,
+			"categoryID", m.MovementCategoryID,
+			"etag", etag,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Update(ctx, m, etag)
 }
 
 // Get provides informative logging when requests are made to the get
 // endpoint.
-func (ls movementLoggingService) Get(ctx context.Context, id string) (Movement, error) {
+func (ls movementLoggingService) Get(ctx context.Context, id string, showDeleted bool) (Movement, error) {
 	defer func(begin time.Time) {
 		ls.logger.Info(
 			method, "Get",
 			requestContext, fmt.Sprintf("%+v", ctx),
 			"id", id,
+			"showDeleted", showDeleted,
 			took, time.Since(begin),
 		)
 	}(time.Now())
-	return ls.service.Get(ctx, id)
+	return ls.service.Get(ctx, id, showDeleted)
 }
 
 // List provides informative logging when requests are made to the list
 // endpoint.
-func (ls movementLoggingService) List(ctx context.Context, categegoryName string) ([]Movement, error) {
+func (ls movementLoggingService) List(ctx context.Context, filter MovementFilter) ([]Movement, error) {
 	defer func(begin time.Time) {
 		ls.logger.Info(
 			method, "List",
 			requestContext, fmt.Sprintf("%+v", ctx),
-			"categoryName", categegoryName,
+			"filter", fmt.Sprintf("%+v", filter),
 			took, time.Since(begin),
 		)
 	}(time.Now())
-	return ls.service.List(ctx, categegoryName)
+	return ls.service.List(ctx, filter)
 }
 
 // Delete provides informative logging when requests are made to the delete
 // endpoint.
-func (ls movementLoggingService) Delete(ctx context.Context, id string) error {
+func (ls movementLoggingService) Delete(ctx context.Context, id string, etag string) error {
 	defer func(begin time.Time) {
 		ls.logger.Info(
-			method, "List",
+			method, "Delete",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"id", id,
+			"etag", etag,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Delete(ctx, id, etag)
+}
+
+// BatchCreate provides informative logging when requests are made to the
+// batch create endpoint.
+func (ls movementLoggingService) BatchCreate(ctx context.Context, ms []Movement, partial bool) ([]MovementResult, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "BatchCreate",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"count", len(ms),
+			"partial", partial,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.BatchCreate(ctx, ms, partial)
+}
+
+// BatchGet provides informative logging when requests are made to the batch
+// get endpoint.
+func (ls movementLoggingService) BatchGet(ctx context.Context, ids []string, showDeleted bool, partial bool) ([]MovementResult, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "BatchGet",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"ids", ids,
+			"showDeleted", showDeleted,
+			"partial", partial,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.BatchGet(ctx, ids, showDeleted, partial)
+}
+
+// BatchDelete provides informative logging when requests are made to the
+// batch delete endpoint.
+func (ls movementLoggingService) BatchDelete(ctx context.Context, deletions []MovementDeletion, partial bool) ([]MovementResult, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "BatchDelete",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"deletions", fmt.Sprintf("%+v", deletions),
+			"partial", partial,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.BatchDelete(ctx, deletions, partial)
+}
+
+// Import provides informative logging when requests are made to the import
+// endpoint.
+func (ls movementLoggingService) Import(ctx context.Context, imp MovementImport) (ImportReport, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Import",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"format", imp.Format,
+			"policy", imp.Policy,
+			"dryRun", imp.DryRun,
+			"bytes", len(imp.Data),
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Import(ctx, imp)
+}
+
+// Export provides informative logging when requests are made to the export
+// endpoint.
+func (ls movementLoggingService) Export(ctx context.Context, format MovementFormat, filter MovementFilter) ([]byte, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Export",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"format", format,
+			"filter", fmt.Sprintf("%+v", filter),
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Export(ctx, format, filter)
+}
+
+// Undelete provides informative logging when requests are made to the
+// undelete endpoint.
+func (ls movementLoggingService) Undelete(ctx context.Context, id string) (Movement, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Undelete",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"id", id,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Undelete(ctx, id)
+}
+
+// Override provides informative logging when requests are made to the
+// override endpoint.
+func (ls movementLoggingService) Override(ctx context.Context, id string, hidden bool, alias string) (Movement, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Override",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"id", id,
+			"hidden", hidden,
+			"alias", alias,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Override(ctx, id, hidden, alias)
+}
+
+// Fork provides informative logging when requests are made to the fork
+// endpoint.
+func (ls movementLoggingService) Fork(ctx context.Context, id string) (Movement, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Fork",
 			requestContext, fmt.Sprintf("%+v", ctx),
 			"id", id,
 			took, time.Since(begin),
 		)
 	}(time.Now())
-	return ls.service.Delete(ctx, id)
+	return ls.service.Fork(ctx, id)
+}
+
+// Search provides informative logging when requests are made to the search
+// endpoint.
+func (ls movementLoggingService) Search(ctx context.Context, query string, limit int) ([]MovementMatch, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Search",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"query", query,
+			"limit", limit,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Search(ctx, query, limit)
+}
+
+// Merge provides informative logging when requests are made to the merge
+// endpoint.
+func (ls movementLoggingService) Merge(ctx context.Context, canonicalID string, duplicateIDs []string) (Movement, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Merge",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"canonicalID", canonicalID,
+			"duplicateIDs", fmt.Sprintf("%v", duplicateIDs),
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Merge(ctx, canonicalID, duplicateIDs)
+}
+
+// Watch provides informative logging when a watch of the catalog ends.
+func (ls movementLoggingService) Watch(ctx context.Context, resumeToken string, send func(MovementEvent) error) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Watch",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"resumeToken", resumeToken,
+			"err", err,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Watch(ctx, resumeToken, send)
 }
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MovementMatch is a movement found by a search along with how well it
+// matched, from 0 (no match) to 1 (exact match).
+type MovementMatch struct {
+	Movement Movement `json:"movement"`
+	Score    float64  `json:"score"`
+}
+
+// minSearchSimilarity is the trigram similarity below which a name is not
+// considered a match. It mirrors pg_trgm's default similarity threshold.
+const minSearchSimilarity = 0.3
+
+// NormalizeSearch lowercases s and collapses anything that isn't a letter or
+// digit into single spaces, so that "Push-Up" and "push up" compare equal.
+func NormalizeSearch(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// SearchScore rates how well name matches query. Exact matches score
+// highest, followed by prefixes of the name, prefixes of any word in it and
+// finally trigram similarity and edit distance, which tolerate typos.
+// Repositories without a native search index use it to find candidates.
+func SearchScore(query string, name string) float64 {
+	q, n := NormalizeSearch(query), NormalizeSearch(name)
+	switch {
+	case q == "" || n == "":
+		return 0
+	case n == q:
+		return 1
+	case strings.HasPrefix(n, q):
+		return 0.9
+	case strings.Contains(" "+n, " "+q):
+		return 0.8
+	}
+	sim := trigramSimilarity(q, n)
+	// Compare against runs of words as long as the query too, so that a short
+	// query isn't penalized for the rest of a long name.
+	words := strings.Fields(n)
+	span := len(strings.Fields(q))
+	for i := 0; i+span <= len(words); i++ {
+		if s := trigramSimilarity(q, strings.Join(words[i:i+span], " ")); s > sim {
+			sim = s
+		}
+	}
+	if sim >= minSearchSimilarity {
+		return 0.7 * sim
+	}
+	// Trigrams handle dropped and doubled letters well but not swapped ones,
+	// so fall back to an edit distance on single-word queries.
+	if span == 1 && len(q) >= 4 {
+		for _, w := range words {
+			if editDistance(q, w) == 1 || (len(q) >= 8 && editDistance(q, w) == 2) {
+				return 0.5
+			}
+		}
+	}
+	return 0
+}
+
+// editDistance computes the optimal string alignment distance between a and
+// b: the Levenshtein distance, with swapping two adjacent runes counting as a
+// single edit.
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min3(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] && d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+func min3(a int, b int, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// SearchScore rates the movement against query by the best match among its
+// name and aliases.
+func (m Movement) SearchScore(query string) float64 {
+	best := SearchScore(query, m.MovementName)
+	for _, alias := range m.Aliases {
+		if score := SearchScore(query, alias); score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+// trigramSimilarity computes the same similarity as pg_trgm: the share of
+// distinct padded word trigrams the two strings have in common.
+func trigramSimilarity(a string, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+func trigrams(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		r := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(r); i++ {
+			set[string(r[i:i+3])] = true
+		}
+	}
+	return set
+}
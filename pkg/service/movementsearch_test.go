@@ -0,0 +1,64 @@
+package service
+
+import "testing"
+
+func TestNormalizeSearch(t *testing.T) {
+	for in, want := range map[string]string{
+		"Push-Up":              "push up",
+		"  Bench  Press (BB) ": "bench press bb",
+		"Überzug":              "überzug",
+		"--":                   "",
+	} {
+		if got := NormalizeSearch(in); got != want {
+			t.Errorf("NormalizeSearch(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSearchScore(t *testing.T) {
+	for _, tc := range []struct {
+		query, name string
+		want        float64
+	}{
+		{"squat", "Squat", 1},
+		{"push up", "Push-Up", 1},
+		{"bench", "Bench Press", 0.9},
+		{"press", "Bench Press", 0.8},
+		{"ench", "Bench Press", 0.7 * trigramSimilarity("ench", "bench")},
+		{"", "Squat", 0},
+		{"squat", "", 0},
+		{"deadlift", "Romanian Deadlift", 0.8},
+		{"sqaut", "Back Squat", 0.5},
+		{"sqat", "Squat", 0.7 * trigramSimilarity("sqat", "squat")},
+		{"deadlfit", "Deadlift", 0.7 * trigramSimilarity("deadlfit", "deadlift")},
+		{"dedlfit", "Deadlift", 0},
+		{"squat rdl", "Romanian Deadlift", 0},
+		{"squ", "Squat", 0.9},
+		{"sqau", "Squat", 0},
+		{"abc", "acb", 0},
+		{"curl", "Row", 0},
+	} {
+		if got := SearchScore(tc.query, tc.name); got != tc.want {
+			t.Errorf("SearchScore(%q, %q) = %g, want %g", tc.query, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"squat", "squat", 0},
+		{"sqaut", "squat", 1},
+		{"squt", "squat", 1},
+		{"sqquat", "squat", 1},
+		{"sqaut", "", 5},
+		{"ca", "abc", 3},
+		{"kitten", "sitting", 3},
+	} {
+		if got := editDistance(tc.a, tc.b); got != tc.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
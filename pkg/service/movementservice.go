@@ -2,86 +2,945 @@ package service
 
 import (
 	"context"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
 
 	"workout-manager-service/logging"
 )
 
+// Equipment is the implement a movement is performed with.
+type Equipment string
+
+// The equipment a movement may use.
+const (
+	Barbell    Equipment = "barbell"
+	Dumbbell   Equipment = "dumbbell"
+	Kettlebell Equipment = "kettlebell"
+	Machine    Equipment = "machine"
+	Cable      Equipment = "cable"
+	Band       Equipment = "band"
+	Bodyweight Equipment = "bodyweight"
+	Ergometer  Equipment = "ergometer"
+	OtherGear  Equipment = "other"
+)
+
+// MuscleGroup is a muscle group a movement trains.
+type MuscleGroup string
+
+// The muscle groups movements are tagged with.
+const (
+	Chest      MuscleGroup = "chest"
+	Back       MuscleGroup = "back"
+	Lats       MuscleGroup = "lats"
+	Traps      MuscleGroup = "traps"
+	Shoulders  MuscleGroup = "shoulders"
+	Biceps     MuscleGroup = "biceps"
+	Triceps    MuscleGroup = "triceps"
+	Forearms   MuscleGroup = "forearms"
+	Core       MuscleGroup = "core"
+	Glutes     MuscleGroup = "glutes"
+	Quadriceps MuscleGroup = "quadriceps"
+	Hamstrings MuscleGroup = "hamstrings"
+	Calves     MuscleGroup = "calves"
+	Adductors  MuscleGroup = "adductors"
+	Cardio     MuscleGroup = "cardio"
+)
+
+var muscleGroups = map[MuscleGroup]bool{
+	Chest: true, Back: true, Lats: true, Traps: true, Shoulders: true,
+	Biceps: true, Triceps: true, Forearms: true, Core: true, Glutes: true,
+	Quadriceps: true, Hamstrings: true, Calves: true, Adductors: true,
+	Cardio: true,
+}
+
+// Laterality says whether a movement works one side of the body at a time.
+type Laterality string
+
+// The lateralities a movement may have.
+const (
+	Bilateral  Laterality = "bilateral"
+	Unilateral Laterality = "unilateral"
+)
+
+// LoadType says how the load of a set of a movement is measured.
+type LoadType string
+
+// The load types a movement may have. Assisted loads are subtracted from the
+// athlete's bodyweight; time and distance movements carry no weight at all.
+const (
+	ExternalLoad   LoadType = "external"
+	BodyweightLoad LoadType = "bodyweight"
+	AssistedLoad   LoadType = "assisted"
+	TimeLoad       LoadType = "time"
+	DistanceLoad   LoadType = "distance"
+)
+
 // Movement represents a discrete movement like a panda pull, squat, or bench
-// press.
+// press. Movements owned by the SystemTenantID make up the global catalog
+// that every tenant inherits; ForkedFrom is set on tenant copies of a global
+// movement, and Hidden is set when a tenant has hidden a global movement it
+// retrieves by ID. Deleted movements keep their data, so that historical sets
+// still refer to something, until they are purged; DeleteTime is zero for
+// live movements. Version is bumped by the repository on every write.
 type Movement struct {
-	Name               string `json:"id"`
-	TenantID           string `json:"tenantId"`
-	MovementName       string `json:"name"`
-	MovementCategoryID string `json:"movementCategoryId"`
+	Name               string        `json:"id"`
+	TenantID           string        `json:"tenantId"`
+	MovementName       string        `json:"name"`
+	MovementCategoryID string        `json:"movementCategoryId"`
+	ForkedFrom         string        `json:"forkedFrom"`
+	Hidden             bool          `json:"hidden"`
+	Equipment          Equipment     `json:"equipment"`
+	PrimaryMuscles     []MuscleGroup `json:"primaryMuscles"`
+	SecondaryMuscles   []MuscleGroup `json:"secondaryMuscles"`
+	Laterality         Laterality    `json:"laterality"`
+	LoadType           LoadType      `json:"loadType"`
+	Links              []string      `json:"links"`
+	Aliases            []string      `json:"aliases"`
+	DeleteTime         time.Time     `json:"deleteTime"`
+	Version            int64         `json:"version"`
+}
+
+// Etag returns an opaque tag that changes whenever the movement does. Clients
+// send it back with updates and deletes to detect concurrent edits. Movements
+// that were never stored have no etag.
+func (m Movement) Etag() string {
+	if m.Version == 0 {
+		return ""
+	}
+	return strconv.FormatInt(m.Version, 10)
+}
+
+// Deleted reports whether the movement has been soft deleted.
+func (m Movement) Deleted() bool {
+	return !m.DeleteTime.IsZero()
+}
+
+// MovementFilter narrows the movements returned by a listing. Empty fields
+// match every movement; Muscle matches primary and secondary muscle groups.
+// Deleted movements are only included when ShowDeleted is set.
+type MovementFilter struct {
+	CategoryID  string      `json:"movementCategoryId"`
+	Equipment   Equipment   `json:"equipment"`
+	Muscle      MuscleGroup `json:"muscle"`
+	Laterality  Laterality  `json:"laterality"`
+	LoadType    LoadType    `json:"loadType"`
+	ShowDeleted bool        `json:"showDeleted"`
+}
+
+// Matches reports whether m satisfies the filter. Repositories that cannot
+// filter natively may use it.
+func (f MovementFilter) Matches(m Movement) bool {
+	switch {
+	case !f.ShowDeleted && m.Deleted():
+		return false
+	case f.CategoryID != "" && m.MovementCategoryID != f.CategoryID:
+		return false
+	case f.Equipment != "" && m.Equipment != f.Equipment:
+		return false
+	case f.Laterality != "" && m.Laterality != f.Laterality:
+		return false
+	case f.LoadType != "" && m.LoadType != f.LoadType:
+		return false
+	case f.Muscle != "" && !m.Trains(f.Muscle):
+		return false
+	}
+	return true
+}
+
+// Trains reports whether the movement works the muscle group, either as a
+// primary or a secondary mover.
+func (m Movement) Trains(muscle MuscleGroup) bool {
+	for _, g := range m.PrimaryMuscles {
+		if g == muscle {
+			return true
+		}
+	}
+	for _, g := range m.SecondaryMuscles {
+		if g == muscle {
+			return true
+		}
+	}
+	return false
+}
+
+// MovementOverride customizes how a global movement appears to one tenant. A
+// non-empty Alias replaces the movement's name in the tenant's view.
+type MovementOverride struct {
+	TenantID   string `json:"tenantId"`
+	MovementID string `json:"movementId"`
+	Hidden     bool   `json:"hidden"`
+	Alias      string `json:"alias"`
+}
+
+// MovementMerge describes duplicates being folded into a canonical movement.
+// Every workout set referring to a duplicate is repointed at the canonical
+// movement and a redirect is left behind for each duplicate's ID. The
+// canonical movement belongs to TenantID and Aliases replaces its aliases.
+type MovementMerge struct {
+	TenantID     string   `json:"tenantId"`
+	CanonicalID  string   `json:"canonicalId"`
+	DuplicateIDs []string `json:"duplicateIds"`
+	Aliases      []string `json:"aliases"`
+}
+
+// MovementRepository persists movements, the overrides tenants apply to the
+// global catalog and the redirects left behind by merges. A merge must happen
+// in a single transaction. GetMovement, GetFork and ListForks return deleted
+// movements; SearchMovements never does. SearchMovements is given a query
+// normalized by NormalizeSearch and must return at least the movements that
+// SearchScore matches, though it may return more. UpdateMovement and
+// SetMovementDeleteTime are compare-and-set operations: they fail with
+// ErrConflict unless the stored movement still has the given version.
+// CreateMovement, UpdateMovement and MergeMovements store the movements they
+// write with a new version. CreateMovements, ImportMovements and
+// SetMovementsDeleteTime write every movement or none in a single transaction;
+// the latter two check the Version of each movement they change, as
+// UpdateMovement does. Every write of a movement, but not of an override, adds
+// its DomainEvent to the outbox in the same transaction.
+type MovementRepository interface {
+	CreateMovement(ctx context.Context, m Movement) (Movement, error)
+	CreateMovements(ctx context.Context, ms []Movement) ([]Movement, error)
+	GetMovement(ctx context.Context, id string) (Movement, error)
+	ListMovements(ctx context.Context, tenantID string, filter MovementFilter) ([]Movement, error)
+	SearchMovements(ctx context.Context, tenantID string, query string, limit int) ([]Movement, error)
+	UpdateMovement(ctx context.Context, m Movement) (Movement, error)
+	ImportMovements(ctx context.Context, creates []Movement, updates []Movement) error
+	SetMovementDeleteTime(ctx context.Context, id string, deleteTime time.Time, version int64) error
+	SetMovementsDeleteTime(ctx context.Context, ms []Movement, deleteTime time.Time) error
+	PurgeMovements(ctx context.Context, deletedBefore time.Time) (int64, error)
+	GetFork(ctx context.Context, tenantID string, globalID string) (Movement, error)
+	ListForks(ctx context.Context, tenantID string) ([]Movement, error)
+	GetMovementOverride(ctx context.Context, tenantID string, movementID string) (MovementOverride, error)
+	ListMovementOverrides(ctx context.Context, tenantID string) ([]MovementOverride, error)
+	PutMovementOverride(ctx context.Context, o MovementOverride) error
+	DeleteMovementOverride(ctx context.Context, tenantID string, movementID string) error
+	MergeMovements(ctx context.Context, merge MovementMerge) error
+	GetMovementRedirect(ctx context.Context, tenantID string, id string) (string, error)
 }
 
-// MovementService describes a service that deals with movements.
+// MovementService describes a service that deals with movements. Get and
+// List resolve the caller's merged view of their own movements and the
+// global catalog. Create uses every field of the given Movement except its
+// ID, tenant and fork bookkeeping, which are assigned by the service; Update
+// replaces the same fields of an existing movement. Update and Delete take the
+// etag the caller last saw and fail with ErrConflict when the movement has
+// changed since; an empty etag skips the check. Deleted movements can be
+// restored with Undelete until they are purged. The batch methods return one
+// result per item, in order.
 type MovementService interface {
-	Create(ctx context.Context, movementName string, categoryID string) (Movement, error)
-	Get(ctx context.Context, id string) (Movement, error)
-	List(ctx context.Context, categoryName string) ([]Movement, error)
-	Delete(ctx context.Context, id string) error
+	Create(ctx context.Context, m Movement) (Movement, error)
+	Update(ctx context.Context, m Movement, etag string) (Movement, error)
+	Get(ctx context.Context, id string, showDeleted bool) (Movement, error)
+	List(ctx context.Context, filter MovementFilter) ([]Movement, error)
+	Search(ctx context.Context, query string, limit int) ([]MovementMatch, error)
+	Delete(ctx context.Context, id string, etag string) error
+	Undelete(ctx context.Context, id string) (Movement, error)
+	BatchCreate(ctx context.Context, ms []Movement, partial bool) ([]MovementResult, error)
+	BatchGet(ctx context.Context, ids []string, showDeleted bool, partial bool) ([]MovementResult, error)
+	BatchDelete(ctx context.Context, deletions []MovementDeletion, partial bool) ([]MovementResult, error)
+	Import(ctx context.Context, imp MovementImport) (ImportReport, error)
+	Export(ctx context.Context, format MovementFormat, filter MovementFilter) ([]byte, error)
+	Override(ctx context.Context, id string, hidden bool, alias string) (Movement, error)
+	Fork(ctx context.Context, id string) (Movement, error)
+	Merge(ctx context.Context, canonicalID string, duplicateIDs []string) (Movement, error)
+	Watch(ctx context.Context, resumeToken string, send func(MovementEvent) error) error
 }
 
 // NewMovementService returns a basic Service with middleware wired in.
-func NewMovementService(logger logging.IshiLogger) MovementService {
+func NewMovementService(logger logging.IshiLogger, repo MovementRepository, audit AuditRepository, events EventBus) MovementService {
 	var svc MovementService
 	{
-		svc = NewBasicMovementService()
+		svc = NewBasicMovementService(repo, events)
+		svc = NewMovementAuditService(logger, audit, svc)
+		svc = NewMovementEventService(events, svc)
 		svc = NewMovementLoggingService(logger, svc)
 	}
 	return svc
 }
 
-// NewBasicMovementService returns a naïve, stateless implementation of
-// MovementService.
-func NewBasicMovementService() MovementService {
-	return basicMovementService{}
-}
-
-type basicMovementService struct{}
-
-// Create adds a new Movement to the database.
-func (s basicMovementService) Create(ctx context.Context, movementName string, categoryID string) (Movement, error) {
-	return Movement{
-		Name:               "new ID",
-		TenantID:           "test tenant ID",
-		MovementName:       movementName,
-		MovementCategoryID: "test category ID",
-	}, nil
-}
-
-// Get retrieves a Movement from the database by its UUID.
-func (s basicMovementService) Get(ctx context.Context, id string) (Movement, error) {
-	return Movement{
-		Name:               id,
-		TenantID:           "test tenant ID",
-		MovementName:       "Get",
-		MovementCategoryID: "test category ID",
-	}, nil
-}
-
-// List retrieves all movements from the database, optionally filtering by
-// category name.
-// TODO: filter by category
-func (s basicMovementService) List(ctx context.Context, categoryName string) ([]Movement, error) {
-	return []Movement{
-		{
-			Name:               "1",
-			TenantID:           "test tenant ID",
-			MovementName:       "List",
-			MovementCategoryID: "test category ID",
-		},
-		{
-			Name:               "2",
-			TenantID:           "test tenant ID",
-			MovementName:       "List",
-			MovementCategoryID: "test category ID",
-		},
-	}, nil
-}
-
-// Delete removes from the database the movement with the specified ID.
-func (s basicMovementService) Delete(ctx context.Context, id string) error {
+// NewBasicMovementService returns an implementation of MovementService backed
+// by the given repository. Watch follows the given EventBus, which may be nil
+// for services that are never watched.
+func NewBasicMovementService(repo MovementRepository, events EventBus) MovementService {
+	return basicMovementService{repo: repo, events: events}
+}
+
+type basicMovementService struct {
+	repo   MovementRepository
+	events EventBus
+}
+
+// Create adds a new Movement to the caller's tenant. Movements created by
+// operators are added to the global catalog. Laterality and load type
+// default to bilateral and external.
+func (s basicMovementService) Create(ctx context.Context, m Movement) (Movement, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return Movement{}, err
+	}
+	if m, err = newMovement(p, m); err != nil {
+		return Movement{}, err
+	}
+	return s.repo.CreateMovement(ctx, m)
+}
+
+// newMovement validates a movement about to be created by p and fills in the
+// fields the service assigns.
+func newMovement(p Principal, m Movement) (Movement, error) {
+	m = withMovementDefaults(m)
+	m.Aliases = dedupeAliases(m.MovementName, m.Aliases)
+	if err := validateMovement(m); err != nil {
+		return Movement{}, err
+	}
+	m.Name = uuid.New().String()
+	m.TenantID = p.TenantID
+	m.ForkedFrom = ""
+	m.Hidden = false
+	m.DeleteTime = time.Time{}
+	return m, nil
+}
+
+// Update replaces the name and metadata of one of the caller's movements.
+// Global movements have to be forked before a tenant can edit them.
+func (s basicMovementService) Update(ctx context.Context, m Movement, etag string) (Movement, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return Movement{}, err
+	}
+	current, err := s.ownMovement(ctx, p, m.Name)
+	if err != nil {
+		return Movement{}, err
+	}
+	if err := checkEtag(current, etag); err != nil {
+		return Movement{}, err
+	}
+	m = withMovementDefaults(m)
+	m.Aliases = dedupeAliases(m.MovementName, m.Aliases)
+	if err := validateMovement(m); err != nil {
+		return Movement{}, err
+	}
+	m.TenantID = current.TenantID
+	m.ForkedFrom = current.ForkedFrom
+	m.Hidden = false
+	m.DeleteTime = current.DeleteTime
+	m.Version = current.Version
+	return s.repo.UpdateMovement(ctx, m)
+}
+
+// Get retrieves a Movement visible to the caller by its UUID. Asking for a
+// global movement the caller has forked returns the fork instead, and asking
+// for a movement that was merged away returns the movement it was merged
+// into. Deleted movements are only returned when showDeleted is set.
+func (s basicMovementService) Get(ctx context.Context, id string, showDeleted bool) (Movement, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return Movement{}, err
+	}
+	return s.get(ctx, p, id, showDeleted)
+}
+
+func (s basicMovementService) get(ctx context.Context, p Principal, id string, showDeleted bool) (Movement, error) {
+	m, err := s.repo.GetMovement(ctx, id)
+	if errors.Cause(err) == ErrNotFound {
+		to, rerr := s.repo.GetMovementRedirect(ctx, p.TenantID, id)
+		if rerr != nil {
+			return Movement{}, err
+		}
+		return s.get(ctx, p, to, showDeleted)
+	}
+	if err != nil {
+		return Movement{}, err
+	}
+	switch m.TenantID {
+	case p.TenantID:
+	case SystemTenantID:
+		if m, err = s.resolveGlobal(ctx, p.TenantID, m, showDeleted); err != nil {
+			return Movement{}, err
+		}
+	default:
+		return Movement{}, ErrNotFound
+	}
+	if m.Deleted() && !showDeleted {
+		return Movement{}, ErrNotFound
+	}
+	return m, nil
+}
+
+// List retrieves the caller's merged view of movements, optionally filtering
+// them. Global movements the caller has hidden or forked are left out.
+func (s basicMovementService) List(ctx context.Context, filter MovementFilter) ([]Movement, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+	own, err := s.repo.ListMovements(ctx, p.TenantID, filter)
+	if err != nil {
+		return nil, err
+	}
+	if p.TenantID == SystemTenantID {
+		return own, nil
+	}
+	global, err := s.repo.ListMovements(ctx, SystemTenantID, filter)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.repo.ListMovementOverrides(ctx, p.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	return mergeMovements(own, global, overrides), nil
+}
+
+// The bounds on the number of results a search returns.
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// Search finds the movements visible to the caller whose names or aliases
+// match query, tolerating typos. Results are ranked by relevance, best first.
+func (s basicMovementService) Search(ctx context.Context, query string, limit int) ([]MovementMatch, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query = NormalizeSearch(query)
+	if query == "" {
+		return nil, errors.Wrap(ErrInvalidArgument, "search query is required")
+	}
+	if limit <= 0 || limit > maxSearchLimit {
+		limit = defaultSearchLimit
+	}
+	// Over-fetch candidates so that aliases and ranking have room to reorder
+	// them before the limit is applied.
+	candidates := limit * 4
+
+	scores := make(map[string]float64)
+	results := make(map[string]Movement)
+	add := func(m Movement, score float64) {
+		if s := m.SearchScore(query); s > score {
+			score = s
+		}
+		if score > 0 && score > scores[m.Name] {
+			scores[m.Name] = score
+			results[m.Name] = m
+		}
+	}
+
+	own, err := s.repo.SearchMovements(ctx, p.TenantID, query, candidates)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range own {
+		add(m, 0)
+	}
+	if p.TenantID != SystemTenantID {
+		global, err := s.repo.SearchMovements(ctx, SystemTenantID, query, candidates)
+		if err != nil {
+			return nil, err
+		}
+		overrides, err := s.repo.ListMovementOverrides(ctx, p.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		forks, err := s.repo.ListForks(ctx, p.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		found := make(map[string]Movement, len(global))
+		for _, m := range global {
+			found[m.Name] = m
+		}
+		// Global movements match on their catalog name as well as on any
+		// alias the tenant has given them. Those matching only by alias are
+		// looked up one by one; a tenant renames few movements.
+		byMovement := make(map[string]MovementOverride, len(overrides))
+		for _, o := range overrides {
+			byMovement[o.MovementID] = o
+			if _, ok := found[o.MovementID]; ok || o.Hidden || SearchScore(query, o.Alias) == 0 {
+				continue
+			}
+			m, err := s.repo.GetMovement(ctx, o.MovementID)
+			if errors.Cause(err) == ErrNotFound {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if m.TenantID == SystemTenantID && !m.Deleted() {
+				found[m.Name] = m
+			}
+		}
+		forked := make(map[string]bool, len(forks))
+		for _, f := range forks {
+			if !f.Deleted() {
+				forked[f.ForkedFrom] = true
+			}
+		}
+		for id, m := range found {
+			if forked[id] {
+				continue
+			}
+			score := 0.0
+			if o, ok := byMovement[id]; ok {
+				if o.Hidden {
+					continue
+				}
+				// The catalog name still matches once the alias replaces it.
+				score = m.SearchScore(query)
+				m = applyOverride(m, o)
+			}
+			add(m, score)
+		}
+	}
+
+	matches := make([]MovementMatch, 0, len(results))
+	for id, m := range results {
+		matches = append(matches, MovementMatch{Movement: m, Score: scores[id]})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Movement.MovementName < matches[j].Movement.MovementName
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// Delete soft deletes the movement with the specified ID. It disappears from
+// Get and List but keeps its data until it is restored or purged. Tenants can
+// hide global movements but never delete them.
+func (s basicMovementService) Delete(ctx context.Context, id string, etag string) error {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	m, err := s.ownMovement(ctx, p, id)
+	if errors.Cause(err) == ErrPermissionDenied {
+		return errors.Wrap(ErrPermissionDenied, "global movements can be hidden but not deleted")
+	}
+	if err != nil {
+		return err
+	}
+	if err := checkEtag(m, etag); err != nil {
+		return err
+	}
+	return s.repo.SetMovementDeleteTime(ctx, id, time.Now().UTC(), m.Version)
+}
+
+// Undelete restores a soft deleted movement. Restoring a live movement is a
+// no-op.
+func (s basicMovementService) Undelete(ctx context.Context, id string) (Movement, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return Movement{}, err
+	}
+	m, err := s.repo.GetMovement(ctx, id)
+	if err != nil {
+		return Movement{}, err
+	}
+	if m.TenantID != p.TenantID {
+		return Movement{}, ErrNotFound
+	}
+	if !m.Deleted() {
+		return m, nil
+	}
+	if err := s.repo.SetMovementDeleteTime(ctx, id, time.Time{}, m.Version); err != nil {
+		return Movement{}, err
+	}
+	return s.repo.GetMovement(ctx, id)
+}
+
+// Override hides or aliases a global movement for the caller's tenant.
+// Passing neither removes any existing override.
+func (s basicMovementService) Override(ctx context.Context, id string, hidden bool, alias string) (Movement, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return Movement{}, err
+	}
+	if _, err := s.globalMovement(ctx, p, id); err != nil {
+		return Movement{}, err
+	}
+	if !hidden && alias == "" {
+		err = s.repo.DeleteMovementOverride(ctx, p.TenantID, id)
+		if errors.Cause(err) == ErrNotFound {
+			err = nil
+		}
+	} else {
+		err = s.repo.PutMovementOverride(ctx, MovementOverride{
+			TenantID:   p.TenantID,
+			MovementID: id,
+			Hidden:     hidden,
+			Alias:      alias,
+		})
+	}
+	if err != nil {
+		return Movement{}, err
+	}
+	return s.get(ctx, p, id, false)
+}
+
+// Fork copies a global movement into the caller's tenant, where it can be
+// edited freely. The fork replaces the global movement in the caller's view
+// and supersedes any override. Forking twice returns the existing fork, and
+// forking again after deleting the fork restores it.
+func (s basicMovementService) Fork(ctx context.Context, id string) (Movement, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return Movement{}, err
+	}
+	if _, err := s.globalMovement(ctx, p, id); err != nil {
+		return Movement{}, err
+	}
+	existing, err := s.repo.GetFork(ctx, p.TenantID, id)
+	if err == nil {
+		return s.Undelete(ctx, existing.Name)
+	}
+	if errors.Cause(err) != ErrNotFound {
+		return Movement{}, err
+	}
+	resolved, err := s.get(ctx, p, id, false)
+	if err != nil {
+		return Movement{}, err
+	}
+	fork := resolved
+	fork.Name = uuid.New().String()
+	fork.TenantID = p.TenantID
+	fork.ForkedFrom = id
+	fork.Hidden = false
+	fork, err = s.repo.CreateMovement(ctx, fork)
+	if err != nil {
+		return Movement{}, err
+	}
+	if err := s.repo.DeleteMovementOverride(ctx, p.TenantID, id); err != nil && errors.Cause(err) != ErrNotFound {
+		return Movement{}, err
+	}
+	return fork, nil
+}
+
+// Merge folds duplicate movements owned by the caller's tenant into a
+// canonical movement of its own; a global movement must be forked before
+// duplicates can be merged into it. Historical sets are repointed, the
+// duplicates' names become aliases of the canonical movement and their IDs
+// keep resolving through Get.
+func (s basicMovementService) Merge(ctx context.Context, canonicalID string, duplicateIDs []string) (Movement, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return Movement{}, err
+	}
+	if p.TenantID == SystemTenantID {
+		return Movement{}, errors.Wrap(ErrInvalidArgument, "the global catalog cannot be merged")
+	}
+	if len(duplicateIDs) == 0 {
+		return Movement{}, errors.Wrap(ErrInvalidArgument, "at least one duplicate is required")
+	}
+	canonical, err := s.get(ctx, p, canonicalID, false)
+	if err != nil {
+		return Movement{}, errors.Wrap(err, "failed to look up canonical movement")
+	}
+	if canonical.Hidden {
+		return Movement{}, errors.Wrap(ErrInvalidArgument, "cannot merge into a hidden movement")
+	}
+	if canonical.TenantID != p.TenantID {
+		return Movement{}, errors.Wrapf(ErrInvalidArgument, "global movement %s must be forked before it can be merged into", canonical.Name)
+	}
+
+	aliases := canonical.Aliases
+	seen := make(map[string]bool, len(duplicateIDs))
+	var duplicates []string
+	for _, id := range duplicateIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		dup, err := s.repo.GetMovement(ctx, id)
+		if err != nil {
+			return Movement{}, errors.Wrapf(err, "failed to look up duplicate %s", id)
+		}
+		switch {
+		case dup.Name == canonical.Name:
+			return Movement{}, errors.Wrap(ErrInvalidArgument, "a movement cannot be merged into itself")
+		case dup.TenantID == SystemTenantID:
+			return Movement{}, errors.Wrap(ErrInvalidArgument, "global movements cannot be merged away")
+		case dup.TenantID != p.TenantID:
+			return Movement{}, ErrNotFound
+		}
+		duplicates = append(duplicates, dup.Name)
+		aliases = append(append(aliases, dup.MovementName), dup.Aliases...)
+	}
+
+	err = s.repo.MergeMovements(ctx, MovementMerge{
+		TenantID:     p.TenantID,
+		CanonicalID:  canonical.Name,
+		DuplicateIDs: duplicates,
+		Aliases:      dedupeAliases(canonical.MovementName, aliases),
+	})
+	if err != nil {
+		return Movement{}, err
+	}
+	return s.get(ctx, p, canonical.Name, false)
+}
+
+// ownMovement retrieves a live movement owned by the caller's tenant, which
+// the caller may edit or delete.
+func (s basicMovementService) ownMovement(ctx context.Context, p Principal, id string) (Movement, error) {
+	m, err := s.repo.GetMovement(ctx, id)
+	if err != nil {
+		return Movement{}, err
+	}
+	switch {
+	case m.TenantID == SystemTenantID && p.TenantID != SystemTenantID:
+		return Movement{}, errors.Wrap(ErrPermissionDenied, "global movements must be forked before they can be edited")
+	case m.TenantID != p.TenantID, m.Deleted():
+		return Movement{}, ErrNotFound
+	}
+	return m, nil
+}
+
+// checkEtag fails with ErrConflict when the caller's etag is stale.
+func checkEtag(m Movement, etag string) error {
+	if etag != "" && etag != m.Etag() {
+		return errors.Wrap(ErrConflict, "movement was modified since it was read")
+	}
+	return nil
+}
+
+// globalMovement retrieves a global movement that a tenant may customize.
+func (s basicMovementService) globalMovement(ctx context.Context, p Principal, id string) (Movement, error) {
+	if p.TenantID == SystemTenantID {
+		return Movement{}, errors.Wrap(ErrInvalidArgument, "the global catalog cannot override itself")
+	}
+	m, err := s.repo.GetMovement(ctx, id)
+	if err != nil {
+		return Movement{}, err
+	}
+	if m.TenantID != SystemTenantID {
+		return Movement{}, errors.Wrap(ErrInvalidArgument, "only global movements can be overridden or forked")
+	}
+	if m.Deleted() {
+		return Movement{}, ErrNotFound
+	}
+	return m, nil
+}
+
+// resolveGlobal applies a tenant's fork or override to a global movement. A
+// deleted fork only stands in for the global movement when showDeleted is
+// set.
+func (s basicMovementService) resolveGlobal(ctx context.Context, tenantID string, m Movement, showDeleted bool) (Movement, error) {
+	fork, err := s.repo.GetFork(ctx, tenantID, m.Name)
+	if err == nil && (showDeleted || !fork.Deleted()) {
+		return fork, nil
+	}
+	if err != nil && errors.Cause(err) != ErrNotFound {
+		return Movement{}, err
+	}
+	o, err := s.repo.GetMovementOverride(ctx, tenantID, m.Name)
+	if errors.Cause(err) == ErrNotFound {
+		return m, nil
+	}
+	if err != nil {
+		return Movement{}, err
+	}
+	return applyOverride(m, o), nil
+}
+
+func applyOverride(m Movement, o MovementOverride) Movement {
+	m.Hidden = o.Hidden
+	if o.Alias != "" {
+		m.MovementName = o.Alias
+	}
+	return m
+}
+
+// mergeMovements combines a tenant's own movements with the global catalog,
+// leaving out hidden and forked global movements.
+func mergeMovements(own []Movement, global []Movement, overrides []MovementOverride) []Movement {
+	forked := make(map[string]bool, len(own))
+	for _, m := range own {
+		if m.ForkedFrom != "" {
+			forked[m.ForkedFrom] = true
+		}
+	}
+	byMovement := make(map[string]MovementOverride, len(overrides))
+	for _, o := range overrides {
+		byMovement[o.MovementID] = o
+	}
+	merged := append([]Movement(nil), own...)
+	for _, m := range global {
+		if forked[m.Name] {
+			continue
+		}
+		if o, ok := byMovement[m.Name]; ok {
+			if o.Hidden {
+				continue
+			}
+			m = applyOverride(m, o)
+		}
+		merged = append(merged, m)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].MovementName < merged[j].MovementName
+	})
+	return merged
+}
+
+// dedupeAliases drops empty aliases, aliases equal to the movement's name and
+// repeats, comparing them the way search does.
+func dedupeAliases(name string, aliases []string) []string {
+	seen := map[string]bool{NormalizeSearch(name): true}
+	var deduped []string
+	for _, a := range aliases {
+		key := NormalizeSearch(a)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		deduped = append(deduped, strings.TrimSpace(a))
+	}
+	return deduped
+}
+
+func withMovementDefaults(m Movement) Movement {
+	if m.Laterality == "" {
+		m.Laterality = Bilateral
+	}
+	if m.LoadType == "" {
+		m.LoadType = ExternalLoad
+	}
+	return m
+}
+
+func validateMovement(m Movement) error {
+	if m.MovementName == "" {
+		return errors.Wrap(ErrInvalidArgument, "movement name is required")
+	}
+	if err := validateFilter(MovementFilter{
+		Equipment:  m.Equipment,
+		Laterality: m.Laterality,
+		LoadType:   m.LoadType,
+	}); err != nil {
+		return err
+	}
+	for _, g := range append(append([]MuscleGroup(nil), m.PrimaryMuscles...), m.SecondaryMuscles...) {
+		if !muscleGroups[g] {
+			return errors.Wrapf(ErrInvalidArgument, "unknown muscle group %q", g)
+		}
+	}
+	for _, link := range m.Links {
+		u, err := url.Parse(link)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.Wrapf(ErrInvalidArgument, "invalid link %q", link)
+		}
+	}
+	return nil
+}
+
+func validateFilter(f MovementFilter) error {
+	switch f.Equipment {
+	case "", Barbell, Dumbbell, Kettlebell, Machine, Cable, Band, Bodyweight, Ergometer, OtherGear:
+	default:
+		return errors.Wrapf(ErrInvalidArgument, "unknown equipment %q", f.Equipment)
+	}
+	if f.Muscle != "" && !muscleGroups[f.Muscle] {
+		return errors.Wrapf(ErrInvalidArgument, "unknown muscle group %q", f.Muscle)
+	}
+	switch f.Laterality {
+	case "", Bilateral, Unilateral:
+	default:
+		return errors.Wrapf(ErrInvalidArgument, "unknown laterality %q", f.Laterality)
+	}
+	switch f.LoadType {
+	case "", ExternalLoad, BodyweightLoad, AssistedLoad, TimeLoad, DistanceLoad:
+	default:
+		return errors.Wrapf(ErrInvalidArgument, "unknown load type %q", f.LoadType)
+	}
 	return nil
 }
+
+// starterMovements is the library the global catalog is seeded with.
+var starterMovements = []Movement{
+	{MovementName: "back squat", MovementCategoryID: "squat", Equipment: Barbell, PrimaryMuscles: []MuscleGroup{Quadriceps, Glutes}, SecondaryMuscles: []MuscleGroup{Adductors, Core}},
+	{MovementName: "front squat", MovementCategoryID: "squat", Equipment: Barbell, PrimaryMuscles: []MuscleGroup{Quadriceps}, SecondaryMuscles: []MuscleGroup{Glutes, Core}},
+	{MovementName: "goblet squat", MovementCategoryID: "squat", Equipment: Kettlebell, PrimaryMuscles: []MuscleGroup{Quadriceps}, SecondaryMuscles: []MuscleGroup{Glutes, Core}},
+	{MovementName: "deadlift", MovementCategoryID: "hinge", Equipment: Barbell, PrimaryMuscles: []MuscleGroup{Hamstrings, Glutes, Back}, SecondaryMuscles: []MuscleGroup{Traps, Forearms}},
+	{MovementName: "romanian deadlift", MovementCategoryID: "hinge", Equipment: Barbell, PrimaryMuscles: []MuscleGroup{Hamstrings}, SecondaryMuscles: []MuscleGroup{Glutes, Back}},
+	{MovementName: "hip thrust", MovementCategoryID: "hinge", Equipment: Barbell, PrimaryMuscles: []MuscleGroup{Glutes}, SecondaryMuscles: []MuscleGroup{Hamstrings}},
+	{MovementName: "bench press", MovementCategoryID: "horizontal-press", Equipment: Barbell, PrimaryMuscles: []MuscleGroup{Chest}, SecondaryMuscles: []MuscleGroup{Triceps, Shoulders}},
+	{MovementName: "dumbbell bench press", MovementCategoryID: "horizontal-press", Equipment: Dumbbell, PrimaryMuscles: []MuscleGroup{Chest}, SecondaryMuscles: []MuscleGroup{Triceps, Shoulders}},
+	{MovementName: "push-up", MovementCategoryID: "horizontal-press", Equipment: Bodyweight, LoadType: BodyweightLoad, PrimaryMuscles: []MuscleGroup{Chest}, SecondaryMuscles: []MuscleGroup{Triceps, Shoulders, Core}},
+	{MovementName: "overhead press", MovementCategoryID: "vertical-press", Equipment: Barbell, PrimaryMuscles: []MuscleGroup{Shoulders}, SecondaryMuscles: []MuscleGroup{Triceps, Core}},
+	{MovementName: "push press", MovementCategoryID: "vertical-press", Equipment: Barbell, PrimaryMuscles: []MuscleGroup{Shoulders}, SecondaryMuscles: []MuscleGroup{Triceps, Quadriceps}},
+	{MovementName: "barbell row", MovementCategoryID: "horizontal-pull", Equipment: Barbell, PrimaryMuscles: []MuscleGroup{Back, Lats}, SecondaryMuscles: []MuscleGroup{Biceps, Forearms}},
+	{MovementName: "dumbbell row", MovementCategoryID: "horizontal-pull", Equipment: Dumbbell, Laterality: Unilateral, PrimaryMuscles: []MuscleGroup{Back, Lats}, SecondaryMuscles: []MuscleGroup{Biceps}},
+	{MovementName: "pull-up", MovementCategoryID: "vertical-pull", Equipment: Bodyweight, LoadType: BodyweightLoad, PrimaryMuscles: []MuscleGroup{Lats}, SecondaryMuscles: []MuscleGroup{Biceps, Back}},
+	{MovementName: "chin-up", MovementCategoryID: "vertical-pull", Equipment: Bodyweight, LoadType: BodyweightLoad, PrimaryMuscles: []MuscleGroup{Lats, Biceps}, SecondaryMuscles: []MuscleGroup{Back}},
+	{MovementName: "lat pulldown", MovementCategoryID: "vertical-pull", Equipment: Cable, PrimaryMuscles: []MuscleGroup{Lats}, SecondaryMuscles: []MuscleGroup{Biceps}},
+	{MovementName: "farmer's carry", MovementCategoryID: "carry", Equipment: Dumbbell, PrimaryMuscles: []MuscleGroup{Forearms, Traps}, SecondaryMuscles: []MuscleGroup{Core}},
+	{MovementName: "run", MovementCategoryID: "conditioning", Equipment: Bodyweight, LoadType: DistanceLoad, PrimaryMuscles: []MuscleGroup{Cardio}},
+	{MovementName: "row", MovementCategoryID: "conditioning", Equipment: Ergometer, LoadType: DistanceLoad, PrimaryMuscles: []MuscleGroup{Cardio}},
+	{MovementName: "bike", MovementCategoryID: "conditioning", Equipment: Ergometer, LoadType: TimeLoad, PrimaryMuscles: []MuscleGroup{Cardio}},
+}
+
+// SeedGlobalMovements populates the global catalog with the starter library
+// when it is empty, so that every tenant starts with a usable set of
+// movements.
+func SeedGlobalMovements(ctx context.Context, repo MovementRepository) error {
+	existing, err := repo.ListMovements(ctx, SystemTenantID, MovementFilter{ShowDeleted: true})
+	if err != nil {
+		return errors.Wrap(err, "failed to list global movements")
+	}
+	if len(existing) > 0 {
+		return nil
+	}
+	for _, m := range starterMovements {
+		m = withMovementDefaults(m)
+		m.Name = uuid.New().String()
+		m.TenantID = SystemTenantID
+		if _, err := repo.CreateMovement(ctx, m); err != nil {
+			return errors.Wrapf(err, "failed to seed %q", m.MovementName)
+		}
+	}
+	return nil
+}
+
+// PurgeDeletedMovements permanently removes movements that were deleted more
+// than retention ago. Movements still referenced by a workout set,
+// conditioning effort or template are kept so that history never points at
+// nothing and templates can still be cloned.
+func PurgeDeletedMovements(ctx context.Context, repo MovementRepository, retention time.Duration) (int64, error) {
+	n, err := repo.PurgeMovements(ctx, time.Now().UTC().Add(-retention))
+	return n, errors.Wrap(err, "failed to purge deleted movements")
+}
+
+// RunMovementPurge calls PurgeDeletedMovements every interval until ctx is
+// cancelled.
+func RunMovementPurge(ctx context.Context, logger logging.IshiLogger, repo MovementRepository, retention time.Duration, interval time.Duration) {
+	logger = logger.WithFields("job", "movement-purge")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := PurgeDeletedMovements(ctx, repo, retention)
+			if err != nil {
+				logger.Error("purge failed", "err", err)
+				continue
+			}
+			logger.Info("purge complete", "purged", n, "retention", retention)
+		}
+	}
+}
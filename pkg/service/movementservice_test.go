@@ -0,0 +1,548 @@
+package service_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/inmem"
+	"workout-manager-service/pkg/service"
+)
+
+func TestMovementEtags(t *testing.T) {
+	ctx := service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: "u1", TenantID: "t1", Role: service.RoleAdmin})
+	s := inmem.NewStore()
+	m, err := s.CreateMovement(ctx, service.Movement{Name: "squat", TenantID: "t1", MovementName: "Squat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := service.NewBasicMovementService(s, nil)
+	stale := m.Etag()
+
+	m.MovementName = "Back Squat"
+	updated, err := svc.Update(ctx, m, stale)
+	if err != nil {
+		t.Fatalf("Update() with the current etag = %v", err)
+	}
+	if updated.Etag() == stale || updated.MovementName != "Back Squat" {
+		t.Fatalf("Update() = %+v, want the new name under a new etag", updated)
+	}
+
+	m.MovementName = "High-Bar Squat"
+	if _, err := svc.Update(ctx, m, stale); errors.Cause(err) != service.ErrConflict {
+		t.Errorf("Update() with a stale etag = %v, want %v", err, service.ErrConflict)
+	}
+	if err := svc.Delete(ctx, "squat", stale); errors.Cause(err) != service.ErrConflict {
+		t.Errorf("Delete() with a stale etag = %v, want %v", err, service.ErrConflict)
+	}
+	results, err := svc.BatchDelete(ctx, []service.MovementDeletion{{ID: "squat", Etag: stale}}, true)
+	if err != nil || len(results) != 1 || errors.Cause(results[0].Err) != service.ErrConflict {
+		t.Errorf("BatchDelete() with a stale etag = %+v, %v, want the item to conflict", results, err)
+	}
+	if got, _ := s.GetMovement(ctx, "squat"); got.MovementName != "Back Squat" || got.Deleted() {
+		t.Fatalf("movement = %+v, want it untouched by stale writes", got)
+	}
+
+	// An empty etag skips the check.
+	if updated, err = svc.Update(ctx, m, ""); err != nil || updated.MovementName != "High-Bar Squat" {
+		t.Fatalf("Update() without an etag = %+v, %v", updated, err)
+	}
+	if err := svc.Delete(ctx, "squat", updated.Etag()); err != nil {
+		t.Errorf("Delete() with the current etag = %v", err)
+	}
+}
+
+func TestMovementWritesCompareVersions(t *testing.T) {
+	ctx := context.Background()
+	s := inmem.NewStore()
+	m, err := s.CreateMovement(ctx, service.Movement{Name: "squat", TenantID: "t1", MovementName: "Squat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Two writers that read the same version: only the first may win.
+	if _, err := s.UpdateMovement(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UpdateMovement(ctx, m); errors.Cause(err) != service.ErrConflict {
+		t.Errorf("second UpdateMovement() = %v, want %v", err, service.ErrConflict)
+	}
+	if err := s.SetMovementDeleteTime(ctx, "squat", time.Now(), m.Version); errors.Cause(err) != service.ErrConflict {
+		t.Errorf("SetMovementDeleteTime() = %v, want %v", err, service.ErrConflict)
+	}
+	if err := s.SetMovementsDeleteTime(ctx, []service.Movement{m}, time.Now()); errors.Cause(err) != service.ErrConflict {
+		t.Errorf("SetMovementsDeleteTime() = %v, want %v", err, service.ErrConflict)
+	}
+}
+
+func TestMergeMovementsRedirects(t *testing.T) {
+	ctx := service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: "u1", TenantID: "t1", Role: service.RoleAdmin})
+	s := inmem.NewStore()
+	for _, m := range []service.Movement{
+		{Name: "squat", TenantID: "t1", MovementName: "Squat"},
+		{Name: "back-squat", TenantID: "t1", MovementName: "Back Squat", Aliases: []string{"BS"}},
+		{Name: "low-bar", TenantID: "t1", MovementName: "Low-Bar Squat"},
+		{Name: "their-squat", TenantID: "t2", MovementName: "Squat"},
+		{Name: "global-squat", TenantID: service.SystemTenantID, MovementName: "Squat"},
+	} {
+		if _, err := s.CreateMovement(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc := service.NewBasicMovementService(s, nil)
+
+	for _, tc := range []struct {
+		name       string
+		duplicates []string
+		want       error
+	}{
+		{"no duplicates", nil, service.ErrInvalidArgument},
+		{"into itself", []string{"squat"}, service.ErrInvalidArgument},
+		{"a global movement", []string{"global-squat"}, service.ErrInvalidArgument},
+		{"another tenant's movement", []string{"their-squat"}, service.ErrNotFound},
+	} {
+		if _, err := svc.Merge(ctx, "squat", tc.duplicates); errors.Cause(err) != tc.want {
+			t.Errorf("%s: Merge() = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	// The duplicates' names would be lost as aliases of a global movement.
+	if _, err := svc.Merge(ctx, "global-squat", []string{"low-bar"}); errors.Cause(err) != service.ErrInvalidArgument {
+		t.Errorf("Merge() into a global movement = %v, want %v", err, service.ErrInvalidArgument)
+	}
+	if _, err := svc.Get(ctx, "low-bar", false); err != nil {
+		t.Errorf("a rejected merge removed the duplicate: %v", err)
+	}
+
+	if _, err := svc.Merge(ctx, "back-squat", []string{"low-bar"}); err != nil {
+		t.Fatal(err)
+	}
+	canonical, err := svc.Merge(ctx, "squat", []string{"back-squat", "back-squat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Back Squat", "BS", "Low-Bar Squat"}
+	if !reflect.DeepEqual(canonical.Aliases, want) {
+		t.Errorf("aliases = %v, want %v", canonical.Aliases, want)
+	}
+	// Merges chain: low-bar went into back-squat, which went into squat.
+	for _, id := range []string{"back-squat", "low-bar"} {
+		if m, err := svc.Get(ctx, id, false); err != nil || m.Name != "squat" {
+			t.Errorf("Get(%s) = %s, %v, want the canonical squat", id, m.Name, err)
+		}
+	}
+	other := service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: "u2", TenantID: "t2", Role: service.RoleAdmin})
+	if _, err := svc.Get(other, "back-squat", false); errors.Cause(err) != service.ErrNotFound {
+		t.Errorf("Get() of another tenant's merged movement = %v, want %v", err, service.ErrNotFound)
+	}
+}
+
+func TestSearchMovementsRanks(t *testing.T) {
+	ctx := service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: "u1", TenantID: "t1", Role: service.RoleAdmin})
+	s := inmem.NewStore()
+	for _, m := range []service.Movement{
+		{Name: "squat", TenantID: "t1", MovementName: "Squat"},
+		{Name: "zercher", TenantID: "t1", MovementName: "Zercher Lift", Aliases: []string{"Zercher Squat"}},
+		{Name: "box-fork", TenantID: "t1", MovementName: "Box Squat", ForkedFrom: "box-squat"},
+		{Name: "their-squat", TenantID: "t2", MovementName: "Squat"},
+		{Name: "squat-jump", TenantID: service.SystemTenantID, MovementName: "Squat Jump"},
+		{Name: "box-squat", TenantID: service.SystemTenantID, MovementName: "Box Squat"},
+		{Name: "overhead-squat", TenantID: service.SystemTenantID, MovementName: "Overhead Squat"},
+		{Name: "hack", TenantID: service.SystemTenantID, MovementName: "Hack Machine"},
+		{Name: "row", TenantID: service.SystemTenantID, MovementName: "Row"},
+	} {
+		if _, err := s.CreateMovement(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, o := range []service.MovementOverride{
+		{TenantID: "t1", MovementID: "overhead-squat", Hidden: true},
+		{TenantID: "t1", MovementID: "hack", Alias: "Hack Squat"},
+	} {
+		if err := s.PutMovementOverride(ctx, o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc := service.NewBasicMovementService(s, nil)
+
+	for _, tc := range []struct {
+		query string
+		limit int
+		want  []string
+	}{
+		// An exact match, a prefix, then words of names, renames and aliases.
+		{"squat", 0, []string{"squat", "squat-jump", "box-fork", "hack", "zercher"}},
+		{"Squat", 2, []string{"squat", "squat-jump"}},
+		{"zercher sq", 0, []string{"zercher"}},
+		{"hack sq", 0, []string{"hack"}},
+		// Typos rank below any other match.
+		{"sqaut", 0, []string{"box-fork", "hack", "squat", "squat-jump", "zercher"}},
+		{"rwo", 0, nil},
+	} {
+		matches, err := svc.Search(ctx, tc.query, tc.limit)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.query, err)
+		}
+		var got []string
+		for _, m := range matches {
+			got = append(got, m.Movement.Name)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Search(%q) = %v, want %v", tc.query, got, tc.want)
+		}
+	}
+	matches, err := svc.Search(ctx, "hack squat", 1)
+	if err != nil || len(matches) != 1 || matches[0].Movement.MovementName != "Hack Squat" || matches[0].Score != 1 {
+		t.Errorf("Search() = %+v, %v, want the renamed global movement as an exact match", matches, err)
+	}
+	if _, err := svc.Search(ctx, " - ", 0); errors.Cause(err) != service.ErrInvalidArgument {
+		t.Errorf("Search() of punctuation = %v, want %v", err, service.ErrInvalidArgument)
+	}
+}
+
+// newGlobalCatalog returns a store with three global movements and a curl of
+// t1's own, and the contexts of admins of t1 and t2.
+func newGlobalCatalog(t *testing.T) (*inmem.Store, context.Context, context.Context) {
+	t.Helper()
+	s := inmem.NewStore()
+	for _, m := range []service.Movement{
+		{Name: "squat", TenantID: service.SystemTenantID, MovementName: "Squat"},
+		{Name: "bench", TenantID: service.SystemTenantID, MovementName: "Bench Press"},
+		{Name: "row", TenantID: service.SystemTenantID, MovementName: "Row"},
+		{Name: "curl", TenantID: "t1", MovementName: "Curl"},
+	} {
+		if _, err := s.CreateMovement(context.Background(), m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t1 := service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: "u1", TenantID: "t1", Role: service.RoleAdmin})
+	t2 := service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: "u2", TenantID: "t2", Role: service.RoleAdmin})
+	return s, t1, t2
+}
+
+// movementNames lists the names of the movements in the caller's view.
+func movementNames(t *testing.T, svc service.MovementService, ctx context.Context) []string {
+	t.Helper()
+	ms, err := svc.List(ctx, service.MovementFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(ms))
+	for i, m := range ms {
+		names[i] = m.MovementName
+	}
+	return names
+}
+
+func TestMovementOverrides(t *testing.T) {
+	s, ctx, other := newGlobalCatalog(t)
+	svc := service.NewBasicMovementService(s, nil)
+	if got, want := movementNames(t, svc, ctx), []string{"Bench Press", "Curl", "Row", "Squat"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("movements = %v, want %v", got, want)
+	}
+
+	if m, err := svc.Override(ctx, "bench", true, ""); err != nil || !m.Hidden {
+		t.Fatalf("Override() hiding = %+v, %v", m, err)
+	}
+	if m, err := svc.Override(ctx, "squat", false, "Back Squat"); err != nil || m.MovementName != "Back Squat" || m.TenantID != service.SystemTenantID {
+		t.Fatalf("Override() aliasing = %+v, %v", m, err)
+	}
+	if got, want := movementNames(t, svc, ctx), []string{"Back Squat", "Curl", "Row"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("movements = %v, want %v", got, want)
+	}
+	if m, err := svc.Get(ctx, "bench", false); err != nil || !m.Hidden {
+		t.Errorf("Get() of a hidden movement = %+v, %v, want it marked hidden", m, err)
+	}
+	if got, want := movementNames(t, svc, other), []string{"Bench Press", "Row", "Squat"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("other tenant's movements = %v, want %v", got, want)
+	}
+
+	// Neither hiding nor aliasing removes the override.
+	if _, err := svc.Override(ctx, "bench", false, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := movementNames(t, svc, ctx), []string{"Back Squat", "Bench Press", "Curl", "Row"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("movements = %v, want %v", got, want)
+	}
+
+	system := service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: "op", TenantID: service.SystemTenantID, Role: service.RoleAdmin})
+	for _, tc := range []struct {
+		name string
+		ctx  context.Context
+		id   string
+		err  error
+	}{
+		{"own movement", ctx, "curl", service.ErrInvalidArgument},
+		{"unknown movement", ctx, "nope", service.ErrNotFound},
+		{"global catalog", system, "squat", service.ErrInvalidArgument},
+	} {
+		if _, err := svc.Override(tc.ctx, tc.id, true, ""); errors.Cause(err) != tc.err {
+			t.Errorf("%s: Override() = %v, want %v", tc.name, err, tc.err)
+		}
+	}
+}
+
+func TestForkMovement(t *testing.T) {
+	s, ctx, other := newGlobalCatalog(t)
+	svc := service.NewBasicMovementService(s, nil)
+	if _, err := svc.Override(ctx, "squat", false, "Back Squat"); err != nil {
+		t.Fatal(err)
+	}
+
+	fork, err := svc.Fork(ctx, "squat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fork.TenantID != "t1" || fork.ForkedFrom != "squat" || fork.Name == "squat" || fork.MovementName != "Back Squat" {
+		t.Fatalf("fork = %+v, want t1's copy of the aliased squat", fork)
+	}
+	if _, err := s.GetMovementOverride(ctx, "t1", "squat"); errors.Cause(err) != service.ErrNotFound {
+		t.Errorf("override after forking = %v, want it superseded", err)
+	}
+	if again, err := svc.Fork(ctx, "squat"); err != nil || again.Name != fork.Name {
+		t.Errorf("second Fork() = %+v, %v, want the existing fork", again, err)
+	}
+
+	fork.MovementName = "High-Bar Squat"
+	if _, err := svc.Update(ctx, fork, ""); err != nil {
+		t.Fatal(err)
+	}
+	if m, err := svc.Get(ctx, "squat", false); err != nil || m.Name != fork.Name || m.MovementName != "High-Bar Squat" {
+		t.Errorf("Get() of the global movement = %+v, %v, want the fork", m, err)
+	}
+	if got, want := movementNames(t, svc, ctx), []string{"Bench Press", "Curl", "High-Bar Squat", "Row"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("movements = %v, want %v", got, want)
+	}
+	if m, err := svc.Get(other, "squat", false); err != nil || m.MovementName != "Squat" {
+		t.Errorf("other tenant's squat = %+v, %v, want the global one", m, err)
+	}
+
+	if err := svc.Delete(ctx, fork.Name, ""); err != nil {
+		t.Fatal(err)
+	}
+	if m, err := svc.Get(ctx, "squat", false); err != nil || m.Name != "squat" {
+		t.Errorf("Get() after deleting the fork = %+v, %v, want the global movement", m, err)
+	}
+	if restored, err := svc.Fork(ctx, "squat"); err != nil || restored.Name != fork.Name || restored.Deleted() || restored.MovementName != "High-Bar Squat" {
+		t.Errorf("Fork() after deleting the fork = %+v, %v, want it restored", restored, err)
+	}
+
+	if _, err := svc.Fork(ctx, "curl"); errors.Cause(err) != service.ErrInvalidArgument {
+		t.Errorf("Fork() of an own movement = %v, want %v", err, service.ErrInvalidArgument)
+	}
+	bench, _ := s.GetMovement(ctx, "bench")
+	if err := s.SetMovementDeleteTime(ctx, "bench", time.Now(), bench.Version); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Fork(ctx, "bench"); errors.Cause(err) != service.ErrNotFound {
+		t.Errorf("Fork() of a deleted global movement = %v, want %v", err, service.ErrNotFound)
+	}
+	row, _ := svc.Get(ctx, "row", false)
+	row.MovementName = "Barbell Row"
+	if _, err := svc.Update(ctx, row, ""); errors.Cause(err) != service.ErrPermissionDenied {
+		t.Errorf("Update() of a global movement = %v, want %v", err, service.ErrPermissionDenied)
+	}
+	if err := svc.Delete(ctx, "row", ""); errors.Cause(err) != service.ErrPermissionDenied {
+		t.Errorf("Delete() of a global movement = %v, want %v", err, service.ErrPermissionDenied)
+	}
+}
+
+func TestMovementMetadata(t *testing.T) {
+	ctx := service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: "u1", TenantID: "t1", Role: service.RoleAdmin})
+	svc := service.NewBasicMovementService(inmem.NewStore(), nil)
+
+	squat, err := svc.Create(ctx, service.Movement{
+		MovementName:     "Squat",
+		Equipment:        service.Barbell,
+		PrimaryMuscles:   []service.MuscleGroup{service.Quadriceps, service.Glutes},
+		SecondaryMuscles: []service.MuscleGroup{service.Core},
+		Links:            []string{"https://example.com/squat"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if squat.Laterality != service.Bilateral || squat.LoadType != service.ExternalLoad {
+		t.Errorf("squat = %+v, want bilateral and externally loaded by default", squat)
+	}
+	if got, err := svc.Get(ctx, squat.Name, false); err != nil || !reflect.DeepEqual(got.PrimaryMuscles, squat.PrimaryMuscles) || got.Links[0] != "https://example.com/squat" {
+		t.Errorf("Get() = %+v, %v, want the metadata stored", got, err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		m    service.Movement
+	}{
+		{"no name", service.Movement{Equipment: service.Barbell}},
+		{"unknown equipment", service.Movement{MovementName: "Row", Equipment: "trampoline"}},
+		{"unknown primary muscle", service.Movement{MovementName: "Row", PrimaryMuscles: []service.MuscleGroup{"wings"}}},
+		{"unknown secondary muscle", service.Movement{MovementName: "Row", SecondaryMuscles: []service.MuscleGroup{"wings"}}},
+		{"unknown laterality", service.Movement{MovementName: "Row", Laterality: "sideways"}},
+		{"unknown load type", service.Movement{MovementName: "Row", LoadType: "vibes"}},
+		{"relative link", service.Movement{MovementName: "Row", Links: []string{"/row"}}},
+		{"link without http", service.Movement{MovementName: "Row", Links: []string{"ftp://example.com/row"}}},
+	} {
+		if _, err := svc.Create(ctx, tc.m); errors.Cause(err) != service.ErrInvalidArgument {
+			t.Errorf("%s: Create() = %v, want %v", tc.name, err, service.ErrInvalidArgument)
+		}
+		m := tc.m
+		m.Name = squat.Name
+		if _, err := svc.Update(ctx, m, ""); errors.Cause(err) != service.ErrInvalidArgument {
+			t.Errorf("%s: Update() = %v, want %v", tc.name, err, service.ErrInvalidArgument)
+		}
+	}
+}
+
+func TestListMovementsFilters(t *testing.T) {
+	ctx := service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: "u1", TenantID: "t1", Role: service.RoleAdmin})
+	svc := service.NewBasicMovementService(inmem.NewStore(), nil)
+	for _, m := range []service.Movement{
+		{MovementName: "Squat", MovementCategoryID: "legs", Equipment: service.Barbell, PrimaryMuscles: []service.MuscleGroup{service.Quadriceps}, SecondaryMuscles: []service.MuscleGroup{service.Core}},
+		{MovementName: "Split Squat", MovementCategoryID: "legs", Equipment: service.Dumbbell, Laterality: service.Unilateral, PrimaryMuscles: []service.MuscleGroup{service.Quadriceps}},
+		{MovementName: "Pull-Up", Equipment: service.Bodyweight, LoadType: service.BodyweightLoad, PrimaryMuscles: []service.MuscleGroup{service.Lats}, SecondaryMuscles: []service.MuscleGroup{service.Biceps}},
+		{MovementName: "Plank", Equipment: service.Bodyweight, LoadType: service.TimeLoad, PrimaryMuscles: []service.MuscleGroup{service.Core}},
+	} {
+		if _, err := svc.Create(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		filter service.MovementFilter
+		want   []string
+	}{
+		{"everything", service.MovementFilter{}, []string{"Plank", "Pull-Up", "Split Squat", "Squat"}},
+		{"category", service.MovementFilter{CategoryID: "legs"}, []string{"Split Squat", "Squat"}},
+		{"equipment", service.MovementFilter{Equipment: service.Bodyweight}, []string{"Plank", "Pull-Up"}},
+		{"primary or secondary muscle", service.MovementFilter{Muscle: service.Core}, []string{"Plank", "Squat"}},
+		{"laterality", service.MovementFilter{Laterality: service.Unilateral}, []string{"Split Squat"}},
+		{"load type", service.MovementFilter{LoadType: service.TimeLoad}, []string{"Plank"}},
+		{"combined", service.MovementFilter{Equipment: service.Bodyweight, Muscle: service.Biceps}, []string{"Pull-Up"}},
+		{"nothing matches", service.MovementFilter{Equipment: service.Cable}, []string{}},
+	} {
+		ms, err := svc.List(ctx, tc.filter)
+		if err != nil {
+			t.Fatalf("%s: List() = %v", tc.name, err)
+		}
+		got := make([]string, len(ms))
+		for i, m := range ms {
+			got[i] = m.MovementName
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: movements = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	for _, filter := range []service.MovementFilter{
+		{Equipment: "trampoline"},
+		{Muscle: "wings"},
+		{Laterality: "sideways"},
+		{LoadType: "vibes"},
+	} {
+		if _, err := svc.List(ctx, filter); errors.Cause(err) != service.ErrInvalidArgument {
+			t.Errorf("List(%+v) = %v, want %v", filter, err, service.ErrInvalidArgument)
+		}
+	}
+}
+
+func TestSoftDeleteMovements(t *testing.T) {
+	ctx := service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: "u1", TenantID: "t1", Role: service.RoleAdmin})
+	other := service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: "u2", TenantID: "t2", Role: service.RoleAdmin})
+	s := inmem.NewStore()
+	for _, name := range []string{"squat", "bench"} {
+		if _, err := s.CreateMovement(ctx, service.Movement{Name: name, TenantID: "t1", MovementName: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc := service.NewBasicMovementService(s, nil)
+
+	if err := svc.Delete(ctx, "squat", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Get(ctx, "squat", false); errors.Cause(err) != service.ErrNotFound {
+		t.Errorf("Get() of a deleted movement = %v, want %v", err, service.ErrNotFound)
+	}
+	if m, err := svc.Get(ctx, "squat", true); err != nil || !m.Deleted() {
+		t.Errorf("Get() showing deleted = %+v, %v, want the tombstone", m, err)
+	}
+	if ms, _ := svc.List(ctx, service.MovementFilter{}); len(ms) != 1 || ms[0].Name != "bench" {
+		t.Errorf("movements = %+v, want only the bench press", ms)
+	}
+	if ms, _ := svc.List(ctx, service.MovementFilter{ShowDeleted: true}); len(ms) != 2 {
+		t.Errorf("movements showing deleted = %+v, want both", ms)
+	}
+	if err := svc.Delete(ctx, "squat", ""); errors.Cause(err) != service.ErrNotFound {
+		t.Errorf("second Delete() = %v, want %v", err, service.ErrNotFound)
+	}
+
+	for _, tc := range []struct {
+		name string
+		ctx  context.Context
+		id   string
+		err  error
+	}{
+		{"another tenant's movement", other, "squat", service.ErrNotFound},
+		{"unknown movement", ctx, "nope", service.ErrNotFound},
+		{"no caller", context.Background(), "squat", service.ErrUnauthenticated},
+	} {
+		if _, err := svc.Undelete(tc.ctx, tc.id); errors.Cause(err) != tc.err {
+			t.Errorf("%s: Undelete() = %v, want %v", tc.name, err, tc.err)
+		}
+	}
+	restored, err := svc.Undelete(ctx, "squat")
+	if err != nil || restored.Deleted() {
+		t.Fatalf("Undelete() = %+v, %v, want the squat restored", restored, err)
+	}
+	if again, err := svc.Undelete(ctx, "squat"); err != nil || again.Version != restored.Version {
+		t.Errorf("Undelete() of a live movement = %+v, %v, want it unchanged", again, err)
+	}
+	if _, err := svc.Get(ctx, "squat", false); err != nil {
+		t.Errorf("Get() of a restored movement = %v", err)
+	}
+}
+
+func TestPurgeDeletedMovements(t *testing.T) {
+	ctx := service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: "u1", TenantID: "t1", Role: service.RoleAdmin})
+	s := inmem.NewStore()
+	const retention = 30 * 24 * time.Hour
+	expired := time.Now().Add(-retention - time.Hour)
+	for _, tc := range []struct {
+		id         string
+		deleteTime time.Time
+	}{
+		{"squat", time.Time{}},
+		{"bench", expired},
+		{"row", expired},
+		{"curl", time.Now()},
+	} {
+		m, err := s.CreateMovement(ctx, service.Movement{Name: tc.id, TenantID: "t1", MovementName: tc.id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tc.deleteTime.IsZero() {
+			if err := s.SetMovementDeleteTime(ctx, tc.id, tc.deleteTime, m.Version); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// The row is still logged in a workout.
+	if _, err := s.CreateWorkout(ctx, service.Workout{Name: "w1", TenantID: "t1", AthleteID: "a1", Sets: []service.WorkoutSet{{MovementID: "row", Reps: 5}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := service.PurgeDeletedMovements(ctx, s, retention)
+	if err != nil || n != 1 {
+		t.Fatalf("PurgeDeletedMovements() = %d, %v, want the bench press purged", n, err)
+	}
+	if _, err := s.GetMovement(ctx, "bench"); errors.Cause(err) != service.ErrNotFound {
+		t.Errorf("purged movement = %v, want %v", err, service.ErrNotFound)
+	}
+	for _, id := range []string{"squat", "row", "curl"} {
+		if _, err := s.GetMovement(ctx, id); err != nil {
+			t.Errorf("%s was purged: %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/logging"
+)
+
+// DomainEventType names what happened to an aggregate.
+type DomainEventType string
+
+// The domain events published to other services. Movement events carry the
+// movement as it was written, except merges, which carry the MovementMerge.
+// Workout events carry the workout.
+const (
+	MovementCreatedEvent  DomainEventType = "movement.created"
+	MovementUpdatedEvent  DomainEventType = "movement.updated"
+	MovementDeletedEvent  DomainEventType = "movement.deleted"
+	MovementRestoredEvent DomainEventType = "movement.restored"
+	MovementMergedEvent   DomainEventType = "movement.merged"
+	MovementPurgedEvent   DomainEventType = "movement.purged"
+	WorkoutCreatedEvent   DomainEventType = "workout.created"
+	WorkoutRatedEvent     DomainEventType = "workout.rated"
+	WorkoutDeletedEvent   DomainEventType = "workout.deleted"
+)
+
+// The aggregates domain events are about.
+const (
+	MovementAggregate = "movement"
+	WorkoutAggregate  = "workout"
+)
+
+// DomainEvent is a change to a movement or workout that other services may
+// react to. Events are delivered at least once, so consumers should ignore
+// IDs they have already handled; events of the same aggregate are delivered
+// in the order they happened.
+type DomainEvent struct {
+	ID            string          `json:"id"`
+	Type          DomainEventType `json:"type"`
+	AggregateType string          `json:"aggregateType"`
+	AggregateID   string          `json:"aggregateId"`
+	TenantID      string          `json:"tenantId"`
+	Time          time.Time       `json:"time"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// NewMovementDomainEvent returns an event about a movement, carrying it.
+func NewMovementDomainEvent(t DomainEventType, m Movement) DomainEvent {
+	return newDomainEvent(t, MovementAggregate, m.Name, m.TenantID, m)
+}
+
+// NewMergeDomainEvent returns the event about a merge. The canonical movement
+// is its aggregate; the duplicates no longer exist.
+func NewMergeDomainEvent(merge MovementMerge) DomainEvent {
+	return newDomainEvent(MovementMergedEvent, MovementAggregate, merge.CanonicalID, merge.TenantID, merge)
+}
+
+// NewWorkoutDomainEvent returns an event about a workout, carrying it.
+func NewWorkoutDomainEvent(t DomainEventType, w Workout) DomainEvent {
+	return newDomainEvent(t, WorkoutAggregate, w.Name, w.TenantID, w)
+}
+
+func newDomainEvent(t DomainEventType, aggregateType string, aggregateID string, tenantID string, data interface{}) DomainEvent {
+	// The payloads are plain structs, which always marshal.
+	b, _ := json.Marshal(data)
+	return DomainEvent{
+		ID:            uuid.New().String(),
+		Type:          t,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		TenantID:      tenantID,
+		Time:          time.Now().UTC(),
+		Data:          b,
+	}
+}
+
+// OutboxRepository holds domain events until they are published. The
+// movement and workout repositories add an event to the outbox in the same
+// transaction as every write it describes, so that no change goes
+// unpublished and no event describes a change that was rolled back.
+// ListOutboxEvents returns the oldest events first, in the order their
+// writes were committed. AddOutboxEvents adds events that are derived from
+// writes already committed, such as alerts; events whose ID is already in
+// the outbox are skipped.
+type OutboxRepository interface {
+	AddOutboxEvents(ctx context.Context, events []DomainEvent) error
+	ListOutboxEvents(ctx context.Context, limit int) ([]DomainEvent, error)
+	DeleteOutboxEvents(ctx context.Context, ids []string) error
+}
+
+// Broker delivers domain events to other services. Publish returns once the
+// broker has accepted the event for delivery.
+type Broker interface {
+	Publish(ctx context.Context, e DomainEvent) error
+}
+
+// Brokers publishes every event to each of several brokers in turn. An event
+// that any of them fails to accept is published to all of them again, so
+// each must tolerate duplicates.
+type Brokers []Broker
+
+// Publish implements Broker.
+func (bs Brokers) Publish(ctx context.Context, e DomainEvent) error {
+	for _, b := range bs {
+		if err := b.Publish(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RelayOutbox publishes up to limit events from the outbox and removes the
+// ones that were published. When an event cannot be published, the later
+// events of its aggregate are held back so that they are never delivered out
+// of order; events of other aggregates are still published. An event that
+// was published but could not be removed is published again on a later call.
+func RelayOutbox(ctx context.Context, repo OutboxRepository, broker Broker, limit int) (int, error) {
+	events, err := repo.ListOutboxEvents(ctx, limit)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list outbox events")
+	}
+	var (
+		published []string
+		held      = make(map[string]bool)
+		failure   error
+	)
+	for _, e := range events {
+		aggregate := e.AggregateType + "/" + e.AggregateID
+		if held[aggregate] {
+			continue
+		}
+		if err := broker.Publish(ctx, e); err != nil {
+			held[aggregate] = true
+			if failure == nil {
+				failure = errors.Wrapf(err, "failed to publish %s event %s", e.Type, e.ID)
+			}
+			continue
+		}
+		published = append(published, e.ID)
+	}
+	if len(published) > 0 {
+		if err := repo.DeleteOutboxEvents(ctx, published); err != nil {
+			return 0, errors.Wrap(err, "failed to delete published outbox events")
+		}
+	}
+	return len(published), failure
+}
+
+// RunOutboxRelay calls RelayOutbox every interval until ctx is cancelled,
+// draining the outbox batch by batch. Only one relay should run per
+// database: two would deliver each event twice and could interleave the
+// events of an aggregate.
+func RunOutboxRelay(ctx context.Context, logger logging.IshiLogger, repo OutboxRepository, broker Broker, batch int, interval time.Duration) {
+	logger = logger.WithFields("job", "outbox-relay")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				n, err := RelayOutbox(ctx, repo, broker, batch)
+				if err != nil {
+					logger.Error("relay failed", "published", n, "err", err)
+					break
+				}
+				if n > 0 {
+					logger.Debug("relay complete", "published", n)
+				}
+				if n < batch {
+					break
+				}
+			}
+		}
+	}
+}
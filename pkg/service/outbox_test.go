@@ -0,0 +1,107 @@
+package service_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/inmem"
+	"workout-manager-service/pkg/service"
+)
+
+// recordingBroker records the IDs of the events it accepts and rejects the
+// ones in fail.
+type recordingBroker struct {
+	fail      map[string]bool
+	published []string
+}
+
+func (b *recordingBroker) Publish(_ context.Context, e service.DomainEvent) error {
+	if b.fail[e.ID] {
+		return errors.New("broker unavailable")
+	}
+	b.published = append(b.published, e.ID)
+	return nil
+}
+
+func outboxEvent(id string, aggregateID string) service.DomainEvent {
+	return service.DomainEvent{
+		ID:            id,
+		Type:          service.MovementUpdatedEvent,
+		AggregateType: service.MovementAggregate,
+		AggregateID:   aggregateID,
+		TenantID:      "t1",
+	}
+}
+
+func TestRelayOutboxHoldsBackFailedAggregates(t *testing.T) {
+	ctx := context.Background()
+	s := inmem.NewStore()
+	err := s.AddOutboxEvents(ctx, []service.DomainEvent{
+		outboxEvent("squat-1", "squat"),
+		outboxEvent("press-1", "press"),
+		outboxEvent("squat-2", "squat"),
+		outboxEvent("press-2", "press"),
+		outboxEvent("squat-3", "squat"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	broker := &recordingBroker{fail: map[string]bool{"squat-2": true}}
+	n, err := service.RelayOutbox(ctx, s, broker, 10)
+	if n != 3 || err == nil {
+		t.Fatalf("RelayOutbox() = %d, %v, want 3 and the publish error", n, err)
+	}
+	if want := []string{"squat-1", "press-1", "press-2"}; !reflect.DeepEqual(broker.published, want) {
+		t.Fatalf("published %v, want %v; squat-3 must wait for squat-2", broker.published, want)
+	}
+
+	broker.fail = nil
+	if n, err := service.RelayOutbox(ctx, s, broker, 10); n != 2 || err != nil {
+		t.Fatalf("retry: RelayOutbox() = %d, %v, want 2, nil", n, err)
+	}
+	if want := []string{"squat-1", "press-1", "press-2", "squat-2", "squat-3"}; !reflect.DeepEqual(broker.published, want) {
+		t.Errorf("published %v, want %v", broker.published, want)
+	}
+	if left, _ := s.ListOutboxEvents(ctx, 10); len(left) != 0 {
+		t.Errorf("%d events are left in the outbox", len(left))
+	}
+}
+
+func TestRelayOutboxLimit(t *testing.T) {
+	ctx := context.Background()
+	s := inmem.NewStore()
+	for _, id := range []string{"e1", "e2", "e3"} {
+		if err := s.AddOutboxEvents(ctx, []service.DomainEvent{outboxEvent(id, id)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Adding an event that is already in the outbox is a no-op.
+	if err := s.AddOutboxEvents(ctx, []service.DomainEvent{outboxEvent("e1", "e1")}); err != nil {
+		t.Fatal(err)
+	}
+	broker := &recordingBroker{}
+	for _, want := range []int{2, 1, 0} {
+		if n, err := service.RelayOutbox(ctx, s, broker, 2); n != want || err != nil {
+			t.Fatalf("RelayOutbox() = %d, %v, want %d, nil", n, err, want)
+		}
+	}
+	if want := []string{"e1", "e2", "e3"}; !reflect.DeepEqual(broker.published, want) {
+		t.Errorf("published %v, want %v", broker.published, want)
+	}
+}
+
+func TestBrokersStopAtTheFirstFailure(t *testing.T) {
+	first := &recordingBroker{}
+	failing := &recordingBroker{fail: map[string]bool{"e1": true}}
+	last := &recordingBroker{}
+	if err := (service.Brokers{first, failing, last}).Publish(context.Background(), outboxEvent("e1", "squat")); err == nil {
+		t.Fatal("Publish succeeded although a broker failed")
+	}
+	if len(first.published) != 1 || len(last.published) != 0 {
+		t.Errorf("published to %d and %d brokers around the failure, want 1 and 0", len(first.published), len(last.published))
+	}
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// plateTolerance is how far, in kilograms, a loaded bar may be from its
+// target and still count as exact.
+const plateTolerance = 1e-6
+
+// The bounds on a PlateInventory, well beyond what any gym stocks.
+const (
+	MaxPlateSizes = 16
+	MaxPlatePairs = 50
+)
+
+// maxPlateSearchSteps bounds the combinations of plates LoadPlates tries, as
+// the number of combinations grows exponentially with the number of sizes.
+// Racks of sizes that divide one another find the best loading in a handful
+// of steps; odd sizes that do not may stop short of it, with the best loading
+// found by then.
+const maxPlateSearchSteps = 100000
+
+// Plate is one size of plate in a gym. Pairs is how many pairs of it there
+// are, since plates are always loaded one per side.
+type Plate struct {
+	Weight Load  `json:"weight"`
+	Pairs  int32 `json:"pairs"`
+}
+
+// PlateInventory is the plates a tenant's gym has, and the bar loads are
+// calculated for when a request does not name one.
+type PlateInventory struct {
+	TenantID   string    `json:"tenantId"`
+	Bar        Load      `json:"bar"`
+	Plates     []Plate   `json:"plates"`
+	UpdateTime time.Time `json:"updateTime"`
+}
+
+// DefaultPlateInventories apply to tenants that have not stocked their own,
+// by the tenant's default unit: a standard bar and a typical rack of
+// competition plates.
+var DefaultPlateInventories = map[WeightUnit]PlateInventory{
+	Kilograms: {
+		Bar: Kilos(20),
+		Plates: []Plate{
+			{Weight: Kilos(25), Pairs: 4},
+			{Weight: Kilos(20), Pairs: 2},
+			{Weight: Kilos(15), Pairs: 2},
+			{Weight: Kilos(10), Pairs: 2},
+			{Weight: Kilos(5), Pairs: 2},
+			{Weight: Kilos(2.5), Pairs: 2},
+			{Weight: Kilos(1.25), Pairs: 2},
+		},
+	},
+	Pounds: {
+		Bar: pounds(45),
+		Plates: []Plate{
+			{Weight: pounds(45), Pairs: 6},
+			{Weight: pounds(35), Pairs: 2},
+			{Weight: pounds(25), Pairs: 2},
+			{Weight: pounds(10), Pairs: 2},
+			{Weight: pounds(5), Pairs: 2},
+			{Weight: pounds(2.5), Pairs: 2},
+		},
+	},
+}
+
+func pounds(lb float64) Load {
+	return Load{Kilograms: lb * KilogramsPerPound, Unit: Pounds}
+}
+
+// PlateCount is a number of plates of one weight.
+type PlateCount struct {
+	Weight Load  `json:"weight"`
+	Count  int32 `json:"count"`
+}
+
+// PlateLoading is how to load a bar for a target. Total is what the bar
+// actually comes to, which is the target when Exact is set and otherwise the
+// heaviest load under it the plates allow. PerSide lists the plates for one
+// side, heaviest first.
+type PlateLoading struct {
+	Target  Load         `json:"target"`
+	Bar     Load         `json:"bar"`
+	Total   Load         `json:"total"`
+	Exact   bool         `json:"exact"`
+	PerSide []PlateCount `json:"perSide"`
+}
+
+// LoadPlates works out the plates to put on each side of bar to come as close
+// to target as plates allow without going over. Heavier plates are tried
+// first, so the bar carries few of them, and the search gives up after
+// maxPlateSearchSteps combinations. Total is in the unit of target. A target
+// lighter than the bar loads no plates.
+func LoadPlates(target Load, bar Load, plates []Plate) PlateLoading {
+	sorted := make([]Plate, 0, len(plates))
+	for _, p := range plates {
+		if p.Pairs > 0 && p.Weight.Kilograms > 0 {
+			sorted = append(sorted, p)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Weight.Kilograms > sorted[j].Weight.Kilograms
+	})
+	side := plateSearch{plates: sorted, best: make([]int32, len(sorted)), counts: make([]int32, len(sorted))}
+	if perSide := (target.Kilograms - bar.Kilograms) / 2; perSide > 0 {
+		side.remaining = make([]float64, len(sorted)+1)
+		for i := len(sorted) - 1; i >= 0; i-- {
+			side.remaining[i] = side.remaining[i+1] + sorted[i].Weight.Kilograms*float64(sorted[i].Pairs)
+		}
+		side.search(0, perSide, 0)
+	}
+
+	loading := PlateLoading{Target: target, Bar: bar}
+	for i, n := range side.best {
+		if n > 0 {
+			loading.PerSide = append(loading.PerSide, PlateCount{Weight: sorted[i].Weight, Count: n})
+		}
+	}
+	total := bar.Kilograms + 2*side.loaded
+	loading.Total = Load{Kilograms: total, Unit: target.Unit}
+	loading.Exact = math.Abs(total-target.Kilograms) < plateTolerance
+	return loading
+}
+
+// plateSearch finds the heaviest combination of plates that fits on one side
+// of a bar, backtracking when taking the heaviest plates first leaves a gap
+// the smaller ones cannot fill. The first combination it tries takes as many
+// of each plate as fit, heaviest first.
+type plateSearch struct {
+	plates    []Plate
+	remaining []float64
+	counts    []int32
+	best      []int32
+	loaded    float64
+	steps     int
+	done      bool
+}
+
+func (s *plateSearch) search(i int, limit float64, loaded float64) {
+	if loaded > s.loaded+plateTolerance {
+		s.loaded = loaded
+		copy(s.best, s.counts)
+		s.done = math.Abs(limit-loaded) < plateTolerance
+	}
+	s.steps++
+	if s.steps >= maxPlateSearchSteps {
+		s.done = true
+	}
+	if s.done || i == len(s.plates) || loaded+s.remaining[i] <= s.loaded+plateTolerance {
+		return
+	}
+	w := s.plates[i].Weight.Kilograms
+	n := int32(math.Floor((limit - loaded + plateTolerance) / w))
+	if n > s.plates[i].Pairs {
+		n = s.plates[i].Pairs
+	}
+	for ; n >= 0 && !s.done; n-- {
+		s.counts[i] = n
+		s.search(i+1, limit, loaded+float64(n)*w)
+	}
+	s.counts[i] = 0
+}
+
+// WarmupSet is one set of a warm-up ladder. Percent is the fraction of the
+// working weight it was planned at; the load itself is rounded to what the
+// movement's equipment can be loaded to. Plates are only given for barbells.
+type WarmupSet struct {
+	Weight  Load         `json:"weight"`
+	Reps    int32        `json:"reps"`
+	Percent float64      `json:"percent"`
+	Plates  []PlateCount `json:"plates"`
+}
+
+// warmupStep is a rung of the ladder warm-ups are built from. A zero percent
+// is the empty bar.
+type warmupStep struct {
+	percent float64
+	reps    int32
+}
+
+// warmupLadder is the classic progression to a working weight: the empty
+// bar, then fewer reps as the load climbs, so the athlete arrives primed but
+// not tired.
+var warmupLadder = []warmupStep{
+	{percent: 0, reps: 10},
+	{percent: 0.4, reps: 5},
+	{percent: 0.6, reps: 3},
+	{percent: 0.8, reps: 2},
+	{percent: 0.9, reps: 1},
+}
+
+// singleRepMaxReps is the number of working reps above which the heavy
+// single at the top of the ladder is left out, as it adds fatigue without
+// preparing the athlete for lighter volume work.
+const singleRepMaxReps = 5
+
+// EquipmentIncrements are the steps loads go up in for equipment that is not
+// loaded with plates: dumbbell and kettlebell sizes and machine stacks.
+var EquipmentIncrements = map[Equipment]map[WeightUnit]float64{
+	Dumbbell:   {Kilograms: 2, Pounds: 5},
+	Kettlebell: {Kilograms: 4, Pounds: 5},
+	Machine:    {Kilograms: 5, Pounds: 10},
+	Cable:      {Kilograms: 5, Pounds: 10},
+}
+
+// BuildWarmups builds the warm-up ladder to working for workingReps of a
+// movement with equipment. Barbell sets start with the empty bar and are
+// loaded with plates; other equipment has no bar and is rounded to the
+// nearest step it comes in. Rungs that round to the same load as the one
+// below them, or reach the working weight, are left out.
+func BuildWarmups(equipment Equipment, working Load, workingReps int32, bar Load, plates []Plate) []WarmupSet {
+	unit := working.Unit
+	if unit == "" {
+		unit = Kilograms
+	}
+	var (
+		sets []WarmupSet
+		last float64
+	)
+	for _, step := range warmupLadder {
+		if step.percent == 0 && equipment != Barbell {
+			continue
+		}
+		if step.percent >= 0.9 && workingReps > singleRepMaxReps {
+			continue
+		}
+		target := Load{Kilograms: working.Kilograms * step.percent, Unit: unit}
+		set := WarmupSet{Reps: step.reps, Percent: step.percent}
+		if equipment == Barbell {
+			if target.Kilograms < bar.Kilograms {
+				target = bar
+			}
+			loading := LoadPlates(target, bar, plates)
+			set.Weight, set.Plates = Load{Kilograms: loading.Total.Kilograms, Unit: unit}, loading.PerSide
+		} else {
+			increment := PlateIncrements[unit]
+			if steps, ok := EquipmentIncrements[equipment]; ok {
+				increment = steps[unit]
+			}
+			set.Weight, _ = NewLoad(RoundToIncrement(target.In(unit), increment), unit)
+		}
+		if set.Weight.Kilograms <= 0 || set.Weight.Kilograms >= working.Kilograms-plateTolerance {
+			continue
+		}
+		if len(sets) > 0 && set.Weight.Kilograms <= last+plateTolerance {
+			continue
+		}
+		last = set.Weight.Kilograms
+		sets = append(sets, set)
+	}
+	return sets
+}
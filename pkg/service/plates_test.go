@@ -0,0 +1,191 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func kiloPlates(pairs map[float64]int32) []Plate {
+	var plates []Plate
+	for kg, n := range pairs {
+		plates = append(plates, Plate{Weight: Kilos(kg), Pairs: n})
+	}
+	return plates
+}
+
+func TestLoadPlates(t *testing.T) {
+	standard := DefaultPlateInventories[Kilograms].Plates
+	for _, tc := range []struct {
+		name    string
+		target  Load
+		bar     Load
+		plates  []Plate
+		total   float64
+		exact   bool
+		perSide map[float64]int32
+	}{
+		{
+			name:    "exact",
+			target:  Kilos(142.5),
+			bar:     Kilos(20),
+			plates:  standard,
+			total:   142.5,
+			exact:   true,
+			perSide: map[float64]int32{25: 2, 10: 1, 1.25: 1},
+		},
+		{
+			name:    "under the target",
+			target:  Kilos(143),
+			bar:     Kilos(20),
+			plates:  standard,
+			total:   142.5,
+			perSide: map[float64]int32{25: 2, 10: 1, 1.25: 1},
+		},
+		{
+			name:   "empty bar",
+			target: Kilos(15),
+			bar:    Kilos(20),
+			plates: standard,
+			total:  20,
+		},
+		{
+			name:    "backtracks when the heaviest plate leaves a gap",
+			target:  Kilos(80),
+			bar:     Kilos(20),
+			plates:  kiloPlates(map[float64]int32{20: 1, 15: 2}),
+			total:   80,
+			exact:   true,
+			perSide: map[float64]int32{15: 2},
+		},
+		{
+			name:    "runs out of plates",
+			target:  Kilos(300),
+			bar:     Kilos(20),
+			plates:  kiloPlates(map[float64]int32{25: 2, 10: 1}),
+			total:   140,
+			perSide: map[float64]int32{25: 2, 10: 1},
+		},
+		{
+			name:    "pounds",
+			target:  pounds(225),
+			bar:     pounds(45),
+			plates:  DefaultPlateInventories[Pounds].Plates,
+			total:   225 * KilogramsPerPound,
+			exact:   true,
+			perSide: map[float64]int32{45 * KilogramsPerPound: 2},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := LoadPlates(tc.target, tc.bar, tc.plates)
+			if math.Abs(got.Total.Kilograms-tc.total) > plateTolerance || got.Exact != tc.exact || got.Total.Unit != tc.target.Unit {
+				t.Errorf("LoadPlates() = %g kg (%s), exact %v, want %g kg (%s), exact %v",
+					got.Total.Kilograms, got.Total.Unit, got.Exact, tc.total, tc.target.Unit, tc.exact)
+			}
+			if len(got.PerSide) != len(tc.perSide) {
+				t.Fatalf("per side = %+v, want %v", got.PerSide, tc.perSide)
+			}
+			for i, p := range got.PerSide {
+				if i > 0 && p.Weight.Kilograms >= got.PerSide[i-1].Weight.Kilograms {
+					t.Errorf("per side = %+v, want heaviest first", got.PerSide)
+				}
+				found := false
+				for kg, n := range tc.perSide {
+					if math.Abs(p.Weight.Kilograms-kg) < plateTolerance && p.Count == n {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("per side = %+v, want %v", got.PerSide, tc.perSide)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadPlatesIsBounded(t *testing.T) {
+	// Sizes that do not divide one another defeat the pruning, which made
+	// the search run for minutes before it was bounded.
+	var plates []Plate
+	for i := 0; i < MaxPlateSizes; i++ {
+		plates = append(plates, Plate{Weight: Kilos(1.37 + 1.91*float64(i)), Pairs: MaxPlatePairs})
+	}
+	begin := time.Now()
+	got := LoadPlates(Kilos(1000.01), Kilos(20), plates)
+	if took := time.Since(begin); took > 2*time.Second {
+		t.Errorf("LoadPlates took %s", took)
+	}
+	if got.Total.Kilograms > 1000.01 || got.Total.Kilograms < 990 {
+		t.Errorf("LoadPlates() = %g kg, want close to but not over 1000.01 kg", got.Total.Kilograms)
+	}
+}
+
+func TestValidatePlateInventory(t *testing.T) {
+	tooMany := make([]Plate, MaxPlateSizes+1)
+	for i := range tooMany {
+		tooMany[i] = Plate{Weight: Kilos(float64(i + 1)), Pairs: 1}
+	}
+	for _, tc := range []struct {
+		name string
+		inv  PlateInventory
+		ok   bool
+	}{
+		{"default", DefaultPlateInventories[Kilograms], true},
+		{"no plates", PlateInventory{Bar: Kilos(20)}, false},
+		{"negative bar", PlateInventory{Bar: Kilos(-1), Plates: kiloPlates(map[float64]int32{20: 1})}, false},
+		{"no pairs", PlateInventory{Plates: kiloPlates(map[float64]int32{20: 0})}, false},
+		{"too many pairs", PlateInventory{Plates: kiloPlates(map[float64]int32{20: MaxPlatePairs + 1})}, false},
+		{"as many pairs as allowed", PlateInventory{Plates: kiloPlates(map[float64]int32{20: MaxPlatePairs})}, true},
+		{"too many sizes", PlateInventory{Plates: tooMany}, false},
+		{"listed twice", PlateInventory{Plates: []Plate{{Weight: Kilos(20), Pairs: 1}, {Weight: Kilos(20), Pairs: 2}}}, false},
+		{"unknown unit", PlateInventory{Plates: []Plate{{Weight: Load{Kilograms: 20, Unit: "stone"}, Pairs: 1}}}, false},
+	} {
+		err := validatePlateInventory(tc.inv)
+		if tc.ok && err != nil {
+			t.Errorf("%s: validatePlateInventory() = %v, want nil", tc.name, err)
+		}
+		if !tc.ok && errors.Cause(err) != ErrInvalidArgument {
+			t.Errorf("%s: validatePlateInventory() = %v, want %v", tc.name, err, ErrInvalidArgument)
+		}
+	}
+}
+
+func TestBuildWarmups(t *testing.T) {
+	standard := DefaultPlateInventories[Kilograms].Plates
+	for _, tc := range []struct {
+		name      string
+		equipment Equipment
+		working   Load
+		reps      int32
+		want      []float64
+	}{
+		{"barbell single", Barbell, Kilos(200), 1, []float64{20, 80, 120, 160, 180}},
+		{"barbell volume skips the single", Barbell, Kilos(100), 8, []float64{20, 40, 60, 80}},
+		{"light barbell drops repeats", Barbell, Kilos(30), 5, []float64{20, 22.5, 25}},
+		{"dumbbell", Dumbbell, Kilos(40), 5, []float64{16, 24, 32, 36}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sets := BuildWarmups(tc.equipment, tc.working, tc.reps, Kilos(20), standard)
+			if len(sets) != len(tc.want) {
+				t.Fatalf("got %d warm-up sets %+v, want %v", len(sets), sets, tc.want)
+			}
+			for i, set := range sets {
+				if math.Abs(set.Weight.Kilograms-tc.want[i]) > plateTolerance {
+					t.Errorf("set %d = %g kg, want %g kg", i+1, set.Weight.Kilograms, tc.want[i])
+				}
+				var side float64
+				for _, p := range set.Plates {
+					side += p.Weight.Kilograms * float64(p.Count)
+				}
+				if tc.equipment == Barbell && math.Abs(20+2*side-set.Weight.Kilograms) > plateTolerance {
+					t.Errorf("set %d is loaded with %+v, which do not make %g kg", i+1, set.Plates, set.Weight.Kilograms)
+				}
+				if tc.equipment != Barbell && set.Plates != nil {
+					t.Errorf("set %d of a %s is loaded with plates", i+1, tc.equipment)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package service
+
+import "context"
+
+type principalContextKey struct{}
+
+// Principal identifies the authenticated caller of a request. Unit is the
+// weight unit they prefer loads in.
+type Principal struct {
+	UserID   string
+	TenantID string
+	Role     Role
+	Unit     WeightUnit
+}
+
+// HasRole reports whether the principal holds any of the given roles.
+func (p Principal) HasRole(roles ...Role) bool {
+	for _, r := range roles {
+		if p.Role == r {
+			return true
+		}
+	}
+	return false
+}
+
+// NewContextWithPrincipal returns a copy of ctx that carries p.
+func NewContextWithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, p)
+}
+
+// PrincipalFromContext returns the Principal stored in ctx, if any.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalContextKey{}).(Principal)
+	return p, ok
+}
+
+// principalFromContext is like PrincipalFromContext but returns
+// ErrUnauthenticated when no caller is present, which is what every service
+// method wants.
+func principalFromContext(ctx context.Context) (Principal, error) {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return Principal{}, ErrUnauthenticated
+	}
+	return p, nil
+}
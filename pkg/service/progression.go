@@ -0,0 +1,372 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ProgressionScheme is the rule a load suggestion follows from one session
+// to the next.
+type ProgressionScheme string
+
+// The progression schemes load suggestions may follow.
+const (
+	// RPEProgression sets the load from the athlete's latest estimated
+	// one-rep max so that the target reps land at the target RPE.
+	RPEProgression ProgressionScheme = "rpe"
+	// LinearProgression adds a fixed increment whenever every working set
+	// hit the target reps, and backs off after repeated misses.
+	LinearProgression ProgressionScheme = "linear"
+	// DoubleProgression adds reps within a range at the same load, and adds
+	// load once every working set reaches the top of the range.
+	DoubleProgression ProgressionScheme = "double"
+)
+
+// DefaultProgressionIncrements are the loads linear and double progression
+// add, and RPE-targeted loads are rounded to, when a rule does not say.
+var DefaultProgressionIncrements = map[WeightUnit]float64{
+	Kilograms: 2.5,
+	Pounds:    5,
+}
+
+// Defaults of a progression query and rule.
+const (
+	defaultTargetRPE   = 8
+	defaultDeload      = 0.1
+	doubleRepRange     = 3
+	progressionHistory = 5
+	// grindMargin is how far above the target RPE a working set may be
+	// before progression holds the load rather than adding to it.
+	grindMargin = 1
+)
+
+// ProgressionRule configures how a suggestion progresses. A zero Increment
+// uses DefaultProgressionIncrements in the unit the athlete lifts in. MinReps
+// and MaxReps bound the rep range of double progression, which defaults to
+// the target reps and three more. Deload is the fraction linear progression
+// takes off after two sessions of missed reps, 10% when zero.
+type ProgressionRule struct {
+	Scheme    ProgressionScheme `json:"scheme"`
+	Increment Load              `json:"increment"`
+	MinReps   int32             `json:"minReps"`
+	MaxReps   int32             `json:"maxReps"`
+	Deload    float64           `json:"deload"`
+}
+
+// ProgressionQuery asks for the working load of a movement's next session
+// for TargetReps at TargetRPE. Without target reps the reps of the last
+// session's working sets are kept; without a target RPE it is 8.
+type ProgressionQuery struct {
+	MovementID string
+	TargetReps int32
+	TargetRPE  float64
+	Rule       ProgressionRule
+}
+
+// OneRepMaxEstimate is the best estimated one-rep max of a movement in one
+// session.
+type OneRepMaxEstimate struct {
+	WorkoutID    string    `json:"workoutId"`
+	PerformedAt  time.Time `json:"performedAt"`
+	EstimatedMax Load      `json:"estimatedMax"`
+}
+
+// LoadSuggestion is the proposed working load of a movement's next session,
+// with the reasoning behind it in Explanations. EstimatedMax is the
+// athlete's latest estimated one-rep max, and History the estimates of up to
+// their last five sessions of the movement, oldest first.
+type LoadSuggestion struct {
+	MovementID   string              `json:"movementId"`
+	Scheme       ProgressionScheme   `json:"scheme"`
+	Weight       Load                `json:"weight"`
+	Reps         int32               `json:"reps"`
+	RPE          float64             `json:"rpe"`
+	EstimatedMax Load                `json:"estimatedMax"`
+	History      []OneRepMaxEstimate `json:"history"`
+	Explanations []string            `json:"explanations"`
+}
+
+// EstimateOneRepMaxAtRPE returns the one-rep max a set suggests, counting the
+// reps its RPE says were left in reserve as if they had been done. Sets
+// without an RPE are taken to have gone to failure, as in EstimateOneRepMax.
+func EstimateOneRepMaxAtRPE(weight float64, reps int32, rpe float64) float64 {
+	if rpe <= 0 || rpe >= 10 {
+		return EstimateOneRepMax(weight, reps)
+	}
+	effective := float64(reps) + 10 - rpe
+	if weight <= 0 || reps <= 0 || effective > maxE1RMReps {
+		return 0
+	}
+	return weight * (1 + effective/30)
+}
+
+// LoadAtRPE returns the weight a one-rep max allows reps of at rpe. It is
+// the inverse of EstimateOneRepMaxAtRPE.
+func LoadAtRPE(oneRepMax float64, reps int32, rpe float64) float64 {
+	effective := float64(reps) + 10 - rpe
+	if effective <= 1 {
+		return oneRepMax
+	}
+	return oneRepMax / (1 + effective/30)
+}
+
+// movementSession is the sets of one movement in one workout.
+type movementSession struct {
+	workoutID   string
+	performedAt time.Time
+	sets        []WorkoutSet
+	e1RM        float64
+}
+
+// workingSets returns the sets of the session at its top weight, which are
+// the ones progression is judged on, and that weight.
+func (s movementSession) workingSets() ([]WorkoutSet, Load) {
+	var top Load
+	for _, set := range s.sets {
+		if set.Weight.Kilograms > top.Kilograms {
+			top = set.Weight
+		}
+	}
+	var working []WorkoutSet
+	for _, set := range s.sets {
+		if math.Abs(set.Weight.Kilograms-top.Kilograms) < plateTolerance {
+			working = append(working, set)
+		}
+	}
+	return working, top
+}
+
+// movementSessions collects the loaded sets of a movement from the workouts
+// of history that were performed, oldest session first.
+func movementSessions(movementID string, history []Workout) []movementSession {
+	var sessions []movementSession
+	for _, w := range history {
+		if w.Planned {
+			continue
+		}
+		s := movementSession{workoutID: w.Name, performedAt: w.PerformedAt}
+		for _, set := range w.Sets {
+			if set.MovementID != movementID || set.Reps <= 0 || set.Weight.Kilograms <= 0 {
+				continue
+			}
+			s.sets = append(s.sets, set)
+			if e := EstimateOneRepMaxAtRPE(set.Weight.Kilograms, set.Reps, set.RPE); e > s.e1RM {
+				s.e1RM = e
+			}
+		}
+		if len(s.sets) > 0 {
+			sessions = append(sessions, s)
+		}
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].performedAt.Before(sessions[j].performedAt)
+	})
+	return sessions
+}
+
+// SuggestLoad proposes the working load of the next session of a movement
+// from the athlete's history of it, following the query's progression rule.
+// Loads are suggested in the unit the athlete last lifted the movement in.
+func SuggestLoad(q ProgressionQuery, history []Workout) (LoadSuggestion, error) {
+	sessions := movementSessions(q.MovementID, history)
+	if len(sessions) == 0 {
+		return LoadSuggestion{}, errors.Wrapf(ErrNotFound, "no loaded sets of movement %s have been logged", q.MovementID)
+	}
+	last := sessions[len(sessions)-1]
+	working, top := last.workingSets()
+	unit := top.Unit
+	if unit == "" {
+		unit = Kilograms
+	}
+	if q.Rule.Scheme == "" {
+		q.Rule.Scheme = RPEProgression
+	}
+	if q.TargetRPE == 0 {
+		q.TargetRPE = defaultTargetRPE
+	}
+	if q.TargetReps == 0 {
+		q.TargetReps = minReps(working)
+	}
+	increment := q.Rule.Increment
+	if increment.Kilograms == 0 {
+		increment, _ = NewLoad(DefaultProgressionIncrements[unit], unit)
+	}
+
+	s := LoadSuggestion{
+		MovementID: q.MovementID,
+		Scheme:     q.Rule.Scheme,
+		Reps:       q.TargetReps,
+		RPE:        q.TargetRPE,
+	}
+	recent := sessions
+	if len(recent) > progressionHistory {
+		recent = recent[len(recent)-progressionHistory:]
+	}
+	for _, session := range recent {
+		if session.e1RM > 0 {
+			s.History = append(s.History, OneRepMaxEstimate{
+				WorkoutID:    session.workoutID,
+				PerformedAt:  session.performedAt,
+				EstimatedMax: Load{Kilograms: session.e1RM, Unit: unit},
+			})
+		}
+	}
+	if n := len(s.History); n > 0 {
+		s.EstimatedMax = s.History[n-1].EstimatedMax
+	}
+	explain := func(format string, args ...interface{}) {
+		s.Explanations = append(s.Explanations, fmt.Sprintf(format, args...))
+	}
+	grinding := hardestRPE(working) > q.TargetRPE+grindMargin
+
+	switch q.Rule.Scheme {
+	case RPEProgression:
+		if last.e1RM == 0 {
+			return LoadSuggestion{}, errors.Wrapf(ErrInvalidArgument, "the last session of movement %s has no set a one-rep max can be estimated from", q.MovementID)
+		}
+		best := bestSet(last.sets)
+		explain("Your estimated one-rep max is %s, from %s on %s.",
+			formatLoad(s.EstimatedMax, unit), formatSet(best, unit), last.performedAt.Format("2006-01-02"))
+		if n := len(s.History); n > 1 {
+			explain("That is %s on the session before.", change(s.History[n-2].EstimatedMax.Kilograms, last.e1RM))
+		}
+		target := LoadAtRPE(last.e1RM, q.TargetReps, q.TargetRPE)
+		s.Weight = roundLoad(Load{Kilograms: target, Unit: unit}, increment)
+		explain("%d reps at RPE %g is about %.0f%% of that, or %s, rounded to the nearest %s.",
+			q.TargetReps, q.TargetRPE, target/last.e1RM*100, formatLoad(s.Weight, unit), formatLoad(increment, increment.Unit))
+
+	case LinearProgression:
+		deload := q.Rule.Deload
+		if deload == 0 {
+			deload = defaultDeload
+		}
+		switch {
+		case minReps(working) < q.TargetReps && len(sessions) > 1 && missed(sessions[len(sessions)-2], top, q.TargetReps):
+			s.Weight = roundLoad(Load{Kilograms: top.Kilograms * (1 - deload), Unit: unit}, increment)
+			explain("You missed %d reps at %s in each of your last two sessions.", q.TargetReps, formatLoad(top, unit))
+			explain("Back off %.0f%% to %s and build up again.", deload*100, formatLoad(s.Weight, unit))
+		case minReps(working) < q.TargetReps:
+			s.Weight = top
+			explain("You missed %d reps on a set at %s last time, so repeat it.", q.TargetReps, formatLoad(top, unit))
+		case grinding:
+			s.Weight = top
+			explain("You hit %d reps at %s, but harder than RPE %g, so repeat it before adding weight.", q.TargetReps, formatLoad(top, unit), q.TargetRPE)
+		default:
+			s.Weight = Load{Kilograms: top.Kilograms + increment.Kilograms, Unit: unit}
+			explain("You hit %d reps on every set at %s last time, so add %s.", q.TargetReps, formatLoad(top, unit), formatLoad(increment, increment.Unit))
+		}
+
+	case DoubleProgression:
+		low, high := q.Rule.MinReps, q.Rule.MaxReps
+		if low == 0 {
+			low = q.TargetReps
+		}
+		if high == 0 {
+			high = low + doubleRepRange
+		}
+		if low > high {
+			return LoadSuggestion{}, errors.Wrap(ErrInvalidArgument, "the rep range of double progression is empty")
+		}
+		reps := minReps(working)
+		switch {
+		case reps >= high && !grinding:
+			s.Weight, s.Reps = Load{Kilograms: top.Kilograms + increment.Kilograms, Unit: unit}, low
+			explain("You reached %d reps on every set at %s, the top of your %d-%d range.", high, formatLoad(top, unit), low, high)
+			explain("Add %s and start again at %d reps.", formatLoad(increment, increment.Unit), low)
+		case reps >= high:
+			s.Weight, s.Reps = top, high
+			explain("You reached %d reps at %s, but harder than RPE %g, so repeat it before adding weight.", high, formatLoad(top, unit), q.TargetRPE)
+		default:
+			s.Weight, s.Reps = top, clampReps(reps+1, low, high)
+			explain("Your weakest set at %s had %d reps; stay at %s and aim for %d on every set.", formatLoad(top, unit), reps, formatLoad(top, unit), s.Reps)
+		}
+
+	default:
+		return LoadSuggestion{}, errors.Wrapf(ErrInvalidArgument, "unknown progression scheme %q", q.Rule.Scheme)
+	}
+	return s, nil
+}
+
+// missed reports whether any set of a session at load fell short of reps.
+func missed(s movementSession, load Load, reps int32) bool {
+	working, top := s.workingSets()
+	return math.Abs(top.Kilograms-load.Kilograms) < plateTolerance && minReps(working) < reps
+}
+
+func minReps(sets []WorkoutSet) int32 {
+	var reps int32
+	for i, set := range sets {
+		if i == 0 || set.Reps < reps {
+			reps = set.Reps
+		}
+	}
+	return reps
+}
+
+func hardestRPE(sets []WorkoutSet) float64 {
+	var rpe float64
+	for _, set := range sets {
+		if set.RPE > rpe {
+			rpe = set.RPE
+		}
+	}
+	return rpe
+}
+
+// bestSet returns the set with the highest estimated one-rep max.
+func bestSet(sets []WorkoutSet) WorkoutSet {
+	var (
+		best WorkoutSet
+		e1RM float64
+	)
+	for _, set := range sets {
+		if e := EstimateOneRepMaxAtRPE(set.Weight.Kilograms, set.Reps, set.RPE); e > e1RM {
+			best, e1RM = set, e
+		}
+	}
+	return best
+}
+
+func clampReps(reps int32, low int32, high int32) int32 {
+	switch {
+	case reps < low:
+		return low
+	case reps > high:
+		return high
+	}
+	return reps
+}
+
+// roundLoad rounds a load in its unit to the nearest multiple of increment.
+func roundLoad(l Load, increment Load) Load {
+	r, _ := NewLoad(RoundToIncrement(l.In(""), increment.In(l.Unit)), l.Unit)
+	return r
+}
+
+// formatLoad writes a load for an explanation, to a tenth of unit.
+func formatLoad(l Load, unit WeightUnit) string {
+	return fmt.Sprintf("%g %s", math.Round(l.Loadable(unit)*10)/10, unit)
+}
+
+func formatSet(set WorkoutSet, unit WeightUnit) string {
+	if set.RPE > 0 {
+		return fmt.Sprintf("%s for %d reps at RPE %g", formatLoad(set.Weight, unit), set.Reps, set.RPE)
+	}
+	return fmt.Sprintf("%s for %d reps", formatLoad(set.Weight, unit), set.Reps)
+}
+
+// change describes the relative change from before to after.
+func change(before float64, after float64) string {
+	pct := (after - before) / before * 100
+	switch {
+	case math.Abs(pct) < 0.05:
+		return "unchanged"
+	case pct < 0:
+		return fmt.Sprintf("down %.1f%%", -pct)
+	}
+	return fmt.Sprintf("up %.1f%%", pct)
+}
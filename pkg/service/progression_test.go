@@ -0,0 +1,200 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestEstimateOneRepMaxAtRPE(t *testing.T) {
+	for _, tc := range []struct {
+		weight float64
+		reps   int32
+		rpe    float64
+		want   float64
+	}{
+		{100, 1, 0, 100},
+		{100, 5, 0, 100 * (1 + 5.0/30)},
+		{100, 5, 10, 100 * (1 + 5.0/30)},
+		{100, 5, 8, 100 * (1 + 7.0/30)},
+		{100, 1, 9, 100 * (1 + 2.0/30)},
+		{100, 10, 7, 0},
+		{100, 0, 8, 0},
+		{0, 5, 8, 0},
+	} {
+		if got := EstimateOneRepMaxAtRPE(tc.weight, tc.reps, tc.rpe); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("EstimateOneRepMaxAtRPE(%g, %d, %g) = %g, want %g", tc.weight, tc.reps, tc.rpe, got, tc.want)
+		}
+		if tc.want == 0 || tc.rpe == 0 {
+			continue
+		}
+		if got := LoadAtRPE(tc.want, tc.reps, tc.rpe); math.Abs(got-tc.weight) > 1e-9 {
+			t.Errorf("LoadAtRPE(%g, %d, %g) = %g, want it to invert the estimate to %g", tc.want, tc.reps, tc.rpe, got, tc.weight)
+		}
+	}
+}
+
+// sessions returns a workout per list of sets, a day apart and oldest first.
+func sessions(sets ...[]WorkoutSet) []Workout {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	workouts := make([]Workout, len(sets))
+	for i, s := range sets {
+		workouts[i] = Workout{Name: string(rune('a' + i)), PerformedAt: day.AddDate(0, 0, i), Sets: s}
+	}
+	return workouts
+}
+
+// squats returns sets of squats at weight, one per number of reps, at rpe.
+func squats(weight Load, rpe float64, reps ...int32) []WorkoutSet {
+	var sets []WorkoutSet
+	for _, r := range reps {
+		sets = append(sets, WorkoutSet{MovementID: "squat", Reps: r, Weight: weight, RPE: rpe})
+	}
+	return sets
+}
+
+func TestSuggestLoad(t *testing.T) {
+	linear := ProgressionRule{Scheme: LinearProgression}
+	double := ProgressionRule{Scheme: DoubleProgression, MinReps: 8, MaxReps: 10}
+	for _, tc := range []struct {
+		name    string
+		q       ProgressionQuery
+		history []Workout
+		weight  Load
+		reps    int32
+	}{
+		{
+			name:    "RPE keeps the reps at the target RPE",
+			q:       ProgressionQuery{MovementID: "squat"},
+			history: sessions(squats(Kilos(100), 8, 5, 5, 5)),
+			weight:  Kilos(100),
+			reps:    5,
+		},
+		{
+			name:    "RPE for fewer reps is heavier",
+			q:       ProgressionQuery{MovementID: "squat", TargetReps: 3},
+			history: sessions(squats(Kilos(100), 8, 5)),
+			weight:  Kilos(105),
+			reps:    3,
+		},
+		{
+			name:    "linear adds the increment",
+			q:       ProgressionQuery{MovementID: "squat", Rule: linear},
+			history: sessions(squats(Kilos(100), 8, 5, 5, 5)),
+			weight:  Kilos(102.5),
+			reps:    5,
+		},
+		{
+			name:    "linear in pounds adds five pounds",
+			q:       ProgressionQuery{MovementID: "squat", Rule: linear},
+			history: sessions(squats(pounds(225), 8, 5, 5, 5)),
+			weight:  pounds(230),
+			reps:    5,
+		},
+		{
+			name:    "linear repeats a miss",
+			q:       ProgressionQuery{MovementID: "squat", TargetReps: 5, Rule: linear},
+			history: sessions(squats(Kilos(100), 9, 5, 5, 4)),
+			weight:  Kilos(100),
+			reps:    5,
+		},
+		{
+			name:    "linear deloads after two misses",
+			q:       ProgressionQuery{MovementID: "squat", TargetReps: 5, Rule: linear},
+			history: sessions(squats(Kilos(100), 9, 5, 4), squats(Kilos(100), 9, 5, 3)),
+			weight:  Kilos(90),
+			reps:    5,
+		},
+		{
+			name:    "linear repeats a grind",
+			q:       ProgressionQuery{MovementID: "squat", Rule: linear},
+			history: sessions(squats(Kilos(100), 9.5, 5, 5, 5)),
+			weight:  Kilos(100),
+			reps:    5,
+		},
+		{
+			name:    "double adds a rep",
+			q:       ProgressionQuery{MovementID: "squat", Rule: double},
+			history: sessions(squats(Kilos(60), 8, 9, 8, 8)),
+			weight:  Kilos(60),
+			reps:    9,
+		},
+		{
+			name:    "double adds load at the top of the range",
+			q:       ProgressionQuery{MovementID: "squat", Rule: double},
+			history: sessions(squats(Kilos(60), 8, 10, 10, 10)),
+			weight:  Kilos(62.5),
+			reps:    8,
+		},
+		{
+			name: "judged on the top weight of the last session",
+			q:    ProgressionQuery{MovementID: "squat", Rule: linear},
+			history: sessions(
+				squats(Kilos(80), 9, 5, 2),
+				append(squats(Kilos(60), 5, 3), squats(Kilos(90), 8, 5, 5)...),
+			),
+			weight: Kilos(92.5),
+			reps:   5,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := SuggestLoad(tc.q, tc.history)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(s.Weight.Kilograms-tc.weight.Kilograms) > plateTolerance || s.Weight.Unit != tc.weight.Unit || s.Reps != tc.reps {
+				t.Errorf("SuggestLoad() = %g %s for %d, want %g %s for %d: %v",
+					s.Weight.In(""), s.Weight.Unit, s.Reps, tc.weight.In(""), tc.weight.Unit, tc.reps, s.Explanations)
+			}
+			if len(s.Explanations) == 0 {
+				t.Error("the suggestion is not explained")
+			}
+		})
+	}
+}
+
+func TestSuggestLoadHistory(t *testing.T) {
+	var history [][]WorkoutSet
+	for i := 0; i < progressionHistory+2; i++ {
+		history = append(history, squats(Kilos(100+float64(i)*2.5), 0, 5))
+	}
+	workouts := sessions(history...)
+	workouts = append(workouts, Workout{Name: "planned", Planned: true, PerformedAt: time.Now(), Sets: squats(Kilos(200), 0, 5)})
+
+	s, err := SuggestLoad(ProgressionQuery{MovementID: "squat"}, workouts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.History) != progressionHistory {
+		t.Fatalf("history has %d sessions, want %d", len(s.History), progressionHistory)
+	}
+	if last := s.History[len(s.History)-1]; last.WorkoutID != workouts[len(history)-1].Name || s.EstimatedMax != last.EstimatedMax {
+		t.Errorf("latest estimate = %+v from %s, want the last performed session", s.EstimatedMax, last.WorkoutID)
+	}
+	for i := 1; i < len(s.History); i++ {
+		if !s.History[i].PerformedAt.After(s.History[i-1].PerformedAt) {
+			t.Errorf("history = %+v, want oldest first", s.History)
+		}
+	}
+}
+
+func TestSuggestLoadErrors(t *testing.T) {
+	history := sessions(squats(Kilos(100), 8, 5))
+	for _, tc := range []struct {
+		name    string
+		q       ProgressionQuery
+		history []Workout
+		want    error
+	}{
+		{"never lifted", ProgressionQuery{MovementID: "press"}, history, ErrNotFound},
+		{"unknown scheme", ProgressionQuery{MovementID: "squat", Rule: ProgressionRule{Scheme: "wave"}}, history, ErrInvalidArgument},
+		{"empty rep range", ProgressionQuery{MovementID: "squat", Rule: ProgressionRule{Scheme: DoubleProgression, MinReps: 8, MaxReps: 6}}, history, ErrInvalidArgument},
+		{"nothing to estimate from", ProgressionQuery{MovementID: "squat"}, sessions(squats(Kilos(40), 0, 20)), ErrInvalidArgument},
+	} {
+		if _, err := SuggestLoad(tc.q, tc.history); errors.Cause(err) != tc.want {
+			t.Errorf("%s: SuggestLoad() = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
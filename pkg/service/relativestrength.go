@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Sex selects the coefficients of the relative-strength formulas, which
+// differ between men and women.
+type Sex string
+
+// The sexes relative strength is scored for.
+const (
+	SexMale   Sex = "male"
+	SexFemale Sex = "female"
+)
+
+// wilksCoefficients are the a to f of the original Wilks formula, and the
+// bodyweights it is clamped to.
+var wilksCoefficients = map[Sex]struct {
+	poly     [6]float64
+	min, max float64
+}{
+	SexMale:   {[6]float64{-216.0475144, 16.2606339, -0.002388645, -0.00113732, 7.01863e-06, -1.291e-08}, 40, 201.9},
+	SexFemale: {[6]float64{594.31747775582, -27.23842536447, 0.82112226871, -0.00930733913, 4.731582e-05, -9.054e-08}, 26.51, 154.53},
+}
+
+// dotsCoefficients are the a to e of the DOTS formula, from the fourth power
+// down, and the bodyweights it is clamped to.
+var dotsCoefficients = map[Sex]struct {
+	poly     [5]float64
+	min, max float64
+}{
+	SexMale:   {[5]float64{-0.000001093, 0.0007391293, -0.1918759221, 24.0900756, -307.75076}, 40, 210},
+	SexFemale: {[5]float64{-0.0000010706, 0.0005158568, -0.1126655495, 13.6175032, -57.96288}, 40, 150},
+}
+
+// ipfGLEvent is a competition the IPF GL formula has coefficients for.
+type ipfGLEvent struct {
+	equipped  bool
+	benchOnly bool
+	sex       Sex
+}
+
+// ipfGLCoefficients are the A, B and C of the IPF GL formula.
+var ipfGLCoefficients = map[ipfGLEvent][3]float64{
+	{false, false, SexMale}:   {1199.72839, 1025.18162, 0.00921},
+	{false, false, SexFemale}: {610.32796, 1045.59282, 0.03048},
+	{true, false, SexMale}:    {1236.25115, 1449.21864, 0.01644},
+	{true, false, SexFemale}:  {758.63878, 949.31382, 0.02435},
+	{false, true, SexMale}:    {320.98041, 281.40258, 0.01008},
+	{false, true, SexFemale}:  {142.40398, 442.52671, 0.04724},
+	{true, true, SexMale}:     {381.22073, 733.79378, 0.02398},
+	{true, true, SexFemale}:   {221.82209, 357.00377, 0.02937},
+}
+
+// Wilks returns the Wilks score of lifting weight at bodyweight, both in
+// kilograms.
+func Wilks(sex Sex, bodyweight float64, weight float64) float64 {
+	c, ok := wilksCoefficients[sex]
+	if !ok || bodyweight <= 0 {
+		return 0
+	}
+	bw := math.Min(math.Max(bodyweight, c.min), c.max)
+	var denominator float64
+	for i, a := range c.poly {
+		denominator += a * math.Pow(bw, float64(i))
+	}
+	return weight * 500 / denominator
+}
+
+// DOTS returns the DOTS score of lifting weight at bodyweight, both in
+// kilograms.
+func DOTS(sex Sex, bodyweight float64, weight float64) float64 {
+	c, ok := dotsCoefficients[sex]
+	if !ok || bodyweight <= 0 {
+		return 0
+	}
+	bw := math.Min(math.Max(bodyweight, c.min), c.max)
+	var denominator float64
+	for _, a := range c.poly {
+		denominator = denominator*bw + a
+	}
+	return weight * 500 / denominator
+}
+
+// IPFGL returns the IPF GL points of a powerlifting total, or of a bench
+// press when benchOnly is set, lifted at bodyweight, both in kilograms.
+func IPFGL(sex Sex, equipped bool, benchOnly bool, bodyweight float64, weight float64) float64 {
+	c, ok := ipfGLCoefficients[ipfGLEvent{equipped: equipped, benchOnly: benchOnly, sex: sex}]
+	if !ok || bodyweight < 35 {
+		return 0
+	}
+	return weight * 100 / (c[0] - c[1]*math.Exp(-c[2]*bodyweight))
+}
+
+// RelativeStrengthQuery selects how personal records are scored.
+// TotalMovementIDs are the movements whose records add up to a total, such
+// as the squat, bench press and deadlift, and BenchMovementID the movement
+// scored as a bench press; IPF GL points are only defined for those.
+type RelativeStrengthQuery struct {
+	Sex              Sex      `json:"sex"`
+	Equipped         bool     `json:"equipped"`
+	TotalMovementIDs []string `json:"totalMovementIds"`
+	BenchMovementID  string   `json:"benchMovementId"`
+}
+
+// RelativeStrength is a personal record, the set with the highest estimated
+// one-rep max an athlete has lifted in a movement, scored against their
+// bodyweight when they lifted it. The formulas score one-rep maxes, so a
+// record is scored by OneRepMax, estimated from Weight and Reps with the
+// Epley formula, rather than by Weight; sets of more than maxE1RMReps reps
+// are not records. Bodyweight is zero, and so are the scores, when the
+// athlete never recorded one. A total has no movement, workout or reps, is
+// in kilograms, and its Weight and OneRepMax are both the sum of its
+// records' one-rep maxes.
+type RelativeStrength struct {
+	MovementID  string    `json:"movementId"`
+	WorkoutID   string    `json:"workoutId"`
+	PerformedAt time.Time `json:"performedAt"`
+	Weight      Load      `json:"weight"`
+	Reps        int32     `json:"reps"`
+	OneRepMax   Load      `json:"oneRepMax"`
+	Bodyweight  Load      `json:"bodyweight"`
+	Wilks       float64   `json:"wilks"`
+	DOTS        float64   `json:"dots"`
+	IPFGL       float64   `json:"ipfGl"`
+}
+
+// RelativeStrengthReport is an athlete's scored personal records, strongest
+// first, and their total when one was asked for and every movement of it has
+// a record.
+type RelativeStrengthReport struct {
+	Records []RelativeStrength `json:"records"`
+	Total   *RelativeStrength  `json:"total"`
+}
+
+// RelativeStrength scores an athlete's personal records with the Wilks, DOTS
+// and IPF GL formulas. Each record is scored against the athlete's average
+// bodyweight of the week before it was set, so that losing weight after a
+// record does not inflate it; a total is scored at the latest of its records.
+func (s basicMetricsService) RelativeStrength(ctx context.Context, athleteID string, q RelativeStrengthQuery) (RelativeStrengthReport, error) {
+	p, _, err := s.athleteTenant(ctx, athleteID)
+	if err != nil {
+		return RelativeStrengthReport{}, err
+	}
+	if q.Sex != SexMale && q.Sex != SexFemale {
+		return RelativeStrengthReport{}, errors.Wrapf(ErrInvalidArgument, "unknown sex %q", q.Sex)
+	}
+	workouts, err := s.workouts.ListWorkouts(ctx, p.TenantID, athleteID)
+	if err != nil {
+		return RelativeStrengthReport{}, err
+	}
+	bodyweights, err := s.repo.ListBodyMetrics(ctx, p.TenantID, athleteID, BodyMetricFilter{Type: BodyweightMetric})
+	if err != nil {
+		return RelativeStrengthReport{}, err
+	}
+
+	best := make(map[string]RelativeStrength)
+	for _, w := range workouts {
+		if w.Planned {
+			continue
+		}
+		for _, set := range w.Sets {
+			r, ok := best[set.MovementID]
+			e1RM := EstimateOneRepMax(set.Weight.Kilograms, set.Reps)
+			if e1RM <= 0 || (ok && (e1RM < r.OneRepMax.Kilograms || e1RM == r.OneRepMax.Kilograms && set.Reps >= r.Reps)) {
+				continue
+			}
+			best[set.MovementID] = RelativeStrength{
+				MovementID:  set.MovementID,
+				WorkoutID:   w.Name,
+				PerformedAt: w.PerformedAt,
+				Weight:      set.Weight,
+				Reps:        set.Reps,
+				OneRepMax:   Load{Kilograms: e1RM, Unit: set.Weight.Unit},
+			}
+		}
+	}
+	var report RelativeStrengthReport
+	for _, r := range best {
+		bw := bodyweightAt(bodyweights, r.PerformedAt)
+		r.Bodyweight = Kilos(bw)
+		r.Wilks = Wilks(q.Sex, bw, r.OneRepMax.Kilograms)
+		r.DOTS = DOTS(q.Sex, bw, r.OneRepMax.Kilograms)
+		if q.BenchMovementID != "" && r.MovementID == q.BenchMovementID {
+			r.IPFGL = IPFGL(q.Sex, q.Equipped, true, bw, r.OneRepMax.Kilograms)
+		}
+		report.Records = append(report.Records, r)
+	}
+	sort.Slice(report.Records, func(i, j int) bool {
+		if report.Records[i].OneRepMax.Kilograms != report.Records[j].OneRepMax.Kilograms {
+			return report.Records[i].OneRepMax.Kilograms > report.Records[j].OneRepMax.Kilograms
+		}
+		return report.Records[i].MovementID < report.Records[j].MovementID
+	})
+
+	if len(q.TotalMovementIDs) == 0 {
+		return report, nil
+	}
+	var (
+		total RelativeStrength
+		kg    float64
+	)
+	for _, id := range q.TotalMovementIDs {
+		r, ok := best[id]
+		if !ok {
+			return report, nil
+		}
+		kg += r.OneRepMax.Kilograms
+		if r.PerformedAt.After(total.PerformedAt) {
+			total.PerformedAt = r.PerformedAt
+		}
+	}
+	bw := bodyweightAt(bodyweights, total.PerformedAt)
+	total.Weight, total.OneRepMax, total.Bodyweight = Kilos(kg), Kilos(kg), Kilos(bw)
+	total.Wilks = Wilks(q.Sex, bw, kg)
+	total.DOTS = DOTS(q.Sex, bw, kg)
+	total.IPFGL = IPFGL(q.Sex, q.Equipped, false, bw, kg)
+	report.Total = &total
+	return report, nil
+}
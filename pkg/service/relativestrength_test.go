@@ -0,0 +1,35 @@
+package service
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRelativeStrengthFormulas(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		score func() float64
+		want  float64
+	}{
+		{"Wilks men", func() float64 { return Wilks(SexMale, 100, 700) }, 426.012},
+		{"Wilks women", func() float64 { return Wilks(SexFemale, 60, 400) }, 445.955},
+		{"Wilks clamps bodyweight", func() float64 { return Wilks(SexMale, 250, 700) }, 372.052},
+		{"Wilks without bodyweight", func() float64 { return Wilks(SexMale, 0, 700) }, 0},
+		{"Wilks of an unknown sex", func() float64 { return Wilks("", 100, 700) }, 0},
+		{"DOTS men", func() float64 { return DOTS(SexMale, 90, 700) }, 452.621},
+		{"DOTS women", func() float64 { return DOTS(SexFemale, 60, 400) }, 443.418},
+		{"DOTS clamps bodyweight", func() float64 { return DOTS(SexMale, 300, 700) }, 346.934},
+		{"DOTS without bodyweight", func() float64 { return DOTS(SexFemale, 0, 400) }, 0},
+		{"IPF GL classic men", func() float64 { return IPFGL(SexMale, false, false, 93, 700) }, 91.575},
+		{"IPF GL classic women", func() float64 { return IPFGL(SexFemale, false, false, 63, 450) }, 98.452},
+		{"IPF GL classic bench", func() float64 { return IPFGL(SexMale, false, true, 83, 180) }, 90.413},
+		{"IPF GL below its bodyweights", func() float64 { return IPFGL(SexMale, false, false, 30, 300) }, 0},
+	} {
+		if got := tc.score(); math.Abs(got-tc.want) > 0.001 {
+			t.Errorf("%s = %.3f, want %.3f", tc.name, got, tc.want)
+		}
+	}
+	if equipped, raw := IPFGL(SexMale, true, false, 93, 700), IPFGL(SexMale, false, false, 93, 700); equipped >= raw {
+		t.Errorf("equipped IPF GL %.3f is not below the classic %.3f", equipped, raw)
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+
+	"workout-manager-service/logging"
+)
+
+type templateAuditService struct {
+	auditor
+	service TemplateService
+}
+
+// NewTemplateAuditService takes an AuditRepository as a dependency and
+// returns a TemplateService that records every successful mutation.
+func NewTemplateAuditService(logger logging.IshiLogger, repo AuditRepository, s TemplateService) TemplateService {
+	return templateAuditService{
+		auditor: newAuditor(logger, repo),
+		service: s,
+	}
+}
+
+func templateResource(id string) string {
+	return "templates/" + id
+}
+
+// Create records the new template.
+func (as templateAuditService) Create(ctx context.Context, t WorkoutTemplate) (created WorkoutTemplate, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		if created, err = as.service.Create(ctx, t); err != nil {
+			return err
+		}
+		return as.record(ctx, "CreateTemplate", templateResource(created.Name), nil, created)
+	})
+	return created, err
+}
+
+// Get is not audited.
+func (as templateAuditService) Get(ctx context.Context, id string) (WorkoutTemplate, error) {
+	return as.service.Get(ctx, id)
+}
+
+// List is not audited.
+func (as templateAuditService) List(ctx context.Context) ([]WorkoutTemplate, error) {
+	return as.service.List(ctx)
+}
+
+// Update records the template before and after it changed.
+func (as templateAuditService) Update(ctx context.Context, t WorkoutTemplate) (after WorkoutTemplate, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		before, _ := as.service.Get(ctx, t.Name)
+		if after, err = as.service.Update(ctx, t); err != nil {
+			return err
+		}
+		return as.record(ctx, "UpdateTemplate", templateResource(after.Name), before, after)
+	})
+	return after, err
+}
+
+// Delete records the template as it was before it was deleted.
+func (as templateAuditService) Delete(ctx context.Context, id string) error {
+	return as.repo.InTx(ctx, func(ctx context.Context) error {
+		before, _ := as.service.Get(ctx, id)
+		if err := as.service.Delete(ctx, id); err != nil {
+			return err
+		}
+		return as.record(ctx, "DeleteTemplate", templateResource(id), before, nil)
+	})
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"workout-manager-service/logging"
+)
+
+type templateLoggingService struct {
+	logger  logging.IshiLogger
+	service TemplateService
+}
+
+// NewTemplateLoggingService takes an IshiLogger as a dependency and returns a
+// TemplateService.
+func NewTemplateLoggingService(logger logging.IshiLogger, s TemplateService) TemplateService {
+	return templateLoggingService{
+		logger:  logger.WithFields("service", "template"),
+		service: s,
+	}
+}
+
+// Create provides informative logging when requests are made to the create
+// endpoint.
+func (ls templateLoggingService) Create(ctx context.Context, t WorkoutTemplate) (WorkoutTemplate, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Create",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"title", t.Title,
+			"scope", t.Scope,
+			"sets", len(t.Sets),
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Create(ctx, t)
+}
+
+// Get provides informative logging when requests are made to the get
+// endpoint.
+func (ls templateLoggingService) Get(ctx context.Context, id string) (WorkoutTemplate, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Get",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"id", id,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Get(ctx, id)
+}
+
+// List provides informative logging when requests are made to the list
+// endpoint.
+func (ls templateLoggingService) List(ctx context.Context) ([]WorkoutTemplate, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "List",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.List(ctx)
+}
+
+// Update provides informative logging when requests are made to the update
+// endpoint.
+func (ls templateLoggingService) Update(ctx context.Context, t WorkoutTemplate) (WorkoutTemplate, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Update",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"id", t.Name,
+			"scope", t.Scope,
+			"sharedWith", fmt.Sprintf("%v", t.SharedWith),
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Update(ctx, t)
+}
+
+// Delete provides informative logging when requests are made to the delete
+// endpoint.
+func (ls templateLoggingService) Delete(ctx context.Context, id string) error {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Delete",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"id", id,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Delete(ctx, id)
+}
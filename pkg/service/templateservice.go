@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/logging"
+)
+
+// TemplateScope determines who can see a WorkoutTemplate.
+type TemplateScope string
+
+// The scopes of a template. A user template is seen by its owner and the
+// users it is shared with, while a tenant template is seen by everyone in the
+// tenant and can only be saved by coaches and admins.
+const (
+	TemplateUserScope   TemplateScope = "user"
+	TemplateTenantScope TemplateScope = "tenant"
+)
+
+// WorkoutTemplate is a saved workout, the movements, set scheme and blocks
+// of a session athletes do again and again, with the blocks never scored.
+// Workouts are planned from it with CloneWorkout. SharedWith lists the users
+// of the tenant a user template is shared with besides its owner.
+type WorkoutTemplate struct {
+	Name       string         `json:"id"`
+	TenantID   string         `json:"tenantId"`
+	OwnerID    string         `json:"ownerId"`
+	Title      string         `json:"title"`
+	Scope      TemplateScope  `json:"scope"`
+	SharedWith []string       `json:"sharedWith"`
+	Sets       []WorkoutSet   `json:"sets"`
+	Blocks     []WorkoutBlock `json:"blocks"`
+	CreateTime time.Time      `json:"createTime"`
+	UpdateTime time.Time      `json:"updateTime"`
+}
+
+// VisibleTo reports whether a caller may see and use the template. Admins see
+// every template of their tenant.
+func (t WorkoutTemplate) VisibleTo(p Principal) bool {
+	if t.TenantID != p.TenantID {
+		return false
+	}
+	if t.Scope == TemplateTenantScope || t.OwnerID == p.UserID || p.Role == RoleAdmin {
+		return true
+	}
+	for _, id := range t.SharedWith {
+		if id == p.UserID {
+			return true
+		}
+	}
+	return false
+}
+
+// TemplateRepository persists workout templates. ListTemplates returns every
+// template of a tenant, most recently updated first.
+type TemplateRepository interface {
+	CreateTemplate(ctx context.Context, t WorkoutTemplate) error
+	GetTemplate(ctx context.Context, id string) (WorkoutTemplate, error)
+	ListTemplates(ctx context.Context, tenantID string) ([]WorkoutTemplate, error)
+	UpdateTemplate(ctx context.Context, t WorkoutTemplate) error
+	DeleteTemplate(ctx context.Context, id string) error
+}
+
+// TemplateService describes a service that manages the workout templates the
+// caller can see. Templates may only be changed by their owner or an admin.
+type TemplateService interface {
+	Create(ctx context.Context, t WorkoutTemplate) (WorkoutTemplate, error)
+	Get(ctx context.Context, id string) (WorkoutTemplate, error)
+	List(ctx context.Context) ([]WorkoutTemplate, error)
+	Update(ctx context.Context, t WorkoutTemplate) (WorkoutTemplate, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// NewTemplateService returns a basic TemplateService with middleware wired
+// in.
+func NewTemplateService(logger logging.IshiLogger, repo TemplateRepository, users UserRepository, audit AuditRepository) TemplateService {
+	var svc TemplateService
+	{
+		svc = NewBasicTemplateService(repo, users)
+		svc = NewTemplateAuditService(logger, audit, svc)
+		svc = NewTemplateLoggingService(logger, svc)
+	}
+	return svc
+}
+
+// NewBasicTemplateService returns an implementation of TemplateService
+// backed by the given repositories.
+func NewBasicTemplateService(repo TemplateRepository, users UserRepository) TemplateService {
+	return basicTemplateService{repo: repo, users: users}
+}
+
+type basicTemplateService struct {
+	repo  TemplateRepository
+	users UserRepository
+}
+
+// Create saves a template owned by the caller. Templates are user scoped
+// unless another scope is given.
+func (s basicTemplateService) Create(ctx context.Context, t WorkoutTemplate) (WorkoutTemplate, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return WorkoutTemplate{}, err
+	}
+	if t.Scope == "" {
+		t.Scope = TemplateUserScope
+	}
+	if err := s.validate(ctx, p, t); err != nil {
+		return WorkoutTemplate{}, err
+	}
+	now := time.Now().UTC()
+	t = WorkoutTemplate{
+		Name:       uuid.New().String(),
+		TenantID:   p.TenantID,
+		OwnerID:    p.UserID,
+		Title:      t.Title,
+		Scope:      t.Scope,
+		SharedWith: t.SharedWith,
+		Sets:       t.Sets,
+		Blocks:     unscoredBlocks(t.Blocks),
+		CreateTime: now,
+		UpdateTime: now,
+	}
+	if err := s.repo.CreateTemplate(ctx, t); err != nil {
+		return WorkoutTemplate{}, err
+	}
+	return t, nil
+}
+
+// Get retrieves a template the caller can see by its UUID.
+func (s basicTemplateService) Get(ctx context.Context, id string) (WorkoutTemplate, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return WorkoutTemplate{}, err
+	}
+	return visibleTemplate(ctx, s.repo, p, id)
+}
+
+// List retrieves the templates the caller can see, most recently updated
+// first.
+func (s basicTemplateService) List(ctx context.Context) ([]WorkoutTemplate, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	all, err := s.repo.ListTemplates(ctx, p.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	templates := make([]WorkoutTemplate, 0, len(all))
+	for _, t := range all {
+		if t.VisibleTo(p) {
+			templates = append(templates, t)
+		}
+	}
+	return templates, nil
+}
+
+// Update replaces a template's title, scope, sharing, sets and blocks.
+func (s basicTemplateService) Update(ctx context.Context, t WorkoutTemplate) (WorkoutTemplate, error) {
+	p, current, err := s.owned(ctx, t.Name)
+	if err != nil {
+		return WorkoutTemplate{}, err
+	}
+	if t.Scope == "" {
+		t.Scope = current.Scope
+	}
+	if err := s.validate(ctx, p, t); err != nil {
+		return WorkoutTemplate{}, err
+	}
+	current.Title = t.Title
+	current.Scope = t.Scope
+	current.SharedWith = t.SharedWith
+	current.Sets = t.Sets
+	current.Blocks = unscoredBlocks(t.Blocks)
+	current.UpdateTime = time.Now().UTC()
+	if err := s.repo.UpdateTemplate(ctx, current); err != nil {
+		return WorkoutTemplate{}, err
+	}
+	return current, nil
+}
+
+// Delete removes a template. Workouts planned from it are kept.
+func (s basicTemplateService) Delete(ctx context.Context, id string) error {
+	if _, _, err := s.owned(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteTemplate(ctx, id)
+}
+
+// owned retrieves a template the caller may change.
+func (s basicTemplateService) owned(ctx context.Context, id string) (Principal, WorkoutTemplate, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return Principal{}, WorkoutTemplate{}, err
+	}
+	t, err := visibleTemplate(ctx, s.repo, p, id)
+	if err != nil {
+		return Principal{}, WorkoutTemplate{}, err
+	}
+	if t.OwnerID != p.UserID && p.Role != RoleAdmin {
+		return Principal{}, WorkoutTemplate{}, errors.Wrap(ErrPermissionDenied, "only the owner of a template may change it")
+	}
+	return p, t, nil
+}
+
+// validate checks a template the caller is saving. Only coaches and admins
+// may share a template with the whole tenant, and a template may only be
+// shared with users of the caller's tenant.
+func (s basicTemplateService) validate(ctx context.Context, p Principal, t WorkoutTemplate) error {
+	if t.Title == "" {
+		return errors.Wrap(ErrInvalidArgument, "a template needs a title")
+	}
+	if len(t.Sets) == 0 {
+		return errors.Wrap(ErrInvalidArgument, "a template needs at least one set")
+	}
+	switch t.Scope {
+	case TemplateUserScope:
+	case TemplateTenantScope:
+		if !p.HasRole(RoleCoach, RoleAdmin) {
+			return errors.Wrap(ErrPermissionDenied, "only coaches and admins may save tenant templates")
+		}
+	default:
+		return errors.Wrapf(ErrInvalidArgument, "unknown template scope %q", t.Scope)
+	}
+	for _, id := range t.SharedWith {
+		u, err := s.users.GetUser(ctx, id)
+		if errors.Cause(err) == ErrNotFound || err == nil && u.TenantID != p.TenantID {
+			return errors.Wrapf(ErrInvalidArgument, "user %s is not in the tenant", id)
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to look up user")
+		}
+	}
+	if err := checkSets(t.Sets); err != nil {
+		return err
+	}
+	return checkBlocks(t.Blocks, t.Sets)
+}
+
+// visibleTemplate retrieves a template the caller can see, as if the others
+// did not exist.
+func visibleTemplate(ctx context.Context, repo TemplateRepository, p Principal, id string) (WorkoutTemplate, error) {
+	t, err := repo.GetTemplate(ctx, id)
+	if err != nil {
+		return WorkoutTemplate{}, err
+	}
+	if !t.VisibleTo(p) {
+		return WorkoutTemplate{}, ErrNotFound
+	}
+	return t, nil
+}
@@ -0,0 +1,139 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/inmem"
+	"workout-manager-service/pkg/service"
+)
+
+// newTemplateStore returns an athlete store with a second athlete and a
+// coach, and the contexts of the athletes, the coach and the admin.
+func newTemplateStore(t *testing.T) (*inmem.Store, map[string]context.Context) {
+	t.Helper()
+	s, admin := newAthleteStore(t)
+	for _, u := range []service.User{
+		{Name: "a2", TenantID: "t1", Email: "a2@example.com", Role: service.RoleAthlete},
+		{Name: "c1", TenantID: "t1", Email: "c1@example.com", Role: service.RoleCoach},
+		{Name: "x1", TenantID: "t2", Email: "x1@example.com", Role: service.RoleAthlete},
+	} {
+		if _, err := s.CreateUser(admin, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctxs := map[string]context.Context{"admin": admin}
+	for id, role := range map[string]service.Role{"a1": service.RoleAthlete, "a2": service.RoleAthlete, "c1": service.RoleCoach} {
+		ctxs[id] = service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: id, TenantID: "t1", Role: role})
+	}
+	return s, ctxs
+}
+
+var templateSets = []service.WorkoutSet{{MovementID: "squat", Reps: 5, Weight: service.Load{Kilograms: 100}}}
+
+func TestTemplateVisibility(t *testing.T) {
+	s, ctxs := newTemplateStore(t)
+	svc := service.NewBasicTemplateService(s, s)
+
+	private, err := svc.Create(ctxs["a1"], service.WorkoutTemplate{Title: "Legs", Sets: templateSets})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared, err := svc.Create(ctxs["a1"], service.WorkoutTemplate{Title: "Partner Legs", SharedWith: []string{"a2"}, Sets: templateSets})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if private.Scope != service.TemplateUserScope || private.OwnerID != "a1" || private.TenantID != "t1" {
+		t.Errorf("template = %+v, want a user template of a1", private)
+	}
+	if _, err := svc.Create(ctxs["a1"], service.WorkoutTemplate{Title: "Team Legs", Scope: service.TemplateTenantScope, Sets: templateSets}); errors.Cause(err) != service.ErrPermissionDenied {
+		t.Errorf("Create() of a tenant template by an athlete = %v, want %v", err, service.ErrPermissionDenied)
+	}
+	team, err := svc.Create(ctxs["c1"], service.WorkoutTemplate{Title: "Team Legs", Scope: service.TemplateTenantScope, Sets: templateSets})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for user, want := range map[string][]string{
+		"a1":    {team.Name, shared.Name, private.Name},
+		"a2":    {team.Name, shared.Name},
+		"c1":    {team.Name},
+		"admin": {team.Name, shared.Name, private.Name},
+	} {
+		got, err := svc.List(ctxs[user])
+		if err != nil {
+			t.Fatal(err)
+		}
+		visible := make(map[string]bool)
+		for _, tmpl := range got {
+			visible[tmpl.Name] = true
+		}
+		for _, id := range want {
+			if !visible[id] {
+				t.Errorf("%s cannot see template %s", user, id)
+			}
+		}
+		if len(got) != len(want) {
+			t.Errorf("%s sees %d templates, want %d", user, len(got), len(want))
+		}
+	}
+	if _, err := svc.Get(ctxs["a2"], private.Name); errors.Cause(err) != service.ErrNotFound {
+		t.Errorf("Get() of another athlete's template = %v, want %v", err, service.ErrNotFound)
+	}
+	if got, err := svc.Get(ctxs["a2"], shared.Name); err != nil || got.Title != "Partner Legs" {
+		t.Errorf("Get() of a shared template = %+v, %v", got, err)
+	}
+}
+
+func TestTemplateChanges(t *testing.T) {
+	s, ctxs := newTemplateStore(t)
+	svc := service.NewBasicTemplateService(s, s)
+	tmpl, err := svc.Create(ctxs["a1"], service.WorkoutTemplate{Title: "Legs", SharedWith: []string{"a2"}, Sets: templateSets})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl.Title = "Leg Day"
+	if _, err := svc.Update(ctxs["a2"], tmpl); errors.Cause(err) != service.ErrPermissionDenied {
+		t.Errorf("Update() by a user it is shared with = %v, want %v", err, service.ErrPermissionDenied)
+	}
+	if err := svc.Delete(ctxs["a2"], tmpl.Name); errors.Cause(err) != service.ErrPermissionDenied {
+		t.Errorf("Delete() by a user it is shared with = %v, want %v", err, service.ErrPermissionDenied)
+	}
+	updated, err := svc.Update(ctxs["a1"], tmpl)
+	if err != nil || updated.Title != "Leg Day" || updated.Scope != service.TemplateUserScope || updated.OwnerID != "a1" {
+		t.Errorf("Update() by the owner = %+v, %v, want the new title and the old scope", updated, err)
+	}
+	if err := svc.Delete(ctxs["admin"], tmpl.Name); err != nil {
+		t.Errorf("Delete() by an admin = %v", err)
+	}
+	if _, err := svc.Get(ctxs["a1"], tmpl.Name); errors.Cause(err) != service.ErrNotFound {
+		t.Errorf("Get() of a deleted template = %v, want %v", err, service.ErrNotFound)
+	}
+}
+
+func TestCreateTemplateErrors(t *testing.T) {
+	s, ctxs := newTemplateStore(t)
+	svc := service.NewBasicTemplateService(s, s)
+
+	for _, tc := range []struct {
+		name string
+		ctx  context.Context
+		t    service.WorkoutTemplate
+		err  error
+	}{
+		{"no title", ctxs["a1"], service.WorkoutTemplate{Sets: templateSets}, service.ErrInvalidArgument},
+		{"no sets", ctxs["a1"], service.WorkoutTemplate{Title: "Legs"}, service.ErrInvalidArgument},
+		{"unknown scope", ctxs["a1"], service.WorkoutTemplate{Title: "Legs", Scope: "world", Sets: templateSets}, service.ErrInvalidArgument},
+		{"shared with an unknown user", ctxs["a1"], service.WorkoutTemplate{Title: "Legs", SharedWith: []string{"nope"}, Sets: templateSets}, service.ErrInvalidArgument},
+		{"shared with another tenant", ctxs["a1"], service.WorkoutTemplate{Title: "Legs", SharedWith: []string{"x1"}, Sets: templateSets}, service.ErrInvalidArgument},
+		{"malformed set", ctxs["a1"], service.WorkoutTemplate{Title: "Legs", Sets: []service.WorkoutSet{{Reps: 5}}}, service.ErrInvalidArgument},
+		{"no caller", context.Background(), service.WorkoutTemplate{Title: "Legs", Sets: templateSets}, service.ErrUnauthenticated},
+	} {
+		if _, err := svc.Create(tc.ctx, tc.t); errors.Cause(err) != tc.err {
+			t.Errorf("%s: Create() = %v, want %v", tc.name, err, tc.err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+
+	"workout-manager-service/logging"
+)
+
+type tenantAuditService struct {
+	auditor
+	service TenantService
+}
+
+// NewTenantAuditService takes an AuditRepository as a dependency and returns
+// a TenantService that records every successful mutation.
+func NewTenantAuditService(logger logging.IshiLogger, repo AuditRepository, s TenantService) TenantService {
+	return tenantAuditService{
+		auditor: newAuditor(logger, repo),
+		service: s,
+	}
+}
+
+func tenantResource(id string) string {
+	return "tenants/" + id
+}
+
+// Create records the tenant and its first admin.
+func (as tenantAuditService) Create(ctx context.Context, displayName string, settings TenantSettings, admin User) (t Tenant, u User, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		if t, u, err = as.service.Create(ctx, displayName, settings, admin); err != nil {
+			return err
+		}
+		if err := as.record(ctx, "CreateTenant", tenantResource(t.Name), nil, t); err != nil {
+			return err
+		}
+		return as.record(ctx, "CreateTenant", userResource(u.Name), nil, u)
+	})
+	return t, u, err
+}
+
+// Get is not audited.
+func (as tenantAuditService) Get(ctx context.Context, id string) (Tenant, error) {
+	return as.service.Get(ctx, id)
+}
+
+// List is not audited.
+func (as tenantAuditService) List(ctx context.Context) ([]Tenant, error) {
+	return as.service.List(ctx)
+}
+
+// UpdateSettings records the tenant before and after its settings changed.
+func (as tenantAuditService) UpdateSettings(ctx context.Context, id string, settings TenantSettings, mask []string) (t Tenant, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		before, _ := as.service.Get(ctx, id)
+		if t, err = as.service.UpdateSettings(ctx, id, settings, mask); err != nil {
+			return err
+		}
+		return as.record(ctx, "UpdateTenantSettings", tenantResource(id), before, t)
+	})
+	return t, err
+}
+
+// Suspend records the tenant before and after it was suspended.
+func (as tenantAuditService) Suspend(ctx context.Context, id string) (t Tenant, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		before, _ := as.service.Get(ctx, id)
+		if t, err = as.service.Suspend(ctx, id); err != nil {
+			return err
+		}
+		return as.record(ctx, "SuspendTenant", tenantResource(id), before, t)
+	})
+	return t, err
+}
+
+// Resume records the tenant before and after it was resumed.
+func (as tenantAuditService) Resume(ctx context.Context, id string) (t Tenant, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		before, _ := as.service.Get(ctx, id)
+		if t, err = as.service.Resume(ctx, id); err != nil {
+			return err
+		}
+		return as.record(ctx, "ResumeTenant", tenantResource(id), before, t)
+	})
+	return t, err
+}
+
+// Delete records the tenant as it was before it was deleted. The deletion
+// record itself is kept by the TenantRepository.
+func (as tenantAuditService) Delete(ctx context.Context, id string, reason string) (d TenantDeletion, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		before, _ := as.service.Get(ctx, id)
+		if d, err = as.service.Delete(ctx, id, reason); err != nil {
+			return err
+		}
+		return as.record(ctx, "DeleteTenant", tenantResource(id), before, nil)
+	})
+	return d, err
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"workout-manager-service/logging"
+)
+
+type tenantLoggingService struct {
+	logger  logging.IshiLogger
+	service TenantService
+}
+
+// NewTenantLoggingService takes an IshiLogger as a dependency and returns a
+// TenantService.
+func NewTenantLoggingService(logger logging.IshiLogger, s TenantService) TenantService {
+	return tenantLoggingService{
+		logger:  logger.WithFields("service", "tenant"),
+		service: s,
+	}
+}
+
+// Create provides informative logging when requests are made to the create
+// endpoint.
+func (ls tenantLoggingService) Create(ctx context.Context, displayName string, settings TenantSettings, admin User) (Tenant, User, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Create",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"displayName", displayName,
+			"settings", fmt.Sprintf("%+v", settings),
+			"adminEmail", admin.Email,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Create(ctx, displayName, settings, admin)
+}
+
+// Get provides informative logging when requests are made to the get
+// endpoint.
+func (ls tenantLoggingService) Get(ctx context.Context, id string) (Tenant, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Get",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"id", id,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Get(ctx, id)
+}
+
+// List provides informative logging when requests are made to the list
+// endpoint.
+func (ls tenantLoggingService) List(ctx context.Context) ([]Tenant, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "List",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.List(ctx)
+}
+
+// UpdateSettings provides informative logging when requests are made to the
+// update settings endpoint.
+func (ls tenantLoggingService) UpdateSettings(ctx context.Context, id string, settings TenantSettings, mask []string) (Tenant, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "UpdateSettings",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"id", id,
+			"settings", fmt.Sprintf("%+v", settings),
+			"mask", fmt.Sprintf("%v", mask),
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.UpdateSettings(ctx, id, settings, mask)
+}
+
+// Suspend provides informative logging when requests are made to the suspend
+// endpoint.
+func (ls tenantLoggingService) Suspend(ctx context.Context, id string) (Tenant, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Suspend",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"id", id,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Suspend(ctx, id)
+}
+
+// Resume provides informative logging when requests are made to the resume
+// endpoint.
+func (ls tenantLoggingService) Resume(ctx context.Context, id string) (Tenant, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Resume",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"id", id,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Resume(ctx, id)
+}
+
+// Delete provides informative logging when requests are made to the delete
+// endpoint. Deletions are logged at warn level along with what was removed.
+func (ls tenantLoggingService) Delete(ctx context.Context, id string, reason string) (d TenantDeletion, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Warn(
+			method, "Delete",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"id", id,
+			"reason", reason,
+			"rowCounts", fmt.Sprintf("%v", d.RowCounts),
+			"err", err,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Delete(ctx, id, reason)
+}
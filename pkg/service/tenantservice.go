@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/logging"
+)
+
+// SystemTenantID is the reserved tenant that platform operators belong to. It
+// never appears in the tenant catalog.
+const SystemTenantID = "system"
+
+// TenantState describes whether a tenant's users may use the service.
+type TenantState string
+
+// The states a Tenant moves through. Tenants must be suspended before they can
+// be deleted.
+const (
+	TenantActive    TenantState = "active"
+	TenantSuspended TenantState = "suspended"
+)
+
+// WeightUnit is the unit loads are displayed in.
+type WeightUnit string
+
+// The weight units a tenant may default to.
+const (
+	Kilograms WeightUnit = "kg"
+	Pounds    WeightUnit = "lb"
+)
+
+// Tenant represents a gym or team that uses the service. Every other resource
+// belongs to exactly one tenant.
+type Tenant struct {
+	Name        string         `json:"id"`
+	DisplayName string         `json:"displayName"`
+	State       TenantState    `json:"state"`
+	Settings    TenantSettings `json:"settings"`
+	CreateTime  time.Time      `json:"createTime"`
+}
+
+// TenantSettings holds the per-tenant preferences applied to every user in the
+// tenant.
+type TenantSettings struct {
+	DefaultUnit WeightUnit   `json:"defaultUnit"`
+	WeekStart   time.Weekday `json:"weekStart"`
+	TimeZone    string       `json:"timeZone"`
+}
+
+// The settings an update mask may name.
+const (
+	SettingDefaultUnit = "default_unit"
+	SettingWeekStart   = "week_start"
+	SettingTimeZone    = "time_zone"
+)
+
+// DefaultTenantSettings are applied to any setting left empty when a tenant
+// is created.
+var DefaultTenantSettings = TenantSettings{
+	DefaultUnit: Kilograms,
+	WeekStart:   time.Monday,
+	TimeZone:    "UTC",
+}
+
+// TenantDeletion is the audit record left behind when a tenant and all of its
+// data are removed. It outlives the tenant itself.
+type TenantDeletion struct {
+	TenantID    string           `json:"tenantId"`
+	DisplayName string           `json:"displayName"`
+	RequestedBy string           `json:"requestedBy"`
+	Reason      string           `json:"reason"`
+	DeleteTime  time.Time        `json:"deleteTime"`
+	RowCounts   map[string]int64 `json:"rowCounts"`
+}
+
+// TenantRepository persists tenants. Creating a tenant with its first admin,
+// and deleting a tenant with everything that belongs to it, must each happen
+// in a single transaction.
+type TenantRepository interface {
+	CreateTenant(ctx context.Context, t Tenant, admin User) error
+	GetTenant(ctx context.Context, id string) (Tenant, error)
+	ListTenants(ctx context.Context) ([]Tenant, error)
+	UpdateTenant(ctx context.Context, t Tenant) error
+	DeleteTenant(ctx context.Context, d TenantDeletion) (TenantDeletion, error)
+}
+
+// TenantService describes a service that provisions and administers tenants.
+type TenantService interface {
+	Create(ctx context.Context, displayName string, settings TenantSettings, admin User) (Tenant, User, error)
+	Get(ctx context.Context, id string) (Tenant, error)
+	List(ctx context.Context) ([]Tenant, error)
+	UpdateSettings(ctx context.Context, id string, settings TenantSettings, mask []string) (Tenant, error)
+	Suspend(ctx context.Context, id string) (Tenant, error)
+	Resume(ctx context.Context, id string) (Tenant, error)
+	Delete(ctx context.Context, id string, reason string) (TenantDeletion, error)
+}
+
+// NewTenantService returns a basic TenantService with middleware wired in.
+func NewTenantService(logger logging.IshiLogger, repo TenantRepository, audit AuditRepository) TenantService {
+	var svc TenantService
+	{
+		svc = NewBasicTenantService(repo)
+		svc = NewTenantAuditService(logger, audit, svc)
+		svc = NewTenantLoggingService(logger, svc)
+	}
+	return svc
+}
+
+// NewBasicTenantService returns an implementation of TenantService backed by
+// the given repository.
+func NewBasicTenantService(repo TenantRepository) TenantService {
+	return basicTenantService{repo: repo}
+}
+
+type basicTenantService struct {
+	repo TenantRepository
+}
+
+// Create provisions a new tenant along with its first admin. The tenant
+// inherits the global movement catalog, so nothing else needs to be seeded.
+func (s basicTenantService) Create(ctx context.Context, displayName string, settings TenantSettings, admin User) (Tenant, User, error) {
+	if displayName == "" {
+		return Tenant{}, User{}, errors.Wrap(ErrInvalidArgument, "display name is required")
+	}
+	if admin.DisplayName == "" || admin.Email == "" {
+		return Tenant{}, User{}, errors.Wrap(ErrInvalidArgument, "admin display name and email are required")
+	}
+	settings = withDefaultSettings(settings)
+	if err := validateSettings(settings); err != nil {
+		return Tenant{}, User{}, err
+	}
+
+	t := Tenant{
+		Name:        uuid.New().String(),
+		DisplayName: displayName,
+		State:       TenantActive,
+		Settings:    settings,
+		CreateTime:  time.Now().UTC(),
+	}
+	admin = User{
+		Name:        uuid.New().String(),
+		TenantID:    t.Name,
+		DisplayName: admin.DisplayName,
+		Email:       admin.Email,
+		Role:        RoleAdmin,
+	}
+	if err := s.repo.CreateTenant(ctx, t, admin); err != nil {
+		return Tenant{}, User{}, err
+	}
+	return t, admin, nil
+}
+
+// Get retrieves a tenant by its UUID.
+func (s basicTenantService) Get(ctx context.Context, id string) (Tenant, error) {
+	return s.repo.GetTenant(ctx, id)
+}
+
+// List retrieves every tenant.
+func (s basicTenantService) List(ctx context.Context) ([]Tenant, error) {
+	return s.repo.ListTenants(ctx)
+}
+
+// UpdateSettings changes the tenant settings named in mask. Without a mask
+// only the settings that are not zero are changed, so a week starting on
+// Sunday, the zero weekday, has to be named explicitly.
+func (s basicTenantService) UpdateSettings(ctx context.Context, id string, settings TenantSettings, mask []string) (Tenant, error) {
+	t, err := s.repo.GetTenant(ctx, id)
+	if err != nil {
+		return Tenant{}, err
+	}
+	if len(mask) == 0 {
+		if settings.DefaultUnit != "" {
+			mask = append(mask, SettingDefaultUnit)
+		}
+		if settings.WeekStart != time.Sunday {
+			mask = append(mask, SettingWeekStart)
+		}
+		if settings.TimeZone != "" {
+			mask = append(mask, SettingTimeZone)
+		}
+	}
+	for _, path := range mask {
+		switch path {
+		case SettingDefaultUnit:
+			t.Settings.DefaultUnit = settings.DefaultUnit
+		case SettingWeekStart:
+			t.Settings.WeekStart = settings.WeekStart
+		case SettingTimeZone:
+			t.Settings.TimeZone = settings.TimeZone
+		default:
+			return Tenant{}, errors.Wrapf(ErrInvalidArgument, "unknown setting %q", path)
+		}
+	}
+	if err := validateSettings(t.Settings); err != nil {
+		return Tenant{}, err
+	}
+	return t, s.repo.UpdateTenant(ctx, t)
+}
+
+// Suspend prevents a tenant's users from using the service without removing
+// any of their data.
+func (s basicTenantService) Suspend(ctx context.Context, id string) (Tenant, error) {
+	return s.setState(ctx, id, TenantSuspended)
+}
+
+// Resume reactivates a suspended tenant.
+func (s basicTenantService) Resume(ctx context.Context, id string) (Tenant, error) {
+	return s.setState(ctx, id, TenantActive)
+}
+
+// Delete permanently removes a suspended tenant and everything that belongs
+// to it, returning the audit record of what was removed.
+func (s basicTenantService) Delete(ctx context.Context, id string, reason string) (TenantDeletion, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return TenantDeletion{}, err
+	}
+	if reason == "" {
+		return TenantDeletion{}, errors.Wrap(ErrInvalidArgument, "a reason is required to delete a tenant")
+	}
+	t, err := s.repo.GetTenant(ctx, id)
+	if err != nil {
+		return TenantDeletion{}, err
+	}
+	if t.State != TenantSuspended {
+		return TenantDeletion{}, errors.Wrap(ErrInvalidArgument, "tenant must be suspended before it is deleted")
+	}
+	return s.repo.DeleteTenant(ctx, TenantDeletion{
+		TenantID:    t.Name,
+		DisplayName: t.DisplayName,
+		RequestedBy: p.UserID,
+		Reason:      reason,
+		DeleteTime:  time.Now().UTC(),
+	})
+}
+
+func (s basicTenantService) setState(ctx context.Context, id string, state TenantState) (Tenant, error) {
+	t, err := s.repo.GetTenant(ctx, id)
+	if err != nil {
+		return Tenant{}, err
+	}
+	t.State = state
+	return t, s.repo.UpdateTenant(ctx, t)
+}
+
+func withDefaultSettings(settings TenantSettings) TenantSettings {
+	if settings.DefaultUnit == "" {
+		settings.DefaultUnit = DefaultTenantSettings.DefaultUnit
+	}
+	if settings.TimeZone == "" {
+		settings.TimeZone = DefaultTenantSettings.TimeZone
+	}
+	return settings
+}
+
+func validateSettings(settings TenantSettings) error {
+	if settings.DefaultUnit != Kilograms && settings.DefaultUnit != Pounds {
+		return errors.Wrapf(ErrInvalidArgument, "unknown unit %q", settings.DefaultUnit)
+	}
+	if settings.WeekStart < time.Sunday || settings.WeekStart > time.Saturday {
+		return errors.Wrapf(ErrInvalidArgument, "invalid week start day %d", settings.WeekStart)
+	}
+	if _, err := time.LoadLocation(settings.TimeZone); err != nil {
+		return errors.Wrapf(ErrInvalidArgument, "unknown time zone %q", settings.TimeZone)
+	}
+	return nil
+}
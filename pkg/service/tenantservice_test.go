@@ -0,0 +1,132 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/inmem"
+	"workout-manager-service/pkg/service"
+)
+
+func TestUpdateTenantSettings(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewBasicTenantService(inmem.NewStore())
+	settings := service.TenantSettings{DefaultUnit: service.Kilograms, WeekStart: time.Monday, TimeZone: "Europe/Berlin"}
+	tenant, _, err := svc.Create(ctx, "Gym", settings, service.User{DisplayName: "Admin", Email: "admin@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name     string
+		settings service.TenantSettings
+		mask     []string
+		want     service.TenantSettings
+		err      error
+	}{
+		{
+			name:     "unit without a mask keeps the week start",
+			settings: service.TenantSettings{DefaultUnit: service.Pounds},
+			want:     service.TenantSettings{DefaultUnit: service.Pounds, WeekStart: time.Monday, TimeZone: "Europe/Berlin"},
+		},
+		{
+			name:     "week start without a mask",
+			settings: service.TenantSettings{WeekStart: time.Saturday},
+			want:     service.TenantSettings{DefaultUnit: service.Pounds, WeekStart: time.Saturday, TimeZone: "Europe/Berlin"},
+		},
+		{
+			name: "Sunday named in the mask",
+			mask: []string{service.SettingWeekStart},
+			want: service.TenantSettings{DefaultUnit: service.Pounds, WeekStart: time.Sunday, TimeZone: "Europe/Berlin"},
+		},
+		{
+			name:     "only the masked settings change",
+			settings: service.TenantSettings{DefaultUnit: service.Kilograms, WeekStart: time.Monday, TimeZone: "UTC"},
+			mask:     []string{service.SettingTimeZone},
+			want:     service.TenantSettings{DefaultUnit: service.Pounds, WeekStart: time.Sunday, TimeZone: "UTC"},
+		},
+		{
+			name: "unknown setting",
+			mask: []string{"currency"},
+			err:  service.ErrInvalidArgument,
+		},
+		{
+			name: "masked unit left empty",
+			mask: []string{service.SettingDefaultUnit},
+			err:  service.ErrInvalidArgument,
+		},
+		{
+			name:     "unknown time zone",
+			settings: service.TenantSettings{TimeZone: "Mars/Olympus"},
+			err:      service.ErrInvalidArgument,
+		},
+	} {
+		got, err := svc.UpdateSettings(ctx, tenant.Name, tc.settings, tc.mask)
+		if errors.Cause(err) != tc.err {
+			t.Errorf("%s: UpdateSettings() error = %v, want %v", tc.name, err, tc.err)
+			continue
+		}
+		if tc.err == nil && got.Settings != tc.want {
+			t.Errorf("%s: settings = %+v, want %+v", tc.name, got.Settings, tc.want)
+		}
+	}
+	if _, err := svc.UpdateSettings(ctx, "nope", service.TenantSettings{}, nil); errors.Cause(err) != service.ErrNotFound {
+		t.Errorf("UpdateSettings() of an unknown tenant = %v, want %v", err, service.ErrNotFound)
+	}
+}
+
+func TestDeleteTenant(t *testing.T) {
+	s := inmem.NewStore()
+	svc := service.NewBasicTenantService(s)
+	var tenants []service.Tenant
+	for _, name := range []string{"Gym", "Other Gym"} {
+		tenant, admin, err := svc.Create(context.Background(), name, service.TenantSettings{}, service.User{DisplayName: "Admin", Email: "admin@example.com"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: admin.Name, TenantID: tenant.Name, Role: service.RoleAdmin})
+		if _, err := s.CreateMovement(ctx, service.Movement{Name: name, TenantID: tenant.Name, MovementName: "Squat"}); err != nil {
+			t.Fatal(err)
+		}
+		tenants = append(tenants, tenant)
+	}
+	ctx := service.NewContextWithPrincipal(context.Background(), service.Principal{UserID: "operator", Role: service.RoleAdmin})
+	id := tenants[0].Name
+
+	if _, err := svc.Delete(ctx, id, ""); errors.Cause(err) != service.ErrInvalidArgument {
+		t.Errorf("Delete() without a reason = %v, want %v", err, service.ErrInvalidArgument)
+	}
+	if _, err := svc.Delete(ctx, id, "contract ended"); errors.Cause(err) != service.ErrInvalidArgument {
+		t.Errorf("Delete() of an active tenant = %v, want %v", err, service.ErrInvalidArgument)
+	}
+	if _, err := svc.Delete(ctx, "nope", "contract ended"); errors.Cause(err) != service.ErrNotFound {
+		t.Errorf("Delete() of an unknown tenant = %v, want %v", err, service.ErrNotFound)
+	}
+	if _, err := svc.Suspend(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	d, err := svc.Delete(ctx, id, "contract ended")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.RequestedBy != "operator" || d.Reason != "contract ended" || d.DisplayName != "Gym" {
+		t.Errorf("deletion = %+v, want it requested by the operator", d)
+	}
+	for table, want := range map[string]int64{"tenants": 1, "users": 1, "movements": 1, "outbox": 1} {
+		if d.RowCounts[table] != want {
+			t.Errorf("%s rows deleted = %d, want %d", table, d.RowCounts[table], want)
+		}
+	}
+	if _, err := svc.Get(ctx, id); errors.Cause(err) != service.ErrNotFound {
+		t.Errorf("Get() of the deleted tenant = %v, want %v", err, service.ErrNotFound)
+	}
+	events, _ := s.ListOutboxEvents(ctx, 10)
+	if len(events) != 1 || events[0].TenantID != tenants[1].Name {
+		t.Errorf("outbox = %+v, want only the other tenant's event", events)
+	}
+	if _, err := s.GetMovement(ctx, "Other Gym"); err != nil {
+		t.Errorf("the other tenant's movement was deleted: %v", err)
+	}
+}
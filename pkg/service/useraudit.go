@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+
+	"workout-manager-service/logging"
+)
+
+type userAuditService struct {
+	auditor
+	service UserService
+}
+
+// NewUserAuditService takes an AuditRepository as a dependency and returns a
+// UserService that records every successful mutation.
+func NewUserAuditService(logger logging.IshiLogger, repo AuditRepository, s UserService) UserService {
+	return userAuditService{
+		auditor: newAuditor(logger, repo),
+		service: s,
+	}
+}
+
+func userResource(id string) string {
+	return "users/" + id
+}
+
+// coaching is the snapshot recorded when rosters change.
+type coaching struct {
+	CoachID   string `json:"coachId"`
+	AthleteID string `json:"athleteId"`
+}
+
+// Authenticate is not audited.
+func (as userAuditService) Authenticate(ctx context.Context, userID string) (Principal, error) {
+	return as.service.Authenticate(ctx, userID)
+}
+
+// Create records the user that was created.
+func (as userAuditService) Create(ctx context.Context, displayName string, email string, role Role) (u User, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		if u, err = as.service.Create(ctx, displayName, email, role); err != nil {
+			return err
+		}
+		return as.record(ctx, "CreateUser", userResource(u.Name), nil, u)
+	})
+	return u, err
+}
+
+// Get is not audited.
+func (as userAuditService) Get(ctx context.Context, id string) (User, error) {
+	return as.service.Get(ctx, id)
+}
+
+// List is not audited.
+func (as userAuditService) List(ctx context.Context, role Role) ([]User, error) {
+	return as.service.List(ctx, role)
+}
+
+// AssignCoach records the athlete joining the coach's roster.
+func (as userAuditService) AssignCoach(ctx context.Context, coachID string, athleteID string) error {
+	return as.repo.InTx(ctx, func(ctx context.Context) error {
+		if err := as.service.AssignCoach(ctx, coachID, athleteID); err != nil {
+			return err
+		}
+		return as.record(ctx, "AssignCoach", userResource(athleteID), nil, coaching{coachID, athleteID})
+	})
+}
+
+// UnassignCoach records the athlete leaving the coach's roster.
+func (as userAuditService) UnassignCoach(ctx context.Context, coachID string, athleteID string) error {
+	return as.repo.InTx(ctx, func(ctx context.Context) error {
+		if err := as.service.UnassignCoach(ctx, coachID, athleteID); err != nil {
+			return err
+		}
+		return as.record(ctx, "UnassignCoach", userResource(athleteID), coaching{coachID, athleteID}, nil)
+	})
+}
+
+// ListRoster is not audited.
+func (as userAuditService) ListRoster(ctx context.Context, coachID string) ([]User, error) {
+	return as.service.ListRoster(ctx, coachID)
+}
+
+// IsCoachOf is not audited.
+func (as userAuditService) IsCoachOf(ctx context.Context, coachID string, athleteID string) (bool, error) {
+	return as.service.IsCoachOf(ctx, coachID, athleteID)
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"workout-manager-service/logging"
+)
+
+type userLoggingService struct {
+	logger  logging.IshiLogger
+	service UserService
+}
+
+// NewUserLoggingService takes an IshiLogger as a dependency and returns a
+// UserService.
+func NewUserLoggingService(logger logging.IshiLogger, s UserService) UserService {
+	return userLoggingService{
+		logger:  logger.WithFields("service", "user"),
+		service: s,
+	}
+}
+
+// Authenticate provides informative logging when a request's caller is
+// resolved.
+func (ls userLoggingService) Authenticate(ctx context.Context, userID string) (Principal, error) {
+	defer func(begin time.Time) {
+		ls.logger.Debug(
+			method, "Authenticate",
+			"userID", userID,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Authenticate(ctx, userID)
+}
+
+// Create provides informative logging when requests are made to the create
+// endpoint.
+func (ls userLoggingService) Create(ctx context.Context, displayName string, email string, role Role) (User, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Create",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"displayName", displayName,
+			"role", role,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Create(ctx, displayName, email, role)
+}
+
+// Get provides informative logging when requests are made to the get
+// endpoint.
+func (ls userLoggingService) Get(ctx context.Context, id string) (User, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Get",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"id", id,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Get(ctx, id)
+}
+
+// List provides informative logging when requests are made to the list
+// endpoint.
+func (ls userLoggingService) List(ctx context.Context, role Role) ([]User, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "List",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"role", role,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.List(ctx, role)
+}
+
+// AssignCoach provides informative logging when requests are made to the
+// assign coach endpoint.
+func (ls userLoggingService) AssignCoach(ctx context.Context, coachID string, athleteID string) error {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "AssignCoach",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"coachID", coachID,
+			"athleteID", athleteID,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.AssignCoach(ctx, coachID, athleteID)
+}
+
+// UnassignCoach provides informative logging when requests are made to the
+// unassign coach endpoint.
+func (ls userLoggingService) UnassignCoach(ctx context.Context, coachID string, athleteID string) error {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "UnassignCoach",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"coachID", coachID,
+			"athleteID", athleteID,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.UnassignCoach(ctx, coachID, athleteID)
+}
+
+// ListRoster provides informative logging when requests are made to the list
+// roster endpoint.
+func (ls userLoggingService) ListRoster(ctx context.Context, coachID string) ([]User, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "ListRoster",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"coachID", coachID,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.ListRoster(ctx, coachID)
+}
+
+// IsCoachOf provides informative logging when authorization policies check a
+// coach's roster.
+func (ls userLoggingService) IsCoachOf(ctx context.Context, coachID string, athleteID string) (bool, error) {
+	defer func(begin time.Time) {
+		ls.logger.Debug(
+			method, "IsCoachOf",
+			"coachID", coachID,
+			"athleteID", athleteID,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.IsCoachOf(ctx, coachID, athleteID)
+}
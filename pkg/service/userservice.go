@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/logging"
+)
+
+// Role determines what a User is allowed to do within their tenant.
+type Role string
+
+// The roles a User can hold. Operators are platform staff who belong to the
+// SystemTenantID rather than to a gym.
+const (
+	RoleAthlete  Role = "athlete"
+	RoleCoach    Role = "coach"
+	RoleAdmin    Role = "admin"
+	RoleOperator Role = "operator"
+)
+
+// Valid reports whether r is one of the roles that can be held inside a
+// tenant.
+func (r Role) Valid() bool {
+	switch r {
+	case RoleAthlete, RoleCoach, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// User represents a person inside a tenant: an athlete who logs workouts, a
+// coach who programs them, or an administrator who runs the gym.
+type User struct {
+	Name        string `json:"id"`
+	TenantID    string `json:"tenantId"`
+	DisplayName string `json:"displayName"`
+	Email       string `json:"email"`
+	Role        Role   `json:"role"`
+}
+
+// UserRepository persists users and the coach-to-athlete relationships
+// between them.
+type UserRepository interface {
+	CreateUser(ctx context.Context, u User) (User, error)
+	GetUser(ctx context.Context, id string) (User, error)
+	ListUsers(ctx context.Context, tenantID string, role Role) ([]User, error)
+	CreateCoaching(ctx context.Context, tenantID string, coachID string, athleteID string) error
+	DeleteCoaching(ctx context.Context, tenantID string, coachID string, athleteID string) error
+	ListAthletes(ctx context.Context, tenantID string, coachID string) ([]User, error)
+	IsCoachOf(ctx context.Context, tenantID string, coachID string, athleteID string) (bool, error)
+}
+
+// UserService describes a service that deals with users and the coaches
+// assigned to them.
+type UserService interface {
+	Authenticate(ctx context.Context, userID string) (Principal, error)
+	Create(ctx context.Context, displayName string, email string, role Role) (User, error)
+	Get(ctx context.Context, id string) (User, error)
+	List(ctx context.Context, role Role) ([]User, error)
+	AssignCoach(ctx context.Context, coachID string, athleteID string) error
+	UnassignCoach(ctx context.Context, coachID string, athleteID string) error
+	ListRoster(ctx context.Context, coachID string) ([]User, error)
+	IsCoachOf(ctx context.Context, coachID string, athleteID string) (bool, error)
+}
+
+// NewUserService returns a basic UserService with middleware wired in.
+func NewUserService(logger logging.IshiLogger, repo UserRepository, tenants TenantRepository, audit AuditRepository) UserService {
+	var svc UserService
+	{
+		svc = NewBasicUserService(repo, tenants)
+		svc = NewUserAuditService(logger, audit, svc)
+		svc = NewUserLoggingService(logger, svc)
+	}
+	return svc
+}
+
+// NewBasicUserService returns an implementation of UserService backed by the
+// given repositories.
+func NewBasicUserService(repo UserRepository, tenants TenantRepository) UserService {
+	return basicUserService{repo: repo, tenants: tenants}
+}
+
+type basicUserService struct {
+	repo    UserRepository
+	tenants TenantRepository
+}
+
+// Authenticate resolves a user ID into the Principal making a request, who
+// prefers loads in their tenant's default unit. Users of suspended tenants
+// are refused.
+func (s basicUserService) Authenticate(ctx context.Context, userID string) (Principal, error) {
+	if userID == "" {
+		return Principal{}, ErrUnauthenticated
+	}
+	u, err := s.repo.GetUser(ctx, userID)
+	if errors.Cause(err) == ErrNotFound {
+		return Principal{}, ErrUnauthenticated
+	}
+	if err != nil {
+		return Principal{}, err
+	}
+	p := Principal{UserID: u.Name, TenantID: u.TenantID, Role: u.Role, Unit: DefaultTenantSettings.DefaultUnit}
+	if u.TenantID != SystemTenantID {
+		t, err := s.tenants.GetTenant(ctx, u.TenantID)
+		if err != nil {
+			return Principal{}, errors.Wrap(err, "failed to look up tenant")
+		}
+		if t.State != TenantActive {
+			return Principal{}, errors.Wrap(ErrPermissionDenied, "tenant is suspended")
+		}
+		if t.Settings.DefaultUnit != "" {
+			p.Unit = t.Settings.DefaultUnit
+		}
+	}
+	return p, nil
+}
+
+// Create adds a new User to the caller's tenant.
+func (s basicUserService) Create(ctx context.Context, displayName string, email string, role Role) (User, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return User{}, err
+	}
+	if displayName == "" || email == "" {
+		return User{}, errors.Wrap(ErrInvalidArgument, "display name and email are required")
+	}
+	if !role.Valid() {
+		return User{}, errors.Wrapf(ErrInvalidArgument, "unknown role %q", role)
+	}
+	return s.repo.CreateUser(ctx, User{
+		Name:        uuid.New().String(),
+		TenantID:    p.TenantID,
+		DisplayName: displayName,
+		Email:       email,
+		Role:        role,
+	})
+}
+
+// Get retrieves a User in the caller's tenant by its UUID.
+func (s basicUserService) Get(ctx context.Context, id string) (User, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return User{}, err
+	}
+	return s.getInTenant(ctx, p.TenantID, id)
+}
+
+// List retrieves the users in the caller's tenant, optionally filtering by
+// role.
+func (s basicUserService) List(ctx context.Context, role Role) ([]User, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if role != "" && !role.Valid() {
+		return nil, errors.Wrapf(ErrInvalidArgument, "unknown role %q", role)
+	}
+	return s.repo.ListUsers(ctx, p.TenantID, role)
+}
+
+// AssignCoach adds an athlete to a coach's roster.
+func (s basicUserService) AssignCoach(ctx context.Context, coachID string, athleteID string) error {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.checkCoaching(ctx, p.TenantID, coachID, athleteID); err != nil {
+		return err
+	}
+	return s.repo.CreateCoaching(ctx, p.TenantID, coachID, athleteID)
+}
+
+// UnassignCoach removes an athlete from a coach's roster.
+func (s basicUserService) UnassignCoach(ctx context.Context, coachID string, athleteID string) error {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteCoaching(ctx, p.TenantID, coachID, athleteID)
+}
+
+// ListRoster retrieves the athletes assigned to a coach.
+func (s basicUserService) ListRoster(ctx context.Context, coachID string) ([]User, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListAthletes(ctx, p.TenantID, coachID)
+}
+
+// IsCoachOf reports whether the athlete is on the coach's roster.
+func (s basicUserService) IsCoachOf(ctx context.Context, coachID string, athleteID string) (bool, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	return s.repo.IsCoachOf(ctx, p.TenantID, coachID, athleteID)
+}
+
+// getInTenant hides users belonging to other tenants behind ErrNotFound so
+// that IDs can't be probed across tenants.
+func (s basicUserService) getInTenant(ctx context.Context, tenantID string, id string) (User, error) {
+	u, err := s.repo.GetUser(ctx, id)
+	if err != nil {
+		return User{}, err
+	}
+	if u.TenantID != tenantID {
+		return User{}, ErrNotFound
+	}
+	return u, nil
+}
+
+func (s basicUserService) checkCoaching(ctx context.Context, tenantID string, coachID string, athleteID string) error {
+	coach, err := s.getInTenant(ctx, tenantID, coachID)
+	if err != nil {
+		return errors.Wrap(err, "failed to look up coach")
+	}
+	if coach.Role != RoleCoach {
+		return errors.Wrapf(ErrInvalidArgument, "user %s is not a coach", coachID)
+	}
+	athlete, err := s.getInTenant(ctx, tenantID, athleteID)
+	if err != nil {
+		return errors.Wrap(err, "failed to look up athlete")
+	}
+	if athlete.Role != RoleAthlete {
+		return errors.Wrapf(ErrInvalidArgument, "user %s is not an athlete", athleteID)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+
+	"workout-manager-service/logging"
+)
+
+type webhookAuditService struct {
+	auditor
+	service WebhookService
+}
+
+// NewWebhookAuditService takes an AuditRepository as a dependency and
+// returns a WebhookService that records every successful mutation. Secrets
+// are never recorded.
+func NewWebhookAuditService(logger logging.IshiLogger, repo AuditRepository, s WebhookService) WebhookService {
+	return webhookAuditService{
+		auditor: newAuditor(logger, repo),
+		service: s,
+	}
+}
+
+func webhookResource(id string) string {
+	return "webhooks/" + id
+}
+
+func webhookDeliveryResource(d WebhookDelivery) string {
+	return webhookResource(d.WebhookID) + "/deliveries/" + d.Name
+}
+
+// Create records the new webhook.
+func (as webhookAuditService) Create(ctx context.Context, w Webhook) (created Webhook, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		if created, err = as.service.Create(ctx, w); err != nil {
+			return err
+		}
+		after := created
+		after.Secret = ""
+		return as.record(ctx, "CreateWebhook", webhookResource(created.Name), nil, after)
+	})
+	return created, err
+}
+
+// Get is not audited.
+func (as webhookAuditService) Get(ctx context.Context, id string) (Webhook, error) {
+	return as.service.Get(ctx, id)
+}
+
+// List is not audited.
+func (as webhookAuditService) List(ctx context.Context) ([]Webhook, error) {
+	return as.service.List(ctx)
+}
+
+// Update records the webhook before and after it changed.
+func (as webhookAuditService) Update(ctx context.Context, w Webhook) (after Webhook, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		before, _ := as.service.Get(ctx, w.Name)
+		if after, err = as.service.Update(ctx, w); err != nil {
+			return err
+		}
+		return as.record(ctx, "UpdateWebhook", webhookResource(after.Name), before, after)
+	})
+	return after, err
+}
+
+// Delete records the webhook as it was before it was deleted.
+func (as webhookAuditService) Delete(ctx context.Context, id string) error {
+	return as.repo.InTx(ctx, func(ctx context.Context) error {
+		before, _ := as.service.Get(ctx, id)
+		if err := as.service.Delete(ctx, id); err != nil {
+			return err
+		}
+		return as.record(ctx, "DeleteWebhook", webhookResource(id), before, nil)
+	})
+}
+
+// RotateSecret records that the secret was rotated, without either secret.
+func (as webhookAuditService) RotateSecret(ctx context.Context, id string) (w Webhook, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		before, _ := as.service.Get(ctx, id)
+		if w, err = as.service.RotateSecret(ctx, id); err != nil {
+			return err
+		}
+		after := w
+		after.Secret = ""
+		return as.record(ctx, "RotateWebhookSecret", webhookResource(id), before, after)
+	})
+	return w, err
+}
+
+// ListDeliveries is not audited.
+func (as webhookAuditService) ListDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error) {
+	return as.service.ListDeliveries(ctx, filter)
+}
+
+// Redeliver records the delivery once it was scheduled again.
+func (as webhookAuditService) Redeliver(ctx context.Context, deliveryID string) (d WebhookDelivery, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		if d, err = as.service.Redeliver(ctx, deliveryID); err != nil {
+			return err
+		}
+		return as.record(ctx, "RedeliverWebhook", webhookDeliveryResource(d), nil, d)
+	})
+	return d, err
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// forbiddenWebhookNetworks are the addresses webhooks may not be delivered
+// to: loopback, private, shared, link-local (which holds the cloud metadata
+// endpoints at 169.254.169.254 and fd00:ec2::254), multicast and unspecified
+// addresses. Otherwise any tenant admin could have the service post to
+// hosts only it can reach.
+var forbiddenWebhookNetworks = parseNetworks(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = n
+	}
+	return networks
+}
+
+// forbiddenWebhookIP reports whether webhooks may not be delivered to ip.
+// IPv4 addresses mapped into IPv6 are checked as IPv4.
+func forbiddenWebhookIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, n := range forbiddenWebhookNetworks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWebhookURL resolves the host of a webhook URL and rejects it if any
+// of its addresses is forbidden. Since the host may resolve differently by
+// the time a delivery is made, the client of NewWebhookClient checks again
+// when it dials.
+func checkWebhookURL(ctx context.Context, u *url.URL) error {
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if forbiddenWebhookIP(ip) {
+			return errors.Wrapf(ErrInvalidArgument, "webhooks may not be delivered to %s", host)
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return errors.Wrapf(ErrInvalidArgument, "failed to resolve webhook host %q: %v", host, err)
+	}
+	for _, addr := range addrs {
+		if forbiddenWebhookIP(addr.IP) {
+			return errors.Wrapf(ErrInvalidArgument, "webhooks may not be delivered to %s, which resolves to %s", host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// NewWebhookClient returns the client to deliver webhooks with. It refuses
+// to connect to forbidden addresses, checking the address it is about to dial
+// rather than the host name, so that a host which resolved to a public
+// address when the webhook was registered cannot be rebound to a private one,
+// and redirects are held to the same rule. Proxies from the environment are
+// not used, since the client could not check where they connect.
+func NewWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   webhookDialControl,
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+	}
+}
+
+// webhookDialControl is called with the resolved address of every
+// connection the webhook client makes, before it is made.
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || forbiddenWebhookIP(ip) {
+		return errors.Errorf("webhooks may not be delivered to %s", host)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestForbiddenWebhookIP(t *testing.T) {
+	for _, tc := range []struct {
+		ip        string
+		forbidden bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"172.32.0.1", false},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"::1", true},
+		{"::", true},
+		{"fe80::1", true},
+		{"fd00:ec2::254", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:10.0.0.1", true},
+		{"8.8.8.8", false},
+		{"93.184.216.34", false},
+		{"2606:4700:4700::1111", false},
+	} {
+		if got := forbiddenWebhookIP(net.ParseIP(tc.ip)); got != tc.forbidden {
+			t.Errorf("forbiddenWebhookIP(%s) = %v, want %v", tc.ip, got, tc.forbidden)
+		}
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	for _, tc := range []struct {
+		url string
+		ok  bool
+	}{
+		{"https://93.184.216.34/hook", true},
+		{"http://8.8.8.8:8080/hook", true},
+		{"ftp://8.8.8.8/hook", false},
+		{"https:///hook", false},
+		{"http://127.0.0.1:8080/hook", false},
+		{"http://localhost/hook", false},
+		{"http://169.254.169.254/latest/meta-data/", false},
+		{"http://[::1]/hook", false},
+		{"http://[::ffff:192.168.0.1]/hook", false},
+		{"http://10.0.0.8/hook", false},
+	} {
+		w := Webhook{URL: tc.url, EventTypes: []DomainEventType{MovementCreatedEvent}}
+		err := validateWebhook(context.Background(), w)
+		if tc.ok && err != nil {
+			t.Errorf("validateWebhook(%s) = %v, want nil", tc.url, err)
+		}
+		if !tc.ok && errors.Cause(err) != ErrInvalidArgument {
+			t.Errorf("validateWebhook(%s) = %v, want %v", tc.url, err, ErrInvalidArgument)
+		}
+	}
+}
+
+func TestWebhookClientRefusesForbiddenAddresses(t *testing.T) {
+	called := false
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer receiver.Close()
+
+	_, err := NewWebhookClient(time.Second).Post(receiver.URL, "application/json", strings.NewReader("{}"))
+	if err == nil || !strings.Contains(err.Error(), "may not be delivered") {
+		t.Fatalf("posting to %s: got %v, want a refused dial", receiver.URL, err)
+	}
+	if called {
+		t.Fatal("the receiver was called")
+	}
+}
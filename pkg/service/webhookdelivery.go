@@ -0,0 +1,374 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/logging"
+)
+
+// The headers every webhook delivery carries. The ID is the event's, so that
+// receivers can ignore events they have already handled.
+const (
+	WebhookIDHeader        = "Webhook-Id"
+	WebhookEventHeader     = "Webhook-Event"
+	WebhookTimestampHeader = "Webhook-Timestamp"
+	WebhookSignatureHeader = "Webhook-Signature"
+)
+
+// SignWebhook returns the Webhook-Signature of a delivery: "v1=" followed by
+// the hex encoded HMAC-SHA256, keyed by the webhook's secret, of the
+// Webhook-Timestamp, a period and the body. Receivers should compute it
+// themselves, compare in constant time and reject stale timestamps.
+func SignWebhook(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	io.WriteString(mac, timestamp)
+	io.WriteString(mac, ".")
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// PersonalRecord is a movement in which a workout beat the heaviest weight
+// an athlete had lifted before. Weight and PreviousWeight are in kilograms.
+type PersonalRecord struct {
+	MovementID     string  `json:"movementId"`
+	Weight         float64 `json:"weight"`
+	Reps           int32   `json:"reps"`
+	PreviousWeight float64 `json:"previousWeight"`
+}
+
+// PersonalRecords is the data of a WorkoutPersonalRecordEvent.
+type PersonalRecords struct {
+	WorkoutID   string           `json:"workoutId"`
+	AthleteID   string           `json:"athleteId"`
+	PerformedAt time.Time        `json:"performedAt"`
+	Records     []PersonalRecord `json:"records"`
+}
+
+// FindPersonalRecords compares a workout with the athlete's workouts
+// performed before it. Movements the athlete had never lifted before do not
+// count, so a first workout is not a wall of records. Planned workouts neither
+// set records nor count towards them.
+func FindPersonalRecords(w Workout, history []Workout) []PersonalRecord {
+	if w.Planned {
+		return nil
+	}
+	best := make(map[string]float64)
+	for _, h := range history {
+		if h.Name == w.Name || h.Planned || !h.PerformedAt.Before(w.PerformedAt) {
+			continue
+		}
+		for _, set := range h.Sets {
+			if set.Reps > 0 && set.Weight.Kilograms > best[set.MovementID] {
+				best[set.MovementID] = set.Weight.Kilograms
+			}
+		}
+	}
+	var (
+		records []PersonalRecord
+		index   = make(map[string]int)
+	)
+	for _, set := range w.Sets {
+		previous, ok := best[set.MovementID]
+		if !ok || set.Reps <= 0 || set.Weight.Kilograms <= previous {
+			continue
+		}
+		i, seen := index[set.MovementID]
+		switch {
+		case !seen:
+			index[set.MovementID] = len(records)
+			records = append(records, PersonalRecord{
+				MovementID:     set.MovementID,
+				Weight:         set.Weight.Kilograms,
+				Reps:           set.Reps,
+				PreviousWeight: previous,
+			})
+		case set.Weight.Kilograms > records[i].Weight || (set.Weight.Kilograms == records[i].Weight && set.Reps > records[i].Reps):
+			records[i].Weight, records[i].Reps = set.Weight.Kilograms, set.Reps
+		}
+	}
+	return records
+}
+
+// NewWebhookDispatcher returns a Broker that queues a delivery of every
+// event to each enabled webhook of the event's tenant that subscribes to its
+// type. Workout creations that set personal records also queue a
+// WorkoutPersonalRecordEvent. Events of the SystemTenantID are not
+// delivered, since no tenant registers webhooks there.
+func NewWebhookDispatcher(webhooks WebhookRepository, workouts WorkoutRepository) Broker {
+	return webhookDispatcher{webhooks: webhooks, workouts: workouts}
+}
+
+type webhookDispatcher struct {
+	webhooks WebhookRepository
+	workouts WorkoutRepository
+}
+
+// Publish implements Broker.
+func (d webhookDispatcher) Publish(ctx context.Context, e DomainEvent) error {
+	if e.TenantID == "" || e.TenantID == SystemTenantID {
+		return nil
+	}
+	webhooks, err := d.webhooks.ListWebhooks(ctx, e.TenantID)
+	if err != nil {
+		return errors.Wrap(err, "failed to list webhooks")
+	}
+	var subscribed []Webhook
+	for _, w := range webhooks {
+		if !w.Disabled {
+			subscribed = append(subscribed, w)
+		}
+	}
+	if len(subscribed) == 0 {
+		return nil
+	}
+	events := []DomainEvent{e}
+	if e.Type == WorkoutCreatedEvent && wanted(subscribed, WorkoutPersonalRecordEvent) {
+		pr, ok, err := d.personalRecordEvent(ctx, e)
+		if err != nil {
+			return err
+		}
+		if ok {
+			events = append(events, pr)
+		}
+	}
+	var (
+		deliveries []WebhookDelivery
+		now        = time.Now().UTC()
+	)
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode event")
+		}
+		for _, w := range subscribed {
+			if !w.Wants(event.Type) {
+				continue
+			}
+			deliveries = append(deliveries, WebhookDelivery{
+				Name:            uuid.New().String(),
+				TenantID:        w.TenantID,
+				WebhookID:       w.Name,
+				EventID:         event.ID,
+				EventType:       event.Type,
+				Payload:         payload,
+				State:           WebhookDeliveryPending,
+				NextAttemptTime: now,
+				CreateTime:      now,
+				UpdateTime:      now,
+			})
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return errors.Wrap(d.webhooks.CreateWebhookDeliveries(ctx, deliveries), "failed to queue webhook deliveries")
+}
+
+// personalRecordEvent derives the personal record event of a workout
+// creation, if it set any. Its ID is derived from the creation's, so that a
+// creation published again derives the same event.
+func (d webhookDispatcher) personalRecordEvent(ctx context.Context, e DomainEvent) (DomainEvent, bool, error) {
+	var w Workout
+	if err := json.Unmarshal(e.Data, &w); err != nil {
+		return DomainEvent{}, false, errors.Wrap(err, "failed to decode workout")
+	}
+	history, err := d.workouts.ListWorkouts(ctx, w.TenantID, w.AthleteID)
+	if err != nil {
+		return DomainEvent{}, false, errors.Wrap(err, "failed to list workouts")
+	}
+	records := FindPersonalRecords(w, history)
+	if len(records) == 0 {
+		return DomainEvent{}, false, nil
+	}
+	pr := newDomainEvent(WorkoutPersonalRecordEvent, WorkoutAggregate, w.Name, w.TenantID, PersonalRecords{
+		WorkoutID:   w.Name,
+		AthleteID:   w.AthleteID,
+		PerformedAt: w.PerformedAt,
+		Records:     records,
+	})
+	pr.ID = uuid.NewSHA1(uuid.NameSpaceOID, []byte(e.ID+"/"+string(WorkoutPersonalRecordEvent))).String()
+	pr.Time = e.Time
+	return pr, true, nil
+}
+
+func wanted(webhooks []Webhook, t DomainEventType) bool {
+	for _, w := range webhooks {
+		if w.Wants(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookRetryPolicy decides when failed deliveries are attempted again. The
+// wait doubles after each failure, from InitialBackoff up to MaxBackoff, and
+// a delivery is dead once it has failed MaxAttempts times in a row.
+type WebhookRetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultWebhookRetryPolicy gives a receiver about four hours to recover.
+var DefaultWebhookRetryPolicy = WebhookRetryPolicy{
+	MaxAttempts:    10,
+	InitialBackoff: 30 * time.Second,
+	MaxBackoff:     time.Hour,
+}
+
+// Backoff returns the wait after the given number of consecutive failures,
+// with up to a fifth added at random so that a receiver recovering from an
+// outage is not hit by every retry at once.
+func (p WebhookRetryPolicy) Backoff(failures int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < failures && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d > 0 {
+		d += time.Duration(rand.Int63n(int64(d)/5 + 1))
+	}
+	return d
+}
+
+// maxLoggedAttempts bounds the attempts a delivery's log keeps.
+const maxLoggedAttempts = 20
+
+// DeliverWebhooks attempts up to limit due deliveries, one after another,
+// and records the outcome of each. Receivers must answer with a 2xx status
+// for a delivery to succeed. It returns how many deliveries were attempted.
+// Deliveries to a webhook are not ordered, since each is retried on its own.
+// Deliveries whose webhook cannot be looked up, or was disabled since they
+// were listed, are skipped and left pending, and the first lookup error is
+// returned once the rest have been attempted.
+func DeliverWebhooks(ctx context.Context, repo WebhookRepository, client *http.Client, policy WebhookRetryPolicy, limit int) (int, error) {
+	due, err := repo.ListDueWebhookDeliveries(ctx, time.Now().UTC(), limit)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list due webhook deliveries")
+	}
+	var (
+		webhooks  = make(map[string]Webhook)
+		failed    = make(map[string]bool)
+		attempted int
+		lookupErr error
+	)
+	for _, d := range due {
+		if failed[d.WebhookID] {
+			continue
+		}
+		w, ok := webhooks[d.WebhookID]
+		if !ok {
+			if w, err = repo.GetWebhook(ctx, d.WebhookID); err != nil {
+				failed[d.WebhookID] = true
+				if lookupErr == nil && errors.Cause(err) != ErrNotFound {
+					lookupErr = errors.Wrapf(err, "failed to get webhook %s", d.WebhookID)
+				}
+				continue
+			}
+			webhooks[d.WebhookID] = w
+		}
+		if w.Disabled {
+			continue
+		}
+		attempted++
+		attempt := deliverWebhook(ctx, client, w, d)
+		d.Attempts = append(d.Attempts, attempt)
+		if len(d.Attempts) > maxLoggedAttempts {
+			d.Attempts = d.Attempts[len(d.Attempts)-maxLoggedAttempts:]
+		}
+		now := time.Now().UTC()
+		switch {
+		case attempt.Error == "":
+			d.State = WebhookDeliverySucceeded
+		case d.Failures+1 >= policy.MaxAttempts:
+			d.Failures++
+			d.State = WebhookDeliveryDead
+		default:
+			d.Failures++
+			d.NextAttemptTime = now.Add(policy.Backoff(d.Failures))
+		}
+		d.UpdateTime = now
+		if err := repo.UpdateWebhookDelivery(ctx, d); err != nil {
+			return attempted, errors.Wrap(err, "failed to record webhook delivery")
+		}
+	}
+	return attempted, lookupErr
+}
+
+// deliverWebhook posts a delivery's payload to its webhook, signed with the
+// webhook's current secret.
+func deliverWebhook(ctx context.Context, client *http.Client, w Webhook, d WebhookDelivery) WebhookAttempt {
+	begin := time.Now()
+	attempt := WebhookAttempt{Time: begin.UTC()}
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	timestamp := strconv.FormatInt(begin.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "workout-manager-webhooks")
+	req.Header.Set(WebhookIDHeader, d.EventID)
+	req.Header.Set(WebhookEventHeader, string(d.EventType))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(w.Secret, timestamp, d.Payload))
+	res, err := client.Do(req.WithContext(ctx))
+	attempt.Duration = time.Since(begin)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	// Draining a little of the body lets the connection be reused.
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(res.Body, 4096))
+	res.Body.Close()
+	attempt.StatusCode = res.StatusCode
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		attempt.Error = "receiver answered " + res.Status
+	}
+	return attempt
+}
+
+// RunWebhookDelivery calls DeliverWebhooks every interval until ctx is
+// cancelled, working through due deliveries batch by batch. Like the outbox
+// relay, only one should run per database.
+func RunWebhookDelivery(ctx context.Context, logger logging.IshiLogger, repo WebhookRepository, client *http.Client, policy WebhookRetryPolicy, batch int, interval time.Duration) {
+	logger = logger.WithFields("job", "webhook-delivery")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				n, err := DeliverWebhooks(ctx, repo, client, policy, batch)
+				if err != nil {
+					logger.Error("delivery failed", "attempted", n, "err", err)
+					break
+				}
+				if n > 0 {
+					logger.Debug("delivery complete", "attempted", n)
+				}
+				if n < batch {
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package service_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/inmem"
+	"workout-manager-service/pkg/service"
+)
+
+// receiver is a webhook receiver that records the requests it is sent and
+// answers them with status.
+type receiver struct {
+	mtx      sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	rc.mtx.Lock()
+	defer rc.mtx.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	w.WriteHeader(rc.status)
+}
+
+func (rc *receiver) calls() int {
+	rc.mtx.Lock()
+	defer rc.mtx.Unlock()
+	return len(rc.requests)
+}
+
+// newDeliveryStore returns a store holding a webhook posting to url with a
+// due delivery of one event.
+func newDeliveryStore(t *testing.T, ctx context.Context, url string, ids ...string) *inmem.Store {
+	t.Helper()
+	s := inmem.NewStore()
+	now := time.Now().UTC().Add(-time.Second)
+	for _, id := range ids {
+		w := service.Webhook{
+			Name:       id,
+			TenantID:   "t1",
+			URL:        url,
+			EventTypes: []service.DomainEventType{service.MovementCreatedEvent},
+			Secret:     "whsec_" + id,
+		}
+		if err := s.CreateWebhook(ctx, w); err != nil {
+			t.Fatal(err)
+		}
+		err := s.CreateWebhookDeliveries(ctx, []service.WebhookDelivery{{
+			Name:            "delivery-" + id,
+			TenantID:        "t1",
+			WebhookID:       id,
+			EventID:         "event-1",
+			EventType:       service.MovementCreatedEvent,
+			Payload:         []byte(`{"id":"event-1"}`),
+			State:           service.WebhookDeliveryPending,
+			NextAttemptTime: now,
+			CreateTime:      now,
+			UpdateTime:      now,
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestDeliverWebhooksSigns(t *testing.T) {
+	ctx := context.Background()
+	rc := &receiver{status: http.StatusNoContent}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+	s := newDeliveryStore(t, ctx, server.URL, "hook")
+
+	n, err := service.DeliverWebhooks(ctx, s, server.Client(), service.DefaultWebhookRetryPolicy, 10)
+	if err != nil || n != 1 {
+		t.Fatalf("DeliverWebhooks() = %d, %v, want 1, nil", n, err)
+	}
+	if rc.calls() != 1 {
+		t.Fatalf("receiver was called %d times, want 1", rc.calls())
+	}
+	r, body := rc.requests[0], rc.bodies[0]
+	if got := r.Header.Get(service.WebhookIDHeader); got != "event-1" {
+		t.Errorf("%s = %q, want event-1", service.WebhookIDHeader, got)
+	}
+	if got := r.Header.Get(service.WebhookEventHeader); got != string(service.MovementCreatedEvent) {
+		t.Errorf("%s = %q, want %s", service.WebhookEventHeader, got, service.MovementCreatedEvent)
+	}
+	timestamp := r.Header.Get(service.WebhookTimestampHeader)
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		t.Errorf("%s = %q, want a unix time", service.WebhookTimestampHeader, timestamp)
+	}
+	want := service.SignWebhook("whsec_hook", timestamp, body)
+	if got := r.Header.Get(service.WebhookSignatureHeader); got != want {
+		t.Errorf("%s = %q, want %q", service.WebhookSignatureHeader, got, want)
+	}
+	if service.SignWebhook("whsec_other", timestamp, body) == want {
+		t.Error("the signature does not depend on the secret")
+	}
+
+	d, err := s.GetWebhookDelivery(ctx, "delivery-hook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.State != service.WebhookDeliverySucceeded || len(d.Attempts) != 1 || d.Attempts[0].StatusCode != http.StatusNoContent {
+		t.Errorf("delivery = %+v, want one successful attempt", d)
+	}
+}
+
+func TestDeliverWebhooksRetries(t *testing.T) {
+	ctx := context.Background()
+	rc := &receiver{status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+	s := newDeliveryStore(t, ctx, server.URL, "hook")
+	policy := service.WebhookRetryPolicy{MaxAttempts: 3, InitialBackoff: time.Minute, MaxBackoff: time.Hour}
+
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		before := time.Now().UTC()
+		if n, err := service.DeliverWebhooks(ctx, s, server.Client(), policy, 10); err != nil || n != 1 {
+			t.Fatalf("attempt %d: DeliverWebhooks() = %d, %v, want 1, nil", attempt, n, err)
+		}
+		d, err := s.GetWebhookDelivery(ctx, "delivery-hook")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Failures != attempt || len(d.Attempts) != attempt || d.Attempts[attempt-1].StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("attempt %d: delivery = %+v", attempt, d)
+		}
+		if attempt == policy.MaxAttempts {
+			if d.State != service.WebhookDeliveryDead {
+				t.Fatalf("after %d failures the delivery is %s, want dead", attempt, d.State)
+			}
+			break
+		}
+		if d.State != service.WebhookDeliveryPending {
+			t.Fatalf("attempt %d: delivery is %s, want pending", attempt, d.State)
+		}
+		wait := d.NextAttemptTime.Sub(before)
+		backoff := policy.InitialBackoff << uint(attempt-1)
+		if wait < backoff || wait > backoff+backoff/5+time.Second {
+			t.Fatalf("attempt %d: next attempt in %s, want %s plus up to a fifth", attempt, wait, backoff)
+		}
+		if n, _ := service.DeliverWebhooks(ctx, s, server.Client(), policy, 10); n != 0 {
+			t.Fatalf("attempt %d: a delivery that is not due was attempted", attempt)
+		}
+		d.NextAttemptTime = before
+		if err := s.UpdateWebhookDelivery(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, _ := service.DeliverWebhooks(ctx, s, server.Client(), policy, 10); n != 0 || rc.calls() != policy.MaxAttempts {
+		t.Fatalf("a dead delivery was attempted again")
+	}
+}
+
+func TestWebhookRetryPolicyBackoff(t *testing.T) {
+	policy := service.WebhookRetryPolicy{MaxAttempts: 10, InitialBackoff: 30 * time.Second, MaxBackoff: time.Hour}
+	for _, tc := range []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{50, time.Hour},
+	} {
+		for i := 0; i < 20; i++ {
+			if got := policy.Backoff(tc.failures); got < tc.want || got > tc.want+tc.want/5 {
+				t.Fatalf("Backoff(%d) = %s, want %s plus up to a fifth", tc.failures, got, tc.want)
+			}
+		}
+	}
+}
+
+// racyStore disables a webhook right after its due deliveries are listed,
+// and fails to look up another.
+type racyStore struct {
+	*inmem.Store
+	disable, broken string
+}
+
+func (s racyStore) ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]service.WebhookDelivery, error) {
+	due, err := s.Store.ListDueWebhookDeliveries(ctx, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	w, err := s.Store.GetWebhook(ctx, s.disable)
+	if err != nil {
+		return nil, err
+	}
+	w.Disabled = true
+	return due, s.Store.UpdateWebhook(ctx, w)
+}
+
+func (s racyStore) GetWebhook(ctx context.Context, id string) (service.Webhook, error) {
+	if id == s.broken {
+		return service.Webhook{}, errors.New("connection reset")
+	}
+	return s.Store.GetWebhook(ctx, id)
+}
+
+func TestDeliverWebhooksSkips(t *testing.T) {
+	ctx := context.Background()
+	rc := &receiver{status: http.StatusOK}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+	s := racyStore{
+		Store:   newDeliveryStore(t, ctx, server.URL, "broken", "disabled", "healthy"),
+		disable: "disabled",
+		broken:  "broken",
+	}
+
+	n, err := service.DeliverWebhooks(ctx, s, server.Client(), service.DefaultWebhookRetryPolicy, 10)
+	if n != 1 || err == nil {
+		t.Fatalf("DeliverWebhooks() = %d, %v, want 1 and the lookup error", n, err)
+	}
+	if rc.calls() != 1 {
+		t.Fatalf("receiver was called %d times, want 1", rc.calls())
+	}
+	for id, want := range map[string]service.WebhookDeliveryState{
+		"broken":   service.WebhookDeliveryPending,
+		"disabled": service.WebhookDeliveryPending,
+		"healthy":  service.WebhookDeliverySucceeded,
+	} {
+		d, err := s.GetWebhookDelivery(ctx, "delivery-"+id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.State != want || (want == service.WebhookDeliveryPending && len(d.Attempts) != 0) {
+			t.Errorf("delivery of %s = %s with %d attempts, want %s", id, d.State, len(d.Attempts), want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"workout-manager-service/logging"
+)
+
+type webhookLoggingService struct {
+	logger  logging.IshiLogger
+	service WebhookService
+}
+
+// NewWebhookLoggingService takes an IshiLogger as a dependency and returns a
+// WebhookService.
+func NewWebhookLoggingService(logger logging.IshiLogger, s WebhookService) WebhookService {
+	return webhookLoggingService{
+		logger:  logger.WithFields("service", "webhook"),
+		service: s,
+	}
+}
+
+// Create provides informative logging when requests are made to the create
+// endpoint. The secret is never logged.
+func (ls webhookLoggingService) Create(ctx context.Context, w Webhook) (Webhook, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Create",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"url", w.URL,
+			"eventTypes", fmt.Sprintf("%v", w.EventTypes),
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Create(ctx, w)
+}
+
+// Get provides informative logging when requests are made to the get
+// endpoint.
+func (ls webhookLoggingService) Get(ctx context.Context, id string) (Webhook, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Get",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"id", id,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Get(ctx, id)
+}
+
+// List provides informative logging when requests are made to the list
+// endpoint.
+func (ls webhookLoggingService) List(ctx context.Context) ([]Webhook, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "List",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.List(ctx)
+}
+
+// Update provides informative logging when requests are made to the update
+// endpoint.
+func (ls webhookLoggingService) Update(ctx context.Context, w Webhook) (Webhook, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Update",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"id", w.Name,
+			"url", w.URL,
+			"eventTypes", fmt.Sprintf("%v", w.EventTypes),
+			"disabled", w.Disabled,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Update(ctx, w)
+}
+
+// Delete provides informative logging when requests are made to the delete
+// endpoint.
+func (ls webhookLoggingService) Delete(ctx context.Context, id string) error {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Delete",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"id", id,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Delete(ctx, id)
+}
+
+// RotateSecret provides informative logging when requests are made to the
+// rotate secret endpoint.
+func (ls webhookLoggingService) RotateSecret(ctx context.Context, id string) (Webhook, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "RotateSecret",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"id", id,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.RotateSecret(ctx, id)
+}
+
+// ListDeliveries provides informative logging when requests are made to the
+// list deliveries endpoint.
+func (ls webhookLoggingService) ListDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "ListDeliveries",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"filter", fmt.Sprintf("%+v", filter),
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.ListDeliveries(ctx, filter)
+}
+
+// Redeliver provides informative logging when requests are made to the
+// redeliver endpoint.
+func (ls webhookLoggingService) Redeliver(ctx context.Context, deliveryID string) (WebhookDelivery, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Redeliver",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"deliveryId", deliveryID,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Redeliver(ctx, deliveryID)
+}
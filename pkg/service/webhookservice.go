@@ -0,0 +1,378 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/logging"
+)
+
+// WorkoutPersonalRecordEvent is delivered to webhooks when a new workout
+// beats the heaviest weight an athlete has lifted in a movement before. It is
+// derived from the workout.created event rather than kept in the outbox, and
+// carries a PersonalRecords.
+const WorkoutPersonalRecordEvent DomainEventType = "workout.personal_record"
+
+// WebhookEventTypes are the event types a webhook may subscribe to.
+var WebhookEventTypes = []DomainEventType{
+	MovementCreatedEvent,
+	MovementUpdatedEvent,
+	MovementDeletedEvent,
+	MovementRestoredEvent,
+	MovementMergedEvent,
+	MovementPurgedEvent,
+	WorkoutCreatedEvent,
+	WorkoutRatedEvent,
+	WorkoutDeletedEvent,
+	WorkoutPersonalRecordEvent,
+	WorkloadAlertEvent,
+}
+
+// Webhook is a URL a tenant has registered to be notified of events, such as
+// a Slack or Zapier integration. Every delivery is signed with the Secret,
+// which is only returned when the webhook is created or its secret rotated.
+// Disabled webhooks keep their deliveries pending until they are enabled.
+type Webhook struct {
+	Name        string            `json:"id"`
+	TenantID    string            `json:"tenantId"`
+	URL         string            `json:"url"`
+	EventTypes  []DomainEventType `json:"eventTypes"`
+	Description string            `json:"description"`
+	Secret      string            `json:"secret,omitempty"`
+	Disabled    bool              `json:"disabled"`
+	CreateTime  time.Time         `json:"createTime"`
+	UpdateTime  time.Time         `json:"updateTime"`
+}
+
+// Wants reports whether the webhook is subscribed to an event type.
+func (w Webhook) Wants(t DomainEventType) bool {
+	for _, want := range w.EventTypes {
+		if want == t {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryState describes how far the delivery of an event to a
+// webhook has got.
+type WebhookDeliveryState string
+
+// The states a WebhookDelivery moves through. Deliveries are pending until
+// the receiver accepts them or they fail too many times in a row, after which
+// they are dead: kept in the dead-letter list until they are redelivered.
+const (
+	WebhookDeliveryPending   WebhookDeliveryState = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryState = "succeeded"
+	WebhookDeliveryDead      WebhookDeliveryState = "dead"
+)
+
+// WebhookDelivery is the delivery of one event to one webhook. Payload is
+// the JSON encoding of the DomainEvent that is posted. Failures counts the
+// failed attempts since the delivery was created or last redelivered, and
+// Attempts logs the most recent attempts, oldest first.
+type WebhookDelivery struct {
+	Name            string               `json:"id"`
+	TenantID        string               `json:"tenantId"`
+	WebhookID       string               `json:"webhookId"`
+	EventID         string               `json:"eventId"`
+	EventType       DomainEventType      `json:"eventType"`
+	Payload         json.RawMessage      `json:"payload"`
+	State           WebhookDeliveryState `json:"state"`
+	Failures        int                  `json:"failures"`
+	Attempts        []WebhookAttempt     `json:"attempts"`
+	NextAttemptTime time.Time            `json:"nextAttemptTime"`
+	CreateTime      time.Time            `json:"createTime"`
+	UpdateTime      time.Time            `json:"updateTime"`
+}
+
+// WebhookAttempt records one attempt to deliver an event. StatusCode is zero
+// when no response was received, and Error explains any failure.
+type WebhookAttempt struct {
+	Time       time.Time     `json:"time"`
+	StatusCode int           `json:"statusCode"`
+	Error      string        `json:"error"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// WebhookDeliveryFilter narrows the deliveries returned by a listing. Empty
+// fields match every delivery.
+type WebhookDeliveryFilter struct {
+	WebhookID string               `json:"webhookId"`
+	State     WebhookDeliveryState `json:"state"`
+	Limit     int                  `json:"limit"`
+}
+
+// WebhookRepository persists webhooks and their deliveries. Deleting a
+// webhook deletes its deliveries. CreateWebhookDeliveries ignores deliveries
+// of an event a webhook already has one for, so that events the outbox relay
+// publishes again are not delivered twice. Listings of deliveries are ordered
+// newest first, except ListDueWebhookDeliveries, which returns the pending
+// deliveries of enabled webhooks whose next attempt is due, oldest first.
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, w Webhook) error
+	GetWebhook(ctx context.Context, id string) (Webhook, error)
+	ListWebhooks(ctx context.Context, tenantID string) ([]Webhook, error)
+	UpdateWebhook(ctx context.Context, w Webhook) error
+	DeleteWebhook(ctx context.Context, id string) error
+	CreateWebhookDeliveries(ctx context.Context, ds []WebhookDelivery) error
+	GetWebhookDelivery(ctx context.Context, id string) (WebhookDelivery, error)
+	ListWebhookDeliveries(ctx context.Context, tenantID string, filter WebhookDeliveryFilter) ([]WebhookDelivery, error)
+	ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, d WebhookDelivery) error
+}
+
+// WebhookService describes a service that manages the webhooks of the
+// caller's tenant and the log of their deliveries.
+type WebhookService interface {
+	Create(ctx context.Context, w Webhook) (Webhook, error)
+	Get(ctx context.Context, id string) (Webhook, error)
+	List(ctx context.Context) ([]Webhook, error)
+	Update(ctx context.Context, w Webhook) (Webhook, error)
+	Delete(ctx context.Context, id string) error
+	RotateSecret(ctx context.Context, id string) (Webhook, error)
+	ListDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error)
+	Redeliver(ctx context.Context, deliveryID string) (WebhookDelivery, error)
+}
+
+// NewWebhookService returns a basic WebhookService with middleware wired in.
+func NewWebhookService(logger logging.IshiLogger, repo WebhookRepository, audit AuditRepository) WebhookService {
+	var svc WebhookService
+	{
+		svc = NewBasicWebhookService(repo)
+		svc = NewWebhookAuditService(logger, audit, svc)
+		svc = NewWebhookLoggingService(logger, svc)
+	}
+	return svc
+}
+
+// NewBasicWebhookService returns an implementation of WebhookService backed
+// by the given repository.
+func NewBasicWebhookService(repo WebhookRepository) WebhookService {
+	return basicWebhookService{repo: repo}
+}
+
+type basicWebhookService struct {
+	repo WebhookRepository
+}
+
+// The bounds on the number of deliveries a listing returns.
+const (
+	defaultWebhookDeliveryLimit = 100
+	maxWebhookDeliveryLimit     = 1000
+)
+
+// Create registers a webhook for the caller's tenant and generates its
+// signing secret.
+func (s basicWebhookService) Create(ctx context.Context, w Webhook) (Webhook, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return Webhook{}, err
+	}
+	if err := validateWebhook(ctx, w); err != nil {
+		return Webhook{}, err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return Webhook{}, err
+	}
+	now := time.Now().UTC()
+	w = Webhook{
+		Name:        uuid.New().String(),
+		TenantID:    p.TenantID,
+		URL:         w.URL,
+		EventTypes:  w.EventTypes,
+		Description: w.Description,
+		Secret:      secret,
+		Disabled:    w.Disabled,
+		CreateTime:  now,
+		UpdateTime:  now,
+	}
+	if err := s.repo.CreateWebhook(ctx, w); err != nil {
+		return Webhook{}, err
+	}
+	return w, nil
+}
+
+// Get retrieves one of the tenant's webhooks by its UUID, without its secret.
+func (s basicWebhookService) Get(ctx context.Context, id string) (Webhook, error) {
+	w, err := s.get(ctx, id)
+	w.Secret = ""
+	return w, err
+}
+
+// List retrieves the tenant's webhooks, without their secrets.
+func (s basicWebhookService) List(ctx context.Context) ([]Webhook, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	webhooks, err := s.repo.ListWebhooks(ctx, p.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, nil
+}
+
+// Update replaces a webhook's URL, event types, description and whether it
+// is disabled.
+func (s basicWebhookService) Update(ctx context.Context, w Webhook) (Webhook, error) {
+	current, err := s.get(ctx, w.Name)
+	if err != nil {
+		return Webhook{}, err
+	}
+	if err := validateWebhook(ctx, w); err != nil {
+		return Webhook{}, err
+	}
+	current.URL = w.URL
+	current.EventTypes = w.EventTypes
+	current.Description = w.Description
+	current.Disabled = w.Disabled
+	current.UpdateTime = time.Now().UTC()
+	if err := s.repo.UpdateWebhook(ctx, current); err != nil {
+		return Webhook{}, err
+	}
+	current.Secret = ""
+	return current, nil
+}
+
+// Delete removes one of the tenant's webhooks along with its deliveries.
+func (s basicWebhookService) Delete(ctx context.Context, id string) error {
+	if _, err := s.get(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteWebhook(ctx, id)
+}
+
+// RotateSecret replaces a webhook's signing secret and returns the webhook
+// with the new one. Deliveries attempted from then on are signed with it.
+func (s basicWebhookService) RotateSecret(ctx context.Context, id string) (Webhook, error) {
+	w, err := s.get(ctx, id)
+	if err != nil {
+		return Webhook{}, err
+	}
+	if w.Secret, err = newWebhookSecret(); err != nil {
+		return Webhook{}, err
+	}
+	w.UpdateTime = time.Now().UTC()
+	if err := s.repo.UpdateWebhook(ctx, w); err != nil {
+		return Webhook{}, err
+	}
+	return w, nil
+}
+
+// ListDeliveries retrieves the delivery log of the tenant's webhooks, newest
+// first. Filtering by the dead state lists the dead letters.
+func (s basicWebhookService) ListDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch filter.State {
+	case "", WebhookDeliveryPending, WebhookDeliverySucceeded, WebhookDeliveryDead:
+	default:
+		return nil, errors.Wrapf(ErrInvalidArgument, "unknown delivery state %q", filter.State)
+	}
+	if filter.Limit <= 0 || filter.Limit > maxWebhookDeliveryLimit {
+		filter.Limit = defaultWebhookDeliveryLimit
+	}
+	return s.repo.ListWebhookDeliveries(ctx, p.TenantID, filter)
+}
+
+// Redeliver schedules a delivery to be attempted again right away, with its
+// failures reset. Deliveries that are still pending are left alone.
+func (s basicWebhookService) Redeliver(ctx context.Context, deliveryID string) (WebhookDelivery, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return WebhookDelivery{}, err
+	}
+	d, err := s.repo.GetWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		return WebhookDelivery{}, err
+	}
+	if d.TenantID != p.TenantID {
+		return WebhookDelivery{}, ErrNotFound
+	}
+	if d.State == WebhookDeliveryPending {
+		return WebhookDelivery{}, errors.Wrap(ErrInvalidArgument, "delivery is still pending")
+	}
+	now := time.Now().UTC()
+	d.State = WebhookDeliveryPending
+	d.Failures = 0
+	d.NextAttemptTime = now
+	d.UpdateTime = now
+	if err := s.repo.UpdateWebhookDelivery(ctx, d); err != nil {
+		return WebhookDelivery{}, err
+	}
+	return d, nil
+}
+
+// get retrieves a webhook of the caller's tenant, secret included.
+func (s basicWebhookService) get(ctx context.Context, id string) (Webhook, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return Webhook{}, err
+	}
+	w, err := s.repo.GetWebhook(ctx, id)
+	if err != nil {
+		return Webhook{}, err
+	}
+	if w.TenantID != p.TenantID {
+		return Webhook{}, ErrNotFound
+	}
+	return w, nil
+}
+
+// validateWebhook checks a webhook being registered or updated, including
+// that its URL does not point at a forbidden address.
+func validateWebhook(ctx context.Context, w Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Hostname() == "" {
+		return errors.Wrapf(ErrInvalidArgument, "invalid webhook URL %q; expected an http or https URL", w.URL)
+	}
+	if err := checkWebhookURL(ctx, u); err != nil {
+		return err
+	}
+	if len(w.EventTypes) == 0 {
+		return errors.Wrap(ErrInvalidArgument, "a webhook must subscribe to at least one event type")
+	}
+	seen := make(map[DomainEventType]bool)
+	for _, t := range w.EventTypes {
+		if !knownWebhookEventType(t) {
+			return errors.Wrapf(ErrInvalidArgument, "unknown event type %q", t)
+		}
+		if seen[t] {
+			return errors.Wrapf(ErrInvalidArgument, "event type %q is listed twice", t)
+		}
+		seen[t] = true
+	}
+	return nil
+}
+
+func knownWebhookEventType(t DomainEventType) bool {
+	for _, known := range WebhookEventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// newWebhookSecret returns a random secret for signing deliveries.
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate webhook secret")
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,395 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/logging"
+)
+
+// SessionLoad is the internal load of one workout by the session-RPE method:
+// how hard the athlete rated the session times how many minutes it lasted.
+// Unrated sessions fall back to the mean RPE of their sets and to the total
+// duration of their conditioning; sessions missing either carry no load.
+type SessionLoad struct {
+	WorkoutID   string        `json:"workoutId"`
+	PerformedAt time.Time     `json:"performedAt"`
+	RPE         float64       `json:"rpe"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// Load returns the session's load in arbitrary units.
+func (l SessionLoad) Load() float64 {
+	return l.RPE * l.Duration.Minutes()
+}
+
+// SessionLoadOf returns the SessionLoad of a workout.
+func SessionLoadOf(w Workout) SessionLoad {
+	l := SessionLoad{WorkoutID: w.Name, PerformedAt: w.PerformedAt, RPE: w.SessionRPE, Duration: w.Duration}
+	if l.RPE == 0 {
+		var sum float64
+		var n int
+		for _, set := range w.Sets {
+			if set.RPE > 0 {
+				sum += set.RPE
+				n++
+			}
+		}
+		if n > 0 {
+			l.RPE = sum / float64(n)
+		}
+	}
+	if l.Duration == 0 {
+		for _, c := range w.Conditioning {
+			l.Duration += c.Duration
+		}
+	}
+	return l
+}
+
+// The windows workload is compared over, in days.
+const (
+	acuteWindow   = 7
+	chronicWindow = 28
+)
+
+// WorkloadDay is an athlete's workload on one day of the tenant's calendar,
+// which starts at Date. Load is the sum of the day's session loads.
+//
+// AcuteLoad and ChronicLoad are the mean daily loads of the last 7 and 28
+// days, and ACWR is their ratio. The EWMA fields are exponentially weighted
+// moving averages over the same windows, which weigh recent days more and do
+// not drop a hard session all at once. Both ratios are zero until the athlete
+// has 28 days of history and a chronic load, since a ratio against a partial
+// chronic window overstates every spike.
+//
+// Monotony is the mean daily load of the last 7 days over its standard
+// deviation, zero while it is undefined, and Strain is the 7 day load times
+// monotony.
+type WorkloadDay struct {
+	Date        time.Time `json:"date"`
+	Load        float64   `json:"load"`
+	AcuteLoad   float64   `json:"acuteLoad"`
+	ChronicLoad float64   `json:"chronicLoad"`
+	ACWR        float64   `json:"acwr"`
+	AcuteEWMA   float64   `json:"acuteEwma"`
+	ChronicEWMA float64   `json:"chronicEwma"`
+	EWMAACWR    float64   `json:"ewmaAcwr"`
+	Monotony    float64   `json:"monotony"`
+	Strain      float64   `json:"strain"`
+}
+
+// ComputeWorkload returns an athlete's workload on every day from the one
+// start falls on until end, given the loads of every session they performed
+// before end. The averages are carried from the athlete's first session, so
+// that the days around start are not skewed by a cold start.
+func ComputeWorkload(sessions []SessionLoad, loc *time.Location, start time.Time, end time.Time) []WorkloadDay {
+	daily := make(map[time.Time]float64)
+	first := BucketDay.StartOf(start, loc, time.Sunday)
+	from := first
+	for _, s := range sessions {
+		if !s.PerformedAt.Before(end) {
+			continue
+		}
+		day := BucketDay.StartOf(s.PerformedAt, loc, time.Sunday)
+		daily[day] += s.Load()
+		if day.Before(from) {
+			from = day
+		}
+	}
+
+	var (
+		days        []WorkloadDay
+		window      []float64
+		acute       float64
+		chronic     float64
+		acuteRate   = 2.0 / (acuteWindow + 1)
+		chronicRate = 2.0 / (chronicWindow + 1)
+	)
+	for day, n := from, 0; day.Before(end); day, n = day.AddDate(0, 0, 1), n+1 {
+		load := daily[day]
+		window = append(window, load)
+		if len(window) > chronicWindow {
+			window = window[1:]
+		}
+		if n == 0 {
+			acute, chronic = load, load
+		} else {
+			acute = load*acuteRate + acute*(1-acuteRate)
+			chronic = load*chronicRate + chronic*(1-chronicRate)
+		}
+		if day.Before(first) {
+			continue
+		}
+
+		d := WorkloadDay{Date: day, Load: load, AcuteEWMA: acute, ChronicEWMA: chronic}
+		week := window
+		if len(week) > acuteWindow {
+			week = week[len(week)-acuteWindow:]
+		}
+		weekLoad := sum(week)
+		d.AcuteLoad = weekLoad / acuteWindow
+		d.ChronicLoad = sum(window) / chronicWindow
+		if n+1 >= chronicWindow {
+			if d.ChronicLoad > 0 {
+				d.ACWR = d.AcuteLoad / d.ChronicLoad
+			}
+			if chronic > 0 {
+				d.EWMAACWR = acute / chronic
+			}
+		}
+		if len(week) == acuteWindow {
+			var variance float64
+			for _, l := range week {
+				variance += (l - d.AcuteLoad) * (l - d.AcuteLoad)
+			}
+			if sd := math.Sqrt(variance / acuteWindow); sd > 0 {
+				d.Monotony = d.AcuteLoad / sd
+				d.Strain = weekLoad * d.Monotony
+			}
+		}
+		days = append(days, d)
+	}
+	return days
+}
+
+func sum(loads []float64) float64 {
+	var total float64
+	for _, l := range loads {
+		total += l
+	}
+	return total
+}
+
+// WorkloadThresholds are the limits beyond which a tenant wants to hear
+// about an athlete's workload. The ACWR limits apply to both ratios. A zero
+// threshold is not checked.
+type WorkloadThresholds struct {
+	TenantID    string    `json:"tenantId"`
+	MaxACWR     float64   `json:"maxAcwr"`
+	MinACWR     float64   `json:"minAcwr"`
+	MaxMonotony float64   `json:"maxMonotony"`
+	MaxStrain   float64   `json:"maxStrain"`
+	UpdateTime  time.Time `json:"updateTime"`
+}
+
+// DefaultWorkloadThresholds apply to tenants that have not set their own.
+// An ACWR above 1.5 and a monotony above 2 are the limits most commonly
+// associated with a raised risk of injury.
+var DefaultWorkloadThresholds = WorkloadThresholds{
+	MaxACWR:     1.5,
+	MaxMonotony: 2,
+}
+
+// WorkloadMetric names the measure a WorkloadAlert is about.
+type WorkloadMetric string
+
+// The metrics workload alerts are raised for.
+const (
+	MetricACWR     WorkloadMetric = "acwr"
+	MetricEWMAACWR WorkloadMetric = "ewma_acwr"
+	MetricMonotony WorkloadMetric = "monotony"
+	MetricStrain   WorkloadMetric = "strain"
+)
+
+// WorkloadAlertEvent is raised when a session takes one of an athlete's
+// workload metrics past a threshold. It carries a WorkloadAlert.
+const WorkloadAlertEvent DomainEventType = "athlete.workload_alert"
+
+// AthleteAggregate is the aggregate of events about an athlete as a whole.
+const AthleteAggregate = "athlete"
+
+// WorkloadAlert is the data of a WorkloadAlertEvent.
+type WorkloadAlert struct {
+	TenantID  string         `json:"tenantId"`
+	AthleteID string         `json:"athleteId"`
+	WorkoutID string         `json:"workoutId"`
+	Metric    WorkloadMetric `json:"metric"`
+	Value     float64        `json:"value"`
+	Threshold float64        `json:"threshold"`
+	Day       WorkloadDay    `json:"day"`
+}
+
+// Breaches returns the thresholds a day's workload is past, each as an alert
+// with only its metric, value and threshold set.
+func (t WorkloadThresholds) Breaches(d WorkloadDay) []WorkloadAlert {
+	var alerts []WorkloadAlert
+	check := func(metric WorkloadMetric, value float64, threshold float64, over bool) {
+		if threshold == 0 || value == 0 {
+			return
+		}
+		if over && value > threshold || !over && value < threshold {
+			alerts = append(alerts, WorkloadAlert{Metric: metric, Value: value, Threshold: threshold})
+		}
+	}
+	check(MetricACWR, d.ACWR, t.MaxACWR, true)
+	check(MetricACWR, d.ACWR, t.MinACWR, false)
+	check(MetricEWMAACWR, d.EWMAACWR, t.MaxACWR, true)
+	check(MetricEWMAACWR, d.EWMAACWR, t.MinACWR, false)
+	check(MetricMonotony, d.Monotony, t.MaxMonotony, true)
+	check(MetricStrain, d.Strain, t.MaxStrain, true)
+	return alerts
+}
+
+// WorkloadMonitor raises a WorkloadAlertEvent when a session takes an
+// athlete past one of their tenant's workload thresholds. A metric that was
+// already past its threshold the day before is not raised again, and the
+// event of each athlete, day and metric has a fixed ID so that consumers see
+// it only once however many sessions that day keep it there.
+type WorkloadMonitor struct {
+	logger    logging.IshiLogger
+	analytics AnalyticsRepository
+	tenants   TenantRepository
+	outbox    OutboxRepository
+}
+
+// NewWorkloadMonitor returns a WorkloadMonitor that reads sessions and
+// thresholds from analytics and adds its events to outbox.
+func NewWorkloadMonitor(logger logging.IshiLogger, analytics AnalyticsRepository, tenants TenantRepository, outbox OutboxRepository) WorkloadMonitor {
+	return WorkloadMonitor{
+		logger:    logger.WithFields("monitor", "workload"),
+		analytics: analytics,
+		tenants:   tenants,
+		outbox:    outbox,
+	}
+}
+
+// Check raises the alerts the workload of w's day calls for.
+func (m WorkloadMonitor) Check(ctx context.Context, w Workout) error {
+	thresholds, err := workloadThresholds(ctx, m.analytics, w.TenantID)
+	if err != nil {
+		return err
+	}
+	t, err := m.tenants.GetTenant(ctx, w.TenantID)
+	if err != nil {
+		return errors.Wrap(err, "failed to look up tenant")
+	}
+	loc, err := time.LoadLocation(timeZone(t))
+	if err != nil {
+		return errors.Wrapf(err, "failed to load time zone %q", timeZone(t))
+	}
+	day := BucketDay.StartOf(w.PerformedAt, loc, time.Sunday)
+	end := day.AddDate(0, 0, 1)
+	sessions, err := m.analytics.ListSessionLoads(ctx, w.TenantID, w.AthleteID, end)
+	if err != nil {
+		return errors.Wrap(err, "failed to list session loads")
+	}
+	days := ComputeWorkload(sessions, loc, day.AddDate(0, 0, -1), end)
+	if len(days) != 2 {
+		return nil
+	}
+	already := make(map[WorkloadMetric]bool)
+	for _, a := range thresholds.Breaches(days[0]) {
+		already[a.Metric] = true
+	}
+	var events []DomainEvent
+	for _, a := range thresholds.Breaches(days[1]) {
+		if already[a.Metric] {
+			continue
+		}
+		already[a.Metric] = true
+		a.TenantID = w.TenantID
+		a.AthleteID = w.AthleteID
+		a.WorkoutID = w.Name
+		a.Day = days[1]
+		e := newDomainEvent(WorkloadAlertEvent, AthleteAggregate, w.AthleteID, w.TenantID, a)
+		e.ID = uuid.NewSHA1(uuid.NameSpaceOID, []byte(w.AthleteID+"/"+day.Format("2006-01-02")+"/"+string(a.Metric))).String()
+		events = append(events, e)
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	m.logger.Info("workload alert", "athleteID", w.AthleteID, "day", day.Format("2006-01-02"), "alerts", len(events))
+	return errors.Wrap(m.outbox.AddOutboxEvents(ctx, events), "failed to add workload alerts to the outbox")
+}
+
+// workloadThresholds returns the thresholds of a tenant, or the defaults when
+// it has not set any.
+func workloadThresholds(ctx context.Context, repo AnalyticsRepository, tenantID string) (WorkloadThresholds, error) {
+	t, err := repo.GetWorkloadThresholds(ctx, tenantID)
+	if errors.Cause(err) == ErrNotFound {
+		t = DefaultWorkloadThresholds
+		t.TenantID = tenantID
+		return t, nil
+	}
+	if err != nil {
+		return WorkloadThresholds{}, errors.Wrap(err, "failed to look up workload thresholds")
+	}
+	return t, nil
+}
+
+type workloadMonitoringService struct {
+	monitor WorkloadMonitor
+	service WorkoutService
+}
+
+// NewWorkloadMonitoringService returns a WorkoutService that checks an
+// athlete's workload after every workout that is recorded or rated. Failed
+// checks are logged rather than returned, since the workout was recorded.
+// Imported history is not checked, as it alerts to nothing that can still be
+// prevented.
+func NewWorkloadMonitoringService(monitor WorkloadMonitor, s WorkoutService) WorkoutService {
+	return workloadMonitoringService{monitor: monitor, service: s}
+}
+
+// Create checks the workload of the day of the new workout.
+func (ms workloadMonitoringService) Create(ctx context.Context, athleteID string, title string, performedAt time.Time, sets []WorkoutSet, blocks []WorkoutBlock) (Workout, error) {
+	w, err := ms.service.Create(ctx, athleteID, title, performedAt, sets, blocks)
+	if err == nil {
+		ms.check(ctx, w)
+	}
+	return w, err
+}
+
+// Get is not monitored.
+func (ms workloadMonitoringService) Get(ctx context.Context, athleteID string, id string) (Workout, error) {
+	return ms.service.Get(ctx, athleteID, id)
+}
+
+// List is not monitored.
+func (ms workloadMonitoringService) List(ctx context.Context, athleteID string) ([]Workout, error) {
+	return ms.service.List(ctx, athleteID)
+}
+
+// Delete is not monitored; removing load cannot raise an alert.
+func (ms workloadMonitoringService) Delete(ctx context.Context, athleteID string, id string) error {
+	return ms.service.Delete(ctx, athleteID, id)
+}
+
+// ImportHistory is not monitored.
+func (ms workloadMonitoringService) ImportHistory(ctx context.Context, athleteID string, imp HistoryImport) (HistoryReport, error) {
+	return ms.service.ImportHistory(ctx, athleteID, imp)
+}
+
+// UploadActivity checks the workload of the day of the new workout.
+func (ms workloadMonitoringService) UploadActivity(ctx context.Context, athleteID string, upload ActivityUpload) (Workout, error) {
+	w, err := ms.service.UploadActivity(ctx, athleteID, upload)
+	if err == nil {
+		ms.check(ctx, w)
+	}
+	return w, err
+}
+
+// RateSession checks the workload of the day of the rated workout.
+func (ms workloadMonitoringService) RateSession(ctx context.Context, athleteID string, id string, rpe float64, duration time.Duration) (Workout, error) {
+	w, err := ms.service.RateSession(ctx, athleteID, id, rpe, duration)
+	if err == nil {
+		ms.check(ctx, w)
+	}
+	return w, err
+}
+
+func (ms workloadMonitoringService) check(ctx context.Context, w Workout) {
+	if err := ms.monitor.Check(ctx, w); err != nil {
+		ms.monitor.logger.Error("workload check failed", "athleteID", w.AthleteID, "workoutID", w.Name, "err", err)
+	}
+}
+
+// CloneWorkout is not monitored, as planned workouts carry no load.
+func (ms workloadMonitoringService) CloneWorkout(ctx context.Context, athleteID string, c WorkoutClone) (Workout, error) {
+	return ms.service.CloneWorkout(ctx, athleteID, c)
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSessionLoadOf(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		w    Workout
+		want float64
+	}{
+		{"rated", Workout{SessionRPE: 7, Duration: time.Hour, Sets: []WorkoutSet{{RPE: 9}}}, 420},
+		{"mean set RPE", Workout{Duration: 30 * time.Minute, Sets: []WorkoutSet{{RPE: 6}, {RPE: 8}, {}}}, 210},
+		{"conditioning duration", Workout{SessionRPE: 5, Conditioning: []Conditioning{{Duration: 20 * time.Minute}, {Duration: 10 * time.Minute}}}, 150},
+		{"no RPE", Workout{Duration: time.Hour, Sets: []WorkoutSet{{Reps: 5}}}, 0},
+		{"no duration", Workout{SessionRPE: 8}, 0},
+	} {
+		if got := SessionLoadOf(tc.w).Load(); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("%s: load = %g, want %g", tc.name, got, tc.want)
+		}
+	}
+}
+
+var workloadStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// dailyLoads returns a session of each load, one a day from workloadStart,
+// skipping zero loads.
+func dailyLoads(loads ...float64) []SessionLoad {
+	var sessions []SessionLoad
+	for i, l := range loads {
+		if l == 0 {
+			continue
+		}
+		sessions = append(sessions, SessionLoad{
+			PerformedAt: workloadStart.AddDate(0, 0, i).Add(18 * time.Hour),
+			RPE:         1,
+			Duration:    time.Duration(l) * time.Minute,
+		})
+	}
+	return sessions
+}
+
+func repeatLoad(load float64, days int) []float64 {
+	loads := make([]float64, days)
+	for i := range loads {
+		loads[i] = load
+	}
+	return loads
+}
+
+func TestComputeWorkload(t *testing.T) {
+	const eps = 1e-6
+	acuteRate, chronicRate := 2.0/8, 2.0/29
+	alternating := []float64{100, 0, 100, 0, 100, 0, 100}
+	mean := 400.0 / 7
+	sd := math.Sqrt((4*(100-mean)*(100-mean) + 3*mean*mean) / 7)
+
+	for _, tc := range []struct {
+		name  string
+		loads []float64
+		want  WorkloadDay
+	}{
+		{
+			name:  "steady",
+			loads: repeatLoad(100, 35),
+			want:  WorkloadDay{Load: 100, AcuteLoad: 100, ChronicLoad: 100, ACWR: 1, AcuteEWMA: 100, ChronicEWMA: 100, EWMAACWR: 1},
+		},
+		{
+			name:  "spike",
+			loads: append(repeatLoad(100, 28), 800),
+			want: WorkloadDay{
+				Load:        800,
+				AcuteLoad:   1400.0 / 7,
+				ChronicLoad: 3500.0 / 28,
+				ACWR:        (1400.0 / 7) / (3500.0 / 28),
+				AcuteEWMA:   800*acuteRate + 100*(1-acuteRate),
+				ChronicEWMA: 800*chronicRate + 100*(1-chronicRate),
+				EWMAACWR:    (800*acuteRate + 100*(1-acuteRate)) / (800*chronicRate + 100*(1-chronicRate)),
+				Monotony:    (1400.0 / 7) / math.Sqrt((6*100*100+600*600)/7.0),
+				Strain:      1400 * (1400.0 / 7) / math.Sqrt((6*100*100+600*600)/7.0),
+			},
+		},
+		{
+			name:  "too little history for a ratio",
+			loads: repeatLoad(100, 27),
+			want:  WorkloadDay{Load: 100, AcuteLoad: 100, ChronicLoad: 2700.0 / 28, AcuteEWMA: 100, ChronicEWMA: 100},
+		},
+		{
+			name:  "monotony of alternating days",
+			loads: alternating,
+			want: WorkloadDay{
+				Load:        100,
+				AcuteLoad:   mean,
+				ChronicLoad: 400.0 / 28,
+				AcuteEWMA:   ewma(alternating, acuteRate),
+				ChronicEWMA: ewma(alternating, chronicRate),
+				Monotony:    mean / sd,
+				Strain:      400 * mean / sd,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			end := workloadStart.AddDate(0, 0, len(tc.loads))
+			days := ComputeWorkload(dailyLoads(tc.loads...), time.UTC, workloadStart, end)
+			if len(days) != len(tc.loads) {
+				t.Fatalf("got %d days, want %d", len(days), len(tc.loads))
+			}
+			got := days[len(days)-1]
+			for _, f := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"load", got.Load, tc.want.Load},
+				{"acute load", got.AcuteLoad, tc.want.AcuteLoad},
+				{"chronic load", got.ChronicLoad, tc.want.ChronicLoad},
+				{"ACWR", got.ACWR, tc.want.ACWR},
+				{"acute EWMA", got.AcuteEWMA, tc.want.AcuteEWMA},
+				{"chronic EWMA", got.ChronicEWMA, tc.want.ChronicEWMA},
+				{"EWMA ACWR", got.EWMAACWR, tc.want.EWMAACWR},
+				{"monotony", got.Monotony, tc.want.Monotony},
+				{"strain", got.Strain, tc.want.Strain},
+			} {
+				if math.Abs(f.got-f.want) > eps {
+					t.Errorf("%s = %g, want %g", f.name, f.got, f.want)
+				}
+			}
+		})
+	}
+}
+
+// ewma returns the exponentially weighted moving average of loads, seeded
+// with the first.
+func ewma(loads []float64, rate float64) float64 {
+	avg := loads[0]
+	for _, l := range loads[1:] {
+		avg = l*rate + avg*(1-rate)
+	}
+	return avg
+}
+
+func TestComputeWorkloadWindow(t *testing.T) {
+	loads := repeatLoad(100, 40)
+	sessions := append(dailyLoads(loads...), SessionLoad{PerformedAt: workloadStart.AddDate(0, 0, 40), RPE: 10, Duration: time.Hour})
+	start, end := workloadStart.AddDate(0, 0, 30), workloadStart.AddDate(0, 0, 40)
+
+	days := ComputeWorkload(sessions, time.UTC, start, end)
+	if len(days) != 10 || !days[0].Date.Equal(start) || !days[9].Date.Equal(end.AddDate(0, 0, -1)) {
+		t.Fatalf("got %d days from %v, want the 10 from %v", len(days), days[0].Date, start)
+	}
+	for _, d := range days {
+		// Days before start still count towards the averages, and the
+		// session at end does not.
+		if d.ACWR != 1 || d.ChronicLoad != 100 {
+			t.Errorf("%s: ACWR %g over a chronic load of %g, want 1 over 100", d.Date.Format("2006-01-02"), d.ACWR, d.ChronicLoad)
+		}
+	}
+}
+
+func TestWorkloadThresholdsBreaches(t *testing.T) {
+	thresholds := WorkloadThresholds{MaxACWR: 1.5, MinACWR: 0.8, MaxMonotony: 2, MaxStrain: 5000}
+	for _, tc := range []struct {
+		name string
+		day  WorkloadDay
+		want []WorkloadMetric
+	}{
+		{"within", WorkloadDay{ACWR: 1.2, EWMAACWR: 1.1, Monotony: 1.5, Strain: 3000}, nil},
+		{"spike", WorkloadDay{ACWR: 1.6, EWMAACWR: 1.7, Monotony: 1.5}, []WorkloadMetric{MetricACWR, MetricEWMAACWR}},
+		{"detraining", WorkloadDay{ACWR: 0.5, EWMAACWR: 0.9}, []WorkloadMetric{MetricACWR}},
+		{"monotonous", WorkloadDay{ACWR: 1, EWMAACWR: 1, Monotony: 2.5, Strain: 6000}, []WorkloadMetric{MetricMonotony, MetricStrain}},
+		{"undefined values are not checked", WorkloadDay{}, nil},
+	} {
+		alerts := thresholds.Breaches(tc.day)
+		if len(alerts) != len(tc.want) {
+			t.Errorf("%s: breaches = %+v, want %v", tc.name, alerts, tc.want)
+			continue
+		}
+		for i, a := range alerts {
+			if a.Metric != tc.want[i] {
+				t.Errorf("%s: breaches = %+v, want %v", tc.name, alerts, tc.want)
+			}
+		}
+	}
+	if alerts := (WorkloadThresholds{}).Breaches(WorkloadDay{ACWR: 3, Monotony: 5, Strain: 1e6}); len(alerts) != 0 {
+		t.Errorf("zero thresholds raised %+v", alerts)
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/activity"
+)
+
+// ActivityUpload is a watch or ergometer recording of a conditioning session
+// in FIT or TCX format. MovementID names the conditioning movement every
+// session of the file is recorded as; when it is empty each session is
+// linked to the movement whose name or alias matches its sport, such as
+// "Running" or "Row". Title defaults to the sports of the file.
+type ActivityUpload struct {
+	Format     activity.Format `json:"format"`
+	MovementID string          `json:"movementId"`
+	Title      string          `json:"title"`
+	Data       []byte          `json:"-"`
+}
+
+// sportNames are the movement names, most specific first, that a sport is
+// matched against when an upload names no movement.
+var sportNames = map[activity.Sport][]string{
+	activity.Running:    {"running", "run"},
+	activity.Cycling:    {"cycling", "bike", "biking"},
+	activity.Rowing:     {"rowing", "row", "indoor rowing"},
+	activity.Swimming:   {"swimming", "swim"},
+	activity.Walking:    {"walking", "walk"},
+	activity.Hiking:     {"hiking", "hike"},
+	activity.Skiing:     {"skiing", "cross country skiing", "ski"},
+	activity.Elliptical: {"elliptical"},
+}
+
+// UploadActivity parses an activity file and records it as a new workout for
+// the athlete, with one Conditioning entry per session. Uploading a file
+// whose sessions were already recorded fails with ErrAlreadyExists, so that
+// a device syncing the same file twice does not double count it.
+func (s basicWorkoutService) UploadActivity(ctx context.Context, athleteID string, upload ActivityUpload) (Workout, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return Workout{}, err
+	}
+	if err := s.checkAthlete(ctx, p, athleteID); err != nil {
+		return Workout{}, err
+	}
+	sessions, err := activity.Parse(upload.Format, bytes.NewReader(upload.Data))
+	if err != nil {
+		return Workout{}, errors.Wrap(ErrInvalidArgument, err.Error())
+	}
+	catalog, err := s.catalog.List(ctx, MovementFilter{})
+	if err != nil {
+		return Workout{}, err
+	}
+	var conditioning []Movement
+	for _, m := range catalog {
+		if isConditioning(m) {
+			conditioning = append(conditioning, m)
+		}
+	}
+	if upload.MovementID != "" {
+		found := false
+		for _, m := range conditioning {
+			found = found || m.Name == upload.MovementID
+		}
+		if !found {
+			return Workout{}, errors.Wrapf(ErrInvalidArgument, "%s is not a conditioning movement", upload.MovementID)
+		}
+	}
+	resolve, err := newExerciseResolver(conditioning, nil)
+	if err != nil {
+		return Workout{}, err
+	}
+	existing, err := s.workouts.ListWorkouts(ctx, p.TenantID, athleteID)
+	if err != nil {
+		return Workout{}, err
+	}
+	recorded := make(map[time.Time]string)
+	for _, w := range existing {
+		for _, c := range w.Conditioning {
+			recorded[c.StartTime.UTC().Truncate(time.Second)] = w.Name
+		}
+	}
+
+	w := Workout{
+		Name:        uuid.New().String(),
+		TenantID:    p.TenantID,
+		AthleteID:   athleteID,
+		Title:       upload.Title,
+		PerformedAt: sessions[0].StartTime.UTC(),
+	}
+	var sports []string
+	for _, session := range sessions {
+		start := session.StartTime.UTC()
+		if id, ok := recorded[start.Truncate(time.Second)]; ok {
+			return Workout{}, errors.Wrapf(ErrAlreadyExists, "the %s session starting at %s is already recorded in workout %s", session.Sport, start.Format(time.RFC3339), id)
+		}
+		movementID := upload.MovementID
+		for _, name := range sportNames[session.Sport] {
+			if movementID != "" {
+				break
+			}
+			movementID, _ = resolve(name)
+		}
+		if movementID == "" {
+			return Workout{}, errors.Wrapf(ErrInvalidArgument, "no conditioning movement matches the %s session; name one with a movement ID", session.Sport)
+		}
+		c := Conditioning{
+			MovementID:   movementID,
+			Sport:        string(session.Sport),
+			StartTime:    start,
+			Duration:     session.Duration,
+			Distance:     session.Distance,
+			AvgHeartRate: session.AvgHeartRate,
+			MaxHeartRate: session.MaxHeartRate,
+		}
+		for _, l := range session.Laps {
+			c.Laps = append(c.Laps, Lap{
+				StartTime:    l.StartTime.UTC(),
+				Duration:     l.Duration,
+				Distance:     l.Distance,
+				AvgHeartRate: l.AvgHeartRate,
+				MaxHeartRate: l.MaxHeartRate,
+			})
+		}
+		w.Conditioning = append(w.Conditioning, c)
+		sports = append(sports, strings.Title(string(session.Sport)))
+	}
+	if w.Title == "" {
+		w.Title = strings.Join(sports, " + ")
+	}
+	return s.workouts.CreateWorkout(ctx, w)
+}
+
+// isConditioning reports whether a movement is measured by time or distance,
+// or performed on an ergometer, rather than loaded for reps.
+func isConditioning(m Movement) bool {
+	return m.LoadType == TimeLoad || m.LoadType == DistanceLoad || m.Equipment == Ergometer
+}
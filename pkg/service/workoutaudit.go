@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"workout-manager-service/logging"
+)
+
+type workoutAuditService struct {
+	auditor
+	service WorkoutService
+}
+
+// NewWorkoutAuditService takes an AuditRepository as a dependency and returns
+// a WorkoutService that records every successful mutation.
+func NewWorkoutAuditService(logger logging.IshiLogger, repo AuditRepository, s WorkoutService) WorkoutService {
+	return workoutAuditService{
+		auditor: newAuditor(logger, repo),
+		service: s,
+	}
+}
+
+func workoutResource(athleteID string, id string) string {
+	return "athletes/" + athleteID + "/workouts/" + id
+}
+
+// Create records the workout that was created.
+func (as workoutAuditService) Create(ctx context.Context, athleteID string, title string, performedAt time.Time, sets []WorkoutSet, blocks []WorkoutBlock) (w Workout, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		if w, err = as.service.Create(ctx, athleteID, title, performedAt, sets, blocks); err != nil {
+			return err
+		}
+		return as.record(ctx, "CreateWorkout", workoutResource(athleteID, w.Name), nil, w)
+	})
+	return w, err
+}
+
+// Get is not audited.
+func (as workoutAuditService) Get(ctx context.Context, athleteID string, id string) (Workout, error) {
+	return as.service.Get(ctx, athleteID, id)
+}
+
+// List is not audited.
+func (as workoutAuditService) List(ctx context.Context, athleteID string) ([]Workout, error) {
+	return as.service.List(ctx, athleteID)
+}
+
+// Delete records the workout as it was before it was deleted.
+func (as workoutAuditService) Delete(ctx context.Context, athleteID string, id string) error {
+	return as.repo.InTx(ctx, func(ctx context.Context) error {
+		before, _ := as.service.Get(ctx, athleteID, id)
+		if err := as.service.Delete(ctx, athleteID, id); err != nil {
+			return err
+		}
+		return as.record(ctx, "DeleteWorkout", workoutResource(athleteID, id), before, nil)
+	})
+}
+
+// ImportHistory records a committed import by its report; the workouts it
+// created are not recorded one by one.
+func (as workoutAuditService) ImportHistory(ctx context.Context, athleteID string, imp HistoryImport) (report HistoryReport, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		if report, err = as.service.ImportHistory(ctx, athleteID, imp); err != nil || !report.Committed {
+			return err
+		}
+		return as.record(ctx, "ImportWorkoutHistory", "athletes/"+athleteID+"/workouts", nil, report)
+	})
+	return report, err
+}
+
+// UploadActivity records the workout that was created.
+func (as workoutAuditService) UploadActivity(ctx context.Context, athleteID string, upload ActivityUpload) (w Workout, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		if w, err = as.service.UploadActivity(ctx, athleteID, upload); err != nil {
+			return err
+		}
+		return as.record(ctx, "UploadActivity", workoutResource(athleteID, w.Name), nil, w)
+	})
+	return w, err
+}
+
+// RateSession records the workout before and after it was rated.
+func (as workoutAuditService) RateSession(ctx context.Context, athleteID string, id string, rpe float64, duration time.Duration) (w Workout, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		before, _ := as.service.Get(ctx, athleteID, id)
+		if w, err = as.service.RateSession(ctx, athleteID, id, rpe, duration); err != nil {
+			return err
+		}
+		return as.record(ctx, "RateWorkoutSession", workoutResource(athleteID, id), before, w)
+	})
+	return w, err
+}
+
+// CloneWorkout records the planned workout that was created.
+func (as workoutAuditService) CloneWorkout(ctx context.Context, athleteID string, c WorkoutClone) (w Workout, err error) {
+	err = as.repo.InTx(ctx, func(ctx context.Context) error {
+		if w, err = as.service.CloneWorkout(ctx, athleteID, c); err != nil {
+			return err
+		}
+		return as.record(ctx, "CloneWorkout", workoutResource(athleteID, w.Name), nil, w)
+	})
+	return w, err
+}
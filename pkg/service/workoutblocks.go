@@ -0,0 +1,183 @@
+package service
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// BlockType describes how the sets of a WorkoutBlock are performed.
+type BlockType string
+
+// The kinds of block a session is built from. Straight sets work one
+// movement with rest between sets. Supersets, giant sets and circuits
+// alternate two, three or more, and two or more movements round after round.
+// An EMOM starts a round every interval, an AMRAP repeats rounds until the
+// time cap and a for time block is raced to the finish.
+const (
+	StraightSets BlockType = "straight"
+	Superset     BlockType = "superset"
+	GiantSet     BlockType = "giant_set"
+	Circuit      BlockType = "circuit"
+	EMOM         BlockType = "emom"
+	AMRAP        BlockType = "amrap"
+	ForTime      BlockType = "for_time"
+)
+
+// DefaultEMOMInterval is the interval of an EMOM that does not give one:
+// every minute on the minute.
+const DefaultEMOMInterval = time.Minute
+
+// maxBlockDuration bounds the time caps and intervals of a block.
+const maxBlockDuration = 24 * time.Hour
+
+// WorkoutBlock groups the sets of a Workout that share a Label, the A of
+// the A1 and A2 coaches write supersets as. Rounds is how many times the
+// sets are gone through, zero when they are simply listed. Interval is how
+// often an EMOM starts a round and TimeCap how long an AMRAP lasts, or the
+// most a for time block may take.
+type WorkoutBlock struct {
+	Label    string        `json:"label"`
+	Type     BlockType     `json:"type"`
+	Rounds   int32         `json:"rounds"`
+	Interval time.Duration `json:"interval"`
+	TimeCap  time.Duration `json:"timeCap"`
+	Score    BlockScore    `json:"score"`
+}
+
+// BlockScore is the athlete's result in a block, zero until it is recorded.
+// An AMRAP scores the rounds completed and the reps into the next one, an
+// EMOM the rounds completed within their interval, and a for time block the
+// time it took, or the rounds and reps done when the time cap ran out.
+type BlockScore struct {
+	Rounds int32         `json:"rounds"`
+	Reps   int32         `json:"reps"`
+	Time   time.Duration `json:"time"`
+}
+
+// IsZero reports whether no score has been recorded.
+func (s BlockScore) IsZero() bool {
+	return s == BlockScore{}
+}
+
+// checkBlocks validates the blocks of a workout or template against its
+// sets, filling in the default interval of EMOMs. Sets without a label are
+// straight sets outside any block, while every label a set carries must be
+// the label of a block, and every block must have sets.
+func checkBlocks(blocks []WorkoutBlock, sets []WorkoutSet) error {
+	movements := make(map[string]map[string]bool, len(blocks))
+	for i, b := range blocks {
+		if b.Label == "" {
+			return errors.Wrapf(ErrInvalidArgument, "block %d needs a label", i)
+		}
+		if movements[b.Label] != nil {
+			return errors.Wrapf(ErrInvalidArgument, "block %s is listed twice", b.Label)
+		}
+		movements[b.Label] = make(map[string]bool)
+	}
+	for i, set := range sets {
+		if set.Block == "" {
+			continue
+		}
+		if movements[set.Block] == nil {
+			return errors.Wrapf(ErrInvalidArgument, "set %d is in unknown block %q", i, set.Block)
+		}
+		movements[set.Block][set.MovementID] = true
+	}
+	for i, b := range blocks {
+		if err := checkBlock(b, len(movements[b.Label])); err != nil {
+			return err
+		}
+		if b.Type == EMOM && b.Interval == 0 {
+			blocks[i].Interval = DefaultEMOMInterval
+		}
+	}
+	return nil
+}
+
+// checkBlock validates a block with sets of the given number of movements.
+func checkBlock(b WorkoutBlock, movements int) error {
+	invalid := func(format string, args ...interface{}) error {
+		return errors.Wrapf(ErrInvalidArgument, "block %s: "+format, append([]interface{}{b.Label}, args...)...)
+	}
+	switch {
+	case b.Rounds < 0 || b.Score.Rounds < 0 || b.Score.Reps < 0 || b.Score.Time < 0:
+		return invalid("rounds, reps and times may not be negative")
+	case b.Interval < 0 || b.Interval > maxBlockDuration || b.TimeCap < 0 || b.TimeCap > maxBlockDuration:
+		return invalid("intervals and time caps must be between 0 and %s", maxBlockDuration)
+	case movements == 0:
+		return invalid("a block needs at least one set")
+	}
+
+	switch b.Type {
+	case StraightSets:
+		if movements != 1 {
+			return invalid("straight sets work a single movement")
+		}
+	case Superset:
+		if movements != 2 {
+			return invalid("a superset pairs two movements")
+		}
+	case GiantSet:
+		if movements < 3 {
+			return invalid("a giant set needs at least three movements")
+		}
+	case Circuit:
+		if movements < 2 {
+			return invalid("a circuit needs at least two movements")
+		}
+	case EMOM:
+		if b.Rounds == 0 {
+			return invalid("an EMOM needs a number of rounds")
+		}
+		if b.TimeCap != 0 {
+			return invalid("an EMOM lasts its rounds times its interval and has no time cap")
+		}
+		if b.Score.Rounds > b.Rounds || b.Score.Reps != 0 || b.Score.Time != 0 {
+			return invalid("an EMOM scores at most %d rounds completed", b.Rounds)
+		}
+		return nil
+	case AMRAP:
+		if b.TimeCap == 0 {
+			return invalid("an AMRAP needs a time cap")
+		}
+		if b.Rounds != 0 || b.Interval != 0 {
+			return invalid("an AMRAP has no set rounds or interval")
+		}
+		if b.Score.Time != 0 {
+			return invalid("an AMRAP scores rounds and reps, not time")
+		}
+		return nil
+	case ForTime:
+		if b.Interval != 0 {
+			return invalid("a for time block has no interval")
+		}
+		if b.TimeCap != 0 && b.Score.Time > b.TimeCap {
+			return invalid("the time is over the time cap")
+		}
+		if b.Score.Time != 0 && (b.Score.Rounds != 0 || b.Score.Reps != 0) {
+			return invalid("a for time block scores either a time or the rounds and reps done by the cap")
+		}
+		return nil
+	default:
+		return errors.Wrapf(ErrInvalidArgument, "block %s has unknown type %q", b.Label, b.Type)
+	}
+	if b.Interval != 0 || b.TimeCap != 0 || !b.Score.IsZero() {
+		return invalid("%s blocks are not timed or scored", b.Type)
+	}
+	return nil
+}
+
+// unscoredBlocks returns a copy of blocks without their scores, for planning
+// a workout from them.
+func unscoredBlocks(blocks []WorkoutBlock) []WorkoutBlock {
+	if len(blocks) == 0 {
+		return nil
+	}
+	unscored := make([]WorkoutBlock, len(blocks))
+	for i, b := range blocks {
+		b.Score = BlockScore{}
+		unscored[i] = b
+	}
+	return unscored
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestCheckBlock(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		block     WorkoutBlock
+		movements int
+		ok        bool
+	}{
+		{"straight sets", WorkoutBlock{Type: StraightSets}, 1, true},
+		{"straight sets of two movements", WorkoutBlock{Type: StraightSets}, 2, false},
+		{"superset", WorkoutBlock{Type: Superset, Rounds: 3}, 2, true},
+		{"superset of three", WorkoutBlock{Type: Superset}, 3, false},
+		{"timed superset", WorkoutBlock{Type: Superset, TimeCap: time.Minute}, 2, false},
+		{"scored superset", WorkoutBlock{Type: Superset, Score: BlockScore{Rounds: 3}}, 2, false},
+		{"giant set", WorkoutBlock{Type: GiantSet}, 3, true},
+		{"giant set of two", WorkoutBlock{Type: GiantSet}, 2, false},
+		{"circuit", WorkoutBlock{Type: Circuit, Rounds: 4}, 5, true},
+		{"circuit of one", WorkoutBlock{Type: Circuit}, 1, false},
+		{"EMOM", WorkoutBlock{Type: EMOM, Rounds: 10, Score: BlockScore{Rounds: 9}}, 1, true},
+		{"EMOM without rounds", WorkoutBlock{Type: EMOM}, 1, false},
+		{"EMOM with a time cap", WorkoutBlock{Type: EMOM, Rounds: 10, TimeCap: 10 * time.Minute}, 1, false},
+		{"EMOM scoring more rounds than it has", WorkoutBlock{Type: EMOM, Rounds: 10, Score: BlockScore{Rounds: 11}}, 1, false},
+		{"EMOM scoring a time", WorkoutBlock{Type: EMOM, Rounds: 10, Score: BlockScore{Time: time.Minute}}, 1, false},
+		{"AMRAP", WorkoutBlock{Type: AMRAP, TimeCap: 20 * time.Minute, Score: BlockScore{Rounds: 7, Reps: 12}}, 3, true},
+		{"AMRAP without a time cap", WorkoutBlock{Type: AMRAP}, 3, false},
+		{"AMRAP with rounds", WorkoutBlock{Type: AMRAP, TimeCap: 20 * time.Minute, Rounds: 5}, 3, false},
+		{"AMRAP scoring a time", WorkoutBlock{Type: AMRAP, TimeCap: 20 * time.Minute, Score: BlockScore{Time: time.Minute}}, 3, false},
+		{"for time", WorkoutBlock{Type: ForTime, Rounds: 3, Score: BlockScore{Time: 9 * time.Minute}}, 2, true},
+		{"for time capped", WorkoutBlock{Type: ForTime, TimeCap: 10 * time.Minute, Score: BlockScore{Rounds: 2, Reps: 15}}, 2, true},
+		{"for time over its cap", WorkoutBlock{Type: ForTime, TimeCap: 10 * time.Minute, Score: BlockScore{Time: 11 * time.Minute}}, 2, false},
+		{"for time scoring a time and reps", WorkoutBlock{Type: ForTime, Score: BlockScore{Time: time.Minute, Reps: 3}}, 2, false},
+		{"for time with an interval", WorkoutBlock{Type: ForTime, Interval: time.Minute}, 2, false},
+		{"no sets", WorkoutBlock{Type: Circuit}, 0, false},
+		{"negative rounds", WorkoutBlock{Type: Circuit, Rounds: -1}, 2, false},
+		{"negative score", WorkoutBlock{Type: AMRAP, TimeCap: time.Minute, Score: BlockScore{Reps: -1}}, 2, false},
+		{"time cap too long", WorkoutBlock{Type: AMRAP, TimeCap: maxBlockDuration + time.Second}, 2, false},
+		{"unknown type", WorkoutBlock{Type: "tabata"}, 1, false},
+	} {
+		tc.block.Label = "A"
+		err := checkBlock(tc.block, tc.movements)
+		if tc.ok && err != nil {
+			t.Errorf("%s: checkBlock() = %v, want nil", tc.name, err)
+		}
+		if !tc.ok && errors.Cause(err) != ErrInvalidArgument {
+			t.Errorf("%s: checkBlock() = %v, want %v", tc.name, err, ErrInvalidArgument)
+		}
+	}
+}
+
+func TestCheckBlocks(t *testing.T) {
+	sets := []WorkoutSet{
+		{MovementID: "squat", Block: "A"},
+		{MovementID: "pull-up", Block: "A"},
+		{MovementID: "squat", Block: "A"},
+		{MovementID: "burpee", Block: "B"},
+		{MovementID: "deadlift"},
+	}
+	for _, tc := range []struct {
+		name   string
+		blocks []WorkoutBlock
+		sets   []WorkoutSet
+		ok     bool
+	}{
+		{"blocks and straight sets", []WorkoutBlock{{Label: "A", Type: Superset}, {Label: "B", Type: EMOM, Rounds: 10}}, sets, true},
+		{"no blocks", nil, []WorkoutSet{{MovementID: "squat"}}, true},
+		{"unlabelled block", []WorkoutBlock{{Type: StraightSets}}, []WorkoutSet{{MovementID: "squat"}}, false},
+		{"block listed twice", []WorkoutBlock{{Label: "A", Type: Superset}, {Label: "A", Type: Superset}}, sets[:3], false},
+		{"set in an unknown block", []WorkoutBlock{{Label: "A", Type: Superset}}, sets, false},
+		{"block without sets", []WorkoutBlock{{Label: "A", Type: Superset}, {Label: "B", Type: EMOM, Rounds: 10}, {Label: "C", Type: Circuit}}, sets, false},
+	} {
+		blocks := append([]WorkoutBlock(nil), tc.blocks...)
+		err := checkBlocks(blocks, tc.sets)
+		if tc.ok && err != nil {
+			t.Errorf("%s: checkBlocks() = %v, want nil", tc.name, err)
+		}
+		if !tc.ok && errors.Cause(err) != ErrInvalidArgument {
+			t.Errorf("%s: checkBlocks() = %v, want %v", tc.name, err, ErrInvalidArgument)
+		}
+		for _, b := range blocks {
+			if tc.ok && b.Type == EMOM && b.Interval != DefaultEMOMInterval {
+				t.Errorf("%s: EMOM %s has interval %s, want the default %s", tc.name, b.Label, b.Interval, DefaultEMOMInterval)
+			}
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// WorkoutClone asks for a new planned workout with the movements and set
+// scheme of an earlier workout of the athlete or of a WorkoutTemplate. At
+// most one of WorkoutID and TemplateID may be given; with neither, the
+// athlete's most recent workout is cloned. An empty Title keeps the title of
+// what is cloned and a zero PlannedFor plans the workout for now.
+type WorkoutClone struct {
+	WorkoutID   string
+	TemplateID  string
+	Title       string
+	PlannedFor  time.Time
+	Progression CloneProgression
+}
+
+// CloneProgression is the load added to the sets of a cloned workout, such
+// as +2.5 kg on the main lifts. The increment applies to the sets of the
+// movements in MovementIDs, or to the sets of every barbell movement when it
+// is empty, and only to sets loaded with weight. A zero Increment clones the
+// loads as they were.
+type CloneProgression struct {
+	Increment   Load
+	MovementIDs []string
+}
+
+// CloneWorkout plans a workout for an athlete from one they did before or a
+// template the caller can see. Only the sets and blocks are cloned, with the
+// RPE of a set kept as the target of the planned one; conditioning, block
+// scores and the session rating are left for the athlete to record.
+func (s basicWorkoutService) CloneWorkout(ctx context.Context, athleteID string, c WorkoutClone) (Workout, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return Workout{}, err
+	}
+	if err := s.checkAthlete(ctx, p, athleteID); err != nil {
+		return Workout{}, err
+	}
+	if c.WorkoutID != "" && c.TemplateID != "" {
+		return Workout{}, errors.Wrap(ErrInvalidArgument, "clone either a workout or a template")
+	}
+	if c.Progression.Increment.Kilograms < 0 {
+		return Workout{}, errors.Wrap(ErrInvalidArgument, "the progression may not take weight off")
+	}
+	if _, ok := PlateIncrements[c.Progression.Increment.Unit]; !ok && c.Progression.Increment.Unit != "" {
+		return Workout{}, errors.Wrapf(ErrInvalidArgument, "unknown unit %q", c.Progression.Increment.Unit)
+	}
+	title, sets, blocks, err := s.cloneSource(ctx, p, athleteID, c)
+	if err != nil {
+		return Workout{}, err
+	}
+	if c.Title != "" {
+		title = c.Title
+	}
+	if c.Progression.Increment.Kilograms > 0 {
+		if sets, err = s.progress(ctx, sets, c.Progression); err != nil {
+			return Workout{}, err
+		}
+	}
+	plannedFor := c.PlannedFor
+	if plannedFor.IsZero() {
+		plannedFor = time.Now()
+	}
+	return s.workouts.CreateWorkout(ctx, Workout{
+		Name:        uuid.New().String(),
+		TenantID:    p.TenantID,
+		AthleteID:   athleteID,
+		Title:       title,
+		PerformedAt: plannedFor.UTC(),
+		Sets:        sets,
+		Blocks:      unscoredBlocks(blocks),
+		Planned:     true,
+	})
+}
+
+// cloneSource returns the title, a copy of the sets and the blocks of what c
+// clones.
+func (s basicWorkoutService) cloneSource(ctx context.Context, p Principal, athleteID string, c WorkoutClone) (string, []WorkoutSet, []WorkoutBlock, error) {
+	if c.TemplateID != "" {
+		t, err := visibleTemplate(ctx, s.templates, p, c.TemplateID)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		return t.Title, append([]WorkoutSet(nil), t.Sets...), t.Blocks, nil
+	}
+	if c.WorkoutID != "" {
+		w, err := s.Get(ctx, athleteID, c.WorkoutID)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		return w.Title, append([]WorkoutSet(nil), w.Sets...), w.Blocks, nil
+	}
+	history, err := s.workouts.ListWorkouts(ctx, p.TenantID, athleteID)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	for _, w := range history {
+		if !w.Planned && len(w.Sets) > 0 {
+			return w.Title, append([]WorkoutSet(nil), w.Sets...), w.Blocks, nil
+		}
+	}
+	return "", nil, nil, errors.Wrapf(ErrNotFound, "athlete %s has no workout to clone", athleteID)
+}
+
+// progress adds the increment of a progression to the loaded sets it applies
+// to. The increment is converted to the unit of each set and rounded to a
+// jump its plates can make, so that +2.5 kg on a set logged in pounds adds
+// 6 lb rather than 5.51 lb.
+func (s basicWorkoutService) progress(ctx context.Context, sets []WorkoutSet, prog CloneProgression) ([]WorkoutSet, error) {
+	progressed := make(map[string]bool)
+	if len(prog.MovementIDs) > 0 {
+		for _, id := range prog.MovementIDs {
+			m, err := s.catalog.Get(ctx, id, true)
+			if err != nil {
+				return nil, err
+			}
+			progressed[id], progressed[m.Name] = true, true
+		}
+	} else {
+		checked := make(map[string]bool)
+		for _, set := range sets {
+			if checked[set.MovementID] {
+				continue
+			}
+			checked[set.MovementID] = true
+			m, err := s.catalog.Get(ctx, set.MovementID, true)
+			if errors.Cause(err) == ErrNotFound {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			progressed[set.MovementID] = m.Equipment == Barbell
+		}
+	}
+	for i, set := range sets {
+		if !progressed[set.MovementID] || set.Weight.Kilograms <= 0 {
+			continue
+		}
+		unit := set.Weight.Unit
+		if unit == "" {
+			unit = Kilograms
+		}
+		step := RoundToIncrement(prog.Increment.In(unit), PlateIncrements[unit])
+		if step == 0 {
+			step = PlateIncrements[unit]
+		}
+		sets[i].Weight, _ = NewLoad(set.Weight.In(unit)+step, unit)
+	}
+	return sets, nil
+}
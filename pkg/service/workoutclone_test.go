@@ -0,0 +1,145 @@
+package service_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/inmem"
+	"workout-manager-service/pkg/service"
+)
+
+// newCloneStore returns a template store with a barbell squat and a dumbbell
+// curl, a heavy day logged in kilograms, a later light day logged in pounds
+// and a workout planned after both.
+func newCloneStore(t *testing.T) (*inmem.Store, map[string]context.Context) {
+	t.Helper()
+	s, ctxs := newTemplateStore(t)
+	for _, m := range []service.Movement{
+		{Name: "squat", TenantID: "t1", MovementName: "Squat", Equipment: service.Barbell},
+		{Name: "curl", TenantID: "t1", MovementName: "Curl", Equipment: service.Dumbbell},
+	} {
+		if _, err := s.CreateMovement(ctxs["admin"], m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pounds, _ := service.NewLoad(225, service.Pounds)
+	for _, w := range []service.Workout{
+		{Name: "heavy", Title: "Heavy Day", PerformedAt: time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC), Sets: []service.WorkoutSet{
+			{MovementID: "squat", Reps: 5, Weight: service.Load{Kilograms: 100, Unit: service.Kilograms}, RPE: 8},
+			{MovementID: "curl", Reps: 10, Weight: service.Load{Kilograms: 15, Unit: service.Kilograms}},
+		}},
+		{Name: "light", Title: "Light Day", PerformedAt: time.Date(2024, 3, 8, 18, 0, 0, 0, time.UTC), Sets: []service.WorkoutSet{
+			{MovementID: "squat", Reps: 5, Weight: pounds},
+		}},
+		{Name: "next", Title: "Next Day", PerformedAt: time.Date(2024, 3, 15, 18, 0, 0, 0, time.UTC), Planned: true, Sets: []service.WorkoutSet{
+			{MovementID: "curl", Reps: 12},
+		}},
+	} {
+		w.TenantID, w.AthleteID = "t1", "a1"
+		if _, err := s.CreateWorkout(ctxs["admin"], w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s, ctxs
+}
+
+func TestCloneWorkout(t *testing.T) {
+	s, ctxs := newCloneStore(t)
+	svc := service.NewBasicWorkoutService(s, s, s, s)
+	ctx := ctxs["admin"]
+	kilos := func(kg float64) service.Load { return service.Load{Kilograms: kg} }
+	plannedFor := time.Date(2024, 3, 22, 18, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name  string
+		clone service.WorkoutClone
+		title string
+		want  []float64 // the weight of each set in its own unit
+	}{
+		{"latest performed workout", service.WorkoutClone{}, "Light Day", []float64{225}},
+		{"earlier workout", service.WorkoutClone{WorkoutID: "heavy", Title: "Heavy Day II"}, "Heavy Day II", []float64{100, 15}},
+		{"barbell progression", service.WorkoutClone{WorkoutID: "heavy", Progression: service.CloneProgression{Increment: kilos(2.5)}}, "Heavy Day", []float64{102.5, 15}},
+		{"progression in pounds", service.WorkoutClone{WorkoutID: "light", Progression: service.CloneProgression{Increment: kilos(2.5)}}, "Light Day", []float64{231}},
+		{"progression of named movements", service.WorkoutClone{WorkoutID: "heavy", Progression: service.CloneProgression{Increment: kilos(2.5), MovementIDs: []string{"curl"}}}, "Heavy Day", []float64{100, 17.5}},
+	} {
+		tc.clone.PlannedFor = plannedFor
+		w, err := svc.CloneWorkout(ctx, "a1", tc.clone)
+		if err != nil {
+			t.Errorf("%s: CloneWorkout() = %v", tc.name, err)
+			continue
+		}
+		if !w.Planned || w.AthleteID != "a1" || w.Title != tc.title || !w.PerformedAt.Equal(plannedFor) {
+			t.Errorf("%s: workout = %+v, want %q planned for a1", tc.name, w, tc.title)
+		}
+		if len(w.Sets) != len(tc.want) {
+			t.Errorf("%s: sets = %+v, want %v", tc.name, w.Sets, tc.want)
+			continue
+		}
+		for i, set := range w.Sets {
+			if got := set.Weight.In(""); math.Abs(got-tc.want[i]) > 1e-9 {
+				t.Errorf("%s: set %d weighs %g %s, want %g", tc.name, i, got, set.Weight.Unit, tc.want[i])
+			}
+		}
+		if stored, err := s.GetWorkout(ctx, w.Name); err != nil || !stored.Planned {
+			t.Errorf("%s: stored workout = %+v, %v", tc.name, stored, err)
+		}
+	}
+
+	heavy, _ := s.GetWorkout(ctx, "heavy")
+	if heavy.Sets[0].Weight.Kilograms != 100 || heavy.Sets[0].RPE != 8 {
+		t.Errorf("cloned workout = %+v, want it unchanged", heavy)
+	}
+}
+
+func TestCloneTemplate(t *testing.T) {
+	s, ctxs := newCloneStore(t)
+	templates := service.NewBasicTemplateService(s, s)
+	tmpl, err := templates.Create(ctxs["a2"], service.WorkoutTemplate{Title: "Squat Day", Sets: templateSets})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := service.NewBasicWorkoutService(s, s, s, s)
+
+	if _, err := svc.CloneWorkout(ctxs["c1"], "a1", service.WorkoutClone{TemplateID: tmpl.Name}); errors.Cause(err) != service.ErrNotFound {
+		t.Errorf("CloneWorkout() of a template the caller cannot see = %v, want %v", err, service.ErrNotFound)
+	}
+	w, err := svc.CloneWorkout(ctxs["admin"], "a1", service.WorkoutClone{TemplateID: tmpl.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Title != "Squat Day" || !w.Planned || len(w.Sets) != 1 || w.Sets[0].Weight.Kilograms != 100 {
+		t.Errorf("workout = %+v, want the template's squat planned", w)
+	}
+}
+
+func TestCloneWorkoutErrors(t *testing.T) {
+	s, ctxs := newCloneStore(t)
+	svc := service.NewBasicWorkoutService(s, s, s, s)
+	ctx := ctxs["admin"]
+
+	for _, tc := range []struct {
+		name    string
+		ctx     context.Context
+		athlete string
+		clone   service.WorkoutClone
+		err     error
+	}{
+		{"workout and template", ctx, "a1", service.WorkoutClone{WorkoutID: "heavy", TemplateID: "legs"}, service.ErrInvalidArgument},
+		{"negative increment", ctx, "a1", service.WorkoutClone{Progression: service.CloneProgression{Increment: service.Load{Kilograms: -2.5}}}, service.ErrInvalidArgument},
+		{"unknown unit", ctx, "a1", service.WorkoutClone{Progression: service.CloneProgression{Increment: service.Load{Kilograms: 2.5, Unit: "stone"}}}, service.ErrInvalidArgument},
+		{"unknown progression movement", ctx, "a1", service.WorkoutClone{Progression: service.CloneProgression{Increment: service.Load{Kilograms: 2.5}, MovementIDs: []string{"nope"}}}, service.ErrNotFound},
+		{"another athlete's workout", ctx, "a2", service.WorkoutClone{WorkoutID: "heavy"}, service.ErrNotFound},
+		{"unknown template", ctx, "a1", service.WorkoutClone{TemplateID: "nope"}, service.ErrNotFound},
+		{"nothing performed yet", ctx, "a2", service.WorkoutClone{}, service.ErrNotFound},
+		{"not an athlete", ctx, "c1", service.WorkoutClone{}, service.ErrInvalidArgument},
+		{"no caller", context.Background(), "a1", service.WorkoutClone{}, service.ErrUnauthenticated},
+	} {
+		if _, err := svc.CloneWorkout(tc.ctx, tc.athlete, tc.clone); errors.Cause(err) != tc.err {
+			t.Errorf("%s: CloneWorkout() = %v, want %v", tc.name, err, tc.err)
+		}
+	}
+}
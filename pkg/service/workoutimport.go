@@ -0,0 +1,272 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pkg/importer"
+)
+
+// HistoryImport describes an import of an athlete's training history from
+// another app's CSV export. Mappings resolve exercise names, as the app
+// writes them, to movement IDs for names that do not match a movement or one
+// of its aliases. Unit is the weight unit of exports that do not say and
+// TimeZone, an IANA name, places timestamps that carry no zone; they default
+// to kilograms and UTC.
+type HistoryImport struct {
+	Source         importer.Source     `json:"source"`
+	Unit           importer.WeightUnit `json:"unit"`
+	TimeZone       string              `json:"timeZone"`
+	Mappings       map[string]string   `json:"mappings"`
+	SkipUnresolved bool                `json:"skipUnresolved"`
+	DryRun         bool                `json:"dryRun"`
+	Data           []byte              `json:"-"`
+}
+
+// UnresolvedExercise is an exercise name from an import that matches no
+// movement. Suggestions are the movements it most resembles, best first.
+type UnresolvedExercise struct {
+	Name        string          `json:"name"`
+	Sets        int             `json:"sets"`
+	Suggestions []MovementMatch `json:"suggestions"`
+}
+
+// HistoryReport summarizes a history import. Workouts and Sets count what
+// was, or would be, created. Duplicates counts workouts skipped because the
+// athlete already has one with the same title performed at the same time, so
+// that importing a newer export of the same app only adds what is new.
+// IgnoredRows counts rows without reps or weight, such as cardio, and
+// SkippedSets the sets of unresolved exercises left out with SkipUnresolved.
+type HistoryReport struct {
+	DryRun      bool                 `json:"dryRun"`
+	Committed   bool                 `json:"committed"`
+	Workouts    int                  `json:"workouts"`
+	Sets        int                  `json:"sets"`
+	Duplicates  int                  `json:"duplicates"`
+	IgnoredRows int                  `json:"ignoredRows"`
+	SkippedSets int                  `json:"skippedSets"`
+	Unresolved  []UnresolvedExercise `json:"unresolved"`
+}
+
+// maxSuggestions bounds the movements suggested for an unresolved exercise.
+const maxSuggestions = 3
+
+// ImportHistory parses another app's export and, unless it is a dry run or
+// an exercise name is unresolved and SkipUnresolved is unset, creates the
+// athlete's workouts in a single transaction.
+func (s basicWorkoutService) ImportHistory(ctx context.Context, athleteID string, imp HistoryImport) (HistoryReport, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return HistoryReport{}, err
+	}
+	if err := s.checkAthlete(ctx, p, athleteID); err != nil {
+		return HistoryReport{}, err
+	}
+	if len(imp.Data) > MaxImportSize {
+		return HistoryReport{}, errors.Wrapf(ErrInvalidArgument, "imports are limited to %d bytes", MaxImportSize)
+	}
+	loc := time.UTC
+	if imp.TimeZone != "" {
+		if loc, err = time.LoadLocation(imp.TimeZone); err != nil {
+			return HistoryReport{}, errors.Wrapf(ErrInvalidArgument, "unknown time zone %q", imp.TimeZone)
+		}
+	}
+	history, err := importer.Parse(imp.Source, bytes.NewReader(imp.Data), importer.Options{Unit: imp.Unit, Location: loc})
+	if err != nil {
+		return HistoryReport{}, errors.Wrap(ErrInvalidArgument, err.Error())
+	}
+	catalog, err := s.catalog.List(ctx, MovementFilter{})
+	if err != nil {
+		return HistoryReport{}, err
+	}
+	resolve, err := newExerciseResolver(catalog, imp.Mappings)
+	if err != nil {
+		return HistoryReport{}, err
+	}
+	performed, err := s.performedWorkouts(ctx, p.TenantID, athleteID, history.Sessions)
+	if err != nil {
+		return HistoryReport{}, err
+	}
+
+	report := HistoryReport{DryRun: imp.DryRun, IgnoredRows: history.Ignored}
+	unresolved := make(map[string]*UnresolvedExercise)
+	var workouts []Workout
+	for _, session := range history.Sessions {
+		if performed[workoutKey(session.Title, session.PerformedAt)] {
+			report.Duplicates++
+			continue
+		}
+		w := Workout{
+			Name:        uuid.New().String(),
+			TenantID:    p.TenantID,
+			AthleteID:   athleteID,
+			Title:       session.Title,
+			PerformedAt: session.PerformedAt.UTC(),
+		}
+		for _, set := range session.Sets {
+			id, ok := resolve(set.Exercise)
+			if !ok {
+				u, seen := unresolved[set.Exercise]
+				if !seen {
+					u = &UnresolvedExercise{Name: set.Exercise, Suggestions: suggestMovements(catalog, set.Exercise)}
+					unresolved[set.Exercise] = u
+				}
+				u.Sets++
+				if imp.SkipUnresolved {
+					report.SkippedSets++
+				}
+				continue
+			}
+			weight, err := NewLoad(set.Weight, WeightUnit(set.Unit))
+			if err != nil {
+				return HistoryReport{}, err
+			}
+			w.Sets = append(w.Sets, WorkoutSet{
+				MovementID: id,
+				Reps:       set.Reps,
+				Weight:     weight,
+				RPE:        set.RPE,
+			})
+		}
+		if len(w.Sets) == 0 {
+			continue
+		}
+		report.Workouts++
+		report.Sets += len(w.Sets)
+		workouts = append(workouts, w)
+	}
+	for _, u := range unresolved {
+		report.Unresolved = append(report.Unresolved, *u)
+	}
+	sort.Slice(report.Unresolved, func(i, j int) bool {
+		return report.Unresolved[i].Name < report.Unresolved[j].Name
+	})
+	if imp.DryRun || (!imp.SkipUnresolved && len(unresolved) > 0) || len(workouts) == 0 {
+		return report, nil
+	}
+	if _, err := s.workouts.CreateWorkouts(ctx, workouts); err != nil {
+		return HistoryReport{}, err
+	}
+	report.Committed = true
+	return report, nil
+}
+
+// performedWorkouts returns the keys of the workouts the athlete performed
+// over the time span of sessions.
+func (s basicWorkoutService) performedWorkouts(ctx context.Context, tenantID string, athleteID string, sessions []importer.Session) (map[string]bool, error) {
+	performed := make(map[string]bool)
+	if len(sessions) == 0 {
+		return performed, nil
+	}
+	// Sessions are sorted oldest first.
+	from := sessions[0].PerformedAt.UTC().Truncate(time.Minute)
+	to := sessions[len(sessions)-1].PerformedAt.UTC().Truncate(time.Minute).Add(time.Minute)
+	existing, err := s.workouts.ListPerformedWorkouts(ctx, tenantID, athleteID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range existing {
+		performed[workoutKey(w.Title, w.PerformedAt)] = true
+	}
+	return performed, nil
+}
+
+// workoutKey identifies a workout for duplicate detection. Times are compared
+// to the minute, the precision apps export them with.
+func workoutKey(title string, performedAt time.Time) string {
+	return NormalizeSearch(title) + "\x00" + performedAt.UTC().Truncate(time.Minute).Format(time.RFC3339)
+}
+
+// newExerciseResolver returns a function resolving an exercise name to a
+// movement ID. Explicit mappings win, then names and aliases matching one of
+// the exercise's spellings, with the tenant's own movements preferred over
+// global ones. Mappings must name movements the caller can see.
+func newExerciseResolver(catalog []Movement, mappings map[string]string) (func(string) (string, bool), error) {
+	known := make(map[string]bool, len(catalog))
+	for _, m := range catalog {
+		known[m.Name] = true
+	}
+	mapped := make(map[string]string, len(mappings))
+	for name, id := range mappings {
+		if !known[id] {
+			return nil, errors.Wrapf(ErrInvalidArgument, "exercise %q is mapped to unknown movement %q", name, id)
+		}
+		mapped[NormalizeSearch(name)] = id
+	}
+	byName := make(map[string]string)
+	index := func(global bool) {
+		for _, m := range catalog {
+			if (m.TenantID == SystemTenantID) != global {
+				continue
+			}
+			byName[NormalizeSearch(m.MovementName)] = m.Name
+			for _, alias := range m.Aliases {
+				byName[NormalizeSearch(alias)] = m.Name
+			}
+		}
+	}
+	index(true)
+	index(false)
+	return func(exercise string) (string, bool) {
+		if id, ok := mapped[NormalizeSearch(exercise)]; ok {
+			return id, true
+		}
+		for _, spelling := range exerciseSpellings(exercise) {
+			if id, ok := byName[spelling]; ok {
+				return id, true
+			}
+		}
+		return "", false
+	}, nil
+}
+
+// exerciseSpellings returns normalized ways of writing an exercise name,
+// most specific first. Strong and Hevy qualify names with the equipment in
+// parentheses, as in "Bench Press (Barbell)", where a catalog would more
+// likely say "Barbell Bench Press" or just "Bench Press".
+func exerciseSpellings(exercise string) []string {
+	spellings := []string{NormalizeSearch(exercise)}
+	open := strings.LastIndex(exercise, "(")
+	if open < 0 || !strings.HasSuffix(strings.TrimSpace(exercise), ")") {
+		return spellings
+	}
+	base := NormalizeSearch(exercise[:open])
+	qualifier := NormalizeSearch(exercise[open:])
+	if base == "" {
+		return spellings
+	}
+	if qualifier != "" {
+		spellings = append(spellings, qualifier+" "+base)
+	}
+	return append(spellings, base)
+}
+
+// suggestMovements returns the movements whose names or aliases best match
+// any spelling of an exercise.
+func suggestMovements(catalog []Movement, exercise string) []MovementMatch {
+	var matches []MovementMatch
+	for _, m := range catalog {
+		best := 0.0
+		for _, spelling := range exerciseSpellings(exercise) {
+			if score := m.SearchScore(spelling); score > best {
+				best = score
+			}
+		}
+		if best > 0 {
+			matches = append(matches, MovementMatch{Movement: m, Score: best})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if len(matches) > maxSuggestions {
+		matches = matches[:maxSuggestions]
+	}
+	return matches
+}
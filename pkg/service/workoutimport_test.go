@@ -0,0 +1,122 @@
+package service_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"workout-manager-service/inmem"
+	"workout-manager-service/pkg/importer"
+	"workout-manager-service/pkg/service"
+)
+
+// newAthleteStore returns a store of tenant t1 with the admin "admin" and the
+// athlete "a1", and a context in which the admin is the caller.
+func newAthleteStore(t *testing.T) (*inmem.Store, context.Context) {
+	t.Helper()
+	ctx := context.Background()
+	s := inmem.NewStore()
+	if err := s.CreateTenant(ctx, service.Tenant{Name: "t1"}, service.User{Name: "admin", TenantID: "t1", Email: "admin@example.com", Role: service.RoleAdmin}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateUser(ctx, service.User{Name: "a1", TenantID: "t1", Email: "a1@example.com", Role: service.RoleAthlete}); err != nil {
+		t.Fatal(err)
+	}
+	return s, service.NewContextWithPrincipal(ctx, service.Principal{UserID: "admin", TenantID: "t1", Role: service.RoleAdmin})
+}
+
+const strongPounds = `Date,Workout Name,Duration,Exercise Name,Set Order,Weight,Reps,Distance,Seconds,Notes,Workout Notes,RPE
+2024-03-01 07:30:00,"Legs",50m,"Squat (Barbell)",1,225,5,0,0,"","",8
+2024-03-04 18:00:00,"Push Day",1h,"Bench Press (Barbell)",1,185,5,0,0,"","",
+2024-03-04 18:00:00,"Push Day",1h,"Lateral Raise (Dumbbell)",1,20,12,0,0,"","",
+`
+
+func TestImportHistory(t *testing.T) {
+	s, ctx := newAthleteStore(t)
+	for _, m := range []service.Movement{
+		{Name: "squat", TenantID: service.SystemTenantID, MovementName: "Squat"},
+		{Name: "bench", TenantID: "t1", MovementName: "Barbell Bench Press"},
+	} {
+		if _, err := s.CreateMovement(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A planned workout like an imported one is not a duplicate of it.
+	_, err := s.CreateWorkout(ctx, service.Workout{Name: "plan", TenantID: "t1", AthleteID: "a1", Title: "Legs", PerformedAt: time.Date(2024, 3, 1, 7, 30, 0, 0, time.UTC), Planned: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := service.NewBasicWorkoutService(s, s, s, s)
+	imp := service.HistoryImport{Source: importer.Strong, Unit: importer.Pounds, Data: []byte(strongPounds)}
+
+	report, err := svc.ImportHistory(ctx, "a1", imp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Committed || len(report.Unresolved) != 1 || report.Unresolved[0].Name != "Lateral Raise (Dumbbell)" {
+		t.Fatalf("report = %+v, want the lateral raise unresolved and nothing imported", report)
+	}
+
+	imp.SkipUnresolved = true
+	imp.DryRun = true
+	if report, err = svc.ImportHistory(ctx, "a1", imp); err != nil || report.Committed || report.Workouts != 2 || report.SkippedSets != 1 {
+		t.Fatalf("dry run = %+v, %v, want 2 workouts to import and nothing committed", report, err)
+	}
+	if workouts, _ := s.ListWorkouts(ctx, "t1", "a1"); len(workouts) != 1 {
+		t.Fatalf("the dry run stored %d workouts", len(workouts)-1)
+	}
+
+	imp.DryRun = false
+	if report, err = svc.ImportHistory(ctx, "a1", imp); err != nil || !report.Committed || report.Workouts != 2 || report.Sets != 2 {
+		t.Fatalf("import = %+v, %v, want 2 workouts of a set each committed", report, err)
+	}
+	workouts, err := s.ListWorkouts(ctx, "t1", "a1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var squat *service.WorkoutSet
+	for _, w := range workouts {
+		if w.Title == "Legs" && !w.Planned {
+			squat = &w.Sets[0]
+		}
+	}
+	if squat == nil || squat.MovementID != "squat" {
+		t.Fatalf("workouts = %+v, want the squats imported", workouts)
+	}
+	if math.Abs(squat.Weight.Kilograms-225*service.KilogramsPerPound) > 1e-9 || squat.Weight.In("") != 225 {
+		t.Errorf("squat weight = %+v, want 225 lb", squat.Weight)
+	}
+
+	// Importing the same export again only finds duplicates.
+	if report, err = svc.ImportHistory(ctx, "a1", imp); err != nil || report.Committed || report.Duplicates != 2 || report.Workouts != 0 {
+		t.Errorf("reimport = %+v, %v, want 2 duplicates and nothing committed", report, err)
+	}
+}
+
+func TestListPerformedWorkouts(t *testing.T) {
+	s, ctx := newAthleteStore(t)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, w := range []service.Workout{
+		{Name: "before", PerformedAt: day.Add(-time.Minute)},
+		{Name: "first", PerformedAt: day},
+		{Name: "planned", PerformedAt: day.Add(time.Hour), Planned: true},
+		{Name: "last", PerformedAt: day.Add(2*time.Hour - time.Second), Sets: []service.WorkoutSet{{MovementID: "squat", Reps: 5}}},
+		{Name: "after", PerformedAt: day.Add(2 * time.Hour)},
+	} {
+		w.TenantID, w.AthleteID = "t1", "a1"
+		if _, err := s.CreateWorkout(ctx, w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	workouts, err := s.ListPerformedWorkouts(ctx, "t1", "a1", day, day.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workouts) != 2 || workouts[0].Name != "last" || workouts[1].Name != "first" {
+		t.Fatalf("workouts = %+v, want last and first", workouts)
+	}
+	if len(workouts[0].Sets) != 0 {
+		t.Errorf("workouts were listed with their sets")
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"workout-manager-service/logging"
+)
+
+type workoutLoggingService struct {
+	logger  logging.IshiLogger
+	service WorkoutService
+}
+
+// NewWorkoutLoggingService takes an IshiLogger as a dependency and returns a
+// WorkoutService.
+func NewWorkoutLoggingService(logger logging.IshiLogger, s WorkoutService) WorkoutService {
+	return workoutLoggingService{
+		logger:  logger.WithFields("service", "workout"),
+		service: s,
+	}
+}
+
+// Create provides informative logging when requests are made to the create
+// endpoint.
+func (ls workoutLoggingService) Create(ctx context.Context, athleteID string, title string, performedAt time.Time, sets []WorkoutSet, blocks []WorkoutBlock) (Workout, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Create",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"athleteID", athleteID,
+			"title", title,
+			"sets", len(sets),
+			"blocks", len(blocks),
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Create(ctx, athleteID, title, performedAt, sets, blocks)
+}
+
+// Get provides informative logging when requests are made to the get
+// endpoint.
+func (ls workoutLoggingService) Get(ctx context.Context, athleteID string, id string) (Workout, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Get",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"athleteID", athleteID,
+			"id", id,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Get(ctx, athleteID, id)
+}
+
+// List provides informative logging when requests are made to the list
+// endpoint.
+func (ls workoutLoggingService) List(ctx context.Context, athleteID string) ([]Workout, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "List",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"athleteID", athleteID,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.List(ctx, athleteID)
+}
+
+// Delete provides informative logging when requests are made to the delete
+// endpoint.
+func (ls workoutLoggingService) Delete(ctx context.Context, athleteID string, id string) error {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "Delete",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"athleteID", athleteID,
+			"id", id,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.Delete(ctx, athleteID, id)
+}
+
+// ImportHistory provides informative logging when requests are made to the
+// import history endpoint.
+func (ls workoutLoggingService) ImportHistory(ctx context.Context, athleteID string, imp HistoryImport) (HistoryReport, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "ImportHistory",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"athleteID", athleteID,
+			"source", imp.Source,
+			"bytes", len(imp.Data),
+			"dryRun", imp.DryRun,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.ImportHistory(ctx, athleteID, imp)
+}
+
+// UploadActivity provides informative logging when requests are made to the
+// upload activity endpoint.
+func (ls workoutLoggingService) UploadActivity(ctx context.Context, athleteID string, upload ActivityUpload) (Workout, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "UploadActivity",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"athleteID", athleteID,
+			"format", upload.Format,
+			"movementID", upload.MovementID,
+			"bytes", len(upload.Data),
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.UploadActivity(ctx, athleteID, upload)
+}
+
+// RateSession provides informative logging when requests are made to the
+// rate session endpoint.
+func (ls workoutLoggingService) RateSession(ctx context.Context, athleteID string, id string, rpe float64, duration time.Duration) (Workout, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "RateSession",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"athleteID", athleteID,
+			"id", id,
+			"rpe", rpe,
+			"duration", duration,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.RateSession(ctx, athleteID, id, rpe, duration)
+}
+
+// CloneWorkout provides informative logging when requests are made to the
+// clone workout endpoint.
+func (ls workoutLoggingService) CloneWorkout(ctx context.Context, athleteID string, c WorkoutClone) (Workout, error) {
+	defer func(begin time.Time) {
+		ls.logger.Info(
+			method, "CloneWorkout",
+			requestContext, fmt.Sprintf("%+v", ctx),
+			"athleteID", athleteID,
+			"workoutID", c.WorkoutID,
+			"templateID", c.TemplateID,
+			"increment", c.Progression.Increment.Kilograms,
+			took, time.Since(begin),
+		)
+	}(time.Now())
+	return ls.service.CloneWorkout(ctx, athleteID, c)
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/logging"
+)
+
+// Workout represents a single training session performed by an athlete.
+// Strength work is recorded as Sets, grouped into supersets, circuits and
+// timed pieces by Blocks, and timed efforts, such as runs, rows or bike
+// intervals, as Conditioning. SessionRPE is the athlete's rating of the
+// whole session and Duration how long it lasted, both zero until the session
+// is rated. A Planned workout has not been performed yet and PerformedAt is
+// when it is planned for; planned workouts are left out of analytics and
+// personal records.
+type Workout struct {
+	Name         string         `json:"id"`
+	TenantID     string         `json:"tenantId"`
+	AthleteID    string         `json:"athleteId"`
+	Title        string         `json:"title"`
+	PerformedAt  time.Time      `json:"performedAt"`
+	Sets         []WorkoutSet   `json:"sets"`
+	Conditioning []Conditioning `json:"conditioning"`
+	SessionRPE   float64        `json:"sessionRpe"`
+	Duration     time.Duration  `json:"duration"`
+	Planned      bool           `json:"planned"`
+	Blocks       []WorkoutBlock `json:"blocks"`
+}
+
+// WorkoutSet is one set of a Movement performed within a Workout. RPE is
+// optional, with zero meaning unrecorded. Block is the label of the
+// WorkoutBlock the set belongs to, empty for straight sets outside any.
+type WorkoutSet struct {
+	MovementID string  `json:"movementId"`
+	Reps       int32   `json:"reps"`
+	Weight     Load    `json:"weight"`
+	RPE        float64 `json:"rpe"`
+	Block      string  `json:"block"`
+}
+
+// Conditioning is a continuous timed effort of a conditioning Movement within
+// a Workout, as a watch or ergometer records it. Duration excludes pauses,
+// Distance is in meters and heart rates are in beats per minute, with zero
+// meaning unrecorded. Sport is the activity the device recorded.
+type Conditioning struct {
+	MovementID   string        `json:"movementId"`
+	Sport        string        `json:"sport"`
+	StartTime    time.Time     `json:"startTime"`
+	Duration     time.Duration `json:"duration"`
+	Distance     float64       `json:"distance"`
+	AvgHeartRate int32         `json:"avgHeartRate"`
+	MaxHeartRate int32         `json:"maxHeartRate"`
+	Laps         []Lap         `json:"laps"`
+}
+
+// Lap is one lap or interval of a Conditioning effort, with the same units.
+type Lap struct {
+	StartTime    time.Time     `json:"startTime"`
+	Duration     time.Duration `json:"duration"`
+	Distance     float64       `json:"distance"`
+	AvgHeartRate int32         `json:"avgHeartRate"`
+	MaxHeartRate int32         `json:"maxHeartRate"`
+}
+
+// WorkoutRepository persists workouts along with their sets. Creating,
+// rating and deleting a workout adds its DomainEvent to the outbox in the
+// same transaction. RateWorkout writes only the session RPE and duration of
+// the given workout. ListPerformedWorkouts returns the workouts an athlete
+// performed, not planned, from from up to but excluding to, without their
+// sets, blocks or conditioning.
+type WorkoutRepository interface {
+	CreateWorkout(ctx context.Context, w Workout) (Workout, error)
+	CreateWorkouts(ctx context.Context, ws []Workout) ([]Workout, error)
+	GetWorkout(ctx context.Context, id string) (Workout, error)
+	ListWorkouts(ctx context.Context, tenantID string, athleteID string) ([]Workout, error)
+	ListPerformedWorkouts(ctx context.Context, tenantID string, athleteID string, from time.Time, to time.Time) ([]Workout, error)
+	RateWorkout(ctx context.Context, w Workout) error
+	DeleteWorkout(ctx context.Context, id string) error
+}
+
+// WorkoutService describes a service that deals with an athlete's workouts.
+// Every method is addressed by athlete so that authorization policies can
+// decide access before the service is invoked.
+type WorkoutService interface {
+	Create(ctx context.Context, athleteID string, title string, performedAt time.Time, sets []WorkoutSet, blocks []WorkoutBlock) (Workout, error)
+	Get(ctx context.Context, athleteID string, id string) (Workout, error)
+	List(ctx context.Context, athleteID string) ([]Workout, error)
+	Delete(ctx context.Context, athleteID string, id string) error
+	ImportHistory(ctx context.Context, athleteID string, imp HistoryImport) (HistoryReport, error)
+	UploadActivity(ctx context.Context, athleteID string, upload ActivityUpload) (Workout, error)
+	RateSession(ctx context.Context, athleteID string, id string, rpe float64, duration time.Duration) (Workout, error)
+	CloneWorkout(ctx context.Context, athleteID string, c WorkoutClone) (Workout, error)
+}
+
+// NewWorkoutService returns a basic WorkoutService with middleware wired in.
+func NewWorkoutService(logger logging.IshiLogger, workouts WorkoutRepository, movements MovementRepository, users UserRepository, templates TemplateRepository, audit AuditRepository, monitor WorkloadMonitor) WorkoutService {
+	var svc WorkoutService
+	{
+		svc = NewBasicWorkoutService(workouts, movements, users, templates)
+		svc = NewWorkloadMonitoringService(monitor, svc)
+		svc = NewWorkoutAuditService(logger, audit, svc)
+		svc = NewWorkoutLoggingService(logger, svc)
+	}
+	return svc
+}
+
+// NewBasicWorkoutService returns an implementation of WorkoutService backed
+// by the given repositories. Movements are read through a basic
+// MovementService so that imports resolve names against the same view of the
+// catalog the athlete sees. Templates are read to plan workouts from them.
+func NewBasicWorkoutService(workouts WorkoutRepository, movements MovementRepository, users UserRepository, templates TemplateRepository) WorkoutService {
+	return basicWorkoutService{
+		workouts:  workouts,
+		catalog:   NewBasicMovementService(movements, nil),
+		users:     users,
+		templates: templates,
+	}
+}
+
+type basicWorkoutService struct {
+	workouts  WorkoutRepository
+	catalog   MovementService
+	users     UserRepository
+	templates TemplateRepository
+}
+
+// Create records a new Workout for an athlete, with its sets grouped into
+// blocks.
+func (s basicWorkoutService) Create(ctx context.Context, athleteID string, title string, performedAt time.Time, sets []WorkoutSet, blocks []WorkoutBlock) (Workout, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return Workout{}, err
+	}
+	if err := s.checkAthlete(ctx, p, athleteID); err != nil {
+		return Workout{}, err
+	}
+	if err := checkSets(sets); err != nil {
+		return Workout{}, err
+	}
+	if err := checkBlocks(blocks, sets); err != nil {
+		return Workout{}, err
+	}
+	if performedAt.IsZero() {
+		performedAt = time.Now()
+	}
+	return s.workouts.CreateWorkout(ctx, Workout{
+		Name:        uuid.New().String(),
+		TenantID:    p.TenantID,
+		AthleteID:   athleteID,
+		Title:       title,
+		PerformedAt: performedAt.UTC(),
+		Sets:        sets,
+		Blocks:      blocks,
+	})
+}
+
+// checkSets validates the sets of a workout or template, taking weights
+// without a unit to be in kilograms.
+func checkSets(sets []WorkoutSet) error {
+	for i, set := range sets {
+		if set.MovementID == "" || set.Reps < 0 || set.Weight.Kilograms < 0 || set.RPE < 0 || set.RPE > 10 {
+			return errors.Wrapf(ErrInvalidArgument, "set %d is malformed", i)
+		}
+		if set.Weight.Unit == "" {
+			sets[i].Weight.Unit = Kilograms
+		} else if _, ok := PlateIncrements[set.Weight.Unit]; !ok {
+			return errors.Wrapf(ErrInvalidArgument, "set %d has unknown unit %q", i, set.Weight.Unit)
+		}
+	}
+	return nil
+}
+
+// checkAthlete fails unless athleteID is an athlete of the caller's tenant.
+func (s basicWorkoutService) checkAthlete(ctx context.Context, p Principal, athleteID string) error {
+	athlete, err := s.users.GetUser(ctx, athleteID)
+	if err != nil {
+		return errors.Wrap(err, "failed to look up athlete")
+	}
+	if athlete.TenantID != p.TenantID || athlete.Role != RoleAthlete {
+		return errors.Wrapf(ErrInvalidArgument, "user %s is not an athlete", athleteID)
+	}
+	return nil
+}
+
+// Get retrieves one of an athlete's workouts by its UUID.
+func (s basicWorkoutService) Get(ctx context.Context, athleteID string, id string) (Workout, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return Workout{}, err
+	}
+	w, err := s.workouts.GetWorkout(ctx, id)
+	if err != nil {
+		return Workout{}, err
+	}
+	if w.TenantID != p.TenantID || w.AthleteID != athleteID {
+		return Workout{}, ErrNotFound
+	}
+	return w, nil
+}
+
+// List retrieves all of an athlete's workouts, most recent first.
+func (s basicWorkoutService) List(ctx context.Context, athleteID string) ([]Workout, error) {
+	p, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.workouts.ListWorkouts(ctx, p.TenantID, athleteID)
+}
+
+// maxSessionDuration bounds the duration a session may be rated with.
+const maxSessionDuration = 24 * time.Hour
+
+// RateSession records how hard an athlete found a workout as a whole and how
+// long it lasted, which is usually done some time after it ended. Rating a
+// session again replaces the earlier rating, and a zero RPE or duration
+// clears it. Planned workouts cannot be rated.
+func (s basicWorkoutService) RateSession(ctx context.Context, athleteID string, id string, rpe float64, duration time.Duration) (Workout, error) {
+	if rpe < 0 || rpe > 10 {
+		return Workout{}, errors.Wrap(ErrInvalidArgument, "session RPE must be between 0 and 10")
+	}
+	if duration < 0 || duration > maxSessionDuration {
+		return Workout{}, errors.Wrapf(ErrInvalidArgument, "session duration must be between 0 and %s", maxSessionDuration)
+	}
+	w, err := s.Get(ctx, athleteID, id)
+	if err != nil {
+		return Workout{}, err
+	}
+	if w.Planned {
+		return Workout{}, errors.Wrap(ErrInvalidArgument, "a planned workout has not been performed yet")
+	}
+	w.SessionRPE = rpe
+	w.Duration = duration
+	if err := s.workouts.RateWorkout(ctx, w); err != nil {
+		return Workout{}, err
+	}
+	return w, nil
+}
+
+// Delete removes one of an athlete's workouts.
+func (s basicWorkoutService) Delete(ctx context.Context, athleteID string, id string) error {
+	if _, err := s.Get(ctx, athleteID, id); err != nil {
+		return err
+	}
+	return s.workouts.DeleteWorkout(ctx, id)
+}
@@ -0,0 +1,229 @@
+package transport
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/transport/grpc"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+
+	"workout-manager-service/pb"
+	"workout-manager-service/pkg/endpoint"
+	"workout-manager-service/pkg/service"
+)
+
+type analyticsGRPCServer struct {
+	getTrainingVolume        grpc.Handler
+	getWorkload              grpc.Handler
+	getWorkloadThresholds    grpc.Handler
+	updateWorkloadThresholds grpc.Handler
+}
+
+// NewAnalyticsGRPCServer makes a set of endpoints available as a gRPC
+// AnalyticsManagerServer.
+func NewAnalyticsGRPCServer(endpoints endpoint.AnalyticsSet) pb.AnalyticsManagerServer {
+	options := []grpc.ServerOption{grpc.ServerBefore(userIDToContext, correlationIDToContext, weightUnitToContext)}
+	return &analyticsGRPCServer{
+		getTrainingVolume: grpc.NewServer(
+			endpoints.VolumeEndpoint,
+			decodeGetTrainingVolumeRequest,
+			encodeGetTrainingVolumeResponse,
+			options...,
+		),
+		getWorkload: grpc.NewServer(
+			endpoints.WorkloadEndpoint,
+			decodeGetWorkloadRequest,
+			encodeGetWorkloadResponse,
+			options...,
+		),
+		getWorkloadThresholds: grpc.NewServer(
+			endpoints.GetThresholdsEndpoint,
+			decodeGetWorkloadThresholdsRequest,
+			encodeWorkloadThresholdsResponse,
+			options...,
+		),
+		updateWorkloadThresholds: grpc.NewServer(
+			endpoints.UpdateThresholdsEndpoint,
+			decodeUpdateWorkloadThresholdsRequest,
+			encodeWorkloadThresholdsResponse,
+			options...,
+		),
+	}
+}
+
+// GetTrainingVolume handles incoming gRPC requests to aggregate an athlete's
+// training volume.
+func (s *analyticsGRPCServer) GetTrainingVolume(ctx context.Context, req *pb.GetTrainingVolumeRequest) (*pb.GetTrainingVolumeResponse, error) {
+	_, res, err := s.getTrainingVolume.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.GetTrainingVolumeResponse), nil
+}
+
+func decodeGetTrainingVolumeRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.GetTrainingVolumeRequest)
+	start, end, err := timeRangepb2domain(request.GetStartTime(), request.GetEndTime())
+	if err != nil {
+		return nil, err
+	}
+	return endpoint.TrainingVolumeRequest{
+		AthleteID: request.GetAthleteId(),
+		Start:     start,
+		End:       end,
+		Bucket:    service.Bucket(request.GetBucket()),
+		GroupBy:   service.VolumeGrouping(request.GetGroupBy()),
+	}, nil
+}
+
+func encodeGetTrainingVolumeResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.TrainingVolumeResponse)
+	var pblist []*pb.VolumeAggregate
+	{
+		for _, a := range response.Data {
+			pblist = append(pblist, volumeaggregatedomain2pb(a, response.Unit))
+		}
+	}
+	return &pb.GetTrainingVolumeResponse{
+		Data: pblist,
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+func volumeaggregatedomain2pb(a service.VolumeAggregate, unit service.WeightUnit) *pb.VolumeAggregate {
+	bucketStart, _ := ptypes.TimestampProto(a.BucketStart)
+	return &pb.VolumeAggregate{
+		BucketStart:       bucketStart,
+		Group:             a.Group,
+		Sets:              a.Sets,
+		HardSets:          a.HardSets,
+		Reps:              a.Reps,
+		RelativeIntensity: a.RelativeIntensity,
+		Tonnage:           weightdomain2pb(a.Tonnage, unit),
+		AverageIntensity:  weightdomain2pb(a.AverageIntensity, unit),
+	}
+}
+
+// GetWorkload handles incoming gRPC requests to read an athlete's daily
+// workload.
+func (s *analyticsGRPCServer) GetWorkload(ctx context.Context, req *pb.GetWorkloadRequest) (*pb.GetWorkloadResponse, error) {
+	_, res, err := s.getWorkload.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.GetWorkloadResponse), nil
+}
+
+func decodeGetWorkloadRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.GetWorkloadRequest)
+	start, end, err := timeRangepb2domain(request.GetStartTime(), request.GetEndTime())
+	if err != nil {
+		return nil, err
+	}
+	return endpoint.WorkloadRequest{
+		AthleteID: request.GetAthleteId(),
+		Start:     start,
+		End:       end,
+	}, nil
+}
+
+func encodeGetWorkloadResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.WorkloadResponse)
+	var pblist []*pb.WorkloadDay
+	{
+		for _, d := range response.Data {
+			date, _ := ptypes.TimestampProto(d.Date)
+			pblist = append(pblist, &pb.WorkloadDay{
+				Date:        date,
+				Load:        d.Load,
+				AcuteLoad:   d.AcuteLoad,
+				ChronicLoad: d.ChronicLoad,
+				Acwr:        d.ACWR,
+				AcuteEwma:   d.AcuteEWMA,
+				ChronicEwma: d.ChronicEWMA,
+				EwmaAcwr:    d.EWMAACWR,
+				Monotony:    d.Monotony,
+				Strain:      d.Strain,
+			})
+		}
+	}
+	return &pb.GetWorkloadResponse{
+		Data: pblist,
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// GetWorkloadThresholds handles incoming gRPC requests to read the workload
+// thresholds of the caller's tenant.
+func (s *analyticsGRPCServer) GetWorkloadThresholds(ctx context.Context, req *pb.GetWorkloadThresholdsRequest) (*pb.WorkloadThresholdsResponse, error) {
+	_, res, err := s.getWorkloadThresholds.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.WorkloadThresholdsResponse), nil
+}
+
+func decodeGetWorkloadThresholdsRequest(_ context.Context, _ interface{}) (interface{}, error) {
+	return endpoint.GetWorkloadThresholdsRequest{}, nil
+}
+
+// UpdateWorkloadThresholds handles incoming gRPC requests to change the
+// workload thresholds of the caller's tenant.
+func (s *analyticsGRPCServer) UpdateWorkloadThresholds(ctx context.Context, req *pb.UpdateWorkloadThresholdsRequest) (*pb.WorkloadThresholdsResponse, error) {
+	_, res, err := s.updateWorkloadThresholds.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.WorkloadThresholdsResponse), nil
+}
+
+func decodeUpdateWorkloadThresholdsRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.UpdateWorkloadThresholdsRequest)
+	t := request.GetThresholds()
+	return endpoint.UpdateWorkloadThresholdsRequest{Thresholds: service.WorkloadThresholds{
+		MaxACWR:     t.GetMaxAcwr(),
+		MinACWR:     t.GetMinAcwr(),
+		MaxMonotony: t.GetMaxMonotony(),
+		MaxStrain:   t.GetMaxStrain(),
+	}}, nil
+}
+
+func encodeWorkloadThresholdsResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.WorkloadThresholdsResponse)
+	var updateTime *timestamp.Timestamp
+	if !response.Data.UpdateTime.IsZero() {
+		updateTime, _ = ptypes.TimestampProto(response.Data.UpdateTime)
+	}
+	return &pb.WorkloadThresholdsResponse{
+		Data: &pb.WorkloadThresholds{
+			MaxAcwr:     response.Data.MaxACWR,
+			MinAcwr:     response.Data.MinACWR,
+			MaxMonotony: response.Data.MaxMonotony,
+			MaxStrain:   response.Data.MaxStrain,
+			UpdateTime:  updateTime,
+		},
+		Err: err2str(response.Err),
+	}, nil
+}
+
+// timeRangepb2domain converts the optional bounds of a time range, leaving
+// missing ones zero.
+func timeRangepb2domain(startTime *timestamp.Timestamp, endTime *timestamp.Timestamp) (time.Time, time.Time, error) {
+	var start, end time.Time
+	if startTime != nil {
+		t, err := ptypes.Timestamp(startTime)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		start = t
+	}
+	if endTime != nil {
+		t, err := ptypes.Timestamp(endTime)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		end = t
+	}
+	return start, end, nil
+}
@@ -0,0 +1,95 @@
+package transport
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/transport/grpc"
+	"github.com/golang/protobuf/ptypes"
+
+	"workout-manager-service/pb"
+	"workout-manager-service/pkg/endpoint"
+	"workout-manager-service/pkg/service"
+)
+
+type auditGRPCServer struct {
+	listAuditEvents grpc.Handler
+}
+
+// NewAuditGRPCServer makes a set of endpoints available as a gRPC
+// AuditManagerServer.
+func NewAuditGRPCServer(endpoints endpoint.AuditSet) pb.AuditManagerServer {
+	options := []grpc.ServerOption{grpc.ServerBefore(userIDToContext, correlationIDToContext)}
+	return &auditGRPCServer{
+		listAuditEvents: grpc.NewServer(
+			endpoints.ListEndpoint,
+			decodeListAuditEventsRequest,
+			encodeListAuditEventsResponse,
+			options...,
+		),
+	}
+}
+
+// ListAuditEvents handles incoming gRPC requests to read the audit log of the
+// caller's tenant.
+func (s *auditGRPCServer) ListAuditEvents(ctx context.Context, req *pb.ListAuditEventsRequest) (*pb.ListAuditEventsResponse, error) {
+	_, res, err := s.listAuditEvents.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.ListAuditEventsResponse), nil
+}
+
+func decodeListAuditEventsRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.ListAuditEventsRequest)
+	var start, end time.Time
+	if request.GetStartTime() != nil {
+		t, err := ptypes.Timestamp(request.GetStartTime())
+		if err != nil {
+			return nil, err
+		}
+		start = t
+	}
+	if request.GetEndTime() != nil {
+		t, err := ptypes.Timestamp(request.GetEndTime())
+		if err != nil {
+			return nil, err
+		}
+		end = t
+	}
+	return endpoint.ListAuditEventsRequest{
+		Resource: request.GetResource(),
+		Start:    start,
+		End:      end,
+		Limit:    int(request.GetPageSize()),
+	}, nil
+}
+
+func encodeListAuditEventsResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.ListAuditEventsResponse)
+	var pblist []*pb.AuditEvent
+	{
+		for _, e := range response.Data {
+			pblist = append(pblist, auditeventdomain2pb(e))
+		}
+	}
+	return &pb.ListAuditEventsResponse{
+		Data: pblist,
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+func auditeventdomain2pb(e service.AuditEvent) *pb.AuditEvent {
+	eventTime, _ := ptypes.TimestampProto(e.Time)
+	return &pb.AuditEvent{
+		Name:          e.Name,
+		TenantId:      e.TenantID,
+		ActorId:       e.ActorID,
+		Method:        e.Method,
+		Resource:      e.Resource,
+		Before:        string(e.Before),
+		After:         string(e.After),
+		CorrelationId: e.CorrelationID,
+		Time:          eventTime,
+	}
+}
@@ -2,45 +2,194 @@ package transport
 
 import (
 	"context"
+	"math"
 
+	kitendpoint "github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/transport/grpc"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"workout-manager-service/pb"
 	"workout-manager-service/pkg/endpoint"
 	"workout-manager-service/pkg/service"
 )
 
+// userIDMetadataKey is the gRPC metadata key carrying the ID of the user
+// making a request. It is set by an authenticating proxy, and only trusted
+// when TrustedProxy lets the call through.
+const userIDMetadataKey = "x-user-id"
+
+// correlationIDMetadataKey is the gRPC metadata key carrying the ID that
+// correlates a request across services. One is generated when it is missing.
+const correlationIDMetadataKey = "x-correlation-id"
+
+// requestIDMetadataKey is the gRPC metadata key carrying a client-supplied ID
+// that makes a create request safe to retry.
+const requestIDMetadataKey = "x-request-id"
+
+// weightUnitMetadataKey is the gRPC metadata key carrying the weight unit,
+// kg or lb, a caller wants loads in when it is not their preferred one.
+const weightUnitMetadataKey = "x-weight-unit"
+
 type grpcServer struct {
-	createMovement grpc.Handler
-	getMovement    grpc.Handler
-	listMovements  grpc.Handler
-	deleteMovement grpc.Handler
+	createMovement   grpc.Handler
+	updateMovement   grpc.Handler
+	getMovement      grpc.Handler
+	listMovements    grpc.Handler
+	searchMovements  grpc.Handler
+	deleteMovement   grpc.Handler
+	undeleteMovement grpc.Handler
+	overrideMovement grpc.Handler
+	forkMovement     grpc.Handler
+	mergeMovements   grpc.Handler
+	batchCreate      grpc.Handler
+	batchGet         grpc.Handler
+	batchDelete      grpc.Handler
+	importMovements  kitendpoint.Endpoint
+	exportMovements  kitendpoint.Endpoint
+	watchMovements   kitendpoint.Endpoint
+	before           []grpc.ServerRequestFunc
+	createWorkout    grpc.Handler
+	getWorkout       grpc.Handler
+	listWorkouts     grpc.Handler
+	deleteWorkout    grpc.Handler
+	rateWorkout      grpc.Handler
+	cloneWorkout     grpc.Handler
+	importHistory    kitendpoint.Endpoint
+	uploadActivity   kitendpoint.Endpoint
 }
 
 // NewGRPCServer makes a set of endpoints available as a gRPC
 // WorkoutManagerServer.
-func NewGRPCServer(endpoints endpoint.MovementSet) pb.WorkoutManagerServer {
+func NewGRPCServer(movements endpoint.MovementSet, workouts endpoint.WorkoutSet) pb.WorkoutManagerServer {
+	before := []grpc.ServerRequestFunc{userIDToContext, correlationIDToContext, requestIDToContext, weightUnitToContext}
+	options := []grpc.ServerOption{grpc.ServerBefore(before...)}
 	return &grpcServer{
 		createMovement: grpc.NewServer(
-			endpoints.CreateEndpoint,
+			movements.CreateEndpoint,
 			decodeCreateMovementRequest,
 			encodeCreateMovementResponse,
+			options...,
+		),
+		updateMovement: grpc.NewServer(
+			movements.UpdateEndpoint,
+			decodeUpdateMovementRequest,
+			encodeUpdateMovementResponse,
+			options...,
 		),
 		getMovement: grpc.NewServer(
-			endpoints.GetEndpoint,
+			movements.GetEndpoint,
 			decodeGetMovementRequest,
 			encodeGetMovementResponse,
+			options...,
 		),
 		listMovements: grpc.NewServer(
-			endpoints.ListEndpoint,
+			movements.ListEndpoint,
 			decodeListMovementsRequest,
 			encodeListMovementsResponse,
+			options...,
+		),
+		searchMovements: grpc.NewServer(
+			movements.SearchEndpoint,
+			decodeSearchMovementsRequest,
+			encodeSearchMovementsResponse,
+			options...,
 		),
 		deleteMovement: grpc.NewServer(
-			endpoints.DeleteEndpoint,
+			movements.DeleteEndpoint,
 			decodeDeleteMovementRequest,
 			encodeDeleteMovementResponse,
+			options...,
+		),
+		undeleteMovement: grpc.NewServer(
+			movements.UndeleteEndpoint,
+			decodeUndeleteMovementRequest,
+			encodeUndeleteMovementResponse,
+			options...,
+		),
+		overrideMovement: grpc.NewServer(
+			movements.OverrideEndpoint,
+			decodeOverrideMovementRequest,
+			encodeOverrideMovementResponse,
+			options...,
+		),
+		forkMovement: grpc.NewServer(
+			movements.ForkEndpoint,
+			decodeForkMovementRequest,
+			encodeForkMovementResponse,
+			options...,
+		),
+		batchCreate: grpc.NewServer(
+			movements.BatchCreateEndpoint,
+			decodeBatchCreateMovementsRequest,
+			encodeBatchCreateMovementsResponse,
+			options...,
+		),
+		batchGet: grpc.NewServer(
+			movements.BatchGetEndpoint,
+			decodeBatchGetMovementsRequest,
+			encodeBatchGetMovementsResponse,
+			options...,
+		),
+		batchDelete: grpc.NewServer(
+			movements.BatchDeleteEndpoint,
+			decodeBatchDeleteMovementsRequest,
+			encodeBatchDeleteMovementsResponse,
+			options...,
+		),
+		mergeMovements: grpc.NewServer(
+			movements.MergeEndpoint,
+			decodeMergeMovementsRequest,
+			encodeMergeMovementsResponse,
+			options...,
 		),
+		createWorkout: grpc.NewServer(
+			workouts.CreateEndpoint,
+			decodeCreateWorkoutRequest,
+			encodeCreateWorkoutResponse,
+			options...,
+		),
+		getWorkout: grpc.NewServer(
+			workouts.GetEndpoint,
+			decodeGetWorkoutRequest,
+			encodeGetWorkoutResponse,
+			options...,
+		),
+		listWorkouts: grpc.NewServer(
+			workouts.ListEndpoint,
+			decodeListWorkoutsRequest,
+			encodeListWorkoutsResponse,
+			options...,
+		),
+		deleteWorkout: grpc.NewServer(
+			workouts.DeleteEndpoint,
+			decodeDeleteWorkoutRequest,
+			encodeDeleteWorkoutResponse,
+			options...,
+		),
+		rateWorkout: grpc.NewServer(
+			workouts.RateEndpoint,
+			decodeRateWorkoutSessionRequest,
+			encodeRateWorkoutSessionResponse,
+			options...,
+		),
+		cloneWorkout: grpc.NewServer(
+			workouts.CloneEndpoint,
+			decodeCloneWorkoutRequest,
+			encodeCloneWorkoutResponse,
+			options...,
+		),
+		importMovements: movements.ImportEndpoint,
+		exportMovements: movements.ExportEndpoint,
+		watchMovements:  movements.WatchEndpoint,
+		importHistory:   workouts.ImportEndpoint,
+		uploadActivity:  workouts.UploadEndpoint,
+		before:          before,
 	}
 }
 
@@ -48,30 +197,73 @@ func NewGRPCServer(endpoints endpoint.MovementSet) pb.WorkoutManagerServer {
 func (s *grpcServer) CreateMovement(ctx context.Context, req *pb.CreateMovementRequest) (*pb.CreateMovementResponse, error) {
 	_, res, err := s.createMovement.ServeGRPC(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, encodeError(err)
 	}
 	return res.(*pb.CreateMovementResponse), nil
 }
 
 func decodeCreateMovementRequest(_ context.Context, req interface{}) (interface{}, error) {
-	request := req.(*pb.CreateMovementRequest)
+	return createmovementpb2domain(req.(*pb.CreateMovementRequest)), nil
+}
+
+func createmovementpb2domain(request *pb.CreateMovementRequest) endpoint.CreateMovementRequest {
 	return endpoint.CreateMovementRequest{
+		RequestID:          request.GetRequestId(),
 		TenantID:           request.GetTenantId(),
 		MovementName:       request.GetMovementName(),
 		MovementCategoryID: request.GetMovementCategoryId(),
-	}, nil
+		Equipment:          equipmentpb2domain(request.GetEquipment()),
+		PrimaryMuscles:     musclespb2domain(request.GetPrimaryMuscles()),
+		SecondaryMuscles:   musclespb2domain(request.GetSecondaryMuscles()),
+		Laterality:         lateralitypb2domain(request.GetLaterality()),
+		LoadType:           loadtypepb2domain(request.GetLoadType()),
+		Links:              request.GetLinks(),
+		Aliases:            request.GetAliases(),
+	}
 }
 
 func encodeCreateMovementResponse(_ context.Context, res interface{}) (interface{}, error) {
 	response := res.(endpoint.CreateMovementResponse)
 	return &pb.CreateMovementResponse{
-		Data: &pb.Movement{
-			Name:               response.Data.Name,
-			TenantId:           response.Data.TenantID,
-			MovementName:       response.Data.MovementName,
-			MovementCategoryId: response.Data.MovementCategoryID,
-		},
-		Err: err2str(response.Err),
+		Data: movementdomain2pb(response.Data),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// UpdateMovement handles incoming gRPC requests to edit an existing movement.
+func (s *grpcServer) UpdateMovement(ctx context.Context, req *pb.UpdateMovementRequest) (*pb.UpdateMovementResponse, error) {
+	_, res, err := s.updateMovement.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.UpdateMovementResponse), nil
+}
+
+func decodeUpdateMovementRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.UpdateMovementRequest)
+	return endpoint.UpdateMovementRequest{
+		Name:               request.GetName(),
+		Etag:               request.GetEtag(),
+		MovementName:       request.GetMovementName(),
+		MovementCategoryID: request.GetMovementCategoryId(),
+		Equipment:          equipmentpb2domain(request.GetEquipment()),
+		PrimaryMuscles:     musclespb2domain(request.GetPrimaryMuscles()),
+		SecondaryMuscles:   musclespb2domain(request.GetSecondaryMuscles()),
+		Laterality:         lateralitypb2domain(request.GetLaterality()),
+		LoadType:           loadtypepb2domain(request.GetLoadType()),
+		Links:              request.GetLinks(),
+		Aliases:            request.GetAliases(),
+	}, nil
+}
+
+func encodeUpdateMovementResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.UpdateMovementResponse)
+	if err := concurrencyError(response.Err); err != nil {
+		return nil, err
+	}
+	return &pb.UpdateMovementResponse{
+		Data: movementdomain2pb(response.Data),
+		Err:  err2str(response.Err),
 	}, nil
 }
 
@@ -80,26 +272,24 @@ func encodeCreateMovementResponse(_ context.Context, res interface{}) (interface
 func (s *grpcServer) GetMovement(ctx context.Context, req *pb.GetMovementRequest) (*pb.GetMovementResponse, error) {
 	_, res, err := s.getMovement.ServeGRPC(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, encodeError(err)
 	}
 	return res.(*pb.GetMovementResponse), nil
 }
 
 func decodeGetMovementRequest(_ context.Context, req interface{}) (interface{}, error) {
 	request := req.(*pb.GetMovementRequest)
-	return endpoint.GetMovementRequest{Name: request.GetName()}, nil
+	return endpoint.GetMovementRequest{
+		Name:        request.GetName(),
+		ShowDeleted: request.GetShowDeleted(),
+	}, nil
 }
 
 func encodeGetMovementResponse(_ context.Context, res interface{}) (interface{}, error) {
 	response := res.(endpoint.GetMovementResponse)
 	return &pb.GetMovementResponse{
-		Data: &pb.Movement{
-			Name:               response.Data.Name,
-			TenantId:           response.Data.TenantID,
-			MovementName:       response.Data.MovementName,
-			MovementCategoryId: response.Data.MovementCategoryID,
-		},
-		Err: err2str(response.Err),
+		Data: movementdomain2pb(response.Data),
+		Err:  err2str(response.Err),
 	}, nil
 }
 
@@ -108,14 +298,21 @@ func encodeGetMovementResponse(_ context.Context, res interface{}) (interface{},
 func (s *grpcServer) ListMovements(ctx context.Context, req *pb.ListMovementsRequest) (*pb.ListMovementsResponse, error) {
 	_, res, err := s.listMovements.ServeGRPC(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, encodeError(err)
 	}
 	return res.(*pb.ListMovementsResponse), nil
 }
 
 func decodeListMovementsRequest(_ context.Context, req interface{}) (interface{}, error) {
 	request := req.(*pb.ListMovementsRequest)
-	return endpoint.ListMovementsRequest{CategoryName: request.GetCategoryName()}, nil
+	return endpoint.ListMovementsRequest{
+		CategoryName: request.GetCategoryName(),
+		Equipment:    equipmentpb2domain(request.GetEquipment()),
+		Muscle:       service.MuscleGroup(request.GetMuscle()),
+		Laterality:   lateralitypb2domain(request.GetLaterality()),
+		LoadType:     loadtypepb2domain(request.GetLoadType()),
+		ShowDeleted:  request.GetShowDeleted(),
+	}, nil
 }
 
 func encodeListMovementsResponse(_ context.Context, res interface{}) (interface{}, error) {
@@ -132,26 +329,366 @@ func encodeListMovementsResponse(_ context.Context, res interface{}) (interface{
 	}, nil
 }
 
+// SearchMovements handles incoming gRPC requests to find movements by name,
+// ranked by relevance.
+func (s *grpcServer) SearchMovements(ctx context.Context, req *pb.SearchMovementsRequest) (*pb.SearchMovementsResponse, error) {
+	_, res, err := s.searchMovements.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.SearchMovementsResponse), nil
+}
+
+func decodeSearchMovementsRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.SearchMovementsRequest)
+	return endpoint.SearchMovementsRequest{
+		Query: request.GetQuery(),
+		Limit: int(request.GetPageSize()),
+	}, nil
+}
+
+func encodeSearchMovementsResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.SearchMovementsResponse)
+	var pblist []*pb.MovementMatch
+	{
+		for _, m := range response.Data {
+			pblist = append(pblist, &pb.MovementMatch{
+				Movement: movementdomain2pb(m.Movement),
+				Score:    m.Score,
+			})
+		}
+	}
+	return &pb.SearchMovementsResponse{
+		Data: pblist,
+		Err:  err2str(response.Err),
+	}, nil
+}
+
 // DeleteMovement handles incoming gRPC requests to delete an existing
 // movement by its UUID.
 func (s *grpcServer) DeleteMovement(ctx context.Context, req *pb.DeleteMovementRequest) (*pb.DeleteMovementResponse, error) {
 	_, res, err := s.deleteMovement.ServeGRPC(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, encodeError(err)
 	}
 	return res.(*pb.DeleteMovementResponse), nil
 }
 
 func decodeDeleteMovementRequest(_ context.Context, req interface{}) (interface{}, error) {
 	request := req.(*pb.DeleteMovementRequest)
-	return endpoint.DeleteMovementRequest{Name: request.GetName()}, nil
+	return endpoint.DeleteMovementRequest{Name: request.GetName(), Etag: request.GetEtag()}, nil
 }
 
 func encodeDeleteMovementResponse(_ context.Context, res interface{}) (interface{}, error) {
 	response := res.(endpoint.DeleteMovementResponse)
+	if err := concurrencyError(response.Err); err != nil {
+		return nil, err
+	}
 	return &pb.DeleteMovementResponse{Err: err2str(response.Failed())}, nil
 }
 
+// UndeleteMovement handles incoming gRPC requests to restore a deleted
+// movement.
+func (s *grpcServer) UndeleteMovement(ctx context.Context, req *pb.UndeleteMovementRequest) (*pb.UndeleteMovementResponse, error) {
+	_, res, err := s.undeleteMovement.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.UndeleteMovementResponse), nil
+}
+
+func decodeUndeleteMovementRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.UndeleteMovementRequest)
+	return endpoint.UndeleteMovementRequest{Name: request.GetName()}, nil
+}
+
+func encodeUndeleteMovementResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.UndeleteMovementResponse)
+	return &pb.UndeleteMovementResponse{
+		Data: movementdomain2pb(response.Data),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// OverrideMovement handles incoming gRPC requests to hide or alias a global
+// movement for the caller's tenant.
+func (s *grpcServer) OverrideMovement(ctx context.Context, req *pb.OverrideMovementRequest) (*pb.OverrideMovementResponse, error) {
+	_, res, err := s.overrideMovement.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.OverrideMovementResponse), nil
+}
+
+func decodeOverrideMovementRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.OverrideMovementRequest)
+	return endpoint.OverrideMovementRequest{
+		Name:   request.GetName(),
+		Hidden: request.GetHidden(),
+		Alias:  request.GetAlias(),
+	}, nil
+}
+
+func encodeOverrideMovementResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.OverrideMovementResponse)
+	return &pb.OverrideMovementResponse{
+		Data: movementdomain2pb(response.Data),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// ForkMovement handles incoming gRPC requests to copy a global movement into
+// the caller's tenant.
+func (s *grpcServer) ForkMovement(ctx context.Context, req *pb.ForkMovementRequest) (*pb.ForkMovementResponse, error) {
+	_, res, err := s.forkMovement.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.ForkMovementResponse), nil
+}
+
+func decodeForkMovementRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.ForkMovementRequest)
+	return endpoint.ForkMovementRequest{Name: request.GetName()}, nil
+}
+
+func encodeForkMovementResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.ForkMovementResponse)
+	return &pb.ForkMovementResponse{
+		Data: movementdomain2pb(response.Data),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// MergeMovements handles incoming gRPC requests to fold duplicate movements
+// into a canonical one.
+func (s *grpcServer) MergeMovements(ctx context.Context, req *pb.MergeMovementsRequest) (*pb.MergeMovementsResponse, error) {
+	_, res, err := s.mergeMovements.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.MergeMovementsResponse), nil
+}
+
+func decodeMergeMovementsRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.MergeMovementsRequest)
+	return endpoint.MergeMovementsRequest{
+		CanonicalID:  request.GetCanonicalId(),
+		DuplicateIDs: request.GetDuplicateIds(),
+	}, nil
+}
+
+func encodeMergeMovementsResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.MergeMovementsResponse)
+	return &pb.MergeMovementsResponse{
+		Data: movementdomain2pb(response.Data),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// BatchCreateMovements handles incoming gRPC requests to create many
+// movements at once.
+func (s *grpcServer) BatchCreateMovements(ctx context.Context, req *pb.BatchCreateMovementsRequest) (*pb.BatchCreateMovementsResponse, error) {
+	_, res, err := s.batchCreate.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.BatchCreateMovementsResponse), nil
+}
+
+func decodeBatchCreateMovementsRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.BatchCreateMovementsRequest)
+	requests := make([]endpoint.CreateMovementRequest, len(request.GetRequests()))
+	for i, r := range request.GetRequests() {
+		requests[i] = createmovementpb2domain(r)
+	}
+	return endpoint.BatchCreateMovementsRequest{
+		RequestID: request.GetRequestId(),
+		Requests:  requests,
+		Partial:   request.GetPartial(),
+	}, nil
+}
+
+func encodeBatchCreateMovementsResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.BatchMovementsResponse)
+	return &pb.BatchCreateMovementsResponse{
+		Data: resultsdomain2pb(response.Data),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// BatchGetMovements handles incoming gRPC requests to retrieve many movements
+// at once.
+func (s *grpcServer) BatchGetMovements(ctx context.Context, req *pb.BatchGetMovementsRequest) (*pb.BatchGetMovementsResponse, error) {
+	_, res, err := s.batchGet.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.BatchGetMovementsResponse), nil
+}
+
+func decodeBatchGetMovementsRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.BatchGetMovementsRequest)
+	return endpoint.BatchGetMovementsRequest{
+		Names:       request.GetNames(),
+		ShowDeleted: request.GetShowDeleted(),
+		Partial:     request.GetPartial(),
+	}, nil
+}
+
+func encodeBatchGetMovementsResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.BatchMovementsResponse)
+	return &pb.BatchGetMovementsResponse{
+		Data: resultsdomain2pb(response.Data),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// BatchDeleteMovements handles incoming gRPC requests to delete many
+// movements at once.
+func (s *grpcServer) BatchDeleteMovements(ctx context.Context, req *pb.BatchDeleteMovementsRequest) (*pb.BatchDeleteMovementsResponse, error) {
+	_, res, err := s.batchDelete.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.BatchDeleteMovementsResponse), nil
+}
+
+func decodeBatchDeleteMovementsRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.BatchDeleteMovementsRequest)
+	requests := make([]endpoint.DeleteMovementRequest, len(request.GetRequests()))
+	for i, r := range request.GetRequests() {
+		requests[i] = endpoint.DeleteMovementRequest{Name: r.GetName(), Etag: r.GetEtag()}
+	}
+	return endpoint.BatchDeleteMovementsRequest{Requests: requests, Partial: request.GetPartial()}, nil
+}
+
+func encodeBatchDeleteMovementsResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.BatchMovementsResponse)
+	if err := concurrencyError(response.Err); err != nil {
+		return nil, err
+	}
+	return &pb.BatchDeleteMovementsResponse{
+		Data: resultsdomain2pb(response.Data),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+func resultsdomain2pb(results []service.MovementResult) []*pb.MovementResult {
+	pbResults := make([]*pb.MovementResult, len(results))
+	for i, r := range results {
+		if r.Err != nil {
+			pbResults[i] = &pb.MovementResult{Err: err2str(r.Err)}
+			continue
+		}
+		pbResults[i] = &pb.MovementResult{Movement: movementdomain2pb(r.Movement)}
+	}
+	return pbResults
+}
+
+// userIDToContext moves the caller's user ID from the incoming gRPC metadata
+// into the request context, where the endpoint middleware expects it.
+func userIDToContext(ctx context.Context, md metadata.MD) context.Context {
+	if ids := md.Get(userIDMetadataKey); len(ids) > 0 {
+		return context.WithValue(ctx, endpoint.UserIDContextKey, ids[0])
+	}
+	return ctx
+}
+
+// correlationIDToContext moves the request's correlation ID from the incoming
+// gRPC metadata into the request context, generating one if the caller didn't
+// send it.
+func correlationIDToContext(ctx context.Context, md metadata.MD) context.Context {
+	id := uuid.New().String()
+	if ids := md.Get(correlationIDMetadataKey); len(ids) > 0 && ids[0] != "" {
+		id = ids[0]
+	}
+	return service.NewContextWithCorrelationID(ctx, id)
+}
+
+// weightUnitToContext moves the weight unit a caller asked for from the
+// incoming gRPC metadata into the request context.
+func weightUnitToContext(ctx context.Context, md metadata.MD) context.Context {
+	if units := md.Get(weightUnitMetadataKey); len(units) > 0 {
+		return context.WithValue(ctx, endpoint.WeightUnitContextKey, units[0])
+	}
+	return ctx
+}
+
+// loaddomain2pb returns a lifted load in unit, rounded to plate increments
+// when it was entered in another.
+func loaddomain2pb(l service.Load, unit service.WeightUnit) *pb.Load {
+	return &pb.Load{
+		Value:     l.Loadable(unit),
+		Unit:      unitdomain2pb(unit),
+		Kilograms: l.Kilograms,
+	}
+}
+
+// weightdomain2pb returns a computed weight, such as a sum or an average, in
+// unit without rounding it to plates.
+func weightdomain2pb(kg float64, unit service.WeightUnit) *pb.Load {
+	return &pb.Load{
+		Value:     service.Kilos(kg).In(unit),
+		Unit:      unitdomain2pb(unit),
+		Kilograms: kg,
+	}
+}
+
+// loadpb2domain converts a load sent by a client. Loads in units this server
+// doesn't know, and amounts that aren't numbers, are invalid arguments.
+func loadpb2domain(l *pb.Load) (service.Load, error) {
+	unit := unitpb2domain(l.GetUnit())
+	if unit == "" && l.GetUnit() != pb.WeightUnit_WEIGHT_UNIT_UNSPECIFIED {
+		return service.Load{}, errors.Wrapf(service.ErrInvalidArgument, "unknown weight unit %d", l.GetUnit())
+	}
+	if math.IsNaN(l.GetValue()) || math.IsInf(l.GetValue(), 0) {
+		return service.Load{}, errors.Wrapf(service.ErrInvalidArgument, "load %g is not a number", l.GetValue())
+	}
+	return service.NewLoad(l.GetValue(), unit)
+}
+
+// requestIDToContext moves the client-supplied request ID from the incoming
+// gRPC metadata into the request context.
+func requestIDToContext(ctx context.Context, md metadata.MD) context.Context {
+	if ids := md.Get(requestIDMetadataKey); len(ids) > 0 {
+		return context.WithValue(ctx, endpoint.RequestIDContextKey, ids[0])
+	}
+	return ctx
+}
+
+// encodeError translates errors returned by endpoint middleware into gRPC
+// status errors so that clients receive a meaningful code.
+func encodeError(err error) error {
+	switch errors.Cause(err) {
+	case service.ErrUnauthenticated:
+		return status.Error(codes.Unauthenticated, err.Error())
+	case service.ErrPermissionDenied:
+		return status.Error(codes.PermissionDenied, err.Error())
+	case service.ErrNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case service.ErrInvalidArgument:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case service.ErrConflict:
+		return status.Error(codes.Aborted, err.Error())
+	case service.ErrAlreadyExists:
+		return status.Error(codes.AlreadyExists, err.Error())
+	case service.ErrExpired:
+		return status.Error(codes.OutOfRange, err.Error())
+	}
+	return err
+}
+
+// concurrencyError returns err when it reports a concurrent modification.
+// Such errors are raised as an ABORTED status rather than reported in the
+// response body, so that clients can tell a stale etag from other failures.
+func concurrencyError(err error) error {
+	if errors.Cause(err) == service.ErrConflict {
+		return err
+	}
+	return nil
+}
+
 func err2str(err error) string {
 	if err == nil {
 		return ""
@@ -160,10 +697,107 @@ func err2str(err error) string {
 }
 
 func movementdomain2pb(mvm service.Movement) *pb.Movement {
+	var deleteTime *timestamp.Timestamp
+	if mvm.Deleted() {
+		deleteTime, _ = ptypes.TimestampProto(mvm.DeleteTime)
+	}
 	return &pb.Movement{
 		Name:               mvm.Name,
 		TenantId:           mvm.TenantID,
 		MovementName:       mvm.MovementName,
 		MovementCategoryId: mvm.MovementCategoryID,
+		ForkedFrom:         mvm.ForkedFrom,
+		Hidden:             mvm.Hidden,
+		Equipment:          equipmentdomain2pb(mvm.Equipment),
+		PrimaryMuscles:     musclesdomain2pb(mvm.PrimaryMuscles),
+		SecondaryMuscles:   musclesdomain2pb(mvm.SecondaryMuscles),
+		Laterality:         lateralitydomain2pb(mvm.Laterality),
+		LoadType:           loadtypedomain2pb(mvm.LoadType),
+		Links:              mvm.Links,
+		Aliases:            mvm.Aliases,
+		DeleteTime:         deleteTime,
+		Etag:               mvm.Etag(),
+	}
+}
+
+var equipment = map[service.Equipment]pb.Equipment{
+	service.Barbell:    pb.Equipment_EQUIPMENT_BARBELL,
+	service.Dumbbell:   pb.Equipment_EQUIPMENT_DUMBBELL,
+	service.Kettlebell: pb.Equipment_EQUIPMENT_KETTLEBELL,
+	service.Machine:    pb.Equipment_EQUIPMENT_MACHINE,
+	service.Cable:      pb.Equipment_EQUIPMENT_CABLE,
+	service.Band:       pb.Equipment_EQUIPMENT_BAND,
+	service.Bodyweight: pb.Equipment_EQUIPMENT_BODYWEIGHT,
+	service.Ergometer:  pb.Equipment_EQUIPMENT_ERGOMETER,
+	service.OtherGear:  pb.Equipment_EQUIPMENT_OTHER,
+}
+
+func equipmentpb2domain(e pb.Equipment) service.Equipment {
+	for domain, p := range equipment {
+		if p == e {
+			return domain
+		}
 	}
+	return ""
+}
+
+func equipmentdomain2pb(e service.Equipment) pb.Equipment {
+	return equipment[e]
+}
+
+func musclespb2domain(muscles []string) []service.MuscleGroup {
+	var groups []service.MuscleGroup
+	for _, m := range muscles {
+		groups = append(groups, service.MuscleGroup(m))
+	}
+	return groups
+}
+
+func musclesdomain2pb(groups []service.MuscleGroup) []string {
+	var muscles []string
+	for _, g := range groups {
+		muscles = append(muscles, string(g))
+	}
+	return muscles
+}
+
+func lateralitypb2domain(l pb.Laterality) service.Laterality {
+	switch l {
+	case pb.Laterality_LATERALITY_BILATERAL:
+		return service.Bilateral
+	case pb.Laterality_LATERALITY_UNILATERAL:
+		return service.Unilateral
+	}
+	return ""
+}
+
+func lateralitydomain2pb(l service.Laterality) pb.Laterality {
+	switch l {
+	case service.Bilateral:
+		return pb.Laterality_LATERALITY_BILATERAL
+	case service.Unilateral:
+		return pb.Laterality_LATERALITY_UNILATERAL
+	}
+	return pb.Laterality_LATERALITY_UNSPECIFIED
+}
+
+var loadTypes = map[service.LoadType]pb.LoadType{
+	service.ExternalLoad:   pb.LoadType_LOAD_TYPE_EXTERNAL,
+	service.BodyweightLoad: pb.LoadType_LOAD_TYPE_BODYWEIGHT,
+	service.AssistedLoad:   pb.LoadType_LOAD_TYPE_ASSISTED,
+	service.TimeLoad:       pb.LoadType_LOAD_TYPE_TIME,
+	service.DistanceLoad:   pb.LoadType_LOAD_TYPE_DISTANCE,
+}
+
+func loadtypepb2domain(l pb.LoadType) service.LoadType {
+	for domain, p := range loadTypes {
+		if p == l {
+			return domain
+		}
+	}
+	return ""
+}
+
+func loadtypedomain2pb(l service.LoadType) pb.LoadType {
+	return loadTypes[l]
 }
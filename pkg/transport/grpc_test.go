@@ -0,0 +1,94 @@
+package transport
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"workout-manager-service/pb"
+	"workout-manager-service/pkg/endpoint"
+	"workout-manager-service/pkg/service"
+)
+
+func TestLoadpb2domain(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		load *pb.Load
+		kg   float64
+		unit service.WeightUnit
+		ok   bool
+	}{
+		{"unspecified is kilograms", &pb.Load{Value: 100}, 100, service.Kilograms, true},
+		{"kilograms", &pb.Load{Value: 100, Unit: pb.WeightUnit_WEIGHT_UNIT_KG}, 100, service.Kilograms, true},
+		{"pounds", &pb.Load{Value: 225, Unit: pb.WeightUnit_WEIGHT_UNIT_LB}, 225 * service.KilogramsPerPound, service.Pounds, true},
+		{"missing", nil, 0, service.Kilograms, true},
+		{"unknown unit", &pb.Load{Value: 100, Unit: pb.WeightUnit(7)}, 0, "", false},
+		{"not a number", &pb.Load{Value: math.NaN()}, 0, "", false},
+		{"infinite", &pb.Load{Value: math.Inf(1), Unit: pb.WeightUnit_WEIGHT_UNIT_LB}, 0, "", false},
+	} {
+		got, err := loadpb2domain(tc.load)
+		if !tc.ok {
+			if code := status.Code(encodeError(err)); code != codes.InvalidArgument {
+				t.Errorf("%s: loadpb2domain() = %v, want %s", tc.name, err, codes.InvalidArgument)
+			}
+			continue
+		}
+		if err != nil || math.Abs(got.Kilograms-tc.kg) > 1e-9 || got.Unit != tc.unit {
+			t.Errorf("%s: loadpb2domain() = %+v, %v, want %g kg in %s", tc.name, got, err, tc.kg, tc.unit)
+		}
+	}
+}
+
+func TestDecodeRejectsUnknownUnits(t *testing.T) {
+	bad := &pb.Load{Value: 100, Unit: pb.WeightUnit(7)}
+	for name, decode := range map[string]func() error{
+		"CalculatePlates": func() error {
+			_, err := decodeCalculatePlatesRequest(context.Background(), &pb.CalculatePlatesRequest{Target: &pb.Load{Value: 100}, Bar: bad})
+			return err
+		},
+		"CreateWorkout": func() error {
+			_, err := decodeCreateWorkoutRequest(context.Background(), &pb.CreateWorkoutRequest{Sets: []*pb.WorkoutSet{{Reps: 5, Weight: bad}}})
+			return err
+		},
+		"RecordBodyMetric": func() error {
+			_, err := decodeRecordBodyMetricRequest(context.Background(), &pb.RecordBodyMetricRequest{Metric: &pb.BodyMetric{Measurement: &pb.BodyMetric_Bodyweight{Bodyweight: bad}}})
+			return err
+		},
+	} {
+		if code := status.Code(encodeError(decode())); code != codes.InvalidArgument {
+			t.Errorf("%s with an unknown unit = %s, want %s", name, code, codes.InvalidArgument)
+		}
+	}
+}
+
+func TestBodyMetricBodyweightIsALoad(t *testing.T) {
+	req, err := decodeRecordBodyMetricRequest(context.Background(), &pb.RecordBodyMetricRequest{Metric: &pb.BodyMetric{
+		Measurement: &pb.BodyMetric_Bodyweight{Bodyweight: &pb.Load{Value: 180, Unit: pb.WeightUnit_WEIGHT_UNIT_LB}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := req.(endpoint.RecordBodyMetricRequest).Metric
+	if m.Type != service.BodyweightMetric || m.Unit != service.UnitKilograms || math.Abs(m.Value-180*service.KilogramsPerPound) > 1e-9 {
+		t.Errorf("decoded %+v, want 180 lb of bodyweight in kilograms", m)
+	}
+	_, err = decodeRecordBodyMetricRequest(context.Background(), &pb.RecordBodyMetricRequest{Metric: &pb.BodyMetric{
+		Type:        string(service.SleepMetric),
+		Measurement: &pb.BodyMetric_Bodyweight{Bodyweight: &pb.Load{Value: 80}},
+	}})
+	if code := status.Code(encodeError(err)); code != codes.InvalidArgument {
+		t.Errorf("sleep recorded as a load = %s, want %s", code, codes.InvalidArgument)
+	}
+
+	bodyweight := bodymetricdomain2pb(service.BodyMetric{Type: service.BodyweightMetric, Value: 180, Unit: service.UnitPounds})
+	if w := bodyweight.GetBodyweight(); w.GetValue() != 180 || w.GetUnit() != pb.WeightUnit_WEIGHT_UNIT_LB || math.Abs(w.GetKilograms()-180*service.KilogramsPerPound) > 1e-9 || bodyweight.GetUnit() != "" {
+		t.Errorf("encoded %+v, want 180 lb as a load", bodyweight)
+	}
+	sleep := bodymetricdomain2pb(service.BodyMetric{Type: service.SleepMetric, Value: 7.5, Unit: service.UnitHours})
+	if sleep.GetValue() != 7.5 || sleep.GetUnit() != "h" || sleep.GetBodyweight() != nil {
+		t.Errorf("encoded %+v, want 7.5 h", sleep)
+	}
+}
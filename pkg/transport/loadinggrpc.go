@@ -0,0 +1,319 @@
+package transport
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/transport/grpc"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+
+	"workout-manager-service/pb"
+	"workout-manager-service/pkg/endpoint"
+	"workout-manager-service/pkg/service"
+)
+
+type loadingGRPCServer struct {
+	calculatePlates      grpc.Handler
+	generateWarmups      grpc.Handler
+	getPlateInventory    grpc.Handler
+	updatePlateInventory grpc.Handler
+	suggestNextLoad      grpc.Handler
+}
+
+// NewLoadingGRPCServer makes a set of endpoints available as a gRPC
+// LoadingManagerServer.
+func NewLoadingGRPCServer(endpoints endpoint.LoadingSet) pb.LoadingManagerServer {
+	options := []grpc.ServerOption{grpc.ServerBefore(userIDToContext, correlationIDToContext, weightUnitToContext)}
+	return &loadingGRPCServer{
+		calculatePlates: grpc.NewServer(
+			endpoints.CalculatePlatesEndpoint,
+			decodeCalculatePlatesRequest,
+			encodeCalculatePlatesResponse,
+			options...,
+		),
+		generateWarmups: grpc.NewServer(
+			endpoints.GenerateWarmupsEndpoint,
+			decodeGenerateWarmupsRequest,
+			encodeGenerateWarmupsResponse,
+			options...,
+		),
+		getPlateInventory: grpc.NewServer(
+			endpoints.GetInventoryEndpoint,
+			decodeGetPlateInventoryRequest,
+			encodePlateInventoryResponse,
+			options...,
+		),
+		updatePlateInventory: grpc.NewServer(
+			endpoints.UpdateInventoryEndpoint,
+			decodeUpdatePlateInventoryRequest,
+			encodePlateInventoryResponse,
+			options...,
+		),
+		suggestNextLoad: grpc.NewServer(
+			endpoints.SuggestNextLoadEndpoint,
+			decodeSuggestNextLoadRequest,
+			encodeSuggestNextLoadResponse,
+			options...,
+		),
+	}
+}
+
+// CalculatePlates handles incoming gRPC requests to work out the plates for a
+// target load.
+func (s *loadingGRPCServer) CalculatePlates(ctx context.Context, req *pb.CalculatePlatesRequest) (*pb.CalculatePlatesResponse, error) {
+	_, res, err := s.calculatePlates.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.CalculatePlatesResponse), nil
+}
+
+func decodeCalculatePlatesRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.CalculatePlatesRequest)
+	target, err := loadpb2domain(request.GetTarget())
+	if err != nil {
+		return nil, err
+	}
+	bar, err := optionalloadpb2domain(request.GetBar())
+	if err != nil {
+		return nil, err
+	}
+	return endpoint.CalculatePlatesRequest{Query: service.PlateQuery{
+		Target: target,
+		Bar:    bar,
+	}}, nil
+}
+
+func encodeCalculatePlatesResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.CalculatePlatesResponse)
+	l := response.Data
+	return &pb.CalculatePlatesResponse{
+		Data: &pb.PlateLoading{
+			Target:  loaddomain2pb(l.Target, l.Target.Unit),
+			Bar:     loaddomain2pb(l.Bar, l.Bar.Unit),
+			Total:   loaddomain2pb(l.Total, l.Total.Unit),
+			Exact:   l.Exact,
+			PerSide: platecountsdomain2pb(l.PerSide),
+		},
+		Err: err2str(response.Err),
+	}, nil
+}
+
+// GenerateWarmups handles incoming gRPC requests to build the warm-up ladder
+// to a working set.
+func (s *loadingGRPCServer) GenerateWarmups(ctx context.Context, req *pb.GenerateWarmupsRequest) (*pb.GenerateWarmupsResponse, error) {
+	_, res, err := s.generateWarmups.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.GenerateWarmupsResponse), nil
+}
+
+func decodeGenerateWarmupsRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.GenerateWarmupsRequest)
+	working, err := loadpb2domain(request.GetWorkingWeight())
+	if err != nil {
+		return nil, err
+	}
+	bar, err := optionalloadpb2domain(request.GetBar())
+	if err != nil {
+		return nil, err
+	}
+	return endpoint.GenerateWarmupsRequest{Query: service.WarmupQuery{
+		MovementID:    request.GetMovementId(),
+		WorkingWeight: working,
+		WorkingReps:   request.GetWorkingReps(),
+		Bar:           bar,
+	}}, nil
+}
+
+func encodeGenerateWarmupsResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.GenerateWarmupsResponse)
+	ladder := response.Data
+	var sets []*pb.WarmupSet
+	{
+		for _, s := range ladder.Sets {
+			sets = append(sets, &pb.WarmupSet{
+				Weight:  loaddomain2pb(s.Weight, s.Weight.Unit),
+				Reps:    s.Reps,
+				Percent: s.Percent,
+				Plates:  platecountsdomain2pb(s.Plates),
+			})
+		}
+	}
+	return &pb.GenerateWarmupsResponse{
+		Data: &pb.WarmupLadder{
+			MovementId:    ladder.MovementID,
+			Equipment:     string(ladder.Equipment),
+			WorkingWeight: loaddomain2pb(ladder.WorkingWeight, ladder.WorkingWeight.Unit),
+			WorkingReps:   ladder.WorkingReps,
+			Bar:           loaddomain2pb(ladder.Bar, ladder.Bar.Unit),
+			Sets:          sets,
+		},
+		Err: err2str(response.Err),
+	}, nil
+}
+
+// GetPlateInventory handles incoming gRPC requests to read the plate
+// inventory of the caller's tenant.
+func (s *loadingGRPCServer) GetPlateInventory(ctx context.Context, req *pb.GetPlateInventoryRequest) (*pb.PlateInventoryResponse, error) {
+	_, res, err := s.getPlateInventory.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.PlateInventoryResponse), nil
+}
+
+func decodeGetPlateInventoryRequest(_ context.Context, _ interface{}) (interface{}, error) {
+	return endpoint.GetPlateInventoryRequest{}, nil
+}
+
+// UpdatePlateInventory handles incoming gRPC requests to replace the plate
+// inventory of the caller's tenant.
+func (s *loadingGRPCServer) UpdatePlateInventory(ctx context.Context, req *pb.UpdatePlateInventoryRequest) (*pb.PlateInventoryResponse, error) {
+	_, res, err := s.updatePlateInventory.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.PlateInventoryResponse), nil
+}
+
+func decodeUpdatePlateInventoryRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.UpdatePlateInventoryRequest)
+	inv := request.GetInventory()
+	var plates []service.Plate
+	for _, p := range inv.GetPlates() {
+		weight, err := loadpb2domain(p.GetWeight())
+		if err != nil {
+			return nil, err
+		}
+		plates = append(plates, service.Plate{
+			Weight: weight,
+			Pairs:  p.GetPairs(),
+		})
+	}
+	bar, err := loadpb2domain(inv.GetBar())
+	if err != nil {
+		return nil, err
+	}
+	return endpoint.UpdatePlateInventoryRequest{Inventory: service.PlateInventory{
+		Bar:    bar,
+		Plates: plates,
+	}}, nil
+}
+
+func encodePlateInventoryResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.PlateInventoryResponse)
+	inv := response.Data
+	var plates []*pb.Plate
+	{
+		for _, p := range inv.Plates {
+			plates = append(plates, &pb.Plate{
+				Weight: loaddomain2pb(p.Weight, p.Weight.Unit),
+				Pairs:  p.Pairs,
+			})
+		}
+	}
+	var updateTime *timestamp.Timestamp
+	if !inv.UpdateTime.IsZero() {
+		updateTime, _ = ptypes.TimestampProto(inv.UpdateTime)
+	}
+	return &pb.PlateInventoryResponse{
+		Data: &pb.PlateInventory{
+			Bar:        loaddomain2pb(inv.Bar, inv.Bar.Unit),
+			Plates:     plates,
+			UpdateTime: updateTime,
+		},
+		Err: err2str(response.Err),
+	}, nil
+}
+
+// SuggestNextLoad handles incoming gRPC requests to propose the working load
+// of an athlete's next session of a movement.
+func (s *loadingGRPCServer) SuggestNextLoad(ctx context.Context, req *pb.SuggestNextLoadRequest) (*pb.SuggestNextLoadResponse, error) {
+	_, res, err := s.suggestNextLoad.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.SuggestNextLoadResponse), nil
+}
+
+func decodeSuggestNextLoadRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.SuggestNextLoadRequest)
+	rule := request.GetRule()
+	q := service.ProgressionQuery{
+		MovementID: request.GetMovementId(),
+		TargetReps: request.GetTargetReps(),
+		TargetRPE:  request.GetTargetRpe(),
+		Rule: service.ProgressionRule{
+			Scheme:  service.ProgressionScheme(rule.GetScheme()),
+			MinReps: rule.GetMinReps(),
+			MaxReps: rule.GetMaxReps(),
+			Deload:  rule.GetDeload(),
+		},
+	}
+	if rule.GetIncrement() != nil {
+		increment, err := loadpb2domain(rule.GetIncrement())
+		if err != nil {
+			return nil, err
+		}
+		q.Rule.Increment = increment
+	}
+	return endpoint.SuggestNextLoadRequest{
+		AthleteID: request.GetAthleteId(),
+		Query:     q,
+	}, nil
+}
+
+func encodeSuggestNextLoadResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.SuggestNextLoadResponse)
+	s := response.Data
+	var history []*pb.OneRepMaxEstimate
+	{
+		for _, e := range s.History {
+			performedAt, _ := ptypes.TimestampProto(e.PerformedAt)
+			history = append(history, &pb.OneRepMaxEstimate{
+				WorkoutId:    e.WorkoutID,
+				PerformedAt:  performedAt,
+				EstimatedMax: weightdomain2pb(e.EstimatedMax.Kilograms, response.Unit),
+			})
+		}
+	}
+	return &pb.SuggestNextLoadResponse{
+		Data: &pb.LoadSuggestion{
+			MovementId:   s.MovementID,
+			Scheme:       string(s.Scheme),
+			Weight:       loaddomain2pb(s.Weight, response.Unit),
+			Reps:         s.Reps,
+			Rpe:          s.RPE,
+			EstimatedMax: weightdomain2pb(s.EstimatedMax.Kilograms, response.Unit),
+			History:      history,
+			Explanations: s.Explanations,
+		},
+		Err: err2str(response.Err),
+	}, nil
+}
+
+func platecountsdomain2pb(counts []service.PlateCount) []*pb.PlateCount {
+	var pblist []*pb.PlateCount
+	for _, c := range counts {
+		pblist = append(pblist, &pb.PlateCount{
+			Weight: loaddomain2pb(c.Weight, c.Weight.Unit),
+			Count:  c.Count,
+		})
+	}
+	return pblist
+}
+
+// optionalloadpb2domain converts a load a request may leave out, returning
+// nil when it does.
+func optionalloadpb2domain(l *pb.Load) (*service.Load, error) {
+	if l == nil {
+		return nil, nil
+	}
+	load, err := loadpb2domain(l)
+	if err != nil {
+		return nil, err
+	}
+	return &load, nil
+}
@@ -0,0 +1,338 @@
+package transport
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/transport/grpc"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pb"
+	"workout-manager-service/pkg/endpoint"
+	"workout-manager-service/pkg/service"
+)
+
+type metricsGRPCServer struct {
+	recordBodyMetric    grpc.Handler
+	listBodyMetrics     grpc.Handler
+	deleteBodyMetric    grpc.Handler
+	getMetricTrend      grpc.Handler
+	getRelativeStrength grpc.Handler
+}
+
+// NewMetricsGRPCServer makes a set of endpoints available as a gRPC
+// MetricsManagerServer.
+func NewMetricsGRPCServer(endpoints endpoint.MetricsSet) pb.MetricsManagerServer {
+	options := []grpc.ServerOption{grpc.ServerBefore(userIDToContext, correlationIDToContext, weightUnitToContext)}
+	return &metricsGRPCServer{
+		recordBodyMetric: grpc.NewServer(
+			endpoints.RecordEndpoint,
+			decodeRecordBodyMetricRequest,
+			encodeBodyMetricResponse,
+			options...,
+		),
+		listBodyMetrics: grpc.NewServer(
+			endpoints.ListEndpoint,
+			decodeListBodyMetricsRequest,
+			encodeListBodyMetricsResponse,
+			options...,
+		),
+		deleteBodyMetric: grpc.NewServer(
+			endpoints.DeleteEndpoint,
+			decodeDeleteBodyMetricRequest,
+			encodeDeleteBodyMetricResponse,
+			options...,
+		),
+		getMetricTrend: grpc.NewServer(
+			endpoints.TrendEndpoint,
+			decodeGetMetricTrendRequest,
+			encodeGetMetricTrendResponse,
+			options...,
+		),
+		getRelativeStrength: grpc.NewServer(
+			endpoints.RelativeStrengthEndpoint,
+			decodeGetRelativeStrengthRequest,
+			encodeGetRelativeStrengthResponse,
+			options...,
+		),
+	}
+}
+
+// RecordBodyMetric handles incoming gRPC requests to record a measurement of
+// an athlete.
+func (s *metricsGRPCServer) RecordBodyMetric(ctx context.Context, req *pb.RecordBodyMetricRequest) (*pb.BodyMetricResponse, error) {
+	_, res, err := s.recordBodyMetric.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.BodyMetricResponse), nil
+}
+
+func decodeRecordBodyMetricRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.RecordBodyMetricRequest)
+	m := request.GetMetric()
+	metric := service.BodyMetric{
+		Type:  service.BodyMetricType(m.GetType()),
+		Site:  m.GetSite(),
+		Value: m.GetValue(),
+		Unit:  service.MeasurementUnit(m.GetUnit()),
+	}
+	if m.GetBodyweight() != nil {
+		if metric.Type == "" {
+			metric.Type = service.BodyweightMetric
+		}
+		if metric.Type != service.BodyweightMetric {
+			return nil, errors.Wrapf(service.ErrInvalidArgument, "%s is not a bodyweight", metric.Type)
+		}
+		weight, err := loadpb2domain(m.GetBodyweight())
+		if err != nil {
+			return nil, err
+		}
+		metric.Value, metric.Unit = weight.Kilograms, service.UnitKilograms
+	}
+	if a := m.GetReadiness(); a != nil {
+		metric.Readiness = &service.ReadinessAnswers{
+			SleepQuality: a.GetSleepQuality(),
+			Fatigue:      a.GetFatigue(),
+			Soreness:     a.GetSoreness(),
+			Stress:       a.GetStress(),
+			Mood:         a.GetMood(),
+		}
+	}
+	if m.GetMeasuredAt() != nil {
+		t, err := ptypes.Timestamp(m.GetMeasuredAt())
+		if err != nil {
+			return nil, err
+		}
+		metric.MeasuredAt = t
+	}
+	return endpoint.RecordBodyMetricRequest{
+		RequestID: request.GetRequestId(),
+		AthleteID: request.GetAthleteId(),
+		Metric:    metric,
+	}, nil
+}
+
+func encodeBodyMetricResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.BodyMetricResponse)
+	return &pb.BodyMetricResponse{
+		Data: bodymetricdomain2pb(response.Data),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+func bodymetricdomain2pb(m service.BodyMetric) *pb.BodyMetric {
+	measuredAt, _ := ptypes.TimestampProto(m.MeasuredAt)
+	createTime, _ := ptypes.TimestampProto(m.CreateTime)
+	metric := &pb.BodyMetric{
+		Name:       m.Name,
+		AthleteId:  m.AthleteID,
+		Type:       string(m.Type),
+		Site:       m.Site,
+		MeasuredAt: measuredAt,
+		CreateTime: createTime,
+	}
+	if m.Type == service.BodyweightMetric {
+		metric.Measurement = &pb.BodyMetric_Bodyweight{Bodyweight: bodyweightdomain2pb(m)}
+	} else {
+		metric.Measurement = &pb.BodyMetric_Value{Value: m.Value}
+		metric.Unit = string(m.Unit)
+	}
+	if a := m.Readiness; a != nil {
+		metric.Readiness = &pb.ReadinessAnswers{
+			SleepQuality: a.SleepQuality,
+			Fatigue:      a.Fatigue,
+			Soreness:     a.Soreness,
+			Stress:       a.Stress,
+			Mood:         a.Mood,
+		}
+	}
+	return metric
+}
+
+// bodyweightdomain2pb returns a bodyweight in the unit the service gave it
+// in, without rounding it to plates.
+func bodyweightdomain2pb(m service.BodyMetric) *pb.Load {
+	unit := service.WeightUnit(m.Unit)
+	weight, _ := service.NewLoad(m.Value, unit)
+	return &pb.Load{
+		Value:     m.Value,
+		Unit:      unitdomain2pb(unit),
+		Kilograms: weight.Kilograms,
+	}
+}
+
+// ListBodyMetrics handles incoming gRPC requests to list an athlete's
+// measurements.
+func (s *metricsGRPCServer) ListBodyMetrics(ctx context.Context, req *pb.ListBodyMetricsRequest) (*pb.ListBodyMetricsResponse, error) {
+	_, res, err := s.listBodyMetrics.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.ListBodyMetricsResponse), nil
+}
+
+func decodeListBodyMetricsRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.ListBodyMetricsRequest)
+	start, end, err := timeRangepb2domain(request.GetStartTime(), request.GetEndTime())
+	if err != nil {
+		return nil, err
+	}
+	return endpoint.ListBodyMetricsRequest{
+		AthleteID: request.GetAthleteId(),
+		Filter: service.BodyMetricFilter{
+			Type:  service.BodyMetricType(request.GetType()),
+			Site:  request.GetSite(),
+			Start: start,
+			End:   end,
+		},
+		Unit: service.MeasurementUnit(request.GetUnit()),
+	}, nil
+}
+
+func encodeListBodyMetricsResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.ListBodyMetricsResponse)
+	var pblist []*pb.BodyMetric
+	{
+		for _, m := range response.Data {
+			pblist = append(pblist, bodymetricdomain2pb(m))
+		}
+	}
+	return &pb.ListBodyMetricsResponse{
+		Data: pblist,
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// DeleteBodyMetric handles incoming gRPC requests to delete one of an
+// athlete's measurements.
+func (s *metricsGRPCServer) DeleteBodyMetric(ctx context.Context, req *pb.DeleteBodyMetricRequest) (*pb.DeleteBodyMetricResponse, error) {
+	_, res, err := s.deleteBodyMetric.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.DeleteBodyMetricResponse), nil
+}
+
+func decodeDeleteBodyMetricRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.DeleteBodyMetricRequest)
+	return endpoint.DeleteBodyMetricRequest{
+		AthleteID: request.GetAthleteId(),
+		Name:      request.GetName(),
+	}, nil
+}
+
+func encodeDeleteBodyMetricResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.DeleteBodyMetricResponse)
+	return &pb.DeleteBodyMetricResponse{Err: err2str(response.Err)}, nil
+}
+
+// GetMetricTrend handles incoming gRPC requests to read the trend of one of
+// an athlete's metrics.
+func (s *metricsGRPCServer) GetMetricTrend(ctx context.Context, req *pb.GetMetricTrendRequest) (*pb.GetMetricTrendResponse, error) {
+	_, res, err := s.getMetricTrend.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.GetMetricTrendResponse), nil
+}
+
+func decodeGetMetricTrendRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.GetMetricTrendRequest)
+	start, end, err := timeRangepb2domain(request.GetStartTime(), request.GetEndTime())
+	if err != nil {
+		return nil, err
+	}
+	return endpoint.MetricTrendRequest{
+		AthleteID: request.GetAthleteId(),
+		Query: service.TrendQuery{
+			Type:   service.BodyMetricType(request.GetType()),
+			Site:   request.GetSite(),
+			Start:  start,
+			End:    end,
+			Window: int(request.GetWindow()),
+			Unit:   service.MeasurementUnit(request.GetUnit()),
+		},
+	}, nil
+}
+
+func encodeGetMetricTrendResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.MetricTrendResponse)
+	var points []*pb.TrendPoint
+	{
+		for _, p := range response.Data.Points {
+			date, _ := ptypes.TimestampProto(p.Date)
+			points = append(points, &pb.TrendPoint{
+				Date:           date,
+				Value:          p.Value,
+				RollingAverage: p.RollingAverage,
+				Count:          int32(p.Count),
+			})
+		}
+	}
+	return &pb.GetMetricTrendResponse{
+		Data: &pb.MetricTrend{
+			Type:       string(response.Data.Type),
+			Site:       response.Data.Site,
+			Unit:       string(response.Data.Unit),
+			Points:     points,
+			Change:     response.Data.Change,
+			WeeklyRate: response.Data.WeeklyRate,
+		},
+		Err: err2str(response.Err),
+	}, nil
+}
+
+// GetRelativeStrength handles incoming gRPC requests to score an athlete's
+// personal records against their bodyweight.
+func (s *metricsGRPCServer) GetRelativeStrength(ctx context.Context, req *pb.GetRelativeStrengthRequest) (*pb.GetRelativeStrengthResponse, error) {
+	_, res, err := s.getRelativeStrength.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.GetRelativeStrengthResponse), nil
+}
+
+func decodeGetRelativeStrengthRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.GetRelativeStrengthRequest)
+	return endpoint.RelativeStrengthRequest{
+		AthleteID: request.GetAthleteId(),
+		Query: service.RelativeStrengthQuery{
+			Sex:              service.Sex(request.GetSex()),
+			Equipped:         request.GetEquipped(),
+			TotalMovementIDs: request.GetTotalMovementIds(),
+			BenchMovementID:  request.GetBenchMovementId(),
+		},
+	}, nil
+}
+
+func encodeGetRelativeStrengthResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.RelativeStrengthResponse)
+	report := &pb.RelativeStrengthReport{}
+	for _, r := range response.Data.Records {
+		report.Records = append(report.Records, relativestrengthdomain2pb(r, response.Unit))
+	}
+	if response.Data.Total != nil {
+		report.Total = relativestrengthdomain2pb(*response.Data.Total, response.Unit)
+	}
+	return &pb.GetRelativeStrengthResponse{
+		Data: report,
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+func relativestrengthdomain2pb(r service.RelativeStrength, unit service.WeightUnit) *pb.RelativeStrength {
+	performedAt, _ := ptypes.TimestampProto(r.PerformedAt)
+	return &pb.RelativeStrength{
+		MovementId:  r.MovementID,
+		WorkoutId:   r.WorkoutID,
+		PerformedAt: performedAt,
+		Reps:        r.Reps,
+		Wilks:       r.Wilks,
+		Dots:        r.DOTS,
+		IpfGl:       r.IPFGL,
+		Weight:      loaddomain2pb(r.Weight, unit),
+		OneRepMax:   loaddomain2pb(r.OneRepMax, unit),
+		Bodyweight:  weightdomain2pb(r.Bodyweight.Kilograms, unit),
+	}
+}
@@ -0,0 +1,188 @@
+package transport
+
+import (
+	"context"
+	"io"
+
+	"github.com/go-kit/kit/transport/grpc"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/metadata"
+
+	"workout-manager-service/pb"
+	"workout-manager-service/pkg/endpoint"
+	"workout-manager-service/pkg/service"
+)
+
+// exportChunkSize is the most export data sent in a single stream message.
+const exportChunkSize = 64 << 10
+
+// The streaming calls are served directly rather than through go-kit, whose
+// gRPC transport only handles unary calls. They still go through the
+// endpoints, and so through the same middleware.
+
+// ImportMovements handles incoming gRPC streams that upload a movement file.
+// The whole file is read before it is imported.
+func (s *grpcServer) ImportMovements(stream pb.WorkoutManager_ImportMovementsServer) error {
+	ctx := streamContext(stream.Context(), s.before)
+	var (
+		options *pb.ImportOptions
+		data    []byte
+	)
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if options == nil {
+			options = msg.GetOptions()
+			if options == nil {
+				return encodeError(errors.Wrap(service.ErrInvalidArgument, "the first message must carry import options"))
+			}
+		}
+		if len(data)+len(msg.GetChunk()) > service.MaxImportSize {
+			return encodeError(errors.Wrapf(service.ErrInvalidArgument, "imports are limited to %d bytes", service.MaxImportSize))
+		}
+		data = append(data, msg.GetChunk()...)
+	}
+	if options == nil {
+		return encodeError(errors.Wrap(service.ErrInvalidArgument, "import is empty"))
+	}
+
+	res, err := s.importMovements(ctx, endpoint.ImportMovementsRequest{
+		Format:  formatpb2domain(options.GetFormat()),
+		Columns: options.GetColumns(),
+		Policy:  conflictPolicies[options.GetConflictPolicy()],
+		DryRun:  options.GetDryRun(),
+		Data:    data,
+	})
+	if err != nil {
+		return encodeError(err)
+	}
+	response := res.(endpoint.ImportMovementsResponse)
+	return stream.SendAndClose(&pb.ImportMovementsResponse{
+		Report: reportdomain2pb(response.Data),
+		Err:    err2str(response.Err),
+	})
+}
+
+// ExportMovements handles incoming gRPC requests to download the movement
+// catalog, streaming the file back in chunks.
+func (s *grpcServer) ExportMovements(req *pb.ExportMovementsRequest, stream pb.WorkoutManager_ExportMovementsServer) error {
+	ctx := streamContext(stream.Context(), s.before)
+	res, err := s.exportMovements(ctx, endpoint.ExportMovementsRequest{
+		Format:       formatpb2domain(req.GetFormat()),
+		CategoryName: req.GetCategoryName(),
+		Equipment:    equipmentpb2domain(req.GetEquipment()),
+		Muscle:       service.MuscleGroup(req.GetMuscle()),
+		Laterality:   lateralitypb2domain(req.GetLaterality()),
+		LoadType:     loadtypepb2domain(req.GetLoadType()),
+		ShowDeleted:  req.GetShowDeleted(),
+	})
+	if err != nil {
+		return encodeError(err)
+	}
+	response := res.(endpoint.ExportMovementsResponse)
+	if response.Err != nil {
+		return encodeError(response.Err)
+	}
+	for data := response.Data; len(data) > 0; {
+		n := len(data)
+		if n > exportChunkSize {
+			n = exportChunkSize
+		}
+		if err := stream.Send(&pb.ExportMovementsResponse{Chunk: data[:n]}); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// WatchMovements handles incoming gRPC requests to follow changes to the
+// movement catalog. The stream stays open until the client cancels it; an
+// expired resume token ends it with an OUT_OF_RANGE status.
+func (s *grpcServer) WatchMovements(req *pb.WatchMovementsRequest, stream pb.WorkoutManager_WatchMovementsServer) error {
+	ctx := streamContext(stream.Context(), s.before)
+	res, err := s.watchMovements(ctx, endpoint.WatchMovementsRequest{
+		ResumeToken: req.GetResumeToken(),
+		Send: func(e service.MovementEvent) error {
+			return stream.Send(movementeventdomain2pb(e))
+		},
+	})
+	if err != nil {
+		return encodeError(err)
+	}
+	return encodeError(res.(endpoint.WatchMovementsResponse).Err)
+}
+
+var movementEventTypes = map[service.MovementEventType]pb.MovementEventType{
+	service.MovementCreated:  pb.MovementEventType_MOVEMENT_EVENT_TYPE_CREATED,
+	service.MovementUpdated:  pb.MovementEventType_MOVEMENT_EVENT_TYPE_UPDATED,
+	service.MovementDeleted:  pb.MovementEventType_MOVEMENT_EVENT_TYPE_DELETED,
+	service.MovementBookmark: pb.MovementEventType_MOVEMENT_EVENT_TYPE_BOOKMARK,
+}
+
+func movementeventdomain2pb(e service.MovementEvent) *pb.WatchMovementsResponse {
+	eventTime, _ := ptypes.TimestampProto(e.Time)
+	res := &pb.WatchMovementsResponse{
+		Type:        movementEventTypes[e.Type],
+		ResumeToken: e.ResumeToken,
+		EventTime:   eventTime,
+	}
+	if e.Type != service.MovementBookmark {
+		res.Movement = movementdomain2pb(e.Movement)
+	}
+	return res
+}
+
+// streamContext prepares the context of a streaming call the way
+// grpc.ServerBefore prepares the context of unary ones.
+func streamContext(ctx context.Context, before []grpc.ServerRequestFunc) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, f := range before {
+		ctx = f(ctx, md)
+	}
+	return ctx
+}
+
+func formatpb2domain(f pb.MovementFormat) service.MovementFormat {
+	switch f {
+	case pb.MovementFormat_MOVEMENT_FORMAT_CSV:
+		return service.CSVFormat
+	case pb.MovementFormat_MOVEMENT_FORMAT_NDJSON:
+		return service.NDJSONFormat
+	}
+	return ""
+}
+
+var conflictPolicies = map[pb.ConflictPolicy]service.ConflictPolicy{
+	pb.ConflictPolicy_CONFLICT_POLICY_FAIL:      service.FailOnConflict,
+	pb.ConflictPolicy_CONFLICT_POLICY_SKIP:      service.SkipConflicts,
+	pb.ConflictPolicy_CONFLICT_POLICY_OVERWRITE: service.OverwriteConflicts,
+}
+
+func reportdomain2pb(r service.ImportReport) *pb.ImportReport {
+	rows := make([]*pb.ImportRow, len(r.Rows))
+	for i, row := range r.Rows {
+		rows[i] = &pb.ImportRow{
+			Row:        int32(row.Row),
+			Name:       row.Name,
+			MovementId: row.MovementID,
+			Action:     string(row.Action),
+			Err:        row.Err,
+		}
+	}
+	return &pb.ImportReport{
+		DryRun:    r.DryRun,
+		Committed: r.Committed,
+		Created:   int32(r.Created),
+		Updated:   int32(r.Updated),
+		Skipped:   int32(r.Skipped),
+		Rejected:  int32(r.Rejected),
+		Rows:      rows,
+	}
+}
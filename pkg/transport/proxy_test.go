@@ -0,0 +1,63 @@
+package transport
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeStream is a server stream that only has a context.
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s fakeStream) Context() context.Context { return s.ctx }
+
+func TestTrustedProxy(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		proxy TrustedProxy
+		md    metadata.MD
+		ok    bool
+	}{
+		{"anonymous", TrustedProxy{Secret: "s3cret"}, metadata.Pairs(), true},
+		{"from the proxy", TrustedProxy{Secret: "s3cret"}, metadata.Pairs(userIDMetadataKey, "u1", proxySecretMetadataKey, "s3cret"), true},
+		{"without the secret", TrustedProxy{Secret: "s3cret"}, metadata.Pairs(userIDMetadataKey, "u1"), false},
+		{"with a wrong secret", TrustedProxy{Secret: "s3cret"}, metadata.Pairs(userIDMetadataKey, "u1", proxySecretMetadataKey, "guess"), false},
+		{"no secret configured", TrustedProxy{}, metadata.Pairs(userIDMetadataKey, "u1", proxySecretMetadataKey, ""), false},
+		{"insecure", TrustedProxy{Insecure: true}, metadata.Pairs(userIDMetadataKey, "u1"), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tc.md)
+			var called int
+			next := func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				return handler(ctx, req)
+			}
+			unary := tc.proxy.UnaryInterceptor(next)
+			_, err := unary(ctx, nil, &grpc.UnaryServerInfo{}, func(context.Context, interface{}) (interface{}, error) {
+				called++
+				return nil, nil
+			})
+			serr := tc.proxy.StreamInterceptor(nil, fakeStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(interface{}, grpc.ServerStream) error {
+				called++
+				return nil
+			})
+			for _, err := range []error{err, serr} {
+				if tc.ok && err != nil {
+					t.Errorf("call was rejected: %v", err)
+				}
+				if !tc.ok && status.Code(err) != codes.Unauthenticated {
+					t.Errorf("call = %v, want %s", err, codes.Unauthenticated)
+				}
+			}
+			if want := map[bool]int{true: 2, false: 0}[tc.ok]; called != want {
+				t.Errorf("handlers were called %d times, want %d", called, want)
+			}
+		})
+	}
+}
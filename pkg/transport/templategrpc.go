@@ -0,0 +1,220 @@
+package transport
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/transport/grpc"
+	"github.com/golang/protobuf/ptypes"
+
+	"workout-manager-service/pb"
+	"workout-manager-service/pkg/endpoint"
+	"workout-manager-service/pkg/service"
+)
+
+type templateGRPCServer struct {
+	createTemplate grpc.Handler
+	getTemplate    grpc.Handler
+	listTemplates  grpc.Handler
+	updateTemplate grpc.Handler
+	deleteTemplate grpc.Handler
+}
+
+// NewTemplateGRPCServer makes a set of endpoints available as a gRPC
+// TemplateManagerServer.
+func NewTemplateGRPCServer(endpoints endpoint.TemplateSet) pb.TemplateManagerServer {
+	options := []grpc.ServerOption{grpc.ServerBefore(userIDToContext, correlationIDToContext, weightUnitToContext)}
+	return &templateGRPCServer{
+		createTemplate: grpc.NewServer(
+			endpoints.CreateEndpoint,
+			decodeCreateTemplateRequest,
+			encodeTemplateResponse,
+			options...,
+		),
+		getTemplate: grpc.NewServer(
+			endpoints.GetEndpoint,
+			decodeGetTemplateRequest,
+			encodeTemplateResponse,
+			options...,
+		),
+		listTemplates: grpc.NewServer(
+			endpoints.ListEndpoint,
+			decodeListTemplatesRequest,
+			encodeListTemplatesResponse,
+			options...,
+		),
+		updateTemplate: grpc.NewServer(
+			endpoints.UpdateEndpoint,
+			decodeUpdateTemplateRequest,
+			encodeTemplateResponse,
+			options...,
+		),
+		deleteTemplate: grpc.NewServer(
+			endpoints.DeleteEndpoint,
+			decodeDeleteTemplateRequest,
+			encodeDeleteTemplateResponse,
+			options...,
+		),
+	}
+}
+
+// CreateTemplate handles incoming gRPC requests to save a workout template.
+func (s *templateGRPCServer) CreateTemplate(ctx context.Context, req *pb.CreateTemplateRequest) (*pb.TemplateResponse, error) {
+	_, res, err := s.createTemplate.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.TemplateResponse), nil
+}
+
+func decodeCreateTemplateRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.CreateTemplateRequest)
+	t, err := templatepb2domain(request.GetTemplate())
+	if err != nil {
+		return nil, err
+	}
+	return endpoint.CreateTemplateRequest{RequestID: request.GetRequestId(), Template: t}, nil
+}
+
+// GetTemplate handles incoming gRPC requests to retrieve a template by its
+// UUID.
+func (s *templateGRPCServer) GetTemplate(ctx context.Context, req *pb.GetTemplateRequest) (*pb.TemplateResponse, error) {
+	_, res, err := s.getTemplate.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.TemplateResponse), nil
+}
+
+func decodeGetTemplateRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.GetTemplateRequest)
+	return endpoint.GetTemplateRequest{Name: request.GetName()}, nil
+}
+
+// ListTemplates handles incoming gRPC requests to retrieve the templates the
+// caller can see.
+func (s *templateGRPCServer) ListTemplates(ctx context.Context, req *pb.ListTemplatesRequest) (*pb.ListTemplatesResponse, error) {
+	_, res, err := s.listTemplates.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.ListTemplatesResponse), nil
+}
+
+func decodeListTemplatesRequest(_ context.Context, _ interface{}) (interface{}, error) {
+	return endpoint.ListTemplatesRequest{}, nil
+}
+
+func encodeListTemplatesResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.ListTemplatesResponse)
+	var pblist []*pb.WorkoutTemplate
+	{
+		for _, t := range response.Data {
+			pblist = append(pblist, templatedomain2pb(t, response.Unit))
+		}
+	}
+	return &pb.ListTemplatesResponse{
+		Data: pblist,
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// UpdateTemplate handles incoming gRPC requests to change a template.
+func (s *templateGRPCServer) UpdateTemplate(ctx context.Context, req *pb.UpdateTemplateRequest) (*pb.TemplateResponse, error) {
+	_, res, err := s.updateTemplate.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.TemplateResponse), nil
+}
+
+func decodeUpdateTemplateRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.UpdateTemplateRequest)
+	t, err := templatepb2domain(request.GetTemplate())
+	if err != nil {
+		return nil, err
+	}
+	return endpoint.UpdateTemplateRequest{Template: t}, nil
+}
+
+// DeleteTemplate handles incoming gRPC requests to remove a template.
+func (s *templateGRPCServer) DeleteTemplate(ctx context.Context, req *pb.DeleteTemplateRequest) (*pb.DeleteTemplateResponse, error) {
+	_, res, err := s.deleteTemplate.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.DeleteTemplateResponse), nil
+}
+
+func decodeDeleteTemplateRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.DeleteTemplateRequest)
+	return endpoint.DeleteTemplateRequest{Name: request.GetName()}, nil
+}
+
+func encodeDeleteTemplateResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.DeleteTemplateResponse)
+	return &pb.DeleteTemplateResponse{Err: err2str(response.Failed())}, nil
+}
+
+func encodeTemplateResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.TemplateResponse)
+	return &pb.TemplateResponse{
+		Data: templatedomain2pb(response.Data, response.Unit),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+var templateScopes = map[pb.TemplateScope]service.TemplateScope{
+	pb.TemplateScope_TEMPLATE_SCOPE_UNSPECIFIED: "",
+	pb.TemplateScope_TEMPLATE_SCOPE_USER:        service.TemplateUserScope,
+	pb.TemplateScope_TEMPLATE_SCOPE_TENANT:      service.TemplateTenantScope,
+}
+
+func templatepb2domain(t *pb.WorkoutTemplate) (service.WorkoutTemplate, error) {
+	var sets []service.WorkoutSet
+	for _, s := range t.GetSets() {
+		set, err := workoutsetpb2domain(s)
+		if err != nil {
+			return service.WorkoutTemplate{}, err
+		}
+		sets = append(sets, set)
+	}
+	blocks, err := blockspb2domain(t.GetBlocks())
+	if err != nil {
+		return service.WorkoutTemplate{}, err
+	}
+	return service.WorkoutTemplate{
+		Name:       t.GetName(),
+		Title:      t.GetTitle(),
+		Scope:      templateScopes[t.GetScope()],
+		SharedWith: t.GetSharedWith(),
+		Sets:       sets,
+		Blocks:     blocks,
+	}, nil
+}
+
+func templatedomain2pb(t service.WorkoutTemplate, unit service.WeightUnit) *pb.WorkoutTemplate {
+	createTime, _ := ptypes.TimestampProto(t.CreateTime)
+	updateTime, _ := ptypes.TimestampProto(t.UpdateTime)
+	var scope pb.TemplateScope
+	for s, domain := range templateScopes {
+		if domain == t.Scope {
+			scope = s
+		}
+	}
+	var sets []*pb.WorkoutSet
+	for _, s := range t.Sets {
+		sets = append(sets, workoutsetdomain2pb(s, unit))
+	}
+	return &pb.WorkoutTemplate{
+		Name:       t.Name,
+		TenantId:   t.TenantID,
+		OwnerId:    t.OwnerID,
+		Title:      t.Title,
+		Scope:      scope,
+		SharedWith: t.SharedWith,
+		Sets:       sets,
+		Blocks:     blocksdomain2pb(t.Blocks),
+		CreateTime: createTime,
+		UpdateTime: updateTime,
+	}
+}
@@ -0,0 +1,284 @@
+package transport
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/transport/grpc"
+	"github.com/golang/protobuf/ptypes"
+
+	"workout-manager-service/pb"
+	"workout-manager-service/pkg/endpoint"
+	"workout-manager-service/pkg/service"
+)
+
+type tenantGRPCServer struct {
+	createTenant         grpc.Handler
+	getTenant            grpc.Handler
+	listTenants          grpc.Handler
+	updateTenantSettings grpc.Handler
+	suspendTenant        grpc.Handler
+	resumeTenant         grpc.Handler
+	deleteTenant         grpc.Handler
+}
+
+// NewTenantGRPCServer makes a set of endpoints available as a gRPC
+// TenantManagerServer.
+func NewTenantGRPCServer(endpoints endpoint.TenantSet) pb.TenantManagerServer {
+	options := []grpc.ServerOption{grpc.ServerBefore(userIDToContext, correlationIDToContext)}
+	return &tenantGRPCServer{
+		createTenant: grpc.NewServer(
+			endpoints.CreateEndpoint,
+			decodeCreateTenantRequest,
+			encodeCreateTenantResponse,
+			options...,
+		),
+		getTenant: grpc.NewServer(
+			endpoints.GetEndpoint,
+			decodeGetTenantRequest,
+			encodeTenantResponse,
+			options...,
+		),
+		listTenants: grpc.NewServer(
+			endpoints.ListEndpoint,
+			decodeListTenantsRequest,
+			encodeListTenantsResponse,
+			options...,
+		),
+		updateTenantSettings: grpc.NewServer(
+			endpoints.UpdateSettingsEndpoint,
+			decodeUpdateTenantSettingsRequest,
+			encodeTenantResponse,
+			options...,
+		),
+		suspendTenant: grpc.NewServer(
+			endpoints.SuspendEndpoint,
+			decodeSuspendTenantRequest,
+			encodeTenantResponse,
+			options...,
+		),
+		resumeTenant: grpc.NewServer(
+			endpoints.ResumeEndpoint,
+			decodeResumeTenantRequest,
+			encodeTenantResponse,
+			options...,
+		),
+		deleteTenant: grpc.NewServer(
+			endpoints.DeleteEndpoint,
+			decodeDeleteTenantRequest,
+			encodeDeleteTenantResponse,
+			options...,
+		),
+	}
+}
+
+// CreateTenant handles incoming gRPC requests to provision a new tenant.
+func (s *tenantGRPCServer) CreateTenant(ctx context.Context, req *pb.CreateTenantRequest) (*pb.CreateTenantResponse, error) {
+	_, res, err := s.createTenant.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.CreateTenantResponse), nil
+}
+
+func decodeCreateTenantRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.CreateTenantRequest)
+	return endpoint.CreateTenantRequest{
+		DisplayName: request.GetDisplayName(),
+		Settings:    tenantsettingspb2domain(request.GetSettings()),
+		Admin: service.User{
+			DisplayName: request.GetAdmin().GetDisplayName(),
+			Email:       request.GetAdmin().GetEmail(),
+		},
+	}, nil
+}
+
+func encodeCreateTenantResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.CreateTenantResponse)
+	return &pb.CreateTenantResponse{
+		Data:  tenantdomain2pb(response.Data),
+		Admin: userdomain2pb(response.Admin),
+		Err:   err2str(response.Err),
+	}, nil
+}
+
+// GetTenant handles incoming gRPC requests to retrieve a tenant by its UUID.
+func (s *tenantGRPCServer) GetTenant(ctx context.Context, req *pb.GetTenantRequest) (*pb.TenantResponse, error) {
+	_, res, err := s.getTenant.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.TenantResponse), nil
+}
+
+func decodeGetTenantRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.GetTenantRequest)
+	return endpoint.GetTenantRequest{Name: request.GetName()}, nil
+}
+
+// ListTenants handles incoming gRPC requests to retrieve every tenant.
+func (s *tenantGRPCServer) ListTenants(ctx context.Context, req *pb.ListTenantsRequest) (*pb.ListTenantsResponse, error) {
+	_, res, err := s.listTenants.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.ListTenantsResponse), nil
+}
+
+func decodeListTenantsRequest(_ context.Context, _ interface{}) (interface{}, error) {
+	return endpoint.ListTenantsRequest{}, nil
+}
+
+func encodeListTenantsResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.ListTenantsResponse)
+	var pblist []*pb.Tenant
+	{
+		for _, t := range response.Data {
+			pblist = append(pblist, tenantdomain2pb(t))
+		}
+	}
+	return &pb.ListTenantsResponse{
+		Data: pblist,
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// UpdateTenantSettings handles incoming gRPC requests to change a tenant's
+// settings.
+func (s *tenantGRPCServer) UpdateTenantSettings(ctx context.Context, req *pb.UpdateTenantSettingsRequest) (*pb.TenantResponse, error) {
+	_, res, err := s.updateTenantSettings.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.TenantResponse), nil
+}
+
+func decodeUpdateTenantSettingsRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.UpdateTenantSettingsRequest)
+	return endpoint.UpdateTenantSettingsRequest{
+		Name:       request.GetName(),
+		Settings:   tenantsettingspb2domain(request.GetSettings()),
+		UpdateMask: request.GetUpdateMask().GetPaths(),
+	}, nil
+}
+
+// SuspendTenant handles incoming gRPC requests to suspend a tenant.
+func (s *tenantGRPCServer) SuspendTenant(ctx context.Context, req *pb.SuspendTenantRequest) (*pb.TenantResponse, error) {
+	_, res, err := s.suspendTenant.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.TenantResponse), nil
+}
+
+func decodeSuspendTenantRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.SuspendTenantRequest)
+	return endpoint.SuspendTenantRequest{Name: request.GetName()}, nil
+}
+
+// ResumeTenant handles incoming gRPC requests to reactivate a suspended
+// tenant.
+func (s *tenantGRPCServer) ResumeTenant(ctx context.Context, req *pb.ResumeTenantRequest) (*pb.TenantResponse, error) {
+	_, res, err := s.resumeTenant.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.TenantResponse), nil
+}
+
+func decodeResumeTenantRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.ResumeTenantRequest)
+	return endpoint.ResumeTenantRequest{Name: request.GetName()}, nil
+}
+
+func encodeTenantResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.TenantResponse)
+	return &pb.TenantResponse{
+		Data: tenantdomain2pb(response.Data),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// DeleteTenant handles incoming gRPC requests to permanently delete a
+// suspended tenant and all of its data.
+func (s *tenantGRPCServer) DeleteTenant(ctx context.Context, req *pb.DeleteTenantRequest) (*pb.DeleteTenantResponse, error) {
+	_, res, err := s.deleteTenant.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.DeleteTenantResponse), nil
+}
+
+func decodeDeleteTenantRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.DeleteTenantRequest)
+	return endpoint.DeleteTenantRequest{
+		Name:   request.GetName(),
+		Reason: request.GetReason(),
+	}, nil
+}
+
+func encodeDeleteTenantResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.DeleteTenantResponse)
+	deleteTime, _ := ptypes.TimestampProto(response.Data.DeleteTime)
+	return &pb.DeleteTenantResponse{
+		Data: &pb.TenantDeletion{
+			TenantId:    response.Data.TenantID,
+			DisplayName: response.Data.DisplayName,
+			RequestedBy: response.Data.RequestedBy,
+			Reason:      response.Data.Reason,
+			DeleteTime:  deleteTime,
+			RowCounts:   response.Data.RowCounts,
+		},
+		Err: err2str(response.Err),
+	}, nil
+}
+
+func tenantdomain2pb(t service.Tenant) *pb.Tenant {
+	createTime, _ := ptypes.TimestampProto(t.CreateTime)
+	var state pb.TenantState
+	switch t.State {
+	case service.TenantActive:
+		state = pb.TenantState_TENANT_STATE_ACTIVE
+	case service.TenantSuspended:
+		state = pb.TenantState_TENANT_STATE_SUSPENDED
+	}
+	return &pb.Tenant{
+		Name:        t.Name,
+		DisplayName: t.DisplayName,
+		State:       state,
+		Settings: &pb.TenantSettings{
+			DefaultUnit: unitdomain2pb(t.Settings.DefaultUnit),
+			WeekStart:   pb.Weekday(t.Settings.WeekStart),
+			TimeZone:    t.Settings.TimeZone,
+		},
+		CreateTime: createTime,
+	}
+}
+
+func tenantsettingspb2domain(s *pb.TenantSettings) service.TenantSettings {
+	return service.TenantSettings{
+		DefaultUnit: unitpb2domain(s.GetDefaultUnit()),
+		WeekStart:   time.Weekday(s.GetWeekStart()),
+		TimeZone:    s.GetTimeZone(),
+	}
+}
+
+func unitpb2domain(u pb.WeightUnit) service.WeightUnit {
+	switch u {
+	case pb.WeightUnit_WEIGHT_UNIT_KG:
+		return service.Kilograms
+	case pb.WeightUnit_WEIGHT_UNIT_LB:
+		return service.Pounds
+	}
+	return ""
+}
+
+func unitdomain2pb(u service.WeightUnit) pb.WeightUnit {
+	switch u {
+	case service.Kilograms:
+		return pb.WeightUnit_WEIGHT_UNIT_KG
+	case service.Pounds:
+		return pb.WeightUnit_WEIGHT_UNIT_LB
+	}
+	return pb.WeightUnit_WEIGHT_UNIT_UNSPECIFIED
+}
@@ -0,0 +1,253 @@
+package transport
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/transport/grpc"
+
+	"workout-manager-service/pb"
+	"workout-manager-service/pkg/endpoint"
+	"workout-manager-service/pkg/service"
+)
+
+type userGRPCServer struct {
+	createUser    grpc.Handler
+	getUser       grpc.Handler
+	listUsers     grpc.Handler
+	assignCoach   grpc.Handler
+	unassignCoach grpc.Handler
+	listRoster    grpc.Handler
+}
+
+// NewUserGRPCServer makes a set of endpoints available as a gRPC
+// UserManagerServer.
+func NewUserGRPCServer(endpoints endpoint.UserSet) pb.UserManagerServer {
+	options := []grpc.ServerOption{grpc.ServerBefore(userIDToContext, correlationIDToContext)}
+	return &userGRPCServer{
+		createUser: grpc.NewServer(
+			endpoints.CreateEndpoint,
+			decodeCreateUserRequest,
+			encodeCreateUserResponse,
+			options...,
+		),
+		getUser: grpc.NewServer(
+			endpoints.GetEndpoint,
+			decodeGetUserRequest,
+			encodeGetUserResponse,
+			options...,
+		),
+		listUsers: grpc.NewServer(
+			endpoints.ListEndpoint,
+			decodeListUsersRequest,
+			encodeListUsersResponse,
+			options...,
+		),
+		assignCoach: grpc.NewServer(
+			endpoints.AssignCoachEndpoint,
+			decodeAssignCoachRequest,
+			encodeAssignCoachResponse,
+			options...,
+		),
+		unassignCoach: grpc.NewServer(
+			endpoints.UnassignCoachEndpoint,
+			decodeUnassignCoachRequest,
+			encodeUnassignCoachResponse,
+			options...,
+		),
+		listRoster: grpc.NewServer(
+			endpoints.ListRosterEndpoint,
+			decodeListRosterRequest,
+			encodeListRosterResponse,
+			options...,
+		),
+	}
+}
+
+// CreateUser handles incoming gRPC requests to add a user to the caller's
+// tenant.
+func (s *userGRPCServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	_, res, err := s.createUser.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.CreateUserResponse), nil
+}
+
+func decodeCreateUserRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.CreateUserRequest)
+	return endpoint.CreateUserRequest{
+		DisplayName: request.GetDisplayName(),
+		Email:       request.GetEmail(),
+		Role:        rolepb2domain(request.GetRole()),
+	}, nil
+}
+
+func encodeCreateUserResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.CreateUserResponse)
+	return &pb.CreateUserResponse{
+		Data: userdomain2pb(response.Data),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// GetUser handles incoming gRPC requests to retrieve a user by its UUID.
+func (s *userGRPCServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	_, res, err := s.getUser.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.GetUserResponse), nil
+}
+
+func decodeGetUserRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.GetUserRequest)
+	return endpoint.GetUserRequest{Name: request.GetName()}, nil
+}
+
+func encodeGetUserResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.GetUserResponse)
+	return &pb.GetUserResponse{
+		Data: userdomain2pb(response.Data),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// ListUsers handles incoming gRPC requests to retrieve the users in the
+// caller's tenant, optionally filtering by role.
+func (s *userGRPCServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	_, res, err := s.listUsers.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.ListUsersResponse), nil
+}
+
+func decodeListUsersRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.ListUsersRequest)
+	return endpoint.ListUsersRequest{Role: rolepb2domain(request.GetRole())}, nil
+}
+
+func encodeListUsersResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.ListUsersResponse)
+	return &pb.ListUsersResponse{
+		Data: userlistdomain2pb(response.Data),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// AssignCoach handles incoming gRPC requests to add an athlete to a coach's
+// roster.
+func (s *userGRPCServer) AssignCoach(ctx context.Context, req *pb.AssignCoachRequest) (*pb.AssignCoachResponse, error) {
+	_, res, err := s.assignCoach.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.AssignCoachResponse), nil
+}
+
+func decodeAssignCoachRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.AssignCoachRequest)
+	return endpoint.AssignCoachRequest{
+		CoachID:   request.GetCoachId(),
+		AthleteID: request.GetAthleteId(),
+	}, nil
+}
+
+func encodeAssignCoachResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.AssignCoachResponse)
+	return &pb.AssignCoachResponse{Err: err2str(response.Failed())}, nil
+}
+
+// UnassignCoach handles incoming gRPC requests to remove an athlete from a
+// coach's roster.
+func (s *userGRPCServer) UnassignCoach(ctx context.Context, req *pb.UnassignCoachRequest) (*pb.UnassignCoachResponse, error) {
+	_, res, err := s.unassignCoach.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.UnassignCoachResponse), nil
+}
+
+func decodeUnassignCoachRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.UnassignCoachRequest)
+	return endpoint.UnassignCoachRequest{
+		CoachID:   request.GetCoachId(),
+		AthleteID: request.GetAthleteId(),
+	}, nil
+}
+
+func encodeUnassignCoachResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.UnassignCoachResponse)
+	return &pb.UnassignCoachResponse{Err: err2str(response.Failed())}, nil
+}
+
+// ListRoster handles incoming gRPC requests to retrieve the athletes assigned
+// to a coach.
+func (s *userGRPCServer) ListRoster(ctx context.Context, req *pb.ListRosterRequest) (*pb.ListRosterResponse, error) {
+	_, res, err := s.listRoster.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.ListRosterResponse), nil
+}
+
+func decodeListRosterRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.ListRosterRequest)
+	return endpoint.ListRosterRequest{CoachID: request.GetCoachId()}, nil
+}
+
+func encodeListRosterResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.ListRosterResponse)
+	return &pb.ListRosterResponse{
+		Data: userlistdomain2pb(response.Data),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+func userdomain2pb(u service.User) *pb.User {
+	return &pb.User{
+		Name:        u.Name,
+		TenantId:    u.TenantID,
+		DisplayName: u.DisplayName,
+		Email:       u.Email,
+		Role:        roledomain2pb(u.Role),
+	}
+}
+
+func userlistdomain2pb(users []service.User) []*pb.User {
+	var pblist []*pb.User
+	{
+		for _, u := range users {
+			pblist = append(pblist, userdomain2pb(u))
+		}
+	}
+	return pblist
+}
+
+func rolepb2domain(r pb.Role) service.Role {
+	switch r {
+	case pb.Role_ROLE_ATHLETE:
+		return service.RoleAthlete
+	case pb.Role_ROLE_COACH:
+		return service.RoleCoach
+	case pb.Role_ROLE_ADMIN:
+		return service.RoleAdmin
+	case pb.Role_ROLE_OPERATOR:
+		return service.RoleOperator
+	}
+	return ""
+}
+
+func roledomain2pb(r service.Role) pb.Role {
+	switch r {
+	case service.RoleAthlete:
+		return pb.Role_ROLE_ATHLETE
+	case service.RoleCoach:
+		return pb.Role_ROLE_COACH
+	case service.RoleAdmin:
+		return pb.Role_ROLE_ADMIN
+	case service.RoleOperator:
+		return pb.Role_ROLE_OPERATOR
+	}
+	return pb.Role_ROLE_UNSPECIFIED
+}
@@ -0,0 +1,334 @@
+package transport
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/transport/grpc"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+
+	"workout-manager-service/pb"
+	"workout-manager-service/pkg/endpoint"
+	"workout-manager-service/pkg/service"
+)
+
+type webhookGRPCServer struct {
+	createWebhook         grpc.Handler
+	getWebhook            grpc.Handler
+	listWebhooks          grpc.Handler
+	updateWebhook         grpc.Handler
+	deleteWebhook         grpc.Handler
+	rotateWebhookSecret   grpc.Handler
+	listWebhookDeliveries grpc.Handler
+	redeliverWebhook      grpc.Handler
+}
+
+// NewWebhookGRPCServer makes a set of endpoints available as a gRPC
+// WebhookManagerServer.
+func NewWebhookGRPCServer(endpoints endpoint.WebhookSet) pb.WebhookManagerServer {
+	options := []grpc.ServerOption{grpc.ServerBefore(userIDToContext, correlationIDToContext)}
+	return &webhookGRPCServer{
+		createWebhook: grpc.NewServer(
+			endpoints.CreateEndpoint,
+			decodeCreateWebhookRequest,
+			encodeWebhookResponse,
+			options...,
+		),
+		getWebhook: grpc.NewServer(
+			endpoints.GetEndpoint,
+			decodeGetWebhookRequest,
+			encodeWebhookResponse,
+			options...,
+		),
+		listWebhooks: grpc.NewServer(
+			endpoints.ListEndpoint,
+			decodeListWebhooksRequest,
+			encodeListWebhooksResponse,
+			options...,
+		),
+		updateWebhook: grpc.NewServer(
+			endpoints.UpdateEndpoint,
+			decodeUpdateWebhookRequest,
+			encodeWebhookResponse,
+			options...,
+		),
+		deleteWebhook: grpc.NewServer(
+			endpoints.DeleteEndpoint,
+			decodeDeleteWebhookRequest,
+			encodeDeleteWebhookResponse,
+			options...,
+		),
+		rotateWebhookSecret: grpc.NewServer(
+			endpoints.RotateSecretEndpoint,
+			decodeRotateWebhookSecretRequest,
+			encodeWebhookResponse,
+			options...,
+		),
+		listWebhookDeliveries: grpc.NewServer(
+			endpoints.ListDeliveriesEndpoint,
+			decodeListWebhookDeliveriesRequest,
+			encodeListWebhookDeliveriesResponse,
+			options...,
+		),
+		redeliverWebhook: grpc.NewServer(
+			endpoints.RedeliverEndpoint,
+			decodeRedeliverWebhookRequest,
+			encodeWebhookDeliveryResponse,
+			options...,
+		),
+	}
+}
+
+// CreateWebhook handles incoming gRPC requests to register a webhook.
+func (s *webhookGRPCServer) CreateWebhook(ctx context.Context, req *pb.CreateWebhookRequest) (*pb.WebhookResponse, error) {
+	_, res, err := s.createWebhook.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.WebhookResponse), nil
+}
+
+func decodeCreateWebhookRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.CreateWebhookRequest)
+	return endpoint.CreateWebhookRequest{
+		RequestID: request.GetRequestId(),
+		Webhook:   webhookpb2domain(request.GetWebhook()),
+	}, nil
+}
+
+// GetWebhook handles incoming gRPC requests to retrieve a webhook by its
+// UUID.
+func (s *webhookGRPCServer) GetWebhook(ctx context.Context, req *pb.GetWebhookRequest) (*pb.WebhookResponse, error) {
+	_, res, err := s.getWebhook.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.WebhookResponse), nil
+}
+
+func decodeGetWebhookRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.GetWebhookRequest)
+	return endpoint.GetWebhookRequest{Name: request.GetName()}, nil
+}
+
+// ListWebhooks handles incoming gRPC requests to retrieve the webhooks of
+// the caller's tenant.
+func (s *webhookGRPCServer) ListWebhooks(ctx context.Context, req *pb.ListWebhooksRequest) (*pb.ListWebhooksResponse, error) {
+	_, res, err := s.listWebhooks.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.ListWebhooksResponse), nil
+}
+
+func decodeListWebhooksRequest(_ context.Context, _ interface{}) (interface{}, error) {
+	return endpoint.ListWebhooksRequest{}, nil
+}
+
+func encodeListWebhooksResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.ListWebhooksResponse)
+	var pblist []*pb.Webhook
+	{
+		for _, w := range response.Data {
+			pblist = append(pblist, webhookdomain2pb(w))
+		}
+	}
+	return &pb.ListWebhooksResponse{
+		Data: pblist,
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// UpdateWebhook handles incoming gRPC requests to change a webhook.
+func (s *webhookGRPCServer) UpdateWebhook(ctx context.Context, req *pb.UpdateWebhookRequest) (*pb.WebhookResponse, error) {
+	_, res, err := s.updateWebhook.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.WebhookResponse), nil
+}
+
+func decodeUpdateWebhookRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.UpdateWebhookRequest)
+	return endpoint.UpdateWebhookRequest{Webhook: webhookpb2domain(request.GetWebhook())}, nil
+}
+
+// DeleteWebhook handles incoming gRPC requests to remove a webhook.
+func (s *webhookGRPCServer) DeleteWebhook(ctx context.Context, req *pb.DeleteWebhookRequest) (*pb.DeleteWebhookResponse, error) {
+	_, res, err := s.deleteWebhook.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.DeleteWebhookResponse), nil
+}
+
+func decodeDeleteWebhookRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.DeleteWebhookRequest)
+	return endpoint.DeleteWebhookRequest{Name: request.GetName()}, nil
+}
+
+func encodeDeleteWebhookResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.DeleteWebhookResponse)
+	return &pb.DeleteWebhookResponse{Err: err2str(response.Failed())}, nil
+}
+
+// RotateWebhookSecret handles incoming gRPC requests to replace a webhook's
+// signing secret.
+func (s *webhookGRPCServer) RotateWebhookSecret(ctx context.Context, req *pb.RotateWebhookSecretRequest) (*pb.WebhookResponse, error) {
+	_, res, err := s.rotateWebhookSecret.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.WebhookResponse), nil
+}
+
+func decodeRotateWebhookSecretRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.RotateWebhookSecretRequest)
+	return endpoint.RotateWebhookSecretRequest{Name: request.GetName()}, nil
+}
+
+func encodeWebhookResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.WebhookResponse)
+	return &pb.WebhookResponse{
+		Data: webhookdomain2pb(response.Data),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// ListWebhookDeliveries handles incoming gRPC requests to read the delivery
+// log of the caller's tenant.
+func (s *webhookGRPCServer) ListWebhookDeliveries(ctx context.Context, req *pb.ListWebhookDeliveriesRequest) (*pb.ListWebhookDeliveriesResponse, error) {
+	_, res, err := s.listWebhookDeliveries.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.ListWebhookDeliveriesResponse), nil
+}
+
+func decodeListWebhookDeliveriesRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.ListWebhookDeliveriesRequest)
+	return endpoint.ListWebhookDeliveriesRequest{
+		WebhookID: request.GetWebhookId(),
+		State:     webhookDeliveryStates[request.GetState()],
+		Limit:     int(request.GetPageSize()),
+	}, nil
+}
+
+func encodeListWebhookDeliveriesResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.ListWebhookDeliveriesResponse)
+	var pblist []*pb.WebhookDelivery
+	{
+		for _, d := range response.Data {
+			pblist = append(pblist, webhookdeliverydomain2pb(d))
+		}
+	}
+	return &pb.ListWebhookDeliveriesResponse{
+		Data: pblist,
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// RedeliverWebhook handles incoming gRPC requests to attempt a delivery
+// again, such as one from the dead-letter list.
+func (s *webhookGRPCServer) RedeliverWebhook(ctx context.Context, req *pb.RedeliverWebhookRequest) (*pb.WebhookDeliveryResponse, error) {
+	_, res, err := s.redeliverWebhook.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.WebhookDeliveryResponse), nil
+}
+
+func decodeRedeliverWebhookRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.RedeliverWebhookRequest)
+	return endpoint.RedeliverWebhookRequest{Name: request.GetName()}, nil
+}
+
+func encodeWebhookDeliveryResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.WebhookDeliveryResponse)
+	return &pb.WebhookDeliveryResponse{
+		Data: webhookdeliverydomain2pb(response.Data),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+var webhookDeliveryStates = map[pb.WebhookDeliveryState]service.WebhookDeliveryState{
+	pb.WebhookDeliveryState_WEBHOOK_DELIVERY_STATE_UNSPECIFIED: "",
+	pb.WebhookDeliveryState_WEBHOOK_DELIVERY_STATE_PENDING:     service.WebhookDeliveryPending,
+	pb.WebhookDeliveryState_WEBHOOK_DELIVERY_STATE_SUCCEEDED:   service.WebhookDeliverySucceeded,
+	pb.WebhookDeliveryState_WEBHOOK_DELIVERY_STATE_DEAD:        service.WebhookDeliveryDead,
+}
+
+func webhookpb2domain(w *pb.Webhook) service.Webhook {
+	var eventTypes []service.DomainEventType
+	for _, t := range w.GetEventTypes() {
+		eventTypes = append(eventTypes, service.DomainEventType(t))
+	}
+	return service.Webhook{
+		Name:        w.GetName(),
+		URL:         w.GetUrl(),
+		EventTypes:  eventTypes,
+		Description: w.GetDescription(),
+		Disabled:    w.GetDisabled(),
+	}
+}
+
+func webhookdomain2pb(w service.Webhook) *pb.Webhook {
+	createTime, _ := ptypes.TimestampProto(w.CreateTime)
+	updateTime, _ := ptypes.TimestampProto(w.UpdateTime)
+	var eventTypes []string
+	for _, t := range w.EventTypes {
+		eventTypes = append(eventTypes, string(t))
+	}
+	return &pb.Webhook{
+		Name:        w.Name,
+		TenantId:    w.TenantID,
+		Url:         w.URL,
+		EventTypes:  eventTypes,
+		Description: w.Description,
+		Secret:      w.Secret,
+		Disabled:    w.Disabled,
+		CreateTime:  createTime,
+		UpdateTime:  updateTime,
+	}
+}
+
+func webhookdeliverydomain2pb(d service.WebhookDelivery) *pb.WebhookDelivery {
+	var state pb.WebhookDeliveryState
+	for s, domain := range webhookDeliveryStates {
+		if domain == d.State {
+			state = s
+		}
+	}
+	var attempts []*pb.WebhookAttempt
+	{
+		for _, a := range d.Attempts {
+			attemptTime, _ := ptypes.TimestampProto(a.Time)
+			attempts = append(attempts, &pb.WebhookAttempt{
+				Time:       attemptTime,
+				StatusCode: int32(a.StatusCode),
+				Error:      a.Error,
+				Duration:   ptypes.DurationProto(a.Duration),
+			})
+		}
+	}
+	var nextAttemptTime *timestamp.Timestamp
+	if d.State == service.WebhookDeliveryPending {
+		nextAttemptTime, _ = ptypes.TimestampProto(d.NextAttemptTime)
+	}
+	createTime, _ := ptypes.TimestampProto(d.CreateTime)
+	updateTime, _ := ptypes.TimestampProto(d.UpdateTime)
+	return &pb.WebhookDelivery{
+		Name:            d.Name,
+		TenantId:        d.TenantID,
+		WebhookId:       d.WebhookID,
+		EventId:         d.EventID,
+		EventType:       string(d.EventType),
+		Payload:         string(d.Payload),
+		State:           state,
+		Failures:        int32(d.Failures),
+		Attempts:        attempts,
+		NextAttemptTime: nextAttemptTime,
+		CreateTime:      createTime,
+		UpdateTime:      updateTime,
+	}
+}
@@ -0,0 +1,377 @@
+package transport
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/duration"
+
+	"workout-manager-service/pb"
+	"workout-manager-service/pkg/endpoint"
+	"workout-manager-service/pkg/service"
+)
+
+// CreateWorkout handles incoming gRPC requests to record a new workout for an
+// athlete.
+func (s *grpcServer) CreateWorkout(ctx context.Context, req *pb.CreateWorkoutRequest) (*pb.CreateWorkoutResponse, error) {
+	_, res, err := s.createWorkout.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.CreateWorkoutResponse), nil
+}
+
+func decodeCreateWorkoutRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.CreateWorkoutRequest)
+	var performedAt time.Time
+	if request.GetPerformedAt() != nil {
+		t, err := ptypes.Timestamp(request.GetPerformedAt())
+		if err != nil {
+			return nil, err
+		}
+		performedAt = t
+	}
+	var sets []service.WorkoutSet
+	{
+		for _, s := range request.GetSets() {
+			set, err := workoutsetpb2domain(s)
+			if err != nil {
+				return nil, err
+			}
+			sets = append(sets, set)
+		}
+	}
+	blocks, err := blockspb2domain(request.GetBlocks())
+	if err != nil {
+		return nil, err
+	}
+	return endpoint.CreateWorkoutRequest{
+		AthleteID:   request.GetAthleteId(),
+		Title:       request.GetTitle(),
+		PerformedAt: performedAt,
+		Sets:        sets,
+		Blocks:      blocks,
+	}, nil
+}
+
+func encodeCreateWorkoutResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.CreateWorkoutResponse)
+	return &pb.CreateWorkoutResponse{
+		Data: workoutdomain2pb(response.Data, response.Unit),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// GetWorkout handles incoming gRPC requests to retrieve one of an athlete's
+// workouts by its UUID.
+func (s *grpcServer) GetWorkout(ctx context.Context, req *pb.GetWorkoutRequest) (*pb.GetWorkoutResponse, error) {
+	_, res, err := s.getWorkout.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.GetWorkoutResponse), nil
+}
+
+func decodeGetWorkoutRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.GetWorkoutRequest)
+	return endpoint.GetWorkoutRequest{
+		AthleteID: request.GetAthleteId(),
+		Name:      request.GetName(),
+	}, nil
+}
+
+func encodeGetWorkoutResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.GetWorkoutResponse)
+	return &pb.GetWorkoutResponse{
+		Data: workoutdomain2pb(response.Data, response.Unit),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// ListWorkouts handles incoming gRPC requests to retrieve all of an athlete's
+// workouts.
+func (s *grpcServer) ListWorkouts(ctx context.Context, req *pb.ListWorkoutsRequest) (*pb.ListWorkoutsResponse, error) {
+	_, res, err := s.listWorkouts.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.ListWorkoutsResponse), nil
+}
+
+func decodeListWorkoutsRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.ListWorkoutsRequest)
+	return endpoint.ListWorkoutsRequest{AthleteID: request.GetAthleteId()}, nil
+}
+
+func encodeListWorkoutsResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.ListWorkoutsResponse)
+	var pblist []*pb.Workout
+	{
+		for _, w := range response.Data {
+			pblist = append(pblist, workoutdomain2pb(w, response.Unit))
+		}
+	}
+	return &pb.ListWorkoutsResponse{
+		Data: pblist,
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// DeleteWorkout handles incoming gRPC requests to delete one of an athlete's
+// workouts.
+func (s *grpcServer) DeleteWorkout(ctx context.Context, req *pb.DeleteWorkoutRequest) (*pb.DeleteWorkoutResponse, error) {
+	_, res, err := s.deleteWorkout.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.DeleteWorkoutResponse), nil
+}
+
+func decodeDeleteWorkoutRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.DeleteWorkoutRequest)
+	return endpoint.DeleteWorkoutRequest{
+		AthleteID: request.GetAthleteId(),
+		Name:      request.GetName(),
+	}, nil
+}
+
+func encodeDeleteWorkoutResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.DeleteWorkoutResponse)
+	return &pb.DeleteWorkoutResponse{Err: err2str(response.Failed())}, nil
+}
+
+// RateWorkoutSession handles incoming gRPC requests to rate one of an
+// athlete's sessions.
+func (s *grpcServer) RateWorkoutSession(ctx context.Context, req *pb.RateWorkoutSessionRequest) (*pb.RateWorkoutSessionResponse, error) {
+	_, res, err := s.rateWorkout.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.RateWorkoutSessionResponse), nil
+}
+
+func decodeRateWorkoutSessionRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.RateWorkoutSessionRequest)
+	var duration time.Duration
+	if request.GetDuration() != nil {
+		d, err := ptypes.Duration(request.GetDuration())
+		if err != nil {
+			return nil, err
+		}
+		duration = d
+	}
+	return endpoint.RateWorkoutSessionRequest{
+		AthleteID:  request.GetAthleteId(),
+		Name:       request.GetName(),
+		SessionRPE: request.GetSessionRpe(),
+		Duration:   duration,
+	}, nil
+}
+
+func encodeRateWorkoutSessionResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.GetWorkoutResponse)
+	return &pb.RateWorkoutSessionResponse{
+		Data: workoutdomain2pb(response.Data, response.Unit),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+// CloneWorkout handles incoming gRPC requests to plan a workout for an
+// athlete from an earlier one or a template.
+func (s *grpcServer) CloneWorkout(ctx context.Context, req *pb.CloneWorkoutRequest) (*pb.CloneWorkoutResponse, error) {
+	_, res, err := s.cloneWorkout.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*pb.CloneWorkoutResponse), nil
+}
+
+func decodeCloneWorkoutRequest(_ context.Context, req interface{}) (interface{}, error) {
+	request := req.(*pb.CloneWorkoutRequest)
+	var plannedFor time.Time
+	if request.GetPlannedFor() != nil {
+		t, err := ptypes.Timestamp(request.GetPlannedFor())
+		if err != nil {
+			return nil, err
+		}
+		plannedFor = t
+	}
+	var increment service.Load
+	if request.GetIncrement() != nil {
+		l, err := loadpb2domain(request.GetIncrement())
+		if err != nil {
+			return nil, err
+		}
+		increment = l
+	}
+	return endpoint.CloneWorkoutRequest{
+		AthleteID: request.GetAthleteId(),
+		Clone: service.WorkoutClone{
+			WorkoutID:  request.GetWorkoutId(),
+			TemplateID: request.GetTemplateId(),
+			Title:      request.GetTitle(),
+			PlannedFor: plannedFor,
+			Progression: service.CloneProgression{
+				Increment:   increment,
+				MovementIDs: request.GetMovementIds(),
+			},
+		},
+	}, nil
+}
+
+func encodeCloneWorkoutResponse(_ context.Context, res interface{}) (interface{}, error) {
+	response := res.(endpoint.CreateWorkoutResponse)
+	return &pb.CloneWorkoutResponse{
+		Data: workoutdomain2pb(response.Data, response.Unit),
+		Err:  err2str(response.Err),
+	}, nil
+}
+
+func workoutdomain2pb(w service.Workout, unit service.WeightUnit) *pb.Workout {
+	performedAt, _ := ptypes.TimestampProto(w.PerformedAt)
+	var sets []*pb.WorkoutSet
+	{
+		for _, s := range w.Sets {
+			sets = append(sets, workoutsetdomain2pb(s, unit))
+		}
+	}
+	var conditioning []*pb.Conditioning
+	{
+		for _, c := range w.Conditioning {
+			conditioning = append(conditioning, conditioningdomain2pb(c))
+		}
+	}
+	return &pb.Workout{
+		Name:         w.Name,
+		TenantId:     w.TenantID,
+		AthleteId:    w.AthleteID,
+		Title:        w.Title,
+		PerformedAt:  performedAt,
+		Sets:         sets,
+		Conditioning: conditioning,
+		SessionRpe:   w.SessionRPE,
+		Duration:     ptypes.DurationProto(w.Duration),
+		Planned:      w.Planned,
+		Blocks:       blocksdomain2pb(w.Blocks),
+	}
+}
+
+func conditioningdomain2pb(c service.Conditioning) *pb.Conditioning {
+	startTime, _ := ptypes.TimestampProto(c.StartTime)
+	laps := make([]*pb.Lap, len(c.Laps))
+	for i, l := range c.Laps {
+		lapStart, _ := ptypes.TimestampProto(l.StartTime)
+		laps[i] = &pb.Lap{
+			StartTime:    lapStart,
+			Duration:     ptypes.DurationProto(l.Duration),
+			Distance:     l.Distance,
+			AvgHeartRate: l.AvgHeartRate,
+			MaxHeartRate: l.MaxHeartRate,
+		}
+	}
+	return &pb.Conditioning{
+		MovementId:   c.MovementID,
+		Sport:        c.Sport,
+		StartTime:    startTime,
+		Duration:     ptypes.DurationProto(c.Duration),
+		Distance:     c.Distance,
+		AvgHeartRate: c.AvgHeartRate,
+		MaxHeartRate: c.MaxHeartRate,
+		Laps:         laps,
+	}
+}
+
+func workoutsetdomain2pb(s service.WorkoutSet, unit service.WeightUnit) *pb.WorkoutSet {
+	return &pb.WorkoutSet{
+		MovementId: s.MovementID,
+		Reps:       s.Reps,
+		Weight:     loaddomain2pb(s.Weight, unit),
+		Rpe:        s.RPE,
+		Block:      s.Block,
+	}
+}
+
+func workoutsetpb2domain(s *pb.WorkoutSet) (service.WorkoutSet, error) {
+	weight, err := loadpb2domain(s.GetWeight())
+	if err != nil {
+		return service.WorkoutSet{}, err
+	}
+	return service.WorkoutSet{
+		MovementID: s.GetMovementId(),
+		Reps:       s.GetReps(),
+		Weight:     weight,
+		RPE:        s.GetRpe(),
+		Block:      s.GetBlock(),
+	}, nil
+}
+
+var blockTypes = map[pb.BlockType]service.BlockType{
+	pb.BlockType_BLOCK_TYPE_UNSPECIFIED: "",
+	pb.BlockType_BLOCK_TYPE_STRAIGHT:    service.StraightSets,
+	pb.BlockType_BLOCK_TYPE_SUPERSET:    service.Superset,
+	pb.BlockType_BLOCK_TYPE_GIANT_SET:   service.GiantSet,
+	pb.BlockType_BLOCK_TYPE_CIRCUIT:     service.Circuit,
+	pb.BlockType_BLOCK_TYPE_EMOM:        service.EMOM,
+	pb.BlockType_BLOCK_TYPE_AMRAP:       service.AMRAP,
+	pb.BlockType_BLOCK_TYPE_FOR_TIME:    service.ForTime,
+}
+
+func blockspb2domain(pbblocks []*pb.WorkoutBlock) ([]service.WorkoutBlock, error) {
+	var blocks []service.WorkoutBlock
+	for _, b := range pbblocks {
+		block := service.WorkoutBlock{
+			Label:  b.GetLabel(),
+			Type:   blockTypes[b.GetType()],
+			Rounds: b.GetRounds(),
+			Score: service.BlockScore{
+				Rounds: b.GetScore().GetRounds(),
+				Reps:   b.GetScore().GetReps(),
+			},
+		}
+		for _, d := range []struct {
+			pb     *duration.Duration
+			domain *time.Duration
+		}{
+			{b.GetInterval(), &block.Interval},
+			{b.GetTimeCap(), &block.TimeCap},
+			{b.GetScore().GetTime(), &block.Score.Time},
+		} {
+			if d.pb == nil {
+				continue
+			}
+			v, err := ptypes.Duration(d.pb)
+			if err != nil {
+				return nil, err
+			}
+			*d.domain = v
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+func blocksdomain2pb(blocks []service.WorkoutBlock) []*pb.WorkoutBlock {
+	var pbblocks []*pb.WorkoutBlock
+	for _, b := range blocks {
+		var t pb.BlockType
+		for pbtype, domain := range blockTypes {
+			if domain == b.Type {
+				t = pbtype
+			}
+		}
+		pbblocks = append(pbblocks, &pb.WorkoutBlock{
+			Label:    b.Label,
+			Type:     t,
+			Rounds:   b.Rounds,
+			Interval: ptypes.DurationProto(b.Interval),
+			TimeCap:  ptypes.DurationProto(b.TimeCap),
+			Score: &pb.BlockScore{
+				Rounds: b.Score.Rounds,
+				Reps:   b.Score.Reps,
+				Time:   ptypes.DurationProto(b.Score.Time),
+			},
+		})
+	}
+	return pbblocks
+}
@@ -0,0 +1,159 @@
+package transport
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+
+	"workout-manager-service/pb"
+	"workout-manager-service/pkg/activity"
+	"workout-manager-service/pkg/endpoint"
+	"workout-manager-service/pkg/importer"
+	"workout-manager-service/pkg/service"
+)
+
+// ImportWorkoutHistory handles incoming gRPC streams that upload another
+// app's workout history export. The whole export is read before it is
+// imported.
+func (s *grpcServer) ImportWorkoutHistory(stream pb.WorkoutManager_ImportWorkoutHistoryServer) error {
+	ctx := streamContext(stream.Context(), s.before)
+	var (
+		options *pb.HistoryImportOptions
+		data    []byte
+	)
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if options == nil {
+			options = msg.GetOptions()
+			if options == nil {
+				return encodeError(errors.Wrap(service.ErrInvalidArgument, "the first message must carry import options"))
+			}
+		}
+		if len(data)+len(msg.GetChunk()) > service.MaxImportSize {
+			return encodeError(errors.Wrapf(service.ErrInvalidArgument, "imports are limited to %d bytes", service.MaxImportSize))
+		}
+		data = append(data, msg.GetChunk()...)
+	}
+	if options == nil {
+		return encodeError(errors.Wrap(service.ErrInvalidArgument, "import is empty"))
+	}
+
+	res, err := s.importHistory(ctx, endpoint.ImportWorkoutHistoryRequest{
+		AthleteID:      options.GetAthleteId(),
+		Source:         historySources[options.GetSource()],
+		Unit:           importer.WeightUnit(options.GetUnit()),
+		TimeZone:       options.GetTimeZone(),
+		Mappings:       options.GetMappings(),
+		SkipUnresolved: options.GetSkipUnresolved(),
+		DryRun:         options.GetDryRun(),
+		Data:           data,
+	})
+	if err != nil {
+		return encodeError(err)
+	}
+	response := res.(endpoint.ImportWorkoutHistoryResponse)
+	return stream.SendAndClose(&pb.ImportWorkoutHistoryResponse{
+		Report: historyreportdomain2pb(response.Data),
+		Err:    err2str(response.Err),
+	})
+}
+
+// UploadActivity handles incoming gRPC streams that upload an activity file
+// recorded by a watch or ergometer. The whole file is read before it is
+// parsed.
+func (s *grpcServer) UploadActivity(stream pb.WorkoutManager_UploadActivityServer) error {
+	ctx := streamContext(stream.Context(), s.before)
+	var (
+		options *pb.UploadActivityOptions
+		data    []byte
+	)
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if options == nil {
+			options = msg.GetOptions()
+			if options == nil {
+				return encodeError(errors.Wrap(service.ErrInvalidArgument, "the first message must carry upload options"))
+			}
+		}
+		if len(data)+len(msg.GetChunk()) > activity.MaxFileSize {
+			return encodeError(errors.Wrapf(service.ErrInvalidArgument, "activity files are limited to %d bytes", activity.MaxFileSize))
+		}
+		data = append(data, msg.GetChunk()...)
+	}
+	if options == nil {
+		return encodeError(errors.Wrap(service.ErrInvalidArgument, "upload is empty"))
+	}
+
+	res, err := s.uploadActivity(ctx, endpoint.UploadActivityRequest{
+		AthleteID:  options.GetAthleteId(),
+		Format:     activityFormats[options.GetFormat()],
+		MovementID: options.GetMovementId(),
+		Title:      options.GetTitle(),
+		Data:       data,
+	})
+	if err != nil {
+		return encodeError(err)
+	}
+	response := res.(endpoint.UploadActivityResponse)
+	// A repeated upload is raised as an ALREADY_EXISTS status so that syncing
+	// clients can tell it apart from a file that failed to parse.
+	if errors.Cause(response.Err) == service.ErrAlreadyExists {
+		return encodeError(response.Err)
+	}
+	return stream.SendAndClose(&pb.UploadActivityResponse{
+		Data: workoutdomain2pb(response.Data, response.Unit),
+		Err:  err2str(response.Err),
+	})
+}
+
+var activityFormats = map[pb.ActivityFormat]activity.Format{
+	pb.ActivityFormat_ACTIVITY_FORMAT_FIT: activity.FIT,
+	pb.ActivityFormat_ACTIVITY_FORMAT_TCX: activity.TCX,
+}
+
+var historySources = map[pb.HistorySource]importer.Source{
+	pb.HistorySource_HISTORY_SOURCE_STRONG:   importer.Strong,
+	pb.HistorySource_HISTORY_SOURCE_HEVY:     importer.Hevy,
+	pb.HistorySource_HISTORY_SOURCE_FITNOTES: importer.FitNotes,
+}
+
+func historyreportdomain2pb(r service.HistoryReport) *pb.HistoryReport {
+	unresolved := make([]*pb.UnresolvedExercise, len(r.Unresolved))
+	for i, u := range r.Unresolved {
+		suggestions := make([]*pb.ExerciseSuggestion, len(u.Suggestions))
+		for j, m := range u.Suggestions {
+			suggestions[j] = &pb.ExerciseSuggestion{
+				MovementId:   m.Movement.Name,
+				MovementName: m.Movement.MovementName,
+				Score:        m.Score,
+			}
+		}
+		unresolved[i] = &pb.UnresolvedExercise{
+			Name:        u.Name,
+			Sets:        int32(u.Sets),
+			Suggestions: suggestions,
+		}
+	}
+	return &pb.HistoryReport{
+		DryRun:      r.DryRun,
+		Committed:   r.Committed,
+		Workouts:    int32(r.Workouts),
+		Sets:        int32(r.Sets),
+		Duplicates:  int32(r.Duplicates),
+		IgnoredRows: int32(r.IgnoredRows),
+		SkippedSets: int32(r.SkippedSets),
+		Unresolved:  unresolved,
+	}
+}